@@ -44,6 +44,9 @@ const (
 	// execution role
 	awslogsAuthExecutionRole = "ExecutionRole"
 
+	// defaultStopSignal is the signal docker sends to stop a container that does not set a stop signal
+	defaultStopSignal = "SIGTERM"
+
 	// DockerHealthCheckType is the type of container health check provided by docker
 	DockerHealthCheckType = "docker"
 
@@ -87,6 +90,14 @@ const (
 
 	// neuronVisibleDevicesEnvVar is the env which indicates that the container wants to use inferentia devices.
 	neuronVisibleDevicesEnvVar = "AWS_NEURON_VISIBLE_DEVICES"
+
+	// fdLeakTrendSamples is the number of open file descriptor counts of a container that have to increase
+	// steadily for the container to be suspected of leaking file descriptors
+	fdLeakTrendSamples = 5
+
+	// fdLeakUsageRatio is the ratio of its nofile limit a container suspected of leaking file descriptors has
+	// to reach before the leak is reported
+	fdLeakUsageRatio = 0.8
 )
 
 var (
@@ -119,6 +130,33 @@ type HealthStatus struct {
 	Output string `json:"output,omitempty"`
 }
 
+// FDUsage is the number of file descriptors opened by the main process of a container
+type FDUsage struct {
+	// OpenFDs is the number of open file descriptors
+	OpenFDs int
+	// Limit is the nofile soft limit of the process, it is zero if the number of open files is unlimited
+	Limit uint64
+	// LeakSuspected is true if the number of open file descriptors has been steadily increasing toward the limit
+	LeakSuspected bool
+	// CheckedAt is the time of the measurement
+	CheckedAt time.Time
+}
+
+// isFDLeakTrend returns true if the open file descriptor counts, oldest first, never decreased over the last
+// samples, grew overall, and the last count is close to the limit
+func isFDLeakTrend(openFDCounts []int, limit uint64) bool {
+	if limit == 0 || len(openFDCounts) < fdLeakTrendSamples {
+		return false
+	}
+	for i := 1; i < len(openFDCounts); i++ {
+		if openFDCounts[i] < openFDCounts[i-1] {
+			return false
+		}
+	}
+	last := openFDCounts[len(openFDCounts)-1]
+	return last > openFDCounts[0] && float64(last) >= fdLeakUsageRatio*float64(limit)
+}
+
 type ManagedAgentState struct {
 	// ID of this managed agent state
 	ID string `json:"id,omitempty"`
@@ -314,6 +352,52 @@ type Container struct {
 	// pause container
 	ContainerTornDownUnsafe bool `json:"containerTornDown"`
 
+	// ImagePullStartedAtUnsafe is the timestamp when the agent started pulling the image of this container
+	ImagePullStartedAtUnsafe time.Time `json:"imagePullStartedAt,omitempty"`
+	// ImagePullStoppedAtUnsafe is the timestamp when the agent finished pulling the image of this container
+	ImagePullStoppedAtUnsafe time.Time `json:"imagePullStoppedAt,omitempty"`
+	// ImagePullCachedUnsafe is set to true when the image was already present on the host and no pull
+	// was performed for this container
+	ImagePullCachedUnsafe bool `json:"imagePullCached,omitempty"`
+
+	// KilledAfterTimeoutUnsafe is set to true when the agent had to send SIGKILL to the container because
+	// it was still running after its stop signal and stop timeout
+	KilledAfterTimeoutUnsafe bool `json:"killedAfterTimeout,omitempty"`
+
+	// KnownStopSignalUnsafe is the signal docker sends to stop the container as reported by docker, which
+	// includes the STOPSIGNAL instruction of its image
+	KnownStopSignalUnsafe string `json:"knownStopSignal,omitempty"`
+
+	// StartupCPUBoostedUnsafe is set to true while the CPU shares of the container are boosted until it
+	// becomes healthy
+	StartupCPUBoostedUnsafe bool `json:"startupCPUBoosted,omitempty"`
+
+	// RestartCountUnsafe is the number of times the agent has restarted this container
+	RestartCountUnsafe int `json:"restartCount,omitempty"`
+	// RestartCountsByReasonUnsafe is the number of times the agent has restarted this container for each
+	// reason, as the restart attempts of every reason are bounded separately
+	RestartCountsByReasonUnsafe map[RestartReason]int `json:"restartCountsByReason,omitempty"`
+	// restarting is set while the agent is restarting the container
+	restarting bool
+	// lastRestartedAt is the timestamp when the agent last started the container again after a restart
+	lastRestartedAt time.Time
+
+	// healthCheckFirstProbePending is set while the agent runs the first health check probe of the
+	// container with an extended timeout
+	healthCheckFirstProbePending bool
+	// deferredHealth is the unhealthy status reported by docker while the first probe was pending
+	deferredHealth *HealthStatus
+
+	// clockDrift is the difference between the clock of the container and the host clock measured at
+	// clockDriftCheckedAt. It is positive when the container clock is ahead.
+	clockDrift          time.Duration
+	clockDriftCheckedAt time.Time
+
+	// openFDCounts are the last numbers of file descriptors opened by the main process of the container, oldest
+	// first, and fdUsage is the file descriptor usage last measured
+	openFDCounts []int
+	fdUsage      *FDUsage
+
 	createdAt  time.Time
 	startedAt  time.Time
 	finishedAt time.Time
@@ -662,6 +746,102 @@ func (c *Container) GetFinishedAt() time.Time {
 	return c.finishedAt
 }
 
+// SetImagePullStartedAt sets the timestamp when the image pull of the container started
+func (c *Container) SetImagePullStartedAt(pullStartedAt time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.ImagePullStartedAtUnsafe = pullStartedAt
+}
+
+// SetImagePullStoppedAt sets the timestamp when the image pull of the container finished
+func (c *Container) SetImagePullStoppedAt(pullStoppedAt time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.ImagePullStoppedAtUnsafe = pullStoppedAt
+}
+
+// SetImagePullCached records whether the container used an image already present on the host
+func (c *Container) SetImagePullCached(cached bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.ImagePullCachedUnsafe = cached
+}
+
+// IsImagePullCached returns true if the container used an image already present on the host
+func (c *Container) IsImagePullCached() bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.ImagePullCachedUnsafe
+}
+
+// GetImagePullDuration returns the time spent pulling the image of the container. The second
+// return value is false if the pull has not completed yet.
+func (c *Container) GetImagePullDuration() (time.Duration, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.ImagePullCachedUnsafe {
+		return 0, true
+	}
+	if c.ImagePullStartedAtUnsafe.IsZero() || c.ImagePullStoppedAtUnsafe.IsZero() {
+		return 0, false
+	}
+	return c.ImagePullStoppedAtUnsafe.Sub(c.ImagePullStartedAtUnsafe), true
+}
+
+// SetClockDrift records the difference between the clock of the container and the host clock
+func (c *Container) SetClockDrift(drift time.Duration, checkedAt time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.clockDrift = drift
+	c.clockDriftCheckedAt = checkedAt
+}
+
+// GetClockDrift returns the last measured difference between the clock of the container and the host
+// clock along with the time of the measurement. The third return value is false if it was never measured.
+func (c *Container) GetClockDrift() (time.Duration, time.Time, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.clockDrift, c.clockDriftCheckedAt, !c.clockDriftCheckedAt.IsZero()
+}
+
+// RecordFDUsage records the number of file descriptors opened by the main process of the container and its
+// nofile limit. The usage is flagged as a suspected leak when the last counts steadily increased toward the limit.
+func (c *Container) RecordFDUsage(openFDs int, limit uint64, checkedAt time.Time) FDUsage {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.openFDCounts = append(c.openFDCounts, openFDs)
+	if len(c.openFDCounts) > fdLeakTrendSamples {
+		c.openFDCounts = c.openFDCounts[len(c.openFDCounts)-fdLeakTrendSamples:]
+	}
+	c.fdUsage = &FDUsage{
+		OpenFDs:       openFDs,
+		Limit:         limit,
+		LeakSuspected: isFDLeakTrend(c.openFDCounts, limit),
+		CheckedAt:     checkedAt,
+	}
+	return *c.fdUsage
+}
+
+// GetFDUsage returns the file descriptor usage of the container last measured. The second return value is
+// false if it was never measured.
+func (c *Container) GetFDUsage() (FDUsage, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.fdUsage == nil {
+		return FDUsage{}, false
+	}
+	return *c.fdUsage, true
+}
+
 // SetLabels sets the labels for a container
 func (c *Container) SetLabels(labels map[string]string) {
 	c.lock.Lock()
@@ -792,6 +972,16 @@ func (c *Container) SetHealthStatus(health HealthStatus) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
+	// Docker probes are bound by the probe timeout, so a failure reported while the extended first
+	// probe is still running is held until the first probe completes
+	if c.healthCheckFirstProbePending && health.Status == apicontainerstatus.ContainerUnhealthy {
+		c.deferredHealth = &health
+		return
+	}
+	c.setHealthStatusUnsafe(health)
+}
+
+func (c *Container) setHealthStatusUnsafe(health HealthStatus) {
 	if c.Health.Status == health.Status {
 		return
 	}
@@ -1097,6 +1287,32 @@ func (c *Container) GetStopTimeout() time.Duration {
 	return time.Duration(c.StopTimeout) * time.Second
 }
 
+// GetStopSignal returns the signal docker sends to stop the container. The stop signal of the task's docker
+// config takes precedence over the one reported by docker, which may come from the STOPSIGNAL instruction of
+// the image. It defaults to SIGTERM.
+func (c *Container) GetStopSignal() string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.DockerConfig.Config != nil {
+		config := &dockercontainer.Config{}
+		if err := json.Unmarshal([]byte(*c.DockerConfig.Config), config); err == nil && config.StopSignal != "" {
+			return config.StopSignal
+		}
+	}
+	if c.KnownStopSignalUnsafe != "" {
+		return c.KnownStopSignalUnsafe
+	}
+	return defaultStopSignal
+}
+
+// SetKnownStopSignal records the signal docker sends to stop the container as reported by docker
+func (c *Container) SetKnownStopSignal(signal string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.KnownStopSignalUnsafe = signal
+}
+
 func (c *Container) GetDependsOn() []DependsOn {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
@@ -1163,6 +1379,28 @@ func (c *Container) GetLogDriver() string {
 	return hostConfig.LogConfig.Type
 }
 
+// GetDockerLabels gets the docker labels passed into the task definition.
+func (c *Container) GetDockerLabels() map[string]string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.DockerConfig.Config == nil {
+		return map[string]string{}
+	}
+
+	config := &dockercontainer.Config{}
+	err := json.Unmarshal([]byte(*c.DockerConfig.Config), config)
+	if err != nil {
+		seelog.Warnf("Encountered error when trying to get docker labels for container %s: %v", c.RuntimeID, err)
+		return map[string]string{}
+	}
+	if config.Labels == nil {
+		return map[string]string{}
+	}
+
+	return config.Labels
+}
+
 // GetLogOptions gets the log 'options' map passed into the task definition.
 // see https://docs.aws.amazon.com/AmazonECS/latest/APIReference/API_LogConfiguration.html
 func (c *Container) GetLogOptions() map[string]string {
@@ -1203,6 +1441,26 @@ func (c *Container) GetNetworkModeFromHostConfig() string {
 	return hostConfig.NetworkMode.NetworkName()
 }
 
+// GetMemoryReservationFromHostConfig returns the memory reservation in bytes set in the host config of the
+// container, or 0 if there is none.
+func (c *Container) GetMemoryReservationFromHostConfig() int64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.DockerConfig.HostConfig == nil {
+		return 0
+	}
+
+	hostConfig := &dockercontainer.HostConfig{}
+	err := json.Unmarshal([]byte(*c.DockerConfig.HostConfig), hostConfig)
+	if err != nil {
+		seelog.Warnf("Encountered error when trying to get memory reservation for container %s: %v", c.RuntimeID, err)
+		return 0
+	}
+
+	return hostConfig.MemoryReservation
+}
+
 // GetHostConfig returns the container's host config.
 func (c *Container) GetHostConfig() *string {
 	c.lock.RLock()
@@ -1400,3 +1658,127 @@ func (c *Container) IsContainerTornDown() bool {
 	defer c.lock.RUnlock()
 	return c.ContainerTornDownUnsafe
 }
+
+// GetRestartCount returns the number of times the agent has restarted the container
+func (c *Container) GetRestartCount() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.RestartCountUnsafe
+}
+
+// GetRestartCountByReason returns the number of times the agent has restarted the container for the reason
+func (c *Container) GetRestartCountByReason(reason RestartReason) int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.RestartCountsByReasonUnsafe[reason]
+}
+
+// IncrementRestartCount increments the number of times the agent has restarted the container for the
+// reason and returns the updated count for the reason
+func (c *Container) IncrementRestartCount(reason RestartReason) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.RestartCountsByReasonUnsafe == nil {
+		c.RestartCountsByReasonUnsafe = make(map[RestartReason]int)
+	}
+	c.RestartCountUnsafe++
+	c.RestartCountsByReasonUnsafe[reason]++
+	return c.RestartCountsByReasonUnsafe[reason]
+}
+
+// SetRestarting sets whether the agent is currently restarting the container
+func (c *Container) SetRestarting(restarting bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.restarting = restarting
+}
+
+// IsRestarting returns true if the agent is currently restarting the container
+func (c *Container) IsRestarting() bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.restarting
+}
+
+// SetLastRestartedAt sets the timestamp when the agent last started the container again after a restart
+func (c *Container) SetLastRestartedAt(restartedAt time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.lastRestartedAt = restartedAt
+}
+
+// GetLastRestartedAt returns the timestamp when the agent last started the container again after a restart
+func (c *Container) GetLastRestartedAt() time.Time {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.lastRestartedAt
+}
+
+// SetHealthCheckFirstProbePending marks the first health check probe of the container as running.
+// Unhealthy statuses reported until the probe completes are deferred.
+func (c *Container) SetHealthCheckFirstProbePending() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.healthCheckFirstProbePending = true
+	c.deferredHealth = nil
+}
+
+// IsHealthCheckFirstProbePending returns true while the first health check probe of the container is running
+func (c *Container) IsHealthCheckFirstProbePending() bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.healthCheckFirstProbePending
+}
+
+// CompleteHealthCheckFirstProbe records the completion of the first health check probe. The health
+// status is set to the result of the probe if it succeeded, otherwise any unhealthy status deferred
+// while the probe was running is applied.
+func (c *Container) CompleteHealthCheckFirstProbe(result *HealthStatus) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.healthCheckFirstProbePending = false
+	if result != nil {
+		c.setHealthStatusUnsafe(*result)
+	} else if c.deferredHealth != nil {
+		c.setHealthStatusUnsafe(*c.deferredHealth)
+	}
+	c.deferredHealth = nil
+}
+
+// SetStartupCPUBoosted records that the CPU shares of the container are boosted until it becomes healthy
+func (c *Container) SetStartupCPUBoosted() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.StartupCPUBoostedUnsafe = true
+}
+
+// IsStartupCPUBoosted returns true while the CPU shares of the container are boosted
+func (c *Container) IsStartupCPUBoosted() bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.StartupCPUBoostedUnsafe
+}
+
+// CompleteStartupCPUBoost clears the startup CPU boost of the container. It returns true if the
+// container was boosted, in which case the caller reverts its CPU shares.
+func (c *Container) CompleteStartupCPUBoost() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	boosted := c.StartupCPUBoostedUnsafe
+	c.StartupCPUBoostedUnsafe = false
+	return boosted
+}
+
+// SetKilledAfterTimeout records that the container had to be killed after its stop timeout
+func (c *Container) SetKilledAfterTimeout(killed bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.KilledAfterTimeoutUnsafe = killed
+}
+
+// IsKilledAfterTimeout returns true if the container had to be killed after its stop timeout
+func (c *Container) IsKilledAfterTimeout() bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.KilledAfterTimeoutUnsafe
+}
@@ -0,0 +1,367 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package container
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cihub/seelog"
+	dockercontainer "github.com/docker/docker/api/types/container"
+)
+
+// Container level agent settings are specified as docker labels of the container in the task
+// definition.
+const (
+	// agentLabelPrefix is the prefix of the docker labels that are interpreted by the agent
+	agentLabelPrefix = "com.amazonaws.ecs."
+
+	// PreRemoveCommandLabel specifies a command to exec in the container when the agent stops it, before
+	// it is stopped and later removed, as a JSON array such as ["/bin/sh", "-c", "cp -r /data /backup"]
+	PreRemoveCommandLabel = agentLabelPrefix + "pre-remove-command"
+	// PreRemoveTimeoutLabel specifies how long the agent waits for the pre-remove command to complete,
+	// as a duration string such as "30s"
+	PreRemoveTimeoutLabel = agentLabelPrefix + "pre-remove-timeout"
+	// DefaultPreRemoveTimeout is the time the agent waits for the pre-remove command by default
+	DefaultPreRemoveTimeout = 30 * time.Second
+	// MaxPreRemoveTimeout bounds the time the agent waits for the pre-remove command
+	MaxPreRemoveTimeout = 5 * time.Minute
+
+	// DependencyTimeoutRestartAttemptsLabel specifies how many times the agent restarts the container when
+	// another container of the task times out waiting for it as a container ordering dependency, before
+	// failing the dependent container. The container is not restarted on such timeouts when it is not set.
+	DependencyTimeoutRestartAttemptsLabel = agentLabelPrefix + "dependency-timeout-restart-attempts"
+	// MaxDependencyTimeoutRestartAttempts bounds the number of restarts of a timed out dependency
+	MaxDependencyTimeoutRestartAttempts = 3
+
+	// HealthCheckFirstProbeTimeoutLabel specifies the timeout of the first health check probe run after
+	// the start period, as a duration string such as "2m". Subsequent probes use the probe timeout of
+	// the health check.
+	HealthCheckFirstProbeTimeoutLabel = agentLabelPrefix + "health-check-first-probe-timeout"
+	// MaxHealthCheckFirstProbeTimeout bounds the timeout of the first health check probe
+	MaxHealthCheckFirstProbeTimeout = 10 * time.Minute
+	// defaultHealthCheckProbeTimeout is the probe timeout docker uses when the health check does not set one
+	defaultHealthCheckProbeTimeout = 30 * time.Second
+
+	// RestartExitCodesLabel specifies the exit codes upon which the agent restarts the container, as a
+	// comma separated list such as "75,76". The container is not restarted on any other exit code.
+	RestartExitCodesLabel = agentLabelPrefix + "restart-exit-codes"
+	// RestartPolicyLabel specifies when the agent restarts the container after it exits on its own. The only
+	// policy is RestartPolicyOnFailure. Essential containers are not restarted by the policy, as their exit
+	// stops the task; they can still be restarted upon their restart exit codes.
+	RestartPolicyLabel = agentLabelPrefix + "restart-policy"
+	// RestartPolicyOnFailure restarts the container whenever it exits with a non-zero exit code
+	RestartPolicyOnFailure = "on-failure"
+	// RestartMaxAttemptsLabel specifies how many times the agent restarts the container upon one of its
+	// restart exit codes, and separately how many times it restarts it as per its restart policy
+	RestartMaxAttemptsLabel = agentLabelPrefix + "restart-max-attempts"
+	// DefaultRestartMaxAttempts is the number of times the container is restarted by default
+	DefaultRestartMaxAttempts = 3
+	// MaxRestartMaxAttempts bounds the number of times the container is restarted
+	MaxRestartMaxAttempts = 10
+	// RestartBackoffLabel specifies how long the agent waits before restarting the container, as a duration
+	// such as "5s". The wait doubles with every restart attempt. The container is restarted right away
+	// when it is not set.
+	RestartBackoffLabel = agentLabelPrefix + "restart-backoff"
+	// MaxRestartBackoff bounds the wait before restarting the container
+	MaxRestartBackoff = 5 * time.Minute
+	// ForcePullLabel specifies whether the agent pulls the image of the container even if ECS_IMAGE_PULL_BEHAVIOR
+	// allows using the cached image, as a boolean such as "true". This picks up mutable tags such as "latest"
+	// that were updated in the registry.
+	ForcePullLabel = agentLabelPrefix + "force-pull"
+	// ReadonlyRootFilesystemOptOutLabel specifies whether the container opts out of the read only root filesystem
+	// enforced by ECS_FORCE_READONLY_ROOT_FILESYSTEM, as a boolean such as "true"
+	ReadonlyRootFilesystemOptOutLabel = agentLabelPrefix + "readonly-root-filesystem-opt-out"
+	// HostEnvironmentFileLabel specifies the absolute path of an environment file on the host whose variables the
+	// agent adds to the environment of the container when creating it, such as "/etc/ecs/env/app.env". The file
+	// must be within one of the directories of ECS_HOST_ENVIRONMENT_FILE_DIRS.
+	HostEnvironmentFileLabel = agentLabelPrefix + "host-environment-file"
+	// ManagedScratchLabel specifies the absolute path in the container at which the scratch directory the agent
+	// manages for the task is bind mounted, such as "/scratch". The scratch directory is created under
+	// ECS_MANAGED_SCRATCH_DIR.
+	ManagedScratchLabel = agentLabelPrefix + "managed-scratch"
+	// maxExitCode is the largest exit code a container process can exit with
+	maxExitCode = 255
+)
+
+// RestartReason is why the agent restarts a container. The restart attempts of each reason are counted
+// separately, so that one way of restarting the container does not use up the attempts of another.
+type RestartReason string
+
+const (
+	// RestartReasonExitCode restarts the container upon one of its restart exit codes
+	RestartReasonExitCode RestartReason = "ExitCode"
+	// RestartReasonRestartPolicy restarts the container as per its restart policy
+	RestartReasonRestartPolicy RestartReason = "RestartPolicy"
+	// RestartReasonDependencyTimeout restarts the container when it times out as a container ordering dependency
+	RestartReasonDependencyTimeout RestartReason = "DependencyTimeout"
+)
+
+// GetPreRemoveCommand returns the command to exec in the container before it is stopped, along with
+// the time to wait for it to complete. False is returned if no valid command is configured.
+func (c *Container) GetPreRemoveCommand() ([]string, time.Duration, bool) {
+	labels := c.GetDockerLabels()
+	value, ok := labels[PreRemoveCommandLabel]
+	if !ok {
+		return nil, 0, false
+	}
+	var cmd []string
+	if err := json.Unmarshal([]byte(value), &cmd); err != nil || len(cmd) == 0 {
+		seelog.Warnf("Container [%s]: ignoring invalid value %q for docker label %s, expected a JSON array",
+			c.Name, value, PreRemoveCommandLabel)
+		return nil, 0, false
+	}
+
+	timeout := DefaultPreRemoveTimeout
+	if timeoutValue, ok := labels[PreRemoveTimeoutLabel]; ok {
+		parsed, err := time.ParseDuration(timeoutValue)
+		switch {
+		case err != nil || parsed <= 0:
+			seelog.Warnf("Container [%s]: ignoring invalid value %q for docker label %s, using %s",
+				c.Name, timeoutValue, PreRemoveTimeoutLabel, timeout)
+		case parsed > MaxPreRemoveTimeout:
+			seelog.Warnf("Container [%s]: value %s for docker label %s exceeds the maximum, using %s",
+				c.Name, parsed, PreRemoveTimeoutLabel, MaxPreRemoveTimeout)
+			timeout = MaxPreRemoveTimeout
+		default:
+			timeout = parsed
+		}
+	}
+	return cmd, timeout, true
+}
+
+// getHealthCheckConfig returns the docker health check of the container, if one is defined
+func (c *Container) getHealthCheckConfig() *dockercontainer.HealthConfig {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.DockerConfig.Config == nil {
+		return nil
+	}
+	config := &dockercontainer.Config{}
+	if err := json.Unmarshal([]byte(*c.DockerConfig.Config), config); err != nil {
+		return nil
+	}
+	return config.Healthcheck
+}
+
+// GetHealthCheckFirstProbeTimeout returns the health check of the container along with the timeout of
+// its first probe. False is returned if the container does not set a valid first probe timeout.
+func (c *Container) GetHealthCheckFirstProbeTimeout() (*dockercontainer.HealthConfig, time.Duration, bool) {
+	value, ok := c.GetDockerLabels()[HealthCheckFirstProbeTimeoutLabel]
+	if !ok {
+		return nil, 0, false
+	}
+	healthCheck := c.getHealthCheckConfig()
+	if !c.HealthStatusShouldBeReported() || healthCheck == nil || len(healthCheck.Test) < 2 {
+		seelog.Warnf("Container [%s]: ignoring docker label %s as the container does not define a health check",
+			c.Name, HealthCheckFirstProbeTimeoutLabel)
+		return nil, 0, false
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		seelog.Warnf("Container [%s]: ignoring invalid value %q for docker label %s",
+			c.Name, value, HealthCheckFirstProbeTimeoutLabel)
+		return nil, 0, false
+	}
+	probeTimeout := healthCheck.Timeout
+	if probeTimeout == 0 {
+		probeTimeout = defaultHealthCheckProbeTimeout
+	}
+	if timeout <= probeTimeout {
+		seelog.Warnf("Container [%s]: ignoring value %s for docker label %s as it is not longer than the probe timeout %s",
+			c.Name, timeout, HealthCheckFirstProbeTimeoutLabel, probeTimeout)
+		return nil, 0, false
+	}
+	if timeout > MaxHealthCheckFirstProbeTimeout {
+		seelog.Warnf("Container [%s]: value %s for docker label %s exceeds the maximum, using %s",
+			c.Name, timeout, HealthCheckFirstProbeTimeoutLabel, MaxHealthCheckFirstProbeTimeout)
+		timeout = MaxHealthCheckFirstProbeTimeout
+	}
+	return healthCheck, timeout, true
+}
+
+// GetRestartExitCodes returns the exit codes upon which the agent restarts the container, along with the
+// number of times it does so. False is returned if the container does not set a valid set of exit codes.
+func (c *Container) GetRestartExitCodes() (map[int]struct{}, int, bool) {
+	labels := c.GetDockerLabels()
+	value, ok := labels[RestartExitCodesLabel]
+	if !ok {
+		return nil, 0, false
+	}
+	exitCodes := make(map[int]struct{})
+	for _, part := range strings.Split(value, ",") {
+		exitCode, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || exitCode < 1 || exitCode > maxExitCode {
+			seelog.Warnf("Container [%s]: ignoring invalid value %q for docker label %s, expected a comma separated list of exit codes between 1 and %d",
+				c.Name, value, RestartExitCodesLabel, maxExitCode)
+			return nil, 0, false
+		}
+		exitCodes[exitCode] = struct{}{}
+	}
+	return exitCodes, c.getRestartMaxAttempts(labels), true
+}
+
+// GetDependencyTimeoutRestartAttempts returns the number of times the container is restarted when a container
+// depending on it times out waiting for it, before the dependent container is failed
+func (c *Container) GetDependencyTimeoutRestartAttempts() int {
+	value, ok := c.GetDockerLabels()[DependencyTimeoutRestartAttemptsLabel]
+	if !ok {
+		return 0
+	}
+	attempts, err := strconv.Atoi(value)
+	switch {
+	case err != nil || attempts < 0:
+		seelog.Warnf("Container [%s]: ignoring invalid value %q for docker label %s",
+			c.Name, value, DependencyTimeoutRestartAttemptsLabel)
+		return 0
+	case attempts > MaxDependencyTimeoutRestartAttempts:
+		seelog.Warnf("Container [%s]: value %d for docker label %s exceeds the maximum, using %d",
+			c.Name, attempts, DependencyTimeoutRestartAttemptsLabel, MaxDependencyTimeoutRestartAttempts)
+		return MaxDependencyTimeoutRestartAttempts
+	}
+	return attempts
+}
+
+// getRestartMaxAttempts returns the number of times the agent restarts the container
+func (c *Container) getRestartMaxAttempts(labels map[string]string) int {
+	maxAttempts := DefaultRestartMaxAttempts
+	if attemptsValue, ok := labels[RestartMaxAttemptsLabel]; ok {
+		parsed, err := strconv.Atoi(attemptsValue)
+		switch {
+		case err != nil || parsed < 1:
+			seelog.Warnf("Container [%s]: ignoring invalid value %q for docker label %s, using %d",
+				c.Name, attemptsValue, RestartMaxAttemptsLabel, maxAttempts)
+		case parsed > MaxRestartMaxAttempts:
+			seelog.Warnf("Container [%s]: value %d for docker label %s exceeds the maximum, using %d",
+				c.Name, parsed, RestartMaxAttemptsLabel, MaxRestartMaxAttempts)
+			maxAttempts = MaxRestartMaxAttempts
+		default:
+			maxAttempts = parsed
+		}
+	}
+	return maxAttempts
+}
+
+// RestartsOnFailure returns true if the restart policy of the container restarts it whenever it exits
+// with a non-zero exit code
+func (c *Container) RestartsOnFailure() bool {
+	value, ok := c.GetDockerLabels()[RestartPolicyLabel]
+	if !ok {
+		return false
+	}
+	if value != RestartPolicyOnFailure {
+		seelog.Warnf("Container [%s]: ignoring invalid value %q for docker label %s, expected %q",
+			c.Name, value, RestartPolicyLabel, RestartPolicyOnFailure)
+		return false
+	}
+	if c.IsEssential() {
+		seelog.Warnf("Container [%s]: ignoring docker label %s as the container is essential",
+			c.Name, RestartPolicyLabel)
+		return false
+	}
+	return true
+}
+
+// ShouldRestartOnExitCode returns the reason for which the container is to be restarted by the agent after
+// exiting with the given exit code and true, which is the case if it is one of its restart exit codes and the
+// container has restart attempts left for them, or else if its restart policy restarts it on failure and the
+// container has restart attempts left for the policy.
+func (c *Container) ShouldRestartOnExitCode(exitCode int) (RestartReason, bool) {
+	if exitCodes, maxAttempts, ok := c.GetRestartExitCodes(); ok {
+		if _, ok := exitCodes[exitCode]; ok && c.GetRestartCountByReason(RestartReasonExitCode) < maxAttempts {
+			return RestartReasonExitCode, true
+		}
+	}
+	if exitCode != 0 && c.RestartsOnFailure() &&
+		c.GetRestartCountByReason(RestartReasonRestartPolicy) < c.getRestartMaxAttempts(c.GetDockerLabels()) {
+		return RestartReasonRestartPolicy, true
+	}
+	return "", false
+}
+
+// GetRestartBackoff returns how long the agent waits before the given restart attempt of the container,
+// starting at 1. The wait set by the restart backoff label doubles with every attempt, up to the maximum.
+func (c *Container) GetRestartBackoff(attempt int) time.Duration {
+	value, ok := c.GetDockerLabels()[RestartBackoffLabel]
+	if !ok {
+		return 0
+	}
+	backoff, err := time.ParseDuration(value)
+	if err != nil || backoff < 0 {
+		seelog.Warnf("Container [%s]: ignoring invalid value %q for docker label %s, expected a duration",
+			c.Name, value, RestartBackoffLabel)
+		return 0
+	}
+	for i := 1; i < attempt && backoff < MaxRestartBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > MaxRestartBackoff {
+		backoff = MaxRestartBackoff
+	}
+	return backoff
+}
+
+// ShouldForcePull returns true if the image of the container must be pulled even if it is cached
+func (c *Container) ShouldForcePull() bool {
+	value, ok := c.GetDockerLabels()[ForcePullLabel]
+	if !ok {
+		return false
+	}
+	forcePull, err := strconv.ParseBool(value)
+	if err != nil {
+		seelog.Warnf("Container [%s]: ignoring invalid value %q for docker label %s, expected a boolean",
+			c.Name, value, ForcePullLabel)
+		return false
+	}
+	return forcePull
+}
+
+// IsReadonlyRootFilesystemOptedOut returns true if the container opts out of the read only root filesystem
+// enforced by the agent
+func (c *Container) IsReadonlyRootFilesystemOptedOut() bool {
+	value, ok := c.GetDockerLabels()[ReadonlyRootFilesystemOptOutLabel]
+	if !ok {
+		return false
+	}
+	optOut, err := strconv.ParseBool(value)
+	if err != nil {
+		seelog.Warnf("Container [%s]: ignoring invalid value %q for docker label %s, expected a boolean",
+			c.Name, value, ReadonlyRootFilesystemOptOutLabel)
+		return false
+	}
+	return optOut
+}
+
+// GetHostEnvironmentFile returns the path of the environment file on the host whose variables are added to the
+// environment of the container. False is returned if the container does not set one.
+func (c *Container) GetHostEnvironmentFile() (string, bool) {
+	value, ok := c.GetDockerLabels()[HostEnvironmentFileLabel]
+	if !ok || strings.TrimSpace(value) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(value), true
+}
+
+// GetManagedScratchPath returns the path in the container at which the scratch directory of the task is mounted.
+// False is returned if the container does not request it.
+func (c *Container) GetManagedScratchPath() (string, bool) {
+	value, ok := c.GetDockerLabels()[ManagedScratchLabel]
+	if !ok || strings.TrimSpace(value) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(value), true
+}
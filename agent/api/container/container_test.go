@@ -17,6 +17,7 @@
 package container
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"testing"
@@ -26,8 +27,10 @@ import (
 	resourcestatus "github.com/aws/amazon-ecs-agent/agent/taskresource/status"
 
 	"github.com/aws/amazon-ecs-agent/agent/utils"
+	"github.com/aws/aws-sdk-go/aws"
 	dockercontainer "github.com/docker/docker/api/types/container"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type configPair struct {
@@ -705,6 +708,44 @@ func TestGetNetworkModeFromHostConfig(t *testing.T) {
 	}
 }
 
+func TestGetMemoryReservationFromHostConfig(t *testing.T) {
+	getContainer := func(hostConfig *string) *Container {
+		c := &Container{
+			Name: "c",
+		}
+		c.DockerConfig.HostConfig = hostConfig
+		return c
+	}
+
+	testCases := []struct {
+		name           string
+		container      *Container
+		expectedOutput int64
+	}{
+		{
+			name:           "memory reservation",
+			container:      getContainer(aws.String(`{"MemoryReservation":268435456}`)),
+			expectedOutput: 268435456,
+		},
+		{
+			name:           "no host config",
+			container:      getContainer(nil),
+			expectedOutput: 0,
+		},
+		{
+			name:           "invalid case",
+			container:      getContainer(aws.String("invalid")),
+			expectedOutput: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedOutput, tc.container.GetMemoryReservationFromHostConfig())
+		})
+	}
+}
+
 func TestShouldCreateWithEnvfiles(t *testing.T) {
 	cases := []struct {
 		in  Container
@@ -1080,3 +1121,648 @@ func getContainer(hostConfig string) *Container {
 	c.DockerConfig.HostConfig = &hostConfig
 	return c
 }
+
+func TestGetPreRemoveCommand(t *testing.T) {
+	testCases := []struct {
+		name            string
+		labels          map[string]string
+		expectedCmd     []string
+		expectedTimeout time.Duration
+		expectedOK      bool
+	}{
+		{
+			name:   "no label",
+			labels: map[string]string{},
+		},
+		{
+			name:            "command with default timeout",
+			labels:          map[string]string{PreRemoveCommandLabel: `["/bin/sh","-c","sync"]`},
+			expectedCmd:     []string{"/bin/sh", "-c", "sync"},
+			expectedTimeout: DefaultPreRemoveTimeout,
+			expectedOK:      true,
+		},
+		{
+			name:            "command with timeout",
+			labels:          map[string]string{PreRemoveCommandLabel: `["sync"]`, PreRemoveTimeoutLabel: "10s"},
+			expectedCmd:     []string{"sync"},
+			expectedTimeout: 10 * time.Second,
+			expectedOK:      true,
+		},
+		{
+			name:            "timeout above maximum",
+			labels:          map[string]string{PreRemoveCommandLabel: `["sync"]`, PreRemoveTimeoutLabel: "1h"},
+			expectedCmd:     []string{"sync"},
+			expectedTimeout: MaxPreRemoveTimeout,
+			expectedOK:      true,
+		},
+		{
+			name:            "invalid timeout",
+			labels:          map[string]string{PreRemoveCommandLabel: `["sync"]`, PreRemoveTimeoutLabel: "-1s"},
+			expectedCmd:     []string{"sync"},
+			expectedTimeout: DefaultPreRemoveTimeout,
+			expectedOK:      true,
+		},
+		{
+			name:   "command is not a JSON array",
+			labels: map[string]string{PreRemoveCommandLabel: "sync"},
+		},
+		{
+			name:   "empty command",
+			labels: map[string]string{PreRemoveCommandLabel: "[]"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rawConfig, err := json.Marshal(&dockercontainer.Config{Labels: tc.labels})
+			assert.NoError(t, err)
+			container := &Container{
+				Name: "c1",
+				DockerConfig: DockerConfig{
+					Config: aws.String(string(rawConfig)),
+				},
+			}
+			cmd, timeout, ok := container.GetPreRemoveCommand()
+			assert.Equal(t, tc.expectedOK, ok)
+			assert.Equal(t, tc.expectedCmd, cmd)
+			assert.Equal(t, tc.expectedTimeout, timeout)
+		})
+	}
+}
+
+func TestGetDependencyTimeoutRestartAttempts(t *testing.T) {
+	testCases := []struct {
+		name     string
+		labels   map[string]string
+		expected int
+	}{
+		{name: "no label", labels: map[string]string{"foo": "bar"}},
+		{name: "valid value", labels: map[string]string{DependencyTimeoutRestartAttemptsLabel: "2"}, expected: 2},
+		{name: "value above the maximum", labels: map[string]string{DependencyTimeoutRestartAttemptsLabel: "10"},
+			expected: MaxDependencyTimeoutRestartAttempts},
+		{name: "negative value", labels: map[string]string{DependencyTimeoutRestartAttemptsLabel: "-1"}},
+		{name: "invalid value", labels: map[string]string{DependencyTimeoutRestartAttemptsLabel: "many"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rawConfig, err := json.Marshal(&dockercontainer.Config{Labels: tc.labels})
+			assert.NoError(t, err)
+			container := &Container{
+				Name: "c1",
+				DockerConfig: DockerConfig{
+					Config: aws.String(string(rawConfig)),
+				},
+			}
+			assert.Equal(t, tc.expected, container.GetDependencyTimeoutRestartAttempts())
+		})
+	}
+}
+
+func TestGetRestartExitCodes(t *testing.T) {
+	testCases := []struct {
+		name                string
+		labels              map[string]string
+		expectedExitCodes   map[int]struct{}
+		expectedMaxAttempts int
+		expectedOK          bool
+	}{
+		{
+			name:   "no label",
+			labels: map[string]string{},
+		},
+		{
+			name:                "exit codes with default attempts",
+			labels:              map[string]string{RestartExitCodesLabel: "75, 76"},
+			expectedExitCodes:   map[int]struct{}{75: {}, 76: {}},
+			expectedMaxAttempts: DefaultRestartMaxAttempts,
+			expectedOK:          true,
+		},
+		{
+			name:                "exit codes with attempts",
+			labels:              map[string]string{RestartExitCodesLabel: "75", RestartMaxAttemptsLabel: "5"},
+			expectedExitCodes:   map[int]struct{}{75: {}},
+			expectedMaxAttempts: 5,
+			expectedOK:          true,
+		},
+		{
+			name:                "attempts above maximum",
+			labels:              map[string]string{RestartExitCodesLabel: "75", RestartMaxAttemptsLabel: "100"},
+			expectedExitCodes:   map[int]struct{}{75: {}},
+			expectedMaxAttempts: MaxRestartMaxAttempts,
+			expectedOK:          true,
+		},
+		{
+			name:                "invalid attempts",
+			labels:              map[string]string{RestartExitCodesLabel: "75", RestartMaxAttemptsLabel: "0"},
+			expectedExitCodes:   map[int]struct{}{75: {}},
+			expectedMaxAttempts: DefaultRestartMaxAttempts,
+			expectedOK:          true,
+		},
+		{
+			name:   "exit code is not a number",
+			labels: map[string]string{RestartExitCodesLabel: "75,fatal"},
+		},
+		{
+			name:   "exit code out of range",
+			labels: map[string]string{RestartExitCodesLabel: "75,256"},
+		},
+		{
+			name:   "successful exit code",
+			labels: map[string]string{RestartExitCodesLabel: "0"},
+		},
+		{
+			name:   "empty list",
+			labels: map[string]string{RestartExitCodesLabel: ""},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rawConfig, err := json.Marshal(&dockercontainer.Config{Labels: tc.labels})
+			assert.NoError(t, err)
+			container := &Container{
+				Name: "c1",
+				DockerConfig: DockerConfig{
+					Config: aws.String(string(rawConfig)),
+				},
+			}
+			exitCodes, maxAttempts, ok := container.GetRestartExitCodes()
+			assert.Equal(t, tc.expectedOK, ok)
+			assert.Equal(t, tc.expectedExitCodes, exitCodes)
+			assert.Equal(t, tc.expectedMaxAttempts, maxAttempts)
+		})
+	}
+}
+
+func TestShouldRestartOnExitCode(t *testing.T) {
+	rawConfig, err := json.Marshal(&dockercontainer.Config{Labels: map[string]string{
+		RestartExitCodesLabel:   "75",
+		RestartMaxAttemptsLabel: "1",
+	}})
+	assert.NoError(t, err)
+	container := &Container{
+		Name: "c1",
+		DockerConfig: DockerConfig{
+			Config: aws.String(string(rawConfig)),
+		},
+	}
+	reason, ok := container.ShouldRestartOnExitCode(75)
+	assert.True(t, ok)
+	assert.Equal(t, RestartReasonExitCode, reason)
+	_, ok = container.ShouldRestartOnExitCode(1)
+	assert.False(t, ok, "exit codes not in the set are fatal")
+
+	container.IncrementRestartCount(RestartReasonExitCode)
+	_, ok = container.ShouldRestartOnExitCode(75)
+	assert.False(t, ok, "no restart attempts left")
+}
+
+func TestShouldRestartOnExitCodeCountsAttemptsByReason(t *testing.T) {
+	rawConfig, err := json.Marshal(&dockercontainer.Config{Labels: map[string]string{
+		RestartExitCodesLabel:   "75",
+		RestartPolicyLabel:      RestartPolicyOnFailure,
+		RestartMaxAttemptsLabel: "1",
+	}})
+	assert.NoError(t, err)
+	container := &Container{
+		Name: "c1",
+		DockerConfig: DockerConfig{
+			Config: aws.String(string(rawConfig)),
+		},
+	}
+	// Restarts upon timing out as a dependency do not use up the attempts of the exit codes
+	container.IncrementRestartCount(RestartReasonDependencyTimeout)
+	reason, ok := container.ShouldRestartOnExitCode(75)
+	assert.True(t, ok)
+	assert.Equal(t, RestartReasonExitCode, reason)
+	assert.Equal(t, 1, container.IncrementRestartCount(reason))
+
+	// Once the exit code is out of restart attempts, the restart policy still restarts the container on failure
+	reason, ok = container.ShouldRestartOnExitCode(75)
+	assert.True(t, ok)
+	assert.Equal(t, RestartReasonRestartPolicy, reason)
+	assert.Equal(t, 1, container.IncrementRestartCount(reason))
+
+	_, ok = container.ShouldRestartOnExitCode(75)
+	assert.False(t, ok, "no restart attempts left")
+	assert.Equal(t, 3, container.GetRestartCount())
+	assert.Equal(t, 1, container.GetRestartCountByReason(RestartReasonDependencyTimeout))
+}
+
+func TestShouldRestartOnFailure(t *testing.T) {
+	testCases := []struct {
+		name            string
+		labels          map[string]string
+		essential       bool
+		restartCount    int
+		exitCode        int
+		expectedRestart bool
+	}{
+		{
+			name:            "failure",
+			labels:          map[string]string{RestartPolicyLabel: RestartPolicyOnFailure},
+			exitCode:        1,
+			expectedRestart: true,
+		},
+		{
+			name:     "successful exit",
+			labels:   map[string]string{RestartPolicyLabel: RestartPolicyOnFailure},
+			exitCode: 0,
+		},
+		{
+			name:         "no restart attempts left",
+			labels:       map[string]string{RestartPolicyLabel: RestartPolicyOnFailure, RestartMaxAttemptsLabel: "2"},
+			restartCount: 2,
+			exitCode:     1,
+		},
+		{
+			name:      "essential container",
+			labels:    map[string]string{RestartPolicyLabel: RestartPolicyOnFailure},
+			essential: true,
+			exitCode:  1,
+		},
+		{
+			name:     "unknown policy",
+			labels:   map[string]string{RestartPolicyLabel: "always"},
+			exitCode: 1,
+		},
+		{
+			name:            "restart exit code of an essential container",
+			labels:          map[string]string{RestartExitCodesLabel: "75"},
+			essential:       true,
+			exitCode:        75,
+			expectedRestart: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rawConfig, err := json.Marshal(&dockercontainer.Config{Labels: tc.labels})
+			assert.NoError(t, err)
+			container := &Container{
+				Name:      "c1",
+				Essential: tc.essential,
+				DockerConfig: DockerConfig{
+					Config: aws.String(string(rawConfig)),
+				},
+				RestartCountsByReasonUnsafe: map[RestartReason]int{RestartReasonRestartPolicy: tc.restartCount},
+			}
+			_, ok := container.ShouldRestartOnExitCode(tc.exitCode)
+			assert.Equal(t, tc.expectedRestart, ok)
+		})
+	}
+}
+
+func TestGetRestartBackoff(t *testing.T) {
+	testCases := []struct {
+		name            string
+		labels          map[string]string
+		attempt         int
+		expectedBackoff time.Duration
+	}{
+		{
+			name:    "no label",
+			labels:  map[string]string{},
+			attempt: 1,
+		},
+		{
+			name:            "first attempt",
+			labels:          map[string]string{RestartBackoffLabel: "5s"},
+			attempt:         1,
+			expectedBackoff: 5 * time.Second,
+		},
+		{
+			name:            "third attempt",
+			labels:          map[string]string{RestartBackoffLabel: "5s"},
+			attempt:         3,
+			expectedBackoff: 20 * time.Second,
+		},
+		{
+			name:            "backoff above maximum",
+			labels:          map[string]string{RestartBackoffLabel: "1m"},
+			attempt:         10,
+			expectedBackoff: MaxRestartBackoff,
+		},
+		{
+			name:    "invalid backoff",
+			labels:  map[string]string{RestartBackoffLabel: "soon"},
+			attempt: 1,
+		},
+		{
+			name:    "negative backoff",
+			labels:  map[string]string{RestartBackoffLabel: "-5s"},
+			attempt: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rawConfig, err := json.Marshal(&dockercontainer.Config{Labels: tc.labels})
+			assert.NoError(t, err)
+			container := &Container{
+				Name: "c1",
+				DockerConfig: DockerConfig{
+					Config: aws.String(string(rawConfig)),
+				},
+			}
+			assert.Equal(t, tc.expectedBackoff, container.GetRestartBackoff(tc.attempt))
+		})
+	}
+}
+
+func TestGetHealthCheckFirstProbeTimeout(t *testing.T) {
+	healthCheck := &dockercontainer.HealthConfig{
+		Test:    []string{"CMD-SHELL", "curl -f http://localhost/"},
+		Timeout: 5 * time.Second,
+	}
+	testCases := []struct {
+		name            string
+		healthCheckType string
+		healthCheck     *dockercontainer.HealthConfig
+		labels          map[string]string
+		expectedTimeout time.Duration
+		expectedOK      bool
+	}{
+		{
+			name:            "no label",
+			healthCheckType: DockerHealthCheckType,
+			healthCheck:     healthCheck,
+		},
+		{
+			name:            "valid timeout",
+			healthCheckType: DockerHealthCheckType,
+			healthCheck:     healthCheck,
+			labels:          map[string]string{HealthCheckFirstProbeTimeoutLabel: "2m"},
+			expectedTimeout: 2 * time.Minute,
+			expectedOK:      true,
+		},
+		{
+			name:            "timeout above maximum",
+			healthCheckType: DockerHealthCheckType,
+			healthCheck:     healthCheck,
+			labels:          map[string]string{HealthCheckFirstProbeTimeoutLabel: "1h"},
+			expectedTimeout: MaxHealthCheckFirstProbeTimeout,
+			expectedOK:      true,
+		},
+		{
+			name:            "timeout not longer than the probe timeout",
+			healthCheckType: DockerHealthCheckType,
+			healthCheck:     healthCheck,
+			labels:          map[string]string{HealthCheckFirstProbeTimeoutLabel: "5s"},
+		},
+		{
+			name:            "timeout not longer than the default probe timeout",
+			healthCheckType: DockerHealthCheckType,
+			healthCheck:     &dockercontainer.HealthConfig{Test: []string{"CMD", "true"}},
+			labels:          map[string]string{HealthCheckFirstProbeTimeoutLabel: "20s"},
+		},
+		{
+			name:            "invalid timeout",
+			healthCheckType: DockerHealthCheckType,
+			healthCheck:     healthCheck,
+			labels:          map[string]string{HealthCheckFirstProbeTimeoutLabel: "soon"},
+		},
+		{
+			name:            "health check disabled",
+			healthCheckType: DockerHealthCheckType,
+			healthCheck:     &dockercontainer.HealthConfig{Test: []string{"NONE"}},
+			labels:          map[string]string{HealthCheckFirstProbeTimeoutLabel: "2m"},
+		},
+		{
+			name:        "health status not reported",
+			healthCheck: healthCheck,
+			labels:      map[string]string{HealthCheckFirstProbeTimeoutLabel: "2m"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rawConfig, err := json.Marshal(&dockercontainer.Config{Labels: tc.labels, Healthcheck: tc.healthCheck})
+			assert.NoError(t, err)
+			container := &Container{
+				Name:            "c1",
+				HealthCheckType: tc.healthCheckType,
+				DockerConfig: DockerConfig{
+					Config: aws.String(string(rawConfig)),
+				},
+			}
+			config, timeout, ok := container.GetHealthCheckFirstProbeTimeout()
+			assert.Equal(t, tc.expectedOK, ok)
+			assert.Equal(t, tc.expectedTimeout, timeout)
+			if tc.expectedOK {
+				assert.Equal(t, tc.healthCheck, config)
+			}
+		})
+	}
+}
+
+func TestSetHealthStatusWhileFirstProbePending(t *testing.T) {
+	unhealthy := HealthStatus{Status: apicontainerstatus.ContainerUnhealthy, ExitCode: 1}
+
+	container := &Container{HealthCheckType: DockerHealthCheckType}
+	container.SetHealthCheckFirstProbePending()
+	assert.True(t, container.IsHealthCheckFirstProbePending())
+	container.SetHealthStatus(unhealthy)
+	assert.Equal(t, apicontainerstatus.ContainerHealthUnknown, container.GetHealthStatus().Status,
+		"Unhealthy status should be deferred while the first probe is pending")
+	container.CompleteHealthCheckFirstProbe(&HealthStatus{Status: apicontainerstatus.ContainerHealthy})
+	assert.False(t, container.IsHealthCheckFirstProbePending())
+	assert.Equal(t, apicontainerstatus.ContainerHealthy, container.GetHealthStatus().Status)
+
+	container = &Container{HealthCheckType: DockerHealthCheckType}
+	container.SetHealthCheckFirstProbePending()
+	container.SetHealthStatus(unhealthy)
+	container.CompleteHealthCheckFirstProbe(nil)
+	assert.Equal(t, apicontainerstatus.ContainerUnhealthy, container.GetHealthStatus().Status,
+		"Deferred status should be applied when the first probe fails")
+	assert.Equal(t, 1, container.GetHealthStatus().ExitCode)
+}
+
+func TestIsFDLeakTrend(t *testing.T) {
+	testCases := []struct {
+		name         string
+		openFDCounts []int
+		limit        uint64
+		expected     bool
+	}{
+		{
+			name:         "steady growth close to the limit",
+			openFDCounts: []int{700, 750, 800, 850, 900},
+			limit:        1024,
+			expected:     true,
+		},
+		{
+			name:         "steady growth far from the limit",
+			openFDCounts: []int{100, 150, 200, 250, 300},
+			limit:        1024,
+		},
+		{
+			name:         "close to the limit but the count dropped",
+			openFDCounts: []int{900, 950, 850, 900, 950},
+			limit:        1024,
+		},
+		{
+			name:         "close to the limit but flat",
+			openFDCounts: []int{900, 900, 900, 900, 900},
+			limit:        1024,
+		},
+		{
+			name:         "not enough samples",
+			openFDCounts: []int{850, 900, 950},
+			limit:        1024,
+		},
+		{
+			name:         "unlimited",
+			openFDCounts: []int{700, 750, 800, 850, 900},
+			limit:        0,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isFDLeakTrend(tc.openFDCounts, tc.limit))
+		})
+	}
+}
+
+func TestRecordFDUsage(t *testing.T) {
+	container := &Container{}
+	_, ok := container.GetFDUsage()
+	assert.False(t, ok)
+
+	checkedAt := time.Now()
+	// A burst of open files which is closed again is not a leak
+	for _, openFDs := range []int{100, 900, 200} {
+		usage := container.RecordFDUsage(openFDs, 1024, checkedAt)
+		assert.False(t, usage.LeakSuspected)
+	}
+	var usage FDUsage
+	for _, openFDs := range []int{600, 700, 800, 850, 900} {
+		usage = container.RecordFDUsage(openFDs, 1024, checkedAt)
+	}
+	assert.True(t, usage.LeakSuspected, "only the last samples should be considered")
+
+	recorded, ok := container.GetFDUsage()
+	require.True(t, ok)
+	assert.Equal(t, FDUsage{OpenFDs: 900, Limit: 1024, LeakSuspected: true, CheckedAt: checkedAt}, recorded)
+
+	// Closing files clears the suspicion
+	usage = container.RecordFDUsage(300, 1024, checkedAt)
+	assert.False(t, usage.LeakSuspected)
+}
+
+func TestShouldForcePull(t *testing.T) {
+	testCases := []struct {
+		name              string
+		labels            map[string]string
+		expectedForcePull bool
+	}{
+		{name: "no label"},
+		{name: "enabled", labels: map[string]string{ForcePullLabel: "true"}, expectedForcePull: true},
+		{name: "disabled", labels: map[string]string{ForcePullLabel: "false"}},
+		{name: "invalid", labels: map[string]string{ForcePullLabel: "always"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rawConfig, err := json.Marshal(&dockercontainer.Config{Labels: tc.labels})
+			assert.NoError(t, err)
+			container := &Container{
+				Name: "c1",
+				DockerConfig: DockerConfig{
+					Config: aws.String(string(rawConfig)),
+				},
+			}
+			assert.Equal(t, tc.expectedForcePull, container.ShouldForcePull())
+		})
+	}
+}
+
+func TestGetHostEnvironmentFile(t *testing.T) {
+	testCases := []struct {
+		name         string
+		labels       map[string]string
+		expectedPath string
+		expectedOK   bool
+	}{
+		{name: "no label"},
+		{name: "path", labels: map[string]string{HostEnvironmentFileLabel: " /etc/ecs/env/app.env "},
+			expectedPath: "/etc/ecs/env/app.env", expectedOK: true},
+		{name: "empty", labels: map[string]string{HostEnvironmentFileLabel: ""}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rawConfig, err := json.Marshal(&dockercontainer.Config{Labels: tc.labels})
+			assert.NoError(t, err)
+			container := &Container{
+				Name: "c1",
+				DockerConfig: DockerConfig{
+					Config: aws.String(string(rawConfig)),
+				},
+			}
+			path, ok := container.GetHostEnvironmentFile()
+			assert.Equal(t, tc.expectedOK, ok)
+			assert.Equal(t, tc.expectedPath, path)
+		})
+	}
+}
+
+func TestGetManagedScratchPath(t *testing.T) {
+	testCases := []struct {
+		name         string
+		labels       map[string]string
+		expectedPath string
+		expectedOK   bool
+	}{
+		{name: "no label"},
+		{name: "path", labels: map[string]string{ManagedScratchLabel: " /scratch "},
+			expectedPath: "/scratch", expectedOK: true},
+		{name: "empty", labels: map[string]string{ManagedScratchLabel: ""}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rawConfig, err := json.Marshal(&dockercontainer.Config{Labels: tc.labels})
+			assert.NoError(t, err)
+			container := &Container{
+				Name: "c1",
+				DockerConfig: DockerConfig{
+					Config: aws.String(string(rawConfig)),
+				},
+			}
+			path, ok := container.GetManagedScratchPath()
+			assert.Equal(t, tc.expectedOK, ok)
+			assert.Equal(t, tc.expectedPath, path)
+		})
+	}
+}
+
+func TestGetStopSignal(t *testing.T) {
+	testCases := []struct {
+		name            string
+		configSignal    string
+		knownStopSignal string
+		expectedSignal  string
+	}{
+		{name: "default", expectedSignal: "SIGTERM"},
+		{name: "image stop signal", knownStopSignal: "SIGQUIT", expectedSignal: "SIGQUIT"},
+		{name: "task stop signal", configSignal: "SIGINT", expectedSignal: "SIGINT"},
+		{name: "task stop signal overrides image", configSignal: "SIGINT", knownStopSignal: "SIGQUIT", expectedSignal: "SIGINT"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rawConfig, err := json.Marshal(&dockercontainer.Config{StopSignal: tc.configSignal})
+			assert.NoError(t, err)
+			container := &Container{
+				Name: "c1",
+				DockerConfig: DockerConfig{
+					Config: aws.String(string(rawConfig)),
+				},
+			}
+			container.SetKnownStopSignal(tc.knownStopSignal)
+			assert.Equal(t, tc.expectedSignal, container.GetStopSignal())
+		})
+	}
+}
@@ -27,16 +27,19 @@ const (
 	ManagedAgentRunning
 	// ManagedAgentStopped represents a managed agent that has stopped
 	ManagedAgentStopped
+	// ManagedAgentUnhealthy represents a managed agent whose process was found dead after it had started
+	ManagedAgentUnhealthy
 )
 
 // ManagedAgentStatus is an enumeration of valid states in the managed agent lifecycle
 type ManagedAgentStatus int32
 
 var managedAgentStatusMap = map[string]ManagedAgentStatus{
-	"NONE":    ManagedAgentStatusNone,
-	"CREATED": ManagedAgentCreated,
-	"RUNNING": ManagedAgentRunning,
-	"STOPPED": ManagedAgentStopped,
+	"NONE":      ManagedAgentStatusNone,
+	"CREATED":   ManagedAgentCreated,
+	"RUNNING":   ManagedAgentRunning,
+	"STOPPED":   ManagedAgentStopped,
+	"UNHEALTHY": ManagedAgentUnhealthy,
 }
 
 // String returns a human readable string representation of this object
@@ -67,6 +70,9 @@ func (mas ManagedAgentStatus) BackendStatus() string {
 		return mas.String()
 	case ManagedAgentStopped:
 		return mas.String()
+	case ManagedAgentUnhealthy:
+		// The backend has no notion of an unhealthy agent, an agent that died is reported as stopped
+		return ManagedAgentStopped.String()
 	}
 	return "PENDING"
 }
@@ -77,7 +83,8 @@ func (mas ManagedAgentStatus) BackendStatus() string {
 // For now we expect PENDING/RUNNING/STOPPED as valid states
 // we should only skip events that have ManagedAgentStatusNone
 func (mas ManagedAgentStatus) ShouldReportToBackend() bool {
-	return mas == ManagedAgentCreated || mas == ManagedAgentRunning || mas == ManagedAgentStopped
+	return mas == ManagedAgentCreated || mas == ManagedAgentRunning || mas == ManagedAgentStopped ||
+		mas == ManagedAgentUnhealthy
 }
 
 // UnmarshalJSON overrides the logic for parsing the JSON-encoded ManagedAgentStatus data
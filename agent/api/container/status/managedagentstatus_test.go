@@ -50,6 +50,9 @@ func TestShouldReportManagedAgentStatusToBackend(t *testing.T) {
 	// We should report ManagedAgentStooped (ie STOPPED)
 	managedAgentStatus = ManagedAgentStopped
 	assert.Equal(t, true, managedAgentStatus.ShouldReportToBackend())
+	// We should report ManagedAgentUnhealthy (ie STOPPED)
+	managedAgentStatus = ManagedAgentUnhealthy
+	assert.Equal(t, true, managedAgentStatus.ShouldReportToBackend())
 }
 
 func TestManagedAgentBackendStatus(t *testing.T) {
@@ -68,6 +71,10 @@ func TestManagedAgentBackendStatus(t *testing.T) {
 	// BackendStatus is "STOPPED" when managedAgent status is ManagedAgentStopped
 	managedAgentStatus = ManagedAgentStopped
 	assert.Equal(t, "STOPPED", managedAgentStatus.BackendStatus())
+
+	// BackendStatus is "STOPPED" when managedAgent status is ManagedAgentUnhealthy
+	managedAgentStatus = ManagedAgentUnhealthy
+	assert.Equal(t, "STOPPED", managedAgentStatus.BackendStatus())
 }
 
 type testManagedAgentStatus struct {
@@ -452,10 +452,14 @@ func (client *APIECSClient) buildManagedAgentStateChangePayload(change api.Manag
 	if change.Reason != "" {
 		trimmedReason = aws.String(trimString(change.Reason, ecsMaxContainerReasonLength))
 	}
+	stat := change.Status.String()
+	if change.Status == apicontainerstatus.ManagedAgentUnhealthy {
+		stat = change.Status.BackendStatus()
+	}
 	return &ecs.ManagedAgentStateChange{
 		ManagedAgentName: aws.String(change.Name),
 		ContainerName:    aws.String(change.Container.Name),
-		Status:           aws.String(change.Status.String()),
+		Status:           aws.String(stat),
 		Reason:           trimmedReason,
 	}
 }
@@ -1086,6 +1086,21 @@ func TestSubmitTaskStateChangeWithManagedAgents(t *testing.T) {
 	assert.NoError(t, err, "Unable to submit task state change with managed agents")
 }
 
+func TestBuildManagedAgentStateChangePayloadUnhealthy(t *testing.T) {
+	client := &APIECSClient{}
+	payload := client.buildManagedAgentStateChangePayload(api.ManagedAgentStateChange{
+		TaskArn:   "task_arn",
+		Name:      "test_managed_agent",
+		Container: &apicontainer.Container{Name: "test_container"},
+		Status:    apicontainerstatus.ManagedAgentUnhealthy,
+		Reason:    "test_reason",
+	})
+	require.NotNil(t, payload)
+	// The backend does not know about unhealthy agents, they are reported as stopped
+	assert.Equal(t, "STOPPED", aws.StringValue(payload.Status))
+	assert.Equal(t, "test_reason", aws.StringValue(payload.Reason))
+}
+
 // TestSubmitContainerStateChangeWhileTaskInPending tests the container state change was submitted
 // when the task is still in pending state
 func TestSubmitContainerStateChangeWhileTaskInPending(t *testing.T) {
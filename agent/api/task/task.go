@@ -17,6 +17,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"path/filepath"
 	"reflect"
 	"strconv"
@@ -32,6 +33,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/go-connections/nat"
+	"github.com/docker/go-units"
 
 	"github.com/aws/amazon-ecs-agent/agent/acs/model/ecsacs"
 	apiappmesh "github.com/aws/amazon-ecs-agent/agent/api/appmesh"
@@ -224,6 +226,9 @@ type Task struct {
 	// ExecutionStoppedAtUnsafe is the timestamp when the task desired status moved to stopped,
 	// which is when the any of the essential containers stopped
 	ExecutionStoppedAtUnsafe time.Time `json:"ExecutionStoppedAt"`
+	// EssentialContainerStoppedUnsafe is the name of the essential container whose stop moved the
+	// task desired status to stopped, if any
+	EssentialContainerStoppedUnsafe string `json:"EssentialContainerStopped,omitempty"`
 
 	// SentStatusUnsafe represents the last KnownStatusUnsafe that was sent to the ECS SubmitTaskStateChange API.
 	// TODO(samuelkarp) SentStatusUnsafe needs a lock and setters/getters.
@@ -265,6 +270,10 @@ type Task struct {
 	terminalReason     string
 	terminalReasonOnce sync.Once
 
+	// dnsLatencies are the latencies of the DNS resolutions last made from within the network namespace of
+	// the task against each of its resolvers
+	dnsLatencies []DNSLatency
+
 	// PIDMode is used to determine how PID namespaces are organized between
 	// containers of the Task
 	PIDMode string `json:"PidMode,omitempty"`
@@ -1819,6 +1828,65 @@ func (task *Task) DockerHostConfig(container *apicontainer.Container, dockerCont
 	return task.dockerHostConfig(container, dockerContainerMap, apiVersion, cfg)
 }
 
+// extraHostGatewayIP is the value of an extra host that docker resolves to the IP of the host
+const extraHostGatewayIP = "host-gateway"
+
+// validateDNSAndExtraHosts checks that the DNS servers of the host config are IP addresses and that its extra
+// hosts are in the host:ip format, so that the container fails before create with the bad entry as the reason
+func validateDNSAndExtraHosts(hostConfig *dockercontainer.HostConfig) error {
+	for _, dnsServer := range hostConfig.DNS {
+		if net.ParseIP(dnsServer) == nil {
+			return errors.Errorf("invalid DNS server %q: not an IP address", dnsServer)
+		}
+	}
+	for _, extraHost := range hostConfig.ExtraHosts {
+		// IPv6 addresses contain colons, so only the first colon separates the host from the IP
+		parts := strings.SplitN(extraHost, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return errors.Errorf("invalid extra host %q: expected host:ip", extraHost)
+		}
+		if parts[1] != extraHostGatewayIP && net.ParseIP(parts[1]) == nil {
+			return errors.Errorf("invalid extra host %q: %q is not an IP address", extraHost, parts[1])
+		}
+	}
+	return nil
+}
+
+// validateUlimits checks that the ulimits of the host config have a known name and a soft limit not above their
+// hard limit, so that the container fails before create with the bad ulimit as the reason
+func validateUlimits(ulimits []*units.Ulimit) error {
+	for _, ulimit := range ulimits {
+		if ulimit == nil {
+			continue
+		}
+		if _, err := ulimit.GetRlimit(); err != nil {
+			return errors.Errorf("invalid ulimit %q: unknown ulimit name", ulimit.Name)
+		}
+		// -1 stands for unlimited
+		if ulimit.Hard != -1 && (ulimit.Soft == -1 || ulimit.Soft > ulimit.Hard) {
+			return errors.Errorf("invalid ulimit %q: soft limit %d is above hard limit %d", ulimit.Name,
+				ulimit.Soft, ulimit.Hard)
+		}
+	}
+	return nil
+}
+
+// applyDefaultUlimits adds the default ulimits whose name is not set by the host config
+func applyDefaultUlimits(hostConfig *dockercontainer.HostConfig, defaultUlimits []*units.Ulimit) {
+	setUlimits := make(map[string]bool, len(hostConfig.Ulimits))
+	for _, ulimit := range hostConfig.Ulimits {
+		if ulimit != nil {
+			setUlimits[ulimit.Name] = true
+		}
+	}
+	for _, ulimit := range defaultUlimits {
+		if !setUlimits[ulimit.Name] {
+			defaultUlimit := *ulimit
+			hostConfig.Ulimits = append(hostConfig.Ulimits, &defaultUlimit)
+		}
+	}
+}
+
 // ApplyExecutionRoleLogsAuth will check whether the task has execution role
 // credentials, and add the genereated credentials endpoint to the associated HostConfig
 func (task *Task) ApplyExecutionRoleLogsAuth(hostConfig *dockercontainer.HostConfig, credentialsManager credentials.Manager) *apierrors.HostConfigError {
@@ -1886,6 +1954,23 @@ func (task *Task) dockerHostConfig(container *apicontainer.Container, dockerCont
 		}
 	}
 
+	if err := validateDNSAndExtraHosts(hostConfig); err != nil {
+		return nil, &apierrors.HostConfigError{Msg: err.Error()}
+	}
+
+	if err := validateUlimits(hostConfig.Ulimits); err != nil {
+		return nil, &apierrors.HostConfigError{Msg: err.Error()}
+	}
+	if !container.IsInternal() {
+		applyDefaultUlimits(hostConfig, cfg.ContainerDefaultUlimits)
+	}
+
+	// The agent's own containers are not affected by the enforcement of a read only root filesystem
+	if cfg.ForceReadonlyRootFilesystem.Enabled() && !container.IsInternal() &&
+		!container.IsReadonlyRootFilesystemOptedOut() {
+		hostConfig.ReadonlyRootfs = true
+	}
+
 	if err := task.platformHostConfigOverride(hostConfig); err != nil {
 		return nil, &apierrors.HostConfigError{Msg: err.Error()}
 	}
@@ -1974,6 +2059,11 @@ func (task *Task) getDockerResources(container *apicontainer.Container, cfg *con
 	return resources
 }
 
+// GetContainerCPUShares returns the CPU shares the container is created with
+func (task *Task) GetContainerCPUShares(container *apicontainer.Container) int64 {
+	return task.dockerCPUShares(container.CPU)
+}
+
 // shouldOverrideNetworkMode returns true if the network mode of the container needs
 // to be overridden. It also returns the override string in this case. It returns
 // false otherwise
@@ -2458,6 +2548,7 @@ func (task *Task) updateTaskDesiredStatusUnsafe() {
 				field.DesiredStatus: apitaskstatus.TaskStopped.String(),
 			})
 			task.DesiredStatusUnsafe = apitaskstatus.TaskStopped
+			task.EssentialContainerStoppedUnsafe = cont.Name
 		}
 	}
 }
@@ -2732,6 +2823,15 @@ func (task *Task) GetExecutionStoppedAt() time.Time {
 	return task.ExecutionStoppedAtUnsafe
 }
 
+// GetEssentialContainerStopped returns the name of the essential container whose stop triggered
+// the task to stop. An empty string is returned if the task was stopped for another reason.
+func (task *Task) GetEssentialContainerStopped() string {
+	task.lock.RLock()
+	defer task.lock.RUnlock()
+
+	return task.EssentialContainerStoppedUnsafe
+}
+
 // String returns a human readable string representation of this object
 func (task *Task) String() string {
 	return task.stringUnsafe()
@@ -2863,6 +2963,37 @@ func (task *Task) GetTerminalReason() string {
 	return task.terminalReason
 }
 
+// DNSLatency is the latency of a DNS resolution made from within the network namespace of a task against
+// one of its resolvers
+type DNSLatency struct {
+	// Resolver is the address of the resolver
+	Resolver string
+	// Latency is the duration of the resolution, it is only set if the resolver answered
+	Latency time.Duration
+	// Error is the reason the resolver did not answer
+	Error string
+	// CheckedAt is the time of the resolution
+	CheckedAt time.Time
+}
+
+// SetDNSLatencies records the latencies of the DNS resolutions made from within the network namespace of the
+// task
+func (task *Task) SetDNSLatencies(latencies []DNSLatency) {
+	task.lock.Lock()
+	defer task.lock.Unlock()
+
+	task.dnsLatencies = latencies
+}
+
+// GetDNSLatencies returns the latencies of the DNS resolutions last made from within the network namespace
+// of the task
+func (task *Task) GetDNSLatencies() []DNSLatency {
+	task.lock.RLock()
+	defer task.lock.RUnlock()
+
+	return task.dnsLatencies
+}
+
 // PopulateASMAuthData sets docker auth credentials for a container
 func (task *Task) PopulateASMAuthData(container *apicontainer.Container) error {
 	secretID := container.RegistryAuthentication.ASMAuthData.CredentialsParameter
@@ -0,0 +1,202 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package task
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/cihub/seelog"
+)
+
+// Task level agent settings are specified as docker labels in the task definition. Since docker labels
+// are defined per container, a task level setting applies to the whole task when it is set on any of
+// the task's containers.
+const (
+	// agentLabelPrefix is the prefix of the docker labels that are interpreted by the agent
+	agentLabelPrefix = "com.amazonaws.ecs."
+
+	// TaskCleanupWaitDurationLabel overrides the time to wait after the task is stopped before its
+	// containers are cleaned up. It is a duration string such as "12h".
+	TaskCleanupWaitDurationLabel = agentLabelPrefix + "task-cleanup-wait-duration"
+
+	// OOMPolicyLabel specifies what the agent does when a non essential container of the task is
+	// killed due to memory usage. Essential containers always stop the task.
+	OOMPolicyLabel = agentLabelPrefix + "oom-policy"
+	// OOMPolicyKillContainer only stops the container that ran out of memory. This is the default.
+	OOMPolicyKillContainer = "kill-container"
+	// OOMPolicyKillTask stops the whole task when any of its containers runs out of memory
+	OOMPolicyKillTask = "kill-task"
+
+	// EssentialStopTeardownLabel specifies how the remaining containers of the task are stopped
+	// after an essential container stops
+	EssentialStopTeardownLabel = agentLabelPrefix + "essential-stop-teardown"
+	// EssentialStopTeardownOrdered stops the remaining containers in reverse dependency order, where
+	// links and volumesFrom count as dependencies in addition to dependsOn
+	EssentialStopTeardownOrdered = "ordered"
+	// EssentialStopTeardownParallel stops the remaining containers at once, only honoring dependsOn.
+	// This is the default.
+	EssentialStopTeardownParallel = "parallel"
+
+	// SpotInterruptionStopLabel specifies whether the agent stops the task when the instance receives a
+	// spot interruption notice, overriding the configured default
+	SpotInterruptionStopLabel = agentLabelPrefix + "spot-interruption-stop"
+	// SpotInterruptionStopGraceful stops the task as soon as the spot interruption notice is received
+	SpotInterruptionStopGraceful = "graceful"
+	// SpotInterruptionStopNone leaves stopping the task to the scheduler
+	SpotInterruptionStopNone = "none"
+
+	// StartupCPUBoostLabel specifies a factor by which the CPU shares of the containers of the task that
+	// have a health check are multiplied from their start until they become healthy
+	StartupCPUBoostLabel = agentLabelPrefix + "startup-cpu-boost"
+	// MaxStartupCPUBoost bounds the startup CPU boost factor
+	MaxStartupCPUBoost = 4
+
+	// EphemeralStorageSizeLabel specifies the size in GiB to which the writable layer of each container
+	// of the task is limited
+	EphemeralStorageSizeLabel = agentLabelPrefix + "ephemeral-storage-size"
+	// MaxEphemeralStorageSizeGiB bounds the ephemeral storage size
+	MaxEphemeralStorageSizeGiB = 200
+
+	// ImageAccountingOptOutLabel specifies whether the images of the task are left out of the image
+	// accounting of the agent, so that the task neither keeps its images from being cleaned up once it has
+	// stopped nor is tracked by them. It is a boolean string such as "true".
+	ImageAccountingOptOutLabel = agentLabelPrefix + "image-accounting-opt-out"
+)
+
+// getDockerLabel returns the value of a task level docker label. The first non internal container
+// that has the label set wins.
+func (task *Task) getDockerLabel(key string) (string, bool) {
+	for _, container := range task.Containers {
+		if container.IsInternal() {
+			continue
+		}
+		if value, ok := container.GetDockerLabels()[key]; ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// getIntDockerLabel returns the value of a task level docker label as an integer bounded by
+// [0, max]. Zero is returned if the label is not set or is invalid.
+func (task *Task) getIntDockerLabel(key string, max int) int {
+	value, ok := task.getDockerLabel(key)
+	if !ok {
+		return 0
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		seelog.Warnf("Task [%s]: ignoring invalid value %q for docker label %s", task.Arn, value, key)
+		return 0
+	}
+	if parsed > max {
+		seelog.Warnf("Task [%s]: value %d for docker label %s exceeds the maximum, using %d",
+			task.Arn, parsed, key, max)
+		return max
+	}
+	return parsed
+}
+
+// GetOOMPolicy returns the policy applied when a non essential container of the task is killed
+// due to memory usage.
+func (task *Task) GetOOMPolicy() string {
+	value, ok := task.getDockerLabel(OOMPolicyLabel)
+	if !ok {
+		return OOMPolicyKillContainer
+	}
+	switch value {
+	case OOMPolicyKillContainer, OOMPolicyKillTask:
+		return value
+	default:
+		seelog.Warnf("Task [%s]: ignoring invalid value %q for docker label %s", task.Arn, value, OOMPolicyLabel)
+		return OOMPolicyKillContainer
+	}
+}
+
+// GetEssentialStopTeardown returns how the remaining containers of the task are stopped after an
+// essential container stops.
+func (task *Task) GetEssentialStopTeardown() string {
+	value, ok := task.getDockerLabel(EssentialStopTeardownLabel)
+	if !ok {
+		return EssentialStopTeardownParallel
+	}
+	switch value {
+	case EssentialStopTeardownOrdered, EssentialStopTeardownParallel:
+		return value
+	default:
+		seelog.Warnf("Task [%s]: ignoring invalid value %q for docker label %s", task.Arn, value, EssentialStopTeardownLabel)
+		return EssentialStopTeardownParallel
+	}
+}
+
+// GetTaskCleanupWaitDurationOverride returns the time to wait after the task is stopped before it is
+// cleaned up, if the task overrides the configured duration.
+func (task *Task) GetTaskCleanupWaitDurationOverride() (time.Duration, bool) {
+	value, ok := task.getDockerLabel(TaskCleanupWaitDurationLabel)
+	if !ok {
+		return 0, false
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		seelog.Warnf("Task [%s]: ignoring invalid value %q for docker label %s", task.Arn, value, TaskCleanupWaitDurationLabel)
+		return 0, false
+	}
+	return duration, true
+}
+
+// ShouldStopOnSpotInterruption returns true if the task is to be stopped when the instance receives a spot
+// interruption notice. stopByDefault applies to tasks which do not set the docker label.
+func (task *Task) ShouldStopOnSpotInterruption(stopByDefault bool) bool {
+	value, ok := task.getDockerLabel(SpotInterruptionStopLabel)
+	if !ok {
+		return stopByDefault
+	}
+	switch value {
+	case SpotInterruptionStopGraceful:
+		return true
+	case SpotInterruptionStopNone:
+		return false
+	default:
+		seelog.Warnf("Task [%s]: ignoring invalid value %q for docker label %s", task.Arn, value, SpotInterruptionStopLabel)
+		return stopByDefault
+	}
+}
+
+// GetStartupCPUBoost returns the factor by which the CPU shares of the containers of the task are
+// multiplied until they become healthy. A factor lower than 2 means the containers are not boosted.
+func (task *Task) GetStartupCPUBoost() int {
+	return task.getIntDockerLabel(StartupCPUBoostLabel, MaxStartupCPUBoost)
+}
+
+// GetEphemeralStorageSizeGiB returns the size in GiB to which the writable layer of each container of the
+// task is limited. Zero means the size is not limited.
+func (task *Task) GetEphemeralStorageSizeGiB() int {
+	return task.getIntDockerLabel(EphemeralStorageSizeLabel, MaxEphemeralStorageSizeGiB)
+}
+
+// IsImageAccountingOptedOut returns true if the images of the task are not to be accounted by the image
+// manager, i.e. the task neither updates when its images were last used nor references them.
+func (task *Task) IsImageAccountingOptedOut() bool {
+	value, ok := task.getDockerLabel(ImageAccountingOptOutLabel)
+	if !ok {
+		return false
+	}
+	optedOut, err := strconv.ParseBool(value)
+	if err != nil {
+		seelog.Warnf("Task [%s]: ignoring invalid value %q for docker label %s", task.Arn, value, ImageAccountingOptOutLabel)
+		return false
+	}
+	return optedOut
+}
@@ -0,0 +1,329 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package task
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
+
+	dockercontainer "github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func containerWithLabels(t *testing.T, name string, labels map[string]string) *apicontainer.Container {
+	rawConfig, err := json.Marshal(&dockercontainer.Config{Labels: labels})
+	require.NoError(t, err)
+	return &apicontainer.Container{
+		Name: name,
+		DockerConfig: apicontainer.DockerConfig{
+			Config: strptr(string(rawConfig)),
+		},
+	}
+}
+
+func TestGetOOMPolicy(t *testing.T) {
+	testCases := []struct {
+		name     string
+		labels   map[string]string
+		expected string
+	}{
+		{
+			name:     "label not set",
+			labels:   map[string]string{},
+			expected: OOMPolicyKillContainer,
+		},
+		{
+			name:     "kill-task",
+			labels:   map[string]string{OOMPolicyLabel: OOMPolicyKillTask},
+			expected: OOMPolicyKillTask,
+		},
+		{
+			name:     "kill-container",
+			labels:   map[string]string{OOMPolicyLabel: OOMPolicyKillContainer},
+			expected: OOMPolicyKillContainer,
+		},
+		{
+			name:     "invalid value",
+			labels:   map[string]string{OOMPolicyLabel: "kill-host"},
+			expected: OOMPolicyKillContainer,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			task := &Task{
+				Arn:        "arn",
+				Containers: []*apicontainer.Container{containerWithLabels(t, "c1", tc.labels)},
+			}
+			assert.Equal(t, tc.expected, task.GetOOMPolicy())
+		})
+	}
+}
+
+func TestGetEssentialStopTeardown(t *testing.T) {
+	testCases := []struct {
+		name     string
+		labels   map[string]string
+		expected string
+	}{
+		{
+			name:     "label not set",
+			labels:   map[string]string{},
+			expected: EssentialStopTeardownParallel,
+		},
+		{
+			name:     "ordered",
+			labels:   map[string]string{EssentialStopTeardownLabel: EssentialStopTeardownOrdered},
+			expected: EssentialStopTeardownOrdered,
+		},
+		{
+			name:     "parallel",
+			labels:   map[string]string{EssentialStopTeardownLabel: EssentialStopTeardownParallel},
+			expected: EssentialStopTeardownParallel,
+		},
+		{
+			name:     "invalid value",
+			labels:   map[string]string{EssentialStopTeardownLabel: "random"},
+			expected: EssentialStopTeardownParallel,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			task := &Task{
+				Arn:        "arn",
+				Containers: []*apicontainer.Container{containerWithLabels(t, "c1", tc.labels)},
+			}
+			assert.Equal(t, tc.expected, task.GetEssentialStopTeardown())
+		})
+	}
+}
+
+func TestGetTaskCleanupWaitDurationOverride(t *testing.T) {
+	testCases := []struct {
+		name             string
+		labels           map[string]string
+		expectedDuration time.Duration
+		expectedOK       bool
+	}{
+		{
+			name:   "label not set",
+			labels: map[string]string{},
+		},
+		{
+			name:             "valid duration",
+			labels:           map[string]string{TaskCleanupWaitDurationLabel: "90m"},
+			expectedDuration: 90 * time.Minute,
+			expectedOK:       true,
+		},
+		{
+			name:   "negative duration",
+			labels: map[string]string{TaskCleanupWaitDurationLabel: "-1h"},
+		},
+		{
+			name:   "invalid duration",
+			labels: map[string]string{TaskCleanupWaitDurationLabel: "1 hour"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			task := &Task{
+				Arn:        "arn",
+				Containers: []*apicontainer.Container{containerWithLabels(t, "c1", tc.labels)},
+			}
+			duration, ok := task.GetTaskCleanupWaitDurationOverride()
+			assert.Equal(t, tc.expectedOK, ok)
+			assert.Equal(t, tc.expectedDuration, duration)
+		})
+	}
+}
+
+func TestShouldStopOnSpotInterruption(t *testing.T) {
+	testCases := []struct {
+		name          string
+		labels        map[string]string
+		stopByDefault bool
+		expected      bool
+	}{
+		{
+			name:          "label not set, stopped by default",
+			labels:        map[string]string{"foo": "bar"},
+			stopByDefault: true,
+			expected:      true,
+		},
+		{
+			name:          "label not set, left to the scheduler by default",
+			labels:        map[string]string{"foo": "bar"},
+			stopByDefault: false,
+			expected:      false,
+		},
+		{
+			name:          "graceful overrides the default",
+			labels:        map[string]string{SpotInterruptionStopLabel: SpotInterruptionStopGraceful},
+			stopByDefault: false,
+			expected:      true,
+		},
+		{
+			name:          "none overrides the default",
+			labels:        map[string]string{SpotInterruptionStopLabel: SpotInterruptionStopNone},
+			stopByDefault: true,
+			expected:      false,
+		},
+		{
+			name:          "invalid value falls back to the default",
+			labels:        map[string]string{SpotInterruptionStopLabel: "always"},
+			stopByDefault: true,
+			expected:      true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			task := &Task{
+				Arn:        "arn:aws:ecs:us-west-2:1234:task/spot",
+				Containers: []*apicontainer.Container{containerWithLabels(t, "web", tc.labels)},
+			}
+			assert.Equal(t, tc.expected, task.ShouldStopOnSpotInterruption(tc.stopByDefault))
+		})
+	}
+}
+
+func TestGetStartupCPUBoost(t *testing.T) {
+	testCases := []struct {
+		name     string
+		labels   map[string]string
+		expected int
+	}{
+		{
+			name:     "label not set",
+			labels:   map[string]string{"foo": "bar"},
+			expected: 0,
+		},
+		{
+			name:     "valid value",
+			labels:   map[string]string{StartupCPUBoostLabel: "2"},
+			expected: 2,
+		},
+		{
+			name:     "value above the maximum",
+			labels:   map[string]string{StartupCPUBoostLabel: "16"},
+			expected: MaxStartupCPUBoost,
+		},
+		{
+			name:     "fractional value",
+			labels:   map[string]string{StartupCPUBoostLabel: "1.5"},
+			expected: 0,
+		},
+		{
+			name:     "negative value",
+			labels:   map[string]string{StartupCPUBoostLabel: "-2"},
+			expected: 0,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			task := &Task{
+				Arn: "arn",
+				Containers: []*apicontainer.Container{
+					containerWithLabels(t, "c1", tc.labels),
+				},
+			}
+			assert.Equal(t, tc.expected, task.GetStartupCPUBoost())
+		})
+	}
+}
+
+func TestGetEphemeralStorageSizeGiB(t *testing.T) {
+	testCases := []struct {
+		name     string
+		labels   map[string]string
+		expected int
+	}{
+		{
+			name:     "label not set",
+			labels:   map[string]string{"foo": "bar"},
+			expected: 0,
+		},
+		{
+			name:     "valid value",
+			labels:   map[string]string{EphemeralStorageSizeLabel: "30"},
+			expected: 30,
+		},
+		{
+			name:     "value above the maximum",
+			labels:   map[string]string{EphemeralStorageSizeLabel: "1000"},
+			expected: MaxEphemeralStorageSizeGiB,
+		},
+		{
+			name:     "invalid value",
+			labels:   map[string]string{EphemeralStorageSizeLabel: "30G"},
+			expected: 0,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			task := &Task{
+				Arn: "arn",
+				Containers: []*apicontainer.Container{
+					containerWithLabels(t, "c1", tc.labels),
+				},
+			}
+			assert.Equal(t, tc.expected, task.GetEphemeralStorageSizeGiB())
+		})
+	}
+}
+
+func TestIsImageAccountingOptedOut(t *testing.T) {
+	testCases := []struct {
+		name     string
+		labels   map[string]string
+		expected bool
+	}{
+		{
+			name:     "label not set",
+			labels:   map[string]string{"foo": "bar"},
+			expected: false,
+		},
+		{
+			name:     "opted out",
+			labels:   map[string]string{ImageAccountingOptOutLabel: "true"},
+			expected: true,
+		},
+		{
+			name:     "explicitly opted in",
+			labels:   map[string]string{ImageAccountingOptOutLabel: "false"},
+			expected: false,
+		},
+		{
+			name:     "invalid value",
+			labels:   map[string]string{ImageAccountingOptOutLabel: "batch"},
+			expected: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			task := &Task{
+				Arn: "arn",
+				Containers: []*apicontainer.Container{
+					containerWithLabels(t, "c1", tc.labels),
+				},
+			}
+			assert.Equal(t, tc.expected, task.IsImageAccountingOptedOut())
+		})
+	}
+}
@@ -440,13 +440,13 @@ func TestDockerHostConfigRawConfig(t *testing.T) {
 	rawHostConfigInput := dockercontainer.HostConfig{
 		Privileged:     true,
 		ReadonlyRootfs: true,
-		DNS:            []string{"dns1, dns2"},
+		DNS:            []string{"10.0.0.2", "10.0.0.3"},
 		DNSSearch:      []string{"dns.search"},
-		ExtraHosts:     []string{"extra:hosts"},
+		ExtraHosts:     []string{"extra:10.0.0.4"},
 		SecurityOpt:    []string{"foo", "bar"},
 		Resources: dockercontainer.Resources{
 			CPUShares: 2,
-			Ulimits:   []*units.Ulimit{{Name: "ulimit name", Soft: 10, Hard: 100}},
+			Ulimits:   []*units.Ulimit{{Name: "nofile", Soft: 10, Hard: 100}},
 		},
 		LogConfig: dockercontainer.LogConfig{
 			Type:   "foo",
@@ -486,6 +486,174 @@ func TestDockerHostConfigRawConfig(t *testing.T) {
 	assertSetStructFieldsEqual(t, expectedOutput, *config)
 }
 
+func TestDockerHostConfigForceReadonlyRootFilesystem(t *testing.T) {
+	testCases := []struct {
+		name                   string
+		forceReadonlyRootfs    bool
+		containerType          apicontainer.ContainerType
+		labels                 map[string]string
+		expectedReadonlyRootfs bool
+	}{
+		{
+			name:                   "forced",
+			forceReadonlyRootfs:    true,
+			expectedReadonlyRootfs: true,
+		},
+		{
+			name:                "opted out",
+			forceReadonlyRootfs: true,
+			labels:              map[string]string{apicontainer.ReadonlyRootFilesystemOptOutLabel: "true"},
+		},
+		{
+			name:                   "invalid opt out",
+			forceReadonlyRootfs:    true,
+			labels:                 map[string]string{apicontainer.ReadonlyRootFilesystemOptOutLabel: "yes please"},
+			expectedReadonlyRootfs: true,
+		},
+		{
+			name:                "internal container",
+			forceReadonlyRootfs: true,
+			containerType:       apicontainer.ContainerCNIPause,
+		},
+		{
+			name: "not forced by default",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rawConfig, err := json.Marshal(&dockercontainer.Config{Labels: tc.labels})
+			require.NoError(t, err)
+			testTask := &Task{
+				Arn: "arn:aws:ecs:us-east-1:012345678910:task/c09f0188-7f87-4b0f-bfc3-16296622b6fe",
+				Containers: []*apicontainer.Container{
+					{
+						Name: "c1",
+						Type: tc.containerType,
+						DockerConfig: apicontainer.DockerConfig{
+							Config: strptr(string(rawConfig)),
+						},
+					},
+				},
+			}
+			cfg := &config.Config{}
+			if tc.forceReadonlyRootfs {
+				cfg.ForceReadonlyRootFilesystem = config.BooleanDefaultFalse{Value: config.ExplicitlyEnabled}
+			}
+
+			hostConfig, configErr := testTask.DockerHostConfig(testTask.Containers[0], dockerMap(testTask),
+				defaultDockerClientAPIVersion, cfg)
+			require.Nil(t, configErr)
+			assert.Equal(t, tc.expectedReadonlyRootfs, hostConfig.ReadonlyRootfs)
+		})
+	}
+}
+
+func TestDockerHostConfigReadonlyRootFilesystemFromTaskDefinition(t *testing.T) {
+	rawHostConfig, err := json.Marshal(&dockercontainer.HostConfig{ReadonlyRootfs: true})
+	require.NoError(t, err)
+	rawConfig, err := json.Marshal(&dockercontainer.Config{
+		Labels: map[string]string{apicontainer.ReadonlyRootFilesystemOptOutLabel: "true"},
+	})
+	require.NoError(t, err)
+	testTask := &Task{
+		Arn: "arn:aws:ecs:us-east-1:012345678910:task/c09f0188-7f87-4b0f-bfc3-16296622b6fe",
+		Containers: []*apicontainer.Container{
+			{
+				Name: "c1",
+				DockerConfig: apicontainer.DockerConfig{
+					Config:     strptr(string(rawConfig)),
+					HostConfig: strptr(string(rawHostConfig)),
+				},
+			},
+		},
+	}
+
+	// opting out of the enforcement doesn't undo the read only root filesystem of the task definition
+	hostConfig, configErr := testTask.DockerHostConfig(testTask.Containers[0], dockerMap(testTask),
+		defaultDockerClientAPIVersion, &config.Config{
+			ForceReadonlyRootFilesystem: config.BooleanDefaultFalse{Value: config.ExplicitlyEnabled},
+		})
+	require.Nil(t, configErr)
+	assert.True(t, hostConfig.ReadonlyRootfs)
+}
+
+func TestDockerHostConfigUlimits(t *testing.T) {
+	defaultUlimits := []*units.Ulimit{
+		{Name: "nofile", Soft: 65536, Hard: 65536},
+		{Name: "nproc", Soft: 4096, Hard: 4096},
+	}
+	testCases := []struct {
+		name            string
+		ulimits         []*units.Ulimit
+		containerType   apicontainer.ContainerType
+		expectedUlimits []*units.Ulimit
+		expectedError   string
+	}{
+		{
+			name:    "valid ulimits",
+			ulimits: []*units.Ulimit{{Name: "nofile", Soft: 1024, Hard: 4096}, {Name: "core", Soft: -1, Hard: -1}},
+			expectedUlimits: []*units.Ulimit{
+				{Name: "nofile", Soft: 1024, Hard: 4096},
+				{Name: "core", Soft: -1, Hard: -1},
+				{Name: "nproc", Soft: 4096, Hard: 4096},
+			},
+		},
+		{
+			name:            "defaults applied",
+			expectedUlimits: defaultUlimits,
+		},
+		{
+			name:          "defaults not applied to internal containers",
+			containerType: apicontainer.ContainerCNIPause,
+		},
+		{
+			name:          "soft limit above hard limit",
+			ulimits:       []*units.Ulimit{{Name: "nofile", Soft: 8192, Hard: 4096}},
+			expectedError: `invalid ulimit "nofile": soft limit 8192 is above hard limit 4096`,
+		},
+		{
+			name:          "unlimited soft limit above hard limit",
+			ulimits:       []*units.Ulimit{{Name: "memlock", Soft: -1, Hard: 4096}},
+			expectedError: `invalid ulimit "memlock": soft limit -1 is above hard limit 4096`,
+		},
+		{
+			name:          "unknown name",
+			ulimits:       []*units.Ulimit{{Name: "openfiles", Soft: 1024, Hard: 1024}},
+			expectedError: `invalid ulimit "openfiles": unknown ulimit name`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rawHostConfig, err := json.Marshal(&dockercontainer.HostConfig{
+				Resources: dockercontainer.Resources{Ulimits: tc.ulimits},
+			})
+			require.NoError(t, err)
+			testTask := &Task{
+				Arn: "arn:aws:ecs:us-east-1:012345678910:task/c09f0188-7f87-4b0f-bfc3-16296622b6fe",
+				Containers: []*apicontainer.Container{
+					{
+						Name: "c1",
+						Type: tc.containerType,
+						DockerConfig: apicontainer.DockerConfig{
+							HostConfig: strptr(string(rawHostConfig)),
+						},
+					},
+				},
+			}
+			hostConfig, configErr := testTask.DockerHostConfig(testTask.Containers[0], dockerMap(testTask),
+				defaultDockerClientAPIVersion, &config.Config{ContainerDefaultUlimits: defaultUlimits})
+			if tc.expectedError == "" {
+				require.Nil(t, configErr)
+				assert.Equal(t, tc.expectedUlimits, hostConfig.Ulimits)
+				return
+			}
+			require.NotNil(t, configErr)
+			assert.Contains(t, configErr.Error(), tc.expectedError)
+		})
+	}
+}
+
 func TestDockerHostConfigPauseContainer(t *testing.T) {
 	testTask := &Task{
 		ENIs: []*apieni.ENI{
@@ -592,6 +760,81 @@ func TestBadDockerHostConfigRawConfig(t *testing.T) {
 	}
 }
 
+func TestDockerHostConfigDNSAndExtraHosts(t *testing.T) {
+	testCases := []struct {
+		name          string
+		dns           []string
+		extraHosts    []string
+		expectedError string
+	}{
+		{
+			name:       "valid entries",
+			dns:        []string{"10.0.0.2", "fd00::2"},
+			extraHosts: []string{"db:10.0.0.4", "db6:fd00::4", "host:host-gateway"},
+		},
+		{
+			name:          "DNS server is a host name",
+			dns:           []string{"10.0.0.2", "dns.example.com"},
+			expectedError: `invalid DNS server "dns.example.com"`,
+		},
+		{
+			name:          "DNS servers in a single entry",
+			dns:           []string{"10.0.0.2, 10.0.0.3"},
+			expectedError: `invalid DNS server "10.0.0.2, 10.0.0.3"`,
+		},
+		{
+			name:          "extra host without IP",
+			extraHosts:    []string{"db:10.0.0.4", "cache"},
+			expectedError: `invalid extra host "cache": expected host:ip`,
+		},
+		{
+			name:          "extra host without host",
+			extraHosts:    []string{":10.0.0.4"},
+			expectedError: `invalid extra host ":10.0.0.4": expected host:ip`,
+		},
+		{
+			name:          "extra host with a host name instead of IP",
+			extraHosts:    []string{"db:db.example.com"},
+			expectedError: `invalid extra host "db:db.example.com": "db.example.com" is not an IP address`,
+		},
+		{
+			name:          "extra host in the ip host format",
+			extraHosts:    []string{"10.0.0.4 db"},
+			expectedError: `invalid extra host "10.0.0.4 db": expected host:ip`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rawHostConfig, err := json.Marshal(&dockercontainer.HostConfig{
+				DNS:        tc.dns,
+				ExtraHosts: tc.extraHosts,
+			})
+			require.NoError(t, err)
+			testTask := &Task{
+				Arn: "arn:aws:ecs:us-east-1:012345678910:task/c09f0188-7f87-4b0f-bfc3-16296622b6fe",
+				Containers: []*apicontainer.Container{
+					{
+						Name: "c1",
+						DockerConfig: apicontainer.DockerConfig{
+							HostConfig: strptr(string(rawHostConfig)),
+						},
+					},
+				},
+			}
+			hostConfig, configErr := testTask.DockerHostConfig(testTask.Containers[0], dockerMap(testTask),
+				defaultDockerClientAPIVersion, &config.Config{})
+			if tc.expectedError == "" {
+				require.Nil(t, configErr)
+				assert.Equal(t, tc.dns, hostConfig.DNS)
+				assert.Equal(t, tc.extraHosts, hostConfig.ExtraHosts)
+				return
+			}
+			require.NotNil(t, configErr)
+			assert.Contains(t, configErr.Error(), tc.expectedError)
+		})
+	}
+}
+
 func TestDockerConfigRawConfig(t *testing.T) {
 	rawConfigInput := dockercontainer.Config{
 		Hostname:        "hostname",
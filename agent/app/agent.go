@@ -150,6 +150,25 @@ type ecsAgent struct {
 	latestSeqNumberTaskManifest *int64
 }
 
+// newSDKClientFactory returns the factory of the Docker clients of the agent. Its default client uses the Docker
+// API version override, unless the version is not supported by the agent.
+func newSDKClientFactory(ctx context.Context, cfg *config.Config) sdkclientfactory.Factory {
+	if cfg.DockerClientAPIVersionOverride != "" {
+		factory, err := sdkclientfactory.NewFactoryWithDefaultVersion(ctx, cfg.DockerEndpoint, cfg.DockerClientAPIVersionOverride)
+		if err == nil {
+			logger.Info("Using the Docker API version override", logger.Fields{
+				"version": cfg.DockerClientAPIVersionOverride,
+			})
+			return factory
+		}
+		logger.Error("Ignoring the Docker API version override", logger.Fields{
+			"version":   cfg.DockerClientAPIVersionOverride,
+			field.Error: err,
+		})
+	}
+	return sdkclientfactory.NewFactory(ctx, cfg.DockerEndpoint)
+}
+
 // newAgent returns a new ecsAgent object, but does not start anything
 func newAgent(blackholeEC2Metadata bool, acceptInsecureCert *bool) (agent, error) {
 	ctx, cancel := context.WithCancel(context.Background())
@@ -183,7 +202,7 @@ func newAgent(blackholeEC2Metadata bool, acceptInsecureCert *bool) (agent, error
 	}
 
 	ec2Client := ec2.NewClientImpl(cfg.AWSRegion)
-	dockerClient, err := dockerapi.NewDockerGoClient(sdkclientfactory.NewFactory(ctx, cfg.DockerEndpoint), cfg, ctx)
+	dockerClient, err := dockerapi.NewDockerGoClient(newSDKClientFactory(ctx, cfg), cfg, ctx)
 
 	if err != nil {
 		// This is also non terminal in the current config
@@ -273,8 +292,13 @@ func (agent *ecsAgent) start() int {
 	imageManager := engine.NewImageManager(agent.cfg, agent.dockerClient, state)
 	client := ecsclient.NewECSClient(agent.credentialProvider, agent.cfg, agent.ec2MetadataClient)
 
+	execCmdMgr := execcmd.NewManagerWithConfig(agent.cfg)
+	if agent.cfg.ExecSessionLimitFromTaskTags.Enabled() {
+		execCmdMgr.SetTaskTagsClient(client)
+	}
+
 	agent.initializeResourceFields(credentialsManager)
-	return agent.doStart(containerChangeEventStream, credentialsManager, state, imageManager, client, execcmd.NewManager())
+	return agent.doStart(containerChangeEventStream, credentialsManager, state, imageManager, client, execCmdMgr)
 }
 
 // doStart is the worker invoked by start for starting the ECS Agent. This involves
@@ -819,21 +843,26 @@ func (agent *ecsAgent) startAsyncRoutines(
 	doctor *doctor.Doctor,
 ) {
 
+	// Load the images of the pre-warm tarballs, if configured
+	if agent.cfg.ImagePrewarmTarballDir != "" {
+		go imageManager.LoadPrewarmImages(agent.ctx)
+	}
+
 	// Start of the periodic image cleanup process
 	if !agent.cfg.ImageCleanupDisabled.Enabled() {
 		go imageManager.StartImageCleanupProcess(agent.ctx)
 	}
 
 	// Start automatic spot instance draining poller routine
-	if agent.cfg.SpotInstanceDrainingEnabled.Enabled() {
-		go agent.startSpotInstanceDrainingPoller(agent.ctx, client)
+	if agent.cfg.SpotInstanceDrainingEnabled.Enabled() || agent.cfg.SpotInterruptionTaskStopEnabled.Enabled() {
+		go agent.startSpotInstanceDrainingPoller(agent.ctx, client, taskEngine)
 	}
 
-	// Agent introspection api
-	go handlers.ServeIntrospectionHTTPEndpoint(agent.ctx, &agent.containerInstanceARN, taskEngine, agent.cfg)
-
 	statsEngine := stats.NewDockerStatsEngine(agent.cfg, agent.dockerClient, containerChangeEventStream)
 
+	// Agent introspection api
+	go handlers.ServeIntrospectionHTTPEndpoint(agent.ctx, &agent.containerInstanceARN, taskEngine, statsEngine, agent.cfg)
+
 	// Start serving the endpoint to fetch IAM Role credentials and other task metadata
 	if agent.cfg.TaskMetadataAZDisabled {
 		// send empty availability zone
@@ -842,8 +871,15 @@ func (agent *ecsAgent) startAsyncRoutines(
 		go handlers.ServeTaskHTTPEndpoint(agent.ctx, credentialsManager, state, client, agent.containerInstanceARN, agent.cfg, statsEngine, agent.availabilityZone, agent.vpc)
 	}
 
+	// Stream the state changes to local consumers, if configured
+	var lifecycleEvents *eventhandler.LifecycleEventBroadcaster
+	if agent.cfg.LifecycleEventsSocketPath != "" {
+		lifecycleEvents = eventhandler.NewLifecycleEventBroadcaster()
+		go handlers.ServeLifecycleEventsEndpoint(agent.ctx, agent.cfg.LifecycleEventsSocketPath, lifecycleEvents)
+	}
+
 	// Start sending events to the backend
-	go eventhandler.HandleEngineEvents(agent.ctx, taskEngine, client, taskHandler, attachmentEventHandler)
+	go eventhandler.HandleEngineEvents(agent.ctx, taskEngine, client, taskHandler, attachmentEventHandler, lifecycleEvents)
 
 	telemetrySessionParams := tcshandler.TelemetrySessionParams{
 		Ctx:                           agent.ctx,
@@ -861,8 +897,9 @@ func (agent *ecsAgent) startAsyncRoutines(
 	go tcshandler.StartMetricsSession(&telemetrySessionParams)
 }
 
-func (agent *ecsAgent) startSpotInstanceDrainingPoller(ctx context.Context, client api.ECSClient) {
-	for !agent.spotInstanceDrainingPoller(client) {
+func (agent *ecsAgent) startSpotInstanceDrainingPoller(ctx context.Context, client api.ECSClient,
+	taskEngine engine.TaskEngine) {
+	for !agent.spotInstanceDrainingPoller(client, taskEngine) {
 		select {
 		case <-ctx.Done():
 			return
@@ -873,8 +910,10 @@ func (agent *ecsAgent) startSpotInstanceDrainingPoller(ctx context.Context, clie
 }
 
 // spotInstanceDrainingPoller returns true if spot instance interruption has been
-// set AND the container instance state is successfully updated to DRAINING.
-func (agent *ecsAgent) spotInstanceDrainingPoller(client api.ECSClient) bool {
+// set AND the container instance state is successfully updated to DRAINING, if
+// spot instance draining is enabled. The tasks which are to be stopped on spot
+// interruption are stopped as soon as the interruption is set.
+func (agent *ecsAgent) spotInstanceDrainingPoller(client api.ECSClient, taskEngine engine.TaskEngine) bool {
 	// this endpoint 404s unless a interruption has been set, so expect failure in most cases.
 	resp, err := agent.ec2MetadataClient.SpotInstanceAction()
 	if err == nil {
@@ -897,6 +936,12 @@ func (agent *ecsAgent) spotInstanceDrainingPoller(client api.ECSClient) bool {
 			return false
 		}
 
+		// Tasks already stopping are left alone, so this is a no-op when retrying to set the state to DRAINING
+		taskEngine.StopTasksForSpotInterruption()
+		if !agent.cfg.SpotInstanceDrainingEnabled.Enabled() {
+			return true
+		}
+
 		seelog.Infof("Received a spot interruption (%s) scheduled for %s, setting state to DRAINING", ia.Action, ia.Time)
 		err = client.UpdateContainerInstancesState(agent.containerInstanceARN, "DRAINING")
 		if err != nil {
@@ -56,6 +56,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	aws_credentials "github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/docker/docker/api/types"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -427,6 +428,9 @@ func testDoStartHappyPathWithConditions(t *testing.T, blackholed bool, warmPools
 	imageManager.EXPECT().StartImageCleanupProcess(gomock.Any()).MaxTimes(1)
 	dockerClient.EXPECT().ListContainers(gomock.Any(), gomock.Any(), gomock.Any()).Return(
 		dockerapi.ListContainersResponse{}).AnyTimes()
+	dockerClient.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	dockerClient.EXPECT().Info(gomock.Any(), gomock.Any()).Return(types.Info{}, nil).AnyTimes()
 	client.EXPECT().DiscoverPollEndpoint(gomock.Any()).Do(func(x interface{}) {
 		// Ensures that the test waits until acs session has bee started
 		discoverEndpointsInvoked.Done()
@@ -1556,6 +1560,7 @@ func TestSpotInstanceActionCheck_Sunny(t *testing.T) {
 	ec2MetadataClient := mock_ec2.NewMockEC2MetadataClient(ctrl)
 	ec2Client := mock_ec2.NewMockClient(ctrl)
 	ecsClient := mock_api.NewMockECSClient(ctrl)
+	taskEngine := mock_engine.NewMockTaskEngine(ctrl)
 
 	for _, test := range tests {
 		myARN := "myARN"
@@ -1563,12 +1568,35 @@ func TestSpotInstanceActionCheck_Sunny(t *testing.T) {
 			ec2MetadataClient:    ec2MetadataClient,
 			ec2Client:            ec2Client,
 			containerInstanceARN: myARN,
+			cfg:                  &config.Config{SpotInstanceDrainingEnabled: config.BooleanDefaultFalse{Value: config.ExplicitlyEnabled}},
 		}
 		ec2MetadataClient.EXPECT().SpotInstanceAction().Return(test.jsonresp, nil)
+		taskEngine.EXPECT().StopTasksForSpotInterruption()
 		ecsClient.EXPECT().UpdateContainerInstancesState(myARN, "DRAINING").Return(nil)
 
-		assert.True(t, agent.spotInstanceDrainingPoller(ecsClient))
+		assert.True(t, agent.spotInstanceDrainingPoller(ecsClient, taskEngine))
+	}
+}
+
+func TestSpotInstanceActionCheck_StopTasksWithoutDraining(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ec2MetadataClient := mock_ec2.NewMockEC2MetadataClient(ctrl)
+	ecsClient := mock_api.NewMockECSClient(ctrl)
+	taskEngine := mock_engine.NewMockTaskEngine(ctrl)
+
+	agent := &ecsAgent{
+		ec2MetadataClient:    ec2MetadataClient,
+		containerInstanceARN: "myARN",
+		cfg:                  &config.Config{SpotInterruptionTaskStopEnabled: config.BooleanDefaultFalse{Value: config.ExplicitlyEnabled}},
 	}
+	ec2MetadataClient.EXPECT().SpotInstanceAction().Return(`{"action": "terminate", "time": "2017-09-18T08:22:00Z"}`, nil)
+	taskEngine.EXPECT().StopTasksForSpotInterruption()
+	// Container state should NOT be updated because spot instance draining is disabled.
+	ecsClient.EXPECT().UpdateContainerInstancesState(gomock.Any(), gomock.Any()).Times(0)
+
+	assert.True(t, agent.spotInstanceDrainingPoller(ecsClient, taskEngine))
 }
 
 func TestSpotInstanceActionCheck_Fail(t *testing.T) {
@@ -1585,6 +1613,7 @@ func TestSpotInstanceActionCheck_Fail(t *testing.T) {
 	ec2MetadataClient := mock_ec2.NewMockEC2MetadataClient(ctrl)
 	ec2Client := mock_ec2.NewMockClient(ctrl)
 	ecsClient := mock_api.NewMockECSClient(ctrl)
+	taskEngine := mock_engine.NewMockTaskEngine(ctrl)
 
 	for _, test := range tests {
 		myARN := "myARN"
@@ -1596,8 +1625,9 @@ func TestSpotInstanceActionCheck_Fail(t *testing.T) {
 		ec2MetadataClient.EXPECT().SpotInstanceAction().Return(test.jsonresp, nil)
 		// Container state should NOT be updated because the termination time field is empty.
 		ecsClient.EXPECT().UpdateContainerInstancesState(gomock.Any(), gomock.Any()).Times(0)
+		taskEngine.EXPECT().StopTasksForSpotInterruption().Times(0)
 
-		assert.False(t, agent.spotInstanceDrainingPoller(ecsClient))
+		assert.False(t, agent.spotInstanceDrainingPoller(ecsClient, taskEngine))
 	}
 }
 
@@ -1608,6 +1638,7 @@ func TestSpotInstanceActionCheck_NoInstanceActionYet(t *testing.T) {
 	ec2MetadataClient := mock_ec2.NewMockEC2MetadataClient(ctrl)
 	ec2Client := mock_ec2.NewMockClient(ctrl)
 	ecsClient := mock_api.NewMockECSClient(ctrl)
+	taskEngine := mock_engine.NewMockTaskEngine(ctrl)
 
 	myARN := "myARN"
 	agent := &ecsAgent{
@@ -1619,8 +1650,9 @@ func TestSpotInstanceActionCheck_NoInstanceActionYet(t *testing.T) {
 
 	// Container state should NOT be updated because there is no termination time.
 	ecsClient.EXPECT().UpdateContainerInstancesState(gomock.Any(), gomock.Any()).Times(0)
+	taskEngine.EXPECT().StopTasksForSpotInterruption().Times(0)
 
-	assert.False(t, agent.spotInstanceDrainingPoller(ecsClient))
+	assert.False(t, agent.spotInstanceDrainingPoller(ecsClient, taskEngine))
 }
 
 func TestSaveMetadata(t *testing.T) {
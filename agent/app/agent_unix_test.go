@@ -49,6 +49,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/docker/docker/api/types"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 )
@@ -92,6 +93,9 @@ func TestDoStartTaskENIHappyPath(t *testing.T) {
 	dockerClient.EXPECT().SupportedVersions().Return(apiVersions)
 	dockerClient.EXPECT().ListContainers(gomock.Any(), gomock.Any(), gomock.Any()).Return(
 		dockerapi.ListContainersResponse{}).AnyTimes()
+	dockerClient.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	dockerClient.EXPECT().Info(gomock.Any(), gomock.Any()).Return(types.Info{}, nil).AnyTimes()
 	imageManager.EXPECT().StartImageCleanupProcess(gomock.Any()).MaxTimes(1)
 	client.EXPECT().DiscoverPollEndpoint(gomock.Any()).Do(func(x interface{}) {
 		// Ensures that the test waits until acs session has bee started
@@ -486,6 +490,9 @@ func TestDoStartCgroupInitHappyPath(t *testing.T) {
 		dockerClient.EXPECT().ListContainers(gomock.Any(), gomock.Any(), gomock.Any()).Return(
 			dockerapi.ListContainersResponse{}).AnyTimes(),
 	)
+	dockerClient.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	dockerClient.EXPECT().Info(gomock.Any(), gomock.Any()).Return(types.Info{}, nil).AnyTimes()
 
 	cfg := config.DefaultConfig()
 	ctx, cancel := context.WithCancel(context.TODO())
@@ -495,6 +502,7 @@ func TestDoStartCgroupInitHappyPath(t *testing.T) {
 		cfg:                &cfg,
 		credentialProvider: credentials.NewCredentials(mockCredentialsProvider),
 		pauseLoader:        mockPauseLoader,
+		dataClient:         data.NewNoopClient(),
 		dockerClient:       dockerClient,
 		terminationHandler: func(state dockerstate.TaskEngineState, dataClient data.Client, taskEngine engine.TaskEngine, cancel context.CancelFunc) {
 		},
@@ -650,6 +658,9 @@ func TestDoStartGPUManagerHappyPath(t *testing.T) {
 		dockerClient.EXPECT().ListContainers(gomock.Any(), gomock.Any(), gomock.Any()).Return(
 			dockerapi.ListContainersResponse{}).AnyTimes(),
 	)
+	dockerClient.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	dockerClient.EXPECT().Info(gomock.Any(), gomock.Any()).Return(types.Info{}, nil).AnyTimes()
 
 	cfg := getTestConfig()
 	cfg.GPUSupportEnabled = true
@@ -659,6 +670,7 @@ func TestDoStartGPUManagerHappyPath(t *testing.T) {
 		ctx:                ctx,
 		cfg:                &cfg,
 		credentialProvider: credentials.NewCredentials(mockCredentialsProvider),
+		dataClient:         data.NewNoopClient(),
 		dockerClient:       dockerClient,
 		pauseLoader:        mockPauseLoader,
 		terminationHandler: func(state dockerstate.TaskEngineState, dataClient data.Client, taskEngine engine.TaskEngine, cancel context.CancelFunc) {
@@ -758,6 +770,9 @@ func TestDoStartTaskENIPauseError(t *testing.T) {
 	dockerClient.EXPECT().SupportedVersions().Return(apiVersions)
 	dockerClient.EXPECT().ListContainers(gomock.Any(), gomock.Any(), gomock.Any()).Return(
 		dockerapi.ListContainersResponse{}).AnyTimes()
+	dockerClient.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	dockerClient.EXPECT().Info(gomock.Any(), gomock.Any()).Return(types.Info{}, nil).AnyTimes()
 	imageManager.EXPECT().StartImageCleanupProcess(gomock.Any()).MaxTimes(1)
 	mockPauseLoader.EXPECT().LoadImage(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("error")).AnyTimes()
 
@@ -769,6 +784,7 @@ func TestDoStartTaskENIPauseError(t *testing.T) {
 		ctx:                ctx,
 		cfg:                &cfg,
 		credentialProvider: credentials.NewCredentials(mockCredentialsProvider),
+		dataClient:         data.NewNoopClient(),
 		dockerClient:       dockerClient,
 		pauseLoader:        mockPauseLoader,
 		cniClient:          cniClient,
@@ -14,11 +14,13 @@
 package config
 
 import (
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"time"
@@ -60,6 +62,34 @@ const (
 	// clean up task's containers.
 	DefaultTaskCleanupWaitDuration = 3 * time.Hour
 
+	// DefaultTaskCleanupWaitDurationMaxOverride specifies the default maximum task cleanup duration that
+	// a task can request for itself.
+	DefaultTaskCleanupWaitDurationMaxOverride = 24 * time.Hour
+
+	// DefaultTaskCleanupConcurrency specifies the default maximum number of stopped tasks cleaned up at the
+	// same time
+	DefaultTaskCleanupConcurrency = 10
+
+	// DefaultTaskContainerStartConcurrency specifies the default maximum number of containers of a task that
+	// are pulled, created and started at the same time
+	DefaultTaskContainerStartConcurrency = 10
+
+	// DefaultECRTokenCacheTTL specifies the default time for which ECR credentials resolved for image pulls are
+	// cached. It is kept well below the 12 hour lifetime of ECR authorization tokens.
+	DefaultECRTokenCacheTTL = 1 * time.Hour
+
+	// DefaultExecAgentHealthCheckInterval specifies the default interval at which the agent checks that the
+	// ExecCommandAgent process is alive in the containers it was started in
+	DefaultExecAgentHealthCheckInterval = 1 * time.Minute
+
+	// DefaultExecAgentFolderPerm specifies the default permissions of the directories created for the
+	// ExecCommandAgent config and logs
+	DefaultExecAgentFolderPerm os.FileMode = 0755
+
+	// DefaultExecAuditLogMaxSizeMB specifies the default size, in megabytes, above which the exec audit log is
+	// rotated
+	DefaultExecAuditLogMaxSizeMB = 10
+
 	// DefaultPollingMetricsWaitDuration specifies the default value for polling metrics wait duration
 	// This is only used when PollMetrics is set to true
 	DefaultPollingMetricsWaitDuration = DefaultContainerMetricsPublishInterval / 2
@@ -67,6 +97,9 @@ const (
 	// defaultDockerStopTimeout specifies the value for container stop timeout duration
 	defaultDockerStopTimeout = 30 * time.Second
 
+	// DefaultShutdownStopTimeout specifies the default time to wait for tasks to stop on agent shutdown
+	DefaultShutdownStopTimeout = 45 * time.Second
+
 	// DefaultImageCleanupTimeInterval specifies the default value for image cleanup duration. It is used to
 	// remove the images pulled by agent.
 	DefaultImageCleanupTimeInterval = 30 * time.Minute
@@ -79,6 +112,14 @@ const (
 	// nonecs containers cleanup.
 	DefaultNumNonECSContainersToDeletePerCycle = 5
 
+	// DefaultImageCleanupStatsHistorySize specifies the default number of image cleanup cycles whose
+	// statistics are kept for introspection.
+	DefaultImageCleanupStatsHistorySize = 10
+
+	// DefaultImageRemoveFailureWarningThreshold specifies the default number of consecutive failed attempts
+	// to remove an image after which a warning is logged.
+	DefaultImageRemoveFailureWarningThreshold = 3
+
 	// DefaultImageDeletionAge specifies the default value for minimum amount of elapsed time after an image
 	// has been pulled before it can be deleted.
 	DefaultImageDeletionAge = 1 * time.Hour
@@ -87,9 +128,17 @@ const (
 	// has been created before it can be deleted
 	DefaultNonECSImageDeletionAge = 1 * time.Hour
 
+	// DefaultImageCleanupEscalationMinimumAge specifies the default floor of the minimum image age when image
+	// cleanup is escalated, so that images which were just pulled are never removed
+	DefaultImageCleanupEscalationMinimumAge = 10 * time.Minute
+
 	//DefaultImagePullTimeout specifies the timeout for PullImage API.
 	DefaultImagePullTimeout = 2 * time.Hour
 
+	// DefaultImagePullProgressLogInterval specifies the default interval at which the progress of an image pull
+	// is logged
+	DefaultImagePullProgressLogInterval = 30 * time.Second
+
 	// minimumTaskCleanupWaitDuration specifies the minimum duration to wait before cleaning up
 	// a task's container. This is used to enforce sane values for the config.TaskCleanupWaitDuration field.
 	minimumTaskCleanupWaitDuration = time.Second
@@ -98,6 +147,48 @@ const (
 	// 'stuck' in the pull / unpack step. Very small values are unsafe and lead to high failure rate.
 	minimumImagePullInactivityTimeout = 1 * time.Minute
 
+	// maximumEssentialContainerStopGrace bounds how long the remaining containers of a task are left running
+	// after an essential container of the task exits
+	maximumEssentialContainerStopGrace = 2 * time.Minute
+
+	// maximumShutdownStopTimeout bounds how long the agent waits for its tasks to stop on shutdown, so that it
+	// saves its state before ecs-init stops waiting for it to exit and kills it
+	maximumShutdownStopTimeout = 1 * time.Minute
+
+	// minimumImagePullProgressLogInterval specifies the minimum interval at which the progress of an image pull
+	// is logged
+	minimumImagePullProgressLogInterval = 1 * time.Second
+
+	// minimumECRTokenCacheTTL and maximumECRTokenCacheTTL bound the time for which ECR credentials are cached.
+	// The maximum leaves room below the 12 hour lifetime of ECR authorization tokens.
+	minimumECRTokenCacheTTL = 1 * time.Minute
+	maximumECRTokenCacheTTL = 6 * time.Hour
+
+	// minimumContainerClockDriftCheckInterval specifies the minimum interval at which the clock of running
+	// containers is compared against the host clock, since every check execs a command in each container
+	minimumContainerClockDriftCheckInterval = 1 * time.Minute
+
+	// minimumExecAgentHealthCheckInterval specifies the minimum interval at which the ExecCommandAgent process
+	// is checked in each container
+	minimumExecAgentHealthCheckInterval = 10 * time.Second
+
+	// minimumExecAgentFolderPerm and maximumExecAgentFolderPerm bound the permissions of the ExecCommandAgent
+	// directories. The owner always needs full access, and the directories are never writable by others.
+	minimumExecAgentFolderPerm os.FileMode = 0700
+	maximumExecAgentFolderPerm os.FileMode = 0755
+
+	// minimumAWSVPCPauseContainerCheckInterval specifies the minimum interval at which the pause containers
+	// of awsvpc tasks are inspected
+	minimumAWSVPCPauseContainerCheckInterval = 30 * time.Second
+
+	// minimumDNSLatencyCheckInterval specifies the minimum interval at which the latency of DNS resolutions is
+	// measured from within the network namespace of awsvpc tasks
+	minimumDNSLatencyCheckInterval = 30 * time.Second
+
+	// minimumContainerFDCheckInterval specifies the minimum interval at which the file descriptors opened by
+	// containers are counted
+	minimumContainerFDCheckInterval = 30 * time.Second
+
 	// minimumPollingMetricsWaitDuration specifies the minimum duration to wait before polling for new stats
 	// from docker. This is only used when PollMetrics is set to true
 	minimumPollingMetricsWaitDuration = 5 * time.Second
@@ -117,6 +208,10 @@ const (
 	// performing image cleanup.
 	minimumNumImagesToDeletePerCycle = 1
 
+	// maximumImageCleanupStatsHistorySize bounds the number of image cleanup cycles whose statistics
+	// are kept for introspection.
+	maximumImageCleanupStatsHistorySize = 100
+
 	// defaultCNIPluginsPath is the default path where cni binaries are located
 	defaultCNIPluginsPath = "/amazon-ecs-cni-plugins"
 
@@ -280,6 +375,27 @@ func (config *Config) mergeDefaultConfig(errs []error) error {
 	return nil
 }
 
+// RegistryCertPools loads the certificates of the CAs configured for each registry host. An error is returned if
+// a file can't be read or doesn't hold any certificate.
+func (cfg *Config) RegistryCertPools() (map[string]*x509.CertPool, error) {
+	if len(cfg.RegistryCACertificates) == 0 {
+		return nil, nil
+	}
+	pools := make(map[string]*x509.CertPool, len(cfg.RegistryCACertificates))
+	for host, caFile := range cfg.RegistryCACertificates {
+		pem, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("config: unable to read the CA certificates of registry %s: %v", host, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("config: no valid PEM certificate found in %s for registry %s", caFile, host)
+		}
+		pools[host] = pool
+	}
+	return pools, nil
+}
+
 // trimWhitespace trims whitespace from all string cfg values with the
 // `trim` tag
 func (cfg *Config) trimWhitespace() {
@@ -317,6 +433,10 @@ func (cfg *Config) validateAndOverrideBounds() error {
 		return fmt.Errorf("config: invalid value for docker container stop timeout: %v", cfg.DockerStopTimeout.String())
 	}
 
+	if _, err := cfg.RegistryCertPools(); err != nil {
+		return err
+	}
+
 	if cfg.ContainerStartTimeout < minimumContainerStartTimeout {
 		return fmt.Errorf("config: invalid value for docker container start timeout: %v", cfg.ContainerStartTimeout.String())
 	}
@@ -342,11 +462,124 @@ func (cfg *Config) validateAndOverrideBounds() error {
 		cfg.TaskCleanupWaitDuration = DefaultTaskCleanupWaitDuration
 	}
 
+	if cfg.TaskCleanupWaitDurationMaxOverride < minimumTaskCleanupWaitDuration {
+		seelog.Warnf("Invalid value for ECS_ENGINE_TASK_CLEANUP_WAIT_DURATION_MAX_OVERRIDE, will be overridden with the default value: %s. Parsed value: %v, minimum value: %v.", DefaultTaskCleanupWaitDurationMaxOverride.String(), cfg.TaskCleanupWaitDurationMaxOverride, minimumTaskCleanupWaitDuration)
+		cfg.TaskCleanupWaitDurationMaxOverride = DefaultTaskCleanupWaitDurationMaxOverride
+	}
+
+	if cfg.ShutdownStopTimeout <= 0 {
+		seelog.Warnf("Invalid value for ECS_SHUTDOWN_STOP_TIMEOUT, will be overridden with the default value: %s. Parsed value: %v.", DefaultShutdownStopTimeout.String(), cfg.ShutdownStopTimeout)
+		cfg.ShutdownStopTimeout = DefaultShutdownStopTimeout
+	} else if cfg.ShutdownStopTimeout > maximumShutdownStopTimeout {
+		seelog.Warnf("Invalid value for ECS_SHUTDOWN_STOP_TIMEOUT, will be overridden with the maximum value: %s. Parsed value: %v.", maximumShutdownStopTimeout.String(), cfg.ShutdownStopTimeout)
+		cfg.ShutdownStopTimeout = maximumShutdownStopTimeout
+	}
+
+	if cfg.EssentialContainerStopGrace < 0 {
+		seelog.Warnf("Invalid value for ECS_ESSENTIAL_CONTAINER_STOP_GRACE, the remaining containers of a task will be stopped as soon as an essential container exits. Parsed value: %v.", cfg.EssentialContainerStopGrace)
+		cfg.EssentialContainerStopGrace = 0
+	} else if cfg.EssentialContainerStopGrace > maximumEssentialContainerStopGrace {
+		seelog.Warnf("Invalid value for ECS_ESSENTIAL_CONTAINER_STOP_GRACE, will be overridden with the maximum value: %s. Parsed value: %v.", maximumEssentialContainerStopGrace.String(), cfg.EssentialContainerStopGrace)
+		cfg.EssentialContainerStopGrace = maximumEssentialContainerStopGrace
+	}
+
+	if cfg.TaskCleanupConcurrency < 1 {
+		seelog.Warnf("Invalid value for ECS_ENGINE_TASK_CLEANUP_CONCURRENCY, will be overridden with the default value: %d. Parsed value: %d, minimum value: 1.", DefaultTaskCleanupConcurrency, cfg.TaskCleanupConcurrency)
+		cfg.TaskCleanupConcurrency = DefaultTaskCleanupConcurrency
+	}
+
+	if cfg.TaskContainerStartConcurrency < 1 {
+		seelog.Warnf("Invalid value for ECS_ENGINE_TASK_CONTAINER_START_CONCURRENCY, will be overridden with the default value: %d. Parsed value: %d, minimum value: 1.", DefaultTaskContainerStartConcurrency, cfg.TaskContainerStartConcurrency)
+		cfg.TaskContainerStartConcurrency = DefaultTaskContainerStartConcurrency
+	}
+
+	if cfg.MaxConcurrentImagePulls < 0 {
+		seelog.Warnf("Invalid value for ECS_MAX_CONCURRENT_IMAGE_PULLS, image pulls will not be bounded. Parsed value: %d, minimum value: 0.", cfg.MaxConcurrentImagePulls)
+		cfg.MaxConcurrentImagePulls = 0
+	}
+
+	if cfg.MaxConcurrentImagePullsPerTask < 0 {
+		seelog.Warnf("Invalid value for ECS_MAX_CONCURRENT_IMAGE_PULLS_PER_TASK, image pulls of a task will not be bounded. Parsed value: %d, minimum value: 0.", cfg.MaxConcurrentImagePullsPerTask)
+		cfg.MaxConcurrentImagePullsPerTask = 0
+	}
+
+	if cfg.ECRTokenCacheTTL < minimumECRTokenCacheTTL || cfg.ECRTokenCacheTTL > maximumECRTokenCacheTTL {
+		seelog.Warnf("Invalid value for ECS_ECR_TOKEN_CACHE_TTL, will be overridden with the default value: %s. Parsed value: %v, minimum value: %v, maximum value: %v.", DefaultECRTokenCacheTTL.String(), cfg.ECRTokenCacheTTL, minimumECRTokenCacheTTL, maximumECRTokenCacheTTL)
+		cfg.ECRTokenCacheTTL = DefaultECRTokenCacheTTL
+	}
+
+	if cfg.ContainerClockDriftCheckInterval < 0 {
+		seelog.Warnf("Invalid value for ECS_CONTAINER_CLOCK_DRIFT_CHECK_INTERVAL, container clock drift checks will be disabled. Parsed value: %v.", cfg.ContainerClockDriftCheckInterval)
+		cfg.ContainerClockDriftCheckInterval = 0
+	} else if cfg.ContainerClockDriftCheckInterval > 0 && cfg.ContainerClockDriftCheckInterval < minimumContainerClockDriftCheckInterval {
+		seelog.Warnf("Invalid value for ECS_CONTAINER_CLOCK_DRIFT_CHECK_INTERVAL, will be overridden with the minimum value: %s. Parsed value: %v.", minimumContainerClockDriftCheckInterval.String(), cfg.ContainerClockDriftCheckInterval)
+		cfg.ContainerClockDriftCheckInterval = minimumContainerClockDriftCheckInterval
+	}
+
+	if cfg.AWSVPCPauseContainerCheckInterval < 0 {
+		seelog.Warnf("Invalid value for ECS_AWSVPC_PAUSE_CONTAINER_CHECK_INTERVAL, pause container checks will be disabled. Parsed value: %v.", cfg.AWSVPCPauseContainerCheckInterval)
+		cfg.AWSVPCPauseContainerCheckInterval = 0
+	} else if cfg.AWSVPCPauseContainerCheckInterval > 0 && cfg.AWSVPCPauseContainerCheckInterval < minimumAWSVPCPauseContainerCheckInterval {
+		seelog.Warnf("Invalid value for ECS_AWSVPC_PAUSE_CONTAINER_CHECK_INTERVAL, will be overridden with the minimum value: %s. Parsed value: %v.", minimumAWSVPCPauseContainerCheckInterval.String(), cfg.AWSVPCPauseContainerCheckInterval)
+		cfg.AWSVPCPauseContainerCheckInterval = minimumAWSVPCPauseContainerCheckInterval
+	}
+
+	if cfg.DNSLatencyCheckInterval < 0 {
+		seelog.Warnf("Invalid value for ECS_DNS_LATENCY_CHECK_INTERVAL, DNS latency checks will be disabled. Parsed value: %v.", cfg.DNSLatencyCheckInterval)
+		cfg.DNSLatencyCheckInterval = 0
+	} else if cfg.DNSLatencyCheckInterval > 0 && cfg.DNSLatencyCheckInterval < minimumDNSLatencyCheckInterval {
+		seelog.Warnf("Invalid value for ECS_DNS_LATENCY_CHECK_INTERVAL, will be overridden with the minimum value: %s. Parsed value: %v.", minimumDNSLatencyCheckInterval.String(), cfg.DNSLatencyCheckInterval)
+		cfg.DNSLatencyCheckInterval = minimumDNSLatencyCheckInterval
+	}
+
+	if cfg.ContainerFDCheckInterval < 0 {
+		seelog.Warnf("Invalid value for ECS_CONTAINER_FD_CHECK_INTERVAL, file descriptor checks will be disabled. Parsed value: %v.", cfg.ContainerFDCheckInterval)
+		cfg.ContainerFDCheckInterval = 0
+	} else if cfg.ContainerFDCheckInterval > 0 && cfg.ContainerFDCheckInterval < minimumContainerFDCheckInterval {
+		seelog.Warnf("Invalid value for ECS_CONTAINER_FD_CHECK_INTERVAL, will be overridden with the minimum value: %s. Parsed value: %v.", minimumContainerFDCheckInterval.String(), cfg.ContainerFDCheckInterval)
+		cfg.ContainerFDCheckInterval = minimumContainerFDCheckInterval
+	}
+
+	if cfg.ExecAgentHealthCheckInterval < minimumExecAgentHealthCheckInterval {
+		seelog.Warnf("Invalid value for ECS_EXEC_AGENT_HEALTH_CHECK_INTERVAL, will be overridden with the default value: %s. Parsed value: %v, minimum value: %v.", DefaultExecAgentHealthCheckInterval.String(), cfg.ExecAgentHealthCheckInterval, minimumExecAgentHealthCheckInterval)
+		cfg.ExecAgentHealthCheckInterval = DefaultExecAgentHealthCheckInterval
+	}
+
+	if cfg.ExecAgentFolderPerm&minimumExecAgentFolderPerm != minimumExecAgentFolderPerm || cfg.ExecAgentFolderPerm&^maximumExecAgentFolderPerm != 0 {
+		seelog.Warnf("Invalid value for ECS_EXEC_AGENT_FOLDER_PERM, will be overridden with the default value: %#o. Parsed value: %#o, allowed values are between %#o and %#o.", DefaultExecAgentFolderPerm, cfg.ExecAgentFolderPerm, minimumExecAgentFolderPerm, maximumExecAgentFolderPerm)
+		cfg.ExecAgentFolderPerm = DefaultExecAgentFolderPerm
+	}
+
+	if cfg.ExecMaxSessionLimit < 0 {
+		seelog.Warnf("Invalid value for ECS_EXEC_MAX_SESSION_LIMIT, the number of sessions of ECS Exec will not be capped. Parsed value: %d.", cfg.ExecMaxSessionLimit)
+		cfg.ExecMaxSessionLimit = 0
+	}
+
+	if cfg.ManagedScratchDir != "" && !filepath.IsAbs(cfg.ManagedScratchDir) {
+		seelog.Warnf("Invalid value for ECS_MANAGED_SCRATCH_DIR, it must be an absolute path; containers will not be able to request a scratch directory. Parsed value: %s.", cfg.ManagedScratchDir)
+		cfg.ManagedScratchDir = ""
+	}
+
+	if cfg.ExecAuditLogFile != "" && !filepath.IsAbs(cfg.ExecAuditLogFile) {
+		seelog.Warnf("Invalid value for ECS_EXEC_AUDIT_LOG_FILE, it must be an absolute path; exec audit records will not be written. Parsed value: %s.", cfg.ExecAuditLogFile)
+		cfg.ExecAuditLogFile = ""
+	}
+
+	if cfg.ExecAuditLogMaxSizeMB <= 0 {
+		seelog.Warnf("Invalid value for ECS_EXEC_AUDIT_LOG_MAX_SIZE_MB, will be overridden with the default value: %d. Parsed value: %d.", DefaultExecAuditLogMaxSizeMB, cfg.ExecAuditLogMaxSizeMB)
+		cfg.ExecAuditLogMaxSizeMB = DefaultExecAuditLogMaxSizeMB
+	}
+
 	if cfg.ImagePullInactivityTimeout < minimumImagePullInactivityTimeout {
 		seelog.Warnf("Invalid value for image pull inactivity timeout duration, will be overridden with the default value: %s. Parsed value: %v, minimum value: %v.", defaultImagePullInactivityTimeout.String(), cfg.ImagePullInactivityTimeout, minimumImagePullInactivityTimeout)
 		cfg.ImagePullInactivityTimeout = defaultImagePullInactivityTimeout
 	}
 
+	if cfg.ImagePullProgressLogInterval > 0 && cfg.ImagePullProgressLogInterval < minimumImagePullProgressLogInterval {
+		seelog.Warnf("Invalid value for ECS_IMAGE_PULL_PROGRESS_LOG_INTERVAL, will be overridden with the minimum value: %s. Parsed value: %v.", minimumImagePullProgressLogInterval.String(), cfg.ImagePullProgressLogInterval)
+		cfg.ImagePullProgressLogInterval = minimumImagePullProgressLogInterval
+	}
+
 	if cfg.ImageCleanupInterval < minimumImageCleanupInterval {
 		seelog.Warnf("Invalid value for ECS_IMAGE_CLEANUP_INTERVAL, will be overridden with the default value: %s. Parsed value: %v, minimum value: %v.", DefaultImageCleanupTimeInterval.String(), cfg.ImageCleanupInterval, minimumImageCleanupInterval)
 		cfg.ImageCleanupInterval = DefaultImageCleanupTimeInterval
@@ -357,6 +590,92 @@ func (cfg *Config) validateAndOverrideBounds() error {
 		cfg.NumImagesToDeletePerCycle = DefaultNumImagesToDeletePerCycle
 	}
 
+	if cfg.ImageCleanupStatsHistorySize < 1 || cfg.ImageCleanupStatsHistorySize > maximumImageCleanupStatsHistorySize {
+		seelog.Warnf("Invalid value for ECS_IMAGE_CLEANUP_STATS_HISTORY_SIZE, will be overridden with the default value: %d. Parsed value: %d, maximum value: %d.", DefaultImageCleanupStatsHistorySize, cfg.ImageCleanupStatsHistorySize, maximumImageCleanupStatsHistorySize)
+		cfg.ImageCleanupStatsHistorySize = DefaultImageCleanupStatsHistorySize
+	}
+
+	if cfg.ImageRemoveFailureWarningThreshold < 1 {
+		seelog.Warnf("Invalid value for ECS_IMAGE_REMOVE_FAILURE_WARNING_THRESHOLD, will be overridden with the default value: %d. Parsed value: %d.", DefaultImageRemoveFailureWarningThreshold, cfg.ImageRemoveFailureWarningThreshold)
+		cfg.ImageRemoveFailureWarningThreshold = DefaultImageRemoveFailureWarningThreshold
+	}
+
+	if cfg.ImageCleanupMaxTrackedImages < 0 {
+		seelog.Warnf("Invalid value for ECS_IMAGE_CLEANUP_MAX_TRACKED_IMAGES, the number of tracked images will not be capped. Parsed value: %d.", cfg.ImageCleanupMaxTrackedImages)
+		cfg.ImageCleanupMaxTrackedImages = 0
+	}
+
+	if cfg.MaxTotalImageDiskBytes < 0 {
+		seelog.Warnf("Invalid value for ECS_MAX_TOTAL_IMAGE_DISK_BYTES, the disk space taken by images will not be capped. Parsed value: %d.", cfg.MaxTotalImageDiskBytes)
+		cfg.MaxTotalImageDiskBytes = 0
+	}
+
+	if cfg.MinimumImageDeletionAgeSoft < 0 || cfg.MinimumImageDeletionAgeSoft >= cfg.MinimumImageDeletionAge {
+		if cfg.MinimumImageDeletionAgeSoft != 0 {
+			seelog.Warnf("Invalid value for ECS_IMAGE_MINIMUM_CLEANUP_AGE_SOFT, ECS_IMAGE_MINIMUM_CLEANUP_AGE will be used for all image cleanups. Parsed value: %v, maximum value: %v.", cfg.MinimumImageDeletionAgeSoft, cfg.MinimumImageDeletionAge)
+		}
+		cfg.MinimumImageDeletionAgeSoft = 0
+	}
+
+	if cfg.ImageCleanupPullCooldown < 0 {
+		seelog.Warnf("Invalid value for ECS_IMAGE_CLEANUP_PULL_COOLDOWN, image cleanup will not be skipped after image pulls. Parsed value: %v.", cfg.ImageCleanupPullCooldown)
+		cfg.ImageCleanupPullCooldown = 0
+	}
+
+	if cfg.ImageCleanupStartupSettlePeriod < 0 {
+		seelog.Warnf("Invalid value for ECS_IMAGE_CLEANUP_STARTUP_SETTLE_PERIOD, images will not be protected after the agent starts. Parsed value: %v.", cfg.ImageCleanupStartupSettlePeriod)
+		cfg.ImageCleanupStartupSettlePeriod = 0
+	}
+
+	if cfg.ImageFamilyProtectionWindow < 0 {
+		seelog.Warnf("Invalid value for ECS_IMAGE_FAMILY_PROTECTION_WINDOW, images will not be protected by the task families using them. Parsed value: %v.", cfg.ImageFamilyProtectionWindow)
+		cfg.ImageFamilyProtectionWindow = 0
+	}
+
+	if cfg.ImageCleanupEscalationDiskThreshold < 0 || cfg.ImageCleanupEscalationDiskThreshold > 100 {
+		seelog.Warnf("Invalid value for ECS_IMAGE_CLEANUP_ESCALATION_DISK_THRESHOLD, expected a percentage, image cleanup will not be escalated. Parsed value: %d.", cfg.ImageCleanupEscalationDiskThreshold)
+		cfg.ImageCleanupEscalationDiskThreshold = 0
+	}
+
+	if cfg.ImageCleanupEscalationInodeThreshold < 0 || cfg.ImageCleanupEscalationInodeThreshold > 100 {
+		seelog.Warnf("Invalid value for ECS_IMAGE_CLEANUP_ESCALATION_INODE_THRESHOLD, expected a percentage, image cleanup will not be escalated on inode usage. Parsed value: %d.", cfg.ImageCleanupEscalationInodeThreshold)
+		cfg.ImageCleanupEscalationInodeThreshold = 0
+	}
+
+	if cfg.ImageCleanupEscalationMinimumAge <= 0 {
+		if cfg.ImageCleanupEscalationMinimumAge != 0 {
+			seelog.Warnf("Invalid value for ECS_IMAGE_CLEANUP_ESCALATION_MINIMUM_AGE, will be overridden with the default value: %v. Parsed value: %v.", DefaultImageCleanupEscalationMinimumAge, cfg.ImageCleanupEscalationMinimumAge)
+		}
+		cfg.ImageCleanupEscalationMinimumAge = DefaultImageCleanupEscalationMinimumAge
+	}
+	if cfg.ImageCleanupEscalationMinimumAge > cfg.MinimumImageDeletionAge {
+		// The default floor is lowered silently along with a short minimum image age
+		if cfg.ImageCleanupEscalationMinimumAge != DefaultImageCleanupEscalationMinimumAge {
+			seelog.Warnf("Invalid value for ECS_IMAGE_CLEANUP_ESCALATION_MINIMUM_AGE, will be overridden with the value of ECS_IMAGE_MINIMUM_CLEANUP_AGE: %v. Parsed value: %v.", cfg.MinimumImageDeletionAge, cfg.ImageCleanupEscalationMinimumAge)
+		}
+		cfg.ImageCleanupEscalationMinimumAge = cfg.MinimumImageDeletionAge
+	}
+
+	if cfg.ImageCleanupEscalationStoppedTaskGrace < 0 {
+		seelog.Warnf("Invalid value for ECS_IMAGE_CLEANUP_ESCALATION_STOPPED_TASK_GRACE, the images of stopped tasks will be kept until the tasks are cleaned up. Parsed value: %v.", cfg.ImageCleanupEscalationStoppedTaskGrace)
+		cfg.ImageCleanupEscalationStoppedTaskGrace = 0
+	}
+
+	if cfg.ImageLastUsedMetadataDir != "" && !filepath.IsAbs(cfg.ImageLastUsedMetadataDir) {
+		seelog.Warnf("Invalid value for ECS_IMAGE_LAST_USED_METADATA_DIR, expected an absolute path, image metadata will not be written. Parsed value: %s.", cfg.ImageLastUsedMetadataDir)
+		cfg.ImageLastUsedMetadataDir = ""
+	}
+
+	if cfg.ImagePrewarmTarballDir != "" && !filepath.IsAbs(cfg.ImagePrewarmTarballDir) {
+		seelog.Warnf("Invalid value for ECS_IMAGE_PREWARM_TARBALL_DIR, expected an absolute path, images will not be pre-warmed. Parsed value: %s.", cfg.ImagePrewarmTarballDir)
+		cfg.ImagePrewarmTarballDir = ""
+	}
+
+	if cfg.LifecycleEventsSocketPath != "" && !filepath.IsAbs(cfg.LifecycleEventsSocketPath) {
+		seelog.Warnf("Invalid value for ECS_LIFECYCLE_EVENTS_SOCKET_PATH, expected an absolute path, lifecycle events will not be streamed. Parsed value: %s.", cfg.LifecycleEventsSocketPath)
+		cfg.LifecycleEventsSocketPath = ""
+	}
+
 	if cfg.TaskMetadataSteadyStateRate <= 0 || cfg.TaskMetadataBurstRate <= 0 {
 		seelog.Warnf("Invalid values for rate limits, will be overridden with default values: %d,%d.", DefaultTaskMetadataSteadyStateRate, DefaultTaskMetadataBurstRate)
 		cfg.TaskMetadataSteadyStateRate = DefaultTaskMetadataSteadyStateRate
@@ -518,6 +837,7 @@ func environmentConfig() (Config, error) {
 	instanceAttributes, errs := parseInstanceAttributes(errs)
 
 	containerInstanceTags, errs := parseContainerInstanceTags(errs)
+	registryCACertificates, errs := parseRegistryCACertificates(errs)
 
 	additionalLocalRoutes, errs := parseAdditionalLocalRoutes(errs)
 
@@ -526,76 +846,135 @@ func environmentConfig() (Config, error) {
 		err = apierrors.NewMultiError(errs...)
 	}
 	return Config{
-		Cluster:                             os.Getenv("ECS_CLUSTER"),
-		APIEndpoint:                         os.Getenv("ECS_BACKEND_HOST"),
-		AWSRegion:                           os.Getenv("AWS_DEFAULT_REGION"),
-		DockerEndpoint:                      os.Getenv("DOCKER_HOST"),
-		ReservedPorts:                       parseReservedPorts("ECS_RESERVED_PORTS"),
-		ReservedPortsUDP:                    parseReservedPorts("ECS_RESERVED_PORTS_UDP"),
-		DataDir:                             dataDir,
-		Checkpoint:                          parseCheckpoint(dataDir),
-		EngineAuthType:                      os.Getenv("ECS_ENGINE_AUTH_TYPE"),
-		EngineAuthData:                      NewSensitiveRawMessage([]byte(os.Getenv("ECS_ENGINE_AUTH_DATA"))),
-		UpdatesEnabled:                      parseBooleanDefaultFalseConfig("ECS_UPDATES_ENABLED"),
-		UpdateDownloadDir:                   os.Getenv("ECS_UPDATE_DOWNLOAD_DIR"),
-		DisableMetrics:                      parseBooleanDefaultFalseConfig("ECS_DISABLE_METRICS"),
-		ReservedMemory:                      parseEnvVariableUint16("ECS_RESERVED_MEMORY"),
-		AvailableLoggingDrivers:             parseAvailableLoggingDrivers(),
-		PrivilegedDisabled:                  parseBooleanDefaultFalseConfig("ECS_DISABLE_PRIVILEGED"),
-		SELinuxCapable:                      parseBooleanDefaultFalseConfig("ECS_SELINUX_CAPABLE"),
-		AppArmorCapable:                     parseBooleanDefaultFalseConfig("ECS_APPARMOR_CAPABLE"),
-		TaskCleanupWaitDuration:             parseEnvVariableDuration("ECS_ENGINE_TASK_CLEANUP_WAIT_DURATION"),
-		TaskCleanupWaitDurationJitter:       parseEnvVariableDuration("ECS_ENGINE_TASK_CLEANUP_WAIT_DURATION_JITTER"),
-		TaskENIEnabled:                      parseBooleanDefaultFalseConfig("ECS_ENABLE_TASK_ENI"),
-		TaskIAMRoleEnabled:                  parseBooleanDefaultFalseConfig("ECS_ENABLE_TASK_IAM_ROLE"),
-		DeleteNonECSImagesEnabled:           parseBooleanDefaultFalseConfig("ECS_ENABLE_UNTRACKED_IMAGE_CLEANUP"),
-		TaskCPUMemLimit:                     parseBooleanDefaultTrueConfig("ECS_ENABLE_TASK_CPU_MEM_LIMIT"),
-		DockerStopTimeout:                   parseDockerStopTimeout(),
-		ContainerStartTimeout:               parseContainerStartTimeout(),
-		ContainerCreateTimeout:              parseContainerCreateTimeout(),
-		DependentContainersPullUpfront:      parseBooleanDefaultFalseConfig("ECS_PULL_DEPENDENT_CONTAINERS_UPFRONT"),
-		ImagePullInactivityTimeout:          parseImagePullInactivityTimeout(),
-		ImagePullTimeout:                    parseEnvVariableDuration("ECS_IMAGE_PULL_TIMEOUT"),
-		CredentialsAuditLogFile:             os.Getenv("ECS_AUDIT_LOGFILE"),
-		CredentialsAuditLogDisabled:         utils.ParseBool(os.Getenv("ECS_AUDIT_LOGFILE_DISABLED"), false),
-		TaskIAMRoleEnabledForNetworkHost:    utils.ParseBool(os.Getenv("ECS_ENABLE_TASK_IAM_ROLE_NETWORK_HOST"), false),
-		ImageCleanupDisabled:                parseBooleanDefaultFalseConfig("ECS_DISABLE_IMAGE_CLEANUP"),
-		MinimumImageDeletionAge:             parseEnvVariableDuration("ECS_IMAGE_MINIMUM_CLEANUP_AGE"),
-		NonECSMinimumImageDeletionAge:       parseEnvVariableDuration("NON_ECS_IMAGE_MINIMUM_CLEANUP_AGE"),
-		ImageCleanupInterval:                parseEnvVariableDuration("ECS_IMAGE_CLEANUP_INTERVAL"),
-		NumImagesToDeletePerCycle:           parseNumImagesToDeletePerCycle(),
-		NumNonECSContainersToDeletePerCycle: parseNumNonECSContainersToDeletePerCycle(),
-		ImagePullBehavior:                   parseImagePullBehavior(),
-		ImageCleanupExclusionList:           parseImageCleanupExclusionList("ECS_EXCLUDE_UNTRACKED_IMAGE"),
-		InstanceAttributes:                  instanceAttributes,
-		CNIPluginsPath:                      os.Getenv("ECS_CNI_PLUGINS_PATH"),
-		AWSVPCBlockInstanceMetdata:          parseBooleanDefaultFalseConfig("ECS_AWSVPC_BLOCK_IMDS"),
-		AWSVPCAdditionalLocalRoutes:         additionalLocalRoutes,
-		ContainerMetadataEnabled:            parseBooleanDefaultFalseConfig("ECS_ENABLE_CONTAINER_METADATA"),
-		DataDirOnHost:                       os.Getenv("ECS_HOST_DATA_DIR"),
-		OverrideAWSLogsExecutionRole:        parseBooleanDefaultFalseConfig("ECS_ENABLE_AWSLOGS_EXECUTIONROLE_OVERRIDE"),
-		CgroupPath:                          os.Getenv("ECS_CGROUP_PATH"),
-		TaskMetadataSteadyStateRate:         steadyStateRate,
-		TaskMetadataBurstRate:               burstRate,
-		SharedVolumeMatchFullConfig:         parseBooleanDefaultFalseConfig("ECS_SHARED_VOLUME_MATCH_FULL_CONFIG"),
-		ContainerInstanceTags:               containerInstanceTags,
-		ContainerInstancePropagateTagsFrom:  parseContainerInstancePropagateTagsFrom(),
-		PollMetrics:                         parseBooleanDefaultFalseConfig("ECS_POLL_METRICS"),
-		PollingMetricsWaitDuration:          parseEnvVariableDuration("ECS_POLLING_METRICS_WAIT_DURATION"),
-		DisableDockerHealthCheck:            parseBooleanDefaultFalseConfig("ECS_DISABLE_DOCKER_HEALTH_CHECK"),
-		GPUSupportEnabled:                   utils.ParseBool(os.Getenv("ECS_ENABLE_GPU_SUPPORT"), false),
-		InferentiaSupportEnabled:            utils.ParseBool(os.Getenv("ECS_ENABLE_INF_SUPPORT"), false),
-		NvidiaRuntime:                       os.Getenv("ECS_NVIDIA_RUNTIME"),
-		TaskMetadataAZDisabled:              utils.ParseBool(os.Getenv("ECS_DISABLE_TASK_METADATA_AZ"), false),
-		CgroupCPUPeriod:                     parseCgroupCPUPeriod(),
-		SpotInstanceDrainingEnabled:         parseBooleanDefaultFalseConfig("ECS_ENABLE_SPOT_INSTANCE_DRAINING"),
-		GMSACapable:                         parseGMSACapability(),
-		VolumePluginCapabilities:            parseVolumePluginCapabilities(),
-		FSxWindowsFileServerCapable:         parseFSxWindowsFileServerCapability(),
-		External:                            parseBooleanDefaultFalseConfig("ECS_EXTERNAL"),
-		EnableRuntimeStats:                  parseBooleanDefaultFalseConfig("ECS_ENABLE_RUNTIME_STATS"),
-		ShouldExcludeIPv6PortBinding:        parseBooleanDefaultTrueConfig("ECS_EXCLUDE_IPV6_PORTBINDING"),
-		WarmPoolsSupport:                    parseBooleanDefaultFalseConfig("ECS_WARM_POOLS_CHECK"),
+		Cluster:                                os.Getenv("ECS_CLUSTER"),
+		APIEndpoint:                            os.Getenv("ECS_BACKEND_HOST"),
+		AWSRegion:                              os.Getenv("AWS_DEFAULT_REGION"),
+		DockerEndpoint:                         os.Getenv("DOCKER_HOST"),
+		DockerClientAPIVersionOverride:         dockerclient.DockerVersion(strings.TrimSpace(os.Getenv("ECS_DOCKER_CLIENT_API_VERSION_OVERRIDE"))),
+		ReservedPorts:                          parseReservedPorts("ECS_RESERVED_PORTS"),
+		ReservedPortsUDP:                       parseReservedPorts("ECS_RESERVED_PORTS_UDP"),
+		DataDir:                                dataDir,
+		Checkpoint:                             parseCheckpoint(dataDir),
+		EngineAuthType:                         os.Getenv("ECS_ENGINE_AUTH_TYPE"),
+		EngineAuthData:                         NewSensitiveRawMessage([]byte(os.Getenv("ECS_ENGINE_AUTH_DATA"))),
+		UpdatesEnabled:                         parseBooleanDefaultFalseConfig("ECS_UPDATES_ENABLED"),
+		UpdateDownloadDir:                      os.Getenv("ECS_UPDATE_DOWNLOAD_DIR"),
+		DisableMetrics:                         parseBooleanDefaultFalseConfig("ECS_DISABLE_METRICS"),
+		ReservedMemory:                         parseEnvVariableUint16("ECS_RESERVED_MEMORY"),
+		AvailableLoggingDrivers:                parseAvailableLoggingDrivers(),
+		PrivilegedDisabled:                     parseBooleanDefaultFalseConfig("ECS_DISABLE_PRIVILEGED"),
+		ForceReadonlyRootFilesystem:            parseBooleanDefaultFalseConfig("ECS_FORCE_READONLY_ROOT_FILESYSTEM"),
+		ContainerDefaultUlimits:                parseContainerDefaultUlimits(),
+		HostEnvironmentFileDirs:                parseHostEnvironmentFileDirs(),
+		ManagedScratchDir:                      os.Getenv("ECS_MANAGED_SCRATCH_DIR"),
+		SELinuxCapable:                         parseBooleanDefaultFalseConfig("ECS_SELINUX_CAPABLE"),
+		AppArmorCapable:                        parseBooleanDefaultFalseConfig("ECS_APPARMOR_CAPABLE"),
+		TaskCleanupWaitDuration:                parseEnvVariableDuration("ECS_ENGINE_TASK_CLEANUP_WAIT_DURATION"),
+		TaskCleanupWaitDurationJitter:          parseEnvVariableDuration("ECS_ENGINE_TASK_CLEANUP_WAIT_DURATION_JITTER"),
+		TaskCleanupWaitDurationMaxOverride:     parseEnvVariableDuration("ECS_ENGINE_TASK_CLEANUP_WAIT_DURATION_MAX_OVERRIDE"),
+		TaskCleanupConcurrency:                 parseTaskCleanupConcurrency(),
+		MaxConcurrentImagePulls:                parseMaxConcurrentImagePulls("ECS_MAX_CONCURRENT_IMAGE_PULLS"),
+		MaxConcurrentImagePullsPerTask:         parseMaxConcurrentImagePulls("ECS_MAX_CONCURRENT_IMAGE_PULLS_PER_TASK"),
+		TaskContainerStartConcurrency:          parseTaskContainerStartConcurrency(),
+		TaskENIEnabled:                         parseBooleanDefaultFalseConfig("ECS_ENABLE_TASK_ENI"),
+		TaskIAMRoleEnabled:                     parseBooleanDefaultFalseConfig("ECS_ENABLE_TASK_IAM_ROLE"),
+		DeleteNonECSImagesEnabled:              parseBooleanDefaultFalseConfig("ECS_ENABLE_UNTRACKED_IMAGE_CLEANUP"),
+		TaskCPUMemLimit:                        parseBooleanDefaultTrueConfig("ECS_ENABLE_TASK_CPU_MEM_LIMIT"),
+		DockerStopTimeout:                      parseDockerStopTimeout(),
+		ContainerStopEscalation:                parseBooleanDefaultFalseConfig("ECS_ENABLE_CONTAINER_STOP_ESCALATION"),
+		StopContainersOnShutdown:               parseBooleanDefaultFalseConfig("ECS_STOP_CONTAINERS_ON_SHUTDOWN"),
+		ShutdownStopTimeout:                    parseEnvVariableDuration("ECS_SHUTDOWN_STOP_TIMEOUT"),
+		EssentialContainerStopGrace:            parseEnvVariableDuration("ECS_ESSENTIAL_CONTAINER_STOP_GRACE"),
+		ContainerStartTimeout:                  parseContainerStartTimeout(),
+		ContainerCreateTimeout:                 parseContainerCreateTimeout(),
+		DependentContainersPullUpfront:         parseBooleanDefaultFalseConfig("ECS_PULL_DEPENDENT_CONTAINERS_UPFRONT"),
+		UseCachedImageOnPullFailure:            parseBooleanDefaultFalseConfig("ECS_USE_CACHED_IMAGE_ON_PULL_FAILURE"),
+		ImagePullInactivityTimeout:             parseImagePullInactivityTimeout(),
+		ECRTokenCacheTTL:                       parseEnvVariableDuration("ECS_ECR_TOKEN_CACHE_TTL"),
+		ContainerClockDriftCheckInterval:       parseEnvVariableDuration("ECS_CONTAINER_CLOCK_DRIFT_CHECK_INTERVAL"),
+		AWSVPCPauseContainerCheckInterval:      parseEnvVariableDuration("ECS_AWSVPC_PAUSE_CONTAINER_CHECK_INTERVAL"),
+		DNSLatencyCheckInterval:                parseEnvVariableDuration("ECS_DNS_LATENCY_CHECK_INTERVAL"),
+		ContainerFDCheckInterval:               parseEnvVariableDuration("ECS_CONTAINER_FD_CHECK_INTERVAL"),
+		ImagePullTimeout:                       parseEnvVariableDuration("ECS_IMAGE_PULL_TIMEOUT"),
+		ImagePullTimeoutOverrides:              parseImagePullTimeoutOverrides(),
+		ImagePullRateLimit:                     parseImagePullRateLimit(),
+		ImagePullRateLimitOverrides:            parseImagePullRateLimitOverrides(),
+		ImagePullProgressLogInterval:           parseEnvVariableDuration("ECS_IMAGE_PULL_PROGRESS_LOG_INTERVAL"),
+		ImagePullMirrors:                       parseImagePullMirrors(),
+		CredentialsAuditLogFile:                os.Getenv("ECS_AUDIT_LOGFILE"),
+		CredentialsAuditLogDisabled:            utils.ParseBool(os.Getenv("ECS_AUDIT_LOGFILE_DISABLED"), false),
+		TaskIAMRoleEnabledForNetworkHost:       utils.ParseBool(os.Getenv("ECS_ENABLE_TASK_IAM_ROLE_NETWORK_HOST"), false),
+		ImageCleanupDisabled:                   parseBooleanDefaultFalseConfig("ECS_DISABLE_IMAGE_CLEANUP"),
+		MinimumImageDeletionAge:                parseEnvVariableDuration("ECS_IMAGE_MINIMUM_CLEANUP_AGE"),
+		MinimumImageDeletionAgeSoft:            parseEnvVariableDuration("ECS_IMAGE_MINIMUM_CLEANUP_AGE_SOFT"),
+		NonECSMinimumImageDeletionAge:          parseEnvVariableDuration("NON_ECS_IMAGE_MINIMUM_CLEANUP_AGE"),
+		ImageCleanupInterval:                   parseEnvVariableDuration("ECS_IMAGE_CLEANUP_INTERVAL"),
+		NumImagesToDeletePerCycle:              parseNumImagesToDeletePerCycle(),
+		NumNonECSContainersToDeletePerCycle:    parseNumNonECSContainersToDeletePerCycle(),
+		ImageCleanupStatsHistorySize:           parseImageCleanupStatsHistorySize(),
+		ImageRemoveFailureWarningThreshold:     parseImageRemoveFailureWarningThreshold(),
+		ImageLastUsedMetadataDir:               os.Getenv("ECS_IMAGE_LAST_USED_METADATA_DIR"),
+		ImagePrewarmTarballDir:                 os.Getenv("ECS_IMAGE_PREWARM_TARBALL_DIR"),
+		ImageCleanupPrioritizeSize:             parseBooleanDefaultFalseConfig("ECS_IMAGE_CLEANUP_PRIORITIZE_SIZE"),
+		ImageCleanupMaxTrackedImages:           parseImageCleanupMaxTrackedImages(),
+		MaxTotalImageDiskBytes:                 parseMaxTotalImageDiskBytes(),
+		ImageCleanupPullCooldown:               parseEnvVariableDuration("ECS_IMAGE_CLEANUP_PULL_COOLDOWN"),
+		ImageFamilyProtectionWindow:            parseEnvVariableDuration("ECS_IMAGE_FAMILY_PROTECTION_WINDOW"),
+		ImageCleanupStartupSettlePeriod:        parseEnvVariableDuration("ECS_IMAGE_CLEANUP_STARTUP_SETTLE_PERIOD"),
+		ImageCleanupEscalationDiskThreshold:    parseImageCleanupEscalationDiskThreshold(),
+		ImageCleanupEscalationInodeThreshold:   parseImageCleanupEscalationInodeThreshold(),
+		ImageCleanupEscalationMinimumAge:       parseEnvVariableDuration("ECS_IMAGE_CLEANUP_ESCALATION_MINIMUM_AGE"),
+		ImageCleanupEscalationDiskPath:         os.Getenv("ECS_IMAGE_CLEANUP_ESCALATION_DISK_PATH"),
+		ImageCleanupEscalationStoppedTaskGrace: parseEnvVariableDuration("ECS_IMAGE_CLEANUP_ESCALATION_STOPPED_TASK_GRACE"),
+		ImageCleanupWindow:                     parseImageCleanupWindow(),
+		ImageQuarantineStopTasks:               parseBooleanDefaultFalseConfig("ECS_IMAGE_QUARANTINE_STOP_TASKS"),
+		ImagePullBehavior:                      parseImagePullBehavior(),
+		ImageCleanupExclusionList:              parseImageCleanupExclusionList("ECS_EXCLUDE_UNTRACKED_IMAGE"),
+		InstanceAttributes:                     instanceAttributes,
+		CNIPluginsPath:                         os.Getenv("ECS_CNI_PLUGINS_PATH"),
+		AWSVPCBlockInstanceMetdata:             parseBooleanDefaultFalseConfig("ECS_AWSVPC_BLOCK_IMDS"),
+		AWSVPCAdditionalLocalRoutes:            additionalLocalRoutes,
+		ContainerMetadataEnabled:               parseBooleanDefaultFalseConfig("ECS_ENABLE_CONTAINER_METADATA"),
+		DataDirOnHost:                          os.Getenv("ECS_HOST_DATA_DIR"),
+		OverrideAWSLogsExecutionRole:           parseBooleanDefaultFalseConfig("ECS_ENABLE_AWSLOGS_EXECUTIONROLE_OVERRIDE"),
+		CgroupPath:                             os.Getenv("ECS_CGROUP_PATH"),
+		TaskMetadataSteadyStateRate:            steadyStateRate,
+		TaskMetadataBurstRate:                  burstRate,
+		SharedVolumeMatchFullConfig:            parseBooleanDefaultFalseConfig("ECS_SHARED_VOLUME_MATCH_FULL_CONFIG"),
+		ContainerInstanceTags:                  containerInstanceTags,
+		RegistryCACertificates:                 registryCACertificates,
+		ContainerInstancePropagateTagsFrom:     parseContainerInstancePropagateTagsFrom(),
+		PollMetrics:                            parseBooleanDefaultFalseConfig("ECS_POLL_METRICS"),
+		PollingMetricsWaitDuration:             parseEnvVariableDuration("ECS_POLLING_METRICS_WAIT_DURATION"),
+		DisableDockerHealthCheck:               parseBooleanDefaultFalseConfig("ECS_DISABLE_DOCKER_HEALTH_CHECK"),
+		GPUSupportEnabled:                      utils.ParseBool(os.Getenv("ECS_ENABLE_GPU_SUPPORT"), false),
+		InferentiaSupportEnabled:               utils.ParseBool(os.Getenv("ECS_ENABLE_INF_SUPPORT"), false),
+		NvidiaRuntime:                          os.Getenv("ECS_NVIDIA_RUNTIME"),
+		TaskMetadataAZDisabled:                 utils.ParseBool(os.Getenv("ECS_DISABLE_TASK_METADATA_AZ"), false),
+		CgroupCPUPeriod:                        parseCgroupCPUPeriod(),
+		SpotInstanceDrainingEnabled:            parseBooleanDefaultFalseConfig("ECS_ENABLE_SPOT_INSTANCE_DRAINING"),
+		SpotInterruptionTaskStopEnabled:        parseBooleanDefaultFalseConfig("ECS_ENABLE_SPOT_INTERRUPTION_TASK_STOP"),
+		CPUStealReportingEnabled:               parseBooleanDefaultFalseConfig("ECS_ENABLE_CPU_STEAL_REPORTING"),
+		LifecycleEventsSocketPath:              os.Getenv("ECS_LIFECYCLE_EVENTS_SOCKET_PATH"),
+		GMSACapable:                            parseGMSACapability(),
+		VolumePluginCapabilities:               parseVolumePluginCapabilities(),
+		FSxWindowsFileServerCapable:            parseFSxWindowsFileServerCapability(),
+		External:                               parseBooleanDefaultFalseConfig("ECS_EXTERNAL"),
+		EnableRuntimeStats:                     parseBooleanDefaultFalseConfig("ECS_ENABLE_RUNTIME_STATS"),
+		IntrospectionContainerLogsEnabled:      parseBooleanDefaultFalseConfig("ECS_ENABLE_INTROSPECTION_CONTAINER_LOGS"),
+		IntrospectionImageQuarantineEnabled:    parseBooleanDefaultFalseConfig("ECS_ENABLE_INTROSPECTION_IMAGE_QUARANTINE"),
+		ShouldExcludeIPv6PortBinding:           parseBooleanDefaultTrueConfig("ECS_EXCLUDE_IPV6_PORTBINDING"),
+		WarmPoolsSupport:                       parseBooleanDefaultFalseConfig("ECS_WARM_POOLS_CHECK"),
+		ExecAgentCmdUser:                       os.Getenv("ECS_EXEC_AGENT_USER"),
+		ExecInitFailureWarning:                 parseBooleanDefaultFalseConfig("ECS_EXEC_INIT_FAILURE_WARNING"),
+		ExecAgentHealthCheckInterval:           parseEnvVariableDuration("ECS_EXEC_AGENT_HEALTH_CHECK_INTERVAL"),
+		ExecAgentFolderPerm:                    parseExecAgentFolderPerm(),
+		ExecAgentHostLogDir:                    os.Getenv("ECS_EXEC_AGENT_HOST_LOG_DIR"),
+		ExecAgentRemoveSupersededConfigs:       parseBooleanDefaultFalseConfig("ECS_EXEC_AGENT_REMOVE_SUPERSEDED_CONFIGS"),
+		ExecSessionLimitFromTaskTags:           parseBooleanDefaultFalseConfig("ECS_EXEC_SESSION_LIMIT_FROM_TASK_TAGS"),
+		ExecMaxSessionLimit:                    parseExecMaxSessionLimit(),
+		ExecAuditLogFile:                       os.Getenv("ECS_EXEC_AUDIT_LOG_FILE"),
+		ExecAuditLogMaxSizeMB:                  parseExecAuditLogMaxSizeMB(),
 	}, err
 }
 
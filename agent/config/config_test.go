@@ -17,9 +17,13 @@
 package config
 
 import (
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -28,6 +32,7 @@ import (
 	mock_ec2 "github.com/aws/amazon-ecs-agent/agent/ec2/mocks"
 
 	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/docker/go-units"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -127,6 +132,7 @@ func TestEnvironmentConfig(t *testing.T) {
 	defer setTestEnv("ECS_RESERVED_PORTS_UDP", "[42,99]")()
 	defer setTestEnv("ECS_RESERVED_MEMORY", "20")()
 	defer setTestEnv("ECS_CONTAINER_STOP_TIMEOUT", "60s")()
+	defer setTestEnv("ECS_ENABLE_CONTAINER_STOP_ESCALATION", "true")()
 	defer setTestEnv("ECS_CONTAINER_START_TIMEOUT", "5m")()
 	defer setTestEnv("ECS_CONTAINER_CREATE_TIMEOUT", "4m")()
 	defer setTestEnv("ECS_IMAGE_PULL_INACTIVITY_TIMEOUT", "10m")()
@@ -160,6 +166,8 @@ func TestEnvironmentConfig(t *testing.T) {
 	defer setTestEnv("ECS_ENABLE_RUNTIME_STATS", "true")()
 	defer setTestEnv("ECS_EXCLUDE_IPV6_PORTBINDING", "true")()
 	defer setTestEnv("ECS_WARM_POOLS_CHECK", "false")()
+	defer setTestEnv("ECS_EXEC_AGENT_USER", "1000:1000")()
+	defer setTestEnv("ECS_EXEC_INIT_FAILURE_WARNING", "true")()
 	additionalLocalRoutesJSON := `["1.2.3.4/22","5.6.7.8/32"]`
 	setTestEnv("ECS_AWSVPC_ADDITIONAL_LOCAL_ROUTES", additionalLocalRoutesJSON)
 	setTestEnv("ECS_ENABLE_CONTAINER_METADATA", "true")
@@ -176,6 +184,7 @@ func TestEnvironmentConfig(t *testing.T) {
 	assert.Equal(t, uint16(20), conf.ReservedMemory)
 	expectedDurationDockerStopTimeout, _ := time.ParseDuration("60s")
 	assert.Equal(t, expectedDurationDockerStopTimeout, conf.DockerStopTimeout)
+	assert.True(t, conf.ContainerStopEscalation.Enabled(), "Wrong value for ContainerStopEscalation")
 	expectedDurationContainerStartTimeout, _ := time.ParseDuration("5m")
 	assert.Equal(t, expectedDurationContainerStartTimeout, conf.ContainerStartTimeout)
 	expectedDurationContainerCreateTimeout, _ := time.ParseDuration("4m")
@@ -219,6 +228,8 @@ func TestEnvironmentConfig(t *testing.T) {
 	assert.True(t, conf.EnableRuntimeStats.Enabled(), "Wrong value for EnableRuntimeStats")
 	assert.True(t, conf.ShouldExcludeIPv6PortBinding.Enabled(), "Wrong value for ShouldExcludeIPv6PortBinding")
 	assert.False(t, conf.WarmPoolsSupport.Enabled(), "Wrong value for WarmPoolsSupport")
+	assert.Equal(t, "1000:1000", conf.ExecAgentCmdUser, "Wrong value for ExecAgentCmdUser")
+	assert.True(t, conf.ExecInitFailureWarning.Enabled(), "Wrong value for ExecInitFailureWarning")
 }
 
 func TestTrimWhitespaceWhenCreating(t *testing.T) {
@@ -248,11 +259,17 @@ func TestConfigBoolean(t *testing.T) {
 	defer setTestEnv("ECS_DISABLE_DOCKER_HEALTH_CHECK", "true")()
 	defer setTestEnv("ECS_DISABLE_METRICS", "true")()
 	defer setTestEnv("ECS_ENABLE_SPOT_INSTANCE_DRAINING", "true")()
+	defer setTestEnv("ECS_ENABLE_SPOT_INTERRUPTION_TASK_STOP", "true")()
+	defer setTestEnv("ECS_ENABLE_CPU_STEAL_REPORTING", "true")()
+	defer setTestEnv("ECS_IMAGE_QUARANTINE_STOP_TASKS", "true")()
 	cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
 	assert.NoError(t, err)
 	assert.True(t, cfg.DisableMetrics.Enabled())
 	assert.True(t, cfg.DisableDockerHealthCheck.Enabled())
 	assert.True(t, cfg.SpotInstanceDrainingEnabled.Enabled())
+	assert.True(t, cfg.SpotInterruptionTaskStopEnabled.Enabled())
+	assert.True(t, cfg.CPUStealReportingEnabled.Enabled())
+	assert.True(t, cfg.ImageQuarantineStopTasks.Enabled())
 }
 
 func TestBadLoggingDriverSerialization(t *testing.T) {
@@ -547,6 +564,14 @@ func TestDeleteNonECSImagesEnabled(t *testing.T) {
 	assert.Equal(t, ExplicitlyEnabled, cfg.DeleteNonECSImagesEnabled.Value, "Wrong value for DeleteNonECSImagesEnabled")
 }
 
+func TestImageCleanupPrioritizeSize(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_IMAGE_CLEANUP_PRIORITIZE_SIZE", "true")()
+	cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	assert.NoError(t, err)
+	assert.True(t, cfg.ImageCleanupPrioritizeSize.Enabled(), "Wrong value for ImageCleanupPrioritizeSize")
+}
+
 func TestTaskIAMRoleForHostNetworkEnabled(t *testing.T) {
 	defer setTestRegion()()
 	defer setTestEnv("ECS_ENABLE_TASK_IAM_ROLE_NETWORK_HOST", "true")()
@@ -588,6 +613,563 @@ func TestImageCleanupMinimumNumImagesToDeletePerCycle(t *testing.T) {
 	assert.Equal(t, DefaultNumImagesToDeletePerCycle, cfg.NumImagesToDeletePerCycle, "Wrong value for NumImagesToDeletePerCycle")
 }
 
+func TestECRTokenCacheTTL(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected time.Duration
+	}{
+		{envValue: "", expected: DefaultECRTokenCacheTTL},
+		{envValue: "30m", expected: 30 * time.Minute},
+		{envValue: "1s", expected: DefaultECRTokenCacheTTL},
+		{envValue: "12h", expected: DefaultECRTokenCacheTTL},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_ECR_TOKEN_CACHE_TTL", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.ECRTokenCacheTTL)
+		})
+	}
+}
+
+func TestContainerClockDriftCheckInterval(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected time.Duration
+	}{
+		{envValue: "", expected: 0},
+		{envValue: "5m", expected: 5 * time.Minute},
+		{envValue: "10s", expected: minimumContainerClockDriftCheckInterval},
+		{envValue: "-5m", expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_CONTAINER_CLOCK_DRIFT_CHECK_INTERVAL", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.ContainerClockDriftCheckInterval)
+		})
+	}
+}
+
+func TestAWSVPCPauseContainerCheckInterval(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected time.Duration
+	}{
+		{envValue: "", expected: 0},
+		{envValue: "2m", expected: 2 * time.Minute},
+		{envValue: "1s", expected: minimumAWSVPCPauseContainerCheckInterval},
+		{envValue: "-1m", expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_AWSVPC_PAUSE_CONTAINER_CHECK_INTERVAL", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.AWSVPCPauseContainerCheckInterval)
+		})
+	}
+}
+
+func TestExecAgentHealthCheckInterval(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected time.Duration
+	}{
+		{envValue: "", expected: DefaultExecAgentHealthCheckInterval},
+		{envValue: "30s", expected: 30 * time.Second},
+		{envValue: "1s", expected: DefaultExecAgentHealthCheckInterval},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_EXEC_AGENT_HEALTH_CHECK_INTERVAL", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.ExecAgentHealthCheckInterval)
+		})
+	}
+}
+
+func TestExecAgentFolderPerm(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected os.FileMode
+	}{
+		{envValue: "", expected: DefaultExecAgentFolderPerm},
+		{envValue: "0700", expected: 0700},
+		{envValue: "750", expected: 0750},
+		{envValue: "0777", expected: DefaultExecAgentFolderPerm},
+		{envValue: "0600", expected: DefaultExecAgentFolderPerm},
+		{envValue: "rwx", expected: DefaultExecAgentFolderPerm},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_EXEC_AGENT_FOLDER_PERM", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.ExecAgentFolderPerm)
+		})
+	}
+}
+
+func TestExecAuditLog(t *testing.T) {
+	auditLogFile := filepath.Join(os.TempDir(), "exec-audit.log")
+	testCases := []struct {
+		name            string
+		file            string
+		maxSizeMB       string
+		expectedFile    string
+		expectedMaxSize int
+	}{
+		{name: "unset", expectedMaxSize: DefaultExecAuditLogMaxSizeMB},
+		{name: "absolute path", file: auditLogFile, maxSizeMB: "50", expectedFile: auditLogFile, expectedMaxSize: 50},
+		{name: "relative path", file: "exec-audit.log", expectedMaxSize: DefaultExecAuditLogMaxSizeMB},
+		{name: "invalid max size", file: auditLogFile, maxSizeMB: "0", expectedFile: auditLogFile, expectedMaxSize: DefaultExecAuditLogMaxSizeMB},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_EXEC_AUDIT_LOG_FILE", tc.file)()
+			defer setTestEnv("ECS_EXEC_AUDIT_LOG_MAX_SIZE_MB", tc.maxSizeMB)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedFile, cfg.ExecAuditLogFile)
+			assert.Equal(t, tc.expectedMaxSize, cfg.ExecAuditLogMaxSizeMB)
+		})
+	}
+}
+
+func TestExecMaxSessionLimit(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected int
+	}{
+		{envValue: "", expected: 0},
+		{envValue: "5", expected: 5},
+		{envValue: "-1", expected: 0},
+		{envValue: "many", expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_EXEC_MAX_SESSION_LIMIT", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.ExecMaxSessionLimit)
+		})
+	}
+}
+
+func TestTaskCleanupWaitDurationMaxOverride(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected time.Duration
+	}{
+		{envValue: "", expected: DefaultTaskCleanupWaitDurationMaxOverride},
+		{envValue: "48h", expected: 48 * time.Hour},
+		{envValue: "1ms", expected: DefaultTaskCleanupWaitDurationMaxOverride},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_ENGINE_TASK_CLEANUP_WAIT_DURATION_MAX_OVERRIDE", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.TaskCleanupWaitDurationMaxOverride)
+		})
+	}
+}
+
+func TestStopContainersOnShutdown(t *testing.T) {
+	testCases := []struct {
+		enabledEnvValue string
+		timeoutEnvValue string
+		expectedEnabled bool
+		expectedTimeout time.Duration
+	}{
+		{enabledEnvValue: "", timeoutEnvValue: "", expectedEnabled: false, expectedTimeout: DefaultShutdownStopTimeout},
+		{enabledEnvValue: "true", timeoutEnvValue: "50s", expectedEnabled: true, expectedTimeout: 50 * time.Second},
+		{enabledEnvValue: "true", timeoutEnvValue: "5m", expectedEnabled: true, expectedTimeout: maximumShutdownStopTimeout},
+		{enabledEnvValue: "true", timeoutEnvValue: "-5s", expectedEnabled: true, expectedTimeout: DefaultShutdownStopTimeout},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.enabledEnvValue+"/"+tc.timeoutEnvValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_STOP_CONTAINERS_ON_SHUTDOWN", tc.enabledEnvValue)()
+			defer setTestEnv("ECS_SHUTDOWN_STOP_TIMEOUT", tc.timeoutEnvValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedEnabled, cfg.StopContainersOnShutdown.Enabled())
+			assert.Equal(t, tc.expectedTimeout, cfg.ShutdownStopTimeout)
+		})
+	}
+}
+
+func TestEssentialContainerStopGrace(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected time.Duration
+	}{
+		{envValue: "", expected: 0},
+		{envValue: "10s", expected: 10 * time.Second},
+		{envValue: "-10s", expected: 0},
+		{envValue: "1h", expected: maximumEssentialContainerStopGrace},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_ESSENTIAL_CONTAINER_STOP_GRACE", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.EssentialContainerStopGrace)
+		})
+	}
+}
+
+func TestImageCleanupStartupSettlePeriod(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected time.Duration
+	}{
+		{envValue: "", expected: 0},
+		{envValue: "1h", expected: time.Hour},
+		{envValue: "-1h", expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_IMAGE_CLEANUP_STARTUP_SETTLE_PERIOD", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.ImageCleanupStartupSettlePeriod)
+		})
+	}
+}
+
+func TestRegistryCACertificates(t *testing.T) {
+	server := httptest.NewTLSServer(nil)
+	defer server.Close()
+	caFile := filepath.Join(t.TempDir(), "registry-ca.pem")
+	require.NoError(t, os.WriteFile(caFile,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600))
+	invalidCAFile := filepath.Join(t.TempDir(), "invalid-ca.pem")
+	require.NoError(t, os.WriteFile(invalidCAFile, []byte("not a certificate"), 0600))
+
+	t.Run("valid CA file", func(t *testing.T) {
+		defer setTestRegion()()
+		defer setTestEnv("ECS_REGISTRY_CA_CERTIFICATES", `{"registry.example.com": "`+caFile+`"}`)()
+		cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"registry.example.com": caFile}, cfg.RegistryCACertificates)
+		pools, err := cfg.RegistryCertPools()
+		require.NoError(t, err)
+		require.Contains(t, pools, "registry.example.com")
+		_, err = server.Certificate().Verify(x509.VerifyOptions{Roots: pools["registry.example.com"]})
+		assert.NoError(t, err)
+	})
+	for name, env := range map[string]string{
+		"missing CA file": `{"registry.example.com": "` + filepath.Join(t.TempDir(), "missing.pem") + `"}`,
+		"invalid CA file": `{"registry.example.com": "` + invalidCAFile + `"}`,
+		"invalid format":  `["registry.example.com"]`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_REGISTRY_CA_CERTIFICATES", env)()
+			_, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestImageCleanupStatsHistorySize(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected int
+	}{
+		{envValue: "", expected: DefaultImageCleanupStatsHistorySize},
+		{envValue: "20", expected: 20},
+		{envValue: "-1", expected: DefaultImageCleanupStatsHistorySize},
+		{envValue: "1000", expected: DefaultImageCleanupStatsHistorySize},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_IMAGE_CLEANUP_STATS_HISTORY_SIZE", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.ImageCleanupStatsHistorySize, "Wrong value for ImageCleanupStatsHistorySize")
+		})
+	}
+}
+
+func TestImageRemoveFailureWarningThreshold(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected int
+	}{
+		{envValue: "", expected: DefaultImageRemoveFailureWarningThreshold},
+		{envValue: "5", expected: 5},
+		{envValue: "-1", expected: DefaultImageRemoveFailureWarningThreshold},
+		{envValue: "many", expected: DefaultImageRemoveFailureWarningThreshold},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_IMAGE_REMOVE_FAILURE_WARNING_THRESHOLD", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.ImageRemoveFailureWarningThreshold, "Wrong value for ImageRemoveFailureWarningThreshold")
+		})
+	}
+}
+
+func TestImageCleanupMaxTrackedImages(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected int
+	}{
+		{envValue: "", expected: 0},
+		{envValue: "500", expected: 500},
+		{envValue: "-1", expected: 0},
+		{envValue: "many", expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_IMAGE_CLEANUP_MAX_TRACKED_IMAGES", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.ImageCleanupMaxTrackedImages)
+		})
+	}
+}
+
+func TestContainerDefaultUlimits(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected []*units.Ulimit
+	}{
+		{envValue: "", expected: nil},
+		{
+			envValue: "nofile=65536:65536, nproc=4096",
+			expected: []*units.Ulimit{{Name: "nofile", Soft: 65536, Hard: 65536}, {Name: "nproc", Soft: 4096, Hard: 4096}},
+		},
+		{envValue: "nofile=8192:4096,nproc=4096", expected: []*units.Ulimit{{Name: "nproc", Soft: 4096, Hard: 4096}}},
+		{envValue: "openfiles=1024,nofile=1024,nofile=2048", expected: []*units.Ulimit{{Name: "nofile", Soft: 1024, Hard: 1024}}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_CONTAINER_DEFAULT_ULIMITS", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.ContainerDefaultUlimits)
+		})
+	}
+}
+
+func TestMaxTotalImageDiskBytes(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected int64
+	}{
+		{envValue: "", expected: 0},
+		{envValue: "53687091200", expected: 50 * 1024 * 1024 * 1024},
+		{envValue: "-1", expected: 0},
+		{envValue: "50GB", expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_MAX_TOTAL_IMAGE_DISK_BYTES", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.MaxTotalImageDiskBytes)
+		})
+	}
+}
+
+func TestMinimumImageDeletionAgeSoft(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected time.Duration
+	}{
+		{envValue: "", expected: 0},
+		{envValue: "10m", expected: 10 * time.Minute},
+		{envValue: "-1m", expected: 0},
+		{envValue: "2h", expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_IMAGE_MINIMUM_CLEANUP_AGE", "1h")()
+			defer setTestEnv("ECS_IMAGE_MINIMUM_CLEANUP_AGE_SOFT", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.MinimumImageDeletionAgeSoft)
+		})
+	}
+}
+
+func TestImageCleanupPullCooldown(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected time.Duration
+	}{
+		{envValue: "", expected: 0},
+		{envValue: "15m", expected: 15 * time.Minute},
+		{envValue: "-1m", expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_IMAGE_CLEANUP_PULL_COOLDOWN", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.ImageCleanupPullCooldown)
+		})
+	}
+}
+
+func TestImageCleanupEscalationMinimumAge(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected time.Duration
+	}{
+		{envValue: "", expected: DefaultImageCleanupEscalationMinimumAge},
+		{envValue: "15m", expected: 15 * time.Minute},
+		{envValue: "-1m", expected: DefaultImageCleanupEscalationMinimumAge},
+		{envValue: "2h", expected: DefaultImageDeletionAge},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_IMAGE_CLEANUP_ESCALATION_MINIMUM_AGE", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.ImageCleanupEscalationMinimumAge)
+		})
+	}
+}
+
+func TestImageCleanupEscalationStoppedTaskGrace(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected time.Duration
+	}{
+		{envValue: "", expected: 0},
+		{envValue: "5m", expected: 5 * time.Minute},
+		{envValue: "-1m", expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_IMAGE_CLEANUP_ESCALATION_STOPPED_TASK_GRACE", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.ImageCleanupEscalationStoppedTaskGrace)
+		})
+	}
+}
+
+func TestImageFamilyProtectionWindow(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected time.Duration
+	}{
+		{envValue: "", expected: 0},
+		{envValue: "168h", expected: 168 * time.Hour},
+		{envValue: "-1h", expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_IMAGE_FAMILY_PROTECTION_WINDOW", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.ImageFamilyProtectionWindow)
+		})
+	}
+}
+
+func TestDockerClientAPIVersionOverride(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_DOCKER_CLIENT_API_VERSION_OVERRIDE", " 1.32 ")()
+	cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	assert.NoError(t, err)
+	assert.Equal(t, dockerclient.Version_1_32, cfg.DockerClientAPIVersionOverride)
+}
+
+func TestImageLastUsedMetadataDir(t *testing.T) {
+	metadataDir := filepath.Join(os.TempDir(), "image-metadata")
+	testCases := []struct {
+		envValue string
+		expected string
+	}{
+		{envValue: "", expected: ""},
+		{envValue: metadataDir, expected: metadataDir},
+		{envValue: "image-metadata", expected: ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_IMAGE_LAST_USED_METADATA_DIR", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.ImageLastUsedMetadataDir)
+		})
+	}
+}
+
+func TestImagePrewarmTarballDir(t *testing.T) {
+	tarballDir := filepath.Join(os.TempDir(), "image-tarballs")
+	testCases := []struct {
+		envValue string
+		expected string
+	}{
+		{envValue: "", expected: ""},
+		{envValue: tarballDir, expected: tarballDir},
+		{envValue: "image-tarballs", expected: ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_IMAGE_PREWARM_TARBALL_DIR", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.ImagePrewarmTarballDir)
+		})
+	}
+}
+
+func TestLifecycleEventsSocketPath(t *testing.T) {
+	socketPath := filepath.Join(os.TempDir(), "lifecycle-events.sock")
+	testCases := []struct {
+		envValue string
+		expected string
+	}{
+		{envValue: "", expected: ""},
+		{envValue: socketPath, expected: socketPath},
+		{envValue: "lifecycle-events.sock", expected: ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_LIFECYCLE_EVENTS_SOCKET_PATH", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.LifecycleEventsSocketPath)
+		})
+	}
+}
+
 func TestInvalidImagePullBehavior(t *testing.T) {
 	defer setTestRegion()()
 	defer setTestEnv("ECS_IMAGE_PULL_BEHAVIOR", "invalid")()
@@ -612,6 +1194,30 @@ func TestEnableRuntimeStatsConfigEnabled(t *testing.T) {
 	assert.True(t, cfg.EnableRuntimeStats.Enabled(), "Wrong value for EnableRuntimeStats")
 }
 
+func TestIntrospectionContainerLogsEnabled(t *testing.T) {
+	defer setTestRegion()()
+	cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	assert.NoError(t, err)
+	assert.False(t, cfg.IntrospectionContainerLogsEnabled.Enabled(), "Wrong default value for IntrospectionContainerLogsEnabled")
+
+	defer setTestEnv("ECS_ENABLE_INTROSPECTION_CONTAINER_LOGS", "true")()
+	cfg, err = NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	assert.NoError(t, err)
+	assert.True(t, cfg.IntrospectionContainerLogsEnabled.Enabled(), "Wrong value for IntrospectionContainerLogsEnabled")
+}
+
+func TestIntrospectionImageQuarantineEnabled(t *testing.T) {
+	defer setTestRegion()()
+	cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	assert.NoError(t, err)
+	assert.False(t, cfg.IntrospectionImageQuarantineEnabled.Enabled(), "Wrong default value for IntrospectionImageQuarantineEnabled")
+
+	defer setTestEnv("ECS_ENABLE_INTROSPECTION_IMAGE_QUARANTINE", "true")()
+	cfg, err = NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	assert.NoError(t, err)
+	assert.True(t, cfg.IntrospectionImageQuarantineEnabled.Enabled(), "Wrong value for IntrospectionImageQuarantineEnabled")
+}
+
 func TestParseImagePullBehavior(t *testing.T) {
 	testcases := []struct {
 		name                      string
@@ -893,3 +1499,267 @@ func setTestEnv(k, v string) func() {
 		os.Unsetenv(k)
 	}
 }
+
+func TestImagePullProgressLogInterval(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected time.Duration
+	}{
+		{envValue: "", expected: DefaultImagePullProgressLogInterval},
+		{envValue: "10s", expected: 10 * time.Second},
+		{envValue: "100ms", expected: minimumImagePullProgressLogInterval},
+		{envValue: "-1s", expected: -1 * time.Second},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_IMAGE_PULL_PROGRESS_LOG_INTERVAL", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.ImagePullProgressLogInterval)
+		})
+	}
+}
+
+func TestImagePullMirrors(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected []string
+	}{
+		{envValue: "", expected: nil},
+		{envValue: "mirror-a.example.com", expected: []string{"mirror-a.example.com"}},
+		{
+			envValue: " https://mirror-a.example.com/, ,mirror-b.example.com:5000",
+			expected: []string{"mirror-a.example.com", "mirror-b.example.com:5000"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_IMAGE_PULL_MIRRORS", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.ImagePullMirrors)
+		})
+	}
+}
+
+func TestImagePullTimeoutOverrides(t *testing.T) {
+	testCases := []struct {
+		name     string
+		envValue string
+		expected map[string]time.Duration
+	}{
+		{name: "unset", envValue: "", expected: nil},
+		{name: "invalid json", envValue: "public.ecr.aws/ml=4h", expected: nil},
+		{
+			name:     "valid overrides",
+			envValue: `{"public.ecr.aws/ml/": "4h", "busybox": "5m"}`,
+			expected: map[string]time.Duration{"public.ecr.aws/ml/": 4 * time.Hour, "busybox": 5 * time.Minute},
+		},
+		{
+			name:     "invalid durations are ignored",
+			envValue: `{"public.ecr.aws/ml/": "4h", "busybox": "soon", "alpine": "-5m", "": "1h"}`,
+			expected: map[string]time.Duration{"public.ecr.aws/ml/": 4 * time.Hour},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_IMAGE_PULL_TIMEOUT_OVERRIDES", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.ImagePullTimeoutOverrides)
+		})
+	}
+}
+
+func TestImageCleanupWindow(t *testing.T) {
+	testCases := []struct {
+		name     string
+		window   string
+		timezone string
+		expected *ImageCleanupWindow
+	}{
+		{name: "unset"},
+		{
+			name:     "default time zone",
+			window:   "22:00-06:30",
+			expected: &ImageCleanupWindow{Start: 22 * time.Hour, End: 6*time.Hour + 30*time.Minute, Location: time.UTC},
+		},
+		{
+			name:     "named time zone",
+			window:   "01:00-05:00",
+			timezone: "UTC",
+			expected: &ImageCleanupWindow{Start: time.Hour, End: 5 * time.Hour, Location: time.UTC},
+		},
+		{
+			name:     "fixed offset",
+			window:   "01:00-05:00",
+			timezone: "-05:00",
+			expected: &ImageCleanupWindow{Start: time.Hour, End: 5 * time.Hour, Location: time.FixedZone("-05:00", -5*60*60)},
+		},
+		{name: "invalid format", window: "22:00"},
+		{name: "invalid time", window: "25:00-06:00"},
+		{name: "empty window", window: "06:00-06:00"},
+		{name: "invalid time zone", window: "01:00-05:00", timezone: "Not/AZone"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_IMAGE_CLEANUP_WINDOW", tc.window)()
+			defer setTestEnv("ECS_IMAGE_CLEANUP_WINDOW_TIMEZONE", tc.timezone)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.ImageCleanupWindow)
+		})
+	}
+}
+
+func TestImagePullRateLimit(t *testing.T) {
+	testCases := []struct {
+		name              string
+		envValue          string
+		overridesEnvValue string
+		expected          ImagePullRateLimit
+		expectedOverrides map[string]ImagePullRateLimit
+	}{
+		{name: "unset"},
+		{
+			name:              "valid limits",
+			envValue:          `{"rate": 0.5, "burst": 5}`,
+			overridesEnvValue: `{"docker.io": {"rate": 0.1, "burst": 2}}`,
+			expected:          ImagePullRateLimit{Rate: 0.5, Burst: 5},
+			expectedOverrides: map[string]ImagePullRateLimit{"docker.io": {Rate: 0.1, Burst: 2}},
+		},
+		{
+			name:              "invalid json",
+			envValue:          "0.5",
+			overridesEnvValue: "docker.io=0.1",
+		},
+		{
+			name:              "invalid limits are ignored",
+			envValue:          `{"rate": 0.5}`,
+			overridesEnvValue: `{"docker.io": {"rate": 0.1, "burst": 2}, "quay.io": {"rate": -1, "burst": 2}, "": {"rate": 1, "burst": 1}}`,
+			expectedOverrides: map[string]ImagePullRateLimit{"docker.io": {Rate: 0.1, Burst: 2}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_IMAGE_PULL_RATE_LIMIT", tc.envValue)()
+			defer setTestEnv("ECS_IMAGE_PULL_RATE_LIMIT_OVERRIDES", tc.overridesEnvValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.ImagePullRateLimit)
+			assert.Equal(t, tc.expectedOverrides, cfg.ImagePullRateLimitOverrides)
+		})
+	}
+}
+
+func TestDNSLatencyCheckInterval(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected time.Duration
+	}{
+		{envValue: "", expected: 0},
+		{envValue: "2m", expected: 2 * time.Minute},
+		{envValue: "1s", expected: minimumDNSLatencyCheckInterval},
+		{envValue: "-1m", expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_DNS_LATENCY_CHECK_INTERVAL", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.DNSLatencyCheckInterval)
+		})
+	}
+}
+
+func TestTaskContainerStartConcurrency(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected int
+	}{
+		{envValue: "", expected: DefaultTaskContainerStartConcurrency},
+		{envValue: "2", expected: 2},
+		{envValue: "-1", expected: DefaultTaskContainerStartConcurrency},
+		{envValue: "all", expected: DefaultTaskContainerStartConcurrency},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_ENGINE_TASK_CONTAINER_START_CONCURRENCY", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.TaskContainerStartConcurrency)
+		})
+	}
+}
+
+func TestMaxConcurrentImagePulls(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected int
+	}{
+		{envValue: "", expected: 0},
+		{envValue: "4", expected: 4},
+		{envValue: "-1", expected: 0},
+		{envValue: "many", expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_MAX_CONCURRENT_IMAGE_PULLS", tc.envValue)()
+			defer setTestEnv("ECS_MAX_CONCURRENT_IMAGE_PULLS_PER_TASK", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.MaxConcurrentImagePulls)
+			assert.Equal(t, tc.expected, cfg.MaxConcurrentImagePullsPerTask)
+		})
+	}
+}
+
+func TestTaskCleanupConcurrency(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected int
+	}{
+		{envValue: "", expected: DefaultTaskCleanupConcurrency},
+		{envValue: "4", expected: 4},
+		{envValue: "0", expected: DefaultTaskCleanupConcurrency},
+		{envValue: "many", expected: DefaultTaskCleanupConcurrency},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_ENGINE_TASK_CLEANUP_CONCURRENCY", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.TaskCleanupConcurrency)
+		})
+	}
+}
+
+func TestContainerFDCheckInterval(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected time.Duration
+	}{
+		{envValue: "", expected: 0},
+		{envValue: "2m", expected: 2 * time.Minute},
+		{envValue: "1s", expected: minimumContainerFDCheckInterval},
+		{envValue: "-1m", expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_CONTAINER_FD_CHECK_INTERVAL", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.ContainerFDCheckInterval)
+		})
+	}
+}
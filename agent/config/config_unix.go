@@ -23,6 +23,8 @@ import (
 
 	"github.com/aws/amazon-ecs-agent/agent/dockerclient"
 	"github.com/aws/amazon-ecs-agent/agent/utils"
+
+	"github.com/cihub/seelog"
 )
 
 const (
@@ -55,6 +57,11 @@ const (
 	minimumContainerCreateTimeout = 1 * time.Minute
 	// default docker inactivity time is extra time needed on container extraction
 	defaultImagePullInactivityTimeout = 1 * time.Minute
+	// defaultExecAgentCmdUser is the user that runs the ExecCommandAgent inside the containers of a task
+	defaultExecAgentCmdUser = "0"
+	// defaultImageCleanupEscalationDiskPath is the root of the agent container, which is stored alongside the
+	// images in the docker data root
+	defaultImageCleanupEscalationDiskPath = "/"
 )
 
 // DefaultConfig returns the default configuration for Linux
@@ -69,7 +76,11 @@ func DefaultConfig() Config {
 		ReservedMemory:                      0,
 		AvailableLoggingDrivers:             []dockerclient.LoggingDriver{dockerclient.JSONFileDriver, dockerclient.NoneDriver},
 		TaskCleanupWaitDuration:             DefaultTaskCleanupWaitDuration,
+		TaskCleanupWaitDurationMaxOverride:  DefaultTaskCleanupWaitDurationMaxOverride,
+		TaskCleanupConcurrency:              DefaultTaskCleanupConcurrency,
+		TaskContainerStartConcurrency:       DefaultTaskContainerStartConcurrency,
 		DockerStopTimeout:                   defaultDockerStopTimeout,
+		ShutdownStopTimeout:                 DefaultShutdownStopTimeout,
 		ContainerStartTimeout:               defaultContainerStartTimeout,
 		ContainerCreateTimeout:              defaultContainerCreateTimeout,
 		DependentContainersPullUpfront:      BooleanDefaultFalse{Value: ExplicitlyDisabled},
@@ -78,11 +89,17 @@ func DefaultConfig() Config {
 		ImageCleanupDisabled:                BooleanDefaultFalse{Value: ExplicitlyDisabled},
 		MinimumImageDeletionAge:             DefaultImageDeletionAge,
 		NonECSMinimumImageDeletionAge:       DefaultNonECSImageDeletionAge,
+		ImageCleanupEscalationMinimumAge:    DefaultImageCleanupEscalationMinimumAge,
+		ImageCleanupEscalationDiskPath:      defaultImageCleanupEscalationDiskPath,
 		ImageCleanupInterval:                DefaultImageCleanupTimeInterval,
 		ImagePullInactivityTimeout:          defaultImagePullInactivityTimeout,
 		ImagePullTimeout:                    DefaultImagePullTimeout,
+		ImagePullProgressLogInterval:        DefaultImagePullProgressLogInterval,
+		ECRTokenCacheTTL:                    DefaultECRTokenCacheTTL,
 		NumImagesToDeletePerCycle:           DefaultNumImagesToDeletePerCycle,
 		NumNonECSContainersToDeletePerCycle: DefaultNumNonECSContainersToDeletePerCycle,
+		ImageCleanupStatsHistorySize:        DefaultImageCleanupStatsHistorySize,
+		ImageRemoveFailureWarningThreshold:  DefaultImageRemoveFailureWarningThreshold,
 		CNIPluginsPath:                      defaultCNIPluginsPath,
 		PauseContainerTarballPath:           pauseContainerTarballPath,
 		PauseContainerImageName:             DefaultPauseContainerImageName,
@@ -104,7 +121,14 @@ func DefaultConfig() Config {
 		FSxWindowsFileServerCapable:         false,
 		RuntimeStatsLogFile:                 defaultRuntimeStatsLogFile,
 		EnableRuntimeStats:                  BooleanDefaultFalse{Value: NotSet},
+		IntrospectionContainerLogsEnabled:   BooleanDefaultFalse{Value: NotSet},
+		IntrospectionImageQuarantineEnabled: BooleanDefaultFalse{Value: NotSet},
 		ShouldExcludeIPv6PortBinding:        BooleanDefaultTrue{Value: ExplicitlyEnabled},
+		ExecAgentCmdUser:                    defaultExecAgentCmdUser,
+		ExecInitFailureWarning:              BooleanDefaultFalse{Value: ExplicitlyDisabled},
+		ExecAgentHealthCheckInterval:        DefaultExecAgentHealthCheckInterval,
+		ExecAgentFolderPerm:                 DefaultExecAgentFolderPerm,
+		ExecAuditLogMaxSizeMB:               DefaultExecAuditLogMaxSizeMB,
 	}
 }
 
@@ -117,6 +141,12 @@ func (cfg *Config) platformOverrides() {
 	if cfg.TaskENIEnabled.Enabled() { // when task networking is enabled, eni trunking is enabled by default
 		cfg.ENITrunkingEnabled = parseBooleanDefaultTrueConfig("ECS_ENABLE_HIGH_DENSITY_ENI")
 	}
+
+	// the host log directory of the ExecCommandAgent is fixed on linux, as it is mounted into the agent container
+	if cfg.ExecAgentHostLogDir != "" {
+		seelog.Warnf("ECS_EXEC_AGENT_HOST_LOG_DIR is not supported on linux and will be ignored. Parsed value: %s.", cfg.ExecAgentHostLogDir)
+		cfg.ExecAgentHostLogDir = ""
+	}
 }
 
 // platformString returns platform-specific config data that can be serialized
@@ -223,6 +223,100 @@ func TestENITrunkingDisabled(t *testing.T) {
 	assert.False(t, cfg.ENITrunkingEnabled.Enabled(), "ENI trunking should be disabled")
 }
 
+// TestExecAgentHostLogDirIgnored tests that the host log directory of the ExecCommandAgent cannot be
+// overridden on linux
+func TestExecAgentHostLogDirIgnored(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_EXEC_AGENT_HOST_LOG_DIR", "/custom/exec/logs")()
+	cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	require.NoError(t, err)
+	assert.Empty(t, cfg.ExecAgentHostLogDir)
+}
+
+func TestExecAgentRemoveSupersededConfigs(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_EXEC_AGENT_REMOVE_SUPERSEDED_CONFIGS", "true")()
+	cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	require.NoError(t, err)
+	assert.True(t, cfg.ExecAgentRemoveSupersededConfigs.Enabled())
+}
+
+func TestImageCleanupEscalation(t *testing.T) {
+	defer setTestRegion()()
+	cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	require.NoError(t, err)
+	assert.Zero(t, cfg.ImageCleanupEscalationDiskThreshold)
+	assert.Equal(t, "/", cfg.ImageCleanupEscalationDiskPath)
+
+	defer setTestEnv("ECS_IMAGE_CLEANUP_ESCALATION_DISK_THRESHOLD", "85")()
+	defer setTestEnv("ECS_IMAGE_CLEANUP_ESCALATION_DISK_PATH", "/host/var/lib/docker")()
+	cfg, err = NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	require.NoError(t, err)
+	assert.Equal(t, 85, cfg.ImageCleanupEscalationDiskThreshold)
+	assert.Equal(t, "/host/var/lib/docker", cfg.ImageCleanupEscalationDiskPath)
+}
+
+func TestManagedScratchDir(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected string
+	}{
+		{envValue: "", expected: ""},
+		{envValue: "/var/lib/ecs/scratch", expected: "/var/lib/ecs/scratch"},
+		{envValue: "relative/scratch", expected: ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_MANAGED_SCRATCH_DIR", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.ManagedScratchDir)
+		})
+	}
+}
+
+func TestImageCleanupEscalationInodeThreshold(t *testing.T) {
+	testCases := []struct {
+		envValue string
+		expected int
+	}{
+		{envValue: "", expected: 0},
+		{envValue: "90", expected: 90},
+		{envValue: "120", expected: 0},
+		{envValue: "-1", expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.envValue, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_IMAGE_CLEANUP_ESCALATION_INODE_THRESHOLD", tc.envValue)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg.ImageCleanupEscalationInodeThreshold)
+		})
+	}
+}
+
+func TestImageCleanupEscalationInvalidDiskThreshold(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_IMAGE_CLEANUP_ESCALATION_DISK_THRESHOLD", "120")()
+	cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	require.NoError(t, err)
+	assert.Zero(t, cfg.ImageCleanupEscalationDiskThreshold)
+}
+
+func TestForceReadonlyRootFilesystem(t *testing.T) {
+	defer setTestRegion()()
+	cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	require.NoError(t, err)
+	assert.False(t, cfg.ForceReadonlyRootFilesystem.Enabled())
+
+	defer setTestEnv("ECS_FORCE_READONLY_ROOT_FILESYSTEM", "true")()
+	cfg, err = NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	require.NoError(t, err)
+	assert.True(t, cfg.ForceReadonlyRootFilesystem.Enabled())
+}
+
 // setupFileConfiguration create a temp file store the configuration
 func setupFileConfiguration(t *testing.T, configContent string) string {
 	file, err := ioutil.TempFile("", "ecs-test")
@@ -283,3 +377,11 @@ func TestCPUPeriodSettings(t *testing.T) {
 		})
 	}
 }
+
+func TestHostEnvironmentFileDirs(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_HOST_ENVIRONMENT_FILE_DIRS", "/etc/ecs/env/, relative/dir,,/opt/secrets")()
+	cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/etc/ecs/env", "/opt/secrets"}, cfg.HostEnvironmentFileDirs)
+}
@@ -17,6 +17,9 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"time"
@@ -66,6 +69,8 @@ const (
 	minimumContainerCreateTimeout = 1 * time.Minute
 	// default image pull inactivity time is extra time needed on container extraction
 	defaultImagePullInactivityTimeout = 3 * time.Minute
+	// defaultExecAgentCmdUser is the user that runs the ExecCommandAgent inside the containers of a task
+	defaultExecAgentCmdUser = `NT AUTHORITY\SYSTEM`
 	// adminSid is the security ID for the admin group on Windows
 	// Reference: https://docs.microsoft.com/en-us/troubleshoot/windows-server/identity/security-identifiers-in-windows
 	adminSid = "S-1-5-32-544"
@@ -120,12 +125,18 @@ func DefaultConfig() Config {
 		ReservedMemory:                      0,
 		AvailableLoggingDrivers:             []dockerclient.LoggingDriver{dockerclient.JSONFileDriver, dockerclient.NoneDriver, dockerclient.AWSLogsDriver},
 		TaskCleanupWaitDuration:             DefaultTaskCleanupWaitDuration,
+		TaskCleanupWaitDurationMaxOverride:  DefaultTaskCleanupWaitDurationMaxOverride,
+		TaskCleanupConcurrency:              DefaultTaskCleanupConcurrency,
+		TaskContainerStartConcurrency:       DefaultTaskContainerStartConcurrency,
 		DockerStopTimeout:                   defaultDockerStopTimeout,
+		ShutdownStopTimeout:                 DefaultShutdownStopTimeout,
 		ContainerStartTimeout:               defaultContainerStartTimeout,
 		ContainerCreateTimeout:              defaultContainerCreateTimeout,
 		DependentContainersPullUpfront:      BooleanDefaultFalse{Value: ExplicitlyDisabled},
 		ImagePullInactivityTimeout:          defaultImagePullInactivityTimeout,
 		ImagePullTimeout:                    DefaultImagePullTimeout,
+		ImagePullProgressLogInterval:        DefaultImagePullProgressLogInterval,
+		ECRTokenCacheTTL:                    DefaultECRTokenCacheTTL,
 		CredentialsAuditLogFile:             filepath.Join(ecsRoot, defaultCredentialsAuditLogFile),
 		CredentialsAuditLogDisabled:         false,
 		ImageCleanupDisabled:                BooleanDefaultFalse{Value: ExplicitlyDisabled},
@@ -134,6 +145,8 @@ func DefaultConfig() Config {
 		ImageCleanupInterval:                DefaultImageCleanupTimeInterval,
 		NumImagesToDeletePerCycle:           DefaultNumImagesToDeletePerCycle,
 		NumNonECSContainersToDeletePerCycle: DefaultNumNonECSContainersToDeletePerCycle,
+		ImageCleanupStatsHistorySize:        DefaultImageCleanupStatsHistorySize,
+		ImageRemoveFailureWarningThreshold:  DefaultImageRemoveFailureWarningThreshold,
 		ContainerMetadataEnabled:            BooleanDefaultFalse{Value: ExplicitlyDisabled},
 		TaskCPUMemLimit:                     BooleanDefaultTrue{Value: ExplicitlyDisabled},
 		PlatformVariables:                   platformVariables,
@@ -149,7 +162,14 @@ func DefaultConfig() Config {
 		CNIPluginsPath:                      filepath.Join(ecsBinaryDir, defaultCNIPluginDirName),
 		RuntimeStatsLogFile:                 filepath.Join(ecsRoot, defaultRuntimeStatsLogFile),
 		EnableRuntimeStats:                  BooleanDefaultFalse{Value: NotSet},
+		IntrospectionContainerLogsEnabled:   BooleanDefaultFalse{Value: NotSet},
+		IntrospectionImageQuarantineEnabled: BooleanDefaultFalse{Value: NotSet},
 		ShouldExcludeIPv6PortBinding:        BooleanDefaultTrue{Value: ExplicitlyEnabled},
+		ExecAgentCmdUser:                    defaultExecAgentCmdUser,
+		ExecInitFailureWarning:              BooleanDefaultFalse{Value: ExplicitlyDisabled},
+		ExecAgentHealthCheckInterval:        DefaultExecAgentHealthCheckInterval,
+		ExecAgentFolderPerm:                 DefaultExecAgentFolderPerm,
+		ExecAuditLogMaxSizeMB:               DefaultExecAuditLogMaxSizeMB,
 	}
 }
 
@@ -166,6 +186,12 @@ func (cfg *Config) platformOverrides() {
 	// ensure TaskResourceLimit is disabled
 	cfg.TaskCPUMemLimit.Value = ExplicitlyDisabled
 
+	// windows containers don't support a read only root filesystem
+	if cfg.ForceReadonlyRootFilesystem.Enabled() {
+		seelog.Warn("ECS_FORCE_READONLY_ROOT_FILESYSTEM is not supported on windows and will be ignored")
+		cfg.ForceReadonlyRootFilesystem.Value = ExplicitlyDisabled
+	}
+
 	cpuUnbounded := parseBooleanDefaultFalseConfig("ECS_ENABLE_CPU_UNBOUNDED_WINDOWS_WORKAROUND")
 	memoryUnbounded := parseBooleanDefaultFalseConfig("ECS_ENABLE_MEMORY_UNBOUNDED_WINDOWS_WORKAROUND")
 
@@ -174,6 +200,45 @@ func (cfg *Config) platformOverrides() {
 		MemoryUnbounded: memoryUnbounded,
 	}
 	cfg.PlatformVariables = platformVariables
+
+	if cfg.ExecAgentHostLogDir != "" {
+		if err := validateExecAgentHostLogDir(cfg.ExecAgentHostLogDir); err != nil {
+			seelog.Warnf("Invalid value for ECS_EXEC_AGENT_HOST_LOG_DIR, will be overridden with the default directory: %v. Parsed value: %s.", err, cfg.ExecAgentHostLogDir)
+			cfg.ExecAgentHostLogDir = ""
+		}
+	}
+
+	if cfg.ExecAgentRemoveSupersededConfigs.Enabled() {
+		seelog.Warn("ECS_EXEC_AGENT_REMOVE_SUPERSEDED_CONFIGS is not supported on windows and will be ignored.")
+		cfg.ExecAgentRemoveSupersededConfigs = BooleanDefaultFalse{Value: ExplicitlyDisabled}
+	}
+
+	if cfg.ImageCleanupEscalationDiskThreshold != 0 {
+		seelog.Warn("ECS_IMAGE_CLEANUP_ESCALATION_DISK_THRESHOLD is not supported on windows and will be ignored.")
+		cfg.ImageCleanupEscalationDiskThreshold = 0
+	}
+
+	if cfg.ImageCleanupEscalationInodeThreshold != 0 {
+		seelog.Warn("ECS_IMAGE_CLEANUP_ESCALATION_INODE_THRESHOLD is not supported on windows and will be ignored.")
+		cfg.ImageCleanupEscalationInodeThreshold = 0
+	}
+}
+
+// validateExecAgentHostLogDir checks that the host log directory of the ExecCommandAgent is an absolute path
+// the agent can create and write files in
+func validateExecAgentHostLogDir(dir string) error {
+	if !filepath.IsAbs(dir) {
+		return errors.New("path is not absolute")
+	}
+	if err := os.MkdirAll(dir, DefaultExecAgentFolderPerm); err != nil {
+		return fmt.Errorf("unable to create directory: %w", err)
+	}
+	f, err := ioutil.TempFile(dir, "ecs-exec-log-dir-check")
+	if err != nil {
+		return fmt.Errorf("directory is not writable: %w", err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
 }
 
 // platformString returns platform-specific config data that can be serialized
@@ -18,6 +18,7 @@ package config
 
 import (
 	"errors"
+	"io/ioutil"
 	"os"
 	"testing"
 	"time"
@@ -109,6 +110,14 @@ func TestTaskResourceLimitPlatformOverrideDisabled(t *testing.T) {
 	assert.False(t, cfg.TaskCPUMemLimit.Enabled())
 }
 
+func TestForceReadonlyRootFilesystemPlatformOverrideDisabled(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_FORCE_READONLY_ROOT_FILESYSTEM", "true")()
+	cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	assert.NoError(t, err)
+	assert.False(t, cfg.ForceReadonlyRootFilesystem.Enabled())
+}
+
 func TestCPUUnboundedSet(t *testing.T) {
 	defer setTestRegion()()
 	defer setTestEnv("ECS_ENABLE_CPU_UNBOUNDED_WINDOWS_WORKAROUND", "true")()
@@ -143,6 +152,61 @@ func TestMemoryUnboundedWindowsDisabled(t *testing.T) {
 	assert.False(t, cfg.PlatformVariables.MemoryUnbounded.Enabled())
 }
 
+func TestExecAgentRemoveSupersededConfigsIgnored(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_EXEC_AGENT_REMOVE_SUPERSEDED_CONFIGS", "true")()
+	cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	require.NoError(t, err)
+	assert.False(t, cfg.ExecAgentRemoveSupersededConfigs.Enabled())
+}
+
+func TestImageCleanupEscalationIgnored(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_IMAGE_CLEANUP_ESCALATION_DISK_THRESHOLD", "85")()
+	defer setTestEnv("ECS_IMAGE_CLEANUP_ESCALATION_INODE_THRESHOLD", "90")()
+	cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	require.NoError(t, err)
+	assert.Zero(t, cfg.ImageCleanupEscalationDiskThreshold)
+	assert.Zero(t, cfg.ImageCleanupEscalationInodeThreshold)
+}
+
+func TestExecAgentHostLogDir(t *testing.T) {
+	hostLogDir, err := ioutil.TempDir("", "exec-logs")
+	require.NoError(t, err)
+	defer os.RemoveAll(hostLogDir)
+
+	testCases := []struct {
+		name               string
+		envVarVal          string
+		expectedHostLogDir string
+	}{
+		{
+			name:               "unset",
+			envVarVal:          "",
+			expectedHostLogDir: "",
+		},
+		{
+			name:               "absolute writable path",
+			envVarVal:          hostLogDir,
+			expectedHostLogDir: hostLogDir,
+		},
+		{
+			name:               "relative path",
+			envVarVal:          `exec\logs`,
+			expectedHostLogDir: "",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_EXEC_AGENT_HOST_LOG_DIR", tc.envVarVal)()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedHostLogDir, cfg.ExecAgentHostLogDir)
+		})
+	}
+}
+
 func TestGetConfigFileName(t *testing.T) {
 	configFileName := "/foo/bar/config.json"
 	testCases := []struct {
@@ -0,0 +1,47 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// ImageCleanupWindow is the time of the day during which image cleanup is allowed to remove images
+type ImageCleanupWindow struct {
+	// Start is the time of the day when the window opens, as the time since midnight
+	Start time.Duration
+	// End is the time of the day when the window closes, as the time since midnight. The window spans midnight
+	// when End is before Start.
+	End time.Duration
+	// Location is the time zone of Start and End
+	Location *time.Location
+}
+
+// Contains returns true if the time of the day of t, in the time zone of the window, is within the window
+func (window *ImageCleanupWindow) Contains(t time.Time) bool {
+	t = t.In(window.Location)
+	sinceMidnight := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second
+	if window.Start <= window.End {
+		return sinceMidnight >= window.Start && sinceMidnight < window.End
+	}
+	return sinceMidnight >= window.Start || sinceMidnight < window.End
+}
+
+func (window *ImageCleanupWindow) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d %s",
+		int(window.Start.Hours()), int(window.Start.Minutes())%60,
+		int(window.End.Hours()), int(window.End.Minutes())%60, window.Location)
+}
@@ -0,0 +1,75 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImageCleanupWindowContains(t *testing.T) {
+	testCases := []struct {
+		name     string
+		window   ImageCleanupWindow
+		time     time.Time
+		expected bool
+	}{
+		{
+			name:     "within window",
+			window:   ImageCleanupWindow{Start: 1 * time.Hour, End: 5 * time.Hour, Location: time.UTC},
+			time:     time.Date(2021, time.March, 1, 3, 0, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			name:     "at start of window",
+			window:   ImageCleanupWindow{Start: 1 * time.Hour, End: 5 * time.Hour, Location: time.UTC},
+			time:     time.Date(2021, time.March, 1, 1, 0, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			name:     "at end of window",
+			window:   ImageCleanupWindow{Start: 1 * time.Hour, End: 5 * time.Hour, Location: time.UTC},
+			time:     time.Date(2021, time.March, 1, 5, 0, 0, 0, time.UTC),
+			expected: false,
+		},
+		{
+			name:     "within window spanning midnight",
+			window:   ImageCleanupWindow{Start: 22 * time.Hour, End: 6 * time.Hour, Location: time.UTC},
+			time:     time.Date(2021, time.March, 1, 2, 0, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			name:     "outside window spanning midnight",
+			window:   ImageCleanupWindow{Start: 22 * time.Hour, End: 6 * time.Hour, Location: time.UTC},
+			time:     time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC),
+			expected: false,
+		},
+		{
+			name:     "within window of another time zone",
+			window:   ImageCleanupWindow{Start: 1 * time.Hour, End: 5 * time.Hour, Location: time.FixedZone("+02:00", 2*60*60)},
+			time:     time.Date(2021, time.March, 1, 0, 30, 0, 0, time.UTC),
+			expected: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.window.Contains(tc.time))
+		})
+	}
+}
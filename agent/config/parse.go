@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -25,6 +26,7 @@ import (
 	"github.com/aws/amazon-ecs-agent/agent/dockerclient"
 	"github.com/cihub/seelog"
 	cnitypes "github.com/containernetworking/cni/pkg/types"
+	"github.com/docker/go-units"
 )
 
 const (
@@ -161,6 +163,53 @@ func parseNumImagesToDeletePerCycle() int {
 	return numImagesToDeletePerCycle
 }
 
+func parseTaskCleanupConcurrency() int {
+	taskCleanupConcurrencyEnvVal := os.Getenv("ECS_ENGINE_TASK_CLEANUP_CONCURRENCY")
+	taskCleanupConcurrency, err := strconv.Atoi(taskCleanupConcurrencyEnvVal)
+	if taskCleanupConcurrencyEnvVal != "" && err != nil {
+		seelog.Warnf("Invalid format for \"ECS_ENGINE_TASK_CLEANUP_CONCURRENCY\", expected an integer. err %v", err)
+	}
+
+	return taskCleanupConcurrency
+}
+
+func parseMaxConcurrentImagePulls(envVar string) int {
+	maxConcurrentImagePullsEnvVal := os.Getenv(envVar)
+	maxConcurrentImagePulls, err := strconv.Atoi(maxConcurrentImagePullsEnvVal)
+	if maxConcurrentImagePullsEnvVal != "" && err != nil {
+		seelog.Warnf("Invalid format for \"%s\", expected an integer. err %v", envVar, err)
+	}
+	return maxConcurrentImagePulls
+}
+
+func parseExecMaxSessionLimit() int {
+	execMaxSessionLimitEnvVal := os.Getenv("ECS_EXEC_MAX_SESSION_LIMIT")
+	execMaxSessionLimit, err := strconv.Atoi(execMaxSessionLimitEnvVal)
+	if execMaxSessionLimitEnvVal != "" && err != nil {
+		seelog.Warnf("Invalid format for \"ECS_EXEC_MAX_SESSION_LIMIT\", expected an integer. err %v", err)
+	}
+	return execMaxSessionLimit
+}
+
+func parseExecAuditLogMaxSizeMB() int {
+	execAuditLogMaxSizeMBEnvVal := os.Getenv("ECS_EXEC_AUDIT_LOG_MAX_SIZE_MB")
+	execAuditLogMaxSizeMB, err := strconv.Atoi(execAuditLogMaxSizeMBEnvVal)
+	if execAuditLogMaxSizeMBEnvVal != "" && err != nil {
+		seelog.Warnf("Invalid format for \"ECS_EXEC_AUDIT_LOG_MAX_SIZE_MB\", expected an integer. err %v", err)
+	}
+	return execAuditLogMaxSizeMB
+}
+
+func parseTaskContainerStartConcurrency() int {
+	taskContainerStartConcurrencyEnvVal := os.Getenv("ECS_ENGINE_TASK_CONTAINER_START_CONCURRENCY")
+	taskContainerStartConcurrency, err := strconv.Atoi(taskContainerStartConcurrencyEnvVal)
+	if taskContainerStartConcurrencyEnvVal != "" && err != nil {
+		seelog.Warnf("Invalid format for \"ECS_ENGINE_TASK_CONTAINER_START_CONCURRENCY\", expected an integer. err %v", err)
+	}
+
+	return taskContainerStartConcurrency
+}
+
 func parseNumNonECSContainersToDeletePerCycle() int {
 	numNonEcsContainersToDeletePerCycleEnvVal := os.Getenv("NONECS_NUM_CONTAINERS_DELETE_PER_CYCLE")
 	numNonEcsContainersToDeletePerCycle, err := strconv.Atoi(numNonEcsContainersToDeletePerCycleEnvVal)
@@ -170,6 +219,152 @@ func parseNumNonECSContainersToDeletePerCycle() int {
 	return numNonEcsContainersToDeletePerCycle
 }
 
+func parseImageCleanupStatsHistorySize() int {
+	historySizeEnvVal := os.Getenv("ECS_IMAGE_CLEANUP_STATS_HISTORY_SIZE")
+	historySize, err := strconv.Atoi(historySizeEnvVal)
+	if historySizeEnvVal != "" && err != nil {
+		seelog.Warnf("Invalid format for \"ECS_IMAGE_CLEANUP_STATS_HISTORY_SIZE\", expected an integer. err %v", err)
+	}
+	return historySize
+}
+
+func parseImageRemoveFailureWarningThreshold() int {
+	thresholdEnvVal := os.Getenv("ECS_IMAGE_REMOVE_FAILURE_WARNING_THRESHOLD")
+	threshold, err := strconv.Atoi(thresholdEnvVal)
+	if thresholdEnvVal != "" && err != nil {
+		seelog.Warnf("Invalid format for \"ECS_IMAGE_REMOVE_FAILURE_WARNING_THRESHOLD\", expected an integer. err %v", err)
+	}
+	return threshold
+}
+
+func parseImageCleanupMaxTrackedImages() int {
+	maxTrackedImagesEnvVal := os.Getenv("ECS_IMAGE_CLEANUP_MAX_TRACKED_IMAGES")
+	maxTrackedImages, err := strconv.Atoi(maxTrackedImagesEnvVal)
+	if maxTrackedImagesEnvVal != "" && err != nil {
+		seelog.Warnf("Invalid format for \"ECS_IMAGE_CLEANUP_MAX_TRACKED_IMAGES\", expected an integer. err %v", err)
+	}
+	return maxTrackedImages
+}
+
+// parseHostEnvironmentFileDirs parses a comma separated list of absolute host directories, e.g.
+// /etc/ecs/env,/opt/secrets. Relative directories are ignored.
+func parseHostEnvironmentFileDirs() []string {
+	var dirs []string
+	for _, dir := range strings.Split(os.Getenv("ECS_HOST_ENVIRONMENT_FILE_DIRS"), ",") {
+		dir = strings.TrimSpace(dir)
+		if dir == "" {
+			continue
+		}
+		if !filepath.IsAbs(dir) {
+			seelog.Warnf("Ignoring host environment file directory %q in ECS_HOST_ENVIRONMENT_FILE_DIRS, expected an absolute path", dir)
+			continue
+		}
+		dirs = append(dirs, filepath.Clean(dir))
+	}
+	return dirs
+}
+
+// parseContainerDefaultUlimits parses a comma separated list of ulimits in the name=soft[:hard] format of the
+// docker --ulimit option, e.g. nofile=65536:65536,nproc=4096. Invalid ulimits are ignored.
+func parseContainerDefaultUlimits() []*units.Ulimit {
+	ulimitsEnvVal := os.Getenv("ECS_CONTAINER_DEFAULT_ULIMITS")
+	if ulimitsEnvVal == "" {
+		return nil
+	}
+	var ulimits []*units.Ulimit
+	seen := make(map[string]bool)
+	for _, ulimitVal := range strings.Split(ulimitsEnvVal, ",") {
+		ulimit, err := units.ParseUlimit(strings.TrimSpace(ulimitVal))
+		if err != nil {
+			seelog.Warnf("Invalid format for \"ECS_CONTAINER_DEFAULT_ULIMITS\", ignoring ulimit %q: %v", ulimitVal, err)
+			continue
+		}
+		if seen[ulimit.Name] {
+			seelog.Warnf("Duplicate ulimit %s in \"ECS_CONTAINER_DEFAULT_ULIMITS\", ignoring %q", ulimit.Name, ulimitVal)
+			continue
+		}
+		seen[ulimit.Name] = true
+		ulimits = append(ulimits, ulimit)
+	}
+	return ulimits
+}
+
+func parseMaxTotalImageDiskBytes() int64 {
+	maxTotalImageDiskBytesEnvVal := os.Getenv("ECS_MAX_TOTAL_IMAGE_DISK_BYTES")
+	maxTotalImageDiskBytes, err := strconv.ParseInt(maxTotalImageDiskBytesEnvVal, 10, 64)
+	if maxTotalImageDiskBytesEnvVal != "" && err != nil {
+		seelog.Warnf("Invalid format for \"ECS_MAX_TOTAL_IMAGE_DISK_BYTES\", expected an integer. err %v", err)
+	}
+	return maxTotalImageDiskBytes
+}
+
+func parseImageCleanupEscalationDiskThreshold() int {
+	diskThresholdEnvVal := os.Getenv("ECS_IMAGE_CLEANUP_ESCALATION_DISK_THRESHOLD")
+	diskThreshold, err := strconv.Atoi(diskThresholdEnvVal)
+	if diskThresholdEnvVal != "" && err != nil {
+		seelog.Warnf("Invalid format for \"ECS_IMAGE_CLEANUP_ESCALATION_DISK_THRESHOLD\", expected an integer. err %v", err)
+	}
+	return diskThreshold
+}
+
+func parseImageCleanupEscalationInodeThreshold() int {
+	inodeThresholdEnvVal := os.Getenv("ECS_IMAGE_CLEANUP_ESCALATION_INODE_THRESHOLD")
+	inodeThreshold, err := strconv.Atoi(inodeThresholdEnvVal)
+	if inodeThresholdEnvVal != "" && err != nil {
+		seelog.Warnf("Invalid format for \"ECS_IMAGE_CLEANUP_ESCALATION_INODE_THRESHOLD\", expected an integer. err %v", err)
+	}
+	return inodeThreshold
+}
+
+// parseImageCleanupWindow parses the daily window during which image cleanup removes images, formatted as
+// "HH:MM-HH:MM", in the time zone named by ECS_IMAGE_CLEANUP_WINDOW_TIMEZONE, UTC by default. The time zone
+// is either an IANA time zone name or a fixed offset from UTC, e.g. "-07:00".
+func parseImageCleanupWindow() *ImageCleanupWindow {
+	windowEnvVal := os.Getenv("ECS_IMAGE_CLEANUP_WINDOW")
+	if windowEnvVal == "" {
+		return nil
+	}
+	bounds := strings.Split(windowEnvVal, "-")
+	if len(bounds) != 2 {
+		seelog.Warnf(`Invalid format for "ECS_IMAGE_CLEANUP_WINDOW", expected "HH:MM-HH:MM", images will be cleaned up at any time: %s`, windowEnvVal)
+		return nil
+	}
+	start, startErr := parseTimeOfDay(bounds[0])
+	end, endErr := parseTimeOfDay(bounds[1])
+	if startErr != nil || endErr != nil || start == end {
+		seelog.Warnf(`Invalid format for "ECS_IMAGE_CLEANUP_WINDOW", expected "HH:MM-HH:MM" with distinct times, images will be cleaned up at any time: %s`, windowEnvVal)
+		return nil
+	}
+	location, err := parseTimeZone(os.Getenv("ECS_IMAGE_CLEANUP_WINDOW_TIMEZONE"))
+	if err != nil {
+		seelog.Warnf(`Invalid value for "ECS_IMAGE_CLEANUP_WINDOW_TIMEZONE", images will be cleaned up at any time: %v`, err)
+		return nil
+	}
+	return &ImageCleanupWindow{Start: start, End: end, Location: location}
+}
+
+// parseTimeOfDay parses a time of the day formatted as "HH:MM" into the time since midnight
+func parseTimeOfDay(value string) (time.Duration, error) {
+	timeOfDay, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(timeOfDay.Hour())*time.Hour + time.Duration(timeOfDay.Minute())*time.Minute, nil
+}
+
+// parseTimeZone parses either an IANA time zone name or a fixed offset from UTC formatted as "+HH:MM" or
+// "-HH:MM". An empty value is UTC.
+func parseTimeZone(value string) (*time.Location, error) {
+	if value == "" {
+		return time.UTC, nil
+	}
+	if offset, err := time.Parse("-07:00", value); err == nil {
+		_, offsetSeconds := offset.Zone()
+		return time.FixedZone(value, offsetSeconds), nil
+	}
+	return time.LoadLocation(value)
+}
+
 func parseImagePullBehavior() ImagePullBehaviorType {
 	ImagePullBehaviorString := os.Getenv("ECS_IMAGE_PULL_BEHAVIOR")
 	switch ImagePullBehaviorString {
@@ -306,6 +501,21 @@ func parseContainerInstanceTags(errs []error) (map[string]string, []error) {
 	return containerInstanceTags, errs
 }
 
+func parseRegistryCACertificates(errs []error) (map[string]string, []error) {
+	var registryCACertificates map[string]string
+	registryCACertificatesConfigString := os.Getenv("ECS_REGISTRY_CA_CERTIFICATES")
+	if registryCACertificatesConfigString == "" {
+		return nil, errs
+	}
+	err := json.Unmarshal([]byte(registryCACertificatesConfigString), &registryCACertificates)
+	if err != nil {
+		wrappedErr := fmt.Errorf("Invalid format for ECS_REGISTRY_CA_CERTIFICATES. Expected a json hash: %v", err)
+		seelog.Error(wrappedErr)
+		errs = append(errs, wrappedErr)
+	}
+	return registryCACertificates, errs
+}
+
 func parseContainerInstancePropagateTagsFrom() ContainerInstancePropagateTagsFromType {
 	containerInstancePropagateTagsFromString := os.Getenv("ECS_CONTAINER_INSTANCE_PROPAGATE_TAGS_FROM")
 	switch containerInstancePropagateTagsFromString {
@@ -360,6 +570,102 @@ func parseImageCleanupExclusionList(envVar string) []string {
 	return imageCleanupExclusionList
 }
 
+func parseImagePullMirrors() []string {
+	var mirrors []string
+	for _, mirror := range strings.Split(os.Getenv("ECS_IMAGE_PULL_MIRRORS"), ",") {
+		// Mirrors are addressed by host, the scheme is picked by docker
+		mirror = strings.TrimSpace(mirror)
+		mirror = strings.TrimPrefix(strings.TrimPrefix(mirror, "https://"), "http://")
+		mirror = strings.TrimSuffix(mirror, "/")
+		if mirror != "" {
+			mirrors = append(mirrors, mirror)
+		}
+	}
+	return mirrors
+}
+
+func parseImagePullTimeoutOverrides() map[string]time.Duration {
+	envVal := os.Getenv("ECS_IMAGE_PULL_TIMEOUT_OVERRIDES")
+	if envVal == "" {
+		return nil
+	}
+	var rawOverrides map[string]string
+	if err := json.Unmarshal([]byte(envVal), &rawOverrides); err != nil {
+		seelog.Warnf(`Invalid format for "ECS_IMAGE_PULL_TIMEOUT_OVERRIDES", expected a json hash of image name prefixes to durations: %v`, err)
+		return nil
+	}
+	overrides := make(map[string]time.Duration)
+	for prefix, rawTimeout := range rawOverrides {
+		timeout, err := time.ParseDuration(rawTimeout)
+		if prefix == "" || err != nil || timeout <= 0 {
+			seelog.Warnf(`Ignoring invalid pull timeout %q for image prefix %q in "ECS_IMAGE_PULL_TIMEOUT_OVERRIDES"`, rawTimeout, prefix)
+			continue
+		}
+		overrides[prefix] = timeout
+	}
+	if len(overrides) == 0 {
+		return nil
+	}
+	return overrides
+}
+
+// isValidImagePullRateLimit returns true if the image pull rate limit allows pulls at a positive rate
+func isValidImagePullRateLimit(limit ImagePullRateLimit) bool {
+	return limit.Rate > 0 && limit.Burst > 0
+}
+
+func parseImagePullRateLimit() ImagePullRateLimit {
+	envVal := os.Getenv("ECS_IMAGE_PULL_RATE_LIMIT")
+	if envVal == "" {
+		return ImagePullRateLimit{}
+	}
+	var limit ImagePullRateLimit
+	if err := json.Unmarshal([]byte(envVal), &limit); err != nil || !isValidImagePullRateLimit(limit) {
+		seelog.Warnf(`Invalid value for "ECS_IMAGE_PULL_RATE_LIMIT", expected a json hash with a positive "rate" and "burst", pulls will not be rate limited: %s`, envVal)
+		return ImagePullRateLimit{}
+	}
+	return limit
+}
+
+func parseImagePullRateLimitOverrides() map[string]ImagePullRateLimit {
+	envVal := os.Getenv("ECS_IMAGE_PULL_RATE_LIMIT_OVERRIDES")
+	if envVal == "" {
+		return nil
+	}
+	var rawOverrides map[string]ImagePullRateLimit
+	if err := json.Unmarshal([]byte(envVal), &rawOverrides); err != nil {
+		seelog.Warnf(`Invalid format for "ECS_IMAGE_PULL_RATE_LIMIT_OVERRIDES", expected a json hash of registry hosts to rate limits: %v`, err)
+		return nil
+	}
+	overrides := make(map[string]ImagePullRateLimit)
+	for host, limit := range rawOverrides {
+		if host == "" || !isValidImagePullRateLimit(limit) {
+			seelog.Warnf(`Ignoring invalid pull rate limit %+v for registry host %q in "ECS_IMAGE_PULL_RATE_LIMIT_OVERRIDES"`, limit, host)
+			continue
+		}
+		overrides[host] = limit
+	}
+	if len(overrides) == 0 {
+		return nil
+	}
+	return overrides
+}
+
+// parseExecAgentFolderPerm parses the permissions of the ExecCommandAgent directories from their octal
+// representation, e.g. 0700
+func parseExecAgentFolderPerm() os.FileMode {
+	envVal := os.Getenv("ECS_EXEC_AGENT_FOLDER_PERM")
+	if envVal == "" {
+		return 0
+	}
+	perm, err := strconv.ParseUint(envVal, 8, 32)
+	if err != nil {
+		seelog.Warnf("Invalid format for ECS_EXEC_AGENT_FOLDER_PERM, expected an octal value, e.g. 0700: %v", err)
+		return 0
+	}
+	return os.FileMode(perm)
+}
+
 func parseCgroupCPUPeriod() time.Duration {
 	duration := parseEnvVariableDuration("ECS_CGROUP_CPU_PERIOD")
 
@@ -14,16 +14,26 @@
 package config
 
 import (
+	"os"
 	"time"
 
 	"github.com/aws/amazon-ecs-agent/agent/dockerclient"
 	cnitypes "github.com/containernetworking/cni/pkg/types"
+	"github.com/docker/go-units"
 )
 
 // ImagePullBehaviorType is an enum variable type corresponding to different agent pull
 // behaviors including default, always, never and once.
 type ImagePullBehaviorType int8
 
+// ImagePullRateLimit is a token bucket rate limit of image pulls
+type ImagePullRateLimit struct {
+	// Rate is the number of pulls per second
+	Rate float64 `json:"rate"`
+	// Burst is the number of pulls that can be started at once
+	Burst int `json:"burst"`
+}
+
 // ContainerInstancePropagateTagsFromType is an enum variable type corresponding to different
 // ways to propagate tags, it includes none (default) and ec2_instance.
 type ContainerInstancePropagateTagsFromType int8
@@ -46,6 +56,10 @@ type Config struct {
 	// normally would to interact with the daemon. It defaults to
 	// unix:///var/run/docker.sock
 	DockerEndpoint string
+	// DockerClientAPIVersionOverride is the Docker API version used by the default Docker client instead of
+	// the default version of the platform, e.g. to use features of newer daemons. It must be one of the
+	// versions supported by the agent, otherwise the default version is used.
+	DockerClientAPIVersionOverride dockerclient.DockerVersion
 	// AWSRegion is the region to run in (such as "us-east-1"). This value will
 	// be inferred from the EC2 metadata service, but if it cannot be found this
 	// will be fatal.
@@ -77,6 +91,11 @@ type Config struct {
 	// for EngineAuthType for more information.
 	EngineAuthData *SensitiveRawMessage
 
+	// RegistryCACertificates maps registry hosts to the path of a PEM file holding the certificates of the CAs
+	// that the agent verifies them against when it connects to them while pulling images, instead of the
+	// certificates trusted by the host
+	RegistryCACertificates map[string]string
+
 	// UpdatesEnabled specifies whether updates should be applied to this agent.
 	// Default true
 	UpdatesEnabled BooleanDefaultFalse
@@ -110,6 +129,23 @@ type Config struct {
 	// containers managed by ECS
 	DockerStopTimeout time.Duration
 
+	// ContainerStopEscalation specifies whether the agent stops containers itself by sending their stop
+	// signal and escalating to SIGKILL once the stop timeout expires, recording whether the kill was required
+	ContainerStopEscalation BooleanDefaultFalse
+
+	// StopContainersOnShutdown specifies whether the agent stops the tasks it manages when it receives a
+	// termination signal, rather than leaving their containers running
+	StopContainersOnShutdown BooleanDefaultFalse
+
+	// ShutdownStopTimeout specifies how long the agent waits for its tasks to stop on shutdown when
+	// StopContainersOnShutdown is enabled
+	ShutdownStopTimeout time.Duration
+
+	// EssentialContainerStopGrace specifies how long the remaining containers of a task are left running after
+	// an essential container of the task exits, before they are stopped. It gives the other containers of the
+	// task, such as a log router, a chance to flush their work.
+	EssentialContainerStopGrace time.Duration
+
 	// ContainerStartTimeout specifies the amount of time to wait to start a container
 	ContainerStartTimeout time.Duration
 
@@ -120,12 +156,61 @@ type Config struct {
 	// Default false
 	DependentContainersPullUpfront BooleanDefaultFalse
 
+	// UseCachedImageOnPullFailure specifies whether a container uses the cached image of the same reference when
+	// its image can't be pulled, regardless of ImagePullBehavior. The pull error is reported as the reason of the
+	// container when the cached image is used.
+	UseCachedImageOnPullFailure BooleanDefaultFalse
+
 	// ImagePullInactivityTimeout is here to override the amount of time to wait when pulling and extracting a container
 	ImagePullInactivityTimeout time.Duration
 
 	//ImagePullTimeout is here to override the timeout for PullImage API
 	ImagePullTimeout time.Duration
 
+	// ImagePullTimeoutOverrides maps image name prefixes to the timeout for PullImage API of the images they
+	// match, overriding ImagePullTimeout. The longest matching prefix wins.
+	ImagePullTimeoutOverrides map[string]time.Duration
+
+	// ImagePullRateLimit is the rate limit of the image pulls from each registry host. Pulls beyond the rate wait
+	// for their turn. Pulls are not limited if it is unset.
+	ImagePullRateLimit ImagePullRateLimit
+
+	// ImagePullRateLimitOverrides maps registry hosts to the rate limit of the image pulls from them, overriding
+	// ImagePullRateLimit
+	ImagePullRateLimitOverrides map[string]ImagePullRateLimit
+
+	// ImagePullMirrors is the ordered list of registry mirror hosts to try pulling Docker Hub images from before
+	// falling back to Docker Hub itself
+	ImagePullMirrors []string
+
+	// ImagePullProgressLogInterval specifies how often a summary of the progress of an image pull is logged.
+	// Progress logging is disabled when it is negative.
+	ImagePullProgressLogInterval time.Duration
+
+	// ECRTokenCacheTTL specifies how long ECR credentials resolved for image pulls are cached before
+	// they are requested from ECR again
+	ECRTokenCacheTTL time.Duration
+
+	// ContainerClockDriftCheckInterval specifies how often the agent compares the clock of running containers
+	// against the host clock by exec'ing `date` in them. The drift is reported in task metadata. Checks are
+	// disabled when it is zero, which is the default.
+	ContainerClockDriftCheckInterval time.Duration
+
+	// AWSVPCPauseContainerCheckInterval specifies how often the agent inspects the pause container of each
+	// awsvpc task, in addition to watching docker events, so that a task whose network namespace is lost is
+	// stopped. Checks are disabled when it is zero, which is the default.
+	AWSVPCPauseContainerCheckInterval time.Duration
+
+	// DNSLatencyCheckInterval specifies how often the agent measures the latency of DNS resolutions made from
+	// within the network namespace of each awsvpc task against its resolvers. The latencies are reported in
+	// task metadata. Checks are disabled when it is zero, which is the default.
+	DNSLatencyCheckInterval time.Duration
+
+	// ContainerFDCheckInterval specifies how often the agent counts the file descriptors opened by the main
+	// process of running containers, to warn about containers whose count steadily grows toward their nofile
+	// limit. The counts are reported in task metadata. Checks are disabled when it is zero, which is the default.
+	ContainerFDCheckInterval time.Duration
+
 	// AvailableLoggingDrivers specifies the logging drivers available for use
 	// with Docker.  If not set, it defaults to ["json-file","none"].
 	AvailableLoggingDrivers []dockerclient.LoggingDriver
@@ -134,6 +219,26 @@ type Config struct {
 	// tasks with privileged containers
 	PrivilegedDisabled BooleanDefaultFalse
 
+	// ForceReadonlyRootFilesystem specifies whether the root filesystem of the containers of tasks is mounted
+	// as read only, even if their task definition doesn't ask for it. Containers can opt out with the
+	// com.amazonaws.ecs.readonly-root-filesystem-opt-out docker label. It is not supported on Windows.
+	ForceReadonlyRootFilesystem BooleanDefaultFalse
+
+	// ContainerDefaultUlimits are the ulimits the containers of tasks are created with when their task definition
+	// doesn't set a ulimit of the same name. It is not supported on Windows.
+	ContainerDefaultUlimits []*units.Ulimit
+
+	// HostEnvironmentFileDirs are the directories of the host from which containers can load environment
+	// variables with the com.amazonaws.ecs.host-environment-file docker label. Containers can't load any host
+	// environment file when it is empty.
+	HostEnvironmentFileDirs []string
+
+	// ManagedScratchDir is the directory of the host under which the agent creates a scratch directory for each
+	// task, bind mounted into the containers requesting it with the com.amazonaws.ecs.managed-scratch docker
+	// label, and removed when the task is cleaned up. Containers can't request a scratch directory when it is
+	// empty.
+	ManagedScratchDir string `trim:"true"`
+
 	// SELinxuCapable specifies whether the Agent is capable of using SELinux
 	// security options
 	SELinuxCapable BooleanDefaultFalse
@@ -152,6 +257,27 @@ type Config struct {
 	// TaskCleanupWaitDurationJitter].
 	TaskCleanupWaitDurationJitter time.Duration
 
+	// TaskCleanupWaitDurationMaxOverride specifies the maximum task cleanup wait duration that a task
+	// can request for itself through the task cleanup wait duration docker label.
+	TaskCleanupWaitDurationMaxOverride time.Duration
+
+	// TaskCleanupConcurrency specifies the maximum number of stopped tasks whose containers and resources are
+	// cleaned up at the same time
+	TaskCleanupConcurrency int
+
+	// TaskContainerStartConcurrency specifies the maximum number of containers of a task that are pulled,
+	// created and started at the same time. Containers are only started together when their dependencies
+	// allow it.
+	TaskContainerStartConcurrency int
+
+	// MaxConcurrentImagePulls specifies the maximum number of images pulled at the same time across all tasks.
+	// Pulls are not bounded when it is zero.
+	MaxConcurrentImagePulls int
+
+	// MaxConcurrentImagePullsPerTask specifies the maximum number of images of a task pulled at the same time.
+	// Pulls are not bounded per task when it is zero.
+	MaxConcurrentImagePullsPerTask int
+
 	// TaskIAMRoleEnabled specifies if the Agent is capable of launching
 	// tasks with IAM Roles.
 	TaskIAMRoleEnabled BooleanDefaultFalse
@@ -188,6 +314,12 @@ type Config struct {
 	// before it can be deleted
 	MinimumImageDeletionAge time.Duration
 
+	// MinimumImageDeletionAgeSoft specifies the minimum time since it was pulled before an image can be deleted
+	// under pressure, when the agent tracks more images than ImageCleanupMaxTrackedImages or when image cleanup
+	// is escalated as the disk usage is above ImageCleanupEscalationDiskThreshold. It must be lower than
+	// MinimumImageDeletionAge, which applies to all cleanups when it is zero.
+	MinimumImageDeletionAgeSoft time.Duration
+
 	// NonECSMinimumImageDeletionAge specifies the minimum time since non ecs images created before it can be deleted
 	NonECSMinimumImageDeletionAge time.Duration
 
@@ -203,6 +335,85 @@ type Config struct {
 	// when Agent performs cleanup
 	NumNonECSContainersToDeletePerCycle int
 
+	// ImageCleanupStatsHistorySize specifies the number of image cleanup cycles whose statistics
+	// are kept and exposed through the introspection API
+	ImageCleanupStatsHistorySize int
+
+	// ImageRemoveFailureWarningThreshold specifies the number of consecutive failed attempts to
+	// remove an image after which a warning identifying the image is logged
+	ImageRemoveFailureWarningThreshold int
+
+	// ImageCleanupPrioritizeSize specifies whether image cleanup removes the largest eligible images first,
+	// instead of the least recently used ones, to reclaim disk space faster
+	ImageCleanupPrioritizeSize BooleanDefaultFalse
+
+	// ImageCleanupMaxTrackedImages is a soft cap on the number of images tracked by the agent. When it is
+	// exceeded, a cleanup removing the least recently used eligible images runs right away. There is no cap
+	// when it is zero.
+	ImageCleanupMaxTrackedImages int
+
+	// MaxTotalImageDiskBytes is a budget on the disk space taken by the images tracked by the agent. After each
+	// image cleanup cycle, the least recently used eligible images are removed until the images fit in the
+	// budget. There is no budget when it is zero.
+	MaxTotalImageDiskBytes int64
+
+	// ImageCleanupPullCooldown specifies how long periodic image cleanup is skipped for after an image is pulled,
+	// so that images pulled during a scale-up are not evicted right before they are reused. Cleanup is never
+	// skipped when it is zero.
+	ImageCleanupPullCooldown time.Duration
+
+	// ImageFamilyProtectionWindow specifies how long images are protected from cleanup after a task of any task
+	// family used them, however long ago they were last used by a container. Images are not protected when it
+	// is zero.
+	ImageFamilyProtectionWindow time.Duration
+
+	// ImageCleanupStartupSettlePeriod specifies how long after the agent starts the images it has not seen in use
+	// since are protected from cleanup, as their last used times may be stale after a restart of the agent or a
+	// reboot of the instance. Images are not protected when it is zero.
+	ImageCleanupStartupSettlePeriod time.Duration
+
+	// ImageCleanupEscalationDiskThreshold is the disk usage percentage of ImageCleanupEscalationDiskPath above
+	// which, after an image cleanup cycle, MinimumImageDeletionAgeSoft, or MinimumImageDeletionAge when it is
+	// zero, is relaxed step-wise down to
+	// ImageCleanupEscalationMinimumAge to remove more images. Cleanup is never escalated when it is zero.
+	ImageCleanupEscalationDiskThreshold int
+
+	// ImageCleanupEscalationInodeThreshold is the inode usage percentage of ImageCleanupEscalationDiskPath above
+	// which image cleanup is escalated as well, whatever the disk usage, as filesystems holding many small files
+	// may run out of inodes first. Inode usage is not checked when it is zero.
+	ImageCleanupEscalationInodeThreshold int
+
+	// ImageCleanupEscalationMinimumAge is the floor of the minimum image age when image cleanup is escalated.
+	// Images pulled more recently are never removed by an escalated cleanup.
+	ImageCleanupEscalationMinimumAge time.Duration
+
+	// ImageCleanupEscalationDiskPath is the path of the filesystem holding the images, as seen by the agent,
+	// whose disk usage is checked to escalate image cleanup
+	ImageCleanupEscalationDiskPath string
+
+	// ImageCleanupEscalationStoppedTaskGrace is how long after a task stopped an escalated image cleanup may
+	// remove the containers of the task along with their images, before the task is cleaned up after
+	// TaskCleanupWaitDuration. The images of stopped tasks are kept until the tasks are cleaned up when it is zero.
+	ImageCleanupEscalationStoppedTaskGrace time.Duration
+
+	// ImageCleanupWindow is the daily window during which the periodic image cleanup removes images. Outside of
+	// the window, cleanup cycles only log the images they would remove. Images are removed at any time when it
+	// is nil.
+	ImageCleanupWindow *ImageCleanupWindow
+
+	// ImageQuarantineStopTasks specifies whether the running tasks with a container created from an image are
+	// stopped when the image is quarantined. Containers are never created from quarantined images.
+	ImageQuarantineStopTasks BooleanDefaultFalse
+
+	// ImageLastUsedMetadataDir is the directory where the state of each image tracked by the agent, including
+	// when it was last used, is written to a file named after the image ID for host tooling to read. The files
+	// are not written when it is empty.
+	ImageLastUsedMetadataDir string
+
+	// ImagePrewarmTarballDir is the directory of image tarballs, in the format written by `docker save`, that
+	// are loaded on startup so that tasks do not need to pull them. No images are loaded when it is empty.
+	ImagePrewarmTarballDir string
+
 	// ImagePullBehavior specifies the agent's behavior for pulling image and loading
 	// local Docker image cache
 	ImagePullBehavior ImagePullBehaviorType
@@ -326,6 +537,21 @@ type Config struct {
 	// see https://docs.aws.amazon.com/AmazonECS/latest/developerguide/container-instance-draining.html
 	SpotInstanceDrainingEnabled BooleanDefaultFalse
 
+	// SpotInterruptionTaskStopEnabled, if true, agent will poll the container instance's metadata endpoint for an
+	//   ec2 spot instance termination notice, and gracefully stop the running tasks as soon as the notice is received
+	//   instead of leaving it to the scheduler. Tasks can override it with the
+	//   com.amazonaws.ecs.spot-interruption-stop docker label. Defaults to false.
+	SpotInterruptionTaskStopEnabled BooleanDefaultFalse
+
+	// CPUStealReportingEnabled, if true, the CPU time stolen by the hypervisor on the host is sampled and
+	// reported along with the container stats of the v4 task metadata endpoint, on hosts which report it
+	CPUStealReportingEnabled BooleanDefaultFalse
+
+	// LifecycleEventsSocketPath is the path of the unix socket on which the task and container state changes
+	// reported by the agent are streamed as newline-delimited JSON to local consumers. The events are not
+	// streamed when it is empty.
+	LifecycleEventsSocketPath string
+
 	// GMSACapable is the config option to indicate if gMSA is supported.
 	// It should be enabled by default only if the container instance is part of a valid active directory domain.
 	GMSACapable bool
@@ -351,6 +577,16 @@ type Config struct {
 	// is set to false and can be overridden by means of the ECS_ENABLE_RUNTIME_STATS environment variable.
 	EnableRuntimeStats BooleanDefaultFalse
 
+	// IntrospectionContainerLogsEnabled specifies if the logs of task containers can be read through the agent
+	// introspection port. The endpoint only answers requests from the loopback interface. By default, this configuration
+	// is set to false and can be overridden by means of the ECS_ENABLE_INTROSPECTION_CONTAINER_LOGS environment variable.
+	IntrospectionContainerLogsEnabled BooleanDefaultFalse
+
+	// IntrospectionImageQuarantineEnabled specifies if images can be quarantined through the agent introspection port.
+	// The endpoint only answers requests from the loopback interface. By default, this configuration is set to false
+	// and can be overridden by means of the ECS_ENABLE_INTROSPECTION_IMAGE_QUARANTINE environment variable.
+	IntrospectionImageQuarantineEnabled BooleanDefaultFalse
+
 	// ShouldExcludeIPv6PortBinding specifies whether agent should exclude IPv6 port bindings reported from docker. This configuration
 	// is set to true by default, and can be overridden by the ECS_EXCLUDE_IPV6_PORTBINDING environment variable. This is a workaround
 	// for docker's bug as detailed in https://github.com/aws/amazon-ecs-agent/issues/2870.
@@ -359,4 +595,50 @@ type Config struct {
 	// WarmPoolsSupport specifies whether the agent should poll IMDS to check the target lifecycle state for a starting
 	// instance
 	WarmPoolsSupport BooleanDefaultFalse
+
+	// ExecAgentCmdUser specifies the user that runs the ExecCommandAgent inside the containers of a task. It is
+	// validated when a container is initialized for ECS Exec.
+	ExecAgentCmdUser string `trim:"true"`
+
+	// ExecInitFailureWarning specifies whether a failure to initialize the ExecCommandAgent for a container should be
+	// reported as a warning on the container state changes, in addition to the managed agent state change. The task
+	// keeps running regardless of this setting.
+	ExecInitFailureWarning BooleanDefaultFalse
+
+	// ExecAgentHealthCheckInterval specifies how often the agent checks that the ExecCommandAgent process is
+	// alive in each container it was started in. The managed agent is reported as unhealthy when it is not.
+	ExecAgentHealthCheckInterval time.Duration
+
+	// ExecAgentFolderPerm specifies the permissions of the directories the agent creates for the config and logs
+	// of the ExecCommandAgent. It must grant full access to the owner and must not be writable by others.
+	ExecAgentFolderPerm os.FileMode
+
+	// ExecAgentHostLogDir specifies the directory on the host under which the logs of the ExecCommandAgent are
+	// written. It is only supported on Windows, where it must be an absolute path the agent can write to. The
+	// default directory is used when it is unset.
+	ExecAgentHostLogDir string `trim:"true"`
+
+	// ExecAgentRemoveSupersededConfigs specifies whether the ExecCommandAgent config files written for other
+	// session settings are removed when a task is set up, keeping only the current config file. Exec enabled
+	// containers that were started with a removed config file cannot be restarted with it. It is only supported
+	// on Linux.
+	ExecAgentRemoveSupersededConfigs BooleanDefaultFalse
+
+	// ExecSessionLimitFromTaskTags specifies whether the number of sessions of the ExecCommandAgent of a task
+	// can be set by its ecs:exec-session-limit tag, which takes precedence over the limit sent by ECS. The tags
+	// of the task are retrieved from ECS when its exec enabled containers are initialized.
+	ExecSessionLimitFromTaskTags BooleanDefaultFalse
+
+	// ExecMaxSessionLimit caps the number of sessions of the ExecCommandAgent of a task, wherever the limit comes
+	// from. The limit is not capped when it is zero.
+	ExecMaxSessionLimit int
+
+	// ExecAuditLogFile specifies the file on the host the agent appends an audit record to whenever the
+	// ExecCommandAgent of a container starts and stops serving sessions. It must be an absolute path. No audit
+	// record is written when it is unset.
+	ExecAuditLogFile string `trim:"true"`
+
+	// ExecAuditLogMaxSizeMB specifies the size, in megabytes, above which the exec audit log is rotated. A single
+	// rotated file is kept, next to the audit log.
+	ExecAuditLogMaxSizeMB int
 }
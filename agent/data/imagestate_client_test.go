@@ -18,6 +18,7 @@ package data
 
 import (
 	"testing"
+	"time"
 
 	"github.com/aws/amazon-ecs-agent/agent/engine/image"
 
@@ -71,3 +72,23 @@ func TestManageImageStates(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, res, 0)
 }
+
+func TestImageStateFamilyLastUsedAtPersisted(t *testing.T) {
+	testClient, cleanup := newTestClient(t)
+	defer cleanup()
+
+	usedAt := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+	testImageState := &image.ImageState{
+		Image: &image.Image{
+			ImageID: testImageId,
+			Names:   []string{testImageName},
+		},
+	}
+	testImageState.RecordFamilyUse("web", usedAt)
+	assert.NoError(t, testClient.SaveImageState(testImageState))
+
+	res, err := testClient.GetImageStates()
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.True(t, usedAt.Equal(res[0].FamilyLastUsedAt["web"]))
+}
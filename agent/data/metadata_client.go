@@ -23,6 +23,7 @@ const (
 	ClusterNameKey          = "cluster-name"
 	ContainerInstanceARNKey = "container-instance-arn"
 	EC2InstanceIDKey        = "ec2-instance-id"
+	QuarantinedImagesKey    = "quarantined-images"
 	TaskManifestSeqNumKey   = "task-manifest-seq-num"
 )
 
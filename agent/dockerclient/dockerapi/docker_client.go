@@ -14,14 +14,17 @@
 package dockerapi
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
@@ -65,6 +68,14 @@ const (
 	healthCheckUnhealthy = "unhealthy"
 	// maxHealthCheckOutputLength is the maximum length of healthcheck command output that agent will save
 	maxHealthCheckOutputLength = 1024
+	// maxContainerExecOutputSize is the maximum size of the output of an attached exec process that agent will read
+	maxContainerExecOutputSize = 64 * 1024
+	// maxContainerLogsOutputSize is the maximum size of the logs of a container returned by the agent, older
+	// lines are dropped
+	maxContainerLogsOutputSize = 1024 * 1024
+	// containerLogsHeaderSize is the size of the header of each frame of the logs of a container without a tty,
+	// where stdout and stderr are multiplexed
+	containerLogsHeaderSize = 8
 	// VolumeDriverType is one of the plugin capabilities see https://docs.docker.com/engine/reference/commandline/plugin_ls/#filtering
 	VolumeDriverType = "volumedriver"
 	// dockerContainerDieEvent is the name of the event generated by Docker when a container died.
@@ -73,12 +84,19 @@ const (
 	dockerContainerEventExitCodeAttribute = "exitCode"
 )
 
+// registryAuthErrorMessages are the lower case fragments of pull errors returned when a registry rejects
+// the credentials of the pull
+var registryAuthErrorMessages = []string{
+	"403 forbidden",
+	"unauthorized",
+	"denied",
+	"no basic auth credentials",
+}
+
 // Timelimits for docker operations enforced above docker
 const (
 	// Parameters for caching the docker auth for ECR
 	tokenCacheSize = 100
-	// tokenCacheTTL is the default ttl of the docker auth for ECR
-	tokenCacheTTL = 12 * time.Hour
 
 	// pullStatusSuppressDelay controls the time where pull status progress bar
 	// output will be suppressed in debug mode
@@ -94,6 +112,12 @@ const (
 	// pollStatsTimeout is the timeout for polling Docker Stats API;
 	// keeping it same as streaming stats inactivity timeout
 	pollStatsTimeout = 18 * time.Second
+
+	// backoff settings for reopening the docker event stream, e.g. while the docker daemon restarts
+	minimumEventStreamReconnectDelay     = 500 * time.Millisecond
+	maximumEventStreamReconnectDelay     = 30 * time.Second
+	eventStreamReconnectDelayMultiplier  = 2
+	eventStreamReconnectJitterMultiplier = 0.2
 )
 
 // stopContainerTimeoutBuffer is a buffer added to the timeout passed into the docker
@@ -103,6 +127,16 @@ const (
 // for these backup kill methods to finish.
 var stopContainerTimeoutBuffer = 2 * time.Minute
 
+// hostPlatform returns the platform images are pulled for, in the os/arch format of docker, so that the variant
+// matching the host is selected from multi-platform images. It is empty on Windows, where docker selects the variant
+// matching the OS version of the host, which an explicit platform would not take into account.
+var hostPlatform = func() string {
+	if runtime.GOOS != "linux" {
+		return ""
+	}
+	return runtime.GOOS + "/" + runtime.GOARCH
+}
+
 type inactivityTimeoutHandlerFunc func(reader io.ReadCloser, timeout time.Duration, cancelRequest func(), canceled *uint32) (io.ReadCloser, chan<- struct{})
 
 // DockerClient interface to make testing it easier
@@ -136,6 +170,14 @@ type DockerClient interface {
 	// for the request.
 	StopContainer(context.Context, string, time.Duration) DockerContainerMetadata
 
+	// KillContainer sends the signal to the container identified by the name provided. A timeout value and a
+	// context should be provided for the request.
+	KillContainer(context.Context, string, string, time.Duration) error
+
+	// UpdateContainer updates the cgroup resources of the container identified by the name provided. A timeout
+	// value and a context should be provided for the request.
+	UpdateContainer(context.Context, string, dockercontainer.Resources, time.Duration) error
+
 	// DescribeContainer returns status information about the specified container. A context should be provided
 	// for the request
 	DescribeContainer(context.Context, string) (apicontainerstatus.ContainerStatus, DockerContainerMetadata)
@@ -148,6 +190,10 @@ type DockerClient interface {
 	// provided for the request.
 	InspectContainer(context.Context, string, time.Duration) (*types.ContainerJSON, error)
 
+	// ContainerLogs returns the last lines of the stdout and stderr of the specified container, up to the number of
+	// lines provided. A timeout value and a context should be provided for the request.
+	ContainerLogs(context.Context, string, int, time.Duration) ([]byte, error)
+
 	// CreateContainerExec creates a new exec configuration to run an exec process with the provided Config. A timeout value
 	// and a context should be provided for the request.
 	CreateContainerExec(ctx context.Context, containerID string, execConfig types.ExecConfig, timeout time.Duration) (*types.IDResponse, error)
@@ -156,6 +202,10 @@ type DockerClient interface {
 	// and a context should be provided for the request.
 	StartContainerExec(ctx context.Context, execID string, execStartCheck types.ExecStartCheck, timeout time.Duration) error
 
+	// AttachContainerExec starts an exec process already created in the docker host while attached to it, and
+	// returns the output of the process once it exits. A timeout value and a context should be provided for the request.
+	AttachContainerExec(ctx context.Context, execID string, execStartCheck types.ExecStartCheck, timeout time.Duration) ([]byte, error)
+
 	// InspectContainerExec returns information about a specific exec process on the docker host. A timeout value
 	// and a context should be provided for the request.
 	InspectContainerExec(ctx context.Context, execID string, timeout time.Duration) (*types.ContainerExecInspect, error)
@@ -164,6 +214,10 @@ type DockerClient interface {
 	// should be provided for the request.
 	ListContainers(context.Context, bool, time.Duration) ListContainersResponse
 
+	// ListContainersWithFilters returns the containers known to the Docker daemon, in any state, that match the
+	// filters, along with their labels. A timeout value and a context should be provided for the request.
+	ListContainersWithFilters(context.Context, filters.Args, time.Duration) ListContainersResponse
+
 	// SystemPing returns the Ping response from Docker's SystemPing API
 	SystemPing(context.Context, time.Duration) PingResponse
 
@@ -236,6 +290,7 @@ type dockerGoClient struct {
 	version                  dockerclient.DockerVersion
 	ecrClientFactory         ecr.ECRFactory
 	auth                     dockerauth.DockerAuthProvider
+	authResolvers            []dockerauth.RegistryAuthResolver
 	ecrTokenCache            async.Cache
 	config                   *config.Config
 	context                  context.Context
@@ -265,6 +320,7 @@ func (dg *dockerGoClient) WithVersion(version dockerclient.DockerVersion) Docker
 		sdkClientFactory: dg.sdkClientFactory,
 		version:          version,
 		auth:             dg.auth,
+		authResolvers:    dg.authResolvers,
 		config:           dg.config,
 		context:          dg.context,
 	}
@@ -296,11 +352,16 @@ func NewDockerGoClient(sdkclientFactory sdkclientfactory.Factory,
 	if cfg.EngineAuthData != nil {
 		dockerAuthData = cfg.EngineAuthData.Contents()
 	}
+	registryCAs, err := cfg.RegistryCertPools()
+	if err != nil {
+		return nil, err
+	}
 	return &dockerGoClient{
 		sdkClientFactory: sdkclientFactory,
 		auth:             dockerauth.NewDockerAuthProvider(cfg.EngineAuthType, dockerAuthData),
-		ecrClientFactory: ecr.NewECRFactory(cfg.AcceptInsecureCert),
-		ecrTokenCache:    async.NewLRUCache(tokenCacheSize, tokenCacheTTL),
+		authResolvers:    dockerauth.RegisteredRegistryAuthResolvers(),
+		ecrClientFactory: ecr.NewECRFactory(cfg.AcceptInsecureCert, registryCAs),
+		ecrTokenCache:    async.NewLRUCache(tokenCacheSize, cfg.ECRTokenCacheTTL),
 		config:           cfg,
 		context:          ctx,
 		imagePullBackoff: retry.NewExponentialBackoff(minimumPullRetryDelay, maximumPullRetryDelay,
@@ -333,11 +394,18 @@ func (dg *dockerGoClient) PullImage(ctx context.Context, image string,
 	defer metrics.MetricsEngineGlobal.RecordDockerMetric("PULL_IMAGE")()
 	response := make(chan DockerContainerMetadata, 1)
 	go func() {
+		if dg.pullImageFromMirrors(ctx, image, authData) {
+			response <- DockerContainerMetadata{}
+			return
+		}
 		err := retry.RetryNWithBackoffCtx(ctx, dg.imagePullBackoff, maximumPullRetries,
 			func() error {
 				err := dg.pullImage(ctx, image, authData)
 				if err != nil {
 					seelog.Errorf("DockerGoClient: failed to pull image %s: [%s] %s", image, err.ErrorName(), err.Error())
+					if isRegistryAuthError(err) {
+						dg.invalidateAuthdata(image, authData)
+					}
 				}
 				return err
 			})
@@ -356,16 +424,40 @@ func (dg *dockerGoClient) PullImage(ctx context.Context, image string,
 		}
 		// Context was canceled even though there was no timeout. Send
 		// back an error.
-		return DockerContainerMetadata{Error: &CannotPullContainerError{err}}
+		return DockerContainerMetadata{Error: &CannotPullContainerError{FromError: err}}
 	}
 }
 
+// isRegistryAuthError returns true if the pull error indicates that the registry rejected the credentials
+func isRegistryAuthError(err apierrors.NamedError) bool {
+	if _, ok := err.(CannotPullContainerError); !ok {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	for _, authErrorMessage := range registryAuthErrorMessages {
+		if strings.Contains(message, authErrorMessage) {
+			return true
+		}
+	}
+	return false
+}
+
+// invalidateAuthdata discards the cached credentials used to pull the image, so that the next pull attempt
+// resolves them again instead of reusing credentials rejected by the registry
+func (dg *dockerGoClient) invalidateAuthdata(image string, authData *apicontainer.RegistryAuthenticationData) {
+	if authData == nil || authData.Type != apicontainer.AuthTypeECR {
+		return
+	}
+	seelog.Warnf("DockerGoClient: registry rejected the credentials used to pull image %s; discarding cached credentials", image)
+	dockerauth.NewECRAuthProvider(dg.ecrClientFactory, dg.ecrTokenCache).InvalidateAuthconfig(authData)
+}
+
 func wrapPullErrorAsNamedError(err error) apierrors.NamedError {
 	var retErr apierrors.NamedError
 	if err != nil {
 		engErr, ok := err.(apierrors.NamedError)
 		if !ok {
-			engErr = CannotPullContainerError{err}
+			engErr = newCannotPullContainerError(err)
 		}
 		retErr = engErr
 	}
@@ -393,6 +485,7 @@ func (dg *dockerGoClient) pullImage(ctx context.Context, image string,
 	imagePullOpts := types.ImagePullOptions{
 		All:          false,
 		RegistryAuth: base64.URLEncoding.EncodeToString(buf.Bytes()),
+		Platform:     hostPlatform(),
 	}
 
 	repository := getRepository(image)
@@ -421,24 +514,16 @@ func (dg *dockerGoClient) pullImage(ctx context.Context, image string,
 		reader, ch = dg.inactivityTimeoutHandler(reader, dg.config.ImagePullInactivityTimeout, cancelRequest, &canceled)
 		defer reader.Close()
 		defer close(ch)
-		decoder := json.NewDecoder(reader)
-		data := new(ImagePullResponse)
 		var statusDisplayed time.Time
-		for err := decoder.Decode(data); err != io.EOF; err = decoder.Decode(data) {
-			if err != nil {
-				seelog.Warnf("DockerGoClient: Unable to decode pull event message for image %s: %v", image, err)
-				pullFinished <- err
-				return
-			}
+		progress := newPullProgress(image, pullTaskID(ctx), dg.config.ImagePullProgressLogInterval, time.Now())
+		err = decodePullMessages(reader, image, func(data *ImagePullResponse) error {
 			if data.Error != "" {
 				seelog.Warnf("DockerGoClient: Error while pulling image %s: %v", image, data.Error)
 				pullFinished <- errors.New(data.Error)
 			}
 			if atomic.LoadUint32(&canceled) != 0 {
 				seelog.Warnf("DockerGoClient: inactivity time exceeded timeout while pulling image %s", image)
-				pullErr := errors.New("inactivity time exceeded timeout while pulling image")
-				pullFinished <- pullErr
-				return
+				return errors.New("inactivity time exceeded timeout while pulling image")
 			}
 
 			pullBeganOnce.Do(func() {
@@ -446,8 +531,13 @@ func (dg *dockerGoClient) pullImage(ctx context.Context, image string,
 			})
 
 			statusDisplayed = dg.filterPullDebugOutput(data, image, statusDisplayed)
-
-			data = new(ImagePullResponse)
+			progress.update(data, time.Now())
+			return nil
+		})
+		if err != nil {
+			seelog.Warnf("DockerGoClient: Unable to read pull event messages for image %s: %v", image, err)
+			pullFinished <- err
+			return
 		}
 		pullFinished <- nil
 	}()
@@ -457,7 +547,7 @@ func (dg *dockerGoClient) pullImage(ctx context.Context, image string,
 		break
 	case pullErr := <-pullFinished:
 		if pullErr != nil {
-			return CannotPullContainerError{pullErr}
+			return newCannotPullContainerError(pullErr)
 		}
 		seelog.Debugf("DockerGoClient: pulling image complete: %s", image)
 		return nil
@@ -468,7 +558,7 @@ func (dg *dockerGoClient) pullImage(ctx context.Context, image string,
 
 	err = <-pullFinished
 	if err != nil {
-		return CannotPullContainerError{err}
+		return newCannotPullContainerError(err)
 	}
 
 	seelog.Debugf("DockerGoClient: pulling image complete: %s", image)
@@ -512,6 +602,19 @@ func (dg *dockerGoClient) InspectImage(image string) (*types.ImageInspect, error
 }
 
 func (dg *dockerGoClient) getAuthdata(image string, authData *apicontainer.RegistryAuthenticationData) (types.AuthConfig, error) {
+	// Registered resolvers take precedence over the built-in ones
+	if len(dg.authResolvers) > 0 {
+		registry := dockerauth.RegistryHost(image)
+		for _, resolver := range dg.authResolvers {
+			authConfig, ok, err := resolver.ResolveAuth(registry, image, authData)
+			if err != nil {
+				return authConfig, fmt.Errorf("unable to resolve auth for registry %s: %w", registry, err)
+			}
+			if ok {
+				return authConfig, nil
+			}
+		}
+	}
 
 	if authData == nil {
 		return dg.auth.GetAuthconfig(image, nil)
@@ -519,8 +622,8 @@ func (dg *dockerGoClient) getAuthdata(image string, authData *apicontainer.Regis
 
 	switch authData.Type {
 	case apicontainer.AuthTypeECR:
-		provider := dockerauth.NewECRAuthProvider(dg.ecrClientFactory, dg.ecrTokenCache)
-		authConfig, err := provider.GetAuthconfig(image, authData)
+		resolver := dockerauth.NewECRRegistryAuthResolver(dg.ecrClientFactory, dg.ecrTokenCache)
+		authConfig, _, err := resolver.ResolveAuth(dockerauth.RegistryHost(image), image, authData)
 		if err != nil {
 			return authConfig, CannotPullECRContainerError{err}
 		}
@@ -677,6 +780,114 @@ func (dg *dockerGoClient) InspectContainer(ctx context.Context, dockerID string,
 	}
 }
 
+func (dg *dockerGoClient) ContainerLogs(ctx context.Context, dockerID string, tail int, timeout time.Duration) ([]byte, error) {
+	type logsResponse struct {
+		logs []byte
+		err  error
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	defer metrics.MetricsEngineGlobal.RecordDockerMetric("CONTAINER_LOGS")()
+	// Buffered channel so in the case of timeout it takes one write, never gets
+	// read, and can still be GC'd
+	response := make(chan logsResponse, 1)
+	go func() {
+		logs, err := dg.containerLogs(ctx, dockerID, tail)
+		response <- logsResponse{logs, err}
+	}()
+
+	select {
+	case resp := <-response:
+		return resp.logs, resp.err
+	case <-ctx.Done():
+		err := ctx.Err()
+		if err == context.DeadlineExceeded {
+			return nil, &DockerTimeoutError{timeout, "getting logs"}
+		}
+		return nil, &CannotGetContainerLogsError{err}
+	}
+}
+
+func (dg *dockerGoClient) containerLogs(ctx context.Context, dockerID string, tail int) ([]byte, error) {
+	client, err := dg.sdkDockerClient()
+	if err != nil {
+		return nil, err
+	}
+	logsReader, err := client.ContainerLogs(ctx, dockerID, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Tail:       strconv.Itoa(tail),
+	})
+	if err != nil {
+		return nil, &CannotGetContainerLogsError{err}
+	}
+	defer logsReader.Close()
+	logs, err := readContainerLogsTail(logsReader, maxContainerLogsOutputSize)
+	if err != nil {
+		return nil, &CannotGetContainerLogsError{err}
+	}
+	return logs, nil
+}
+
+// readContainerLogsTail reads the logs of a container and returns their last maxSize bytes. The headers of the
+// frames of the logs of a container without a tty, where stdout and stderr are multiplexed, are stripped while
+// reading, keeping the lines of both in order. The logs of a container with a tty are not multiplexed and are
+// read as is. Reading stops at a frame truncated in the middle of its header.
+func readContainerLogsTail(reader io.Reader, maxSize int) ([]byte, error) {
+	tail := &logsTailBuffer{maxSize: maxSize}
+	logsReader := bufio.NewReader(reader)
+	if header, _ := logsReader.Peek(containerLogsHeaderSize); !isContainerLogsFrameHeader(header) {
+		_, err := io.Copy(tail, logsReader)
+		return tail.Bytes(), err
+	}
+	header := make([]byte, containerLogsHeaderSize)
+	for {
+		if _, err := io.ReadFull(logsReader, header); err != nil || !isContainerLogsFrameHeader(header) {
+			if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+				return nil, err
+			}
+			return tail.Bytes(), nil
+		}
+		frameSize := int64(binary.BigEndian.Uint32(header[4:containerLogsHeaderSize]))
+		if _, err := io.CopyN(tail, logsReader, frameSize); err != nil {
+			if err != io.EOF {
+				return nil, err
+			}
+			return tail.Bytes(), nil
+		}
+	}
+}
+
+// isContainerLogsFrameHeader returns true if the bytes are the header of a frame of multiplexed logs, which
+// starts with the stream of the frame followed by three zero bytes
+func isContainerLogsFrameHeader(header []byte) bool {
+	return len(header) == containerLogsHeaderSize && header[0] <= 2 &&
+		header[1] == 0 && header[2] == 0 && header[3] == 0
+}
+
+// logsTailBuffer is a writer keeping the last maxSize bytes written to it
+type logsTailBuffer struct {
+	maxSize int
+	buf     []byte
+}
+
+func (b *logsTailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	// Drop the oldest bytes once the buffer holds twice the bytes kept, so that they are not moved on each write
+	if len(b.buf) > 2*b.maxSize {
+		b.buf = append(b.buf[:0], b.buf[len(b.buf)-b.maxSize:]...)
+	}
+	return len(p), nil
+}
+
+// Bytes returns the last maxSize bytes written to the buffer
+func (b *logsTailBuffer) Bytes() []byte {
+	if len(b.buf) > b.maxSize {
+		return b.buf[len(b.buf)-b.maxSize:]
+	}
+	return b.buf
+}
+
 func (dg *dockerGoClient) inspectContainer(ctx context.Context, dockerID string) (*types.ContainerJSON, error) {
 	client, err := dg.sdkDockerClient()
 	if err != nil {
@@ -732,6 +943,83 @@ func (dg *dockerGoClient) stopContainer(ctx context.Context, dockerID string, ti
 	return metadata
 }
 
+func (dg *dockerGoClient) KillContainer(ctx context.Context, dockerID string, signal string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	defer metrics.MetricsEngineGlobal.RecordDockerMetric("KILL_CONTAINER")()
+	// Buffered channel so in the case of timeout it takes one write, never gets
+	// read, and can still be GC'd
+	response := make(chan error, 1)
+	go func() { response <- dg.killContainer(ctx, dockerID, signal) }()
+	select {
+	case resp := <-response:
+		return resp
+	case <-ctx.Done():
+		// Context has either expired or canceled. If it has timed out,
+		// send back the DockerTimeoutError
+		err := ctx.Err()
+		if err == context.DeadlineExceeded {
+			return &DockerTimeoutError{timeout, "killed"}
+		}
+		return CannotStopContainerError{err}
+	}
+}
+
+func (dg *dockerGoClient) killContainer(ctx context.Context, dockerID string, signal string) error {
+	client, err := dg.sdkDockerClient()
+	if err != nil {
+		return err
+	}
+	err = client.ContainerKill(ctx, dockerID, signal)
+	if err != nil {
+		seelog.Errorf("DockerGoClient: error sending signal %s to container ID=%s: %v", signal, dockerID, err)
+		if strings.Contains(err.Error(), "No such container") {
+			err = NoSuchContainerError{dockerID}
+		}
+		return CannotStopContainerError{err}
+	}
+	return nil
+}
+
+func (dg *dockerGoClient) UpdateContainer(ctx context.Context, dockerID string, resources dockercontainer.Resources,
+	timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	defer metrics.MetricsEngineGlobal.RecordDockerMetric("UPDATE_CONTAINER")()
+	// Buffered channel so in the case of timeout it takes one write, never gets
+	// read, and can still be GC'd
+	response := make(chan error, 1)
+	go func() { response <- dg.updateContainer(ctx, dockerID, resources) }()
+	select {
+	case resp := <-response:
+		return resp
+	case <-ctx.Done():
+		// Context has either expired or canceled. If it has timed out,
+		// send back the DockerTimeoutError
+		err := ctx.Err()
+		if err == context.DeadlineExceeded {
+			return &DockerTimeoutError{timeout, "updated"}
+		}
+		return CannotUpdateContainerError{err}
+	}
+}
+
+func (dg *dockerGoClient) updateContainer(ctx context.Context, dockerID string, resources dockercontainer.Resources) error {
+	client, err := dg.sdkDockerClient()
+	if err != nil {
+		return err
+	}
+	_, err = client.ContainerUpdate(ctx, dockerID, dockercontainer.UpdateConfig{Resources: resources})
+	if err != nil {
+		seelog.Errorf("DockerGoClient: error updating the resources of container ID=%s: %v", dockerID, err)
+		if strings.Contains(err.Error(), "No such container") {
+			err = NoSuchContainerError{dockerID}
+		}
+		return CannotUpdateContainerError{err}
+	}
+	return nil
+}
+
 func (dg *dockerGoClient) RemoveContainer(ctx context.Context, dockerID string, timeout time.Duration) error {
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
@@ -819,6 +1107,7 @@ func MetadataFromContainer(dockerContainer *types.ContainerJSON) DockerContainer
 
 	if dockerContainer.Config != nil {
 		metadata.Labels = dockerContainer.Config.Labels
+		metadata.StopSignal = dockerContainer.Config.StopSignal
 	}
 
 	if dockerContainer.State == nil {
@@ -885,6 +1174,7 @@ func (dg *dockerGoClient) ContainerEvents(ctx context.Context) (<-chan DockerCon
 	events := make(chan *events.Message)
 	buffer := NewInfiniteBuffer()
 
+	changedContainers := make(chan DockerContainerChangeEvent)
 	derivedCtx, cancel := context.WithCancel(ctx)
 	dockerEvents, eventErr := client.Events(derivedCtx, types.EventsOptions{})
 
@@ -893,6 +1183,8 @@ func (dg *dockerGoClient) ContainerEvents(ctx context.Context) (<-chan DockerCon
 	// Receive errors from channels. If error thrown is not EOF, log and reopen channel.
 	// TODO: move the error check into StartListening() to keep event streaming and error handling in one place.
 	go func() {
+		backoff := retry.NewExponentialBackoff(minimumEventStreamReconnectDelay, maximumEventStreamReconnectDelay,
+			eventStreamReconnectJitterMultiplier, eventStreamReconnectDelayMultiplier)
 		for {
 			select {
 			case err := <-eventErr:
@@ -907,6 +1199,14 @@ func (dg *dockerGoClient) ContainerEvents(ctx context.Context) (<-chan DockerCon
 					seelog.Errorf("DockerGoClient: Docker events stream closed with error: %v", err)
 				}
 
+				// The stream also breaks when the docker daemon restarts, in which case reopening it fails
+				// until the daemon is back up.
+				select {
+				case <-dg.time().After(backoff.Duration()):
+				case <-ctx.Done():
+					return
+				}
+
 				// Reopen a new event stream to continue listening.
 				nextCtx, nextCancel := context.WithCancel(ctx)
 				dockerEvents, eventErr = client.Events(nextCtx, types.EventsOptions{})
@@ -916,6 +1216,14 @@ func (dg *dockerGoClient) ContainerEvents(ctx context.Context) (<-chan DockerCon
 				cancel()
 				// Reassign cancel variable next Cancel function to setup next iteration of loop.
 				cancel = nextCancel
+
+				// Events emitted while the stream was closed are lost, so re-sync the state of the
+				// containers from the daemon.
+				if err := dg.resyncContainerStates(ctx, changedContainers); err != nil {
+					seelog.Warnf("DockerGoClient: unable to re-sync container states after reopening the Docker events stream: %v", err)
+					continue
+				}
+				backoff.Reset()
 			case <-ctx.Done():
 				return
 			}
@@ -924,7 +1232,6 @@ func (dg *dockerGoClient) ContainerEvents(ctx context.Context) (<-chan DockerCon
 
 	// Read the buffered events and send to task engine
 	go buffer.Consume(events)
-	changedContainers := make(chan DockerContainerChangeEvent)
 	go dg.handleContainerEvents(ctx, events, changedContainers)
 
 	return changedContainers, nil
@@ -993,6 +1300,47 @@ func (dg *dockerGoClient) handleContainerEvents(ctx context.Context,
 	}
 }
 
+// resyncContainerStates reports the state of all the containers of the docker daemon as container change events.
+// Running containers are reported as running, which does not change containers the task engine already knows as
+// running, and containers that exited are reported as stopped along with their exit code. Containers in any other
+// state are not reported.
+func (dg *dockerGoClient) resyncContainerStates(ctx context.Context,
+	changedContainers chan<- DockerContainerChangeEvent) error {
+	listResponse := dg.ListContainers(ctx, true, dockerclient.ListContainersTimeout)
+	if listResponse.Error != nil {
+		return listResponse.Error
+	}
+	for _, containerID := range listResponse.DockerIDs {
+		dockerContainer, err := dg.InspectContainer(ctx, containerID, dockerclient.InspectContainerTimeout)
+		if err != nil {
+			seelog.Warnf("DockerGoClient: unable to inspect container %s to re-sync its state: %v", containerID, err)
+			continue
+		}
+		if dockerContainer.State == nil {
+			continue
+		}
+		var status apicontainerstatus.ContainerStatus
+		switch {
+		case dockerContainer.State.Running:
+			status = apicontainerstatus.ContainerRunning
+		case dockerContainer.State.Status == "exited" || dockerContainer.State.Status == "dead":
+			status = apicontainerstatus.ContainerStopped
+		default:
+			continue
+		}
+		select {
+		case changedContainers <- DockerContainerChangeEvent{
+			Status:                  status,
+			Type:                    apicontainer.ContainerStatusEvent,
+			DockerContainerMetadata: MetadataFromContainer(dockerContainer),
+		}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
 // setExitCodeFromEvent tries to get exit code from event and stores it in metadata, if metadata doesn't
 // contain the exit code already.
 func setExitCodeFromEvent(event *events.Message, metadata *DockerContainerMetadata) {
@@ -1021,7 +1369,7 @@ func (dg *dockerGoClient) ListContainers(ctx context.Context, all bool, timeout
 	// Buffered channel so in the case of timeout it takes one write, never gets
 	// read, and can still be GC'd
 	response := make(chan ListContainersResponse, 1)
-	go func() { response <- dg.listContainers(ctx, all) }()
+	go func() { response <- dg.listContainers(ctx, types.ContainerListOptions{All: all}) }()
 	select {
 	case resp := <-response:
 		return resp
@@ -1036,15 +1384,40 @@ func (dg *dockerGoClient) ListContainers(ctx context.Context, all bool, timeout
 	}
 }
 
-func (dg *dockerGoClient) listContainers(ctx context.Context, all bool) ListContainersResponse {
+// ListContainersWithFilters returns the IDs of the containers in any state matching the filters, along with
+// their labels.
+func (dg *dockerGoClient) ListContainersWithFilters(ctx context.Context, filters filters.Args,
+	timeout time.Duration) ListContainersResponse {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Buffered channel so in the case of timeout it takes one write, never gets
+	// read, and can still be GC'd
+	response := make(chan ListContainersResponse, 1)
+	go func() {
+		response <- dg.listContainers(ctx, types.ContainerListOptions{All: true, Filters: filters})
+	}()
+	select {
+	case resp := <-response:
+		return resp
+	case <-ctx.Done():
+		// Context has either expired or canceled. If it has timed out,
+		// send back the DockerTimeoutError
+		err := ctx.Err()
+		if err == context.DeadlineExceeded {
+			return ListContainersResponse{Error: &DockerTimeoutError{timeout, "listing"}}
+		}
+		return ListContainersResponse{Error: &CannotListContainersError{err}}
+	}
+}
+
+func (dg *dockerGoClient) listContainers(ctx context.Context, options types.ContainerListOptions) ListContainersResponse {
 	client, err := dg.sdkDockerClient()
 	if err != nil {
 		return ListContainersResponse{Error: err}
 	}
 
-	containers, err := client.ContainerList(ctx, types.ContainerListOptions{
-		All: all,
-	})
+	containers, err := client.ContainerList(ctx, options)
 	if err != nil {
 		return ListContainersResponse{Error: err}
 	}
@@ -1052,11 +1425,15 @@ func (dg *dockerGoClient) listContainers(ctx context.Context, all bool) ListCont
 	// We get an empty slice if there are no containers to be listed.
 	// Extract container IDs from this list.
 	containerIDs := make([]string, len(containers))
+	imageIDs := make(map[string]string, len(containers))
+	labels := make(map[string]map[string]string, len(containers))
 	for i, container := range containers {
 		containerIDs[i] = container.ID
+		imageIDs[container.ID] = container.ImageID
+		labels[container.ID] = container.Labels
 	}
 
-	return ListContainersResponse{DockerIDs: containerIDs, Error: nil}
+	return ListContainersResponse{DockerIDs: containerIDs, ImageIDs: imageIDs, Labels: labels, Error: nil}
 }
 
 func (dg *dockerGoClient) ListImages(ctx context.Context, timeout time.Duration) ListImagesResponse {
@@ -1088,11 +1465,13 @@ func (dg *dockerGoClient) listImages(ctx context.Context) ListImagesResponse {
 	}
 	var imageRepoTags []string
 	imageIDs := make([]string, len(images))
+	repoTagsByImageID := make(map[string][]string, len(images))
 	for i, image := range images {
 		imageIDs[i] = image.ID
 		imageRepoTags = append(imageRepoTags, image.RepoTags...)
+		repoTagsByImageID[image.ID] = image.RepoTags
 	}
-	return ListImagesResponse{ImageIDs: imageIDs, RepoTags: imageRepoTags, Error: nil}
+	return ListImagesResponse{ImageIDs: imageIDs, RepoTags: imageRepoTags, ImageRepoTags: repoTagsByImageID, Error: nil}
 }
 
 func (dg *dockerGoClient) SystemPing(ctx context.Context, timeout time.Duration) PingResponse {
@@ -1670,6 +2049,51 @@ func (dg *dockerGoClient) startContainerExec(ctx context.Context, execID string,
 	return nil
 }
 
+func (dg *dockerGoClient) AttachContainerExec(ctx context.Context, execID string, execStartCheck types.ExecStartCheck, timeout time.Duration) ([]byte, error) {
+	type attachContainerExecResponse struct {
+		output []byte
+		err    error
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	defer metrics.MetricsEngineGlobal.RecordDockerMetric("ATTACH_CONTAINER_EXEC")()
+	response := make(chan attachContainerExecResponse, 1)
+	go func() {
+		output, err := dg.attachContainerExec(ctx, execID, execStartCheck)
+		response <- attachContainerExecResponse{output, err}
+	}()
+
+	select {
+	case resp := <-response:
+		return resp.output, resp.err
+	case <-ctx.Done():
+		err := ctx.Err()
+		if err == context.DeadlineExceeded {
+			return nil, &DockerTimeoutError{timeout, "attach exec command"}
+		}
+		return nil, &CannotStartContainerExecError{err}
+	}
+}
+
+func (dg *dockerGoClient) attachContainerExec(ctx context.Context, execID string, execStartCheck types.ExecStartCheck) ([]byte, error) {
+	client, err := dg.sdkDockerClient()
+	if err != nil {
+		return nil, err
+	}
+
+	hijacked, err := client.ContainerExecAttach(ctx, execID, execStartCheck)
+	if err != nil {
+		return nil, &CannotStartContainerExecError{err}
+	}
+	defer hijacked.Close()
+	output, err := ioutil.ReadAll(io.LimitReader(hijacked.Reader, maxContainerExecOutputSize))
+	if err != nil {
+		return nil, &CannotStartContainerExecError{err}
+	}
+	return output, nil
+}
+
 func (dg *dockerGoClient) InspectContainerExec(ctx context.Context, execID string, timeout time.Duration) (*types.ContainerExecInspect, error) {
 	type inspectContainerExecResponse struct {
 		execInspect *types.ContainerExecInspect
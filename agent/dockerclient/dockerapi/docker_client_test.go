@@ -17,11 +17,15 @@
 package dockerapi
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"io"
 	"io/ioutil"
+	"net"
 	"reflect"
 	"strconv"
 	"strings"
@@ -36,6 +40,7 @@ import (
 	"github.com/aws/amazon-ecs-agent/agent/config"
 	"github.com/aws/amazon-ecs-agent/agent/credentials"
 	"github.com/aws/amazon-ecs-agent/agent/dockerclient"
+	"github.com/aws/amazon-ecs-agent/agent/dockerclient/dockerauth"
 	mock_sdkclient "github.com/aws/amazon-ecs-agent/agent/dockerclient/sdkclient/mocks"
 	mock_sdkclientfactory "github.com/aws/amazon-ecs-agent/agent/dockerclient/sdkclientfactory/mocks"
 	"github.com/aws/amazon-ecs-agent/agent/ec2"
@@ -215,6 +220,159 @@ func TestImagePull(t *testing.T) {
 	assert.NoError(t, metadata.Error, "Expected pull to succeed")
 }
 
+func TestImagePullSelectsHostPlatform(t *testing.T) {
+	mockDockerSDK, client, testTime, _, _, done := dockerClientSetup(t)
+	defer done()
+
+	originalHostPlatform := hostPlatform
+	defer func() {
+		hostPlatform = originalHostPlatform
+	}()
+	hostPlatform = func() string { return "linux/arm64" }
+
+	testTime.EXPECT().After(gomock.Any()).AnyTimes()
+
+	mockDockerSDK.EXPECT().ImagePull(gomock.Any(), "image:latest", gomock.Any()).DoAndReturn(
+		func(ctx context.Context, ref string, options types.ImagePullOptions) (io.ReadCloser, error) {
+			// the arm64 variant of multi-platform images is selected
+			assert.Equal(t, "linux/arm64", options.Platform)
+			return mockReadCloser{
+				reader: strings.NewReader(`{"status":"pull complete"}`),
+			}, nil
+		})
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	metadata := client.PullImage(ctx, "image", nil, defaultTestConfig().ImagePullTimeout)
+	assert.NoError(t, metadata.Error, "Expected pull to succeed")
+}
+
+func TestImagePullSkipsMalformedMessages(t *testing.T) {
+	mockDockerSDK, client, testTime, _, _, done := dockerClientSetup(t)
+	defer done()
+
+	testTime.EXPECT().After(gomock.Any()).AnyTimes()
+
+	mockDockerSDK.EXPECT().ImagePull(gomock.Any(), "image:latest", gomock.Any()).Return(
+		mockReadCloser{
+			reader: strings.NewReader("{\"status\":\"pull in progress\"}\n{\"status\":\n{\"status\":\"pull complete\"}\n"),
+		}, nil)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	metadata := client.PullImage(WithPullTaskID(ctx, "task-id"), "image", nil, defaultTestConfig().ImagePullTimeout)
+	assert.NoError(t, metadata.Error, "Expected pull to succeed")
+}
+
+func TestMirrorImageReference(t *testing.T) {
+	testCases := []struct {
+		image    string
+		expected string
+		mirrored bool
+	}{
+		{image: "image", expected: "mirror.example.com/library/image:latest", mirrored: true},
+		{image: "user/image:tag", expected: "mirror.example.com/user/image:tag", mirrored: true},
+		{image: "docker.io/library/image:tag", expected: "mirror.example.com/library/image:tag", mirrored: true},
+		{image: "registry.example.com/image:tag", mirrored: false},
+		{image: "image@sha256:bc8813ea7b3603864987522f02a76101c17ad122e1c46d790efc0fca78ca7bfb", mirrored: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.image, func(t *testing.T) {
+			mirrorImage, ok := mirrorImageReference(tc.image, "mirror.example.com")
+			assert.Equal(t, tc.mirrored, ok)
+			assert.Equal(t, tc.expected, mirrorImage)
+		})
+	}
+}
+
+func TestImagePullFromFirstMirror(t *testing.T) {
+	mockDockerSDK, client, testTime, _, _, done := dockerClientSetup(t)
+	defer done()
+	client.config.ImagePullMirrors = []string{"mirror-a.example.com", "mirror-b.example.com"}
+
+	testTime.EXPECT().After(gomock.Any()).AnyTimes()
+	gomock.InOrder(
+		mockDockerSDK.EXPECT().ImagePull(gomock.Any(), "mirror-a.example.com/library/image:latest", gomock.Any()).Return(
+			mockReadCloser{
+				reader: strings.NewReader(`{"status":"pull complete"}`),
+			}, nil),
+		mockDockerSDK.EXPECT().ImageTag(gomock.Any(), "mirror-a.example.com/library/image:latest", "image:latest").Return(nil),
+		mockDockerSDK.EXPECT().ImageRemove(gomock.Any(), "mirror-a.example.com/library/image:latest", gomock.Any()).Return(nil, nil),
+	)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	metadata := client.PullImage(ctx, "image", nil, defaultTestConfig().ImagePullTimeout)
+	assert.NoError(t, metadata.Error, "Expected pull to succeed")
+}
+
+func TestImagePullFailsOverToSecondMirror(t *testing.T) {
+	mockDockerSDK, client, testTime, _, _, done := dockerClientSetup(t)
+	defer done()
+	client.config.ImagePullMirrors = []string{"mirror-a.example.com", "mirror-b.example.com"}
+
+	testTime.EXPECT().After(gomock.Any()).AnyTimes()
+	gomock.InOrder(
+		mockDockerSDK.EXPECT().ImagePull(gomock.Any(), "mirror-a.example.com/library/image:tag", gomock.Any()).Return(
+			nil, errors.New("dial tcp 10.0.0.1:443: connect: connection refused")),
+		mockDockerSDK.EXPECT().ImagePull(gomock.Any(), "mirror-b.example.com/library/image:tag", gomock.Any()).Return(
+			mockReadCloser{
+				reader: strings.NewReader(`{"status":"pull complete"}`),
+			}, nil),
+		mockDockerSDK.EXPECT().ImageTag(gomock.Any(), "mirror-b.example.com/library/image:tag", "image:tag").Return(nil),
+		mockDockerSDK.EXPECT().ImageRemove(gomock.Any(), "mirror-b.example.com/library/image:tag", gomock.Any()).Return(nil, nil),
+	)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	metadata := client.PullImage(ctx, "image:tag", nil, defaultTestConfig().ImagePullTimeout)
+	assert.NoError(t, metadata.Error, "Expected pull to succeed")
+}
+
+func TestImagePullFromRegistryWhenAllMirrorsFail(t *testing.T) {
+	mockDockerSDK, client, testTime, _, _, done := dockerClientSetup(t)
+	defer done()
+	client.config.ImagePullMirrors = []string{"mirror-a.example.com", "mirror-b.example.com"}
+
+	testTime.EXPECT().After(gomock.Any()).AnyTimes()
+	gomock.InOrder(
+		mockDockerSDK.EXPECT().ImagePull(gomock.Any(), "mirror-a.example.com/library/image:latest", gomock.Any()).Return(
+			nil, errors.New("dial tcp: lookup mirror-a.example.com: no such host")),
+		mockDockerSDK.EXPECT().ImagePull(gomock.Any(), "mirror-b.example.com/library/image:latest", gomock.Any()).Return(
+			nil, errors.New("received unexpected HTTP status: 503 Service Unavailable")),
+		mockDockerSDK.EXPECT().ImagePull(gomock.Any(), "image:latest", gomock.Any()).Return(
+			mockReadCloser{
+				reader: strings.NewReader(`{"status":"pull complete"}`),
+			}, nil),
+	)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	metadata := client.PullImage(ctx, "image", nil, defaultTestConfig().ImagePullTimeout)
+	assert.NoError(t, metadata.Error, "Expected pull to succeed")
+}
+
+func TestImagePullFromRegistryWhenMirrorMissesImage(t *testing.T) {
+	mockDockerSDK, client, testTime, _, _, done := dockerClientSetup(t)
+	defer done()
+	client.config.ImagePullMirrors = []string{"mirror-a.example.com", "mirror-b.example.com"}
+
+	testTime.EXPECT().After(gomock.Any()).AnyTimes()
+	gomock.InOrder(
+		mockDockerSDK.EXPECT().ImagePull(gomock.Any(), "mirror-a.example.com/library/image:latest", gomock.Any()).Return(
+			nil, errors.New("manifest for mirror-a.example.com/library/image:latest not found")),
+		mockDockerSDK.EXPECT().ImagePull(gomock.Any(), "image:latest", gomock.Any()).Return(
+			mockReadCloser{
+				reader: strings.NewReader(`{"status":"pull complete"}`),
+			}, nil),
+	)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	metadata := client.PullImage(ctx, "image", nil, defaultTestConfig().ImagePullTimeout)
+	assert.NoError(t, metadata.Error, "Expected pull to succeed")
+}
+
 func TestImagePullTag(t *testing.T) {
 	mockDockerSDK, client, testTime, _, _, done := dockerClientSetup(t)
 	defer done()
@@ -275,6 +433,7 @@ func TestPullImageECRSuccess(t *testing.T) {
 	imagePullOpts := types.ImagePullOptions{
 		All:          false,
 		RegistryAuth: "eyJ1c2VybmFtZSI6InVzZXJuYW1lIiwicGFzc3dvcmQiOiJwYXNzd29yZCIsInNlcnZlcmFkZHJlc3MiOiJodHRwczovL3JlZ2lzdHJ5LmVuZHBvaW50In0K",
+		Platform:     hostPlatform(),
 	}
 
 	ecrClientFactory.EXPECT().GetClient(authData.ECRAuthData).Return(ecrClient, nil)
@@ -295,6 +454,140 @@ func TestPullImageECRSuccess(t *testing.T) {
 	assert.NoError(t, metadata.Error, "Expected pull to succeed")
 }
 
+type fakeRegistryAuthResolver struct {
+	registry   string
+	authConfig types.AuthConfig
+	err        error
+	resolved   []string
+}
+
+func (resolver *fakeRegistryAuthResolver) ResolveAuth(registry string, image string,
+	registryAuthData *apicontainer.RegistryAuthenticationData) (types.AuthConfig, bool, error) {
+	if registry != resolver.registry {
+		return types.AuthConfig{}, false, nil
+	}
+	resolver.resolved = append(resolver.resolved, image)
+	return resolver.authConfig, true, resolver.err
+}
+
+func TestPullImageRegistryAuthResolver(t *testing.T) {
+	mockDockerSDK, client, mockTime, _, _, done := dockerClientSetup(t)
+	defer done()
+
+	mockTime.EXPECT().After(gomock.Any()).AnyTimes()
+	resolver := &fakeRegistryAuthResolver{
+		registry: "registry.example.com",
+		authConfig: types.AuthConfig{
+			Username:      "username",
+			Password:      "password",
+			ServerAddress: "registry.example.com",
+		},
+	}
+	client.authResolvers = []dockerauth.RegistryAuthResolver{resolver}
+	image := "registry.example.com/myimage:tag"
+
+	var buf bytes.Buffer
+	require.NoError(t, json.NewEncoder(&buf).Encode(resolver.authConfig))
+	imagePullOpts := types.ImagePullOptions{
+		All:          false,
+		RegistryAuth: base64.URLEncoding.EncodeToString(buf.Bytes()),
+		Platform:     hostPlatform(),
+	}
+	mockDockerSDK.EXPECT().ImagePull(gomock.Any(), image, imagePullOpts).Return(
+		mockReadCloser{
+			reader: strings.NewReader(`{"status":"pull complete"}`),
+		}, nil)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	// the resolver takes precedence over the ECR auth data of the container
+	authData := &apicontainer.RegistryAuthenticationData{
+		Type:        apicontainer.AuthTypeECR,
+		ECRAuthData: &apicontainer.ECRAuthData{RegistryID: "123456789012"},
+	}
+	metadata := client.PullImage(ctx, image, authData, defaultTestConfig().ImagePullTimeout)
+	assert.NoError(t, metadata.Error, "Expected pull to succeed")
+	assert.Equal(t, []string{image}, resolver.resolved)
+}
+
+func TestPullImageRegistryAuthResolverError(t *testing.T) {
+	_, client, mockTime, _, _, done := dockerClientSetup(t)
+	defer done()
+
+	mockTime.EXPECT().After(gomock.Any()).AnyTimes()
+	client.authResolvers = []dockerauth.RegistryAuthResolver{&fakeRegistryAuthResolver{
+		registry: "registry.example.com",
+		err:      errors.New("broker unavailable"),
+	}}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	metadata := client.PullImage(ctx, "registry.example.com/myimage:tag", nil, defaultTestConfig().ImagePullTimeout)
+	require.Error(t, metadata.Error, "Expected pull to fail")
+	assert.Contains(t, metadata.Error.Error(), "broker unavailable")
+}
+
+func TestPullImageECRAuthRejectedInvalidatesCachedToken(t *testing.T) {
+	mockDockerSDK, client, mockTime, ctrl, ecrClientFactory, done := dockerClientSetup(t)
+	defer done()
+
+	mockTime.EXPECT().After(gomock.Any()).AnyTimes()
+	ecrClient := mock_ecr.NewMockECRClient(ctrl)
+
+	registryID := "123456789012"
+	authData := &apicontainer.RegistryAuthenticationData{
+		Type: "ecr",
+		ECRAuthData: &apicontainer.ECRAuthData{
+			RegistryID: registryID,
+			Region:     "eu-west-1",
+		},
+	}
+	imageEndpoint := "registry.endpoint"
+	image := imageEndpoint + "/myimage:tag"
+	ecrAuthData := &ecrapi.AuthorizationData{
+		ProxyEndpoint:      aws.String("https://" + imageEndpoint),
+		AuthorizationToken: aws.String(base64.StdEncoding.EncodeToString([]byte("username:password"))),
+		ExpiresAt:          aws.Time(time.Now().Add(12 * time.Hour)),
+	}
+
+	// The token is cached after the first request, so it is only requested again because the
+	// registry rejected it
+	ecrClientFactory.EXPECT().GetClient(authData.ECRAuthData).Return(ecrClient, nil).Times(2)
+	ecrClient.EXPECT().GetAuthorizationToken(registryID).Return(ecrAuthData, nil).Times(2)
+	gomock.InOrder(
+		mockDockerSDK.EXPECT().ImagePull(gomock.Any(), image, gomock.Any()).Return(
+			nil, errors.New("Error response from daemon: pull access denied: 403 Forbidden")),
+		mockDockerSDK.EXPECT().ImagePull(gomock.Any(), image, gomock.Any()).Return(
+			mockReadCloser{
+				reader: strings.NewReader(`{"status":"pull complete"}`),
+			}, nil),
+	)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	metadata := client.PullImage(ctx, image, authData, defaultTestConfig().ImagePullTimeout)
+	assert.NoError(t, metadata.Error, "Expected pull to succeed with a refreshed token")
+}
+
+func TestIsRegistryAuthError(t *testing.T) {
+	testCases := []struct {
+		err      apierrors.NamedError
+		expected bool
+	}{
+		{err: CannotPullContainerError{FromError: errors.New("unauthorized: authentication required")}, expected: true},
+		{err: CannotPullContainerError{FromError: errors.New("Get https://registry/v2/: no basic auth credentials")}, expected: true},
+		{err: CannotPullContainerError{FromError: errors.New("received unexpected HTTP status: 403 Forbidden")}, expected: true},
+		{err: CannotPullContainerError{FromError: errors.New("manifest for image:tag not found")}, expected: false},
+		{err: CannotPullECRContainerError{errors.New("AccessDenied")}, expected: false},
+		{err: &DockerTimeoutError{}, expected: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.err.Error(), func(t *testing.T) {
+			assert.Equal(t, tc.expected, isRegistryAuthError(tc.err))
+		})
+	}
+}
+
 func TestPullImageECRAuthFail(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -406,6 +699,37 @@ func TestCreateContainerTimeout(t *testing.T) {
 	wait.Done()
 }
 
+func TestCreateContainerTimeoutCancelsCreate(t *testing.T) {
+	mockDockerSDK, client, _, _, _, done := dockerClientSetup(t)
+	defer done()
+
+	// The create call only returns once its context is done, which happens when the create timeout expires
+	createErr := make(chan error, 1)
+	mockDockerSDK.EXPECT().ContainerCreate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "containerName").
+		DoAndReturn(func(ctx context.Context, config *dockercontainer.Config, hostConfig *dockercontainer.HostConfig,
+			networkingConfig *network.NetworkingConfig, name string) (dockercontainer.ContainerCreateCreatedBody, error) {
+			<-ctx.Done()
+			createErr <- ctx.Err()
+			return dockercontainer.ContainerCreateCreatedBody{}, ctx.Err()
+		})
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	start := time.Now()
+	metadata := client.CreateContainer(ctx, &dockercontainer.Config{}, &dockercontainer.HostConfig{}, "containerName",
+		xContainerShortTimeout)
+	assert.True(t, time.Since(start) < time.Second, "the create call should not outlive its timeout")
+	require.Error(t, metadata.Error)
+	assert.Equal(t, "DockerTimeoutError", metadata.Error.(apierrors.NamedError).ErrorName())
+	assert.Empty(t, metadata.DockerID)
+
+	select {
+	case err := <-createErr:
+		assert.Equal(t, context.DeadlineExceeded, err, "the create call should be cancelled at the deadline")
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the create call to be cancelled")
+	}
+}
+
 func TestCreateContainer(t *testing.T) {
 	mockDockerSDK, client, _, _, _, done := dockerClientSetup(t)
 	defer done()
@@ -543,6 +867,99 @@ func TestStartContainerExec(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestAttachContainerExec(t *testing.T) {
+	mockDockerSDK, client, _, _, _, done := dockerClientSetup(t)
+	defer done()
+
+	conn, peer := net.Pipe()
+	defer peer.Close()
+	execStartCheck := types.ExecStartCheck{Tty: true}
+	mockDockerSDK.EXPECT().ContainerExecAttach(gomock.Any(), "id", execStartCheck).Return(types.HijackedResponse{
+		Conn:   conn,
+		Reader: bufio.NewReader(strings.NewReader("1700000000\r\n")),
+	}, nil)
+
+	output, err := client.AttachContainerExec(context.TODO(), "id", execStartCheck, dockerclient.ContainerExecAttachTimeout)
+	assert.NoError(t, err)
+	assert.Equal(t, "1700000000\r\n", string(output))
+}
+
+func TestAttachContainerExecError(t *testing.T) {
+	mockDockerSDK, client, _, _, _, done := dockerClientSetup(t)
+	defer done()
+
+	mockDockerSDK.EXPECT().ContainerExecAttach(gomock.Any(), "id", gomock.Any()).
+		Return(types.HijackedResponse{}, errors.New("error"))
+
+	_, err := client.AttachContainerExec(context.TODO(), "id", types.ExecStartCheck{Tty: true}, dockerclient.ContainerExecAttachTimeout)
+	assert.Error(t, err)
+	assert.Equal(t, "CannotStartContainerExecError", err.(apierrors.NamedError).ErrorName())
+}
+
+func TestContainerLogs(t *testing.T) {
+	mockDockerSDK, client, _, _, _, done := dockerClientSetup(t)
+	defer done()
+
+	// The logs of a container without a tty multiplex stdout and stderr in frames with an 8 byte header
+	multiplexedLogs := []byte{1, 0, 0, 0, 0, 0, 0, 7}
+	multiplexedLogs = append(multiplexedLogs, "stdout\n"...)
+	multiplexedLogs = append(multiplexedLogs, 2, 0, 0, 0, 0, 0, 0, 7)
+	multiplexedLogs = append(multiplexedLogs, "stderr\n"...)
+	mockDockerSDK.EXPECT().ContainerLogs(gomock.Any(), "id", types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Tail:       "10",
+	}).Return(ioutil.NopCloser(bytes.NewReader(multiplexedLogs)), nil)
+
+	logs, err := client.ContainerLogs(context.TODO(), "id", 10, dockerclient.ContainerLogsTimeout)
+	assert.NoError(t, err)
+	assert.Equal(t, "stdout\nstderr\n", string(logs))
+}
+
+func TestContainerLogsReturnsTheLastLines(t *testing.T) {
+	mockDockerSDK, client, _, _, _, done := dockerClientSetup(t)
+	defer done()
+
+	var multiplexedLogs, expectedLogs []byte
+	for i := 0; len(expectedLogs) <= 2*maxContainerLogsOutputSize; i++ {
+		line := []byte("line " + strconv.Itoa(i) + "\n")
+		multiplexedLogs = append(multiplexedLogs, 1, 0, 0, 0, 0, 0, 0, byte(len(line)))
+		multiplexedLogs = append(multiplexedLogs, line...)
+		expectedLogs = append(expectedLogs, line...)
+	}
+	mockDockerSDK.EXPECT().ContainerLogs(gomock.Any(), "id", gomock.Any()).
+		Return(ioutil.NopCloser(bytes.NewReader(multiplexedLogs)), nil)
+
+	logs, err := client.ContainerLogs(context.TODO(), "id", 100000, dockerclient.ContainerLogsTimeout)
+	assert.NoError(t, err)
+	assert.Len(t, logs, maxContainerLogsOutputSize)
+	assert.Equal(t, string(expectedLogs[len(expectedLogs)-maxContainerLogsOutputSize:]), string(logs),
+		"the most recent lines should be returned")
+}
+
+func TestContainerLogsTTY(t *testing.T) {
+	mockDockerSDK, client, _, _, _, done := dockerClientSetup(t)
+	defer done()
+
+	mockDockerSDK.EXPECT().ContainerLogs(gomock.Any(), "id", gomock.Any()).
+		Return(ioutil.NopCloser(strings.NewReader("line 1\r\nline 2\r\n")), nil)
+
+	logs, err := client.ContainerLogs(context.TODO(), "id", 10, dockerclient.ContainerLogsTimeout)
+	assert.NoError(t, err)
+	assert.Equal(t, "line 1\r\nline 2\r\n", string(logs))
+}
+
+func TestContainerLogsError(t *testing.T) {
+	mockDockerSDK, client, _, _, _, done := dockerClientSetup(t)
+	defer done()
+
+	mockDockerSDK.EXPECT().ContainerLogs(gomock.Any(), "id", gomock.Any()).Return(nil, errors.New("error"))
+
+	_, err := client.ContainerLogs(context.TODO(), "id", 10, dockerclient.ContainerLogsTimeout)
+	assert.Error(t, err)
+	assert.Equal(t, "CannotGetContainerLogsError", err.(apierrors.NamedError).ErrorName())
+}
+
 func TestInspectContainerExecTimeout(t *testing.T) {
 	mockDockerSDK, client, _, _, _, done := dockerClientSetup(t)
 	defer done()
@@ -676,6 +1093,74 @@ func TestStopContainer(t *testing.T) {
 	assert.Equal(t, "id", metadata.DockerID)
 }
 
+func TestStopContainerOOMKilled(t *testing.T) {
+	mockDockerSDK, client, _, _, _, done := dockerClientSetup(t)
+	defer done()
+
+	finished := time.Now().Format(time.RFC3339Nano)
+	gomock.InOrder(
+		mockDockerSDK.EXPECT().ContainerStop(gomock.Any(), "id", &client.config.DockerStopTimeout).Return(nil),
+		mockDockerSDK.EXPECT().ContainerInspect(gomock.Any(), "id").
+			Return(
+				types.ContainerJSON{
+					ContainerJSONBase: &types.ContainerJSONBase{
+						ID: "id",
+						State: &types.ContainerState{
+							ExitCode:   137,
+							OOMKilled:  true,
+							FinishedAt: finished,
+						},
+					},
+					Config: &dockercontainer.Config{},
+				},
+				nil),
+	)
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	metadata := client.StopContainer(ctx, "id", client.config.DockerStopTimeout)
+	require.Error(t, metadata.Error)
+	assert.IsType(t, OutOfMemoryError{}, metadata.Error)
+	assert.Equal(t, "OutOfMemoryError", metadata.Error.(apierrors.NamedError).ErrorName())
+	require.NotNil(t, metadata.ExitCode)
+	assert.Equal(t, 137, *metadata.ExitCode)
+}
+
+func TestKillContainer(t *testing.T) {
+	mockDockerSDK, client, _, _, _, done := dockerClientSetup(t)
+	defer done()
+
+	mockDockerSDK.EXPECT().ContainerKill(gomock.Any(), "id", "SIGKILL").Return(nil)
+	mockDockerSDK.EXPECT().ContainerKill(gomock.Any(), "id", "SIGTERM").Return(
+		errors.New("Error: No such container: id"))
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	err := client.KillContainer(ctx, "id", "SIGKILL", dockerclient.KillContainerTimeout)
+	assert.NoError(t, err)
+	err = client.KillContainer(ctx, "id", "SIGTERM", dockerclient.KillContainerTimeout)
+	require.Error(t, err)
+	assert.Equal(t, "CannotStopContainerError", err.(apierrors.NamedError).ErrorName())
+	assert.IsType(t, NoSuchContainerError{}, err.(CannotStopContainerError).FromError)
+}
+
+func TestUpdateContainer(t *testing.T) {
+	mockDockerSDK, client, _, _, _, done := dockerClientSetup(t)
+	defer done()
+
+	resources := dockercontainer.Resources{CPUShares: 2048}
+	mockDockerSDK.EXPECT().ContainerUpdate(gomock.Any(), "id", dockercontainer.UpdateConfig{Resources: resources}).Return(
+		dockercontainer.ContainerUpdateOKBody{}, nil)
+	mockDockerSDK.EXPECT().ContainerUpdate(gomock.Any(), "id", dockercontainer.UpdateConfig{Resources: resources}).Return(
+		dockercontainer.ContainerUpdateOKBody{}, errors.New("Error: No such container: id"))
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	err := client.UpdateContainer(ctx, "id", resources, dockerclient.UpdateContainerTimeout)
+	assert.NoError(t, err)
+	err = client.UpdateContainer(ctx, "id", resources, dockerclient.UpdateContainerTimeout)
+	require.Error(t, err)
+	assert.Equal(t, "CannotUpdateContainerError", err.(apierrors.NamedError).ErrorName())
+	assert.IsType(t, NoSuchContainerError{}, err.(CannotUpdateContainerError).FromError)
+}
+
 func TestRemoveContainerTimeout(t *testing.T) {
 	mockDockerSDK, client, _, _, _, done := dockerClientSetup(t)
 	defer done()
@@ -942,12 +1427,16 @@ func TestContainerEventsError(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			mockDockerSDK, client, _, _, _, done := dockerClientSetup(t)
+			mockDockerSDK, client, mockTime, _, _, done := dockerClientSetup(t)
 			defer done()
 
 			eventsChan := make(chan events.Message, dockerEventBufferSize)
 			errChan := make(chan error)
 			mockDockerSDK.EXPECT().Events(gomock.Any(), gomock.Any()).Return(eventsChan, errChan).MinTimes(1)
+			reconnect := make(chan time.Time)
+			close(reconnect)
+			mockTime.EXPECT().After(gomock.Any()).Return(reconnect).AnyTimes()
+			mockDockerSDK.EXPECT().ContainerList(gomock.Any(), gomock.Any()).Return([]types.Container{}, nil).AnyTimes()
 
 			dockerEvents, err := client.ContainerEvents(context.TODO())
 			require.NoError(t, err, "Could not get container events")
@@ -963,6 +1452,75 @@ func TestContainerEventsError(t *testing.T) {
 	}
 }
 
+func TestContainerEventsReconnectsAndResyncsAfterStreamDrop(t *testing.T) {
+	mockDockerSDK, client, mockTime, _, _, done := dockerClientSetup(t)
+	defer done()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	droppedEventsChan := make(chan events.Message, dockerEventBufferSize)
+	droppedErrChan := make(chan error)
+	eventsChan := make(chan events.Message, dockerEventBufferSize)
+	errChan := make(chan error)
+	reconnect := make(chan time.Time)
+	exitCode := 1
+	gomock.InOrder(
+		mockDockerSDK.EXPECT().Events(gomock.Any(), gomock.Any()).Return(droppedEventsChan, droppedErrChan),
+		mockTime.EXPECT().After(gomock.Any()).Return(reconnect),
+		mockDockerSDK.EXPECT().Events(gomock.Any(), gomock.Any()).Return(eventsChan, errChan),
+		mockDockerSDK.EXPECT().ContainerList(gomock.Any(), gomock.Any()).Return([]types.Container{
+			{ID: "created"}, {ID: "running"}, {ID: "exited"},
+		}, nil),
+		mockDockerSDK.EXPECT().ContainerInspect(gomock.Any(), "created").Return(types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{
+				ID:    "created",
+				State: &types.ContainerState{Status: "created"},
+			},
+		}, nil),
+		mockDockerSDK.EXPECT().ContainerInspect(gomock.Any(), "running").Return(types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{
+				ID:    "running",
+				State: &types.ContainerState{Status: "running", Running: true},
+			},
+		}, nil),
+		mockDockerSDK.EXPECT().ContainerInspect(gomock.Any(), "exited").Return(types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{
+				ID: "exited",
+				State: &types.ContainerState{
+					Status:     "exited",
+					ExitCode:   exitCode,
+					FinishedAt: time.Now().Format(time.RFC3339),
+				},
+			},
+		}, nil),
+	)
+
+	dockerEvents, err := client.ContainerEvents(ctx)
+	require.NoError(t, err, "Could not get container events")
+
+	// the daemon restarts; the stream is only reopened after the backoff
+	droppedErrChan <- io.ErrUnexpectedEOF
+	reconnect <- time.Now()
+
+	// the state of the containers is re-synced from the daemon
+	event := <-dockerEvents
+	assert.Equal(t, "running", event.DockerID)
+	assert.Equal(t, apicontainerstatus.ContainerRunning, event.Status)
+	assert.Nil(t, event.ExitCode)
+	event = <-dockerEvents
+	assert.Equal(t, "exited", event.DockerID)
+	assert.Equal(t, apicontainerstatus.ContainerStopped, event.Status)
+	require.NotNil(t, event.ExitCode)
+	assert.Equal(t, exitCode, *event.ExitCode)
+
+	// events are received from the reopened stream
+	eventsChan <- events.Message{Type: "container", ID: "new", Status: "create"}
+	event = <-dockerEvents
+	assert.Equal(t, "new", event.DockerID)
+	assert.Equal(t, apicontainerstatus.ContainerCreated, event.Status)
+}
+
 func TestSetExitCodeFromEvent(t *testing.T) {
 	var (
 		exitCodeInt    = 42
@@ -1147,7 +1705,7 @@ func TestListContainers(t *testing.T) {
 	mockDockerSDK, client, _, _, _, done := dockerClientSetup(t)
 	defer done()
 
-	containers := []types.Container{{ID: "id"}}
+	containers := []types.Container{{ID: "id", ImageID: "sha256:image"}}
 	mockDockerSDK.EXPECT().ContainerList(gomock.Any(), types.ContainerListOptions{All: true}).Return(containers, nil)
 	ctx, cancel := context.WithCancel(context.TODO())
 	defer cancel()
@@ -1157,6 +1715,23 @@ func TestListContainers(t *testing.T) {
 	containerIds := response.DockerIDs
 	assert.Equal(t, 1, len(containerIds), "Unexpected number of containers in list: ", len(containerIds))
 	assert.Equal(t, "id", containerIds[0], "Unexpected container id in the list: ", containerIds[0])
+	assert.Equal(t, map[string]string{"id": "sha256:image"}, response.ImageIDs)
+}
+
+func TestListContainersWithFilters(t *testing.T) {
+	mockDockerSDK, client, _, _, _, done := dockerClientSetup(t)
+	defer done()
+
+	filterArgs := filters.NewArgs(filters.Arg("status", "created"))
+	containers := []types.Container{{ID: "id", ImageID: "sha256:image", Labels: map[string]string{"key": "value"}}}
+	mockDockerSDK.EXPECT().ContainerList(gomock.Any(), types.ContainerListOptions{All: true, Filters: filterArgs}).
+		Return(containers, nil)
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	response := client.ListContainersWithFilters(ctx, filterArgs, dockerclient.ListContainersTimeout)
+	assert.NoError(t, response.Error)
+	assert.Equal(t, []string{"id"}, response.DockerIDs)
+	assert.Equal(t, map[string]map[string]string{"id": {"key": "value"}}, response.Labels)
 }
 
 func TestListContainersTimeout(t *testing.T) {
@@ -1182,7 +1757,7 @@ func TestListImages(t *testing.T) {
 	mockDocker, client, _, _, _, done := dockerClientSetup(t)
 	defer done()
 
-	images := []types.ImageSummary{{ID: "id"}}
+	images := []types.ImageSummary{{ID: "id", RepoTags: []string{"busybox:latest"}}, {ID: "untagged-id"}}
 	mockDocker.EXPECT().ImageList(gomock.Any(), gomock.Any()).Return(images, nil)
 	ctx, cancel := context.WithCancel(context.TODO())
 	defer cancel()
@@ -1190,8 +1765,13 @@ func TestListImages(t *testing.T) {
 	assert.NoError(t, response.Error, "Did not expect error")
 
 	imageIDs := response.ImageIDs
-	assert.EqualValues(t, len(imageIDs), 1, "Unexpected number of images in list")
+	assert.EqualValues(t, len(imageIDs), 2, "Unexpected number of images in list")
 	assert.EqualValues(t, imageIDs[0], "id", "Unexpected id in list of images")
+	assert.Equal(t, []string{"busybox:latest"}, response.RepoTags)
+	assert.Equal(t, map[string][]string{
+		"id":          {"busybox:latest"},
+		"untagged-id": nil,
+	}, response.ImageRepoTags)
 }
 
 func TestListImagesTimeout(t *testing.T) {
@@ -1825,7 +2405,8 @@ func TestMetadataFromContainer(t *testing.T) {
 			},
 		},
 		Config: &dockercontainer.Config{
-			Labels: labels,
+			Labels:     labels,
+			StopSignal: "SIGQUIT",
 		},
 		Mounts: volumes,
 	}
@@ -1834,6 +2415,7 @@ func TestMetadataFromContainer(t *testing.T) {
 	assert.Equal(t, "1234", metadata.DockerID)
 	assert.Equal(t, volumes, metadata.Volumes)
 	assert.Equal(t, labels, metadata.Labels)
+	assert.Equal(t, "SIGQUIT", metadata.StopSignal)
 	assert.Len(t, metadata.PortBindings, 1)
 	assert.Equal(t, "bridge", metadata.NetworkMode)
 	assert.NotNil(t, metadata.NetworkSettings)
@@ -14,9 +14,13 @@
 package dockerapi
 
 import (
+	"strings"
 	"time"
 
 	"github.com/aws/amazon-ecs-agent/agent/dockerclient"
+
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
 )
 
 const (
@@ -30,6 +34,8 @@ const (
 	CannotDescribeContainerErrorName = "CannotDescribeContainerError"
 	// CannotGetContainerTopErrorName is the name of the top container error.
 	CannotGetContainerTopErrorName = "CannotGetContainerTopError"
+	// CannotGetContainerLogsErrorName is the name of the container logs error.
+	CannotGetContainerLogsErrorName = "CannotGetContainerLogsError"
 	// TopProcessNotFoundErrorName is the error thrown when the specified pid does
 	// not exist in the container
 	TopProcessNotFoundErrorName = "ps: exit status 1"
@@ -134,14 +140,87 @@ func (err CannotStopContainerError) IsRetriableError() bool {
 	return true
 }
 
+// PullErrorCategory is the category of the cause of an image pull failure
+type PullErrorCategory string
+
+const (
+	// PullErrorCategoryDNS is used for pull failures due to the registry host name not resolving
+	PullErrorCategoryDNS PullErrorCategory = "DNSFailure"
+	// PullErrorCategoryTLS is used for pull failures due to the TLS connection or the certificate of the registry
+	PullErrorCategoryTLS PullErrorCategory = "TLSError"
+	// PullErrorCategoryAuth is used for pull failures due to the registry rejecting the credentials of the pull
+	PullErrorCategoryAuth PullErrorCategory = "AuthFailure"
+	// PullErrorCategoryManifestNotFound is used for pull failures due to the image or its tag not existing
+	PullErrorCategoryManifestNotFound PullErrorCategory = "ManifestNotFound"
+	// PullErrorCategoryUnknown is used for pull failures that are not recognized
+	PullErrorCategoryUnknown PullErrorCategory = "Unknown"
+)
+
+// pullErrorCategoryMessages are the lower case fragments of the pull errors of each category, in the order the
+// categories are checked. Network level failures come first since an unreachable registry also fails to
+// authenticate the pull.
+var pullErrorCategoryMessages = []struct {
+	category PullErrorCategory
+	messages []string
+}{
+	{PullErrorCategoryDNS, []string{"no such host", "server misbehaving", "temporary failure in name resolution"}},
+	{PullErrorCategoryTLS, []string{"x509:", "tls:", "tls handshake", "certificate"}},
+	{PullErrorCategoryManifestNotFound, []string{"manifest unknown", "not found", "repository does not exist"}},
+	{PullErrorCategoryAuth, registryAuthErrorMessages},
+}
+
+// classifyPullError returns the category of the cause of an image pull failure
+func classifyPullError(err error) PullErrorCategory {
+	if err == nil {
+		return PullErrorCategoryUnknown
+	}
+	message := strings.ToLower(err.Error())
+	for _, categoryMessages := range pullErrorCategoryMessages {
+		for _, categoryMessage := range categoryMessages.messages {
+			if strings.Contains(message, categoryMessage) {
+				return categoryMessages.category
+			}
+		}
+	}
+	return PullErrorCategoryUnknown
+}
+
 // CannotPullContainerError indicates any error when trying to pull
 // a container image
 type CannotPullContainerError struct {
 	FromError error
+	// Category is the category of the cause of the failure, if it was classified
+	Category PullErrorCategory
+}
+
+// newCannotPullContainerError returns a CannotPullContainerError classifying the pull error
+func newCannotPullContainerError(err error) CannotPullContainerError {
+	return CannotPullContainerError{FromError: err, Category: classifyPullError(err)}
 }
 
 func (err CannotPullContainerError) Error() string {
-	return err.FromError.Error()
+	if err.Category == "" || err.Category == PullErrorCategoryUnknown {
+		return err.FromError.Error()
+	}
+	return string(err.Category) + ": " + err.FromError.Error()
+}
+
+// PullErrorCategoryOf returns the category of the cause of a failed image pull. It returns false if the error
+// is not a classified pull error.
+func PullErrorCategoryOf(err error) (PullErrorCategory, bool) {
+	var pullErr CannotPullContainerError
+	switch e := err.(type) {
+	case CannotPullContainerError:
+		pullErr = e
+	case *CannotPullContainerError:
+		pullErr = *e
+	default:
+		return "", false
+	}
+	if pullErr.Category == "" {
+		return "", false
+	}
+	return pullErr.Category, true
 }
 
 // ErrorName returns name of the CannotPullContainerError.
@@ -203,6 +282,17 @@ func (err CannotCreateContainerError) ErrorName() string {
 	return "CannotCreateContainerError"
 }
 
+// IsRetriableError returns a boolean indicating whether the call that
+// generated the error can be retried.
+// When creating a container, only the errors for which docker is known not
+// to have created the container are retriable, i.e. when the daemon could
+// not be reached or was unavailable. Other errors, such as an image that
+// doesn't exist, an invalid configuration or a conflicting container name,
+// are permanent and retrying would fail again.
+func (err CannotCreateContainerError) IsRetriableError() bool {
+	return client.IsErrConnectionFailed(err.FromError) || errdefs.IsUnavailable(err.FromError)
+}
+
 // CannotStartContainerError indicates any error when trying to start a container
 type CannotStartContainerError struct {
 	FromError error
@@ -217,6 +307,20 @@ func (err CannotStartContainerError) ErrorName() string {
 	return CannotStartContainerErrorName
 }
 
+// CannotUpdateContainerError indicates any error when trying to update the resources of a container
+type CannotUpdateContainerError struct {
+	FromError error
+}
+
+func (err CannotUpdateContainerError) Error() string {
+	return err.FromError.Error()
+}
+
+// ErrorName returns name of the CannotUpdateContainerError
+func (err CannotUpdateContainerError) ErrorName() string {
+	return "CannotUpdateContainerError"
+}
+
 // CannotInspectContainerError indicates any error when trying to inspect a container
 type CannotInspectContainerError struct {
 	FromError error
@@ -245,6 +349,20 @@ func (err CannotGetContainerTopError) ErrorName() string {
 	return CannotGetContainerTopErrorName
 }
 
+// CannotGetContainerLogsError indicates any error when trying to get the logs of a container
+type CannotGetContainerLogsError struct {
+	FromError error
+}
+
+func (err CannotGetContainerLogsError) Error() string {
+	return err.FromError.Error()
+}
+
+// ErrorName returns name of the CannotGetContainerLogsError
+func (err CannotGetContainerLogsError) ErrorName() string {
+	return CannotGetContainerLogsErrorName
+}
+
 // CannotRemoveContainerError indicates any error when trying to remove a container
 type CannotRemoveContainerError struct {
 	FromError error
@@ -17,9 +17,12 @@
 package dockerapi
 
 import (
+	"context"
 	"errors"
 	"testing"
 
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -32,3 +35,137 @@ func TestRetriableErrorReturnsTrue(t *testing.T) {
 	err := CannotStopContainerError{errors.New("error")}
 	assert.True(t, err.IsRetriableError(), "Non unretriable error treated as unretriable docker error")
 }
+
+func TestClassifyPullError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected PullErrorCategory
+	}{
+		{
+			name:     "host not found",
+			err:      errors.New("Get https://registry.example.com/v2/: dial tcp: lookup registry.example.com on 10.0.0.2:53: no such host"),
+			expected: PullErrorCategoryDNS,
+		},
+		{
+			name:     "name server failure",
+			err:      errors.New("dial tcp: lookup registry.example.com: server misbehaving"),
+			expected: PullErrorCategoryDNS,
+		},
+		{
+			name:     "unknown certificate authority",
+			err:      errors.New("Get https://registry.example.com/v2/: x509: certificate signed by unknown authority"),
+			expected: PullErrorCategoryTLS,
+		},
+		{
+			name:     "tls handshake",
+			err:      errors.New("Get https://registry.example.com/v2/: net/http: TLS handshake timeout"),
+			expected: PullErrorCategoryTLS,
+		},
+		{
+			name:     "unauthorized",
+			err:      errors.New("Head https://registry.example.com/v2/app/manifests/latest: unauthorized: authentication required"),
+			expected: PullErrorCategoryAuth,
+		},
+		{
+			name:     "no credentials",
+			err:      errors.New("Get https://123456789012.dkr.ecr.us-west-2.amazonaws.com/v2/: no basic auth credentials"),
+			expected: PullErrorCategoryAuth,
+		},
+		{
+			name:     "manifest not found",
+			err:      errors.New("manifest for busybox:missing not found: manifest unknown: manifest unknown"),
+			expected: PullErrorCategoryManifestNotFound,
+		},
+		{
+			name:     "repository does not exist",
+			err:      errors.New("pull access denied for missing, repository does not exist or may require 'docker login'"),
+			expected: PullErrorCategoryManifestNotFound,
+		},
+		{
+			name:     "unrecognized",
+			err:      errors.New("unexpected EOF"),
+			expected: PullErrorCategoryUnknown,
+		},
+		{
+			name:     "no error",
+			expected: PullErrorCategoryUnknown,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, classifyPullError(tc.err))
+		})
+	}
+}
+
+func TestCannotPullContainerErrorIncludesCategory(t *testing.T) {
+	err := newCannotPullContainerError(errors.New("manifest for busybox:missing not found: manifest unknown"))
+	assert.Equal(t, "ManifestNotFound: manifest for busybox:missing not found: manifest unknown", err.Error())
+	category, ok := PullErrorCategoryOf(&err)
+	assert.True(t, ok)
+	assert.Equal(t, PullErrorCategoryManifestNotFound, category)
+
+	err = newCannotPullContainerError(errors.New("unexpected EOF"))
+	assert.Equal(t, "unexpected EOF", err.Error(), "unknown failures should not be prefixed")
+
+	_, ok = PullErrorCategoryOf(CannotPullContainerError{FromError: errors.New("error")})
+	assert.False(t, ok, "unclassified pull errors should have no category")
+	_, ok = PullErrorCategoryOf(&DockerTimeoutError{})
+	assert.False(t, ok)
+}
+
+func TestCannotCreateContainerErrorIsRetriable(t *testing.T) {
+	testCases := []struct {
+		name      string
+		err       error
+		retriable bool
+	}{
+		{
+			name:      "daemon unreachable",
+			err:       client.ErrorConnectionFailed("unix:///var/run/docker.sock"),
+			retriable: true,
+		},
+		{
+			name:      "daemon unavailable",
+			err:       errdefs.Unavailable(errors.New("daemon is shutting down")),
+			retriable: true,
+		},
+		{
+			name:      "image not found",
+			err:       errdefs.NotFound(errors.New("No such image: busybox:missing")),
+			retriable: false,
+		},
+		{
+			name:      "invalid config",
+			err:       errdefs.InvalidParameter(errors.New("invalid mount config for type \"bind\"")),
+			retriable: false,
+		},
+		{
+			name:      "name conflict",
+			err:       errdefs.Conflict(errors.New("the container name \"/app\" is already in use")),
+			retriable: false,
+		},
+		{
+			name:      "daemon failure",
+			err:       errdefs.System(errors.New("no space left on device")),
+			retriable: false,
+		},
+		{
+			name:      "canceled",
+			err:       context.Canceled,
+			retriable: false,
+		},
+		{
+			name:      "unclassified",
+			err:       errors.New("error"),
+			retriable: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CannotCreateContainerError{tc.err}
+			assert.Equal(t, tc.retriable, err.IsRetriableError())
+		})
+	}
+}
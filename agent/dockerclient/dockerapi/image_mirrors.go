@@ -0,0 +1,134 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package dockerapi
+
+import (
+	"context"
+	"strings"
+
+	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
+	apierrors "github.com/aws/amazon-ecs-agent/agent/api/errors"
+
+	"github.com/cihub/seelog"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+)
+
+const dockerHubDomain = "docker.io"
+
+// mirrorUnavailableErrorMessages are the lower case fragments of pull errors returned when a registry mirror
+// cannot be reached or fails to serve the pull
+var mirrorUnavailableErrorMessages = []string{
+	"connection refused",
+	"connection reset",
+	"no such host",
+	"i/o timeout",
+	"tls handshake timeout",
+	"server misbehaving",
+	"500 internal server error",
+	"502 bad gateway",
+	"503 service unavailable",
+	"504 gateway timeout",
+}
+
+// mirrorImageReference returns the reference of the image in the registry mirror. Only images from Docker Hub
+// which are not pulled by digest are mirrored, as the image pulled from the mirror has to be tagged with its
+// original reference.
+func mirrorImageReference(image, mirror string) (string, bool) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil || reference.Domain(named) != dockerHubDomain {
+		return "", false
+	}
+	if _, ok := named.(reference.Digested); ok {
+		return "", false
+	}
+	tagged, ok := reference.TagNameOnly(named).(reference.Tagged)
+	if !ok {
+		return "", false
+	}
+	mirrorImage := mirror + "/" + reference.Path(named) + ":" + tagged.Tag()
+	if _, err := reference.ParseNormalizedNamed(mirrorImage); err != nil {
+		seelog.Warnf("DockerGoClient: ignoring invalid registry mirror %s for image %s: %v", mirror, image, err)
+		return "", false
+	}
+	return mirrorImage, true
+}
+
+// isMirrorUnavailableError returns true if the pull error indicates that the registry mirror could not serve
+// the pull, in which case the next mirror is tried
+func isMirrorUnavailableError(err apierrors.NamedError) bool {
+	if _, ok := err.(*DockerTimeoutError); ok {
+		return true
+	}
+	if _, ok := err.(CannotPullContainerError); !ok {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	for _, unavailableErrorMessage := range mirrorUnavailableErrorMessages {
+		if strings.Contains(message, unavailableErrorMessage) {
+			return true
+		}
+	}
+	return false
+}
+
+// pullImageFromMirrors tries to pull the image from each of the configured registry mirrors in order, and tags
+// the image pulled from a mirror with its original reference. It returns false if the image has to be pulled
+// from its registry instead.
+func (dg *dockerGoClient) pullImageFromMirrors(ctx context.Context, image string,
+	authData *apicontainer.RegistryAuthenticationData) bool {
+	// Registry credentials are only valid for the registry of the image
+	if len(dg.config.ImagePullMirrors) == 0 || (authData != nil && authData.Type != "") {
+		return false
+	}
+	for _, mirror := range dg.config.ImagePullMirrors {
+		mirrorImage, ok := mirrorImageReference(image, mirror)
+		if !ok {
+			return false
+		}
+		err := dg.pullImage(ctx, mirrorImage, nil)
+		if err == nil {
+			return dg.tagMirrorImage(ctx, mirrorImage, image)
+		}
+		seelog.Warnf("DockerGoClient: failed to pull image %s from registry mirror %s: [%s] %s",
+			image, mirror, err.ErrorName(), err.Error())
+		if !isMirrorUnavailableError(err) {
+			// The mirror is up but cannot serve the image, let the registry of the image serve it
+			return false
+		}
+	}
+	return false
+}
+
+// tagMirrorImage tags the image pulled from a registry mirror with its original reference and removes the
+// mirror reference, so that the image is tracked and cleaned up under its original reference
+func (dg *dockerGoClient) tagMirrorImage(ctx context.Context, mirrorImage, image string) bool {
+	client, err := dg.sdkDockerClient()
+	if err != nil {
+		seelog.Warnf("DockerGoClient: unable to tag image %s pulled from registry mirror: %v", image, err)
+		return false
+	}
+	defer func() {
+		if _, err := client.ImageRemove(ctx, mirrorImage, types.ImageRemoveOptions{}); err != nil {
+			seelog.Warnf("DockerGoClient: unable to remove registry mirror reference %s of image %s: %v",
+				mirrorImage, image, err)
+		}
+	}()
+	if err := client.ImageTag(ctx, mirrorImage, getRepository(image)); err != nil {
+		seelog.Warnf("DockerGoClient: unable to tag image %s pulled from registry mirror: %v", image, err)
+		return false
+	}
+	seelog.Infof("DockerGoClient: pulled image %s from registry mirror as %s", image, mirrorImage)
+	return true
+}
@@ -72,6 +72,21 @@ func (mr *MockDockerClientMockRecorder) APIVersion() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "APIVersion", reflect.TypeOf((*MockDockerClient)(nil).APIVersion))
 }
 
+// AttachContainerExec mocks base method
+func (m *MockDockerClient) AttachContainerExec(arg0 context.Context, arg1 string, arg2 types.ExecStartCheck, arg3 time.Duration) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AttachContainerExec", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AttachContainerExec indicates an expected call of AttachContainerExec
+func (mr *MockDockerClientMockRecorder) AttachContainerExec(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AttachContainerExec", reflect.TypeOf((*MockDockerClient)(nil).AttachContainerExec), arg0, arg1, arg2, arg3)
+}
+
 // ContainerEvents mocks base method
 func (m *MockDockerClient) ContainerEvents(arg0 context.Context) (<-chan dockerapi.DockerContainerChangeEvent, error) {
 	m.ctrl.T.Helper()
@@ -87,6 +102,21 @@ func (mr *MockDockerClientMockRecorder) ContainerEvents(arg0 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ContainerEvents", reflect.TypeOf((*MockDockerClient)(nil).ContainerEvents), arg0)
 }
 
+// ContainerLogs mocks base method
+func (m *MockDockerClient) ContainerLogs(arg0 context.Context, arg1 string, arg2 int, arg3 time.Duration) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ContainerLogs", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ContainerLogs indicates an expected call of ContainerLogs
+func (mr *MockDockerClientMockRecorder) ContainerLogs(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ContainerLogs", reflect.TypeOf((*MockDockerClient)(nil).ContainerLogs), arg0, arg1, arg2, arg3)
+}
+
 // CreateContainer mocks base method
 func (m *MockDockerClient) CreateContainer(arg0 context.Context, arg1 *container0.Config, arg2 *container0.HostConfig, arg3 string, arg4 time.Duration) dockerapi.DockerContainerMetadata {
 	m.ctrl.T.Helper()
@@ -219,6 +249,20 @@ func (mr *MockDockerClientMockRecorder) InspectVolume(arg0, arg1, arg2 interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InspectVolume", reflect.TypeOf((*MockDockerClient)(nil).InspectVolume), arg0, arg1, arg2)
 }
 
+// KillContainer mocks base method
+func (m *MockDockerClient) KillContainer(arg0 context.Context, arg1, arg2 string, arg3 time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KillContainer", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// KillContainer indicates an expected call of KillContainer
+func (mr *MockDockerClientMockRecorder) KillContainer(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KillContainer", reflect.TypeOf((*MockDockerClient)(nil).KillContainer), arg0, arg1, arg2, arg3)
+}
+
 // KnownVersions mocks base method
 func (m *MockDockerClient) KnownVersions() []dockerclient.DockerVersion {
 	m.ctrl.T.Helper()
@@ -247,6 +291,20 @@ func (mr *MockDockerClientMockRecorder) ListContainers(arg0, arg1, arg2 interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListContainers", reflect.TypeOf((*MockDockerClient)(nil).ListContainers), arg0, arg1, arg2)
 }
 
+// ListContainersWithFilters mocks base method
+func (m *MockDockerClient) ListContainersWithFilters(arg0 context.Context, arg1 filters.Args, arg2 time.Duration) dockerapi.ListContainersResponse {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListContainersWithFilters", arg0, arg1, arg2)
+	ret0, _ := ret[0].(dockerapi.ListContainersResponse)
+	return ret0
+}
+
+// ListContainersWithFilters indicates an expected call of ListContainersWithFilters
+func (mr *MockDockerClientMockRecorder) ListContainersWithFilters(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListContainersWithFilters", reflect.TypeOf((*MockDockerClient)(nil).ListContainersWithFilters), arg0, arg1, arg2)
+}
+
 // ListImages mocks base method
 func (m *MockDockerClient) ListImages(arg0 context.Context, arg1 time.Duration) dockerapi.ListImagesResponse {
 	m.ctrl.T.Helper()
@@ -445,6 +503,20 @@ func (mr *MockDockerClientMockRecorder) SystemPing(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SystemPing", reflect.TypeOf((*MockDockerClient)(nil).SystemPing), arg0, arg1)
 }
 
+// UpdateContainer mocks base method
+func (m *MockDockerClient) UpdateContainer(arg0 context.Context, arg1 string, arg2 container0.Resources, arg3 time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateContainer", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateContainer indicates an expected call of UpdateContainer
+func (mr *MockDockerClientMockRecorder) UpdateContainer(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateContainer", reflect.TypeOf((*MockDockerClient)(nil).UpdateContainer), arg0, arg1, arg2, arg3)
+}
+
 // Version mocks base method
 func (m *MockDockerClient) Version(arg0 context.Context, arg1 time.Duration) (string, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,173 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package dockerapi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/logger"
+	"github.com/aws/amazon-ecs-agent/agent/logger/field"
+)
+
+type pullProgressCtxKey int
+
+const (
+	pullTaskIDKey pullProgressCtxKey = iota
+
+	// maxPullMessageSize bounds the size of a single message of the pull progress stream
+	maxPullMessageSize = 1024 * 1024
+
+	// Statuses of the layers of an image reported in the pull progress stream
+	layerStatusPullingFrom      = "Pulling from"
+	layerStatusDownloading      = "Downloading"
+	layerStatusDownloadComplete = "Download complete"
+	layerStatusExtracting       = "Extracting"
+	layerStatusPullComplete     = "Pull complete"
+	layerStatusAlreadyExists    = "Already exists"
+)
+
+// WithPullTaskID returns a context which attributes the image pulls made with it to the given task in the
+// pull progress logs
+func WithPullTaskID(ctx context.Context, taskID string) context.Context {
+	return context.WithValue(ctx, pullTaskIDKey, taskID)
+}
+
+func pullTaskID(ctx context.Context) string {
+	taskID, _ := ctx.Value(pullTaskIDKey).(string)
+	return taskID
+}
+
+// decodePullMessages calls handle for each message of the JSON message stream docker sends while pulling an
+// image, until the stream ends or handle returns an error. Lines that are not valid messages are skipped.
+func decodePullMessages(reader io.Reader, image string, handle func(*ImagePullResponse) error) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxPullMessageSize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		data := new(ImagePullResponse)
+		if err := json.Unmarshal(line, data); err != nil {
+			logger.Debug("Skipping malformed pull event message", logger.Fields{
+				field.Image: image,
+				field.Error: err,
+			})
+			continue
+		}
+		if err := handle(data); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// layerProgress is the download progress of a layer of the image being pulled
+type layerProgress struct {
+	downloaded bool
+	current    int64
+	total      int64
+}
+
+// pullProgressSummary summarizes the download progress of the layers of the image being pulled
+type pullProgressSummary struct {
+	LayersDownloaded int
+	Layers           int
+	BytesDownloaded  int64
+	BytesTotal       int64
+}
+
+// pullProgress keeps track of the progress of an image pull and logs a summary of it at most once per interval,
+// so that a slow pull can be told apart from a hung one
+type pullProgress struct {
+	image      string
+	taskID     string
+	interval   time.Duration
+	lastLogged time.Time
+	layers     map[string]*layerProgress
+}
+
+// newPullProgress returns the progress of a pull of the image started at the given time. No summary is logged
+// if the interval is not positive.
+func newPullProgress(image, taskID string, interval time.Duration, startedAt time.Time) *pullProgress {
+	return &pullProgress{
+		image:      image,
+		taskID:     taskID,
+		interval:   interval,
+		lastLogged: startedAt,
+		layers:     make(map[string]*layerProgress),
+	}
+}
+
+// update records the pull progress message and logs a summary of the progress if the interval has elapsed
+// since the last one. The summary is returned along with whether it was logged.
+func (p *pullProgress) update(data *ImagePullResponse, now time.Time) (pullProgressSummary, bool) {
+	p.record(data)
+	if p.interval <= 0 || now.Sub(p.lastLogged) < p.interval {
+		return pullProgressSummary{}, false
+	}
+	p.lastLogged = now
+	summary := p.summary()
+	logger.Info("Image pull in progress", logger.Fields{
+		field.TaskID:       p.taskID,
+		field.Image:        p.image,
+		"layersDownloaded": summary.LayersDownloaded,
+		"layers":           summary.Layers,
+		"bytesDownloaded":  summary.BytesDownloaded,
+		"bytesTotal":       summary.BytesTotal,
+	})
+	return summary, true
+}
+
+func (p *pullProgress) record(data *ImagePullResponse) {
+	// Messages without an id are about the whole image, and the id of the "Pulling from" message is the tag
+	if data.Id == "" || strings.HasPrefix(data.Status, layerStatusPullingFrom) {
+		return
+	}
+	layer, ok := p.layers[data.Id]
+	if !ok {
+		layer = &layerProgress{}
+		p.layers[data.Id] = layer
+	}
+	switch data.Status {
+	case layerStatusDownloading:
+		layer.current = data.ProgressDetail.Current
+		if data.ProgressDetail.Total > 0 {
+			layer.total = data.ProgressDetail.Total
+		}
+	case layerStatusDownloadComplete, layerStatusExtracting, layerStatusPullComplete:
+		layer.downloaded = true
+		layer.current = layer.total
+	case layerStatusAlreadyExists:
+		layer.downloaded = true
+	}
+}
+
+func (p *pullProgress) summary() pullProgressSummary {
+	summary := pullProgressSummary{Layers: len(p.layers)}
+	for _, layer := range p.layers {
+		if layer.downloaded {
+			summary.LayersDownloaded++
+		}
+		summary.BytesDownloaded += layer.current
+		summary.BytesTotal += layer.total
+	}
+	return summary
+}
@@ -0,0 +1,97 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package dockerapi
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pullProgressStream = `{"status":"Pulling from library/image","id":"latest"}
+{"status":"Pulling fs layer","id":"layer1"}
+{"status":"Pulling fs layer","id":"layer2"}
+{"status":"Already exists","id":"layer3"}
+{"status":"Downloading","progressDetail":{"current":100,"total":1000},"progress":"[=>   ]","id":"layer1"}
+this is not a pull progress message
+{"status":"Downloading","progressDetail":{"current":500,"total":2000},"progress":"[=>   ]","id":"layer2"
+
+{"status":"Downloading","progressDetail":{"current":600,"total":1000},"progress":"[===> ]","id":"layer1"}
+{"status":"Download complete","id":"layer1"}
+{"status":"Downloading","progressDetail":{"current":1500,"total":2000},"progress":"[===> ]","id":"layer2"}
+{"status":"Download complete","id":"layer2"}
+{"status":"Extracting","progressDetail":{"current":2000,"total":2000},"progress":"[=====]","id":"layer2"}
+{"status":"Pull complete","id":"layer2"}
+{"status":"Digest: sha256:bc8813ea7b3603864987522f02a76101c17ad122e1c46d790efc0fca78ca7bfb"}
+{"status":"Status: Downloaded newer image for image:latest"}
+`
+
+func TestPullProgressThrottledSummaries(t *testing.T) {
+	start := time.Now()
+	progress := newPullProgress("image", "task-id", 10*time.Second, start)
+
+	// Every message arrives 3 seconds after the previous one, so a summary is logged for every fourth message
+	var summaries []pullProgressSummary
+	var messages int
+	err := decodePullMessages(strings.NewReader(pullProgressStream), "image", func(data *ImagePullResponse) error {
+		messages++
+		if summary, logged := progress.update(data, start.Add(time.Duration(messages)*3*time.Second)); logged {
+			summaries = append(summaries, summary)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 13, messages, "malformed and empty lines should be skipped")
+	assert.Equal(t, []pullProgressSummary{
+		{LayersDownloaded: 1, Layers: 3, BytesDownloaded: 0, BytesTotal: 0},
+		{LayersDownloaded: 2, Layers: 3, BytesDownloaded: 2500, BytesTotal: 3000},
+		{LayersDownloaded: 3, Layers: 3, BytesDownloaded: 3000, BytesTotal: 3000},
+	}, summaries)
+}
+
+func TestPullProgressDisabled(t *testing.T) {
+	start := time.Now()
+	progress := newPullProgress("image", "task-id", -1, start)
+
+	err := decodePullMessages(strings.NewReader(pullProgressStream), "image", func(data *ImagePullResponse) error {
+		_, logged := progress.update(data, start.Add(time.Hour))
+		assert.False(t, logged, "no summary should be logged when progress logging is disabled")
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestDecodePullMessagesHandlerError(t *testing.T) {
+	handlerErr := errors.New("handler error")
+	var messages int
+	err := decodePullMessages(strings.NewReader(pullProgressStream), "image", func(data *ImagePullResponse) error {
+		messages++
+		return handlerErr
+	})
+	assert.Equal(t, handlerErr, err)
+	assert.Equal(t, 1, messages, "decoding should stop at the first handler error")
+}
+
+func TestPullTaskID(t *testing.T) {
+	assert.Equal(t, "", pullTaskID(context.TODO()))
+	assert.Equal(t, "task-id", pullTaskID(WithPullTaskID(context.TODO(), "task-id")))
+}
@@ -75,6 +75,9 @@ type DockerContainerMetadata struct {
 	NetworkMode string
 	// NetworksUnsafe denotes the Docker Network Settings in the container
 	NetworkSettings *types.NetworkSettings
+	// StopSignal is the signal docker sends to stop the container, as set by the container config or the
+	// STOPSIGNAL instruction of its image, if any
+	StopSignal string
 }
 
 // ListContainersResponse encapsulates the response from the docker client for the
@@ -82,6 +85,10 @@ type DockerContainerMetadata struct {
 type ListContainersResponse struct {
 	// DockerIDs is the list of container IDs from the ListContainers call
 	DockerIDs []string
+	// ImageIDs maps the IDs of the listed containers to the IDs of their images
+	ImageIDs map[string]string
+	// Labels maps the IDs of the listed containers to their labels
+	Labels map[string]map[string]string
 	// Error contains any error returned when listing containers
 	Error error
 }
@@ -93,6 +100,8 @@ type ListImagesResponse struct {
 	ImageIDs []string
 	// RepoTags is the list of Images names from the ListImages call
 	RepoTags []string
+	// ImageRepoTags maps the IDs of the listed images to their names
+	ImageRepoTags map[string][]string
 	// Error contains any error returned when listing images
 	Error error
 }
@@ -55,5 +55,13 @@ the "AuthData" to be a string containing the contents of that file. The contents
 of your ".dockercfg" will generally be a string of the following form:
 
 	'{"http://myregistry.com/v1/":{"auth":"dXNlcjpzd29yZGZpc2g=","email":"email"}}'
+
+# Registry Auth Resolvers
+
+Builds of the agent that retrieve credentials from elsewhere, e.g. a credential
+broker, can implement RegistryAuthResolver and register it with
+RegisterRegistryAuthResolver at startup, before the docker client is created.
+Registered resolvers are consulted with the host of the registry before the auth
+types above and the registry auth data of the task.
 */
 package dockerauth
@@ -57,7 +57,7 @@ func (key *cacheKey) String() string {
 
 // NewECRAuthProvider returns a DockerAuthProvider that can handle retrieve
 // credentials for pulling from Amazon EC2 Container Registry
-func NewECRAuthProvider(ecrFactory ecr.ECRFactory, cache async.Cache) DockerAuthProvider {
+func NewECRAuthProvider(ecrFactory ecr.ECRFactory, cache async.Cache) CachingDockerAuthProvider {
 	return &ecrAuthProvider{
 		tokenCache: cache,
 		factory:    ecrFactory,
@@ -80,6 +80,29 @@ func (authProvider *ecrAuthProvider) GetAuthconfig(image string,
 
 	// First try to get the token from cache, if the token does not exist,
 	// then call ECR api to get the new token
+	key := newCacheKey(authData)
+	auth := authProvider.getAuthConfigFromCache(key)
+	if auth != nil {
+		return *auth, nil
+	}
+
+	// Get the auth config from ECR
+	return authProvider.getAuthConfigFromECR(image, key, authData)
+}
+
+// InvalidateAuthconfig removes the cached token of the registry, so that a token rejected by the
+// registry is requested again from ECR
+func (authProvider *ecrAuthProvider) InvalidateAuthconfig(registryAuthData *apicontainer.RegistryAuthenticationData) {
+	if registryAuthData == nil || registryAuthData.ECRAuthData == nil {
+		return
+	}
+	key := newCacheKey(registryAuthData.ECRAuthData)
+	log.Infof("Invalidating cached ECR credentials for registry %s", key.registryID)
+	authProvider.tokenCache.Delete(key.String())
+}
+
+// newCacheKey returns the key of the registry token in the cache
+func newCacheKey(authData *apicontainer.ECRAuthData) cacheKey {
 	key := cacheKey{
 		region:           authData.Region,
 		endpointOverride: authData.EndpointOverride,
@@ -92,15 +115,7 @@ func (authProvider *ecrAuthProvider) GetAuthconfig(image string,
 	if authData.GetPullCredentials() != (credentials.IAMRoleCredentials{}) {
 		key.roleARN = authData.GetPullCredentials().RoleArn
 	}
-
-	// Try to get the auth config from cache
-	auth := authProvider.getAuthConfigFromCache(key)
-	if auth != nil {
-		return *auth, nil
-	}
-
-	// Get the auth config from ECR
-	return authProvider.getAuthConfigFromECR(image, key, authData)
+	return key
 }
 
 // getAuthconfigFromCache retrieves the token from cache
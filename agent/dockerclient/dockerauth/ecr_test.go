@@ -461,6 +461,99 @@ func TestAuthorizationTokenCacheHitExpired(t *testing.T) {
 	assert.Equal(t, password, authconfig.Password)
 }
 
+func TestAuthorizationTokenCacheTTLExpired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	factory := mock_ecr.NewMockECRFactory(ctrl)
+	ecrClient := mock_ecr.NewMockECRClient(ctrl)
+
+	cacheTTL := 10 * time.Millisecond
+	provider := ecrAuthProvider{
+		factory:    factory,
+		tokenCache: async.NewLRUCache(tokenCacheSize, cacheTTL),
+	}
+	username := "test_user"
+	password := "test_passwd"
+
+	proxyEndpoint := "proxy"
+	authData := &apicontainer.ECRAuthData{
+		Region:           "us-west-2",
+		RegistryID:       "0123456789012",
+		EndpointOverride: "my.endpoint",
+	}
+	registryAuthData := &apicontainer.RegistryAuthenticationData{
+		ECRAuthData: authData,
+	}
+	dockerAuthData := &ecrapi.AuthorizationData{
+		ProxyEndpoint:      aws.String(proxyEndpointScheme + proxyEndpoint),
+		AuthorizationToken: aws.String(base64.StdEncoding.EncodeToString([]byte(username + ":" + password))),
+		ExpiresAt:          aws.Time(time.Now().Add(12 * time.Hour)),
+	}
+
+	// The token is requested from ECR once while it is cached, and again once the cache TTL expires
+	factory.EXPECT().GetClient(authData).Return(ecrClient, nil).Times(2)
+	ecrClient.EXPECT().GetAuthorizationToken(authData.RegistryID).Return(dockerAuthData, nil).Times(2)
+
+	for i := 0; i < 2; i++ {
+		authconfig, err := provider.GetAuthconfig(proxyEndpoint+"/myimage", registryAuthData)
+		require.NoError(t, err)
+		assert.Equal(t, username, authconfig.Username)
+	}
+	time.Sleep(2 * cacheTTL)
+	authconfig, err := provider.GetAuthconfig(proxyEndpoint+"/myimage", registryAuthData)
+	require.NoError(t, err)
+	assert.Equal(t, username, authconfig.Username)
+}
+
+func TestInvalidateAuthconfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	factory := mock_ecr.NewMockECRFactory(ctrl)
+	ecrClient := mock_ecr.NewMockECRClient(ctrl)
+
+	provider := NewECRAuthProvider(factory, async.NewLRUCache(tokenCacheSize, tokenCacheTTL))
+	proxyEndpoint := "proxy"
+	authData := &apicontainer.ECRAuthData{
+		Region:           "us-west-2",
+		RegistryID:       "0123456789012",
+		EndpointOverride: "my.endpoint",
+	}
+	registryAuthData := &apicontainer.RegistryAuthenticationData{
+		ECRAuthData: authData,
+	}
+	staleAuthData := &ecrapi.AuthorizationData{
+		ProxyEndpoint:      aws.String(proxyEndpointScheme + proxyEndpoint),
+		AuthorizationToken: aws.String(base64.StdEncoding.EncodeToString([]byte("user:stale"))),
+		ExpiresAt:          aws.Time(time.Now().Add(12 * time.Hour)),
+	}
+	freshAuthData := &ecrapi.AuthorizationData{
+		ProxyEndpoint:      aws.String(proxyEndpointScheme + proxyEndpoint),
+		AuthorizationToken: aws.String(base64.StdEncoding.EncodeToString([]byte("user:fresh"))),
+		ExpiresAt:          aws.Time(time.Now().Add(12 * time.Hour)),
+	}
+
+	factory.EXPECT().GetClient(authData).Return(ecrClient, nil).Times(2)
+	gomock.InOrder(
+		ecrClient.EXPECT().GetAuthorizationToken(authData.RegistryID).Return(staleAuthData, nil),
+		ecrClient.EXPECT().GetAuthorizationToken(authData.RegistryID).Return(freshAuthData, nil),
+	)
+
+	authconfig, err := provider.GetAuthconfig(proxyEndpoint+"/myimage", registryAuthData)
+	require.NoError(t, err)
+	assert.Equal(t, "stale", authconfig.Password)
+	authconfig, err = provider.GetAuthconfig(proxyEndpoint+"/myimage", registryAuthData)
+	require.NoError(t, err)
+	assert.Equal(t, "stale", authconfig.Password, "Token should be served from cache")
+
+	provider.InvalidateAuthconfig(registryAuthData)
+	authconfig, err = provider.GetAuthconfig(proxyEndpoint+"/myimage", registryAuthData)
+	require.NoError(t, err)
+	assert.Equal(t, "fresh", authconfig.Password, "Token should be requested again after invalidation")
+
+	// Invalidating without ECR auth data is a no-op
+	provider.InvalidateAuthconfig(&apicontainer.RegistryAuthenticationData{})
+}
+
 func TestExtractECRTokenError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
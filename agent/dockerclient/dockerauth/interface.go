@@ -24,3 +24,19 @@ import (
 type DockerAuthProvider interface {
 	GetAuthconfig(image string, registryAuthData *apicontainer.RegistryAuthenticationData) (types.AuthConfig, error)
 }
+
+// CachingDockerAuthProvider is a DockerAuthProvider that caches the auth information it retrieves
+type CachingDockerAuthProvider interface {
+	DockerAuthProvider
+	// InvalidateAuthconfig removes the cached auth information for the registry so that it is retrieved again
+	InvalidateAuthconfig(registryAuthData *apicontainer.RegistryAuthenticationData)
+}
+
+// RegistryAuthResolver resolves the auth information for pulling images from a registry. Resolvers registered with
+// RegisterRegistryAuthResolver are consulted, in the order they were registered, before the built-in auth providers.
+type RegistryAuthResolver interface {
+	// ResolveAuth returns the auth information for pulling the image from the registry host. It returns false
+	// when it doesn't handle the registry, in which case the next resolver is consulted.
+	ResolveAuth(registry string, image string,
+		registryAuthData *apicontainer.RegistryAuthenticationData) (types.AuthConfig, bool, error)
+}
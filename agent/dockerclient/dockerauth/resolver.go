@@ -0,0 +1,76 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package dockerauth
+
+import (
+	"sync"
+
+	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
+	"github.com/aws/amazon-ecs-agent/agent/async"
+	"github.com/aws/amazon-ecs-agent/agent/ecr"
+	"github.com/aws/amazon-ecs-agent/agent/utils"
+	"github.com/docker/docker/api/types"
+)
+
+var (
+	registryAuthResolversLock sync.RWMutex
+	registryAuthResolvers     []RegistryAuthResolver
+)
+
+// RegisterRegistryAuthResolver registers a resolver to be consulted for the auth information of registries before
+// the built-in auth providers. It must be called at startup, before the docker client is created.
+func RegisterRegistryAuthResolver(resolver RegistryAuthResolver) {
+	registryAuthResolversLock.Lock()
+	defer registryAuthResolversLock.Unlock()
+	registryAuthResolvers = append(registryAuthResolvers, resolver)
+}
+
+// RegisteredRegistryAuthResolvers returns the resolvers registered with RegisterRegistryAuthResolver, in the order
+// they were registered
+func RegisteredRegistryAuthResolvers() []RegistryAuthResolver {
+	registryAuthResolversLock.RLock()
+	defer registryAuthResolversLock.RUnlock()
+	resolvers := make([]RegistryAuthResolver, len(registryAuthResolvers))
+	copy(resolvers, registryAuthResolvers)
+	return resolvers
+}
+
+// RegistryHost returns the host of the registry the image is pulled from
+func RegistryHost(image string) string {
+	repository, _ := utils.ParseRepositoryTag(image)
+	indexName, _ := splitReposName(repository)
+	return indexName
+}
+
+type ecrRegistryAuthResolver struct {
+	provider DockerAuthProvider
+}
+
+// NewECRRegistryAuthResolver returns a RegistryAuthResolver that retrieves the credentials of images whose
+// registry auth data is of the ECR type from Amazon EC2 Container Registry
+func NewECRRegistryAuthResolver(ecrFactory ecr.ECRFactory, cache async.Cache) RegistryAuthResolver {
+	return &ecrRegistryAuthResolver{
+		provider: NewECRAuthProvider(ecrFactory, cache),
+	}
+}
+
+// ResolveAuth retrieves the auth information for the registry from ECR if the registry auth data is of the ECR type
+func (resolver *ecrRegistryAuthResolver) ResolveAuth(registry string, image string,
+	registryAuthData *apicontainer.RegistryAuthenticationData) (types.AuthConfig, bool, error) {
+	if registryAuthData == nil || registryAuthData.Type != apicontainer.AuthTypeECR {
+		return types.AuthConfig{}, false, nil
+	}
+	authConfig, err := resolver.provider.GetAuthconfig(image, registryAuthData)
+	return authConfig, true, err
+}
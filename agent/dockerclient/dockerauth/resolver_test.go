@@ -0,0 +1,118 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package dockerauth
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
+	"github.com/aws/amazon-ecs-agent/agent/async"
+	mock_ecr "github.com/aws/amazon-ecs-agent/agent/ecr/mocks"
+	ecrapi "github.com/aws/amazon-ecs-agent/agent/ecr/model/ecr"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/docker/docker/api/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRegistryAuthResolver struct {
+	username string
+}
+
+func (resolver *fakeRegistryAuthResolver) ResolveAuth(registry string, image string,
+	registryAuthData *apicontainer.RegistryAuthenticationData) (types.AuthConfig, bool, error) {
+	return types.AuthConfig{Username: resolver.username}, true, nil
+}
+
+func TestRegisterRegistryAuthResolver(t *testing.T) {
+	defer func() {
+		registryAuthResolvers = nil
+	}()
+	first := &fakeRegistryAuthResolver{username: "first"}
+	second := &fakeRegistryAuthResolver{username: "second"}
+	RegisterRegistryAuthResolver(first)
+	RegisterRegistryAuthResolver(second)
+
+	resolvers := RegisteredRegistryAuthResolvers()
+	assert.Equal(t, []RegistryAuthResolver{first, second}, resolvers)
+
+	// the returned slice must not be shared with the registered resolvers
+	resolvers[0] = nil
+	assert.Equal(t, []RegistryAuthResolver{first, second}, RegisteredRegistryAuthResolvers())
+}
+
+func TestRegistryHost(t *testing.T) {
+	testCases := []struct {
+		image    string
+		expected string
+	}{
+		{image: "busybox", expected: "docker.io"},
+		{image: "library/busybox:latest", expected: "docker.io"},
+		{image: "registry.example.com/team/app:v1", expected: "registry.example.com"},
+		{image: "localhost:5000/app", expected: "localhost:5000"},
+		{image: "123456789012.dkr.ecr.us-west-2.amazonaws.com/app@sha256:abcd",
+			expected: "123456789012.dkr.ecr.us-west-2.amazonaws.com"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.image, func(t *testing.T) {
+			assert.Equal(t, tc.expected, RegistryHost(tc.image))
+		})
+	}
+}
+
+func TestECRRegistryAuthResolverIgnoresOtherAuthTypes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	resolver := NewECRRegistryAuthResolver(mock_ecr.NewMockECRFactory(ctrl), async.NewLRUCache(tokenCacheSize, tokenCacheTTL))
+
+	for _, authData := range []*apicontainer.RegistryAuthenticationData{nil, {Type: apicontainer.AuthTypeASM}} {
+		_, ok, err := resolver.ResolveAuth("registry.example.com", "registry.example.com/app", authData)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	}
+}
+
+func TestECRRegistryAuthResolver(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_ecr.NewMockECRClient(ctrl)
+	factory := mock_ecr.NewMockECRFactory(ctrl)
+	resolver := NewECRRegistryAuthResolver(factory, async.NewLRUCache(tokenCacheSize, tokenCacheTTL))
+
+	authData := &apicontainer.RegistryAuthenticationData{
+		Type: apicontainer.AuthTypeECR,
+		ECRAuthData: &apicontainer.ECRAuthData{
+			RegistryID: "123456789012",
+			Region:     "us-west-2",
+		},
+	}
+	factory.EXPECT().GetClient(authData.ECRAuthData).Return(client, nil)
+	client.EXPECT().GetAuthorizationToken("123456789012").Return(&ecrapi.AuthorizationData{
+		ProxyEndpoint:      aws.String(proxyEndpointScheme + testProxyEndpoint),
+		AuthorizationToken: aws.String(base64.StdEncoding.EncodeToString([]byte("user:pass"))),
+		ExpiresAt:          aws.Time(time.Now().Add(1 * time.Hour)),
+	}, nil)
+
+	authConfig, ok, err := resolver.ResolveAuth(testProxyEndpoint, testProxyEndpoint+"/app", authData)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "user", authConfig.Username)
+	assert.Equal(t, "pass", authConfig.Password)
+}
@@ -57,6 +57,8 @@ type Factory interface {
 type factory struct {
 	endpoint string
 	clients  map[dockerclient.DockerVersion]sdkclient.Client
+	// defaultVersion is the version of the default client
+	defaultVersion dockerclient.DockerVersion
 }
 
 // newVersionedClient is a variable such that the implementation can be
@@ -68,13 +70,41 @@ var newVersionedClient = func(endpoint, version string) (sdkclient.Client, error
 // NewFactory initializes a client factory using a specified endpoint.
 func NewFactory(ctx context.Context, endpoint string) Factory {
 	return &factory{
-		endpoint: endpoint,
-		clients:  findDockerVersions(ctx, endpoint),
+		endpoint:       endpoint,
+		clients:        findDockerVersions(ctx, endpoint),
+		defaultVersion: GetDefaultVersion(),
 	}
 }
 
+// NewFactoryWithDefaultVersion initializes a client factory using a specified endpoint, whose default client
+// uses the specified Docker API version instead of the default version of the platform. An error is returned
+// if the version is not supported by the agent.
+func NewFactoryWithDefaultVersion(ctx context.Context, endpoint string, version dockerclient.DockerVersion) (Factory, error) {
+	if !isAgentSupportedDockerVersion(version) {
+		supportedVersions := getAgentSupportedDockerVersions()
+		return nil, errors.Errorf("docker client factory: unsupported Docker API version %s, expected a version between %s and %s",
+			version, supportedVersions[0], supportedVersions[len(supportedVersions)-1])
+	}
+	return &factory{
+		endpoint:       endpoint,
+		clients:        findDockerVersions(ctx, endpoint),
+		defaultVersion: version,
+	}, nil
+}
+
+// isAgentSupportedDockerVersion returns true if the version is one of the Docker API versions supported by the
+// agent on the platform
+func isAgentSupportedDockerVersion(version dockerclient.DockerVersion) bool {
+	for _, supportedVersion := range getAgentSupportedDockerVersions() {
+		if version == supportedVersion {
+			return true
+		}
+	}
+	return false
+}
+
 func (f *factory) GetDefaultClient() (sdkclient.Client, error) {
-	return f.GetClient(GetDefaultVersion())
+	return f.GetClient(f.defaultVersion)
 }
 
 func (f *factory) FindSupportedAPIVersions() []dockerclient.DockerVersion {
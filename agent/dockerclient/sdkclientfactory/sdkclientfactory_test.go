@@ -54,6 +54,49 @@ func TestGetDefaultClientSuccess(t *testing.T) {
 	assert.Equal(t, expectedClient, actualClient)
 }
 
+func TestGetDefaultClientWithVersionOverride(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	supportedVersions := getAgentSupportedDockerVersions()
+	overrideVersion := supportedVersions[len(supportedVersions)-1]
+	expectedClient := mock_sdkclient.NewMockClient(ctrl)
+	newVersionedClient = func(endpoint, version string) (sdkclient.Client, error) {
+		mockClient := mock_sdkclient.NewMockClient(ctrl)
+		if version == string(overrideVersion) {
+			mockClient = expectedClient
+		}
+		mockClient.EXPECT().ServerVersion(gomock.Any()).Return(docker.Version{}, nil).AnyTimes()
+		mockClient.EXPECT().Ping(gomock.Any()).AnyTimes()
+
+		return mockClient, nil
+	}
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	factory, err := NewFactoryWithDefaultVersion(ctx, expectedEndpoint, overrideVersion)
+	assert.NoError(t, err)
+	actualClient, err := factory.GetDefaultClient()
+	assert.NoError(t, err)
+	assert.Equal(t, expectedClient, actualClient)
+}
+
+func TestNewFactoryWithUnsupportedVersionOverride(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	for _, version := range []dockerclient.DockerVersion{"1.99", "1.0", "latest"} {
+		t.Run(string(version), func(t *testing.T) {
+			newVersionedClient = func(endpoint, version string) (sdkclient.Client, error) {
+				t.Fatal("the daemon should not be queried for an unsupported version override")
+				return nil, nil
+			}
+			_, err := NewFactoryWithDefaultVersion(ctx, expectedEndpoint, version)
+			assert.Error(t, err)
+		})
+	}
+}
+
 func TestFindSupportedAPIVersions(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
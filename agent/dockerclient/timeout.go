@@ -39,10 +39,17 @@ const (
 	ContainerExecCreateTimeout = 1 * time.Minute
 	// ContainerExecStartTimeout is the timeout for the ContainerExecStart API.
 	ContainerExecStartTimeout = 1 * time.Minute
+	// ContainerExecAttachTimeout is the timeout for the ContainerExecAttach API, including reading the output
+	// of the exec process.
+	ContainerExecAttachTimeout = 1 * time.Minute
 	// ContainerExecInspectTimeout is the timeout for the ContainerExecInspect API.
 	ContainerExecInspectTimeout = 1 * time.Minute
 	// StopContainerTimeout is the timeout for the StopContainer API.
 	StopContainerTimeout = 30 * time.Second
+	// KillContainerTimeout is the timeout for the KillContainer API.
+	KillContainerTimeout = 30 * time.Second
+	// UpdateContainerTimeout is the timeout for the UpdateContainer API.
+	UpdateContainerTimeout = 30 * time.Second
 	// RemoveContainerTimeout is the timeout for the RemoveContainer API.
 	RemoveContainerTimeout = 5 * time.Minute
 
@@ -73,4 +80,7 @@ const (
 
 	// InfoTimeout is the timeout for the Info API
 	InfoTimeout = 10 * time.Second
+
+	// ContainerLogsTimeout is the timeout for the ContainerLogs API
+	ContainerLogsTimeout = 10 * time.Second
 )
@@ -15,6 +15,7 @@
 package ecr
 
 import (
+	"crypto/x509"
 	"fmt"
 	"net/http"
 	"time"
@@ -42,10 +43,11 @@ const (
 	roundtripTimeout = 5 * time.Second
 )
 
-// NewECRFactory returns an ECRFactory capable of producing ECRSDK clients
-func NewECRFactory(acceptInsecureCert bool) ECRFactory {
+// NewECRFactory returns an ECRFactory capable of producing ECRSDK clients. The certificates of the hosts in
+// registryCAs are verified against the given CAs.
+func NewECRFactory(acceptInsecureCert bool, registryCAs map[string]*x509.CertPool) ECRFactory {
 	return &ecrFactory{
-		httpClient: httpclient.New(roundtripTimeout, acceptInsecureCert),
+		httpClient: httpclient.NewWithRootCAs(roundtripTimeout, acceptInsecureCert, registryCAs),
 	}
 }
 
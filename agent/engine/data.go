@@ -14,13 +14,21 @@
 package engine
 
 import (
+	"encoding/json"
+	"strings"
+	"time"
+
 	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
 	apicontainerstatus "github.com/aws/amazon-ecs-agent/agent/api/container/status"
 	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
 	"github.com/aws/amazon-ecs-agent/agent/data"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
 	"github.com/aws/amazon-ecs-agent/agent/engine/image"
+	"github.com/aws/amazon-ecs-agent/agent/logger"
+	"github.com/aws/amazon-ecs-agent/agent/logger/field"
+	"github.com/aws/amazon-ecs-agent/agent/metrics"
 	"github.com/aws/amazon-ecs-agent/agent/utils"
+	"github.com/aws/amazon-ecs-agent/agent/utils/retry"
 
 	"github.com/cihub/seelog"
 	"github.com/pkg/errors"
@@ -247,16 +255,132 @@ func (engine *DockerTaskEngine) removeENIAttachmentData(mac string) {
 	}
 }
 
+// saveQuarantinedImageData persists the IDs and digests of the quarantined images so that the quarantine
+// survives a restart of the agent. It must be called while holding the quarantined images lock.
+func (engine *DockerTaskEngine) saveQuarantinedImageData() {
+	imageRefs := make([]string, 0, len(engine.quarantinedImages))
+	for imageRef := range engine.quarantinedImages {
+		imageRefs = append(imageRefs, imageRef)
+	}
+	val, err := json.Marshal(imageRefs)
+	if err != nil {
+		seelog.Errorf("Failed to marshal quarantined images: %v", err)
+		return
+	}
+	if err := engine.dataClient.SaveMetadata(data.QuarantinedImagesKey, string(val)); err != nil {
+		seelog.Errorf("Failed to save data for quarantined images: %v", err)
+	}
+}
+
+// loadQuarantinedImageData restores the quarantined images saved before the agent restarted
+func (engine *DockerTaskEngine) loadQuarantinedImageData() {
+	val, err := engine.dataClient.GetMetadata(data.QuarantinedImagesKey)
+	if err != nil {
+		// No image has been quarantined yet
+		if !strings.Contains(err.Error(), "not found") {
+			seelog.Errorf("Failed to load data for quarantined images: %v", err)
+		}
+		return
+	}
+	if val == "" {
+		return
+	}
+	var imageRefs []string
+	if err := json.Unmarshal([]byte(val), &imageRefs); err != nil {
+		seelog.Errorf("Failed to unmarshal quarantined images: %v", err)
+		return
+	}
+	engine.quarantinedImagesLock.Lock()
+	defer engine.quarantinedImagesLock.Unlock()
+	if engine.quarantinedImages == nil {
+		engine.quarantinedImages = make(map[string]struct{})
+	}
+	for _, imageRef := range imageRefs {
+		engine.quarantinedImages[imageRef] = struct{}{}
+	}
+}
+
+// imageStateDataRetryAttempts is the number of attempts to persist a change of an image state, waiting between
+// imageStateDataRetryMinDelay and imageStateDataRetryMaxDelay between attempts
+const (
+	imageStateDataRetryAttempts   = 3
+	imageStateDataRetryMinDelay   = 10 * time.Millisecond
+	imageStateDataRetryMaxDelay   = 100 * time.Millisecond
+	imageStateDataRetryJitter     = 0.2
+	imageStateDataRetryMultiplier = 2
+)
+
 func (imageManager *dockerImageManager) saveImageStateData(imageState *image.ImageState) {
-	err := imageManager.dataClient.SaveImageState(imageState)
+	imageID := imageState.GetImageID()
+	err := retryImageStateData(func() error {
+		return imageManager.dataClient.SaveImageState(imageState)
+	})
 	if err != nil {
-		seelog.Errorf("Failed to save data for image state %s:, %v", imageState.GetImageID(), err)
+		// The image state in memory stays authoritative, it is saved again on its next change
+		logger.Error("Failed to save data for image state, the image state in memory is kept", logger.Fields{
+			field.Image: imageID,
+			field.Error: err,
+		})
+		metrics.MetricsEngineGlobal.RecordStateManagerMetric("IMAGE_STATE_SAVE_FAILURE")()
+	} else {
+		imageManager.forgetPendingImageStateDeletion(imageID)
 	}
+	imageManager.writeImageLastUsedMetadata(imageState)
 }
 
 func (imageManager *dockerImageManager) removeImageStateData(imageId string) {
-	err := imageManager.dataClient.DeleteImageState(imageId)
+	err := retryImageStateData(func() error {
+		return imageManager.dataClient.DeleteImageState(imageId)
+	})
 	if err != nil {
-		seelog.Errorf("Failed to remove data for image state %s:, %v", imageId, err)
+		// The image state is removed from memory regardless, its data is deleted again on the next cleanup cycle
+		// so that the image is not tracked again after a restart
+		logger.Error("Failed to remove data for image state, it will be removed again on the next image cleanup cycle", logger.Fields{
+			field.Image: imageId,
+			field.Error: err,
+		})
+		metrics.MetricsEngineGlobal.RecordStateManagerMetric("IMAGE_STATE_DELETE_FAILURE")()
+		imageManager.pendingImageStateDeletionsLock.Lock()
+		if imageManager.pendingImageStateDeletions == nil {
+			imageManager.pendingImageStateDeletions = make(map[string]struct{})
+		}
+		imageManager.pendingImageStateDeletions[imageId] = struct{}{}
+		imageManager.pendingImageStateDeletionsLock.Unlock()
+	}
+	imageManager.removeImageLastUsedMetadata(imageId)
+}
+
+// removePendingImageStateData deletes again the data of the image states removed from memory whose data could
+// not be deleted, unless the images have been tracked again since
+func (imageManager *dockerImageManager) removePendingImageStateData() {
+	imageManager.pendingImageStateDeletionsLock.Lock()
+	imageIDs := make([]string, 0, len(imageManager.pendingImageStateDeletions))
+	for imageID := range imageManager.pendingImageStateDeletions {
+		imageIDs = append(imageIDs, imageID)
 	}
+	imageManager.pendingImageStateDeletions = nil
+	imageManager.pendingImageStateDeletionsLock.Unlock()
+
+	for _, imageID := range imageIDs {
+		if _, ok := imageManager.getImageState(imageID); ok {
+			continue
+		}
+		seelog.Infof("Removing data for image state %s which previously failed to be removed", imageID)
+		imageManager.removeImageStateData(imageID)
+	}
+}
+
+// forgetPendingImageStateDeletion stops deleting the data of an image state which has been saved again
+func (imageManager *dockerImageManager) forgetPendingImageStateDeletion(imageID string) {
+	imageManager.pendingImageStateDeletionsLock.Lock()
+	defer imageManager.pendingImageStateDeletionsLock.Unlock()
+	delete(imageManager.pendingImageStateDeletions, imageID)
+}
+
+// retryImageStateData calls fn, which persists a change of an image state, until it succeeds or
+// imageStateDataRetryAttempts is reached
+func retryImageStateData(fn func() error) error {
+	backoff := retry.NewExponentialBackoff(imageStateDataRetryMinDelay, imageStateDataRetryMaxDelay,
+		imageStateDataRetryJitter, imageStateDataRetryMultiplier)
+	return retry.RetryNWithBackoff(backoff, imageStateDataRetryAttempts, fn)
 }
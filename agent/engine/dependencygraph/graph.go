@@ -86,6 +86,14 @@ func (de *dependencyError) IsTerminal() bool {
 	return de.isTerminal
 }
 
+// DependencyTimedOutError is the terminal error returned when a container ordering dependency has
+// not reached its dependency condition within the start timeout of the dependency container.
+type DependencyTimedOutError struct {
+	dependencyError
+	// DependencyName is the name of the dependency container that has timed out
+	DependencyName string
+}
+
 // ValidDependencies takes a task and verifies that it is possible to allow all
 // containers within it to reach the desired status by proceeding in some
 // order.
@@ -113,6 +121,63 @@ OuterLoop:
 	return true
 }
 
+// DependencyCycle returns the names of the containers of the task that depend on each other in a
+// cycle, in the order of their dependencies. Nil is returned if the dependencies of the task are acyclic.
+func DependencyCycle(task *apitask.Task) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	containers := make(map[string]*apicontainer.Container, len(task.Containers))
+	for _, container := range task.Containers {
+		containers[container.Name] = container
+	}
+	state := make(map[string]int, len(task.Containers))
+	var path []string
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		container, ok := containers[name]
+		if !ok {
+			// Missing dependencies are not cycles and are reported by ValidDependencies
+			return nil
+		}
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			for i, pathName := range path {
+				if pathName == name {
+					return append([]string{}, path[i:]...)
+				}
+			}
+		}
+		state[name] = visiting
+		path = append(path, name)
+		dependencies := make([]string, 0, len(container.GetDependsOn())+len(container.SteadyStateDependencies))
+		for _, dependency := range container.GetDependsOn() {
+			dependencies = append(dependencies, dependency.ContainerName)
+		}
+		dependencies = append(dependencies, container.SteadyStateDependencies...)
+		for _, dependency := range dependencies {
+			if cycle := visit(dependency); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+
+	for _, container := range task.Containers {
+		if cycle := visit(container.Name); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
 // DependenciesCanBeResolved verifies that it's possible to transition a `target`
 // given a group of already handled containers, `by`. Essentially, it asks "is
 // `target` resolved by `by`". It assumes that everything in `by` has reached
@@ -289,7 +354,10 @@ func verifyContainerOrderingStatusResolvable(target *apicontainer.Container, exi
 		// However, if dependency container has already stopped, then it cannot time out.
 		if targetKnown < apicontainerstatus.ContainerCreated && dependencyContainer.GetKnownStatus() != apicontainerstatus.ContainerStopped {
 			if hasDependencyTimedOut(dependencyContainer, dependency.Condition) {
-				return nil, &dependencyError{err: fmt.Errorf("dependency graph: container ordering dependency [%v] for target [%v] has timed out.", dependencyContainer, target), isTerminal: true}
+				return nil, &DependencyTimedOutError{
+					dependencyError: dependencyError{err: fmt.Errorf("dependency graph: container ordering dependency [%v] for target [%v] has timed out.", dependencyContainer, target), isTerminal: true},
+					DependencyName:  dependencyContainer.Name,
+				}
 			}
 		}
 
@@ -518,6 +586,86 @@ func verifyShutdownOrder(target *apicontainer.Container, existingContainers map[
 		target.Name, strings.Join(missingShutdownDependencies, "], ["))}
 }
 
+// TeardownOrderIsResolved validates that the `target` container can be stopped as part of an
+// ordered teardown of its task, where containers are stopped one at a time in the reverse of their
+// start order so that each of them gets its full stop timeout.
+func TeardownOrderIsResolved(target *apicontainer.Container, by []*apicontainer.Container) DependencyError {
+	if target.IsInternal() || !target.DesiredTerminal() || target.KnownTerminal() {
+		return nil
+	}
+	nameMap := make(map[string]*apicontainer.Container)
+	for _, cont := range by {
+		nameMap[cont.Name] = cont
+	}
+	missingShutdownDependencies := []string{}
+	for _, name := range teardownOrder(by) {
+		if name == target.Name {
+			break
+		}
+		if !nameMap[name].KnownTerminal() {
+			missingShutdownDependencies = append(missingShutdownDependencies, name)
+		}
+	}
+
+	if len(missingShutdownDependencies) == 0 {
+		return nil
+	}
+
+	return &dependencyError{err: fmt.Errorf("dependency graph: target %s needs other containers stopped before it can be torn down: [%s]",
+		target.Name, strings.Join(missingShutdownDependencies, "], ["))}
+}
+
+// teardownOrder returns the names of the non internal containers in the reverse of their start
+// order. Containers are started after their dependencies, and in task definition order otherwise.
+func teardownOrder(containers []*apicontainer.Container) []string {
+	pending := []*apicontainer.Container{}
+	for _, cont := range containers {
+		if !cont.IsInternal() {
+			pending = append(pending, cont)
+		}
+	}
+	started := make(map[string]bool)
+	order := make([]string, len(pending))
+	for i := len(order) - 1; i >= 0; i-- {
+		next := -1
+		for j, cont := range pending {
+			if dependenciesStarted(cont, pending, started) {
+				next = j
+				break
+			}
+		}
+		if next < 0 {
+			// The dependencies form a cycle, which is rejected when the task is added. Fall back
+			// to the task definition order for the remaining containers.
+			next = 0
+		}
+		started[pending[next].Name] = true
+		order[i] = pending[next].Name
+		pending = append(pending[:next], pending[next+1:]...)
+	}
+	return order
+}
+
+// dependenciesStarted returns true if none of the pending containers is a dependency of `target`
+func dependenciesStarted(target *apicontainer.Container, pending []*apicontainer.Container, started map[string]bool) bool {
+	dependencies := make([]string, 0, len(target.GetDependsOn())+len(target.SteadyStateDependencies))
+	for _, dependsOn := range target.GetDependsOn() {
+		dependencies = append(dependencies, dependsOn.ContainerName)
+	}
+	dependencies = append(dependencies, target.SteadyStateDependencies...)
+	for _, dependency := range dependencies {
+		if started[dependency] || dependency == target.Name {
+			continue
+		}
+		for _, cont := range pending {
+			if cont.Name == dependency {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 func onSteadyStateCanResolve(target *apicontainer.Container, run *apicontainer.Container) bool {
 	return target.GetDesiredStatus() >= apicontainerstatus.ContainerCreated &&
 		run.GetDesiredStatus() >= run.GetSteadyStateStatus()
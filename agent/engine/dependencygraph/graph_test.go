@@ -31,6 +31,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func volumeStrToVol(vols []string) []apicontainer.VolumeFrom {
@@ -106,6 +107,67 @@ func TestValidDependenciesWithCycles(t *testing.T) {
 	assert.False(t, resolveable, "Cycle should not be resolveable")
 }
 
+func TestDependencyCycle(t *testing.T) {
+	testCases := []struct {
+		name          string
+		containers    []*apicontainer.Container
+		expectedCycle []string
+	}{
+		{
+			name: "no dependencies",
+			containers: []*apicontainer.Container{
+				steadyStateContainer("a", nil, apicontainerstatus.ContainerRunning, apicontainerstatus.ContainerRunning),
+			},
+		},
+		{
+			name: "acyclic dependencies",
+			containers: []*apicontainer.Container{
+				steadyStateContainer("a", []apicontainer.DependsOn{{ContainerName: "b", Condition: startCondition}, {ContainerName: "c", Condition: startCondition}}, apicontainerstatus.ContainerRunning, apicontainerstatus.ContainerRunning),
+				steadyStateContainer("b", []apicontainer.DependsOn{{ContainerName: "c", Condition: startCondition}}, apicontainerstatus.ContainerRunning, apicontainerstatus.ContainerRunning),
+				steadyStateContainer("c", nil, apicontainerstatus.ContainerRunning, apicontainerstatus.ContainerRunning),
+			},
+		},
+		{
+			name: "missing dependency",
+			containers: []*apicontainer.Container{
+				steadyStateContainer("a", []apicontainer.DependsOn{{ContainerName: "b", Condition: startCondition}}, apicontainerstatus.ContainerRunning, apicontainerstatus.ContainerRunning),
+			},
+		},
+		{
+			name: "self dependency",
+			containers: []*apicontainer.Container{
+				steadyStateContainer("a", []apicontainer.DependsOn{{ContainerName: "a", Condition: startCondition}}, apicontainerstatus.ContainerRunning, apicontainerstatus.ContainerRunning),
+			},
+			expectedCycle: []string{"a"},
+		},
+		{
+			name: "three container cycle",
+			containers: []*apicontainer.Container{
+				steadyStateContainer("init", nil, apicontainerstatus.ContainerRunning, apicontainerstatus.ContainerRunning),
+				steadyStateContainer("a", []apicontainer.DependsOn{{ContainerName: "init", Condition: successCondition}, {ContainerName: "b", Condition: startCondition}}, apicontainerstatus.ContainerRunning, apicontainerstatus.ContainerRunning),
+				steadyStateContainer("b", []apicontainer.DependsOn{{ContainerName: "c", Condition: healthyCondition}}, apicontainerstatus.ContainerRunning, apicontainerstatus.ContainerRunning),
+				steadyStateContainer("c", []apicontainer.DependsOn{{ContainerName: "a", Condition: createCondition}}, apicontainerstatus.ContainerRunning, apicontainerstatus.ContainerRunning),
+			},
+			expectedCycle: []string{"a", "b", "c"},
+		},
+		{
+			name: "cycle through links",
+			containers: []*apicontainer.Container{
+				{Name: "a", SteadyStateDependencies: []string{"b"}},
+				steadyStateContainer("b", []apicontainer.DependsOn{{ContainerName: "a", Condition: startCondition}}, apicontainerstatus.ContainerRunning, apicontainerstatus.ContainerRunning),
+			},
+			expectedCycle: []string{"a", "b"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			task := &apitask.Task{Containers: tc.containers}
+			assert.Equal(t, tc.expectedCycle, DependencyCycle(task))
+		})
+	}
+}
+
 func TestValidDependenciesWithUnresolvedReference(t *testing.T) {
 	// Unresolveable, reference doesn't exist
 	task := &apitask.Task{
@@ -1320,6 +1382,101 @@ func TestVerifyShutdownOrder(t *testing.T) {
 	}
 }
 
+func TestTeardownOrder(t *testing.T) {
+	containers := []*apicontainer.Container{
+		{Name: "app", DependsOnUnsafe: dependsOn("db")},
+		{Name: "db"},
+		{Name: "logger"},
+		{Name: "pause", Type: apicontainer.ContainerCNIPause},
+		{Name: "sidecar", DependsOnUnsafe: dependsOn("app")},
+	}
+	assert.Equal(t, []string{"sidecar", "logger", "app", "db"}, teardownOrder(containers))
+}
+
+func TestTeardownOrderIsResolved(t *testing.T) {
+	newContainer := func(name string, knownStatus apicontainerstatus.ContainerStatus, dependencies ...string) *apicontainer.Container {
+		return &apicontainer.Container{
+			Name:                name,
+			DependsOnUnsafe:     dependsOn(dependencies...),
+			KnownStatusUnsafe:   knownStatus,
+			DesiredStatusUnsafe: apicontainerstatus.ContainerStopped,
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		containers    []*apicontainer.Container
+		target        string
+		shouldResolve bool
+	}{
+		{
+			name: "last container of the start order",
+			containers: []*apicontainer.Container{
+				newContainer("essential", apicontainerstatus.ContainerStopped),
+				newContainer("a", apicontainerstatus.ContainerRunning),
+				newContainer("b", apicontainerstatus.ContainerRunning),
+			},
+			target:        "b",
+			shouldResolve: true,
+		},
+		{
+			name: "container started later is running",
+			containers: []*apicontainer.Container{
+				newContainer("essential", apicontainerstatus.ContainerStopped),
+				newContainer("a", apicontainerstatus.ContainerRunning),
+				newContainer("b", apicontainerstatus.ContainerRunning),
+			},
+			target:        "a",
+			shouldResolve: false,
+		},
+		{
+			name: "dependent defined earlier is running",
+			containers: []*apicontainer.Container{
+				newContainer("a", apicontainerstatus.ContainerRunning, "b"),
+				newContainer("b", apicontainerstatus.ContainerRunning),
+			},
+			target:        "b",
+			shouldResolve: false,
+		},
+		{
+			name: "dependency defined later waits for its dependent only",
+			containers: []*apicontainer.Container{
+				newContainer("a", apicontainerstatus.ContainerRunning, "b"),
+				newContainer("b", apicontainerstatus.ContainerRunning),
+			},
+			target:        "a",
+			shouldResolve: true,
+		},
+		{
+			name: "containers started later are stopped",
+			containers: []*apicontainer.Container{
+				newContainer("a", apicontainerstatus.ContainerRunning),
+				newContainer("b", apicontainerstatus.ContainerStopped),
+				newContainer("c", apicontainerstatus.ContainerStopped),
+			},
+			target:        "a",
+			shouldResolve: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var target *apicontainer.Container
+			for _, cont := range tc.containers {
+				if cont.Name == tc.target {
+					target = cont
+				}
+			}
+			require.NotNil(t, target)
+			if tc.shouldResolve {
+				assert.NoError(t, TeardownOrderIsResolved(target, tc.containers))
+			} else {
+				assert.Error(t, TeardownOrderIsResolved(target, tc.containers))
+			}
+		})
+	}
+}
+
 func TestStartTimeoutForContainerOrdering(t *testing.T) {
 	testcases := []struct {
 		DependencyStartedAt    time.Time
@@ -1396,3 +1553,39 @@ func TestVerifyContainerOrderingStatusResolvableFailOnDependencyWontStart(t *tes
 	_, err := verifyContainerOrderingStatusResolvable(target, contMap, &config.Config{}, dummyResolves)
 	assert.Error(t, err)
 }
+
+func TestVerifyContainerOrderingStatusResolvableDependencyTimedOut(t *testing.T) {
+	targetName := "target"
+	dependencyName := "dependency"
+	target := &apicontainer.Container{
+		Name:                targetName,
+		KnownStatusUnsafe:   apicontainerstatus.ContainerPulled,
+		DesiredStatusUnsafe: apicontainerstatus.ContainerRunning,
+		DependsOnUnsafe: []apicontainer.DependsOn{
+			{
+				ContainerName: dependencyName,
+				Condition:     healthyCondition,
+			},
+		},
+	}
+	dep := &apicontainer.Container{
+		Name:                dependencyName,
+		KnownStatusUnsafe:   apicontainerstatus.ContainerRunning,
+		DesiredStatusUnsafe: apicontainerstatus.ContainerRunning,
+		StartTimeout:        10,
+	}
+	dep.SetStartedAt(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+	contMap := map[string]*apicontainer.Container{
+		targetName:     target,
+		dependencyName: dep,
+	}
+	dummyResolves := func(*apicontainer.Container, *apicontainer.Container, string, *config.Config) bool {
+		return true
+	}
+	_, err := verifyContainerOrderingStatusResolvable(target, contMap, &config.Config{}, dummyResolves)
+	require.Error(t, err)
+	timedOutErr, ok := err.(*DependencyTimedOutError)
+	require.True(t, ok, "expected a DependencyTimedOutError")
+	assert.True(t, timedOutErr.IsTerminal())
+	assert.Equal(t, dependencyName, timedOutErr.DependencyName)
+}
@@ -15,7 +15,12 @@ package engine
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
@@ -33,11 +38,21 @@ import (
 	"github.com/aws/amazon-ecs-agent/agent/dockerclient/dockerapi"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
 	"github.com/aws/amazon-ecs-agent/agent/engine/image"
+	"github.com/aws/amazon-ecs-agent/agent/metrics"
+	"github.com/aws/amazon-ecs-agent/agent/utils"
 	"github.com/cihub/seelog"
+	"github.com/pborman/uuid"
 )
 
 const (
 	imageNotFoundForDeletionError = "no such image"
+	// untaggedImageRepoTag is the name docker may list for an image without any name
+	untaggedImageRepoTag = "<none>:<none>"
+	// imageLastUsedMetadataTempFile is the prefix of the temporary files image metadata is written to before
+	// replacing the metadata file of the image
+	imageLastUsedMetadataTempFile = "tmp_image_metadata"
+	imageLastUsedMetadataDirPerm  = 0755
+	imageLastUsedMetadataFilePerm = 0644
 )
 
 // ImageManager is responsible for saving the Image states,
@@ -47,8 +62,16 @@ type ImageManager interface {
 	RemoveContainerReferenceFromImageState(container *apicontainer.Container) error
 	AddAllImageStates(imageStates []*image.ImageState)
 	GetImageStateFromImageName(containerImageName string) (*image.ImageState, bool)
+	LoadPrewarmImages(ctx context.Context)
 	StartImageCleanupProcess(ctx context.Context)
 	SetDataClient(dataClient data.Client)
+	GetImageCleanupHistory() []image.CleanupCycleStats
+	RunImageCleanup(ctx context.Context) (string, error)
+	GetImageCleanupEligibility(imageRef string) (image.CleanupEligibility, bool)
+	GetImageCleanupOrder() []image.CleanupEligibility
+	PinImage(imageRef string, ttl time.Duration) time.Time
+	UnpinImage(imageRef string) bool
+	GetPinnedImages() map[string]time.Time
 }
 
 // dockerImageManager accounts all the images and their states in the instance.
@@ -70,6 +93,76 @@ type dockerImageManager struct {
 	nonECSContainerCleanupWaitDuration time.Duration
 	numNonECSContainersToDelete        int
 	nonECSMinimumAgeBeforeDeletion     time.Duration
+	// cleanupStats holds the statistics of the cleanup cycle in progress, if any
+	cleanupStats *image.CleanupCycleStats
+	// cleanupStatsHistory holds the statistics of the most recent cleanup cycles, oldest first
+	cleanupStatsHistory     []image.CleanupCycleStats
+	cleanupStatsHistorySize int
+	cleanupStatsLock        sync.RWMutex
+	// onDemandCleanupCycleID is the ID of the cleanup cycle run on demand in progress, if any
+	onDemandCleanupCycleID string
+	// removeFailureWarningThreshold is the number of consecutive failed attempts to remove an image
+	// after which a warning is logged
+	removeFailureWarningThreshold int
+	// prioritizeSize specifies whether the largest eligible images are removed first
+	prioritizeSize config.BooleanDefaultFalse
+	// lastUsedMetadataDir is the directory where the metadata of the images is written for host tooling, if any
+	lastUsedMetadataDir string
+	// maxTrackedImageStates is a soft cap on the number of tracked images. There is no cap when it is zero.
+	maxTrackedImageStates int
+	// maxTotalImageDiskBytes is the budget on the disk space taken by the tracked images, enforced after each
+	// cleanup cycle. There is no budget when it is zero.
+	maxTotalImageDiskBytes int64
+	// reclaimRequested is signalled when the number of tracked images exceeds maxTrackedImageStates
+	reclaimRequested chan struct{}
+	// minimumAgeBeforeDeletionSoft is the minimum age of the images removed under pressure, either to bring the
+	// number of tracked images under maxTrackedImageStates or when image cleanup is escalated as the disk usage
+	// is high. minimumAgeBeforeDeletion is used instead when it is zero.
+	minimumAgeBeforeDeletionSoft time.Duration
+	// pullCooldown is how long periodic cleanup is skipped for after an image is pulled
+	pullCooldown time.Duration
+	// lastImagePullAt is when an image was last pulled for a container
+	lastImagePullAt   time.Time
+	lastImagePullLock sync.RWMutex
+	// prewarmTarballDir is the directory of the image tarballs loaded on startup
+	prewarmTarballDir string
+	// familyProtectionWindow is how long images are protected from cleanup after a task family used them
+	familyProtectionWindow time.Duration
+	// daemonContainerImageIDs maps the IDs of the images of the containers known to the daemon, in any state,
+	// to the IDs of these containers during a cleanup cycle
+	daemonContainerImageIDs map[string][]string
+	// escalationDiskThreshold is the disk usage percentage of escalationDiskPath above which image cleanup is
+	// escalated after a cleanup cycle. Cleanup is never escalated when it is zero.
+	escalationDiskThreshold int
+	// escalationInodeThreshold is the inode usage percentage of escalationDiskPath above which image cleanup is
+	// escalated after a cleanup cycle, whatever the disk usage. Inode usage is not checked when it is zero.
+	escalationInodeThreshold int
+	// escalationMinimumAge is the floor of the minimum image age when image cleanup is escalated
+	escalationMinimumAge time.Duration
+	escalationDiskPath   string
+	// escalationStoppedTaskGrace is how long after a task stopped an escalated cleanup may remove the containers
+	// of the task to remove their images. The images of stopped tasks are kept until the tasks are cleaned up
+	// when it is zero.
+	escalationStoppedTaskGrace time.Duration
+	// cleanupWindow is the daily window during which periodic cleanup cycles remove images. Cycles outside of
+	// it only log the images they would remove. Images are removed at any time when it is nil.
+	cleanupWindow *config.ImageCleanupWindow
+	// pendingImageStateDeletions are the IDs of the image states removed from memory whose data failed to be
+	// deleted, which is deleted again on the next cleanup cycle
+	pendingImageStateDeletions     map[string]struct{}
+	pendingImageStateDeletionsLock sync.Mutex
+	// pinnedImages maps the IDs and names of the images pinned on demand to when their pin expires. Cleanup
+	// skips pinned images until then.
+	pinnedImages     map[string]time.Time
+	pinnedImagesLock sync.RWMutex
+	// startedAt is when the image manager was created, i.e. when the agent started
+	startedAt time.Time
+	// startupSettlePeriod is how long after startedAt the images not seen in use since then are protected from
+	// cleanup. Images are not protected when it is zero.
+	startupSettlePeriod time.Duration
+	// agentImageRepositories holds the repositories of the images of the agent and of the pause container. Images
+	// from these repositories are never removed, whatever their tag.
+	agentImageRepositories map[string]struct{}
 }
 
 // ImageStatesForDeletion is used for implementing the sort interface
@@ -81,14 +174,34 @@ func NewImageManager(cfg *config.Config, client dockerapi.DockerClient, state do
 		client:                             client,
 		state:                              state,
 		minimumAgeBeforeDeletion:           cfg.MinimumImageDeletionAge,
+		minimumAgeBeforeDeletionSoft:       cfg.MinimumImageDeletionAgeSoft,
 		numImagesToDelete:                  cfg.NumImagesToDeletePerCycle,
 		imageCleanupTimeInterval:           cfg.ImageCleanupInterval,
 		imagePullBehavior:                  cfg.ImagePullBehavior,
 		imageCleanupExclusionList:          buildImageCleanupExclusionList(cfg),
+		agentImageRepositories:             buildAgentImageRepositories(cfg),
 		deleteNonECSImagesEnabled:          cfg.DeleteNonECSImagesEnabled,
 		nonECSContainerCleanupWaitDuration: cfg.TaskCleanupWaitDuration,
 		numNonECSContainersToDelete:        cfg.NumNonECSContainersToDeletePerCycle,
 		nonECSMinimumAgeBeforeDeletion:     cfg.NonECSMinimumImageDeletionAge,
+		cleanupStatsHistorySize:            cfg.ImageCleanupStatsHistorySize,
+		removeFailureWarningThreshold:      cfg.ImageRemoveFailureWarningThreshold,
+		lastUsedMetadataDir:                cfg.ImageLastUsedMetadataDir,
+		prioritizeSize:                     cfg.ImageCleanupPrioritizeSize,
+		maxTrackedImageStates:              cfg.ImageCleanupMaxTrackedImages,
+		maxTotalImageDiskBytes:             cfg.MaxTotalImageDiskBytes,
+		reclaimRequested:                   make(chan struct{}, 1),
+		pullCooldown:                       cfg.ImageCleanupPullCooldown,
+		prewarmTarballDir:                  cfg.ImagePrewarmTarballDir,
+		familyProtectionWindow:             cfg.ImageFamilyProtectionWindow,
+		escalationDiskThreshold:            cfg.ImageCleanupEscalationDiskThreshold,
+		escalationInodeThreshold:           cfg.ImageCleanupEscalationInodeThreshold,
+		escalationMinimumAge:               cfg.ImageCleanupEscalationMinimumAge,
+		escalationDiskPath:                 cfg.ImageCleanupEscalationDiskPath,
+		escalationStoppedTaskGrace:         cfg.ImageCleanupEscalationStoppedTaskGrace,
+		cleanupWindow:                      cfg.ImageCleanupWindow,
+		startedAt:                          time.Now(),
+		startupSettlePeriod:                cfg.ImageCleanupStartupSettlePeriod,
 	}
 }
 
@@ -112,6 +225,31 @@ func buildImageCleanupExclusionList(cfg *config.Config) []string {
 	return excludedImages
 }
 
+// buildAgentImageRepositories returns the repositories of the images the agent itself depends on: the agent image
+// and the pause image, both configured and built in
+func buildAgentImageRepositories(cfg *config.Config) map[string]struct{} {
+	repositories := make(map[string]struct{})
+	for _, name := range []string{
+		cfg.PauseContainerImageName,
+		config.DefaultPauseContainerImageName,
+		config.CachedImageNameAgentContainer,
+	} {
+		if repository := imageRepository(name); repository != "" {
+			repositories[repository] = struct{}{}
+		}
+	}
+	return repositories
+}
+
+// imageRepository returns the repository of the image name, without its tag or digest
+func imageRepository(name string) string {
+	if n := strings.Index(name, "@"); n >= 0 {
+		name = name[:n]
+	}
+	repository, _ := utils.ParseRepositoryTag(name)
+	return repository
+}
+
 func (imageManager *dockerImageManager) AddAllImageStates(imageStates []*image.ImageState) {
 	imageManager.updateLock.Lock()
 	defer imageManager.updateLock.Unlock()
@@ -140,6 +278,9 @@ func (imageManager *dockerImageManager) RecordContainerReference(container *apic
 	if container.Image == "" {
 		return fmt.Errorf("Invalid container reference: Empty image name")
 	}
+	if _, pulled := container.GetImagePullDuration(); pulled && !container.IsImagePullCached() {
+		imageManager.recordImagePull(time.Now())
+	}
 
 	// Inspect image for obtaining Container's Image ID
 	imageInspected, err := imageManager.client.InspectImage(container.Image)
@@ -154,9 +295,29 @@ func (imageManager *dockerImageManager) RecordContainerReference(container *apic
 	if !added {
 		imageManager.addContainerReferenceToNewImageState(container, imageInspected.Size)
 	}
+	imageManager.recordImagePlatform(container.ImageID, imageInspected)
 	return nil
 }
 
+// recordImagePlatform records the platform of the inspected image in its image state, which is the variant
+// that was pulled for multi-platform images
+func (imageManager *dockerImageManager) recordImagePlatform(imageID string, imageInspected *types.ImageInspect) {
+	if imageInspected.Os == "" || imageInspected.Architecture == "" {
+		return
+	}
+	imageManager.updateLock.RLock()
+	defer imageManager.updateLock.RUnlock()
+	imageState, ok := imageManager.getImageState(imageID)
+	if !ok {
+		return
+	}
+	platform := imageInspected.Os + "/" + imageInspected.Architecture
+	if imageState.GetPlatform() != platform {
+		imageState.SetPlatform(platform)
+		imageManager.saveImageStateData(imageState)
+	}
+}
+
 // check whether image pull from ECR
 func (imageManager *dockerImageManager) isImagePullFromECR(container *apicontainer.Container) bool {
 	return container.RegistryAuthentication != nil && container.RegistryAuthentication.ECRAuthData != nil && container.RegistryAuthentication.Type == apicontainer.AuthTypeECR
@@ -192,6 +353,7 @@ func (imageManager *dockerImageManager) addContainerReferenceToExistingImageStat
 	imageState, ok := imageManager.getImageState(container.ImageID)
 	if ok {
 		imageState.UpdateImageState(container)
+		imageManager.recordFamilyUse(imageState, container)
 		imageManager.saveImageStateData(imageState)
 	}
 	return ok
@@ -206,6 +368,7 @@ func (imageManager *dockerImageManager) addContainerReferenceToNewImageState(con
 	imageState, ok := imageManager.getImageState(container.ImageID)
 	if ok {
 		imageState.UpdateImageState(container)
+		imageManager.recordFamilyUse(imageState, container)
 		imageManager.saveImageStateData(imageState)
 	} else {
 		sourceImage := &image.Image{
@@ -218,6 +381,7 @@ func (imageManager *dockerImageManager) addContainerReferenceToNewImageState(con
 			LastUsedAt: time.Now(),
 		}
 		sourceImageState.UpdateImageState(container)
+		imageManager.recordFamilyUse(sourceImageState, container)
 		imageManager.addImageState(sourceImageState)
 	}
 }
@@ -241,6 +405,7 @@ func (imageManager *dockerImageManager) RemoveContainerReferenceFromImageState(c
 	if err != nil {
 		return err
 	}
+	imageManager.recordFamilyUse(imageState, container)
 	imageManager.saveImageStateData(imageState)
 	return nil
 }
@@ -248,7 +413,19 @@ func (imageManager *dockerImageManager) RemoveContainerReferenceFromImageState(c
 func (imageManager *dockerImageManager) addImageState(imageState *image.ImageState) {
 	imageManager.imageStates = append(imageManager.imageStates, imageState)
 	imageManager.saveImageStateData(imageState)
+	imageManager.requestReclaimIfOverCap()
+}
 
+// requestReclaimIfOverCap signals the image cleanup process to reclaim images when more images than the cap
+// are tracked. A reclaim already requested and not yet started covers the new image as well.
+func (imageManager *dockerImageManager) requestReclaimIfOverCap() {
+	if imageManager.maxTrackedImageStates <= 0 || len(imageManager.imageStates) <= imageManager.maxTrackedImageStates {
+		return
+	}
+	select {
+	case imageManager.reclaimRequested <- struct{}{}:
+	default:
+	}
 }
 
 // getAllImageStates returns the list of imageStates in the instance
@@ -279,7 +456,71 @@ func (imageManager *dockerImageManager) removeImageState(imageStateToBeRemoved *
 	}
 }
 
-func (imageManager *dockerImageManager) getCandidateImagesForDeletion() []*image.ImageState {
+// imageLastUsedMetadataFile returns the path of the metadata file of the image. Image IDs are prefixed with
+// their digest algorithm, and colons are not allowed in file names on Windows.
+func (imageManager *dockerImageManager) imageLastUsedMetadataFile(imageID string) string {
+	return filepath.Join(imageManager.lastUsedMetadataDir, strings.Replace(imageID, ":", "-", -1)+".json")
+}
+
+// writeImageLastUsedMetadata writes the image state, including when the image was last used, to the metadata
+// file of the image so that host tooling does not have to query the agent. The file is replaced atomically
+// so that readers never observe a partial write.
+func (imageManager *dockerImageManager) writeImageLastUsedMetadata(imageState *image.ImageState) {
+	imageID := imageState.GetImageID()
+	if imageManager.lastUsedMetadataDir == "" || imageID == "" {
+		return
+	}
+	if err := imageManager.replaceImageLastUsedMetadata(imageState, imageID); err != nil {
+		logger.Warn("Unable to write image metadata", logger.Fields{
+			"imageID":   imageID,
+			field.Error: err,
+		})
+	}
+}
+
+func (imageManager *dockerImageManager) replaceImageLastUsedMetadata(imageState *image.ImageState, imageID string) error {
+	data, err := json.Marshal(imageState)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(imageManager.lastUsedMetadataDir, imageLastUsedMetadataDirPerm); err != nil {
+		return err
+	}
+	temp, err := ioutil.TempFile(imageManager.lastUsedMetadataDir, imageLastUsedMetadataTempFile)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	_, err = temp.Write(data)
+	if err == nil {
+		err = temp.Chmod(imageLastUsedMetadataFilePerm)
+	}
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), imageManager.imageLastUsedMetadataFile(imageID))
+}
+
+// removeImageLastUsedMetadata removes the metadata file of an image which is no longer tracked
+func (imageManager *dockerImageManager) removeImageLastUsedMetadata(imageID string) {
+	if imageManager.lastUsedMetadataDir == "" || imageID == "" {
+		return
+	}
+	err := os.Remove(imageManager.imageLastUsedMetadataFile(imageID))
+	if err != nil && !os.IsNotExist(err) {
+		logger.Warn("Unable to remove image metadata", logger.Fields{
+			"imageID":   imageID,
+			field.Error: err,
+		})
+	}
+}
+
+// getCandidateImagesForDeletion returns the images considered for deletion which are unused and older than
+// minimumAge
+func (imageManager *dockerImageManager) getCandidateImagesForDeletion(minimumAge time.Duration) []*image.ImageState {
 	if len(imageManager.imageStatesConsideredForDeletion) < 1 {
 		seelog.Debugf("Image Manager: Empty state!")
 		// no image states present in image manager
@@ -287,7 +528,11 @@ func (imageManager *dockerImageManager) getCandidateImagesForDeletion() []*image
 	}
 	var imagesForDeletion []*image.ImageState
 	for _, imageState := range imageManager.imageStatesConsideredForDeletion {
-		if imageManager.isImageOldEnough(imageState) && imageState.HasNoAssociatedContainers() {
+		if imageManager.isAgentImage(imageState) {
+			continue
+		}
+		if imageManager.isImageOldEnough(imageState, minimumAge) && !imageManager.isImageInUse(imageState) &&
+			!imageManager.isImageProtectedByFamily(imageState) && !imageManager.isImageSettlingAfterStartup(imageState) {
 			seelog.Infof("Candidate image for deletion: [%s]", imageState.String())
 			imagesForDeletion = append(imagesForDeletion, imageState)
 		}
@@ -295,9 +540,160 @@ func (imageManager *dockerImageManager) getCandidateImagesForDeletion() []*image
 	return imagesForDeletion
 }
 
-func (imageManager *dockerImageManager) isImageOldEnough(imageState *image.ImageState) bool {
+// isImageInUse returns true if the image is referenced by a container, pinned by a task which has not
+// stopped yet, or used by a container known to the daemon
+func (imageManager *dockerImageManager) isImageInUse(imageState *image.ImageState) bool {
+	return !imageState.HasNoAssociatedContainers() || imageManager.isImagePinnedByTask(imageState) ||
+		imageManager.isImageUsedByDaemonContainer(imageState)
+}
+
+// isImageUsedByDaemonContainer returns true if a container known to the daemon at the start of the cleanup
+// cycle uses the image, whatever its state. Containers which are paused or restarting, or which the agent
+// does not track, still need their image when they run again.
+func (imageManager *dockerImageManager) isImageUsedByDaemonContainer(imageState *image.ImageState) bool {
+	_, ok := imageManager.daemonContainerImageIDs[imageState.Image.ImageID]
+	return ok
+}
+
+// loadDaemonContainerImageIDs records the IDs of the images of all the containers known to the daemon for the
+// cleanup cycle in progress. Images are only checked against the containers tracked by the agent when the
+// containers can't be listed.
+func (imageManager *dockerImageManager) loadDaemonContainerImageIDs(ctx context.Context) {
+	imageManager.daemonContainerImageIDs = nil
+	response := imageManager.client.ListContainers(ctx, true, dockerclient.ListContainersTimeout)
+	if response.Error != nil {
+		logger.Warn("Unable to list containers; images will only be checked against the containers tracked by the agent", logger.Fields{
+			field.Error: response.Error,
+		})
+		imageManager.cleanupStats.RecordError("unable to list containers: %v", response.Error)
+		return
+	}
+	imageIDs := make(map[string][]string, len(response.ImageIDs))
+	for containerID, imageID := range response.ImageIDs {
+		imageIDs[imageID] = append(imageIDs[imageID], containerID)
+	}
+	imageManager.daemonContainerImageIDs = imageIDs
+}
+
+// forgetNamesOfUntaggedImages forgets the names of the images considered for deletion which no longer have any
+// name on the daemon, e.g. once all of them have been moved to other images. Such images are then removed by ID,
+// rather than by names which may now refer to other images, and their state is purged.
+func (imageManager *dockerImageManager) forgetNamesOfUntaggedImages(ctx context.Context) {
+	response := imageManager.client.ListImages(ctx, dockerclient.ListImagesTimeout)
+	if response.Error != nil {
+		logger.Warn("Unable to list images; images without names will not be detected", logger.Fields{
+			field.Error: response.Error,
+		})
+		imageManager.cleanupStats.RecordError("unable to list images: %v", response.Error)
+		return
+	}
+	for _, imageState := range imageManager.imageStatesConsideredForDeletion {
+		repoTags, ok := response.ImageRepoTags[imageState.Image.ImageID]
+		if !ok || hasImageName(repoTags) || imageState.GetImageNamesCount() == 0 {
+			continue
+		}
+		names := make([]string, len(imageState.Image.Names))
+		copy(names, imageState.Image.Names)
+		logger.Info("Image no longer has any name on the daemon; forgetting its names so that it is removed by ID", logger.Fields{
+			"imageID": imageState.Image.ImageID,
+			"names":   names,
+		})
+		for _, name := range names {
+			imageState.RemoveImageName(name)
+		}
+		imageManager.saveImageStateData(imageState)
+	}
+}
+
+// hasImageName returns true if any of the names docker lists for an image is an actual name
+func hasImageName(repoTags []string) bool {
+	for _, repoTag := range repoTags {
+		if repoTag != untaggedImageRepoTag {
+			return true
+		}
+	}
+	return false
+}
+
+// recordFamilyUse records that the family of the task of the container used the image now
+func (imageManager *dockerImageManager) recordFamilyUse(imageState *image.ImageState, container *apicontainer.Container) {
+	if family := imageManager.taskFamilyOfContainer(container); family != "" {
+		imageState.RecordFamilyUse(family, time.Now())
+	}
+}
+
+// taskFamilyOfContainer returns the family of the task the container belongs to, or an empty string if the
+// task is not known
+func (imageManager *dockerImageManager) taskFamilyOfContainer(container *apicontainer.Container) string {
+	if imageManager.state == nil {
+		return ""
+	}
+	for _, task := range imageManager.state.AllTasks() {
+		for _, taskContainer := range task.Containers {
+			if taskContainer == container {
+				return task.Family
+			}
+		}
+	}
+	return ""
+}
+
+// isImageProtectedByFamily returns true if a task of any family used the image within the family protection
+// window
+func (imageManager *dockerImageManager) isImageProtectedByFamily(imageState *image.ImageState) bool {
+	if imageManager.familyProtectionWindow <= 0 {
+		return false
+	}
+	return imageState.UsedByFamilySince(time.Now().Add(-imageManager.familyProtectionWindow))
+}
+
+// isImageSettlingAfterStartup returns true if the agent started less than the startup settle period ago and
+// has not seen the image in use since. The last used times loaded from the state may be stale after a restart
+// of the agent or a reboot of the instance, and would make all the images look old enough to be removed at once.
+func (imageManager *dockerImageManager) isImageSettlingAfterStartup(imageState *image.ImageState) bool {
+	if imageManager.startupSettlePeriod <= 0 || time.Since(imageManager.startedAt) >= imageManager.startupSettlePeriod {
+		return false
+	}
+	return imageState.LastUsedAt.Before(imageManager.startedAt)
+}
+
+// isImagePinnedByTask returns true if a container of a task which has not stopped yet uses the image. The
+// container references of an image are removed along with the containers, which can happen before the task
+// is stopped, e.g. when an exited container is restarted.
+func (imageManager *dockerImageManager) isImagePinnedByTask(imageState *image.ImageState) bool {
+	for _, task := range imageManager.state.AllTasks() {
+		if task.GetKnownStatus().Terminal() {
+			continue
+		}
+		for _, container := range task.Containers {
+			if imageStateHasContainerImage(imageState, container) {
+				seelog.Debugf("Image [%s] is pinned by task %s", imageState.String(), task.Arn)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func imageStateHasContainerImage(imageState *image.ImageState, container *apicontainer.Container) bool {
+	if container.ImageID != "" && container.ImageID == imageState.Image.ImageID {
+		return true
+	}
+	return imageState.HasImageName(container.Image)
+}
+
+func (imageManager *dockerImageManager) isImageOldEnough(imageState *image.ImageState, minimumAge time.Duration) bool {
 	ageOfImage := time.Since(imageState.PulledAt)
-	return ageOfImage > imageManager.minimumAgeBeforeDeletion
+	return ageOfImage > minimumAge
+}
+
+// pressureMinimumAge returns the minimum age of the images removed under pressure, to bring the number of
+// tracked images under the cap or the disk usage under the escalation thresholds
+func (imageManager *dockerImageManager) pressureMinimumAge() time.Duration {
+	if imageManager.minimumAgeBeforeDeletionSoft > 0 {
+		return imageManager.minimumAgeBeforeDeletionSoft
+	}
+	return imageManager.minimumAgeBeforeDeletion
 }
 
 // TODO: change image createdTime to image lastUsedTime when docker support it in the future
@@ -320,13 +716,28 @@ func (imageStates ImageStatesForDeletion) Swap(i, j int) {
 }
 
 func (imageManager *dockerImageManager) getLeastRecentlyUsedImage(imagesForDeletion []*image.ImageState) *image.ImageState {
-	var candidateImages ImageStatesForDeletion
-	for _, imageState := range imagesForDeletion {
-		candidateImages = append(candidateImages, imageState)
-	}
+	// return only the top LRU image for deletion
+	return imageManager.getLeastRecentlyUsedImages(imagesForDeletion)[0]
+}
+
+// getLeastRecentlyUsedImages returns a copy of the images ordered from the least recently used one
+func (imageManager *dockerImageManager) getLeastRecentlyUsedImages(imageStates []*image.ImageState) []*image.ImageState {
+	candidateImages := make(ImageStatesForDeletion, len(imageStates))
+	copy(candidateImages, imageStates)
 	// sort images in the order of last used times
+	sort.Stable(candidateImages)
+	return candidateImages
+}
+
+// getLargestImage returns the image taking the most disk space, or the least recently used one among the
+// largest images
+func (imageManager *dockerImageManager) getLargestImage(imagesForDeletion []*image.ImageState) *image.ImageState {
+	candidateImages := make(ImageStatesForDeletion, len(imagesForDeletion))
+	copy(candidateImages, imagesForDeletion)
 	sort.Sort(candidateImages)
-	// return only the top LRU image for deletion
+	sort.SliceStable(candidateImages, func(i, j int) bool {
+		return candidateImages[i].Image.Size > candidateImages[j].Image.Size
+	})
 	return candidateImages[0]
 }
 
@@ -357,7 +768,12 @@ func (imageManager *dockerImageManager) performPeriodicImageCleanup(ctx context.
 	for {
 		select {
 		case <-imageManager.imageCleanupTicker.C:
+			if imageManager.isInPullCooldown() {
+				continue
+			}
 			go imageManager.removeUnusedImages(ctx)
+		case <-imageManager.reclaimRequested:
+			go imageManager.reclaimTrackedImageStates(ctx)
 		case <-ctx.Done():
 			imageManager.imageCleanupTicker.Stop()
 			return
@@ -365,7 +781,47 @@ func (imageManager *dockerImageManager) performPeriodicImageCleanup(ctx context.
 	}
 }
 
-func (imageManager *dockerImageManager) removeUnusedImages(ctx context.Context) {
+// recordImagePull records when an image was last pulled for a container
+func (imageManager *dockerImageManager) recordImagePull(pulledAt time.Time) {
+	imageManager.lastImagePullLock.Lock()
+	defer imageManager.lastImagePullLock.Unlock()
+	if pulledAt.After(imageManager.lastImagePullAt) {
+		imageManager.lastImagePullAt = pulledAt
+	}
+}
+
+// isInPullCooldown returns true if an image was pulled too recently for periodic cleanup to run
+func (imageManager *dockerImageManager) isInPullCooldown() bool {
+	if imageManager.pullCooldown <= 0 {
+		return false
+	}
+	imageManager.lastImagePullLock.RLock()
+	lastImagePullAt := imageManager.lastImagePullAt
+	imageManager.lastImagePullLock.RUnlock()
+	if lastImagePullAt.IsZero() {
+		return false
+	}
+	sinceLastPull := time.Since(lastImagePullAt)
+	if sinceLastPull >= imageManager.pullCooldown {
+		return false
+	}
+	seelog.Infof("Skipping image cleanup cycle as an image was pulled %s ago, within the cooldown of %s",
+		sinceLastPull, imageManager.pullCooldown)
+	return true
+}
+
+// imageCleanupNow returns the current time, against which the cleanup window is checked
+var imageCleanupNow = time.Now
+
+// removeUnusedImages runs an image cleanup cycle and returns its statistics. Cleanup cycles are
+// serialized with each other and with image pulls.
+func (imageManager *dockerImageManager) removeUnusedImages(ctx context.Context) image.CleanupCycleStats {
+	return imageManager.removeUnusedImagesInCycle(ctx, uuid.New())
+}
+
+// removeUnusedImagesInCycle runs the image cleanup cycle of the given ID and returns its statistics
+func (imageManager *dockerImageManager) removeUnusedImagesInCycle(ctx context.Context, cycleID string) image.CleanupCycleStats {
+	defer metrics.MetricsEngineGlobal.RecordTaskEngineMetric("IMAGE_CLEANUP")()
 	seelog.Debug("Attempting to obtain ImagePullDeleteLock for removing images")
 	ImagePullDeleteLock.Lock()
 	seelog.Debug("Obtained ImagePullDeleteLock for removing images")
@@ -375,8 +831,22 @@ func (imageManager *dockerImageManager) removeUnusedImages(ctx context.Context)
 	imageManager.updateLock.Lock()
 	defer imageManager.updateLock.Unlock()
 
+	imageManager.cleanupStats = image.NewCleanupCycleStats(cycleID)
+	imageManager.removePendingImageStateData()
+
 	var numECSImagesDeleted int
-	imageManager.imageStatesConsideredForDeletion = imageManager.imagesConsiderForDeletion(imageManager.getAllImageStates())
+	allImageStates := imageManager.getAllImageStates()
+	imageManager.cleanupStats.RecordEvaluated(len(allImageStates))
+	imageManager.imageStatesConsideredForDeletion = imageManager.imagesConsiderForDeletion(allImageStates)
+	imageManager.loadDaemonContainerImageIDs(ctx)
+	imageManager.forgetNamesOfUntaggedImages(ctx)
+	imageManager.recordIneligibleImages(imageManager.minimumAgeBeforeDeletion)
+
+	if imageManager.cleanupWindow != nil && !imageManager.cleanupWindow.Contains(imageCleanupNow()) {
+		imageManager.logImagesOutsideCleanupWindow()
+		imageManager.daemonContainerImageIDs = nil
+		return imageManager.recordCleanupStats()
+	}
 
 	for i := 0; i < imageManager.numImagesToDelete; i++ {
 		err := imageManager.removeLeastRecentlyUsedImage(ctx)
@@ -386,6 +856,12 @@ func (imageManager *dockerImageManager) removeUnusedImages(ctx context.Context)
 			break
 		}
 	}
+	if imageManager.escalationDiskThreshold > 0 || imageManager.escalationInodeThreshold > 0 {
+		imageManager.escalateImageCleanup(ctx)
+	}
+	if imageManager.maxTotalImageDiskBytes > 0 {
+		imageManager.enforceImageDiskBudget(ctx)
+	}
 	if imageManager.deleteNonECSImagesEnabled.Enabled() {
 		// remove nonecs containers
 		imageManager.removeNonECSContainers(ctx)
@@ -393,6 +869,497 @@ func (imageManager *dockerImageManager) removeUnusedImages(ctx context.Context)
 		var nonECSImagesNumToDelete = imageManager.numImagesToDelete - numECSImagesDeleted
 		imageManager.removeNonECSImages(ctx, nonECSImagesNumToDelete)
 	}
+	imageManager.daemonContainerImageIDs = nil
+	return imageManager.recordCleanupStats()
+}
+
+// logImagesOutsideCleanupWindow logs the images that the cleanup cycle would remove, in order, if it was within
+// the cleanup window, and records them as skipped
+func (imageManager *dockerImageManager) logImagesOutsideCleanupWindow() {
+	candidateImages := ImageStatesForDeletion(imageManager.getCandidateImagesForDeletion(imageManager.minimumAgeBeforeDeletion))
+	sort.Sort(candidateImages)
+	if imageManager.prioritizeSize.Enabled() {
+		sort.SliceStable(candidateImages, func(i, j int) bool {
+			return candidateImages[i].Image.Size > candidateImages[j].Image.Size
+		})
+	}
+	if len(candidateImages) > imageManager.numImagesToDelete {
+		candidateImages = candidateImages[:imageManager.numImagesToDelete]
+	}
+	seelog.Infof("Image cleanup cycle is outside of the cleanup window %s, %d images would be removed",
+		imageManager.cleanupWindow, len(candidateImages))
+	for _, imageState := range candidateImages {
+		seelog.Infof("Image would be removed within the cleanup window: [%s]", imageState.String())
+		imageManager.cleanupStats.RecordSkipped(image.CleanupSkipReasonOutsideWindow)
+	}
+}
+
+// escalateImageCleanup removes more images while the disk usage stays above escalationDiskThreshold, or the
+// inode usage above escalationInodeThreshold. The minimum age of the images removed starts from the soft minimum
+// age and is halved, down to escalationMinimumAge, each time no image is old enough. Images younger than
+// escalationMinimumAge are never removed.
+func (imageManager *dockerImageManager) escalateImageCleanup(ctx context.Context) {
+	floor := imageManager.escalationMinimumAge
+	if floor <= 0 {
+		floor = config.DefaultImageCleanupEscalationMinimumAge
+	}
+	minimumAge := imageManager.pressureMinimumAge()
+	if minimumAge < floor {
+		minimumAge = floor
+	}
+	for {
+		underPressure, usage, err := imageManager.isUnderDiskPressure()
+		if err != nil {
+			logger.Warn("Unable to get the disk usage, image cleanup will not be escalated", logger.Fields{
+				field.Error: err,
+			})
+			return
+		}
+		if !underPressure {
+			return
+		}
+		candidateImageStatesForDeletion := imageManager.getCandidateImagesForDeletion(minimumAge)
+		stoppedTaskContainers := imageManager.getStoppedTaskImagesForDeletion(minimumAge)
+		for imageState := range stoppedTaskContainers {
+			candidateImageStatesForDeletion = append(candidateImageStatesForDeletion, imageState)
+		}
+		if len(candidateImageStatesForDeletion) > 0 {
+			leastRecentlyUsedImage := imageManager.getLeastRecentlyUsedImage(candidateImageStatesForDeletion)
+			if containers, ok := stoppedTaskContainers[leastRecentlyUsedImage]; ok &&
+				!imageManager.removeStoppedTaskContainers(ctx, leastRecentlyUsedImage, containers) {
+				continue
+			}
+			imageManager.removeImage(ctx, leastRecentlyUsedImage)
+			continue
+		}
+		if minimumAge <= floor {
+			logger.Warn("Unable to bring the disk usage under the threshold as the remaining images are in use or too recent", usage, logger.Fields{
+				"minimumAge": minimumAge.String(),
+			})
+			return
+		}
+		minimumAge /= 2
+		if minimumAge < floor {
+			minimumAge = floor
+		}
+		logger.Info("Escalating image cleanup as the disk usage is above the threshold", usage, logger.Fields{
+			"minimumAge": minimumAge.String(),
+		})
+	}
+}
+
+// isUnderDiskPressure returns true if the disk usage of escalationDiskPath is above escalationDiskThreshold, or
+// its inode usage above escalationInodeThreshold, along with the usages checked, to be logged
+func (imageManager *dockerImageManager) isUnderDiskPressure() (bool, logger.Fields, error) {
+	underPressure := false
+	usage := logger.Fields{}
+	if imageManager.escalationDiskThreshold > 0 {
+		diskUsage, err := diskUsagePercent(imageManager.escalationDiskPath)
+		if err != nil {
+			return false, nil, err
+		}
+		usage["diskUsagePercent"] = diskUsage
+		usage["diskThreshold"] = imageManager.escalationDiskThreshold
+		underPressure = diskUsage > float64(imageManager.escalationDiskThreshold)
+	}
+	if imageManager.escalationInodeThreshold > 0 {
+		inodeUsage, err := inodeUsagePercent(imageManager.escalationDiskPath)
+		if err != nil {
+			return false, nil, err
+		}
+		usage["inodeUsagePercent"] = inodeUsage
+		usage["inodeThreshold"] = imageManager.escalationInodeThreshold
+		underPressure = underPressure || inodeUsage > float64(imageManager.escalationInodeThreshold)
+	}
+	return underPressure, usage, nil
+}
+
+// enforceImageDiskBudget removes the least recently used eligible images while the tracked images take more disk
+// space than maxTotalImageDiskBytes
+func (imageManager *dockerImageManager) enforceImageDiskBudget(ctx context.Context) {
+	totalBytes := imageManager.getTotalImageBytes()
+	for totalBytes > imageManager.maxTotalImageDiskBytes {
+		candidateImageStatesForDeletion := imageManager.getCandidateImagesForDeletion(imageManager.minimumAgeBeforeDeletion)
+		if len(candidateImageStatesForDeletion) == 0 {
+			logger.Warn("Unable to bring the disk space taken by images under the budget as the remaining images are in use or too recent", logger.Fields{
+				"totalImageBytes":        totalBytes,
+				"maxTotalImageDiskBytes": imageManager.maxTotalImageDiskBytes,
+			})
+			return
+		}
+		seelog.Infof("Images take %d bytes, above the budget of %d bytes; removing more images",
+			totalBytes, imageManager.maxTotalImageDiskBytes)
+		imageManager.removeImage(ctx, imageManager.getLeastRecentlyUsedImage(candidateImageStatesForDeletion))
+		totalBytes = imageManager.getTotalImageBytes()
+	}
+}
+
+// getTotalImageBytes returns the disk space taken by the tracked images
+func (imageManager *dockerImageManager) getTotalImageBytes() int64 {
+	var totalBytes int64
+	for _, imageState := range imageManager.getAllImageStates() {
+		totalBytes += imageState.Image.Size
+	}
+	return totalBytes
+}
+
+// getStoppedTaskImagesForDeletion returns the images considered for deletion which are older than minimumAge
+// and only used by the containers of tasks stopped for longer than escalationStoppedTaskGrace, along with these
+// containers
+func (imageManager *dockerImageManager) getStoppedTaskImagesForDeletion(minimumAge time.Duration) map[*image.ImageState][]*apicontainer.DockerContainer {
+	if imageManager.escalationStoppedTaskGrace <= 0 {
+		return nil
+	}
+	imagesForDeletion := make(map[*image.ImageState][]*apicontainer.DockerContainer)
+	for _, imageState := range imageManager.imageStatesConsideredForDeletion {
+		if imageManager.isAgentImage(imageState) || !imageManager.isImageOldEnough(imageState, minimumAge) ||
+			imageManager.isImageProtectedByFamily(imageState) || imageManager.isImageSettlingAfterStartup(imageState) {
+			continue
+		}
+		if containers, ok := imageManager.stoppedTaskContainersUsingImage(imageState); ok {
+			seelog.Infof("Candidate image of stopped tasks for deletion: [%s]", imageState.String())
+			imagesForDeletion[imageState] = containers
+		}
+	}
+	return imagesForDeletion
+}
+
+// stoppedTaskContainersUsingImage returns the containers using the image if they all belong to tasks stopped for
+// longer than escalationStoppedTaskGrace. It returns false if any other container, tracked by the agent or
+// known to the daemon, uses the image, or if no container uses it.
+func (imageManager *dockerImageManager) stoppedTaskContainersUsingImage(imageState *image.ImageState) ([]*apicontainer.DockerContainer, bool) {
+	var containers []*apicontainer.DockerContainer
+	for _, task := range imageManager.state.AllTasks() {
+		var taskContainers []*apicontainer.Container
+		for _, container := range task.Containers {
+			if imageStateHasContainerImage(imageState, container) {
+				taskContainers = append(taskContainers, container)
+			}
+		}
+		if len(taskContainers) == 0 {
+			continue
+		}
+		stoppedAt := task.GetExecutionStoppedAt()
+		if !task.GetKnownStatus().Terminal() || stoppedAt.IsZero() ||
+			time.Since(stoppedAt) < imageManager.escalationStoppedTaskGrace {
+			return nil, false
+		}
+		dockerContainers, _ := imageManager.state.ContainerMapByArn(task.Arn)
+		for _, container := range taskContainers {
+			if dockerContainer, ok := dockerContainers[container.Name]; ok && dockerContainer.DockerID != "" {
+				containers = append(containers, dockerContainer)
+			}
+		}
+	}
+	if len(containers) == 0 {
+		return nil, false
+	}
+	dockerIDs := make(map[string]struct{}, len(containers))
+	for _, dockerContainer := range containers {
+		dockerIDs[dockerContainer.DockerID] = struct{}{}
+	}
+	for _, container := range imageState.GetContainers() {
+		if _, ok := dockerIDs[container.GetRuntimeID()]; !ok {
+			return nil, false
+		}
+	}
+	for _, containerID := range imageManager.daemonContainerImageIDs[imageState.Image.ImageID] {
+		if _, ok := dockerIDs[containerID]; !ok {
+			return nil, false
+		}
+	}
+	return containers, true
+}
+
+// removeStoppedTaskContainers removes the containers of stopped tasks using the image so that the image can be
+// removed, and returns false if any of them could not be removed. Such an image is no longer considered for
+// deletion in this cleanup cycle.
+func (imageManager *dockerImageManager) removeStoppedTaskContainers(ctx context.Context, imageState *image.ImageState, containers []*apicontainer.DockerContainer) bool {
+	for _, dockerContainer := range containers {
+		logger.Info("Removing the container of a stopped task to remove its image as the disk usage is above the threshold", logger.Fields{
+			field.Container: dockerContainer.Container.Name,
+			field.RuntimeID: dockerContainer.DockerID,
+			field.Image:     imageState.Image.ImageID,
+		})
+		err := imageManager.client.RemoveContainer(ctx, dockerContainer.DockerID, dockerclient.RemoveContainerTimeout)
+		if err != nil && !strings.Contains(strings.ToLower(err.Error()), "no such container") {
+			logger.Warn("Unable to remove the container of a stopped task, its image will not be removed", logger.Fields{
+				field.Container: dockerContainer.Container.Name,
+				field.RuntimeID: dockerContainer.DockerID,
+				field.Error:     err,
+			})
+			delete(imageManager.imageStatesConsideredForDeletion, imageState.Image.ImageID)
+			imageManager.cleanupStats.RecordSkipped(image.CleanupSkipReasonRemoveFailed)
+			imageManager.cleanupStats.RecordError("unable to remove container %s: %v", dockerContainer.DockerID, err)
+			return false
+		}
+		imageManager.forgetDaemonContainer(imageState.Image.ImageID, dockerContainer.DockerID)
+		if err := imageState.RemoveContainerReference(dockerContainer.Container); err != nil {
+			seelog.Debugf("Image [%s] has no reference to container %s: %v", imageState.String(),
+				dockerContainer.Container.Name, err)
+		}
+	}
+	return true
+}
+
+// forgetDaemonContainer removes a container removed during the cleanup cycle from the containers known to the
+// daemon using the image
+func (imageManager *dockerImageManager) forgetDaemonContainer(imageID string, containerID string) {
+	containerIDs := imageManager.daemonContainerImageIDs[imageID]
+	for i, id := range containerIDs {
+		if id == containerID {
+			containerIDs = append(containerIDs[:i], containerIDs[i+1:]...)
+			break
+		}
+	}
+	if len(containerIDs) == 0 {
+		delete(imageManager.daemonContainerImageIDs, imageID)
+		return
+	}
+	imageManager.daemonContainerImageIDs[imageID] = containerIDs
+}
+
+// reclaimTrackedImageStates runs an image cleanup cycle removing the least recently used eligible images until
+// no more images than the cap are tracked, and returns its statistics. Cleanup cycles are serialized with each
+// other and with image pulls.
+func (imageManager *dockerImageManager) reclaimTrackedImageStates(ctx context.Context) image.CleanupCycleStats {
+	defer metrics.MetricsEngineGlobal.RecordTaskEngineMetric("IMAGE_CLEANUP")()
+	ImagePullDeleteLock.Lock()
+	defer ImagePullDeleteLock.Unlock()
+
+	imageManager.updateLock.Lock()
+	defer imageManager.updateLock.Unlock()
+
+	imageManager.cleanupStats = image.NewCleanupCycleStats(uuid.New())
+	imageManager.removePendingImageStateData()
+
+	allImageStates := imageManager.getAllImageStates()
+	seelog.Infof("Reclaiming images as %d images are tracked, more than the cap of %d",
+		len(allImageStates), imageManager.maxTrackedImageStates)
+	imageManager.cleanupStats.RecordEvaluated(len(allImageStates))
+	imageManager.imageStatesConsideredForDeletion = imageManager.imagesConsiderForDeletion(allImageStates)
+	imageManager.loadDaemonContainerImageIDs(ctx)
+	imageManager.forgetNamesOfUntaggedImages(ctx)
+	minimumAge := imageManager.pressureMinimumAge()
+	imageManager.recordIneligibleImages(minimumAge)
+
+	for len(imageManager.getAllImageStates()) > imageManager.maxTrackedImageStates {
+		candidateImageStatesForDeletion := imageManager.getCandidateImagesForDeletion(minimumAge)
+		if len(candidateImageStatesForDeletion) == 0 {
+			logger.Warn("Unable to bring the number of tracked images under the cap as the remaining images are in use or too recent", logger.Fields{
+				"trackedImages":    len(imageManager.getAllImageStates()),
+				"maxTrackedImages": imageManager.maxTrackedImageStates,
+			})
+			break
+		}
+		imageManager.removeImage(ctx, imageManager.getLeastRecentlyUsedImage(candidateImageStatesForDeletion))
+	}
+	imageManager.daemonContainerImageIDs = nil
+	return imageManager.recordCleanupStats()
+}
+
+// recordIneligibleImages records in the cleanup statistics the images considered for deletion that
+// are still in use or younger than minimumAge.
+func (imageManager *dockerImageManager) recordIneligibleImages(minimumAge time.Duration) {
+	for _, imageState := range imageManager.imageStatesConsideredForDeletion {
+		if imageManager.isImageInUse(imageState) {
+			imageManager.cleanupStats.RecordSkipped(image.CleanupSkipReasonInUse)
+		} else if !imageManager.isImageOldEnough(imageState, minimumAge) {
+			imageManager.cleanupStats.RecordSkipped(image.CleanupSkipReasonTooRecent)
+		} else if imageManager.isImageProtectedByFamily(imageState) {
+			imageManager.cleanupStats.RecordSkipped(image.CleanupSkipReasonFamilyProtected)
+		} else if imageManager.isImageSettlingAfterStartup(imageState) {
+			imageManager.cleanupStats.RecordSkipped(image.CleanupSkipReasonStartupSettling)
+		}
+	}
+}
+
+// recordCleanupStats completes the statistics of the cleanup cycle in progress, adds them to the
+// bounded history of cleanup cycles and returns them.
+func (imageManager *dockerImageManager) recordCleanupStats() image.CleanupCycleStats {
+	stats := imageManager.cleanupStats
+	imageManager.cleanupStats = nil
+	stats.Duration = time.Since(stats.StartedAt)
+	seelog.Infof("Image cleanup cycle completed in %s: evaluated %d images, removed %d images, reclaimed %d bytes, skipped %v",
+		stats.Duration, stats.CandidatesEvaluated, stats.ImagesRemoved, stats.BytesReclaimed, stats.SkipReasons)
+	metrics.MetricsEngineGlobal.RecordImageCleanupCycle(stats.Duration, stats.CandidatesEvaluated, stats.ImagesRemoved,
+		stats.BytesReclaimed, stats.SkipReasons)
+
+	historySize := imageManager.cleanupStatsHistorySize
+	if historySize <= 0 {
+		historySize = config.DefaultImageCleanupStatsHistorySize
+	}
+	imageManager.cleanupStatsLock.Lock()
+	defer imageManager.cleanupStatsLock.Unlock()
+	imageManager.cleanupStatsHistory = append(imageManager.cleanupStatsHistory, *stats)
+	if len(imageManager.cleanupStatsHistory) > historySize {
+		imageManager.cleanupStatsHistory = imageManager.cleanupStatsHistory[len(imageManager.cleanupStatsHistory)-historySize:]
+	}
+	return *stats
+}
+
+// RunImageCleanup starts an image cleanup cycle right away in the background and returns its ID, under which
+// its statistics are added to the cleanup history once it completes. The cycle is serialized with the periodic
+// ones and runs until it completes or ctx is cancelled. Only one cycle run on demand is in progress at a time.
+func (imageManager *dockerImageManager) RunImageCleanup(ctx context.Context) (string, error) {
+	if imageManager.imagePullBehavior == config.ImagePullPreferCachedBehavior {
+		return "", errors.New("image cleanup is disabled as the image pull behavior is prefer-cached")
+	}
+	imageManager.cleanupStatsLock.Lock()
+	defer imageManager.cleanupStatsLock.Unlock()
+	if imageManager.onDemandCleanupCycleID != "" {
+		return "", fmt.Errorf("image cleanup cycle %s run on demand is still in progress",
+			imageManager.onDemandCleanupCycleID)
+	}
+	cycleID := uuid.New()
+	imageManager.onDemandCleanupCycleID = cycleID
+	seelog.Infof("Running image cleanup cycle %s on demand", cycleID)
+	go func() {
+		imageManager.removeUnusedImagesInCycle(ctx, cycleID)
+		imageManager.cleanupStatsLock.Lock()
+		defer imageManager.cleanupStatsLock.Unlock()
+		imageManager.onDemandCleanupCycleID = ""
+	}()
+	return cycleID, nil
+}
+
+// GetImageCleanupHistory returns the statistics of the most recent image cleanup cycles, oldest first
+func (imageManager *dockerImageManager) GetImageCleanupHistory() []image.CleanupCycleStats {
+	imageManager.cleanupStatsLock.RLock()
+	defer imageManager.cleanupStatsLock.RUnlock()
+	history := make([]image.CleanupCycleStats, len(imageManager.cleanupStatsHistory))
+	copy(history, imageManager.cleanupStatsHistory)
+	return history
+}
+
+// GetImageCleanupEligibility returns how far the image of the given ID or name is from being removed by
+// image cleanup. It returns false if the image is not tracked.
+func (imageManager *dockerImageManager) GetImageCleanupEligibility(imageRef string) (image.CleanupEligibility, bool) {
+	imageManager.updateLock.RLock()
+	defer imageManager.updateLock.RUnlock()
+	imageState, ok := imageManager.getImageState(imageRef)
+	if !ok {
+		for _, candidate := range imageManager.getAllImageStates() {
+			if candidate.HasImageName(imageRef) {
+				imageState, ok = candidate, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return image.CleanupEligibility{}, false
+	}
+
+	for i, candidate := range imageManager.getLeastRecentlyUsedImages(imageManager.getAllImageStates()) {
+		if candidate == imageState {
+			return imageManager.getCleanupEligibility(imageState, i+1), true
+		}
+	}
+	return imageManager.getCleanupEligibility(imageState, 0), true
+}
+
+// GetImageCleanupOrder returns how far each tracked image is from being removed by image cleanup, in the order
+// cleanup considers them, from the least recently used one. It is computed from a single snapshot of the tracked
+// images taken under the lock of the image manager.
+func (imageManager *dockerImageManager) GetImageCleanupOrder() []image.CleanupEligibility {
+	imageManager.updateLock.RLock()
+	defer imageManager.updateLock.RUnlock()
+	imageStates := imageManager.getLeastRecentlyUsedImages(imageManager.getAllImageStates())
+	order := make([]image.CleanupEligibility, 0, len(imageStates))
+	for i, imageState := range imageStates {
+		order = append(order, imageManager.getCleanupEligibility(imageState, i+1))
+	}
+	return order
+}
+
+// getCleanupEligibility returns how far the image is from being removed by image cleanup given its position among
+// the tracked images ordered from the least recently used one
+func (imageManager *dockerImageManager) getCleanupEligibility(imageState *image.ImageState, lruPosition int) image.CleanupEligibility {
+	eligibility := image.CleanupEligibility{
+		ImageID:                 imageState.Image.ImageID,
+		Names:                   append([]string{}, imageState.Image.Names...),
+		LastUsedAt:              imageState.LastUsedAt,
+		HasAssociatedContainers: !imageState.HasNoAssociatedContainers(),
+		PinnedByTask:            imageManager.isImagePinnedByTask(imageState),
+		Excluded:                imageManager.isExcludedFromCleanup(imageState),
+		ProtectedByFamily:       imageManager.isImageProtectedByFamily(imageState),
+		Pinned:                  imageManager.isImagePinned(imageState.Image.ImageID, imageState.Image.Names),
+		StartupSettling:         imageManager.isImageSettlingAfterStartup(imageState),
+		LRUPosition:             lruPosition,
+	}
+	if remaining := imageManager.minimumAgeBeforeDeletion - time.Since(imageState.PulledAt); remaining > 0 {
+		eligibility.TimeUntilOldEnough = remaining
+	}
+	eligibility.Eligible = !eligibility.Excluded && imageManager.isImageOldEnough(imageState, imageManager.minimumAgeBeforeDeletion) &&
+		!eligibility.HasAssociatedContainers && !eligibility.PinnedByTask && !eligibility.ProtectedByFamily &&
+		!eligibility.Pinned && !eligibility.StartupSettling
+	return eligibility
+}
+
+// PinImage keeps the image of the given ID or name from being removed by image cleanup for the given
+// duration, e.g. until a deployment replacing it is confirmed healthy. Pinning an image again extends or
+// shortens its pin. It returns when the pin expires.
+func (imageManager *dockerImageManager) PinImage(imageRef string, ttl time.Duration) time.Time {
+	expiresAt := time.Now().Add(ttl)
+	imageManager.pinnedImagesLock.Lock()
+	defer imageManager.pinnedImagesLock.Unlock()
+	if imageManager.pinnedImages == nil {
+		imageManager.pinnedImages = make(map[string]time.Time)
+	}
+	imageManager.pinnedImages[imageRef] = expiresAt
+	logger.Info("Image pinned, image cleanup will skip it", logger.Fields{
+		field.Image: imageRef,
+		"expiresAt": expiresAt.Format(time.RFC3339),
+	})
+	return expiresAt
+}
+
+// UnpinImage lifts the pin of the image of the given ID or name. It returns false if the image is not pinned.
+func (imageManager *dockerImageManager) UnpinImage(imageRef string) bool {
+	imageManager.pinnedImagesLock.Lock()
+	defer imageManager.pinnedImagesLock.Unlock()
+	expiresAt, ok := imageManager.pinnedImages[imageRef]
+	delete(imageManager.pinnedImages, imageRef)
+	if !ok || !time.Now().Before(expiresAt) {
+		return false
+	}
+	logger.Info("Image unpinned", logger.Fields{
+		field.Image: imageRef,
+	})
+	return true
+}
+
+// GetPinnedImages returns the IDs and names of the pinned images mapped to when their pin expires. Expired
+// pins are dropped.
+func (imageManager *dockerImageManager) GetPinnedImages() map[string]time.Time {
+	now := time.Now()
+	imageManager.pinnedImagesLock.Lock()
+	defer imageManager.pinnedImagesLock.Unlock()
+	pinnedImages := make(map[string]time.Time, len(imageManager.pinnedImages))
+	for imageRef, expiresAt := range imageManager.pinnedImages {
+		if !now.Before(expiresAt) {
+			logger.Info("Image pin expired", logger.Fields{
+				field.Image: imageRef,
+			})
+			delete(imageManager.pinnedImages, imageRef)
+			continue
+		}
+		pinnedImages[imageRef] = expiresAt
+	}
+	return pinnedImages
+}
+
+// isImagePinned returns true if the image of the given ID, or any of its names, has a pin that has not expired
+func (imageManager *dockerImageManager) isImagePinned(imageID string, names []string) bool {
+	now := time.Now()
+	imageManager.pinnedImagesLock.RLock()
+	defer imageManager.pinnedImagesLock.RUnlock()
+	for _, imageRef := range append([]string{imageID}, names...) {
+		if expiresAt, ok := imageManager.pinnedImages[imageRef]; ok && now.Before(expiresAt) {
+			return true
+		}
+	}
+	return false
 }
 
 func (imageManager *dockerImageManager) removeNonECSContainers(ctx context.Context) {
@@ -463,6 +1430,7 @@ func (imageManager *dockerImageManager) removeNonECSImages(ctx context.Context,
 		return
 	}
 	nonECSImages := imageManager.getNonECSImages(ctx)
+	imageManager.cleanupStats.RecordEvaluated(len(nonECSImages))
 
 	// we want to sort images with size ascending
 	sort.Slice(nonECSImages, func(i, j int) bool {
@@ -471,33 +1439,45 @@ func (imageManager *dockerImageManager) removeNonECSImages(ctx context.Context,
 
 	// we will remove the remaining nonECSImages in each performPeriodicImageCleanup call()
 	var numImagesAlreadyDeleted = 0
-	for _, image := range nonECSImages {
+	for _, nonECSImage := range nonECSImages {
 		if numImagesAlreadyDeleted >= nonECSImagesNumToDelete {
 			break
 		}
 		// use current time - image creation time to determine if image is old enough to be deleted.
-		if !imageManager.nonECSImageOldEnough(image) {
+		if !imageManager.nonECSImageOldEnough(nonECSImage) {
+			imageManager.cleanupStats.RecordSkipped(image.CleanupSkipReasonTooRecent)
 			continue
 		}
-		if len(image.RepoTags) > 1 {
-			seelog.Debugf("Non-ECS image has more than one tag Image: %s (Tags: %s)", image.ImageID, image.RepoTags)
-			for _, tag := range image.RepoTags {
+		if len(nonECSImage.RepoTags) > 1 {
+			seelog.Debugf("Non-ECS image has more than one tag Image: %s (Tags: %s)", nonECSImage.ImageID, nonECSImage.RepoTags)
+			var numTagsRemoved int
+			for _, tag := range nonECSImage.RepoTags {
 				err := imageManager.client.RemoveImage(ctx, tag, dockerclient.RemoveImageTimeout)
 				if err != nil {
-					seelog.Errorf("Error removing RepoTag (ImageID: %s, Tag: %s) %v", image.ImageID, tag, err)
+					seelog.Errorf("Error removing RepoTag (ImageID: %s, Tag: %s) %v", nonECSImage.ImageID, tag, err)
+					imageManager.cleanupStats.RecordError("unable to remove image tag %s: %v", tag, err)
 				} else {
-					seelog.Infof("Image Tag Removed: %s (ImageID: %s)", tag, image.ImageID)
+					seelog.Infof("Image Tag Removed: %s (ImageID: %s)", tag, nonECSImage.ImageID)
 					numImagesAlreadyDeleted++
+					numTagsRemoved++
 				}
 			}
+			if numTagsRemoved == len(nonECSImage.RepoTags) {
+				imageManager.cleanupStats.RecordRemoved(nonECSImage.ImageID, nonECSImage.Size)
+			} else {
+				imageManager.cleanupStats.RecordSkipped(image.CleanupSkipReasonRemoveFailed)
+			}
 		} else {
-			seelog.Debugf("Removing non-ECS Image: %s (Tags: %s)", image.ImageID, image.RepoTags)
-			err := imageManager.client.RemoveImage(ctx, image.ImageID, dockerclient.RemoveImageTimeout)
+			seelog.Debugf("Removing non-ECS Image: %s (Tags: %s)", nonECSImage.ImageID, nonECSImage.RepoTags)
+			err := imageManager.client.RemoveImage(ctx, nonECSImage.ImageID, dockerclient.RemoveImageTimeout)
 			if err != nil {
-				seelog.Errorf("Error removing Image %s (Tags: %s) - %v", image.ImageID, image.RepoTags, err)
+				seelog.Errorf("Error removing Image %s (Tags: %s) - %v", nonECSImage.ImageID, nonECSImage.RepoTags, err)
+				imageManager.cleanupStats.RecordError("unable to remove image %s: %v", nonECSImage.ImageID, err)
+				imageManager.cleanupStats.RecordSkipped(image.CleanupSkipReasonRemoveFailed)
 			} else {
-				seelog.Infof("Image removed: %s (Tags: %s)", image.ImageID, image.RepoTags)
+				seelog.Infof("Image removed: %s (Tags: %s)", nonECSImage.ImageID, nonECSImage.RepoTags)
 				numImagesAlreadyDeleted++
+				imageManager.cleanupStats.RecordRemoved(nonECSImage.ImageID, nonECSImage.Size)
 			}
 		}
 	}
@@ -542,7 +1522,8 @@ func (imageManager *dockerImageManager) getNonECSImages(ctx context.Context) []I
 			continue
 		}
 		// check image TAG(s) is not excluded
-		if !anyIsInExclusionList(image.RepoTags, imageManager.imageCleanupExclusionList) {
+		if !anyIsInExclusionList(image.RepoTags, imageManager.imageCleanupExclusionList) &&
+			!imageManager.isImagePinned(image.ImageID, image.RepoTags) {
 			nonECSImages = append(nonECSImages, image)
 		}
 	}
@@ -592,6 +1573,10 @@ func (imageManager *dockerImageManager) imagesConsiderForDeletion(allImageStates
 		if imageManager.isExcludedFromCleanup(imageState) {
 			//imageState that we want to keep
 			seelog.Debugf("Image excluded from deletion: [%s]", imageState.String())
+			imageManager.cleanupStats.RecordSkipped(image.CleanupSkipReasonExcluded)
+		} else if imageManager.isImagePinned(imageState.Image.ImageID, imageState.Image.Names) {
+			seelog.Debugf("Image pinned, skipping deletion: [%s]", imageState.String())
+			imageManager.cleanupStats.RecordSkipped(image.CleanupSkipReasonPinned)
 		} else {
 			seelog.Debugf("Image going to be considered for deletion: [%s]", imageState.String())
 			imagesConsiderForDeletionMap[imageState.Image.ImageID] = imageState
@@ -601,6 +1586,9 @@ func (imageManager *dockerImageManager) imagesConsiderForDeletion(allImageStates
 }
 
 func (imageManager *dockerImageManager) isExcludedFromCleanup(imageState *image.ImageState) bool {
+	if imageManager.isAgentImage(imageState) {
+		return true
+	}
 	for _, ecsName := range imageState.Image.Names {
 		for _, exclusionName := range imageManager.imageCleanupExclusionList {
 			if ecsName == exclusionName {
@@ -611,6 +1599,17 @@ func (imageManager *dockerImageManager) isExcludedFromCleanup(imageState *image.
 	return false
 }
 
+// isAgentImage returns true if any name of the image belongs to the repository of an image the agent itself
+// depends on, such as the pause image
+func (imageManager *dockerImageManager) isAgentImage(imageState *image.ImageState) bool {
+	for _, name := range imageState.Image.Names {
+		if _, ok := imageManager.agentImageRepositories[imageRepository(name)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
 func (imageManager *dockerImageManager) removeLeastRecentlyUsedImage(ctx context.Context) error {
 	leastRecentlyUsedImage := imageManager.getUnusedImageForDeletion()
 	if leastRecentlyUsedImage == nil {
@@ -621,12 +1620,15 @@ func (imageManager *dockerImageManager) removeLeastRecentlyUsedImage(ctx context
 }
 
 func (imageManager *dockerImageManager) getUnusedImageForDeletion() *image.ImageState {
-	candidateImageStatesForDeletion := imageManager.getCandidateImagesForDeletion()
+	candidateImageStatesForDeletion := imageManager.getCandidateImagesForDeletion(imageManager.minimumAgeBeforeDeletion)
 	if len(candidateImageStatesForDeletion) < 1 {
 		seelog.Infof("No eligible images for deletion for this cleanup cycle")
 		return nil
 	}
 	seelog.Infof("Found %d eligible images for deletion", len(candidateImageStatesForDeletion))
+	if imageManager.prioritizeSize.Enabled() {
+		return imageManager.getLargestImage(candidateImageStatesForDeletion)
+	}
 	return imageManager.getLeastRecentlyUsedImage(candidateImageStatesForDeletion)
 }
 
@@ -636,15 +1638,33 @@ func (imageManager *dockerImageManager) removeImage(ctx context.Context, leastRe
 	copy(imageNames, leastRecentlyUsedImage.Image.Names)
 	if len(imageNames) == 0 {
 		// potentially untagged image of format <none>:<none>; remove by ID
-		imageManager.deleteImage(ctx, leastRecentlyUsedImage.Image.ImageID, leastRecentlyUsedImage)
+		if !imageManager.isImageInUseSinceSelection(leastRecentlyUsedImage) {
+			imageManager.deleteImage(ctx, leastRecentlyUsedImage.Image.ImageID, leastRecentlyUsedImage)
+		}
 	} else {
 		// Image has multiple tags/repos. Untag each name and delete the final reference to image
 		for _, imageName := range imageNames {
+			if imageManager.isImageInUseSinceSelection(leastRecentlyUsedImage) {
+				return
+			}
 			imageManager.deleteImage(ctx, imageName, leastRecentlyUsedImage)
 		}
 	}
 }
 
+// isImageInUseSinceSelection checks again whether the image is in use right before it is removed, as a task
+// using the image may have started since the image was selected for deletion. Such an image is no longer
+// considered for deletion in this cleanup cycle.
+func (imageManager *dockerImageManager) isImageInUseSinceSelection(imageState *image.ImageState) bool {
+	if !imageManager.isImageInUse(imageState) {
+		return false
+	}
+	seelog.Infof("Image [%s] is in use since it was selected for deletion, skipping its removal", imageState.String())
+	delete(imageManager.imageStatesConsideredForDeletion, imageState.Image.ImageID)
+	imageManager.cleanupStats.RecordSkipped(image.CleanupSkipReasonInUse)
+	return true
+}
+
 func (imageManager *dockerImageManager) deleteImage(ctx context.Context, imageID string, imageState *image.ImageState) {
 	if imageID == "" {
 		seelog.Errorf("Image ID to be deleted is null")
@@ -657,15 +1677,27 @@ func (imageManager *dockerImageManager) deleteImage(ctx context.Context, imageID
 			seelog.Errorf("Image already removed from the instance: %v", err)
 		} else {
 			seelog.Errorf("Error removing Image %v - %v", imageID, err)
+			if failures := imageState.RecordRemoveFailure(); failures == imageManager.removeFailureWarningThreshold {
+				logger.Warn("Image repeatedly failed to be removed; it may be held by a container the agent does not track", logger.Fields{
+					field.Image:           imageID,
+					"imageID":             imageState.Image.ImageID,
+					"consecutiveFailures": failures,
+					field.Error:           err,
+				})
+			}
 			delete(imageManager.imageStatesConsideredForDeletion, imageState.Image.ImageID)
+			imageManager.cleanupStats.RecordSkipped(image.CleanupSkipReasonRemoveFailed)
+			imageManager.cleanupStats.RecordError("unable to remove image %s: %v", imageID, err)
 			return
 		}
 	}
 	seelog.Infof("Image removed: %v", imageID)
+	imageState.ResetRemoveFailures()
 	imageState.RemoveImageName(imageID)
 	if len(imageState.Image.Names) == 0 {
 		seelog.Infof("Cleaning up all tracking information for image %s as it has zero references", imageID)
 		delete(imageManager.imageStatesConsideredForDeletion, imageState.Image.ImageID)
+		imageManager.cleanupStats.RecordRemoved(imageState.Image.ImageID, imageState.Image.Size)
 		imageManager.removeImageState(imageState)
 		imageManager.state.RemoveImageState(imageState)
 	}
@@ -675,10 +1707,8 @@ func (imageManager *dockerImageManager) GetImageStateFromImageName(containerImag
 	imageManager.updateLock.Lock()
 	defer imageManager.updateLock.Unlock()
 	for _, imageState := range imageManager.getAllImageStates() {
-		for _, imageName := range imageState.Image.Names {
-			if imageName == containerImageName {
-				return imageState, true
-			}
+		if imageState.HasImageName(containerImageName) {
+			return imageState, true
 		}
 	}
 	return nil, false
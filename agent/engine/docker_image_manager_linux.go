@@ -0,0 +1,49 @@
+//go:build linux
+// +build linux
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// diskUsagePercent returns the percentage of the space of the filesystem holding path that is in use. The space
+// reserved for the root user is counted as in use, as df does.
+var diskUsagePercent = func(path string) (float64, error) {
+	var fsStats syscall.Statfs_t
+	if err := syscall.Statfs(path, &fsStats); err != nil {
+		return 0, errors.Wrapf(err, "unable to get the disk usage of %s", path)
+	}
+	if fsStats.Blocks == 0 {
+		return 0, errors.Errorf("unable to get the disk usage of %s: the filesystem has no blocks", path)
+	}
+	return float64(fsStats.Blocks-fsStats.Bavail) / float64(fsStats.Blocks) * 100, nil
+}
+
+// inodeUsagePercent returns the percentage of the inodes of the filesystem holding path that are in use. Usage is
+// zero on filesystems which allocate inodes dynamically, and so report no inode.
+var inodeUsagePercent = func(path string) (float64, error) {
+	var fsStats syscall.Statfs_t
+	if err := syscall.Statfs(path, &fsStats); err != nil {
+		return 0, errors.Wrapf(err, "unable to get the inode usage of %s", path)
+	}
+	if fsStats.Files == 0 {
+		return 0, nil
+	}
+	return float64(fsStats.Files-fsStats.Ffree) / float64(fsStats.Files) * 100, nil
+}
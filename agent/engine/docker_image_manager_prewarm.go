@@ -0,0 +1,184 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/dockerclient"
+	"github.com/aws/amazon-ecs-agent/agent/engine/image"
+	"github.com/aws/amazon-ecs-agent/agent/logger"
+	"github.com/aws/amazon-ecs-agent/agent/logger/field"
+	"github.com/pkg/errors"
+)
+
+const (
+	// prewarmTarballExtension is the extension of the image tarballs loaded on startup
+	prewarmTarballExtension = ".tar"
+	// imageTarballManifestFile is the file listing the images of a tarball written by `docker save`
+	imageTarballManifestFile = "manifest.json"
+)
+
+// imageTarballManifestEntry is an image listed in the manifest of a tarball written by `docker save`
+type imageTarballManifestEntry struct {
+	RepoTags []string
+}
+
+// readImageTarballRepoTags returns the tags of the images in a tarball written by `docker save`
+func readImageTarballRepoTags(reader io.Reader) ([]string, error) {
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil, errors.Errorf("%s not found", imageTarballManifestFile)
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read tarball")
+		}
+		if filepath.Clean(header.Name) != imageTarballManifestFile {
+			continue
+		}
+		var manifest []imageTarballManifestEntry
+		if err := json.NewDecoder(tarReader).Decode(&manifest); err != nil {
+			return nil, errors.Wrapf(err, "unable to decode %s", imageTarballManifestFile)
+		}
+		var repoTags []string
+		for _, entry := range manifest {
+			repoTags = append(repoTags, entry.RepoTags...)
+		}
+		return repoTags, nil
+	}
+}
+
+// LoadPrewarmImages loads the image tarballs of the pre-warm directory and tracks the tagged images they
+// contain, so that tasks using them do not need to pull them. Tarballs which cannot be read or loaded are
+// skipped.
+func (imageManager *dockerImageManager) LoadPrewarmImages(ctx context.Context) {
+	if imageManager.prewarmTarballDir == "" {
+		return
+	}
+	files, err := ioutil.ReadDir(imageManager.prewarmTarballDir)
+	if err != nil {
+		logger.Warn("Unable to list image tarballs to pre-warm", logger.Fields{
+			"directory": imageManager.prewarmTarballDir,
+			field.Error: err,
+		})
+		return
+	}
+	for _, file := range files {
+		if ctx.Err() != nil {
+			return
+		}
+		if file.IsDir() || filepath.Ext(file.Name()) != prewarmTarballExtension {
+			continue
+		}
+		tarballPath := filepath.Join(imageManager.prewarmTarballDir, file.Name())
+		if err := imageManager.loadPrewarmImageTarball(ctx, tarballPath); err != nil {
+			logger.Warn("Skipping image tarball which could not be loaded", logger.Fields{
+				"tarball":   tarballPath,
+				field.Error: err,
+			})
+		}
+	}
+}
+
+// loadPrewarmImageTarball loads an image tarball, unless all of its images are present already, and tracks
+// its images
+func (imageManager *dockerImageManager) loadPrewarmImageTarball(ctx context.Context, tarballPath string) error {
+	tarball, err := os.Open(tarballPath)
+	if err != nil {
+		return err
+	}
+	defer tarball.Close()
+
+	repoTags, err := readImageTarballRepoTags(tarball)
+	if err != nil {
+		return err
+	}
+	if len(repoTags) == 0 {
+		// Untagged images can't be referenced by tasks
+		return errors.New("tarball does not contain any tagged image")
+	}
+
+	if !imageManager.allImagesPresent(repoTags) {
+		if _, err := tarball.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if err := imageManager.client.LoadImage(ctx, tarball, dockerclient.LoadImageTimeout); err != nil {
+			return errors.Wrap(err, "unable to load tarball")
+		}
+	}
+
+	for _, repoTag := range repoTags {
+		imageInspected, err := imageManager.client.InspectImage(repoTag)
+		if err != nil {
+			logger.Warn("Unable to inspect pre-warmed image", logger.Fields{
+				field.Image: repoTag,
+				field.Error: err,
+			})
+			continue
+		}
+		imageManager.addPrewarmImageState(repoTag, imageInspected.ID, imageInspected.Size)
+		logger.Info("Pre-warmed image from tarball", logger.Fields{
+			field.Image: repoTag,
+			"imageID":   imageInspected.ID,
+			"tarball":   tarballPath,
+		})
+	}
+	return nil
+}
+
+// allImagesPresent returns true if all the images are present on the host
+func (imageManager *dockerImageManager) allImagesPresent(imageNames []string) bool {
+	for _, imageName := range imageNames {
+		if _, err := imageManager.client.InspectImage(imageName); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// addPrewarmImageState tracks an image loaded from a tarball. Images which are not tracked yet are considered
+// pulled when they are loaded, so that they get the same protection from cleanup as freshly pulled images.
+func (imageManager *dockerImageManager) addPrewarmImageState(imageName string, imageID string, imageSize int64) {
+	imageManager.updateLock.Lock()
+	defer imageManager.updateLock.Unlock()
+	imageManager.removeExistingImageNameOfDifferentID(imageName, imageID)
+	if imageState, ok := imageManager.getImageState(imageID); ok {
+		if !imageState.HasImageName(imageName) {
+			imageState.AddImageName(imageName)
+			imageManager.saveImageStateData(imageState)
+		}
+		return
+	}
+	now := time.Now()
+	imageState := &image.ImageState{
+		Image: &image.Image{
+			ImageID: imageID,
+			Names:   []string{imageName},
+			Size:    imageSize,
+		},
+		PulledAt:      now,
+		LastUsedAt:    now,
+		PullSucceeded: true,
+	}
+	imageManager.addImageState(imageState)
+}
@@ -0,0 +1,142 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/data"
+	"github.com/aws/amazon-ecs-agent/agent/dockerclient"
+	mock_dockerapi "github.com/aws/amazon-ecs-agent/agent/dockerclient/dockerapi/mocks"
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+	"github.com/docker/docker/api/types"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeImageTarball writes a tarball with the layout written by `docker save`
+func writeImageTarball(t *testing.T, path string, manifest string) {
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	defer file.Close()
+	tarWriter := tar.NewWriter(file)
+	files := []struct {
+		name    string
+		content string
+	}{
+		{name: "layer/layer.tar", content: "layer"},
+		{name: "manifest.json", content: manifest},
+	}
+	for _, f := range files {
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{
+			Name: f.name,
+			Mode: 0644,
+			Size: int64(len(f.content)),
+		}))
+		_, err := tarWriter.Write([]byte(f.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tarWriter.Close())
+}
+
+func TestLoadPrewarmImages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+
+	cfg := defaultTestConfig()
+	cfg.ImagePrewarmTarballDir = t.TempDir()
+	imageManager := NewImageManager(cfg, client, dockerstate.NewTaskEngineState())
+	imageManager.SetDataClient(data.NewNoopClient())
+
+	writeImageTarball(t, filepath.Join(cfg.ImagePrewarmTarballDir, "base.tar"),
+		`[{"Config":"abc.json","RepoTags":["base:1","base:latest"],"Layers":["layer/layer.tar"]}]`)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(cfg.ImagePrewarmTarballDir, "corrupt.tar"),
+		[]byte("not a tarball"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(cfg.ImagePrewarmTarballDir, "README"),
+		[]byte("ignored"), 0644))
+
+	gomock.InOrder(
+		client.EXPECT().InspectImage("base:1").Return(nil, errors.New("no such image")),
+		client.EXPECT().LoadImage(gomock.Any(), gomock.Any(), dockerclient.LoadImageTimeout).DoAndReturn(
+			func(ctx context.Context, reader io.Reader, timeout time.Duration) error {
+				// The whole tarball is loaded
+				_, err := readImageTarballRepoTags(reader)
+				return err
+			}),
+		client.EXPECT().InspectImage("base:1").Return(&types.ImageInspect{ID: "sha256:abc", Size: 1024}, nil),
+		client.EXPECT().InspectImage("base:latest").Return(&types.ImageInspect{ID: "sha256:abc", Size: 1024}, nil),
+	)
+
+	loadedAt := time.Now()
+	imageManager.LoadPrewarmImages(context.TODO())
+
+	for _, imageName := range []string{"base:1", "base:latest"} {
+		imageState, ok := imageManager.GetImageStateFromImageName(imageName)
+		require.True(t, ok, "image %s is not tracked", imageName)
+		assert.Equal(t, "sha256:abc", imageState.Image.ImageID)
+		assert.Equal(t, int64(1024), imageState.Image.Size)
+		assert.False(t, imageState.PulledAt.Before(loadedAt))
+		assert.True(t, imageState.GetPullSucceeded())
+	}
+	assert.Len(t, imageManager.(*dockerImageManager).getAllImageStates(), 1)
+}
+
+func TestLoadPrewarmImagesSkipsLoadOfPresentImages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+
+	cfg := defaultTestConfig()
+	cfg.ImagePrewarmTarballDir = t.TempDir()
+	imageManager := NewImageManager(cfg, client, dockerstate.NewTaskEngineState())
+	imageManager.SetDataClient(data.NewNoopClient())
+
+	writeImageTarball(t, filepath.Join(cfg.ImagePrewarmTarballDir, "base.tar"),
+		`[{"Config":"abc.json","RepoTags":["base:1"],"Layers":["layer/layer.tar"]}]`)
+
+	client.EXPECT().InspectImage("base:1").Return(&types.ImageInspect{ID: "sha256:abc"}, nil).Times(2)
+	client.EXPECT().LoadImage(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	imageManager.LoadPrewarmImages(context.TODO())
+
+	_, ok := imageManager.GetImageStateFromImageName("base:1")
+	assert.True(t, ok)
+}
+
+func TestReadImageTarballRepoTagsWithoutManifest(t *testing.T) {
+	tarballPath := filepath.Join(t.TempDir(), "image.tar")
+	file, err := os.Create(tarballPath)
+	require.NoError(t, err)
+	require.NoError(t, tar.NewWriter(file).Close())
+	require.NoError(t, file.Close())
+
+	file, err = os.Open(tarballPath)
+	require.NoError(t, err)
+	defer file.Close()
+	_, err = readImageTarballRepoTags(file)
+	assert.Error(t, err)
+}
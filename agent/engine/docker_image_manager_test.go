@@ -17,14 +17,22 @@
 package engine
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
+	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
+	apitaskstatus "github.com/aws/amazon-ecs-agent/agent/api/task/status"
 	"github.com/aws/amazon-ecs-agent/agent/config"
 	"github.com/aws/amazon-ecs-agent/agent/data"
 	"github.com/aws/amazon-ecs-agent/agent/dockerclient"
@@ -33,9 +41,13 @@ import (
 	"github.com/aws/amazon-ecs-agent/agent/ec2"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
 	"github.com/aws/amazon-ecs-agent/agent/engine/image"
+	"github.com/aws/amazon-ecs-agent/agent/metrics"
 
+	"github.com/cihub/seelog"
 	"github.com/docker/docker/api/types"
 	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -68,6 +80,7 @@ func TestImagePullRemoveDeadlock(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
 
 	cfg := defaultTestConfig()
 	imageManager := NewImageManager(cfg, client, dockerstate.NewTaskEngineState())
@@ -157,6 +170,78 @@ func TestAddAndRemoveContainerToImageStateReferenceHappyPath(t *testing.T) {
 	}
 }
 
+func TestImageLastUsedMetadataWrittenOnUse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+
+	cfg := defaultTestConfig()
+	cfg.ImageLastUsedMetadataDir = filepath.Join(t.TempDir(), "image-metadata")
+	imageManager := NewImageManager(cfg, client, dockerstate.NewTaskEngineState())
+	imageManager.SetDataClient(data.NewNoopClient())
+
+	container := &apicontainer.Container{
+		Name:  "testContainer",
+		Image: "testContainerImage",
+	}
+	metadataFile := filepath.Join(cfg.ImageLastUsedMetadataDir, "sha256-qwerty.json")
+	readLastUsedAt := func() time.Time {
+		data, err := ioutil.ReadFile(metadataFile)
+		require.NoError(t, err)
+		var metadata struct {
+			Image      image.Image
+			LastUsedAt time.Time
+		}
+		require.NoError(t, json.Unmarshal(data, &metadata))
+		assert.Equal(t, "sha256:qwerty", metadata.Image.ImageID)
+		assert.Equal(t, []string{container.Image}, metadata.Image.Names)
+		return metadata.LastUsedAt
+	}
+
+	client.EXPECT().InspectImage(container.Image).Return(&types.ImageInspect{ID: "sha256:qwerty"}, nil)
+	require.NoError(t, imageManager.RecordContainerReference(container))
+	recordedAt := readLastUsedAt()
+	assert.False(t, recordedAt.IsZero())
+
+	require.NoError(t, imageManager.RemoveContainerReferenceFromImageState(container))
+	assert.False(t, readLastUsedAt().Before(recordedAt))
+
+	imageState, ok := imageManager.(*dockerImageManager).getImageState("sha256:qwerty")
+	require.True(t, ok)
+	imageManager.(*dockerImageManager).removeImageState(imageState)
+	_, err := os.Stat(metadataFile)
+	assert.True(t, os.IsNotExist(err), "expected the metadata file of the removed image to be removed")
+	// Only the metadata files of the images are written in the directory
+	files, err := ioutil.ReadDir(cfg.ImageLastUsedMetadataDir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestRecordContainerReferenceRecordsImagePlatform(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+
+	imageManager := NewImageManager(defaultTestConfig(), client, dockerstate.NewTaskEngineState())
+	imageManager.SetDataClient(data.NewNoopClient())
+
+	container := &apicontainer.Container{
+		Name:  "testContainer",
+		Image: "testContainerImage",
+	}
+	// the arm64 variant of the multi-platform image was pulled on an arm64 host
+	client.EXPECT().InspectImage(container.Image).Return(&types.ImageInspect{
+		ID:           "sha256:qwerty",
+		Os:           "linux",
+		Architecture: "arm64",
+	}, nil)
+	require.NoError(t, imageManager.RecordContainerReference(container))
+
+	imageState, ok := imageManager.GetImageStateFromImageName(container.Image)
+	require.True(t, ok)
+	assert.Equal(t, "linux/arm64", imageState.GetPlatform())
+}
+
 func TestRecordContainerReferenceInspectError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -572,7 +657,7 @@ func TestGetCandidateImagesForDeletionImageNoImageState(t *testing.T) {
 		imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
 	}
 
-	imageStates := imageManager.getCandidateImagesForDeletion()
+	imageStates := imageManager.getCandidateImagesForDeletion(imageManager.minimumAgeBeforeDeletion)
 
 	if imageStates != nil {
 		t.Error("Expected no image state to be returned for deletion")
@@ -599,12 +684,60 @@ func TestGetCandidateImagesForDeletionImageJustPulled(t *testing.T) {
 		PulledAt: time.Now(),
 	}
 	imageManager.addImageState(sourceImageState)
-	imageStates := imageManager.getCandidateImagesForDeletion()
+	imageStates := imageManager.getCandidateImagesForDeletion(imageManager.minimumAgeBeforeDeletion)
 	if len(imageStates) > 0 {
 		t.Error("Expected no image state to be returned for deletion")
 	}
 }
 
+func TestGetCandidateImagesForDeletionAgentImages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{}).AnyTimes()
+
+	cfg := defaultTestConfig()
+	cfg.PauseContainerImageName = "pause-name"
+	cfg.PauseContainerTag = "pause-tag"
+	imageManager := &dockerImageManager{
+		client:                   client,
+		state:                    dockerstate.NewTaskEngineState(),
+		minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
+		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
+		imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
+		agentImageRepositories:   buildAgentImageRepositories(cfg),
+	}
+	imageManager.SetDataClient(data.NewNoopClient())
+	imageStates := make(map[string]*image.ImageState)
+	for _, name := range []string{"pause-name:pause-tag", "pause-name:old-tag", "pause-name@sha256:abc",
+		config.CachedImageNameAgentContainer, "app:v1"} {
+		imageState := &image.ImageState{
+			Image:      &image.Image{ImageID: "sha256:" + name, Names: []string{name}},
+			PulledAt:   time.Now().AddDate(-1, 0, 0),
+			LastUsedAt: time.Now().AddDate(-1, 0, 0),
+		}
+		imageManager.addImageState(imageState)
+		imageManager.state.AddImageState(imageState)
+		imageStates[imageState.Image.ImageID] = imageState
+	}
+
+	// The agent images are never candidates, even when their last use is ancient
+	imageManager.imageStatesConsideredForDeletion = imageStates
+	candidates := imageManager.getCandidateImagesForDeletion(imageManager.minimumAgeBeforeDeletion)
+	require.Len(t, candidates, 1)
+	assert.Equal(t, "sha256:app:v1", candidates[0].Image.ImageID)
+
+	// Nor are they removed by a cleanup cycle
+	client.EXPECT().RemoveImage(gomock.Any(), "app:v1", dockerclient.RemoveImageTimeout).Return(nil)
+	stats := imageManager.removeUnusedImages(context.TODO())
+	assert.Equal(t, []string{"sha256:app:v1"}, stats.RemovedImageIDs)
+	eligibility, ok := imageManager.GetImageCleanupEligibility("pause-name:old-tag")
+	require.True(t, ok)
+	assert.True(t, eligibility.Excluded)
+	assert.False(t, eligibility.Eligible)
+}
+
 func TestGetCandidateImagesForDeletionImageHasContainerReference(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -640,7 +773,7 @@ func TestGetCandidateImagesForDeletionImageHasContainerReference(t *testing.T) {
 	if err != nil {
 		t.Error("Error in adding container to an existing image state")
 	}
-	imageStates := imageManager.getCandidateImagesForDeletion()
+	imageStates := imageManager.getCandidateImagesForDeletion(imageManager.minimumAgeBeforeDeletion)
 	if len(imageStates) > 0 {
 		t.Error("Expected no image state to be returned for deletion")
 	}
@@ -695,12 +828,176 @@ func TestGetCandidateImagesForDeletionImageHasMoreContainerReferences(t *testing
 	if err != nil {
 		t.Error("Error removing container reference from image state")
 	}
-	imageStates := imageManager.getCandidateImagesForDeletion()
+	imageStates := imageManager.getCandidateImagesForDeletion(imageManager.minimumAgeBeforeDeletion)
 	if len(imageStates) > 0 {
 		t.Error("Expected no image state to be returned for deletion")
 	}
 }
 
+func TestGetCandidateImagesForDeletionFamilyProtection(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		name             string
+		protectionWindow time.Duration
+		familyLastUsedAt map[string]time.Time
+		expectCandidate  bool
+	}{
+		{
+			name:             "no family history",
+			protectionWindow: 7 * 24 * time.Hour,
+			expectCandidate:  true,
+		},
+		{
+			name:             "family seen within the window",
+			protectionWindow: 7 * 24 * time.Hour,
+			familyLastUsedAt: map[string]time.Time{"web": now.Add(-24 * time.Hour)},
+			expectCandidate:  false,
+		},
+		{
+			name:             "family seen before the window",
+			protectionWindow: 7 * 24 * time.Hour,
+			familyLastUsedAt: map[string]time.Time{"web": now.AddDate(0, 0, -8)},
+			expectCandidate:  true,
+		},
+		{
+			name:             "one of several families seen within the window",
+			protectionWindow: 7 * 24 * time.Hour,
+			familyLastUsedAt: map[string]time.Time{
+				"web":   now.AddDate(0, -1, 0),
+				"batch": now.Add(-time.Hour),
+			},
+			expectCandidate: false,
+		},
+		{
+			name:             "protection disabled",
+			familyLastUsedAt: map[string]time.Time{"web": now.Add(-time.Hour)},
+			expectCandidate:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			imageManager := &dockerImageManager{
+				state:                    dockerstate.NewTaskEngineState(),
+				minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
+				familyProtectionWindow:   tc.protectionWindow,
+			}
+			imageManager.SetDataClient(data.NewNoopClient())
+			// The image was last used by a container long ago
+			imageState := &image.ImageState{
+				Image:            &image.Image{ImageID: "sha256:qwerty", Names: []string{"image"}},
+				PulledAt:         now.AddDate(0, -2, 0),
+				LastUsedAt:       now.AddDate(0, -2, 0),
+				FamilyLastUsedAt: tc.familyLastUsedAt,
+			}
+			imageManager.addImageState(imageState)
+			imageManager.imageStatesConsideredForDeletion = imageManager.imagesConsiderForDeletion(
+				imageManager.getAllImageStates())
+
+			candidates := imageManager.getCandidateImagesForDeletion(imageManager.minimumAgeBeforeDeletion)
+			if tc.expectCandidate {
+				assert.Equal(t, []*image.ImageState{imageState}, candidates)
+			} else {
+				assert.Empty(t, candidates)
+			}
+			eligibility, ok := imageManager.GetImageCleanupEligibility("sha256:qwerty")
+			require.True(t, ok)
+			assert.Equal(t, !tc.expectCandidate, eligibility.ProtectedByFamily)
+			assert.Equal(t, tc.expectCandidate, eligibility.Eligible)
+		})
+	}
+}
+
+func TestContainerReferencesRecordTaskFamilyUse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+
+	imageManager := &dockerImageManager{
+		client:                   client,
+		state:                    dockerstate.NewTaskEngineState(),
+		minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
+	}
+	imageManager.SetDataClient(data.NewNoopClient())
+
+	container := &apicontainer.Container{
+		Name:  "testContainer",
+		Image: "testContainerImage",
+	}
+	imageManager.state.AddTask(&apitask.Task{
+		Arn:        "testTask",
+		Family:     "web",
+		Containers: []*apicontainer.Container{container},
+	})
+	// Containers of unknown tasks are not attributed to a family
+	orphanContainer := &apicontainer.Container{
+		Name:  "orphanContainer",
+		Image: "testContainerImage",
+	}
+	client.EXPECT().InspectImage(container.Image).Return(&types.ImageInspect{ID: "sha256:qwerty"}, nil).Times(2)
+
+	recordedAt := time.Now()
+	require.NoError(t, imageManager.RecordContainerReference(container))
+	require.NoError(t, imageManager.RecordContainerReference(orphanContainer))
+	imageState, ok := imageManager.getImageState("sha256:qwerty")
+	require.True(t, ok)
+	require.Len(t, imageState.FamilyLastUsedAt, 1)
+	firstUse := imageState.FamilyLastUsedAt["web"]
+	assert.False(t, firstUse.Before(recordedAt))
+
+	require.NoError(t, imageManager.RemoveContainerReferenceFromImageState(container))
+	assert.False(t, imageState.FamilyLastUsedAt["web"].Before(firstUse))
+	assert.True(t, imageState.UsedByFamilySince(recordedAt.Add(-time.Second)))
+}
+
+func TestGetCandidateImagesForDeletionImagePinnedByTask(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+
+	imageManager := &dockerImageManager{
+		client:                   client,
+		state:                    dockerstate.NewTaskEngineState(),
+		minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
+		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
+		imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
+	}
+	imageManager.SetDataClient(data.NewNoopClient())
+
+	container := &apicontainer.Container{
+		Name:    "testContainer",
+		Image:   "testContainerImage",
+		ImageID: "sha256:qwerty",
+	}
+	task := &apitask.Task{
+		Arn:        "testTask",
+		Containers: []*apicontainer.Container{container},
+	}
+	task.SetKnownStatus(apitaskstatus.TaskRunning)
+	imageManager.state.AddTask(task)
+
+	sourceImageState := &image.ImageState{
+		Image: &image.Image{
+			ImageID: "sha256:qwerty",
+			Names:   []string{container.Image},
+		},
+		PulledAt: time.Now().AddDate(0, -2, 0),
+	}
+	imageManager.addImageState(sourceImageState)
+	client.EXPECT().InspectImage(container.Image).Return(&types.ImageInspect{ID: "sha256:qwerty"}, nil).AnyTimes()
+	require.NoError(t, imageManager.RecordContainerReference(container))
+	// The exited container is removed while the task keeps running to restart it
+	require.NoError(t, imageManager.RemoveContainerReferenceFromImageState(container))
+	require.True(t, sourceImageState.HasNoAssociatedContainers())
+
+	imageManager.imageStatesConsideredForDeletion = imageManager.imagesConsiderForDeletion(
+		imageManager.getAllImageStates())
+	assert.Empty(t, imageManager.getCandidateImagesForDeletion(imageManager.minimumAgeBeforeDeletion), "Expected the image of the running task to be retained")
+
+	task.SetKnownStatus(apitaskstatus.TaskStopped)
+	assert.Equal(t, []*image.ImageState{sourceImageState}, imageManager.getCandidateImagesForDeletion(imageManager.minimumAgeBeforeDeletion),
+		"Expected the image of the stopped task to be returned for deletion")
+}
+
 func TestImageCleanupExclusionListWithSingleName(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -938,6 +1235,7 @@ func TestImageCleanupHappyPath(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
 
 	imageManager := &dockerImageManager{
 		client:                   client,
@@ -991,10 +1289,68 @@ func TestImageCleanupHappyPath(t *testing.T) {
 	}
 }
 
+func TestImageCleanupPullCooldown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{}).AnyTimes()
+
+	imageManager := &dockerImageManager{
+		client:                   client,
+		state:                    dockerstate.NewTaskEngineState(),
+		minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
+		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
+		imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
+		pullCooldown:             time.Hour,
+	}
+	imageManager.SetDataClient(data.NewNoopClient())
+
+	oldImageState := &image.ImageState{
+		Image:      &image.Image{ImageID: "sha256:old", Names: []string{"old"}},
+		PulledAt:   time.Now().AddDate(0, -2, 0),
+		LastUsedAt: time.Now().AddDate(0, -2, 0),
+	}
+	imageManager.addImageState(oldImageState)
+	imageManager.state.AddImageState(oldImageState)
+
+	// A container whose image was just pulled starts the cooldown
+	container := &apicontainer.Container{
+		Name:  "testContainer",
+		Image: "testContainerImage",
+	}
+	container.SetImagePullStartedAt(time.Now().Add(-time.Minute))
+	container.SetImagePullStoppedAt(time.Now())
+	client.EXPECT().InspectImage(container.Image).Return(&types.ImageInspect{ID: "sha256:qwerty"}, nil)
+	require.NoError(t, imageManager.RecordContainerReference(container))
+	assert.True(t, imageManager.isInPullCooldown())
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	go imageManager.performPeriodicImageCleanup(ctx, 2*time.Millisecond)
+	// No image is removed while in the cooldown
+	time.Sleep(100 * time.Millisecond)
+
+	removed := make(chan struct{})
+	client.EXPECT().RemoveImage(gomock.Any(), "old", dockerclient.RemoveImageTimeout).Do(
+		func(ctx context.Context, imageID string, timeout time.Duration) {
+			close(removed)
+		}).Return(nil)
+	imageManager.lastImagePullLock.Lock()
+	imageManager.lastImagePullAt = time.Now().Add(-2 * time.Hour)
+	imageManager.lastImagePullLock.Unlock()
+	select {
+	case <-removed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("image cleanup did not run after the cooldown")
+	}
+}
+
 func TestImageCleanupCannotRemoveImage(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
 
 	imageManager := &dockerImageManager{
 		client:                   client,
@@ -1053,6 +1409,7 @@ func TestImageCleanupRemoveImageById(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
 
 	imageManager := &dockerImageManager{
 		client:                   client,
@@ -1805,6 +2162,57 @@ func TestDeleteImageOtherRemoveImageErrors(t *testing.T) {
 	}
 }
 
+func TestDeleteImageRepeatedRemoveFailuresWarning(t *testing.T) {
+	var logs bytes.Buffer
+	testLogger, err := seelog.LoggerFromWriterWithMinLevelAndFormat(&logs, seelog.WarnLvl, "%Msg%n")
+	require.NoError(t, err)
+	defaultLogger := seelog.Current
+	seelog.UseLogger(testLogger)
+	defer seelog.UseLogger(defaultLogger)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	imageManager := &dockerImageManager{
+		client:                        client,
+		state:                         dockerstate.NewTaskEngineState(),
+		removeFailureWarningThreshold: 3,
+	}
+	imageManager.SetDataClient(data.NewNoopClient())
+	container := &apicontainer.Container{
+		Name:  "testContainer",
+		Image: "testContainerImage",
+	}
+	imageInspected := &types.ImageInspect{
+		ID: "sha256:qwerty",
+	}
+	client.EXPECT().InspectImage(container.Image).Return(imageInspected, nil).AnyTimes()
+	require.NoError(t, imageManager.RecordContainerReference(container))
+	imageState, _ := imageManager.getImageState(imageInspected.ID)
+	client.EXPECT().RemoveImage(gomock.Any(), container.Image, dockerclient.RemoveImageTimeout).Return(
+		errors.New("conflict: unable to remove repository reference")).Times(5)
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	countWarnings := func() int {
+		testLogger.Flush()
+		return strings.Count(logs.String(), "Image repeatedly failed to be removed")
+	}
+	for i := 1; i <= 5; i++ {
+		imageManager.deleteImage(ctx, container.Image, imageState)
+		if i < 3 {
+			assert.Equal(t, 0, countWarnings(), "Unexpected warning after %d failures", i)
+		} else {
+			assert.Equal(t, 1, countWarnings(), "Expected a single warning after %d failures", i)
+		}
+	}
+	assert.Contains(t, logs.String(), "conflict: unable to remove repository reference")
+
+	// A successful removal resets the consecutive failures
+	client.EXPECT().RemoveImage(gomock.Any(), container.Image, dockerclient.RemoveImageTimeout).Return(nil)
+	imageManager.deleteImage(ctx, container.Image, imageState)
+	assert.Equal(t, 1, imageState.RecordRemoveFailure())
+}
+
 func TestDeleteImageIDNull(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1820,6 +2228,8 @@ func TestRemoveLeastRecentlyUsedImageNoImage(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{}).AnyTimes()
 	imageManager := &dockerImageManager{client: client, state: dockerstate.NewTaskEngineState()}
 	imageManager.SetDataClient(data.NewNoopClient())
 	ctx, cancel := context.WithCancel(context.TODO())
@@ -1834,6 +2244,8 @@ func TestRemoveUnusedImagesNoImages(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{}).AnyTimes()
 	imageManager := &dockerImageManager{client: client, state: dockerstate.NewTaskEngineState()}
 	imageManager.SetDataClient(data.NewNoopClient())
 	ctx, cancel := context.WithCancel(context.TODO())
@@ -1889,12 +2301,63 @@ func TestGetImageStateFromImageNameNoImageState(t *testing.T) {
 	}
 }
 
+func TestGetImageStateFromImageNameEquivalentReferences(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	imageManager := &dockerImageManager{client: client, state: dockerstate.NewTaskEngineState()}
+	imageManager.SetDataClient(data.NewNoopClient())
+	imageInspected := &types.ImageInspect{
+		ID: "sha256:qwerty",
+	}
+	client.EXPECT().InspectImage(gomock.Any()).Return(imageInspected, nil).AnyTimes()
+	require.NoError(t, imageManager.RecordContainerReference(&apicontainer.Container{
+		Name:  "testContainer",
+		Image: "nginx",
+	}))
+	// Equivalent references do not add names to the image state
+	require.NoError(t, imageManager.RecordContainerReference(&apicontainer.Container{
+		Name:  "testContainer2",
+		Image: "docker.io/library/nginx:latest",
+	}))
+	imageState, ok := imageManager.getImageState(imageInspected.ID)
+	require.True(t, ok)
+	assert.Equal(t, []string{"nginx"}, imageState.Image.Names)
+
+	testCases := []struct {
+		imageName string
+		found     bool
+	}{
+		{imageName: "nginx", found: true},
+		{imageName: "nginx:latest", found: true},
+		{imageName: "library/nginx", found: true},
+		{imageName: "library/nginx:latest", found: true},
+		{imageName: "docker.io/library/nginx", found: true},
+		{imageName: "docker.io/library/nginx:latest", found: true},
+		{imageName: "index.docker.io/library/nginx", found: true},
+		{imageName: "nginx:1.25", found: false},
+		{imageName: "myorg/nginx", found: false},
+		{imageName: "public.ecr.aws/nginx/nginx", found: false},
+		{imageName: "NGINX", found: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.imageName, func(t *testing.T) {
+			foundState, found := imageManager.GetImageStateFromImageName(tc.imageName)
+			assert.Equal(t, tc.found, found)
+			if tc.found {
+				assert.Equal(t, imageState, foundState)
+			}
+		})
+	}
+}
+
 // TestConcurrentRemoveUnusedImages checks for concurrent map writes
 // in the imageManager
 func TestConcurrentRemoveUnusedImages(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
 
 	imageManager := &dockerImageManager{
 		client:                   client,
@@ -1965,6 +2428,8 @@ func TestImageCleanupProcessNotStart(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{}).AnyTimes()
 
 	cfg := defaultTestConfig()
 	cfg.ImagePullBehavior = config.ImagePullPreferCachedBehavior
@@ -1974,3 +2439,1497 @@ func TestImageCleanupProcessNotStart(t *testing.T) {
 	imageManager.StartImageCleanupProcess(ctx)
 	// Nothing should happen.
 }
+
+func TestRemoveUnusedImagesKeepsImagesOfDaemonContainers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+
+	imageManager := &dockerImageManager{
+		client:                   client,
+		state:                    dockerstate.NewTaskEngineState(),
+		minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
+		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
+		imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
+	}
+	imageManager.SetDataClient(data.NewNoopClient())
+	// Neither image is referenced by a container tracked by the agent
+	imageStates := []*image.ImageState{
+		{
+			Image:    &image.Image{ImageID: "sha256:unused", Names: []string{"unused"}},
+			PulledAt: time.Now().AddDate(0, -2, 0),
+		},
+		{
+			Image:    &image.Image{ImageID: "sha256:paused", Names: []string{"paused"}},
+			PulledAt: time.Now().AddDate(0, -2, 0),
+		},
+	}
+	for _, imageState := range imageStates {
+		imageManager.addImageState(imageState)
+		imageManager.state.AddImageState(imageState)
+	}
+	// The daemon lists containers in all states, including paused ones
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(
+		dockerapi.ListContainersResponse{
+			DockerIDs: []string{"paused-container"},
+			ImageIDs:  map[string]string{"paused-container": "sha256:paused"},
+		})
+	client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{})
+	client.EXPECT().RemoveImage(gomock.Any(), "unused", dockerclient.RemoveImageTimeout).Return(nil)
+
+	stats := imageManager.removeUnusedImages(context.TODO())
+
+	assert.Equal(t, []string{"sha256:unused"}, stats.RemovedImageIDs)
+	assert.Equal(t, 1, stats.SkipReasons[image.CleanupSkipReasonInUse])
+	_, ok := imageManager.getImageState("sha256:paused")
+	assert.True(t, ok, "image of the paused container should not be removed")
+	assert.Nil(t, imageManager.daemonContainerImageIDs, "images of daemon containers should be cleared after the cycle")
+}
+
+func TestRemoveUnusedImagesEnforcesImageDiskBudget(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{}).AnyTimes()
+
+	imageManager := &dockerImageManager{
+		client:                   client,
+		state:                    dockerstate.NewTaskEngineState(),
+		minimumAgeBeforeDeletion: time.Hour,
+		numImagesToDelete:        1,
+		imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
+		maxTotalImageDiskBytes:   600,
+	}
+	imageManager.SetDataClient(data.NewNoopClient())
+	// The images are listed from the least recently used one, the last one is too recent to be removed
+	images := []struct {
+		name string
+		size int64
+		age  time.Duration
+	}{
+		{name: "a", size: 400, age: 5 * time.Hour},
+		{name: "b", size: 300, age: 4 * time.Hour},
+		{name: "c", size: 200, age: 3 * time.Hour},
+		{name: "d", size: 100, age: 2 * time.Hour},
+		{name: "recent", size: 250, age: time.Minute},
+	}
+	for _, img := range images {
+		imageState := &image.ImageState{
+			Image:      &image.Image{ImageID: "sha256:" + img.name, Names: []string{img.name}, Size: img.size},
+			PulledAt:   time.Now().Add(-img.age),
+			LastUsedAt: time.Now().Add(-img.age),
+		}
+		imageManager.addImageState(imageState)
+		imageManager.state.AddImageState(imageState)
+	}
+
+	// The cycle removes one image, then another one to get from 850 bytes under the budget of 600 bytes
+	client.EXPECT().RemoveImage(gomock.Any(), "a", dockerclient.RemoveImageTimeout).Return(nil)
+	client.EXPECT().RemoveImage(gomock.Any(), "b", dockerclient.RemoveImageTimeout).Return(nil)
+	stats := imageManager.removeUnusedImages(context.TODO())
+	assert.Equal(t, []string{"sha256:a", "sha256:b"}, stats.RemovedImageIDs)
+	assert.Equal(t, int64(550), imageManager.getTotalImageBytes())
+
+	// The budget cannot be met as the recent image alone is above it
+	imageManager.maxTotalImageDiskBytes = 200
+	client.EXPECT().RemoveImage(gomock.Any(), "c", dockerclient.RemoveImageTimeout).Return(nil)
+	client.EXPECT().RemoveImage(gomock.Any(), "d", dockerclient.RemoveImageTimeout).Return(nil)
+	stats = imageManager.removeUnusedImages(context.TODO())
+	assert.Equal(t, []string{"sha256:c", "sha256:d"}, stats.RemovedImageIDs)
+	assert.Equal(t, int64(250), imageManager.getTotalImageBytes())
+	_, ok := imageManager.getImageState("sha256:recent")
+	assert.True(t, ok, "recent image should be kept")
+}
+
+func TestRemoveUnusedImagesSkipsPinnedImages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{}).AnyTimes()
+
+	imageManager := &dockerImageManager{
+		client:                   client,
+		state:                    dockerstate.NewTaskEngineState(),
+		minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
+		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
+		imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
+	}
+	imageManager.SetDataClient(data.NewNoopClient())
+	for _, name := range []string{"app:v1", "app:v2", "app:v3"} {
+		imageState := &image.ImageState{
+			Image:    &image.Image{ImageID: "sha256:" + name, Names: []string{name}},
+			PulledAt: time.Now().AddDate(0, -2, 0),
+		}
+		imageManager.addImageState(imageState)
+		imageManager.state.AddImageState(imageState)
+	}
+
+	// Pinned by name and by ID
+	imageManager.PinImage("app:v1", time.Hour)
+	imageManager.PinImage("sha256:app:v2", time.Hour)
+	eligibility, ok := imageManager.GetImageCleanupEligibility("app:v1")
+	require.True(t, ok)
+	assert.True(t, eligibility.Pinned)
+	assert.False(t, eligibility.Eligible)
+	client.EXPECT().RemoveImage(gomock.Any(), "app:v3", dockerclient.RemoveImageTimeout).Return(nil)
+
+	stats := imageManager.removeUnusedImages(context.TODO())
+	assert.Equal(t, []string{"sha256:app:v3"}, stats.RemovedImageIDs)
+	assert.Equal(t, 2, stats.SkipReasons[image.CleanupSkipReasonPinned])
+
+	// An unpinned image is removed by the next cycle
+	assert.True(t, imageManager.UnpinImage("sha256:app:v2"))
+	assert.False(t, imageManager.UnpinImage("sha256:app:v2"), "image should no longer be pinned")
+	pinnedImages := imageManager.GetPinnedImages()
+	assert.Len(t, pinnedImages, 1)
+	assert.Contains(t, pinnedImages, "app:v1")
+	client.EXPECT().RemoveImage(gomock.Any(), "app:v2", dockerclient.RemoveImageTimeout).Return(nil)
+
+	stats = imageManager.removeUnusedImages(context.TODO())
+	assert.Equal(t, []string{"sha256:app:v2"}, stats.RemovedImageIDs)
+	assert.Equal(t, 1, stats.SkipReasons[image.CleanupSkipReasonPinned])
+}
+
+func TestRemoveUnusedImagesAfterStartupSettlePeriod(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{}).AnyTimes()
+
+	// Simulate a fresh start of the agent with images whose last used times, loaded from the state, look old
+	imageManager := &dockerImageManager{
+		client:                   client,
+		state:                    dockerstate.NewTaskEngineState(),
+		minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
+		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
+		imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
+		startedAt:                time.Now(),
+		startupSettlePeriod:      time.Hour,
+	}
+	imageManager.SetDataClient(data.NewNoopClient())
+	for _, name := range []string{"app:v1", "app:v2", "app:v3", "app:v4", "app:v5"} {
+		imageState := &image.ImageState{
+			Image:      &image.Image{ImageID: "sha256:" + name, Names: []string{name}},
+			PulledAt:   time.Now().AddDate(0, -2, 0),
+			LastUsedAt: time.Now().AddDate(0, -1, 0),
+		}
+		imageManager.addImageState(imageState)
+		imageManager.state.AddImageState(imageState)
+	}
+	eligibility, ok := imageManager.GetImageCleanupEligibility("app:v1")
+	require.True(t, ok)
+	assert.True(t, eligibility.StartupSettling)
+	assert.False(t, eligibility.Eligible)
+
+	// No image is removed right after startup
+	stats := imageManager.removeUnusedImages(context.TODO())
+	assert.Empty(t, stats.RemovedImageIDs)
+	assert.Equal(t, 5, stats.SkipReasons[image.CleanupSkipReasonStartupSettling])
+
+	// An image seen in use since startup is no longer protected
+	usedImage, ok := imageManager.getImageState("sha256:app:v1")
+	require.True(t, ok)
+	usedImage.LastUsedAt = time.Now()
+	client.EXPECT().RemoveImage(gomock.Any(), "app:v1", dockerclient.RemoveImageTimeout).Return(nil)
+
+	stats = imageManager.removeUnusedImages(context.TODO())
+	assert.Equal(t, []string{"sha256:app:v1"}, stats.RemovedImageIDs)
+	assert.Equal(t, 4, stats.SkipReasons[image.CleanupSkipReasonStartupSettling])
+
+	// Once the settle period has elapsed, images are removed as usual
+	imageManager.startedAt = time.Now().Add(-2 * time.Hour)
+	client.EXPECT().RemoveImage(gomock.Any(), gomock.Any(), dockerclient.RemoveImageTimeout).Return(nil).Times(4)
+
+	stats = imageManager.removeUnusedImages(context.TODO())
+	assert.Len(t, stats.RemovedImageIDs, 4)
+	assert.Zero(t, stats.SkipReasons[image.CleanupSkipReasonStartupSettling])
+}
+
+func TestRemoveUnusedImagesRemovesImagesWhosePinExpired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{}).AnyTimes()
+
+	imageManager := &dockerImageManager{
+		client:                   client,
+		state:                    dockerstate.NewTaskEngineState(),
+		minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
+		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
+		imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
+	}
+	imageManager.SetDataClient(data.NewNoopClient())
+	imageState := &image.ImageState{
+		Image:    &image.Image{ImageID: "sha256:app", Names: []string{"app:v1"}},
+		PulledAt: time.Now().AddDate(0, -2, 0),
+	}
+	imageManager.addImageState(imageState)
+	imageManager.state.AddImageState(imageState)
+
+	expiresAt := imageManager.PinImage("app:v1", 10*time.Millisecond)
+	assert.Equal(t, map[string]time.Time{"app:v1": expiresAt}, imageManager.GetPinnedImages())
+	time.Sleep(20 * time.Millisecond)
+
+	client.EXPECT().RemoveImage(gomock.Any(), "app:v1", dockerclient.RemoveImageTimeout).Return(nil)
+	stats := imageManager.removeUnusedImages(context.TODO())
+	assert.Equal(t, []string{"sha256:app"}, stats.RemovedImageIDs)
+	assert.Zero(t, stats.SkipReasons[image.CleanupSkipReasonPinned])
+	assert.Empty(t, imageManager.GetPinnedImages(), "expired pins should be dropped")
+	assert.False(t, imageManager.UnpinImage("app:v1"))
+}
+
+func TestRemoveUnusedImagesWhenContainersCannotBeListed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+
+	imageManager := &dockerImageManager{
+		client:                   client,
+		state:                    dockerstate.NewTaskEngineState(),
+		minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
+		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
+		imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
+	}
+	imageManager.SetDataClient(data.NewNoopClient())
+	imageState := &image.ImageState{
+		Image:    &image.Image{ImageID: "sha256:unused", Names: []string{"unused"}},
+		PulledAt: time.Now().AddDate(0, -2, 0),
+	}
+	imageManager.addImageState(imageState)
+	imageManager.state.AddImageState(imageState)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(
+		dockerapi.ListContainersResponse{Error: errors.New("error listing containers")})
+	client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{})
+	client.EXPECT().RemoveImage(gomock.Any(), "unused", dockerclient.RemoveImageTimeout).Return(nil)
+
+	stats := imageManager.removeUnusedImages(context.TODO())
+
+	assert.Equal(t, []string{"sha256:unused"}, stats.RemovedImageIDs)
+	assert.Equal(t, "unable to list containers: error listing containers", stats.Error)
+}
+
+func TestRemoveUnusedImagesRecordsCleanupError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{}).AnyTimes()
+
+	imageManager := &dockerImageManager{
+		client:                   client,
+		state:                    dockerstate.NewTaskEngineState(),
+		minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
+		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
+		imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
+	}
+	imageManager.SetDataClient(data.NewNoopClient())
+	imageState := &image.ImageState{
+		Image:    &image.Image{ImageID: "sha256:unused", Names: []string{"unused"}},
+		PulledAt: time.Now().AddDate(0, -2, 0),
+	}
+	imageManager.addImageState(imageState)
+	imageManager.state.AddImageState(imageState)
+	client.EXPECT().RemoveImage(gomock.Any(), "unused", dockerclient.RemoveImageTimeout).Return(errors.New("conflict"))
+
+	imageManager.removeUnusedImages(context.TODO())
+
+	history := imageManager.GetImageCleanupHistory()
+	require.Len(t, history, 1)
+	assert.Equal(t, 0, history[0].ImagesRemoved)
+	assert.Equal(t, "unable to remove image unused: conflict", history[0].Error)
+
+	// The error of a cycle is not carried over to the next one
+	client.EXPECT().RemoveImage(gomock.Any(), "unused", dockerclient.RemoveImageTimeout).Return(nil)
+	imageManager.removeUnusedImages(context.TODO())
+	history = imageManager.GetImageCleanupHistory()
+	require.Len(t, history, 2)
+	assert.Equal(t, 1, history[1].ImagesRemoved)
+	assert.Empty(t, history[1].Error)
+}
+
+func TestRemoveUnusedImagesRecordsCleanupStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{}).AnyTimes()
+
+	imageManager := &dockerImageManager{
+		client:                    client,
+		state:                     dockerstate.NewTaskEngineState(),
+		minimumAgeBeforeDeletion:  config.DefaultImageDeletionAge,
+		numImagesToDelete:         config.DefaultNumImagesToDeletePerCycle,
+		imageCleanupTimeInterval:  config.DefaultImageCleanupTimeInterval,
+		imageCleanupExclusionList: []string{"excluded"},
+		cleanupStatsHistorySize:   2,
+	}
+	imageManager.SetDataClient(data.NewNoopClient())
+	registry := prometheus.NewRegistry()
+	metrics.MustInit(&config.Config{PrometheusMetricsEnabled: true}, registry)
+	defer func() {
+		metrics.MetricsEngineGlobal = &metrics.MetricsEngine{}
+	}()
+	imageStates := []*image.ImageState{
+		{
+			Image:    &image.Image{ImageID: "sha256:unused", Names: []string{"unused"}, Size: 1024},
+			PulledAt: time.Now().AddDate(0, -2, 0),
+		},
+		{
+			Image:    &image.Image{ImageID: "sha256:excluded", Names: []string{"excluded"}, Size: 2048},
+			PulledAt: time.Now().AddDate(0, -2, 0),
+		},
+		{
+			Image:      &image.Image{ImageID: "sha256:inuse", Names: []string{"inuse"}, Size: 4096},
+			PulledAt:   time.Now().AddDate(0, -2, 0),
+			Containers: []*apicontainer.Container{{Name: "container"}},
+		},
+		{
+			Image:    &image.Image{ImageID: "sha256:recent", Names: []string{"recent"}, Size: 8192},
+			PulledAt: time.Now(),
+		},
+	}
+	for _, imageState := range imageStates {
+		imageManager.addImageState(imageState)
+		imageManager.state.AddImageState(imageState)
+	}
+	client.EXPECT().RemoveImage(gomock.Any(), "unused", dockerclient.RemoveImageTimeout).Return(nil)
+
+	imageManager.removeUnusedImages(context.TODO())
+
+	history := imageManager.GetImageCleanupHistory()
+	require.Len(t, history, 1)
+	stats := history[0]
+	assert.False(t, stats.StartedAt.IsZero())
+	assert.Equal(t, 4, stats.CandidatesEvaluated)
+	assert.Equal(t, 1, stats.ImagesRemoved)
+	assert.Equal(t, int64(1024), stats.BytesReclaimed)
+	assert.Equal(t, []string{"sha256:unused"}, stats.RemovedImageIDs)
+	assert.Equal(t, map[string]int{
+		image.CleanupSkipReasonExcluded:  1,
+		image.CleanupSkipReasonInUse:     1,
+		image.CleanupSkipReasonTooRecent: 1,
+	}, stats.SkipReasons)
+	assert.Nil(t, imageManager.cleanupStats, "stats of the cycle in progress should be cleared")
+
+	// The statistics of the cycle are emitted as metrics as well
+	assert.Equal(t, 4.0, imageCleanupMetric(t, registry, "AgentMetrics_TaskEngine_image_cleanup_images_evaluated_count", "").GetCounter().GetValue())
+	assert.Equal(t, 1.0, imageCleanupMetric(t, registry, "AgentMetrics_TaskEngine_image_cleanup_images_removed_count", "").GetCounter().GetValue())
+	assert.Equal(t, 1024.0, imageCleanupMetric(t, registry, "AgentMetrics_TaskEngine_image_cleanup_reclaimed_bytes", "").GetCounter().GetValue())
+	for _, reason := range []string{image.CleanupSkipReasonExcluded, image.CleanupSkipReasonInUse, image.CleanupSkipReasonTooRecent} {
+		assert.Equal(t, 1.0, imageCleanupMetric(t, registry, "AgentMetrics_TaskEngine_image_cleanup_images_skipped_count", reason).GetCounter().GetValue(), reason)
+	}
+	assert.Equal(t, uint64(1), imageCleanupMetric(t, registry, "AgentMetrics_TaskEngine_image_cleanup_duration_seconds", "").GetHistogram().GetSampleCount())
+
+	// The history is bounded by its configured size
+	imageManager.removeUnusedImages(context.TODO())
+	imageManager.removeUnusedImages(context.TODO())
+	history = imageManager.GetImageCleanupHistory()
+	require.Len(t, history, 2)
+	assert.Equal(t, 0, history[1].ImagesRemoved)
+	assert.Equal(t, 3, history[1].CandidatesEvaluated)
+}
+
+// imageCleanupMetric returns the image cleanup metric of the family with the given name, and skip reason if any
+func imageCleanupMetric(t *testing.T, registry *prometheus.Registry, name, reason string) *dto.Metric {
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			metricReason := ""
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "Reason" {
+					metricReason = label.GetValue()
+				}
+			}
+			if metricReason == reason {
+				return metric
+			}
+		}
+	}
+	require.Failf(t, "metric not found", "%s %s", name, reason)
+	return nil
+}
+
+func TestRemoveUnusedImagesPrioritizeSize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{}).AnyTimes()
+
+	imageManager := &dockerImageManager{
+		client:                   client,
+		state:                    dockerstate.NewTaskEngineState(),
+		minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
+		numImagesToDelete:        3,
+		imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
+		prioritizeSize:           config.BooleanDefaultFalse{Value: config.ExplicitlyEnabled},
+	}
+	imageManager.SetDataClient(data.NewNoopClient())
+	pulledAt := time.Now().AddDate(0, -2, 0)
+	imageStates := []*image.ImageState{
+		{
+			Image:      &image.Image{ImageID: "sha256:tiny", Names: []string{"tiny"}, Size: 1024},
+			PulledAt:   pulledAt,
+			LastUsedAt: pulledAt,
+		},
+		{
+			Image:      &image.Image{ImageID: "sha256:medium", Names: []string{"medium"}, Size: 4096},
+			PulledAt:   pulledAt,
+			LastUsedAt: pulledAt.Add(2 * time.Hour),
+		},
+		{
+			Image:      &image.Image{ImageID: "sha256:large", Names: []string{"large"}, Size: 8192},
+			PulledAt:   pulledAt,
+			LastUsedAt: pulledAt.Add(3 * time.Hour),
+		},
+		{
+			Image:      &image.Image{ImageID: "sha256:medium-recent", Names: []string{"medium-recent"}, Size: 4096},
+			PulledAt:   pulledAt,
+			LastUsedAt: pulledAt.Add(4 * time.Hour),
+		},
+		{
+			Image:      &image.Image{ImageID: "sha256:huge-inuse", Names: []string{"huge-inuse"}, Size: 16384},
+			PulledAt:   pulledAt,
+			Containers: []*apicontainer.Container{{Name: "container"}},
+		},
+	}
+	for _, imageState := range imageStates {
+		imageManager.addImageState(imageState)
+		imageManager.state.AddImageState(imageState)
+	}
+	// Images of the same size are removed least recently used first
+	gomock.InOrder(
+		client.EXPECT().RemoveImage(gomock.Any(), "large", dockerclient.RemoveImageTimeout).Return(nil),
+		client.EXPECT().RemoveImage(gomock.Any(), "medium", dockerclient.RemoveImageTimeout).Return(nil),
+		client.EXPECT().RemoveImage(gomock.Any(), "medium-recent", dockerclient.RemoveImageTimeout).Return(nil),
+	)
+
+	stats := imageManager.removeUnusedImages(context.TODO())
+	assert.Equal(t, []string{"sha256:large", "sha256:medium", "sha256:medium-recent"}, stats.RemovedImageIDs)
+	_, ok := imageManager.getImageState("sha256:tiny")
+	assert.True(t, ok, "the smallest image should not have been removed")
+}
+
+func TestRemoveUnusedImagesEscalatesWhenDiskUsageIsHigh(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{}).AnyTimes()
+
+	imageManager := &dockerImageManager{
+		client:                   client,
+		state:                    dockerstate.NewTaskEngineState(),
+		minimumAgeBeforeDeletion: time.Hour,
+		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
+		escalationDiskThreshold:  90,
+		escalationMinimumAge:     10 * time.Minute,
+		escalationDiskPath:       "/",
+	}
+	imageManager.SetDataClient(data.NewNoopClient())
+	// Only images younger than the minimum age exist, so a normal cleanup cycle removes none of them
+	pulledAt := time.Now().Add(-40 * time.Minute)
+	imageStates := []*image.ImageState{
+		{
+			Image:      &image.Image{ImageID: "sha256:old", Names: []string{"old"}},
+			PulledAt:   pulledAt,
+			LastUsedAt: pulledAt,
+		},
+		{
+			Image:      &image.Image{ImageID: "sha256:less-old", Names: []string{"less-old"}},
+			PulledAt:   pulledAt,
+			LastUsedAt: pulledAt.Add(time.Minute),
+		},
+		{
+			Image:      &image.Image{ImageID: "sha256:least-old", Names: []string{"least-old"}},
+			PulledAt:   pulledAt,
+			LastUsedAt: pulledAt.Add(2 * time.Minute),
+		},
+		{
+			Image:      &image.Image{ImageID: "sha256:recent", Names: []string{"recent"}},
+			PulledAt:   time.Now(),
+			LastUsedAt: time.Now(),
+		},
+	}
+	for _, imageState := range imageStates {
+		imageManager.addImageState(imageState)
+		imageManager.state.AddImageState(imageState)
+	}
+
+	// The disk usage goes under the threshold after two images are removed
+	diskUsages := []float64{95, 95, 92, 85}
+	originalDiskUsagePercent := diskUsagePercent
+	defer func() {
+		diskUsagePercent = originalDiskUsagePercent
+	}()
+	diskUsagePercent = func(path string) (float64, error) {
+		assert.Equal(t, "/", path)
+		require.NotEmpty(t, diskUsages)
+		usage := diskUsages[0]
+		diskUsages = diskUsages[1:]
+		return usage, nil
+	}
+	gomock.InOrder(
+		client.EXPECT().RemoveImage(gomock.Any(), "old", dockerclient.RemoveImageTimeout).Return(nil),
+		client.EXPECT().RemoveImage(gomock.Any(), "less-old", dockerclient.RemoveImageTimeout).Return(nil),
+	)
+
+	stats := imageManager.removeUnusedImages(context.TODO())
+	assert.Equal(t, []string{"sha256:old", "sha256:less-old"}, stats.RemovedImageIDs)
+	assert.Empty(t, diskUsages)
+	_, ok := imageManager.getImageState("sha256:least-old")
+	assert.True(t, ok, "no image should have been removed once the disk usage is under the threshold")
+}
+
+func TestRemoveUnusedImagesEscalatesWhenInodeUsageIsHigh(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{}).AnyTimes()
+
+	imageManager := &dockerImageManager{
+		client:                   client,
+		state:                    dockerstate.NewTaskEngineState(),
+		minimumAgeBeforeDeletion: time.Hour,
+		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
+		escalationDiskThreshold:  90,
+		escalationInodeThreshold: 80,
+		escalationMinimumAge:     10 * time.Minute,
+		escalationDiskPath:       "/",
+	}
+	imageManager.SetDataClient(data.NewNoopClient())
+	pulledAt := time.Now().Add(-40 * time.Minute)
+	for i, name := range []string{"old", "less-old", "least-old"} {
+		imageState := &image.ImageState{
+			Image:      &image.Image{ImageID: "sha256:" + name, Names: []string{name}},
+			PulledAt:   pulledAt,
+			LastUsedAt: pulledAt.Add(time.Duration(i) * time.Minute),
+		}
+		imageManager.addImageState(imageState)
+		imageManager.state.AddImageState(imageState)
+	}
+
+	// The disk has plenty of space left but few inodes, until two images are removed
+	originalDiskUsagePercent := diskUsagePercent
+	originalInodeUsagePercent := inodeUsagePercent
+	defer func() {
+		diskUsagePercent = originalDiskUsagePercent
+		inodeUsagePercent = originalInodeUsagePercent
+	}()
+	diskUsagePercent = func(path string) (float64, error) {
+		return 40, nil
+	}
+	inodeUsages := []float64{95, 95, 90, 75}
+	inodeUsagePercent = func(path string) (float64, error) {
+		assert.Equal(t, "/", path)
+		require.NotEmpty(t, inodeUsages)
+		usage := inodeUsages[0]
+		inodeUsages = inodeUsages[1:]
+		return usage, nil
+	}
+	gomock.InOrder(
+		client.EXPECT().RemoveImage(gomock.Any(), "old", dockerclient.RemoveImageTimeout).Return(nil),
+		client.EXPECT().RemoveImage(gomock.Any(), "less-old", dockerclient.RemoveImageTimeout).Return(nil),
+	)
+
+	stats := imageManager.removeUnusedImages(context.TODO())
+	assert.Equal(t, []string{"sha256:old", "sha256:less-old"}, stats.RemovedImageIDs)
+	assert.Empty(t, inodeUsages)
+	_, ok := imageManager.getImageState("sha256:least-old")
+	assert.True(t, ok, "no image should have been removed once the inode usage is under the threshold")
+}
+
+func TestRemoveUnusedImagesEscalationStopsAtMinimumAge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{}).AnyTimes()
+
+	imageManager := &dockerImageManager{
+		client:                   client,
+		state:                    dockerstate.NewTaskEngineState(),
+		minimumAgeBeforeDeletion: time.Hour,
+		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
+		escalationDiskThreshold:  90,
+		escalationMinimumAge:     10 * time.Minute,
+	}
+	imageManager.SetDataClient(data.NewNoopClient())
+	imageState := &image.ImageState{
+		Image:      &image.Image{ImageID: "sha256:recent", Names: []string{"recent"}},
+		PulledAt:   time.Now().Add(-5 * time.Minute),
+		LastUsedAt: time.Now().Add(-5 * time.Minute),
+	}
+	imageManager.addImageState(imageState)
+	imageManager.state.AddImageState(imageState)
+
+	originalDiskUsagePercent := diskUsagePercent
+	defer func() {
+		diskUsagePercent = originalDiskUsagePercent
+	}()
+	diskUsagePercent = func(path string) (float64, error) {
+		return 99, nil
+	}
+
+	// The minimum age is relaxed to 30m, 15m and 10m, after which the image younger than the floor is kept
+	stats := imageManager.removeUnusedImages(context.TODO())
+	assert.Empty(t, stats.RemovedImageIDs)
+	_, ok := imageManager.getImageState("sha256:recent")
+	assert.True(t, ok)
+}
+
+func TestRemoveUnusedImagesEscalationRespectsMinimumAgeFloor(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		minimumAgeSoft       time.Duration
+		escalationMinimumAge time.Duration
+	}{
+		{
+			name:                 "configured floor",
+			escalationMinimumAge: 10 * time.Minute,
+		},
+		{
+			name:                 "soft minimum age under the floor",
+			minimumAgeSoft:       time.Minute,
+			escalationMinimumAge: 10 * time.Minute,
+		},
+		{
+			name: "default floor when unset",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := mock_dockerapi.NewMockDockerClient(ctrl)
+			client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
+			client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{}).AnyTimes()
+
+			imageManager := &dockerImageManager{
+				client:                       client,
+				state:                        dockerstate.NewTaskEngineState(),
+				minimumAgeBeforeDeletion:     time.Hour,
+				minimumAgeBeforeDeletionSoft: tc.minimumAgeSoft,
+				numImagesToDelete:            config.DefaultNumImagesToDeletePerCycle,
+				escalationDiskThreshold:      90,
+				escalationMinimumAge:         tc.escalationMinimumAge,
+			}
+			imageManager.SetDataClient(data.NewNoopClient())
+			for name, age := range map[string]time.Duration{"over-floor": 12 * time.Minute, "under-floor": 5 * time.Minute} {
+				imageState := &image.ImageState{
+					Image:      &image.Image{ImageID: "sha256:" + name, Names: []string{name}},
+					PulledAt:   time.Now().Add(-age),
+					LastUsedAt: time.Now().Add(-age),
+				}
+				imageManager.addImageState(imageState)
+				imageManager.state.AddImageState(imageState)
+			}
+
+			originalDiskUsagePercent := diskUsagePercent
+			defer func() {
+				diskUsagePercent = originalDiskUsagePercent
+			}()
+			diskUsagePercent = func(path string) (float64, error) {
+				return 99, nil
+			}
+			client.EXPECT().RemoveImage(gomock.Any(), "over-floor", dockerclient.RemoveImageTimeout).Return(nil)
+
+			// The disk usage stays high, but the image younger than the 10m floor is kept
+			stats := imageManager.removeUnusedImages(context.TODO())
+			assert.Equal(t, []string{"sha256:over-floor"}, stats.RemovedImageIDs)
+			_, ok := imageManager.getImageState("sha256:under-floor")
+			assert.True(t, ok)
+		})
+	}
+}
+
+func TestRemoveUnusedImagesEscalationStartsFromSoftMinimumAge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{}).AnyTimes()
+
+	imageManager := &dockerImageManager{
+		client:                       client,
+		state:                        dockerstate.NewTaskEngineState(),
+		minimumAgeBeforeDeletion:     time.Hour,
+		minimumAgeBeforeDeletionSoft: 45 * time.Minute,
+		numImagesToDelete:            config.DefaultNumImagesToDeletePerCycle,
+		escalationDiskThreshold:      90,
+		escalationMinimumAge:         10 * time.Minute,
+	}
+	imageManager.SetDataClient(data.NewNoopClient())
+	// Only the image pulled before the soft minimum age is eligible at first, even though the other one was used
+	// less recently. Halving the hard minimum age instead would make both eligible and remove the other one.
+	imageStates := []*image.ImageState{
+		{
+			Image:      &image.Image{ImageID: "sha256:older", Names: []string{"older"}},
+			PulledAt:   time.Now().Add(-50 * time.Minute),
+			LastUsedAt: time.Now().Add(-10 * time.Minute),
+		},
+		{
+			Image:      &image.Image{ImageID: "sha256:newer", Names: []string{"newer"}},
+			PulledAt:   time.Now().Add(-40 * time.Minute),
+			LastUsedAt: time.Now().Add(-35 * time.Minute),
+		},
+	}
+	for _, imageState := range imageStates {
+		imageManager.addImageState(imageState)
+		imageManager.state.AddImageState(imageState)
+	}
+
+	diskUsages := []float64{95, 85}
+	originalDiskUsagePercent := diskUsagePercent
+	defer func() {
+		diskUsagePercent = originalDiskUsagePercent
+	}()
+	diskUsagePercent = func(path string) (float64, error) {
+		require.NotEmpty(t, diskUsages)
+		usage := diskUsages[0]
+		diskUsages = diskUsages[1:]
+		return usage, nil
+	}
+	client.EXPECT().RemoveImage(gomock.Any(), "older", dockerclient.RemoveImageTimeout).Return(nil)
+
+	stats := imageManager.removeUnusedImages(context.TODO())
+	assert.Equal(t, []string{"sha256:older"}, stats.RemovedImageIDs)
+	assert.Empty(t, diskUsages)
+	_, ok := imageManager.getImageState("sha256:newer")
+	assert.True(t, ok)
+}
+
+func TestRemoveUnusedImagesRemovesImagesWithoutNamesByID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
+
+	imageManager := &dockerImageManager{
+		client:                   client,
+		state:                    dockerstate.NewTaskEngineState(),
+		minimumAgeBeforeDeletion: time.Hour,
+		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
+	}
+	imageManager.SetDataClient(data.NewNoopClient())
+	pulledAt := time.Now().Add(-2 * time.Hour)
+	// The names of the retagged image have all been moved to a newer image
+	retaggedImageState := &image.ImageState{
+		Image:      &image.Image{ImageID: "sha256:retagged", Names: []string{"app:latest", "app:v1"}},
+		PulledAt:   pulledAt,
+		LastUsedAt: pulledAt,
+	}
+	// The retagged image of a running container is kept whatever its names
+	container := &apicontainer.Container{Name: "web", Image: "app:v0"}
+	inUseImageState := &image.ImageState{
+		Image:      &image.Image{ImageID: "sha256:in-use", Names: []string{"app:v0"}},
+		Containers: []*apicontainer.Container{container},
+		PulledAt:   pulledAt,
+		LastUsedAt: pulledAt,
+	}
+	for _, imageState := range []*image.ImageState{retaggedImageState, inUseImageState} {
+		imageManager.addImageState(imageState)
+		imageManager.state.AddImageState(imageState)
+	}
+	client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{
+		ImageIDs: []string{"sha256:retagged", "sha256:in-use", "sha256:new"},
+		RepoTags: []string{"<none>:<none>", "app:latest", "app:v1"},
+		ImageRepoTags: map[string][]string{
+			"sha256:retagged": {"<none>:<none>"},
+			"sha256:in-use":   nil,
+			"sha256:new":      {"app:latest", "app:v1"},
+		},
+	})
+	// The image is removed by ID, its former names now refer to the newer image
+	client.EXPECT().RemoveImage(gomock.Any(), "sha256:retagged", dockerclient.RemoveImageTimeout).Return(nil)
+
+	stats := imageManager.removeUnusedImages(context.TODO())
+	assert.Equal(t, []string{"sha256:retagged"}, stats.RemovedImageIDs)
+	_, ok := imageManager.getImageState("sha256:retagged")
+	assert.False(t, ok, "state of the image without names should be purged")
+	assert.Len(t, imageManager.state.AllImageStates(), 1)
+	_, ok = imageManager.getImageState("sha256:in-use")
+	assert.True(t, ok, "image in use should be kept")
+}
+
+func TestRemoveUnusedImagesEscalationRemovesImagesOfStoppedTasks(t *testing.T) {
+	testCases := []struct {
+		name        string
+		diskUsage   float64
+		stoppedFor  time.Duration
+		shouldClean bool
+	}{
+		{
+			name:        "disk pressure after grace",
+			diskUsage:   95,
+			stoppedFor:  10 * time.Minute,
+			shouldClean: true,
+		},
+		{
+			name:       "disk pressure within grace",
+			diskUsage:  95,
+			stoppedFor: time.Minute,
+		},
+		{
+			name:       "no disk pressure",
+			diskUsage:  50,
+			stoppedFor: 10 * time.Minute,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := mock_dockerapi.NewMockDockerClient(ctrl)
+			client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{
+				DockerIDs: []string{"container-id"},
+				ImageIDs:  map[string]string{"container-id": "sha256:stopped"},
+			}).AnyTimes()
+			client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{}).AnyTimes()
+
+			imageManager := &dockerImageManager{
+				client:                     client,
+				state:                      dockerstate.NewTaskEngineState(),
+				minimumAgeBeforeDeletion:   time.Hour,
+				numImagesToDelete:          config.DefaultNumImagesToDeletePerCycle,
+				escalationDiskThreshold:    90,
+				escalationMinimumAge:       10 * time.Minute,
+				escalationDiskPath:         "/",
+				escalationStoppedTaskGrace: 5 * time.Minute,
+			}
+			imageManager.SetDataClient(data.NewNoopClient())
+
+			// The task is stopped but not cleaned up yet, so its container still exists and references the image
+			container := &apicontainer.Container{
+				Name:    "container",
+				Image:   "stopped",
+				ImageID: "sha256:stopped",
+			}
+			container.SetRuntimeID("container-id")
+			task := &apitask.Task{
+				Arn:                      "stopped-task",
+				KnownStatusUnsafe:        apitaskstatus.TaskStopped,
+				ExecutionStoppedAtUnsafe: time.Now().Add(-tc.stoppedFor),
+				Containers:               []*apicontainer.Container{container},
+			}
+			imageManager.state.AddTask(task)
+			imageManager.state.AddContainer(&apicontainer.DockerContainer{
+				DockerID:   "container-id",
+				DockerName: "container",
+				Container:  container,
+			}, task)
+			pulledAt := time.Now().Add(-2 * time.Hour)
+			imageState := &image.ImageState{
+				Image:      &image.Image{ImageID: "sha256:stopped", Names: []string{"stopped"}},
+				Containers: []*apicontainer.Container{container},
+				PulledAt:   pulledAt,
+				LastUsedAt: pulledAt,
+			}
+			imageManager.addImageState(imageState)
+			imageManager.state.AddImageState(imageState)
+
+			originalDiskUsagePercent := diskUsagePercent
+			defer func() {
+				diskUsagePercent = originalDiskUsagePercent
+			}()
+			diskUsage := tc.diskUsage
+			diskUsagePercent = func(path string) (float64, error) {
+				return diskUsage, nil
+			}
+			if tc.shouldClean {
+				gomock.InOrder(
+					client.EXPECT().RemoveContainer(gomock.Any(), "container-id", dockerclient.RemoveContainerTimeout).Return(nil),
+					client.EXPECT().RemoveImage(gomock.Any(), "stopped", dockerclient.RemoveImageTimeout).DoAndReturn(
+						func(ctx context.Context, imageID string, timeout time.Duration) error {
+							diskUsage = 80
+							return nil
+						}),
+				)
+			}
+
+			stats := imageManager.removeUnusedImages(context.TODO())
+			_, ok := imageManager.getImageState("sha256:stopped")
+			if tc.shouldClean {
+				assert.Equal(t, []string{"sha256:stopped"}, stats.RemovedImageIDs)
+				assert.False(t, ok, "image of the stopped task should have been removed")
+			} else {
+				assert.Empty(t, stats.RemovedImageIDs)
+				assert.True(t, ok, "image of the stopped task should have been kept")
+			}
+		})
+	}
+}
+
+func TestRemoveUnusedImagesCleanupWindow(t *testing.T) {
+	// The window spans midnight, from 22:00 to 06:00 UTC-05:00
+	window := &config.ImageCleanupWindow{
+		Start:    22 * time.Hour,
+		End:      6 * time.Hour,
+		Location: time.FixedZone("-05:00", -5*60*60),
+	}
+	testCases := []struct {
+		name          string
+		now           time.Time
+		expectRemoval bool
+	}{
+		{
+			name:          "inside window before midnight",
+			now:           time.Date(2021, time.March, 1, 23, 30, 0, 0, window.Location),
+			expectRemoval: true,
+		},
+		{
+			name:          "inside window after midnight",
+			now:           time.Date(2021, time.March, 2, 3, 0, 0, 0, window.Location),
+			expectRemoval: true,
+		},
+		{
+			name:          "outside window",
+			now:           time.Date(2021, time.March, 2, 12, 0, 0, 0, window.Location),
+			expectRemoval: false,
+		},
+		{
+			name:          "outside window in another time zone",
+			now:           time.Date(2021, time.March, 2, 12, 0, 0, 0, time.UTC),
+			expectRemoval: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := mock_dockerapi.NewMockDockerClient(ctrl)
+			client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
+			client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{}).AnyTimes()
+
+			imageManager := &dockerImageManager{
+				client:                   client,
+				state:                    dockerstate.NewTaskEngineState(),
+				minimumAgeBeforeDeletion: time.Hour,
+				numImagesToDelete:        1,
+				cleanupWindow:            window,
+			}
+			imageManager.SetDataClient(data.NewNoopClient())
+			pulledAt := time.Now().Add(-2 * time.Hour)
+			imageStates := []*image.ImageState{
+				{
+					Image:      &image.Image{ImageID: "sha256:old", Names: []string{"old"}},
+					PulledAt:   pulledAt,
+					LastUsedAt: pulledAt,
+				},
+				{
+					Image:      &image.Image{ImageID: "sha256:less-old", Names: []string{"less-old"}},
+					PulledAt:   pulledAt,
+					LastUsedAt: pulledAt.Add(time.Minute),
+				},
+			}
+			for _, imageState := range imageStates {
+				imageManager.addImageState(imageState)
+				imageManager.state.AddImageState(imageState)
+			}
+
+			originalImageCleanupNow := imageCleanupNow
+			defer func() {
+				imageCleanupNow = originalImageCleanupNow
+			}()
+			imageCleanupNow = func() time.Time {
+				return tc.now
+			}
+			if tc.expectRemoval {
+				client.EXPECT().RemoveImage(gomock.Any(), "old", dockerclient.RemoveImageTimeout).Return(nil)
+			}
+
+			stats := imageManager.removeUnusedImages(context.TODO())
+			_, ok := imageManager.getImageState("sha256:old")
+			if tc.expectRemoval {
+				assert.Equal(t, []string{"sha256:old"}, stats.RemovedImageIDs)
+				assert.False(t, ok)
+				assert.Zero(t, stats.SkipReasons[image.CleanupSkipReasonOutsideWindow])
+			} else {
+				assert.Empty(t, stats.RemovedImageIDs)
+				assert.True(t, ok)
+				// Only the image that would have been removed in the cycle is recorded
+				assert.Equal(t, 1, stats.SkipReasons[image.CleanupSkipReasonOutsideWindow])
+			}
+			_, ok = imageManager.getImageState("sha256:less-old")
+			assert.True(t, ok)
+		})
+	}
+}
+
+func TestReclaimTrackedImageStates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{}).AnyTimes()
+
+	imageManager := &dockerImageManager{
+		client:                   client,
+		state:                    dockerstate.NewTaskEngineState(),
+		minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
+		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
+		maxTrackedImageStates:    3,
+		reclaimRequested:         make(chan struct{}, 1),
+	}
+	imageManager.SetDataClient(data.NewNoopClient())
+	pulledAt := time.Now().AddDate(0, -2, 0)
+	imageStates := []*image.ImageState{
+		{
+			Image:      &image.Image{ImageID: "sha256:oldest", Names: []string{"oldest"}},
+			PulledAt:   pulledAt,
+			LastUsedAt: pulledAt,
+		},
+		{
+			Image:      &image.Image{ImageID: "sha256:older", Names: []string{"older"}},
+			PulledAt:   pulledAt,
+			LastUsedAt: pulledAt.Add(time.Hour),
+		},
+		{
+			Image:      &image.Image{ImageID: "sha256:old", Names: []string{"old"}},
+			PulledAt:   pulledAt,
+			LastUsedAt: pulledAt.Add(2 * time.Hour),
+		},
+		{
+			Image:      &image.Image{ImageID: "sha256:inuse", Names: []string{"inuse"}},
+			PulledAt:   pulledAt,
+			Containers: []*apicontainer.Container{{Name: "container"}},
+		},
+	}
+	for _, imageState := range imageStates[:3] {
+		imageManager.addImageState(imageState)
+		imageManager.state.AddImageState(imageState)
+	}
+	assert.Len(t, imageManager.reclaimRequested, 0, "no reclaim should be requested under the cap")
+	imageManager.addImageState(imageStates[3])
+	imageManager.state.AddImageState(imageStates[3])
+	assert.Len(t, imageManager.reclaimRequested, 1, "a reclaim should be requested over the cap")
+
+	reclaimed := make(chan struct{})
+	client.EXPECT().RemoveImage(gomock.Any(), "oldest", dockerclient.RemoveImageTimeout).Do(
+		func(ctx context.Context, imageID string, timeout time.Duration) {
+			close(reclaimed)
+		}).Return(nil)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	go imageManager.performPeriodicImageCleanup(ctx, time.Hour)
+	select {
+	case <-reclaimed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the reclaim pass did not run")
+	}
+	// The reclaim pass holds the update lock until it completes
+	assert.Equal(t, 3, imageManager.GetImageStatesCount())
+
+	// The cap cannot be met when the remaining images are not eligible for deletion
+	imageManager.maxTrackedImageStates = 1
+	imageStates[1].PulledAt = time.Now()
+	imageStates[2].PulledAt = time.Now()
+	stats := imageManager.reclaimTrackedImageStates(context.TODO())
+	assert.Empty(t, stats.RemovedImageIDs)
+	assert.Equal(t, 3, imageManager.GetImageStatesCount())
+}
+
+// newImageManagerWithDeletionAges returns an image manager with a hard minimum deletion age of 1 hour and a
+// soft one of 10 minutes, tracking images pulled 2 hours, 30 minutes and 1 minute ago
+func newImageManagerWithDeletionAges(client *mock_dockerapi.MockDockerClient) *dockerImageManager {
+	imageManager := &dockerImageManager{
+		client:                       client,
+		state:                        dockerstate.NewTaskEngineState(),
+		minimumAgeBeforeDeletion:     time.Hour,
+		minimumAgeBeforeDeletionSoft: 10 * time.Minute,
+		numImagesToDelete:            config.DefaultNumImagesToDeletePerCycle,
+		maxTrackedImageStates:        1,
+		reclaimRequested:             make(chan struct{}, 1),
+	}
+	imageManager.SetDataClient(data.NewNoopClient())
+	now := time.Now()
+	for _, imageState := range []*image.ImageState{
+		{
+			Image:      &image.Image{ImageID: "sha256:hours-old", Names: []string{"hours-old"}},
+			PulledAt:   now.Add(-2 * time.Hour),
+			LastUsedAt: now.Add(-2 * time.Hour),
+		},
+		{
+			Image:      &image.Image{ImageID: "sha256:minutes-old", Names: []string{"minutes-old"}},
+			PulledAt:   now.Add(-30 * time.Minute),
+			LastUsedAt: now.Add(-30 * time.Minute),
+		},
+		{
+			Image:      &image.Image{ImageID: "sha256:fresh", Names: []string{"fresh"}},
+			PulledAt:   now.Add(-time.Minute),
+			LastUsedAt: now.Add(-time.Minute),
+		},
+	} {
+		imageManager.addImageState(imageState)
+		imageManager.state.AddImageState(imageState)
+	}
+	return imageManager
+}
+
+func TestRemoveUnusedImagesUsesHardDeletionAge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{}).AnyTimes()
+	imageManager := newImageManagerWithDeletionAges(client)
+
+	client.EXPECT().RemoveImage(gomock.Any(), "hours-old", dockerclient.RemoveImageTimeout).Return(nil)
+
+	stats := imageManager.removeUnusedImages(context.TODO())
+
+	assert.Equal(t, []string{"sha256:hours-old"}, stats.RemovedImageIDs)
+	assert.Equal(t, 2, stats.SkipReasons[image.CleanupSkipReasonTooRecent])
+}
+
+func TestReclaimTrackedImageStatesUsesSoftDeletionAge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{}).AnyTimes()
+	imageManager := newImageManagerWithDeletionAges(client)
+
+	gomock.InOrder(
+		client.EXPECT().RemoveImage(gomock.Any(), "hours-old", dockerclient.RemoveImageTimeout).Return(nil),
+		client.EXPECT().RemoveImage(gomock.Any(), "minutes-old", dockerclient.RemoveImageTimeout).Return(nil),
+	)
+
+	stats := imageManager.reclaimTrackedImageStates(context.TODO())
+
+	assert.Equal(t, []string{"sha256:hours-old", "sha256:minutes-old"}, stats.RemovedImageIDs)
+	assert.Equal(t, 1, stats.SkipReasons[image.CleanupSkipReasonTooRecent])
+	assert.Equal(t, 1, imageManager.GetImageStatesCount())
+
+	// Without a soft age, the hard one applies to reclaims too
+	imageManager = newImageManagerWithDeletionAges(client)
+	imageManager.minimumAgeBeforeDeletionSoft = 0
+	client.EXPECT().RemoveImage(gomock.Any(), "hours-old", dockerclient.RemoveImageTimeout).Return(nil)
+
+	stats = imageManager.reclaimTrackedImageStates(context.TODO())
+
+	assert.Equal(t, []string{"sha256:hours-old"}, stats.RemovedImageIDs)
+	assert.Equal(t, 2, imageManager.GetImageStatesCount())
+}
+
+func TestRemoveImageSparesImageUsedAfterSelection(t *testing.T) {
+	testCases := []struct {
+		name      string
+		associate func(imageManager *dockerImageManager, imageState *image.ImageState)
+	}{
+		{
+			name: "container reference",
+			associate: func(imageManager *dockerImageManager, imageState *image.ImageState) {
+				imageState.UpdateImageState(&apicontainer.Container{Name: "container", Image: "image"})
+			},
+		},
+		{
+			name: "task pinning the image",
+			associate: func(imageManager *dockerImageManager, imageState *image.ImageState) {
+				imageManager.state.AddTask(&apitask.Task{
+					Arn:               "taskArn",
+					KnownStatusUnsafe: apitaskstatus.TaskCreated,
+					Containers:        []*apicontainer.Container{{Name: "container", Image: "image"}},
+				})
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := mock_dockerapi.NewMockDockerClient(ctrl)
+
+			imageManager := &dockerImageManager{
+				client:                   client,
+				state:                    dockerstate.NewTaskEngineState(),
+				minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
+				numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
+				imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
+			}
+			imageManager.SetDataClient(data.NewNoopClient())
+			imageState := &image.ImageState{
+				Image:    &image.Image{ImageID: "sha256:image", Names: []string{"image", "image-alias"}},
+				PulledAt: time.Now().AddDate(0, -2, 0),
+			}
+			imageManager.addImageState(imageState)
+			imageManager.state.AddImageState(imageState)
+			imageManager.cleanupStats = image.NewCleanupCycleStats("cycle")
+			imageManager.imageStatesConsideredForDeletion = imageManager.imagesConsiderForDeletion(
+				imageManager.getAllImageStates())
+
+			candidate := imageManager.getUnusedImageForDeletion()
+			require.Equal(t, imageState, candidate)
+			// A task using the image starts between the selection of the image and its removal
+			tc.associate(imageManager, candidate)
+			imageManager.removeImage(context.TODO(), candidate)
+
+			_, ok := imageManager.getImageState("sha256:image")
+			assert.True(t, ok, "the image state should have been kept")
+			assert.Equal(t, []string{"image", "image-alias"}, imageState.Image.Names)
+			assert.Empty(t, imageManager.imageStatesConsideredForDeletion)
+			assert.Equal(t, 1, imageManager.cleanupStats.SkipReasons[image.CleanupSkipReasonInUse])
+		})
+	}
+}
+
+func TestGetImageCleanupEligibility(t *testing.T) {
+	imageManager := &dockerImageManager{
+		state:                     dockerstate.NewTaskEngineState(),
+		minimumAgeBeforeDeletion:  time.Hour,
+		imageCleanupExclusionList: []string{"excluded"},
+	}
+	imageManager.SetDataClient(data.NewNoopClient())
+	lastUsedAt := time.Now().Add(-3 * time.Hour)
+	imageStates := []*image.ImageState{
+		{
+			Image:      &image.Image{ImageID: "sha256:young", Names: []string{"young"}},
+			PulledAt:   time.Now().Add(-20 * time.Minute),
+			LastUsedAt: lastUsedAt.Add(2 * time.Hour),
+		},
+		{
+			Image:      &image.Image{ImageID: "sha256:old", Names: []string{"old"}},
+			PulledAt:   time.Now().Add(-2 * time.Hour),
+			LastUsedAt: lastUsedAt,
+		},
+		{
+			Image:      &image.Image{ImageID: "sha256:inuse", Names: []string{"inuse"}},
+			PulledAt:   time.Now().Add(-2 * time.Hour),
+			LastUsedAt: lastUsedAt.Add(time.Hour),
+			Containers: []*apicontainer.Container{{Name: "container"}},
+		},
+	}
+	for _, imageState := range imageStates {
+		imageManager.addImageState(imageState)
+	}
+
+	t.Run("eligible image", func(t *testing.T) {
+		eligibility, ok := imageManager.GetImageCleanupEligibility("sha256:old")
+		require.True(t, ok)
+		assert.Equal(t, "sha256:old", eligibility.ImageID)
+		assert.True(t, eligibility.Eligible)
+		assert.Zero(t, eligibility.TimeUntilOldEnough)
+		assert.False(t, eligibility.HasAssociatedContainers)
+		assert.Equal(t, 1, eligibility.LRUPosition)
+	})
+	t.Run("too young image", func(t *testing.T) {
+		eligibility, ok := imageManager.GetImageCleanupEligibility("young")
+		require.True(t, ok)
+		assert.Equal(t, "sha256:young", eligibility.ImageID)
+		assert.False(t, eligibility.Eligible)
+		assert.True(t, eligibility.TimeUntilOldEnough > 39*time.Minute && eligibility.TimeUntilOldEnough <= 40*time.Minute,
+			"unexpected time until the image is old enough: %s", eligibility.TimeUntilOldEnough)
+		assert.Equal(t, 3, eligibility.LRUPosition)
+	})
+	t.Run("image with associated containers", func(t *testing.T) {
+		eligibility, ok := imageManager.GetImageCleanupEligibility("inuse")
+		require.True(t, ok)
+		assert.False(t, eligibility.Eligible)
+		assert.True(t, eligibility.HasAssociatedContainers)
+		assert.Equal(t, 2, eligibility.LRUPosition)
+	})
+	t.Run("unknown image", func(t *testing.T) {
+		_, ok := imageManager.GetImageCleanupEligibility("unknown")
+		assert.False(t, ok)
+	})
+}
+
+func TestGetImageCleanupOrder(t *testing.T) {
+	imageManager := &dockerImageManager{
+		state:                    dockerstate.NewTaskEngineState(),
+		minimumAgeBeforeDeletion: time.Hour,
+	}
+	imageManager.SetDataClient(data.NewNoopClient())
+	lastUsedAt := time.Now().Add(-3 * time.Hour)
+	imageStates := []*image.ImageState{
+		{
+			Image:      &image.Image{ImageID: "sha256:young", Names: []string{"young"}},
+			PulledAt:   time.Now().Add(-20 * time.Minute),
+			LastUsedAt: lastUsedAt.Add(2 * time.Hour),
+		},
+		{
+			Image:      &image.Image{ImageID: "sha256:old", Names: []string{"old"}},
+			PulledAt:   time.Now().Add(-2 * time.Hour),
+			LastUsedAt: lastUsedAt,
+		},
+		{
+			Image:      &image.Image{ImageID: "sha256:inuse", Names: []string{"inuse"}},
+			PulledAt:   time.Now().Add(-2 * time.Hour),
+			LastUsedAt: lastUsedAt.Add(time.Hour),
+			Containers: []*apicontainer.Container{{Name: "container"}},
+		},
+		{
+			Image:      &image.Image{ImageID: "sha256:pinned", Names: []string{"pinned"}},
+			PulledAt:   time.Now().Add(-2 * time.Hour),
+			LastUsedAt: lastUsedAt.Add(30 * time.Minute),
+		},
+	}
+	for _, imageState := range imageStates {
+		imageManager.addImageState(imageState)
+	}
+	imageManager.PinImage("pinned", time.Hour)
+
+	order := imageManager.GetImageCleanupOrder()
+	lruImages := imageManager.getLeastRecentlyUsedImages(imageManager.getAllImageStates())
+	require.Len(t, order, len(lruImages))
+	for i, eligibility := range order {
+		assert.Equal(t, lruImages[i].Image.ImageID, eligibility.ImageID)
+		assert.Equal(t, lruImages[i].LastUsedAt, eligibility.LastUsedAt)
+
+		expected, ok := imageManager.GetImageCleanupEligibility(eligibility.ImageID)
+		require.True(t, ok)
+		// the time until the image is old enough keeps going down between the calls
+		expected.TimeUntilOldEnough, eligibility.TimeUntilOldEnough = 0, 0
+		assert.Equal(t, expected, eligibility)
+	}
+	assert.Equal(t, []string{"sha256:old", "sha256:pinned", "sha256:inuse", "sha256:young"},
+		[]string{order[0].ImageID, order[1].ImageID, order[2].ImageID, order[3].ImageID})
+	assert.True(t, order[0].Eligible)
+	assert.True(t, order[1].Pinned)
+	assert.True(t, order[2].HasAssociatedContainers)
+	assert.False(t, order[3].Eligible)
+}
+
+func TestRunImageCleanup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{}).AnyTimes()
+
+	imageManager := &dockerImageManager{
+		client:                   client,
+		state:                    dockerstate.NewTaskEngineState(),
+		minimumAgeBeforeDeletion: config.DefaultImageDeletionAge,
+		numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
+		imageCleanupTimeInterval: config.DefaultImageCleanupTimeInterval,
+	}
+	imageManager.SetDataClient(data.NewNoopClient())
+	imageState := &image.ImageState{
+		Image:    &image.Image{ImageID: "sha256:unused", Names: []string{"unused"}, Size: 1024},
+		PulledAt: time.Now().AddDate(0, -2, 0),
+	}
+	imageManager.addImageState(imageState)
+	imageManager.state.AddImageState(imageState)
+
+	removed := make(chan struct{})
+	client.EXPECT().RemoveImage(gomock.Any(), "unused", dockerclient.RemoveImageTimeout).Do(
+		func(interface{}, interface{}, interface{}) { <-removed }).Return(nil)
+
+	cycleID, err := imageManager.RunImageCleanup(context.TODO())
+	require.NoError(t, err)
+	assert.NotEmpty(t, cycleID)
+	// The cycle runs in the background, and another cycle is not started on demand until it completes
+	_, err = imageManager.RunImageCleanup(context.TODO())
+	assert.Error(t, err)
+	close(removed)
+
+	var history []image.CleanupCycleStats
+	for i := 0; len(history) == 0 && i < 100; i++ {
+		time.Sleep(10 * time.Millisecond)
+		history = imageManager.GetImageCleanupHistory()
+	}
+	require.Len(t, history, 1)
+	assert.Equal(t, cycleID, history[0].ID)
+	assert.Equal(t, []string{"sha256:unused"}, history[0].RemovedImageIDs)
+	assert.Empty(t, imageManager.getAllImageStates())
+}
+
+func TestRunImageCleanupPreferCachedBehavior(t *testing.T) {
+	imageManager := &dockerImageManager{
+		imagePullBehavior: config.ImagePullPreferCachedBehavior,
+	}
+	_, err := imageManager.RunImageCleanup(context.TODO())
+	assert.Error(t, err)
+	assert.Empty(t, imageManager.GetImageCleanupHistory())
+}
+
+// failingImageStateDataClient fails to delete image states until failures reaches zero
+type failingImageStateDataClient struct {
+	data.Client
+	failures int
+}
+
+func (c *failingImageStateDataClient) DeleteImageState(id string) error {
+	if c.failures > 0 {
+		c.failures--
+		return errors.New("delete image state failed")
+	}
+	return c.Client.DeleteImageState(id)
+}
+
+func TestRemoveUnusedImagesDataClientFailures(t *testing.T) {
+	testCases := []struct {
+		name              string
+		failures          int
+		expectedPersisted int
+	}{
+		{
+			name:              "transient failure",
+			failures:          1,
+			expectedPersisted: 0,
+		},
+		{
+			name:              "persistent failure",
+			failures:          imageStateDataRetryAttempts,
+			expectedPersisted: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := mock_dockerapi.NewMockDockerClient(ctrl)
+			client.EXPECT().ListContainers(gomock.Any(), true, dockerclient.ListContainersTimeout).Return(dockerapi.ListContainersResponse{}).AnyTimes()
+			client.EXPECT().ListImages(gomock.Any(), dockerclient.ListImagesTimeout).Return(dockerapi.ListImagesResponse{}).AnyTimes()
+			dataClient, cleanup := newTestDataClient(t)
+			defer cleanup()
+			failingDataClient := &failingImageStateDataClient{Client: dataClient}
+
+			imageManager := &dockerImageManager{
+				client:                   client,
+				state:                    dockerstate.NewTaskEngineState(),
+				minimumAgeBeforeDeletion: time.Hour,
+				numImagesToDelete:        config.DefaultNumImagesToDeletePerCycle,
+			}
+			imageManager.SetDataClient(failingDataClient)
+			pulledAt := time.Now().Add(-2 * time.Hour)
+			imageState := &image.ImageState{
+				Image:      &image.Image{ImageID: "sha256:unused", Names: []string{"unused"}},
+				PulledAt:   pulledAt,
+				LastUsedAt: pulledAt,
+			}
+			imageManager.addImageState(imageState)
+			imageManager.state.AddImageState(imageState)
+			imageManager.saveImageStateData(imageState)
+			client.EXPECT().RemoveImage(gomock.Any(), "unused", dockerclient.RemoveImageTimeout).Return(nil)
+
+			failingDataClient.failures = tc.failures
+			stats := imageManager.removeUnusedImages(context.TODO())
+			assert.Equal(t, []string{"sha256:unused"}, stats.RemovedImageIDs, "cleanup should complete despite data client failures")
+			_, ok := imageManager.getImageState("sha256:unused")
+			assert.False(t, ok, "image state in memory should be removed")
+			persisted, err := dataClient.GetImageStates()
+			require.NoError(t, err)
+			assert.Len(t, persisted, tc.expectedPersisted)
+
+			// The data of the image state is deleted on the next cycle once the data client recovers
+			stats = imageManager.removeUnusedImages(context.TODO())
+			assert.Empty(t, stats.RemovedImageIDs)
+			persisted, err = dataClient.GetImageStates()
+			require.NoError(t, err)
+			assert.Empty(t, persisted)
+			assert.Empty(t, imageManager.pendingImageStateDeletions)
+		})
+	}
+}
@@ -17,18 +17,22 @@ package engine
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/aws/amazon-ecs-agent/agent/api"
 	"github.com/aws/amazon-ecs-agent/agent/api/appnet"
 	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
 	apicontainerstatus "github.com/aws/amazon-ecs-agent/agent/api/container/status"
+	apieni "github.com/aws/amazon-ecs-agent/agent/api/eni"
 	apierrors "github.com/aws/amazon-ecs-agent/agent/api/errors"
 	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
 	apitaskstatus "github.com/aws/amazon-ecs-agent/agent/api/task/status"
@@ -38,10 +42,12 @@ import (
 	"github.com/aws/amazon-ecs-agent/agent/data"
 	"github.com/aws/amazon-ecs-agent/agent/dockerclient"
 	"github.com/aws/amazon-ecs-agent/agent/dockerclient/dockerapi"
+	"github.com/aws/amazon-ecs-agent/agent/dockerclient/dockerauth"
 	"github.com/aws/amazon-ecs-agent/agent/ecscni"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dependencygraph"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
 	"github.com/aws/amazon-ecs-agent/agent/engine/execcmd"
+	"github.com/aws/amazon-ecs-agent/agent/engine/image"
 	"github.com/aws/amazon-ecs-agent/agent/engine/serviceconnect"
 	"github.com/aws/amazon-ecs-agent/agent/eventstream"
 	"github.com/aws/amazon-ecs-agent/agent/logger"
@@ -50,6 +56,7 @@ import (
 	"github.com/aws/amazon-ecs-agent/agent/statechange"
 	"github.com/aws/amazon-ecs-agent/agent/taskresource"
 	"github.com/aws/amazon-ecs-agent/agent/taskresource/credentialspec"
+	"github.com/aws/amazon-ecs-agent/agent/taskresource/envFiles"
 	"github.com/aws/amazon-ecs-agent/agent/taskresource/firelens"
 	"github.com/aws/amazon-ecs-agent/agent/utils"
 	"github.com/aws/amazon-ecs-agent/agent/utils/retry"
@@ -58,6 +65,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/docker/docker/api/types"
 	dockercontainer "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/pkg/errors"
 )
 
@@ -81,9 +89,20 @@ const (
 	maxEngineConnectRetryDelay         = 200 * time.Second
 	engineConnectRetryJitterMultiplier = 0.20
 	engineConnectRetryDelayMultiplier  = 1.5
+	// dockerContainerStatusCreated is the docker status of a container which was created but never started
+	dockerContainerStatusCreated = "created"
+	// maxDockerCPUShares is the maximum CPU shares of a container accepted by docker
+	maxDockerCPUShares = 262144
+	// storageDriverOverlay2 is the docker storage driver able to limit the size of the writable layer of
+	// containers when it is backed by xfs
+	storageDriverOverlay2 = "overlay2"
+	// storageOptSize is the storage option limiting the size of the writable layer of a container
+	storageOptSize = "size"
 	// logDriverTypeFirelens is the log driver type for containers that want to use the firelens container to send logs.
-	logDriverTypeFirelens       = "awsfirelens"
-	logDriverTypeFluentd        = "fluentd"
+	logDriverTypeFirelens = "awsfirelens"
+	logDriverTypeFluentd  = "fluentd"
+	// logDriverTypeNone disables the logs of the container, it is not a log driver of the docker daemon
+	logDriverTypeNone           = "none"
 	logDriverTag                = "tag"
 	logDriverFluentdAddress     = "fluentd-address"
 	dataLogDriverPath           = "/data/firelens/"
@@ -109,10 +128,70 @@ const (
 	stopContainerBackoffJitter     = 0.2
 	stopContainerBackoffMultiplier = 1.3
 	stopContainerMaxRetryCount     = 5
+
+	defaultCreateContainerBackoffMin = time.Second
+	defaultCreateContainerBackoffMax = time.Second * 5
+	createContainerMaxRetryCount     = 3
+
+	// dockerKillSignal is the signal sent to containers still running after their stop timeout
+	dockerKillSignal = "SIGKILL"
+	// containerKilledAfterTimeoutReason is the stopped reason of a task with a container killed after its stop timeout
+	containerKilledAfterTimeoutReason = "ContainerKilledAfterStopTimeout"
+	// networkNamespaceLostReason is the stopped reason of an awsvpc task whose pause container stopped unexpectedly
+	networkNamespaceLostReason = "NetworkNamespaceLost"
+	// spotInterruptionReason is the stopped reason of a task stopped because the instance received a spot
+	// interruption notice
+	spotInterruptionReason = "SpotInterruption"
+	// agentShutdownReason is the stopped reason of a task stopped because the agent was shutting down
+	agentShutdownReason = "AgentShutdown"
+	// imageQuarantinedReason is the stopped reason of a task stopped because the image of one of its containers
+	// was quarantined
+	imageQuarantinedReason = "ImageQuarantined"
+	// imagePullFailedReason is the stopped reason of a task stopped because the image of one of its containers
+	// failed to be pulled, followed by the category of the failure
+	imagePullFailedReason = "CannotPullContainerError"
 )
 
 var newExponentialBackoff = retry.NewExponentialBackoff
 
+// containerExecPollInterval is the interval at which a command exec'd in a container by the agent is
+// inspected while waiting for it to complete
+var containerExecPollInterval = time.Second
+
+// clockProbeResolution is the resolution of the time reported by the clock probe of a container
+const clockProbeResolution = time.Second
+
+const (
+	// dnsLatencyProbeHostname is the name resolved to measure the latency of the DNS resolvers of a task. It is
+	// fully qualified so that the search domains of the task are not appended to it.
+	dnsLatencyProbeHostname = "amazonaws.com."
+	// dnsLatencyProbeTimeout bounds the duration of the resolution made to measure the latency of a resolver
+	dnsLatencyProbeTimeout = 5 * time.Second
+)
+
+// dnsLookuper resolves host names against a DNS resolver
+type dnsLookuper interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+const (
+	// healthPingCacheDuration is how long the result of pinging the docker daemon is reused by health checks
+	healthPingCacheDuration = 5 * time.Second
+	// healthPingTimeout bounds the ping of the docker daemon made by a health check
+	healthPingTimeout = 2 * time.Second
+)
+
+// containerStopPollInterval is the interval at which a container stopped by the agent is inspected while
+// waiting for it to exit
+var containerStopPollInterval = time.Second
+
+// stopContainerKillWait is the time to wait for a container to exit after it has been killed
+var stopContainerKillWait = 30 * time.Second
+
+// shutdownStopPollInterval is the interval at which the tasks stopped on agent shutdown are checked while
+// waiting for them to stop
+var shutdownStopPollInterval = time.Second
+
 // DockerTaskEngine is a state machine for managing a task and its containers
 // in ECS.
 //
@@ -137,6 +216,18 @@ type DockerTaskEngine struct {
 
 	taskStopGroup *utilsync.SequentialWaitGroup
 
+	// taskCleanupSlots bounds the number of stopped tasks cleaned up at the same time, cleanups are not
+	// bounded if it is nil
+	taskCleanupSlots chan struct{}
+
+	// imagePullSlots bounds the number of images pulled at the same time across all tasks, pulls are not
+	// bounded if it is nil
+	imagePullSlots chan struct{}
+
+	// imagePullRateLimiter limits the rate of the image pulls from each registry host, pulls are not limited if
+	// it is nil
+	imagePullRateLimiter *imagePullRateLimiter
+
 	events            <-chan dockerapi.DockerContainerChangeEvent
 	stateChangeEvents chan statechange.Event
 
@@ -145,6 +236,11 @@ type DockerTaskEngine struct {
 	cniClient    ecscni.CNIClient
 	appnetClient api.AppnetClient
 
+	// verifiedCNIPlugins are the CNI plugins found to support the awsvpc network mode, which are not
+	// queried again when validating the networking prerequisites of tasks
+	verifiedCNIPlugins     map[string]struct{}
+	verifiedCNIPluginsLock sync.Mutex
+
 	containerChangeEventStream *eventstream.EventStream
 
 	stopEngine context.CancelFunc
@@ -183,7 +279,29 @@ type DockerTaskEngine struct {
 	monitorExecAgentsInterval time.Duration
 	stopContainerBackoffMin   time.Duration
 	stopContainerBackoffMax   time.Duration
+	createContainerBackoffMin time.Duration
+	createContainerBackoffMax time.Duration
 	namespaceHelper           ecscni.NamespaceHelper
+
+	// drained is set to a non-zero value when the engine has been drained. A drained engine
+	// refuses new tasks while the tasks it already manages continue their lifecycle.
+	drained uint32
+
+	// dockerInfo is the system information of the docker daemon, queried when the engine is initialized
+	// to validate the tasks against the features supported by the daemon
+	dockerInfo     *types.Info
+	dockerInfoLock sync.Mutex
+
+	// quarantinedImages are the IDs and digests of the images that containers are not created from
+	quarantinedImages     map[string]struct{}
+	quarantinedImagesLock sync.RWMutex
+
+	// handlingDockerEvents is set to a non-zero value while the engine processes the events of the docker daemon
+	handlingDockerEvents uint32
+	// lastHealthPingAt and lastHealthPingErr cache the last ping of the docker daemon by a health check
+	lastHealthPingAt  time.Time
+	lastHealthPingErr error
+	healthPingLock    sync.Mutex
 }
 
 // NewDockerTaskEngine returns a created, but uninitialized, DockerTaskEngine.
@@ -227,9 +345,21 @@ func NewDockerTaskEngine(cfg *config.Config,
 		monitorExecAgentsInterval:         defaultMonitorExecAgentsInterval,
 		stopContainerBackoffMin:           defaultStopContainerBackoffMin,
 		stopContainerBackoffMax:           defaultStopContainerBackoffMax,
+		createContainerBackoffMin:         defaultCreateContainerBackoffMin,
+		createContainerBackoffMax:         defaultCreateContainerBackoffMax,
 		namespaceHelper:                   ecscni.NewNamespaceHelper(client),
 	}
 
+	if cfg.TaskCleanupConcurrency > 0 {
+		dockerTaskEngine.taskCleanupSlots = make(chan struct{}, cfg.TaskCleanupConcurrency)
+	}
+
+	if cfg.MaxConcurrentImagePulls > 0 {
+		dockerTaskEngine.imagePullSlots = make(chan struct{}, cfg.MaxConcurrentImagePulls)
+	}
+
+	dockerTaskEngine.imagePullRateLimiter = newImagePullRateLimiter(cfg.ImagePullRateLimit, cfg.ImagePullRateLimitOverrides)
+
 	dockerTaskEngine.initializeContainerStatusToTransitionFunction()
 
 	return dockerTaskEngine
@@ -276,11 +406,21 @@ func (engine *DockerTaskEngine) Init(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	engine.loadQuarantinedImageData()
 	engine.synchronizeState()
+	engine.refreshDockerInfo()
+	// Removing the orphaned containers can take a while, it does not need to hold up the engine
+	go engine.removeOrphanedCreatedContainers()
+	engine.removeLeakedExecAgentLogDirs()
 	// Now catch up and start processing new events per normal
 	go engine.handleDockerEvents(derivedCtx)
 	engine.initialized = true
 	go engine.startPeriodicExecAgentsMonitoring(derivedCtx)
+	go engine.startPeriodicExecAgentsHealthChecks(derivedCtx)
+	go engine.startPeriodicClockDriftChecks(derivedCtx)
+	go engine.startPeriodicPauseContainerChecks(derivedCtx)
+	go engine.startPeriodicDNSLatencyChecks(derivedCtx)
+	go engine.startPeriodicFDUsageChecks(derivedCtx)
 	go engine.watchAppNetImage(derivedCtx)
 	return nil
 }
@@ -354,6 +494,276 @@ func (engine *DockerTaskEngine) monitorExecAgentRunning(ctx context.Context,
 
 }
 
+// startPeriodicExecAgentsHealthChecks periodically checks that the ExecCommandAgent is alive in the containers
+// it was started in, so that an agent that died is reported before it gets restarted
+func (engine *DockerTaskEngine) startPeriodicExecAgentsHealthChecks(ctx context.Context) {
+	runPeriodically(ctx, engine.cfg.ExecAgentHealthCheckInterval, engine.checkExecAgentsHealth)
+}
+
+func (engine *DockerTaskEngine) checkExecAgentsHealth(ctx context.Context) {
+	engine.tasksLock.RLock()
+	defer engine.tasksLock.RUnlock()
+	for _, mTask := range engine.managedTasks {
+		if mTask.Task.GetKnownStatus() != apitaskstatus.TaskRunning {
+			continue
+		}
+		for _, c := range mTask.Task.Containers {
+			if !execcmd.IsExecEnabledContainer(c) || !c.IsRunning() {
+				continue
+			}
+			go engine.checkExecAgentHealth(ctx, mTask, c)
+		}
+	}
+}
+
+func (engine *DockerTaskEngine) checkExecAgentHealth(ctx context.Context, mTask *managedTask, c *apicontainer.Container) {
+	changed, err := engine.execCmdMgr.CheckAgentHealth(ctx, engine.client, mTask.Task, c)
+	if err != nil {
+		logger.Debug("Unable to check the health of the ExecCommandAgent for container", logger.Fields{
+			field.TaskID:    mTask.GetID(),
+			field.Container: c.Name,
+			field.Error:     err,
+		})
+		return
+	}
+	if changed {
+		ma, _ := c.GetManagedAgentByName(execcmd.ExecuteCommandAgentName)
+		mTask.emitManagedAgentEvent(mTask.Task, c, execcmd.ExecuteCommandAgentName, ma.Reason)
+	}
+}
+
+// startPeriodicPauseContainerChecks periodically inspects the pause containers of the running awsvpc tasks,
+// if enabled in the config, so that the loss of the network namespace of a task is detected even if the
+// docker event for it was missed
+func (engine *DockerTaskEngine) startPeriodicPauseContainerChecks(ctx context.Context) {
+	runPeriodically(ctx, engine.cfg.AWSVPCPauseContainerCheckInterval, engine.checkPauseContainers)
+}
+
+func (engine *DockerTaskEngine) checkPauseContainers(ctx context.Context) {
+	engine.tasksLock.RLock()
+	defer engine.tasksLock.RUnlock()
+	for _, mTask := range engine.managedTasks {
+		task := mTask.Task
+		if !task.IsNetworkModeAWSVPC() || task.GetKnownStatus() != apitaskstatus.TaskRunning ||
+			task.GetDesiredStatus().Terminal() {
+			continue
+		}
+		for _, c := range task.Containers {
+			if c.Type == apicontainer.ContainerCNIPause &&
+				c.GetKnownStatus() == apicontainerstatus.ContainerResourcesProvisioned {
+				go engine.checkPauseContainer(ctx, mTask, c)
+			}
+		}
+	}
+}
+
+// checkPauseContainer inspects the pause container of the task and reports it as stopped to the task
+// manager if docker no longer runs it
+func (engine *DockerTaskEngine) checkPauseContainer(ctx context.Context, mTask *managedTask, c *apicontainer.Container) {
+	dockerID := c.GetRuntimeID()
+	if dockerID == "" {
+		return
+	}
+	status, metadata := engine.client.DescribeContainer(ctx, dockerID)
+	if metadata.Error != nil {
+		logger.Debug("Unable to inspect the pause container of the task", logger.Fields{
+			field.TaskID:    mTask.GetID(),
+			field.RuntimeID: dockerID,
+			field.Error:     metadata.Error,
+		})
+		return
+	}
+	if status != apicontainerstatus.ContainerStopped {
+		return
+	}
+	logger.Warn("Pause container of the task is no longer running", logger.Fields{
+		field.TaskID:    mTask.GetID(),
+		field.RuntimeID: dockerID,
+	})
+	mTask.emitDockerContainerChange(dockerContainerChange{
+		container: c,
+		event: dockerapi.DockerContainerChangeEvent{
+			Status:                  apicontainerstatus.ContainerStopped,
+			DockerContainerMetadata: metadata,
+		},
+	})
+}
+
+// startPeriodicDNSLatencyChecks periodically measures the latency of the DNS resolvers of the running awsvpc
+// tasks from within their network namespace, if enabled in the config
+func (engine *DockerTaskEngine) startPeriodicDNSLatencyChecks(ctx context.Context) {
+	runPeriodically(ctx, engine.cfg.DNSLatencyCheckInterval, engine.checkTasksDNSLatency)
+}
+
+func (engine *DockerTaskEngine) checkTasksDNSLatency(ctx context.Context) {
+	engine.tasksLock.RLock()
+	defer engine.tasksLock.RUnlock()
+	for _, mTask := range engine.managedTasks {
+		task := mTask.Task
+		if !task.IsNetworkModeAWSVPC() || task.GetKnownStatus() != apitaskstatus.TaskRunning ||
+			task.GetDesiredStatus().Terminal() {
+			continue
+		}
+		go engine.checkTaskDNSLatency(ctx, task)
+	}
+}
+
+// measureDNSLatency resolves the probe host name against the resolver and returns how long the resolver took
+// to answer
+func measureDNSLatency(ctx context.Context, lookuper dnsLookuper, resolver string, clock ttime.Time) apitask.DNSLatency {
+	ctx, cancel := context.WithTimeout(ctx, dnsLatencyProbeTimeout)
+	defer cancel()
+
+	start := clock.Now()
+	_, err := lookuper.LookupHost(ctx, dnsLatencyProbeHostname)
+	end := clock.Now()
+
+	latency := apitask.DNSLatency{Resolver: resolver, CheckedAt: end}
+	if err != nil {
+		// A resolver which does not know the probe host name still answered
+		if dnsErr, ok := err.(*net.DNSError); !ok || !dnsErr.IsNotFound {
+			latency.Error = err.Error()
+			return latency
+		}
+	}
+	latency.Latency = end.Sub(start)
+	return latency
+}
+
+// startPeriodicFDUsageChecks periodically counts the file descriptors opened by the running containers, if
+// enabled in the config
+func (engine *DockerTaskEngine) startPeriodicFDUsageChecks(ctx context.Context) {
+	runPeriodically(ctx, engine.cfg.ContainerFDCheckInterval, engine.checkContainersFDUsage)
+}
+
+func (engine *DockerTaskEngine) checkContainersFDUsage(ctx context.Context) {
+	engine.tasksLock.RLock()
+	defer engine.tasksLock.RUnlock()
+	for _, mTask := range engine.managedTasks {
+		task := mTask.Task
+		if task.GetKnownStatus() != apitaskstatus.TaskRunning {
+			continue
+		}
+		for _, c := range task.Containers {
+			if c.IsInternal() || !c.IsRunning() {
+				continue
+			}
+			go engine.checkContainerFDUsage(ctx, task, c)
+		}
+	}
+}
+
+// runPeriodically calls fn every interval until the context is cancelled. A non positive interval disables
+// the periodic work and returns right away.
+func runPeriodically(ctx context.Context, interval time.Duration, fn func(context.Context)) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			fn(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// startPeriodicClockDriftChecks periodically compares the clock of the running containers against the
+// host clock, if enabled in the config
+func (engine *DockerTaskEngine) startPeriodicClockDriftChecks(ctx context.Context) {
+	runPeriodically(ctx, engine.cfg.ContainerClockDriftCheckInterval, engine.checkContainersClockDrift)
+}
+
+func (engine *DockerTaskEngine) checkContainersClockDrift(ctx context.Context) {
+	engine.tasksLock.RLock()
+	defer engine.tasksLock.RUnlock()
+	for _, mTask := range engine.managedTasks {
+		task := mTask.Task
+		if task.GetKnownStatus() != apitaskstatus.TaskRunning {
+			continue
+		}
+		for _, c := range task.Containers {
+			if c.IsInternal() || !c.IsRunning() {
+				continue
+			}
+			go engine.checkContainerClockDrift(ctx, task, c)
+		}
+	}
+}
+
+// checkContainerClockDrift execs the clock probe in the container and records the difference between
+// the time it reports and the host time
+func (engine *DockerTaskEngine) checkContainerClockDrift(ctx context.Context, task *apitask.Task,
+	container *apicontainer.Container) {
+	fields := logger.Fields{
+		field.TaskID:    task.GetID(),
+		field.Container: container.Name,
+	}
+	dockerID, err := engine.getDockerID(task, container)
+	if err != nil {
+		logger.Warn("Could not retrieve docker id to check the clock drift of container", fields,
+			logger.Fields{field.Error: err})
+		return
+	}
+	fields[field.RuntimeID] = dockerID
+
+	execRes, err := engine.client.CreateContainerExec(ctx, dockerID,
+		types.ExecConfig{Cmd: clockProbeCommand, AttachStdout: true, Tty: true}, dockerclient.ContainerExecCreateTimeout)
+	if err != nil {
+		logger.Warn("Could not create the clock probe for container", fields, logger.Fields{field.Error: err})
+		return
+	}
+	before := engine.time().Now()
+	output, err := engine.client.AttachContainerExec(ctx, execRes.ID, types.ExecStartCheck{Tty: true},
+		dockerclient.ContainerExecAttachTimeout)
+	after := engine.time().Now()
+	if err != nil {
+		logger.Warn("Could not run the clock probe for container", fields, logger.Fields{field.Error: err})
+		return
+	}
+	containerTime, err := parseClockProbeOutput(output)
+	if err != nil {
+		logger.Warn("Could not parse the output of the clock probe for container", fields,
+			logger.Fields{field.Error: err})
+		return
+	}
+
+	drift := computeClockDrift(containerTime, before, after)
+	container.SetClockDrift(drift, after)
+	if drift != 0 {
+		logger.Info("Clock of container drifted from the host clock", fields, logger.Fields{"drift": drift.String()})
+	}
+}
+
+// parseClockProbeOutput parses the number of seconds since the epoch printed by the clock probe
+func parseClockProbeOutput(output []byte) (time.Time, error) {
+	seconds, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return time.Time{}, errors.Errorf("unexpected clock probe output %q", string(output))
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+// computeClockDrift returns the difference between the time reported by the clock probe of a container
+// and the host time, given the host times right before and after the probe ran. The container time is
+// compared to the midpoint of the probe, and drift within the precision of the measurement, which is half
+// the probe duration plus half the one second resolution of the probe, is reported as zero.
+func computeClockDrift(containerTime, before, after time.Time) time.Duration {
+	// The probe truncates the container time to the second
+	containerTime = containerTime.Add(clockProbeResolution / 2)
+	hostTime := before.Add(after.Sub(before) / 2)
+	drift := containerTime.Sub(hostTime)
+
+	precision := after.Sub(before)/2 + clockProbeResolution/2
+	if drift <= precision && drift >= -precision {
+		return 0
+	}
+	return drift
+}
+
 // MustInit blocks and retries until an engine can be initialized.
 func (engine *DockerTaskEngine) MustInit(ctx context.Context) {
 	if engine.initialized {
@@ -471,6 +881,46 @@ func (engine *DockerTaskEngine) synchronizeState() {
 	}
 }
 
+// removeOrphanedCreatedContainers removes the containers created by the agent which were never started and
+// which do not belong to any known task. Such containers are left behind when the agent stops between
+// creating and starting a container, and their references to their image block image cleanup. Containers
+// of known tasks are left alone, as these tasks may still be launching them.
+func (engine *DockerTaskEngine) removeOrphanedCreatedContainers() {
+	// Only list the created containers of tasks, so that no container needs to be inspected
+	listContainersResponse := engine.client.ListContainersWithFilters(engine.ctx, filters.NewArgs(
+		filters.Arg("status", dockerContainerStatusCreated),
+		filters.Arg("label", labelTaskARN),
+	), dockerclient.ListContainersTimeout)
+	if listContainersResponse.Error != nil {
+		logger.Warn("Unable to list containers; orphaned created containers will not be removed", logger.Fields{
+			field.Error: listContainersResponse.Error,
+		})
+		return
+	}
+	for _, dockerID := range listContainersResponse.DockerIDs {
+		if _, ok := engine.state.ContainerByID(dockerID); ok {
+			continue
+		}
+		labels := listContainersResponse.Labels[dockerID]
+		taskARN := labels[labelTaskARN]
+		if _, ok := engine.state.TaskByArn(taskARN); ok {
+			continue
+		}
+		logger.Info("Removing orphaned created container", logger.Fields{
+			field.TaskARN:   taskARN,
+			field.Container: labels[labelContainerName],
+			field.DockerId:  dockerID,
+		})
+		if err := engine.client.RemoveContainer(engine.ctx, dockerID, dockerclient.RemoveContainerTimeout); err != nil {
+			logger.Warn("Unable to remove orphaned created container", logger.Fields{
+				field.TaskARN:  taskARN,
+				field.DockerId: dockerID,
+				field.Error:    err,
+			})
+		}
+	}
+}
+
 // filterTasksToStartUnsafe filters only the tasks that need to be started after
 // the agent has been restarted. It also synchronizes states of all of the containers
 // in tasks that need to be started.
@@ -512,6 +962,10 @@ func updateContainerMetadata(metadata *dockerapi.DockerContainerMetadata, contai
 		container.SetLabels(metadata.Labels)
 	}
 
+	if metadata.StopSignal != "" {
+		container.SetKnownStopSignal(metadata.StopSignal)
+	}
+
 	// Update volume for empty volume container
 	if metadata.Volumes != nil {
 		if container.IsInternal() {
@@ -582,7 +1036,7 @@ func (engine *DockerTaskEngine) synchronizeContainerStatus(container *apicontain
 			container.Container.SetKnownStatus(dockerapi.DockerStateToState(describedContainer.State))
 			// update mappings that need dockerid
 			engine.state.AddContainer(container, task)
-			err := engine.imageManager.RecordContainerReference(container.Container)
+			err := engine.recordContainerReference(task, container.Container)
 			if err != nil {
 				logger.Warn("Unable to add container reference to image state", logger.Fields{
 					field.TaskID:    task.GetID(),
@@ -607,7 +1061,7 @@ func (engine *DockerTaskEngine) synchronizeContainerStatus(container *apicontain
 			})
 			if !container.Container.KnownTerminal() {
 				container.Container.ApplyingError = apierrors.NewNamedError(&ContainerVanishedError{})
-				err := engine.imageManager.RemoveContainerReferenceFromImageState(container.Container)
+				err := engine.removeContainerReference(task, container.Container)
 				if err != nil {
 					logger.Warn("Could not remove container reference from image state", logger.Fields{
 						field.TaskID:    task.GetID(),
@@ -625,7 +1079,7 @@ func (engine *DockerTaskEngine) synchronizeContainerStatus(container *apicontain
 	} else {
 		// update the container metadata in case the container status/metadata changed during agent restart
 		updateContainerMetadata(&metadata, container.Container, task)
-		err := engine.imageManager.RecordContainerReference(container.Container)
+		err := engine.recordContainerReference(task, container.Container)
 		if err != nil {
 			logger.Warn("Unable to add container reference to image state", logger.Fields{
 				field.TaskID:    task.GetID(),
@@ -686,7 +1140,7 @@ func (engine *DockerTaskEngine) sweepTask(task *apitask.Task) {
 		if cont.IsInternal() {
 			continue
 		}
-		err = engine.imageManager.RemoveContainerReferenceFromImageState(cont)
+		err = engine.removeContainerReference(task, cont)
 		if err != nil {
 			logger.Error("Unable to remove container reference from image state", logger.Fields{
 				field.TaskID:    task.GetID(),
@@ -708,6 +1162,17 @@ func (engine *DockerTaskEngine) sweepTask(task *apitask.Task) {
 	}
 }
 
+// runTaskCleanup runs the cleanup of a stopped task once fewer than the configured number of task cleanups
+// are running. The state shared between cleanups is protected by the locks of the engine, the image manager
+// and the data client.
+func (engine *DockerTaskEngine) runTaskCleanup(cleanup func()) {
+	if engine.taskCleanupSlots != nil {
+		engine.taskCleanupSlots <- struct{}{}
+		defer func() { <-engine.taskCleanupSlots }()
+	}
+	cleanup()
+}
+
 var removeAll = os.RemoveAll
 
 func (engine *DockerTaskEngine) deleteTask(task *apitask.Task) {
@@ -728,15 +1193,10 @@ func (engine *DockerTaskEngine) deleteTask(task *apitask.Task) {
 	}
 
 	tID := task.GetID()
-	if execcmd.IsExecEnabledTask(task) {
-		// cleanup host exec agent log dirs
-		if err := removeAll(filepath.Join(execcmd.ECSAgentExecLogDir, tID)); err != nil {
-			logger.Warn("Unable to remove ExecAgent host logs for task", logger.Fields{
-				field.TaskID: tID,
-				field.Error:  err,
-			})
-		}
-	}
+	// cleanup host exec agent log dirs. This is keyed by the task ID only, so that the dirs are removed even if
+	// the managed agents of the task were lost
+	engine.removeExecAgentLogDirs(tID)
+	engine.removeManagedScratchDir(tID)
 
 	if task.IsServiceConnectEnabled() {
 		serviceconnectConfig := task.GetServiceConnectRuntimeConfig()
@@ -835,6 +1295,8 @@ func (engine *DockerTaskEngine) openEventstream(ctx context.Context) error {
 // handleDockerEvents must be called after openEventstream; it processes each
 // event that it reads from the docker eventstream
 func (engine *DockerTaskEngine) handleDockerEvents(ctx context.Context) {
+	atomic.StoreUint32(&engine.handlingDockerEvents, 1)
+	defer atomic.StoreUint32(&engine.handlingDockerEvents, 0)
 	for {
 		select {
 		case <-ctx.Done():
@@ -910,6 +1372,10 @@ func (engine *DockerTaskEngine) handleDockerEvent(event dockerapi.DockerContaine
 				"output":        event.DockerContainerMetadata.Health.Output,
 			})
 			cont.Container.SetHealthStatus(event.DockerContainerMetadata.Health)
+			if event.DockerContainerMetadata.Health.Status == apicontainerstatus.ContainerHealthy &&
+				cont.Container.IsStartupCPUBoosted() {
+				go engine.revertStartupCPUBoost(task, cont.Container, cont.DockerID)
+			}
 		}
 		return
 	}
@@ -939,9 +1405,316 @@ func (engine *DockerTaskEngine) StateChangeEvents() chan statechange.Event {
 	return engine.stateChangeEvents
 }
 
+// SetDrained drains or undrains the task engine. While drained, new tasks are refused and
+// stopped right away; tasks that are already managed by the engine are not affected.
+func (engine *DockerTaskEngine) SetDrained(drained bool) {
+	var value uint32
+	if drained {
+		value = 1
+	}
+	if atomic.SwapUint32(&engine.drained, value) == value {
+		return
+	}
+	if drained {
+		logger.Info("Task engine drained; new tasks will be refused")
+	} else {
+		logger.Info("Task engine undrained; accepting new tasks")
+	}
+}
+
+// validateTaskDockerSupport checks that the features requested by the task are supported by the docker
+// daemon, so that the task fails with a clear reason instead of failing to create its containers
+func (engine *DockerTaskEngine) validateTaskDockerSupport(task *apitask.Task) error {
+	if err := engine.validateTaskLogDrivers(task); err != nil {
+		return err
+	}
+	return engine.validateTaskEphemeralStorage(task)
+}
+
+// validateTaskNetworkingPrerequisites checks that the container instance satisfies the prerequisites of the
+// network mode of the task, so that the task fails with a clear reason instead of failing to set up the network
+// namespace of its pause container
+func (engine *DockerTaskEngine) validateTaskNetworkingPrerequisites(task *apitask.Task) error {
+	if !task.IsNetworkModeAWSVPC() {
+		return nil
+	}
+	prerequisiteError := func(reason string) error {
+		return TaskNetworkingPrerequisiteError{taskArn: task.Arn, networkMode: task.NetworkMode, reason: reason}
+	}
+	if !engine.cfg.TaskENIEnabled.Enabled() {
+		return prerequisiteError("task networking is not enabled on the container instance")
+	}
+	eni := task.GetPrimaryENI()
+	if eni == nil {
+		return prerequisiteError("no elastic network interface is attached to the task")
+	}
+	if eni.InterfaceAssociationProtocol == apieni.VLANInterfaceAssociationProtocol &&
+		!engine.cfg.ENITrunkingEnabled.Enabled() {
+		return prerequisiteError("the task uses a trunk branch interface but ENI trunking is not enabled on the container instance")
+	}
+	for _, plugin := range awsvpcCNIPlugins(eni) {
+		if err := engine.verifyCNIPluginSupportsAWSVPC(plugin); err != nil {
+			return prerequisiteError(err.Error())
+		}
+	}
+	return nil
+}
+
+// verifyCNIPluginSupportsAWSVPC checks that the CNI plugin can be queried and supports the awsvpc network mode
+func (engine *DockerTaskEngine) verifyCNIPluginSupportsAWSVPC(plugin string) error {
+	engine.verifiedCNIPluginsLock.Lock()
+	defer engine.verifiedCNIPluginsLock.Unlock()
+	if _, ok := engine.verifiedCNIPlugins[plugin]; ok {
+		return nil
+	}
+	capabilities, err := engine.cniClient.Capabilities(plugin)
+	if err != nil {
+		return errors.Wrapf(err, "unable to query the capabilities of the CNI plugin %s", plugin)
+	}
+	for _, capability := range capabilities {
+		if capability == ecscni.CapabilityAWSVPCNetworkingMode {
+			if engine.verifiedCNIPlugins == nil {
+				engine.verifiedCNIPlugins = make(map[string]struct{})
+			}
+			engine.verifiedCNIPlugins[plugin] = struct{}{}
+			return nil
+		}
+	}
+	return errors.Errorf("the CNI plugin %s does not support the capability %s",
+		plugin, ecscni.CapabilityAWSVPCNetworkingMode)
+}
+
+// validateTaskLogDrivers checks that the log drivers requested by the containers of the task are supported
+// by the docker daemon. The supported log drivers are queried again before rejecting a log driver, as log
+// driver plugins can be installed while the agent runs. The validation is skipped if the supported log
+// drivers are not known.
+func (engine *DockerTaskEngine) validateTaskLogDrivers(task *apitask.Task) error {
+	var supportedLogDrivers map[string]struct{}
+	refreshed := false
+	for _, container := range task.Containers {
+		logDriver := container.GetLogDriver()
+		if logDriver == "" || logDriver == logDriverTypeNone {
+			continue
+		}
+		// The firelens log driver is implemented with the fluentd log driver
+		if logDriver == logDriverTypeFirelens {
+			logDriver = logDriverTypeFluentd
+		}
+		if supportedLogDrivers == nil {
+			info, ok := engine.getDockerInfo()
+			if !ok {
+				logger.Warn("Skipping log driver validation of task", logger.Fields{field.TaskID: task.GetID()})
+				return nil
+			}
+			supportedLogDrivers = getSupportedLogDrivers(info)
+		}
+		if _, ok := supportedLogDrivers[logDriver]; ok {
+			continue
+		}
+		if !refreshed {
+			refreshed = true
+			if info, ok := engine.refreshDockerInfo(); ok {
+				supportedLogDrivers = getSupportedLogDrivers(info)
+				if _, ok := supportedLogDrivers[logDriver]; ok {
+					continue
+				}
+			}
+		}
+		return UnsupportedLogDriverError{taskArn: task.Arn, container: container.Name, logDriver: logDriver}
+	}
+	return nil
+}
+
+// getSupportedLogDrivers returns the set of the log drivers supported by the docker daemon
+func getSupportedLogDrivers(info *types.Info) map[string]struct{} {
+	supportedLogDrivers := make(map[string]struct{}, len(info.Plugins.Log))
+	for _, supportedLogDriver := range info.Plugins.Log {
+		supportedLogDrivers[supportedLogDriver] = struct{}{}
+	}
+	return supportedLogDrivers
+}
+
+// validateTaskEphemeralStorage checks that the storage driver of the docker daemon can limit the size of the
+// writable layer of the containers of a task requesting an ephemeral storage size. If the storage driver
+// cannot be queried, the size is still applied and docker fails the creation of the containers if needed.
+func (engine *DockerTaskEngine) validateTaskEphemeralStorage(task *apitask.Task) error {
+	if task.GetEphemeralStorageSizeGiB() == 0 {
+		return nil
+	}
+	info, ok := engine.getDockerInfo()
+	if !ok {
+		logger.Warn("Skipping ephemeral storage validation of task", logger.Fields{field.TaskID: task.GetID()})
+		return nil
+	}
+	if !isStorageSizeSupported(info) {
+		return UnsupportedEphemeralStorageError{taskArn: task.Arn, storageDriver: info.Driver}
+	}
+	return nil
+}
+
+// isStorageSizeSupported returns true if the storage driver of the docker daemon supports the size storage
+// option, i.e. it is overlay2 backed by xfs. Docker also requires the xfs file system to be mounted with the
+// pquota option, which it does not report; creating the container fails if the option is missing.
+func isStorageSizeSupported(info *types.Info) bool {
+	if info.Driver != storageDriverOverlay2 {
+		return false
+	}
+	for _, status := range info.DriverStatus {
+		if status[0] == "Backing Filesystem" {
+			return status[1] == "xfs"
+		}
+	}
+	return false
+}
+
+// getDockerInfo returns the system information of the docker daemon queried last, without querying the
+// daemon. False is returned if it has not been queried successfully.
+func (engine *DockerTaskEngine) getDockerInfo() (*types.Info, bool) {
+	engine.dockerInfoLock.Lock()
+	defer engine.dockerInfoLock.Unlock()
+	return engine.dockerInfo, engine.dockerInfo != nil
+}
+
+// refreshDockerInfo queries the system information of the docker daemon and caches it. False is returned
+// if it could not be queried, in which case the information queried last is kept.
+func (engine *DockerTaskEngine) refreshDockerInfo() (*types.Info, bool) {
+	info, err := engine.client.Info(engine.ctx, dockerclient.InfoTimeout)
+	if err != nil {
+		logger.Warn("Unable to query the system information of docker", logger.Fields{
+			field.Error: err,
+		})
+		return nil, false
+	}
+	engine.dockerInfoLock.Lock()
+	defer engine.dockerInfoLock.Unlock()
+	engine.dockerInfo = &info
+	return engine.dockerInfo, true
+}
+
+// StopTasksForSpotInterruption gracefully stops the tasks which are to be stopped when the instance receives
+// a spot interruption notice. The tasks are stopped as if the backend had stopped them: the containers of each
+// task are stopped in reverse dependency order, and are given their stop timeout to exit.
+func (engine *DockerTaskEngine) StopTasksForSpotInterruption() {
+	stopByDefault := engine.cfg.SpotInterruptionTaskStopEnabled.Enabled()
+	var tasksToStop []*managedTask
+	engine.tasksLock.RLock()
+	for _, mtask := range engine.managedTasks {
+		// The service connect relay serves the other tasks until they have stopped
+		if mtask.Task == engine.serviceconnectRelay || mtask.GetDesiredStatus().Terminal() {
+			continue
+		}
+		if mtask.ShouldStopOnSpotInterruption(stopByDefault) {
+			tasksToStop = append(tasksToStop, mtask)
+		}
+	}
+	engine.tasksLock.RUnlock()
+
+	for _, mtask := range tasksToStop {
+		logger.Info("Stopping task due to spot interruption", logger.Fields{
+			field.TaskID: mtask.GetID(),
+		})
+		mtask.SetTerminalReason(spotInterruptionReason)
+		mtask.emitACSTransition(acsTransition{desiredStatus: apitaskstatus.TaskStopped})
+	}
+}
+
+// StopTasksForShutdown gracefully stops the tasks managed by the engine before the agent exits, if stopping
+// containers on shutdown is enabled. The tasks are stopped as if the backend had stopped them, and are given up
+// to the shutdown stop timeout to stop. An error is returned if some tasks have not stopped by then.
+func (engine *DockerTaskEngine) StopTasksForShutdown() error {
+	if !engine.cfg.StopContainersOnShutdown.Enabled() {
+		return nil
+	}
+	var tasksToWaitFor []*managedTask
+	engine.tasksLock.RLock()
+	for _, mtask := range engine.managedTasks {
+		// The service connect relay serves the other tasks until they have stopped
+		if mtask.Task == engine.serviceconnectRelay || mtask.GetKnownStatus().Terminal() {
+			continue
+		}
+		tasksToWaitFor = append(tasksToWaitFor, mtask)
+	}
+	engine.tasksLock.RUnlock()
+
+	for _, mtask := range tasksToWaitFor {
+		if mtask.GetDesiredStatus().Terminal() {
+			continue
+		}
+		logger.Info("Stopping task due to agent shutdown", logger.Fields{
+			field.TaskID: mtask.GetID(),
+		})
+		mtask.SetTerminalReason(agentShutdownReason)
+		mtask.emitACSTransition(acsTransition{desiredStatus: apitaskstatus.TaskStopped})
+	}
+
+	deadline := time.Now().Add(engine.cfg.ShutdownStopTimeout)
+	for {
+		var running []*managedTask
+		for _, mtask := range tasksToWaitFor {
+			if !mtask.GetKnownStatus().Terminal() {
+				running = append(running, mtask)
+			}
+		}
+		tasksToWaitFor = running
+		if len(tasksToWaitFor) == 0 {
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			break
+		}
+		select {
+		case <-engine.ctx.Done():
+			return engine.ctx.Err()
+		case <-time.After(shutdownStopPollInterval):
+		}
+	}
+	for _, mtask := range tasksToWaitFor {
+		logger.Warn("Task did not stop before agent shutdown", logger.Fields{
+			field.TaskID:      mtask.GetID(),
+			field.KnownStatus: mtask.GetKnownStatus().String(),
+		})
+	}
+	return errors.Errorf("%d tasks did not stop within %s", len(tasksToWaitFor), engine.cfg.ShutdownStopTimeout)
+}
+
+// CheckHealth returns an error describing why the agent is unhealthy if the engine is not processing the events
+// of the docker daemon, or if the daemon can't be reached. The daemon is pinged at most once every
+// healthPingCacheDuration.
+func (engine *DockerTaskEngine) CheckHealth() error {
+	if atomic.LoadUint32(&engine.handlingDockerEvents) == 0 {
+		return errors.New("task engine is not running")
+	}
+	engine.healthPingLock.Lock()
+	defer engine.healthPingLock.Unlock()
+	if engine.lastHealthPingAt.IsZero() || time.Since(engine.lastHealthPingAt) >= healthPingCacheDuration {
+		engine.lastHealthPingErr = engine.client.SystemPing(engine.ctx, healthPingTimeout).Error
+		engine.lastHealthPingAt = time.Now()
+	}
+	if engine.lastHealthPingErr != nil {
+		return errors.Wrap(engine.lastHealthPingErr, "docker daemon is unreachable")
+	}
+	return nil
+}
+
+// IsDrained returns true if the task engine has been drained
+func (engine *DockerTaskEngine) IsDrained() bool {
+	return atomic.LoadUint32(&engine.drained) != 0
+}
+
 // AddTask starts tracking a task
 func (engine *DockerTaskEngine) AddTask(task *apitask.Task) {
 	defer metrics.MetricsEngineGlobal.RecordTaskEngineMetric("ADD_TASK")()
+	if engine.IsDrained() && task.GetDesiredStatus() != apitaskstatus.TaskStopped {
+		if _, exists := engine.state.TaskByArn(task.Arn); !exists {
+			logger.Warn("Task engine is drained; refusing new task", logger.Fields{
+				field.TaskID: task.GetID(),
+			})
+			task.SetKnownStatus(apitaskstatus.TaskStopped)
+			task.SetDesiredStatus(apitaskstatus.TaskStopped)
+			engine.emitTaskEvent(task, TaskEngineDrainedError{task.Arn}.Error())
+			return
+		}
+	}
 	err := task.PostUnmarshalTask(engine.cfg, engine.credentialsManager,
 		engine.resourceFields, engine.client, engine.ctx)
 	if err != nil {
@@ -955,6 +1728,29 @@ func (engine *DockerTaskEngine) AddTask(task *apitask.Task) {
 		return
 	}
 
+	if _, exists := engine.state.TaskByArn(task.Arn); !exists && !task.GetDesiredStatus().Terminal() {
+		if err := engine.validateTaskDockerSupport(task); err != nil {
+			logger.Error("Task requests a feature not supported by docker; unable to start", logger.Fields{
+				field.TaskID: task.GetID(),
+				field.Error:  err,
+			})
+			task.SetKnownStatus(apitaskstatus.TaskStopped)
+			task.SetDesiredStatus(apitaskstatus.TaskStopped)
+			engine.emitTaskEvent(task, err.Error())
+			return
+		}
+		if err := engine.validateTaskNetworkingPrerequisites(task); err != nil {
+			logger.Error("Networking prerequisites of task are not satisfied; unable to start", logger.Fields{
+				field.TaskID: task.GetID(),
+				field.Error:  err,
+			})
+			task.SetKnownStatus(apitaskstatus.TaskStopped)
+			task.SetDesiredStatus(apitaskstatus.TaskStopped)
+			engine.emitTaskEvent(task, err.Error())
+			return
+		}
+	}
+
 	// Check if ServiceConnect is Needed
 	if task.IsServiceConnectEnabled() {
 		if engine.serviceconnectRelay == nil {
@@ -990,12 +1786,14 @@ func (engine *DockerTaskEngine) AddTask(task *apitask.Task) {
 		if dependencygraph.ValidDependencies(task, engine.cfg) {
 			engine.startTask(task)
 		} else {
+			cycle := dependencygraph.DependencyCycle(task)
 			logger.Error("Task has circular dependencies; unable to start", logger.Fields{
 				field.TaskID: task.GetID(),
+				"cycle":      strings.Join(cycle, ","),
 			})
 			task.SetKnownStatus(apitaskstatus.TaskStopped)
 			task.SetDesiredStatus(apitaskstatus.TaskStopped)
-			err := TaskDependencyError{task.Arn}
+			err := TaskDependencyError{taskArn: task.Arn, cycle: cycle}
 			engine.emitTaskEvent(task, err.Error())
 		}
 		return
@@ -1025,11 +1823,13 @@ func (engine *DockerTaskEngine) pullContainer(task *apitask.Task, container *api
 		return dockerapi.DockerContainerMetadata{}
 	}
 
+	pullDecisionBegin := time.Now()
 	if engine.imagePullRequired(engine.cfg.ImagePullBehavior, container, task.GetID()) {
 		// Record the pullStoppedAt timestamp
 		defer func() {
 			timestamp := engine.time().Now()
 			task.SetPullStoppedAt(timestamp)
+			container.SetImagePullStoppedAt(timestamp)
 		}()
 		logger.Info("Pulling image for container concurrently", logger.Fields{
 			field.TaskID:    task.GetID(),
@@ -1046,9 +1846,12 @@ func (engine *DockerTaskEngine) pullContainer(task *apitask.Task, container *api
 		Container: container,
 	}
 	engine.state.AddPulledContainer(dockerContainer, task)
+	container.SetImagePullCached(true)
+	metrics.MetricsEngineGlobal.RecordImagePull(metrics.ImagePullCacheHit, dockerauth.RegistryHost(container.Image),
+		time.Since(pullDecisionBegin))
 
 	// No pull image is required, just update container reference and use cached image.
-	engine.updateContainerReference(false, container, task.GetID())
+	engine.updateContainerReference(false, container, task)
 	// Return the metadata without any error
 	return dockerapi.DockerContainerMetadata{Error: nil}
 }
@@ -1059,6 +1862,14 @@ func (engine *DockerTaskEngine) pullContainer(task *apitask.Task, container *api
 func (engine *DockerTaskEngine) imagePullRequired(imagePullBehavior config.ImagePullBehaviorType,
 	container *apicontainer.Container,
 	taskId string) bool {
+	if container.ShouldForcePull() {
+		logger.Info("Container opted into pulling its image even if cached", logger.Fields{
+			field.TaskID:    taskId,
+			field.Container: container.Name,
+			field.Image:     container.Image,
+		})
+		return true
+	}
 	switch imagePullBehavior {
 	case config.ImagePullOnceBehavior:
 		// If this image has been pulled successfully before, don't pull the image,
@@ -1100,6 +1911,12 @@ func (engine *DockerTaskEngine) imagePullRequired(imagePullBehavior config.Image
 }
 
 func (engine *DockerTaskEngine) concurrentPull(task *apitask.Task, container *apicontainer.Container) dockerapi.DockerContainerMetadata {
+	releaseImagePullSlots, err := engine.acquireImagePullSlots(task)
+	if err != nil {
+		return dockerapi.DockerContainerMetadata{Error: dockerapi.CannotPullContainerError{FromError: err}}
+	}
+	defer releaseImagePullSlots()
+
 	logger.Debug("Attempting to obtain ImagePullDeleteLock to pull image for container", logger.Fields{
 		field.TaskID:    task.GetID(),
 		field.Container: container.Name,
@@ -1120,6 +1937,7 @@ func (engine *DockerTaskEngine) concurrentPull(task *apitask.Task, container *ap
 
 	// Record the task pull_started_at timestamp
 	pullStart := engine.time().Now()
+	container.SetImagePullStartedAt(pullStart)
 	ok := task.SetPullStartedAt(pullStart)
 	if ok {
 		logger.Info("Recording start time for image pull", logger.Fields{
@@ -1151,6 +1969,60 @@ func (engine *DockerTaskEngine) concurrentPull(task *apitask.Task, container *ap
 	return metadata
 }
 
+// acquireImagePullSlots waits for a slot of the task, then for a slot of the engine, to pull an image of the
+// task. Slots are taken in this order so that a task waiting for a slot of the engine holds a single one. It
+// returns the function releasing the slots, or an error if the task is done while waiting.
+func (engine *DockerTaskEngine) acquireImagePullSlots(task *apitask.Task) (func(), error) {
+	ctx := engine.ctx
+	var taskSlots chan struct{}
+	engine.tasksLock.RLock()
+	if mtask, ok := engine.managedTasks[task.Arn]; ok {
+		ctx = mtask.ctx
+		taskSlots = mtask.imagePullSlots
+	}
+	engine.tasksLock.RUnlock()
+
+	if taskSlots != nil {
+		select {
+		case taskSlots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if engine.imagePullSlots != nil {
+		select {
+		case engine.imagePullSlots <- struct{}{}:
+		case <-ctx.Done():
+			if taskSlots != nil {
+				<-taskSlots
+			}
+			return nil, ctx.Err()
+		}
+	}
+	return func() {
+		if engine.imagePullSlots != nil {
+			<-engine.imagePullSlots
+		}
+		if taskSlots != nil {
+			<-taskSlots
+		}
+	}, nil
+}
+
+// imagePullTimeout returns the timeout for pulling the image, which is the timeout of the longest image name
+// prefix override matching the image if any, or the configured image pull timeout otherwise
+func (engine *DockerTaskEngine) imagePullTimeout(image string) time.Duration {
+	timeout := engine.cfg.ImagePullTimeout
+	matchedPrefixLen := 0
+	for prefix, prefixTimeout := range engine.cfg.ImagePullTimeoutOverrides {
+		if len(prefix) > matchedPrefixLen && strings.HasPrefix(image, prefix) {
+			timeout = prefixTimeout
+			matchedPrefixLen = len(prefix)
+		}
+	}
+	return timeout
+}
+
 func (engine *DockerTaskEngine) pullAndUpdateContainerReference(task *apitask.Task, container *apicontainer.Container) dockerapi.DockerContainerMetadata {
 	// If a task is blocked here for some time, and before it starts pulling image,
 	// the task's desired status is set to stopped, then don't pull the image
@@ -1204,7 +2076,17 @@ func (engine *DockerTaskEngine) pullAndUpdateContainerReference(task *apitask.Ta
 		defer container.SetASMDockerAuthConfig(types.AuthConfig{})
 	}
 
-	metadata := engine.client.PullImage(engine.ctx, container.Image, container.RegistryAuthentication, engine.cfg.ImagePullTimeout)
+	// Pulls beyond the rate limit of the registry wait for their turn
+	if err := engine.imagePullRateLimiter.wait(engine.ctx, container.Image); err != nil {
+		return dockerapi.DockerContainerMetadata{Error: dockerapi.CannotPullContainerError{FromError: err}}
+	}
+
+	pullBegin := time.Now()
+	metadata := engine.client.PullImage(dockerapi.WithPullTaskID(engine.ctx, task.GetID()), container.Image, container.RegistryAuthentication, engine.imagePullTimeout(container.Image))
+	if metadata.Error == nil {
+		metrics.MetricsEngineGlobal.RecordImagePull(metrics.ImagePullDownload, dockerauth.RegistryHost(container.Image),
+			time.Since(pullBegin))
+	}
 
 	// Don't add internal images(created by ecs-agent) into imagemanger state
 	if container.IsInternal() {
@@ -1214,10 +2096,10 @@ func (engine *DockerTaskEngine) pullAndUpdateContainerReference(task *apitask.Ta
 	findCachedImage := false
 	if !pullSucceeded {
 		// If Agent failed to pull an image when
-		// 1. DependentContainersPullUpfront is enabled
-		// 2. ImagePullBehavior is not set to always
+		// 1. UseCachedImageOnPullFailure is enabled, or
+		// 2. DependentContainersPullUpfront is enabled and ImagePullBehavior is not set to always
 		// search the image in local cached images
-		if engine.cfg.DependentContainersPullUpfront.Enabled() && engine.cfg.ImagePullBehavior != config.ImagePullAlwaysBehavior {
+		if engine.useCachedImageOnPullFailure() {
 			if _, err := engine.client.InspectImage(container.Image); err != nil {
 				logger.Error("Failed to find cached image for container", logger.Fields{
 					field.TaskID:    task.GetID(),
@@ -1233,11 +2115,17 @@ func (engine *DockerTaskEngine) pullAndUpdateContainerReference(task *apitask.Ta
 				}
 				return dockerapi.DockerContainerMetadata{Error: metadata.Error}
 			}
-			logger.Info("Found cached image, use it directly for container", logger.Fields{
+			logger.Warn("Image pull failed, using cached image for container", logger.Fields{
 				field.TaskID:    task.GetID(),
 				field.Container: container.Name,
 				field.Image:     container.Image,
+				field.Error:     metadata.Error,
 			})
+			metrics.MetricsEngineGlobal.RecordTaskEngineMetric("PULL_IMAGE_CACHED_FALLBACK")()
+			if container.ApplyingError == nil {
+				// Surface the pull error on the container state changes, the container still starts
+				container.ApplyingError = apierrors.NewNamedError(&ImagePullFallbackToCacheError{pullErr: metadata.Error})
+			}
 			findCachedImage = true
 		}
 	}
@@ -1249,11 +2137,52 @@ func (engine *DockerTaskEngine) pullAndUpdateContainerReference(task *apitask.Ta
 		engine.state.AddPulledContainer(dockerContainer, task)
 	}
 
-	engine.updateContainerReference(pullSucceeded, container, task.GetID())
-	return metadata
+	engine.updateContainerReference(pullSucceeded, container, task)
+	if findCachedImage && engine.cfg.UseCachedImageOnPullFailure.Enabled() {
+		// The pull error is reported on the container instead, so that it proceeds with the cached image
+		// regardless of the image pull behavior
+		return dockerapi.DockerContainerMetadata{}
+	}
+	return metadata
+}
+
+// useCachedImageOnPullFailure returns true if a container uses the cached image of the same reference when its
+// image can't be pulled
+func (engine *DockerTaskEngine) useCachedImageOnPullFailure() bool {
+	if engine.cfg.UseCachedImageOnPullFailure.Enabled() {
+		return true
+	}
+	return engine.cfg.DependentContainersPullUpfront.Enabled() && engine.cfg.ImagePullBehavior != config.ImagePullAlwaysBehavior
+}
+
+// recordContainerReference adds the container to the references of its image, unless the task opted out of
+// image accounting
+func (engine *DockerTaskEngine) recordContainerReference(task *apitask.Task, container *apicontainer.Container) error {
+	if task.IsImageAccountingOptedOut() {
+		return nil
+	}
+	return engine.imageManager.RecordContainerReference(container)
+}
+
+// removeContainerReference removes the container from the references of its image, unless the task opted out
+// of image accounting, in which case the container was never referenced
+func (engine *DockerTaskEngine) removeContainerReference(task *apitask.Task, container *apicontainer.Container) error {
+	if task.IsImageAccountingOptedOut() {
+		return nil
+	}
+	return engine.imageManager.RemoveContainerReferenceFromImageState(container)
 }
 
-func (engine *DockerTaskEngine) updateContainerReference(pullSucceeded bool, container *apicontainer.Container, taskId string) {
+func (engine *DockerTaskEngine) updateContainerReference(pullSucceeded bool, container *apicontainer.Container, task *apitask.Task) {
+	if task.IsImageAccountingOptedOut() {
+		logger.Debug("Task opted out of image accounting, not recording the image of the container", logger.Fields{
+			field.TaskID:    task.GetID(),
+			field.Container: container.Name,
+			field.Image:     container.Image,
+		})
+		return
+	}
+	taskId := task.GetID()
 	err := engine.imageManager.RecordContainerReference(container)
 	if err != nil {
 		logger.Error("Unable to add container reference to image state", logger.Fields{
@@ -1281,11 +2210,54 @@ func (engine *DockerTaskEngine) updateContainerReference(pullSucceeded bool, con
 	engine.state.AddImageState(imageState)
 }
 
+// mergeHostEnvironmentFile adds the variables of the environment file at the given host path to the environment
+// of the container. The variables already set take precedence. The file must be within one of the host
+// environment file directories, once symbolic links are resolved.
+func (engine *DockerTaskEngine) mergeHostEnvironmentFile(container *apicontainer.Container, path string) error {
+	if !filepath.IsAbs(path) {
+		return errors.Errorf("host environment file %s is not an absolute path", path)
+	}
+	resolvedPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return errors.Wrapf(err, "unable to read host environment file %s", path)
+	}
+	allowed := false
+	for _, dir := range engine.cfg.HostEnvironmentFileDirs {
+		if resolvedDir, err := filepath.EvalSymlinks(dir); err == nil {
+			dir = resolvedDir
+		}
+		if rel, err := filepath.Rel(dir, resolvedPath); err == nil && rel != ".." &&
+			!strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return errors.Errorf("host environment file %s is not within the directories allowed by ECS_HOST_ENVIRONMENT_FILE_DIRS", path)
+	}
+	envVars, err := envFiles.ReadEnvVarsFromFile(resolvedPath)
+	if err != nil {
+		return errors.Wrapf(err, "unable to read host environment file %s", path)
+	}
+	return container.MergeEnvironmentVariablesFromEnvfiles([]map[string]string{envVars})
+}
+
 func (engine *DockerTaskEngine) createContainer(task *apitask.Task, container *apicontainer.Container) dockerapi.DockerContainerMetadata {
 	logger.Info("Creating container", logger.Fields{
 		field.TaskID:    task.GetID(),
 		field.Container: container.Name,
 	})
+	if imageRef, quarantined := engine.quarantinedImageOf(container); quarantined {
+		logger.Warn("Refusing to create container from quarantined image", logger.Fields{
+			field.TaskID:    task.GetID(),
+			field.Container: container.Name,
+			field.Image:     imageRef,
+		})
+		return dockerapi.DockerContainerMetadata{
+			Error: ImageQuarantinedError{container: container.Name, imageRef: imageRef},
+		}
+	}
+
 	client := engine.client
 	if container.DockerConfig.Version != nil {
 		client = client.WithVersion(dockerclient.DockerVersion(*container.DockerConfig.Version))
@@ -1332,6 +2304,14 @@ func (engine *DockerTaskEngine) createContainer(task *apitask.Task, container *a
 		}
 	}
 
+	// The ephemeral storage size of the task limits the writable layer of each of its containers
+	if sizeGiB := task.GetEphemeralStorageSizeGiB(); sizeGiB > 0 && !container.IsInternal() {
+		if hostConfig.StorageOpt == nil {
+			hostConfig.StorageOpt = make(map[string]string)
+		}
+		hostConfig.StorageOpt[storageOptSize] = fmt.Sprintf("%dG", sizeGiB)
+	}
+
 	if container.AWSLogAuthExecutionRole() {
 		err := task.ApplyExecutionRoleLogsAuth(hostConfig, engine.credentialsManager)
 		if err != nil {
@@ -1439,6 +2419,30 @@ func (engine *DockerTaskEngine) createContainer(task *apitask.Task, container *a
 		}
 	}
 
+	if hostEnvFile, ok := container.GetHostEnvironmentFile(); ok {
+		if err := engine.mergeHostEnvironmentFile(container, hostEnvFile); err != nil {
+			logger.Error("Error populating environment variables from host environment file into container", logger.Fields{
+				field.TaskID:    task.GetID(),
+				field.Container: container.Name,
+				field.Error:     err,
+			})
+			return dockerapi.DockerContainerMetadata{Error: apierrors.NamedError(
+				&apierrors.DockerClientConfigError{Msg: err.Error()})}
+		}
+	}
+
+	if scratchPath, ok := container.GetManagedScratchPath(); ok {
+		if err := engine.mountManagedScratchDir(task, container, hostConfig, scratchPath); err != nil {
+			logger.Error("Error mounting scratch directory into container", logger.Fields{
+				field.TaskID:    task.GetID(),
+				field.Container: container.Name,
+				field.Error:     err,
+			})
+			return dockerapi.DockerContainerMetadata{Error: apierrors.NamedError(
+				&apierrors.DockerClientConfigError{Msg: err.Error()})}
+		}
+	}
+
 	if execcmd.IsExecEnabledContainer(container) {
 		tID := task.GetID()
 		err := engine.execCmdMgr.InitializeContainer(tID, container, hostConfig)
@@ -1448,6 +2452,11 @@ func (engine *DockerTaskEngine) createContainer(task *apitask.Task, container *a
 				field.Container: container.Name,
 				field.Error:     err,
 			})
+			if engine.cfg.ExecInitFailureWarning.Enabled() && container.ApplyingError == nil {
+				// Surface the failure on the container state changes as well, so that it is visible
+				// without looking at the managed agents. This does not stop the task.
+				container.ApplyingError = apierrors.NewNamedError(&ExecCommandAgentInitFailedError{reason: err.Error()})
+			}
 			// Emit a managedagent state chnage event if exec agent initialization fails
 			engine.tasksLock.RLock()
 			mTask, ok := engine.managedTasks[task.Arn]
@@ -1522,8 +2531,7 @@ func (engine *DockerTaskEngine) createContainer(task *apitask.Task, container *a
 	}
 
 	createContainerBegin := time.Now()
-	metadata := client.CreateContainer(engine.ctx, config, hostConfig,
-		dockerContainerName, engine.cfg.ContainerCreateTimeout)
+	metadata := engine.createDockerContainer(client, config, hostConfig, dockerContainerName, container.Name)
 	if metadata.DockerID != "" {
 		dockerContainer := &apicontainer.DockerContainer{DockerID: metadata.DockerID,
 			DockerName: dockerContainerName,
@@ -1599,6 +2607,13 @@ func (engine *DockerTaskEngine) startContainer(task *apitask.Task, container *ap
 		field.Elapsed:   time.Since(startContainerBegin),
 	})
 
+	engine.applyStartupCPUBoost(task, container, dockerID)
+
+	if healthCheck, firstProbeTimeout, ok := container.GetHealthCheckFirstProbeTimeout(); ok {
+		container.SetHealthCheckFirstProbePending()
+		go engine.runHealthCheckFirstProbe(task, container, dockerID, healthCheck, firstProbeTimeout)
+	}
+
 	// Get metadata through container inspection and available task information then write this to the metadata file
 	// Performs this in the background to avoid delaying container start
 	// TODO: Add a state to the apicontainer.Container for the status of the metadata file (Whether it needs update) and
@@ -2023,6 +3038,11 @@ func (engine *DockerTaskEngine) stopContainer(task *apitask.Task, container *api
 		}
 	}
 
+	// The pre-remove command can only be exec'd while the container is still running
+	if container.IsRunning() {
+		engine.runPreRemoveCommand(task, container, dockerID)
+	}
+
 	// Cleanup the pause container network namespace before stop the container
 	if container.Type == apicontainer.ContainerCNIPause {
 		if task.IsNetworkModeAWSVPC() || (task.IsNetworkModeBridge() && task.IsServiceConnectEnabled()) {
@@ -2042,9 +3062,114 @@ func (engine *DockerTaskEngine) stopContainer(task *apitask.Task, container *api
 		apiTimeoutStopContainer = engine.cfg.DockerStopTimeout
 	}
 
+	if engine.cfg.ContainerStopEscalation.Enabled() {
+		return engine.stopDockerContainerWithEscalation(task, container, dockerID, apiTimeoutStopContainer)
+	}
+	return engine.stopDockerContainer(dockerID, container.Name, apiTimeoutStopContainer)
+}
+
+// stopDockerContainerWithEscalation sends the stop signal of the container and waits for it to exit for the stop
+// timeout. A container still running after the stop timeout is killed with SIGKILL and marked as killed after
+// timeout, which is also reflected in the stopped reason of the task. If the container cannot be signalled, it is
+// stopped through docker instead.
+func (engine *DockerTaskEngine) stopDockerContainerWithEscalation(task *apitask.Task, container *apicontainer.Container,
+	dockerID string, apiTimeoutStopContainer time.Duration) dockerapi.DockerContainerMetadata {
+	fields := logger.Fields{
+		field.TaskID:    task.GetID(),
+		field.Container: container.Name,
+		field.RuntimeID: dockerID,
+	}
+	stopSignal := container.GetStopSignal()
+	err := engine.client.KillContainer(engine.ctx, dockerID, stopSignal, dockerclient.KillContainerTimeout)
+	if err != nil {
+		logger.Warn("Unable to send stop signal to container; stopping it through docker", fields, logger.Fields{
+			"signal":    stopSignal,
+			field.Error: err,
+		})
+		return engine.stopDockerContainer(dockerID, container.Name, apiTimeoutStopContainer)
+	}
+	if md, stopped := engine.waitForDockerContainerStop(dockerID, apiTimeoutStopContainer); stopped {
+		return md
+	}
+
+	logger.Warn("Container still running after stop timeout; killing it", fields, logger.Fields{
+		"signal":  stopSignal,
+		"timeout": apiTimeoutStopContainer.String(),
+	})
+	err = engine.client.KillContainer(engine.ctx, dockerID, dockerKillSignal, dockerclient.KillContainerTimeout)
+	if err != nil {
+		logger.Warn("Unable to kill container; stopping it through docker", fields, logger.Fields{field.Error: err})
+		return engine.stopDockerContainer(dockerID, container.Name, apiTimeoutStopContainer)
+	}
+	container.SetKilledAfterTimeout(true)
+	task.SetTerminalReason(fmt.Sprintf("%s: %s", containerKilledAfterTimeoutReason, container.Name))
+	if md, stopped := engine.waitForDockerContainerStop(dockerID, stopContainerKillWait); stopped {
+		return md
+	}
+	// The container was never seen stopping, let docker stop it so that its result is reported rather than
+	// assuming the container stopped
+	logger.Warn("Container still running after being killed; stopping it through docker", fields, logger.Fields{
+		"timeout": stopContainerKillWait.String(),
+	})
 	return engine.stopDockerContainer(dockerID, container.Name, apiTimeoutStopContainer)
 }
 
+// waitForDockerContainerStop polls the container until it has stopped or the timeout expires. The metadata of the
+// last inspection is returned along with whether the container has stopped.
+func (engine *DockerTaskEngine) waitForDockerContainerStop(dockerID string,
+	timeout time.Duration) (dockerapi.DockerContainerMetadata, bool) {
+	deadline := time.Now().Add(timeout)
+	for {
+		status, md := engine.client.DescribeContainer(engine.ctx, dockerID)
+		if md.Error == nil && status == apicontainerstatus.ContainerStopped {
+			return md, true
+		}
+		if !time.Now().Before(deadline) {
+			return md, false
+		}
+		select {
+		case <-engine.ctx.Done():
+			return md, false
+		case <-time.After(containerStopPollInterval):
+		}
+	}
+}
+
+// createDockerContainer attempts to create the container, retrying only the errors for which docker did not create
+// it, such as the daemon being unreachable. Permanent errors, such as an image that doesn't exist or an invalid
+// configuration, are returned right away. Time outs are not retried either, as the container may have been created
+// anyway and its name would then conflict.
+func (engine *DockerTaskEngine) createDockerContainer(client dockerapi.DockerClient, config *dockercontainer.Config,
+	hostConfig *dockercontainer.HostConfig, dockerContainerName, containerName string) dockerapi.DockerContainerMetadata {
+	var md dockerapi.DockerContainerMetadata
+	backoff := newExponentialBackoff(engine.createContainerBackoffMin, engine.createContainerBackoffMax, stopContainerBackoffJitter, stopContainerBackoffMultiplier)
+	for i := 0; i < createContainerMaxRetryCount; i++ {
+		md = client.CreateContainer(engine.ctx, config, hostConfig, dockerContainerName, engine.cfg.ContainerCreateTimeout)
+		if md.Error == nil {
+			return md
+		}
+		cannotCreateContainerError, ok := md.Error.(dockerapi.CannotCreateContainerError)
+		if !ok || !cannotCreateContainerError.IsRetriableError() {
+			return md
+		}
+
+		if i < createContainerMaxRetryCount-1 {
+			retryIn := backoff.Duration()
+			logger.Warn(fmt.Sprintf("Error creating container, retrying in %v", retryIn), logger.Fields{
+				field.Container: containerName,
+				field.Error:     md.Error,
+				"attempt":       i + 1,
+			})
+			select {
+			case <-engine.ctx.Done():
+				return md
+			case <-time.After(retryIn):
+			}
+		}
+	}
+	return md
+}
+
 // stopDockerContainer attempts to stop the container, retrying only in case of time out errors.
 // If the maximum number of retries is reached, the container is marked as stopped. This is because docker sometimes
 // deadlocks when trying to stop a container but the actual container process is stopped.
@@ -2076,6 +3201,44 @@ func (engine *DockerTaskEngine) stopDockerContainer(dockerID, containerName stri
 	return md
 }
 
+// restartContainer stops and starts again a running container of the task. The container is flagged
+// as restarting while this happens, so that the stopped event generated by docker is not mistaken for
+// the container exiting.
+func (engine *DockerTaskEngine) restartContainer(task *apitask.Task, container *apicontainer.Container) dockerapi.DockerContainerMetadata {
+	logger.Info("Restarting container", logger.Fields{
+		field.TaskID:    task.GetID(),
+		field.Container: container.Name,
+		"restartCount":  container.GetRestartCount(),
+	})
+	dockerID, err := engine.getDockerID(task, container)
+	if err != nil {
+		return dockerapi.DockerContainerMetadata{
+			Error: dockerapi.CannotStopContainerError{
+				FromError: err,
+			},
+		}
+	}
+	container.SetRestarting(true)
+	defer container.SetRestarting(false)
+
+	apiTimeoutStopContainer := container.GetStopTimeout()
+	if apiTimeoutStopContainer <= 0 {
+		apiTimeoutStopContainer = engine.cfg.DockerStopTimeout
+	}
+	if md := engine.stopDockerContainer(dockerID, container.Name, apiTimeoutStopContainer); md.Error != nil {
+		return md
+	}
+	md := engine.client.StartContainer(engine.ctx, dockerID, engine.cfg.ContainerStartTimeout)
+	if md.Error != nil {
+		return md
+	}
+	restartedAt := engine.time().Now()
+	container.SetLastRestartedAt(restartedAt)
+	// Reset the started at timestamp so that the container start timeout applies to the new run
+	container.SetStartedAt(restartedAt)
+	return md
+}
+
 func (engine *DockerTaskEngine) removeContainer(task *apitask.Task, container *apicontainer.Container) error {
 	logger.Info("Removing container", logger.Fields{
 		field.TaskID:    task.GetID(),
@@ -2089,6 +3252,167 @@ func (engine *DockerTaskEngine) removeContainer(task *apitask.Task, container *a
 	return engine.client.RemoveContainer(engine.ctx, dockerID, dockerclient.RemoveContainerTimeout)
 }
 
+// runPreRemoveCommand execs the pre-remove command of the container, if any, and waits for it to
+// complete before the container is stopped. Failures are logged and do not prevent the container from
+// being stopped and removed.
+func (engine *DockerTaskEngine) runPreRemoveCommand(task *apitask.Task, container *apicontainer.Container, dockerID string) {
+	cmd, timeout, ok := container.GetPreRemoveCommand()
+	if !ok {
+		return
+	}
+	fields := logger.Fields{
+		field.TaskID:    task.GetID(),
+		field.Container: container.Name,
+		field.RuntimeID: dockerID,
+		"command":       cmd,
+	}
+	logger.Info("Running pre-remove command for container", fields)
+
+	ctx, cancel := context.WithTimeout(engine.ctx, timeout)
+	defer cancel()
+	inspect, err := engine.execInContainer(ctx, dockerID, cmd)
+	if err != nil {
+		logger.Warn("Pre-remove command for container did not complete; stopping it anyway", fields,
+			logger.Fields{"timeout": timeout.String(), field.Error: err})
+		return
+	}
+	if inspect.ExitCode != 0 {
+		logger.Warn("Pre-remove command for container exited with a non-zero exit code", fields,
+			logger.Fields{"exitCode": inspect.ExitCode})
+		return
+	}
+	logger.Info("Pre-remove command for container completed", fields)
+}
+
+// execInContainer runs the command in the container and waits for it to exit. The context bounds the
+// time spent waiting for the command.
+func (engine *DockerTaskEngine) execInContainer(ctx context.Context, dockerID string,
+	cmd []string) (*types.ContainerExecInspect, error) {
+	execRes, err := engine.client.CreateContainerExec(ctx, dockerID, types.ExecConfig{Cmd: cmd},
+		dockerclient.ContainerExecCreateTimeout)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create exec")
+	}
+	err = engine.client.StartContainerExec(ctx, execRes.ID, types.ExecStartCheck{Detach: true},
+		dockerclient.ContainerExecStartTimeout)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to start exec")
+	}
+
+	ticker := time.NewTicker(containerExecPollInterval)
+	defer ticker.Stop()
+	for {
+		inspect, err := engine.client.InspectContainerExec(ctx, execRes.ID, dockerclient.ContainerExecInspectTimeout)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to inspect exec")
+		}
+		if !inspect.Running {
+			return inspect, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// runHealthCheckFirstProbe waits for the start period of the health check and runs its first probe
+// with the extended timeout. Docker keeps running the health check with the probe timeout meanwhile,
+// but unhealthy statuses it reports are deferred until the first probe completes.
+func (engine *DockerTaskEngine) runHealthCheckFirstProbe(task *apitask.Task, container *apicontainer.Container,
+	dockerID string, healthCheck *dockercontainer.HealthConfig, timeout time.Duration) {
+	fields := logger.Fields{
+		field.TaskID:    task.GetID(),
+		field.Container: container.Name,
+		field.RuntimeID: dockerID,
+	}
+	select {
+	case <-engine.ctx.Done():
+		return
+	case <-time.After(healthCheck.StartPeriod):
+	}
+
+	logger.Info("Running first health check probe for container", fields, logger.Fields{"timeout": timeout.String()})
+	ctx, cancel := context.WithTimeout(engine.ctx, timeout)
+	defer cancel()
+	inspect, err := engine.execInContainer(ctx, dockerID, healthCheckProbeCommand(healthCheck.Test))
+	if err != nil {
+		logger.Warn("First health check probe for container did not complete", fields, logger.Fields{field.Error: err})
+		container.CompleteHealthCheckFirstProbe(nil)
+		return
+	}
+	if inspect.ExitCode != 0 {
+		logger.Warn("First health check probe for container failed", fields, logger.Fields{"exitCode": inspect.ExitCode})
+		container.CompleteHealthCheckFirstProbe(nil)
+		return
+	}
+	logger.Info("First health check probe for container succeeded", fields)
+	container.CompleteHealthCheckFirstProbe(&apicontainer.HealthStatus{Status: apicontainerstatus.ContainerHealthy})
+	engine.revertStartupCPUBoost(task, container, dockerID)
+}
+
+// applyStartupCPUBoost multiplies the CPU shares of a container that was just started by the startup CPU
+// boost of its task. Only containers with a health check are boosted, as the boost lasts until the
+// container becomes healthy.
+func (engine *DockerTaskEngine) applyStartupCPUBoost(task *apitask.Task, container *apicontainer.Container, dockerID string) {
+	boost := task.GetStartupCPUBoost()
+	if boost < 2 || container.IsInternal() || !container.HealthStatusShouldBeReported() {
+		return
+	}
+	cpuShares := task.GetContainerCPUShares(container)
+	if cpuShares <= 0 {
+		return
+	}
+	boostedCPUShares := cpuShares * int64(boost)
+	if boostedCPUShares > maxDockerCPUShares {
+		boostedCPUShares = maxDockerCPUShares
+	}
+	fields := logger.Fields{
+		field.TaskID:    task.GetID(),
+		field.Container: container.Name,
+		field.RuntimeID: dockerID,
+		"cpuShares":     boostedCPUShares,
+	}
+	err := engine.client.UpdateContainer(engine.ctx, dockerID, dockercontainer.Resources{CPUShares: boostedCPUShares},
+		dockerclient.UpdateContainerTimeout)
+	if err != nil {
+		logger.Warn("Unable to apply the startup CPU boost of container", fields, logger.Fields{field.Error: err})
+		return
+	}
+	container.SetStartupCPUBoosted()
+	logger.Info("Applied the startup CPU boost of container until it becomes healthy", fields)
+}
+
+// revertStartupCPUBoost restores the CPU shares of a container which became healthy, if they were boosted
+func (engine *DockerTaskEngine) revertStartupCPUBoost(task *apitask.Task, container *apicontainer.Container, dockerID string) {
+	if !container.CompleteStartupCPUBoost() {
+		return
+	}
+	cpuShares := task.GetContainerCPUShares(container)
+	fields := logger.Fields{
+		field.TaskID:    task.GetID(),
+		field.Container: container.Name,
+		field.RuntimeID: dockerID,
+		"cpuShares":     cpuShares,
+	}
+	err := engine.client.UpdateContainer(engine.ctx, dockerID, dockercontainer.Resources{CPUShares: cpuShares},
+		dockerclient.UpdateContainerTimeout)
+	if err != nil {
+		logger.Warn("Unable to revert the startup CPU boost of container", fields, logger.Fields{field.Error: err})
+		return
+	}
+	logger.Info("Reverted the startup CPU boost of container", fields)
+}
+
+// healthCheckProbeCommand converts the test of a docker health check into the command to exec
+func healthCheckProbeCommand(test []string) []string {
+	if test[0] == "CMD-SHELL" {
+		return append(append([]string{}, healthCheckShell...), strings.Join(test[1:], " "))
+	}
+	return test[1:]
+}
+
 // updateTaskUnsafe determines if a new transition needs to be applied to the
 // referenced task, and if needed applies it. It should not be called anywhere
 // but from 'AddTask' and is protected by the tasksLock lock there.
@@ -2192,6 +3516,196 @@ func (engine *DockerTaskEngine) State() dockerstate.TaskEngineState {
 	return engine.state
 }
 
+// ImageCleanupHistory returns the statistics of the most recent image cleanup cycles, oldest first
+func (engine *DockerTaskEngine) ImageCleanupHistory() []image.CleanupCycleStats {
+	if engine.imageManager == nil {
+		return nil
+	}
+	return engine.imageManager.GetImageCleanupHistory()
+}
+
+// ImageCleanupEligibility returns how far the image of the given ID or name is from being removed by image
+// cleanup. It returns false if the image is not tracked.
+func (engine *DockerTaskEngine) ImageCleanupEligibility(imageRef string) (image.CleanupEligibility, bool) {
+	if engine.imageManager == nil {
+		return image.CleanupEligibility{}, false
+	}
+	return engine.imageManager.GetImageCleanupEligibility(imageRef)
+}
+
+// ImageCleanupOrder returns how far each tracked image is from being removed by image cleanup, from the least
+// recently used one
+func (engine *DockerTaskEngine) ImageCleanupOrder() []image.CleanupEligibility {
+	if engine.imageManager == nil {
+		return nil
+	}
+	return engine.imageManager.GetImageCleanupOrder()
+}
+
+// maxImagePinTTL is the longest an image can be pinned for at once, so that a pin left behind by deployment
+// tooling does not keep an image from being cleaned up indefinitely
+const maxImagePinTTL = 24 * time.Hour
+
+// PinImage keeps the image of the given ID or name from being removed by image cleanup for the given duration,
+// which must be positive and at most 24 hours. It returns when the pin expires.
+func (engine *DockerTaskEngine) PinImage(imageRef string, ttl time.Duration) (time.Time, error) {
+	if engine.imageManager == nil {
+		return time.Time{}, errors.New("image cleanup is disabled")
+	}
+	if imageRef == "" {
+		return time.Time{}, errors.New("empty image reference")
+	}
+	if ttl <= 0 || ttl > maxImagePinTTL {
+		return time.Time{}, errors.Errorf("invalid pin duration %s, expected a positive duration of at most %s",
+			ttl, maxImagePinTTL)
+	}
+	return engine.imageManager.PinImage(imageRef, ttl), nil
+}
+
+// UnpinImage lifts the pin of the image of the given ID or name. It returns false if the image is not pinned.
+func (engine *DockerTaskEngine) UnpinImage(imageRef string) bool {
+	if engine.imageManager == nil {
+		return false
+	}
+	return engine.imageManager.UnpinImage(imageRef)
+}
+
+// PinnedImages returns the IDs and names of the pinned images mapped to when their pin expires
+func (engine *DockerTaskEngine) PinnedImages() map[string]time.Time {
+	if engine.imageManager == nil {
+		return nil
+	}
+	return engine.imageManager.GetPinnedImages()
+}
+
+// imageDigestPattern matches the image IDs and digests that images are quarantined by
+var imageDigestPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// QuarantineImage quarantines the image of the given ID or digest, which may be qualified with a repository, e.g.
+// repo@sha256:<digest>. Containers are no longer created from the image and, if enabled, the tasks with a
+// container created from it are stopped. The ARNs of the stopped tasks are returned.
+func (engine *DockerTaskEngine) QuarantineImage(imageRef string) ([]string, error) {
+	imageRef, err := normalizeQuarantinedImageRef(imageRef)
+	if err != nil {
+		return nil, err
+	}
+	engine.quarantinedImagesLock.Lock()
+	if engine.quarantinedImages == nil {
+		engine.quarantinedImages = make(map[string]struct{})
+	}
+	engine.quarantinedImages[imageRef] = struct{}{}
+	engine.saveQuarantinedImageData()
+	engine.quarantinedImagesLock.Unlock()
+	logger.Warn("Image quarantined; containers will not be created from it", logger.Fields{
+		field.Image: imageRef,
+	})
+
+	if !engine.cfg.ImageQuarantineStopTasks.Enabled() {
+		return nil, nil
+	}
+	var tasksToStop []*managedTask
+	var stoppedTaskArns []string
+	engine.tasksLock.RLock()
+	for _, mtask := range engine.managedTasks {
+		if mtask.GetDesiredStatus().Terminal() {
+			continue
+		}
+		for _, container := range mtask.Containers {
+			if container.ImageID == imageRef || container.GetImageDigest() == imageRef {
+				tasksToStop = append(tasksToStop, mtask)
+				stoppedTaskArns = append(stoppedTaskArns, mtask.Arn)
+				break
+			}
+		}
+	}
+	engine.tasksLock.RUnlock()
+
+	for _, mtask := range tasksToStop {
+		logger.Warn("Stopping task due to quarantined image", logger.Fields{
+			field.TaskID: mtask.GetID(),
+			field.Image:  imageRef,
+		})
+		mtask.SetTerminalReason(fmt.Sprintf("%s: %s", imageQuarantinedReason, imageRef))
+		mtask.emitACSTransition(acsTransition{desiredStatus: apitaskstatus.TaskStopped})
+	}
+	sort.Strings(stoppedTaskArns)
+	return stoppedTaskArns, nil
+}
+
+// UnquarantineImage lifts the quarantine of the image of the given ID or digest
+func (engine *DockerTaskEngine) UnquarantineImage(imageRef string) error {
+	imageRef, err := normalizeQuarantinedImageRef(imageRef)
+	if err != nil {
+		return err
+	}
+	engine.quarantinedImagesLock.Lock()
+	defer engine.quarantinedImagesLock.Unlock()
+	if _, ok := engine.quarantinedImages[imageRef]; ok {
+		delete(engine.quarantinedImages, imageRef)
+		engine.saveQuarantinedImageData()
+		logger.Info("Image quarantine lifted", logger.Fields{
+			field.Image: imageRef,
+		})
+	}
+	return nil
+}
+
+// QuarantinedImages returns the sorted IDs and digests of the quarantined images
+func (engine *DockerTaskEngine) QuarantinedImages() []string {
+	engine.quarantinedImagesLock.RLock()
+	defer engine.quarantinedImagesLock.RUnlock()
+	imageRefs := make([]string, 0, len(engine.quarantinedImages))
+	for imageRef := range engine.quarantinedImages {
+		imageRefs = append(imageRefs, imageRef)
+	}
+	sort.Strings(imageRefs)
+	return imageRefs
+}
+
+// quarantinedImageOf returns the ID or the digest of the image of the container if the image is quarantined
+func (engine *DockerTaskEngine) quarantinedImageOf(container *apicontainer.Container) (string, bool) {
+	engine.quarantinedImagesLock.RLock()
+	defer engine.quarantinedImagesLock.RUnlock()
+	for _, imageRef := range []string{container.ImageID, container.GetImageDigest()} {
+		if _, ok := engine.quarantinedImages[imageRef]; ok && imageRef != "" {
+			return imageRef, true
+		}
+	}
+	return "", false
+}
+
+// normalizeQuarantinedImageRef strips the repository of an image digest and validates that the image is
+// referred to by its ID or digest
+func normalizeQuarantinedImageRef(imageRef string) (string, error) {
+	if i := strings.LastIndex(imageRef, "@"); i >= 0 {
+		imageRef = imageRef[i+1:]
+	}
+	if !imageDigestPattern.MatchString(imageRef) {
+		return "", errors.Errorf("invalid image reference %q, expected an image ID or digest of the form sha256:<hex>", imageRef)
+	}
+	return imageRef, nil
+}
+
+// ContainerLogs returns the last lines of the stdout and stderr of the container of the given docker ID, up to
+// tail lines
+func (engine *DockerTaskEngine) ContainerLogs(dockerID string, tail int) ([]byte, error) {
+	return engine.client.ContainerLogs(engine.ctx, dockerID, tail, dockerclient.ContainerLogsTimeout)
+}
+
+// RunImageCleanup starts an image cleanup cycle right away in the background on behalf of the request of the
+// given context, and returns the ID of the cycle, under which its statistics are added to the cleanup history.
+// The cycle is not started if the request is already done. As the cycle may take minutes it outlives the
+// request, and runs until it completes or the engine stops.
+func (engine *DockerTaskEngine) RunImageCleanup(ctx context.Context) (string, error) {
+	if engine.imageManager == nil || engine.cfg.ImageCleanupDisabled.Enabled() {
+		return "", errors.New("image cleanup is disabled")
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return engine.imageManager.RunImageCleanup(engine.ctx)
+}
+
 // Version returns the underlying docker version.
 func (engine *DockerTaskEngine) Version() (string, error) {
 	return engine.client.Version(engine.ctx, dockerclient.VersionTimeout)
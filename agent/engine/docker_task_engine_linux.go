@@ -17,19 +17,30 @@
 package engine
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/containernetworking/plugins/pkg/ns"
 	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
 
 	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
+	apieni "github.com/aws/amazon-ecs-agent/agent/api/eni"
 	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
 	apitaskstatus "github.com/aws/amazon-ecs-agent/agent/api/task/status"
+	"github.com/aws/amazon-ecs-agent/agent/ecscni"
 	"github.com/aws/amazon-ecs-agent/agent/logger"
 	"github.com/aws/amazon-ecs-agent/agent/logger/field"
 	"github.com/aws/amazon-ecs-agent/agent/utils"
+	"github.com/aws/amazon-ecs-agent/agent/utils/nswrapper"
 	dockercontainer "github.com/docker/docker/api/types/container"
 )
 
@@ -40,8 +51,32 @@ const (
 
 	defaultKerberosTicketBindPath = "/var/credentials-fetcher/krbdir"
 	readOnly                      = ":ro"
+
+	// resolvConfPathFormat is the path of the resolver configuration of the container with the given pid
+	resolvConfPathFormat = "/host/proc/%s/root/etc/resolv.conf"
+
+	// procFDDirFormat is the directory of the file descriptors opened by the process with the given pid
+	procFDDirFormat = "/host/proc/%s/fd"
+	// procLimitsFileFormat is the file of the resource limits of the process with the given pid
+	procLimitsFileFormat = "/host/proc/%s/limits"
+	// nofileLimitName is the name of the nofile limit in the resource limits of a process
+	nofileLimitName = "Max open files"
 )
 
+// healthCheckShell is the shell used to run CMD-SHELL health check probes
+var healthCheckShell = []string{"/bin/sh", "-c"}
+
+// clockProbeCommand prints the current time of the container in seconds since the epoch
+var clockProbeCommand = []string{"date", "-u", "+%s"}
+
+// awsvpcCNIPlugins returns the CNI plugins used to set up the network namespace of an awsvpc task with the ENI
+func awsvpcCNIPlugins(eni *apieni.ENI) []string {
+	if eni.InterfaceAssociationProtocol == apieni.VLANInterfaceAssociationProtocol {
+		return []string{ecscni.ECSBranchENIPluginName, ecscni.ECSBridgePluginName, ecscni.ECSIPAMPluginName}
+	}
+	return []string{ecscni.ECSENIPluginName, ecscni.ECSBridgePluginName, ecscni.ECSIPAMPluginName}
+}
+
 // updateTaskENIDependencies updates the task's dependencies for awsvpc networking mode.
 // This method is used only on Windows platform.
 func (engine *DockerTaskEngine) updateTaskENIDependencies(task *apitask.Task) {
@@ -154,3 +189,163 @@ func (engine *DockerTaskEngine) updateCredentialSpecMapping(taskID string, conta
 		}
 	}
 }
+
+// checkTaskDNSLatency measures the latency of each of the DNS resolvers of the task from within its network
+// namespace, and records them in the task
+func (engine *DockerTaskEngine) checkTaskDNSLatency(ctx context.Context, task *apitask.Task) {
+	fields := logger.Fields{field.TaskID: task.GetID()}
+	var pauseContainer *apicontainer.Container
+	for _, c := range task.Containers {
+		if c.Type == apicontainer.ContainerCNIPause {
+			pauseContainer = c
+		}
+	}
+	if pauseContainer == nil {
+		return
+	}
+	containerInspectOutput, err := engine.inspectContainer(task, pauseContainer)
+	if err != nil {
+		logger.Debug("Unable to inspect the pause container of the task to check DNS latency", fields,
+			logger.Fields{field.Error: err})
+		return
+	}
+	pid := strconv.Itoa(containerInspectOutput.State.Pid)
+
+	var resolvers []string
+	if eni := task.GetPrimaryENI(); eni != nil {
+		resolvers = eni.DomainNameServers
+	}
+	if len(resolvers) == 0 {
+		resolvConf, err := ioutil.ReadFile(fmt.Sprintf(resolvConfPathFormat, pid))
+		if err != nil {
+			logger.Debug("Unable to read the DNS resolvers of the task to check DNS latency", fields,
+				logger.Fields{field.Error: err})
+			return
+		}
+		resolvers = parseResolvConfNameservers(resolvConf)
+	}
+
+	netNSPath := fmt.Sprintf(ecscni.NetnsFormat, pid)
+	var latencies []apitask.DNSLatency
+	for _, resolver := range resolvers {
+		latency := measureDNSLatency(ctx, newNetNSDNSResolver(netNSPath, resolver), resolver, engine.time())
+		if latency.Error != "" {
+			logger.Warn("DNS resolver of the task did not answer", fields, logger.Fields{
+				"resolver":  resolver,
+				field.Error: latency.Error,
+			})
+		}
+		latencies = append(latencies, latency)
+	}
+	task.SetDNSLatencies(latencies)
+}
+
+// newNetNSDNSResolver returns a resolver which sends its queries to the DNS resolver from within the network
+// namespace. Sockets stay in the network namespace they are created in, so only dialing has to happen in it.
+func newNetNSDNSResolver(netNSPath, resolver string) dnsLookuper {
+	nsWrapper := nswrapper.NewNS()
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var conn net.Conn
+			err := nsWrapper.WithNetNSPath(netNSPath, func(ns.NetNS) error {
+				var dialErr error
+				conn, dialErr = (&net.Dialer{}).DialContext(ctx, network, net.JoinHostPort(resolver, "53"))
+				return dialErr
+			})
+			return conn, err
+		},
+	}
+}
+
+// parseResolvConfNameservers returns the addresses of the nameservers of a resolv.conf file
+func parseResolvConfNameservers(resolvConf []byte) []string {
+	var nameservers []string
+	scanner := bufio.NewScanner(bytes.NewReader(resolvConf))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			nameservers = append(nameservers, fields[1])
+		}
+	}
+	return nameservers
+}
+
+// checkContainerFDUsage counts the file descriptors opened by the main process of the container and warns if
+// the count steadily grows toward its nofile limit
+func (engine *DockerTaskEngine) checkContainerFDUsage(ctx context.Context, task *apitask.Task,
+	container *apicontainer.Container) {
+	fields := logger.Fields{
+		field.TaskID:    task.GetID(),
+		field.Container: container.Name,
+	}
+	containerInspectOutput, err := engine.inspectContainer(task, container)
+	if err != nil {
+		logger.Debug("Unable to inspect the container to count its file descriptors", fields,
+			logger.Fields{field.Error: err})
+		return
+	}
+	if containerInspectOutput.State == nil || containerInspectOutput.State.Pid == 0 {
+		return
+	}
+	pid := strconv.Itoa(containerInspectOutput.State.Pid)
+
+	openFDs, err := countOpenFDs(fmt.Sprintf(procFDDirFormat, pid))
+	if err != nil {
+		logger.Debug("Unable to count the file descriptors of the container", fields, logger.Fields{field.Error: err})
+		return
+	}
+	limits, err := ioutil.ReadFile(fmt.Sprintf(procLimitsFileFormat, pid))
+	if err != nil {
+		logger.Debug("Unable to read the resource limits of the container", fields, logger.Fields{field.Error: err})
+		return
+	}
+	limit, err := parseNofileSoftLimit(limits)
+	if err != nil {
+		logger.Debug("Unable to parse the resource limits of the container", fields, logger.Fields{field.Error: err})
+		return
+	}
+
+	usage := container.RecordFDUsage(openFDs, limit, engine.time().Now())
+	if usage.LeakSuspected {
+		logger.Warn("Container is suspected of leaking file descriptors", fields, logger.Fields{
+			"openFDs": usage.OpenFDs,
+			"limit":   usage.Limit,
+		})
+	}
+}
+
+// countOpenFDs returns the number of entries of the file descriptor directory of a process
+func countOpenFDs(fdDir string) (int, error) {
+	dir, err := os.Open(fdDir)
+	if err != nil {
+		return 0, err
+	}
+	defer dir.Close()
+	names, err := dir.Readdirnames(-1)
+	if err != nil {
+		return 0, err
+	}
+	return len(names), nil
+}
+
+// parseNofileSoftLimit returns the soft limit of open files from the resource limits of a process, or zero if
+// the number of open files is unlimited
+func parseNofileSoftLimit(limits []byte) (uint64, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(limits))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, nofileLimitName) {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, nofileLimitName))
+		if len(fields) == 0 {
+			break
+		}
+		if fields[0] == "unlimited" {
+			return 0, nil
+		}
+		return strconv.ParseUint(fields[0], 10, 64)
+	}
+	return 0, errors.Errorf("no %q limit found", nofileLimitName)
+}
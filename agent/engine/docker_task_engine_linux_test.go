@@ -20,7 +20,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
@@ -109,6 +111,9 @@ func TestResourceContainerProgression(t *testing.T) {
 	// events are processed
 	containerEventsWG := sync.WaitGroup{}
 	client.EXPECT().ContainerEvents(gomock.Any()).Return(eventStream, nil)
+	client.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().Info(gomock.Any(), gomock.Any()).Return(types.Info{}, nil)
 	serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().AnyTimes()
 	gomock.InOrder(
 		// Ensure that the resource is created first
@@ -272,6 +277,9 @@ func TestResourceContainerProgressionFailure(t *testing.T) {
 	sleepTask.AddResource("cgroup", cgroupResource)
 	eventStream := make(chan dockerapi.DockerContainerChangeEvent)
 	client.EXPECT().ContainerEvents(gomock.Any()).Return(eventStream, nil)
+	client.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().Info(gomock.Any(), gomock.Any()).Return(types.Info{}, nil)
 	serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().AnyTimes()
 	gomock.InOrder(
 		// resource creation failure
@@ -334,6 +342,9 @@ func TestTaskCPULimitHappyPath(t *testing.T) {
 			containerEventsWG := sync.WaitGroup{}
 
 			client.EXPECT().ContainerEvents(gomock.Any()).Return(eventStream, nil)
+			client.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				dockerapi.ListContainersResponse{}).AnyTimes()
+			client.EXPECT().Info(gomock.Any(), gomock.Any()).Return(types.Info{}, nil)
 			serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().AnyTimes()
 			containerName := make(chan string)
 			go func() {
@@ -623,6 +634,9 @@ func TestTaskWithSteadyStateResourcesProvisioned(t *testing.T) {
 	containerEventsWG := sync.WaitGroup{}
 
 	client.EXPECT().ContainerEvents(gomock.Any()).Return(eventStream, nil)
+	client.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().Info(gomock.Any(), gomock.Any()).Return(types.Info{}, nil)
 	serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().AnyTimes()
 	// We cannot rely on the order of pulls between images as they can still be downloaded in
 	// parallel. The dependency graph enforcement comes into effect for CREATED transitions.
@@ -741,6 +755,7 @@ func TestPauseContainerHappyPath(t *testing.T) {
 
 	cniClient := mock_ecscni.NewMockCNIClient(ctrl)
 	taskEngine.(*DockerTaskEngine).cniClient = cniClient
+	expectAWSVPCCNIPluginsCapabilities(cniClient)
 	taskEngine.(*DockerTaskEngine).taskSteadyStatePollInterval = taskSteadyStatePollInterval
 	eventStream := make(chan dockerapi.DockerContainerChangeEvent)
 	sleepTask := testdata.LoadTask("sleep5TwoContainers")
@@ -766,6 +781,9 @@ func TestPauseContainerHappyPath(t *testing.T) {
 	})
 
 	dockerClient.EXPECT().ContainerEvents(gomock.Any()).Return(eventStream, nil)
+	dockerClient.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	dockerClient.EXPECT().Info(gomock.Any(), gomock.Any()).Return(types.Info{}, nil)
 	serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().AnyTimes()
 
 	sleepContainerID1 := containerID + "1"
@@ -943,6 +961,7 @@ func TestContainersWithServiceConnect(t *testing.T) {
 	cniClient := mock_ecscni.NewMockCNIClient(ctrl)
 	appnetClient := mock_api.NewMockAppnetClient(ctrl)
 	taskEngine.(*DockerTaskEngine).cniClient = cniClient
+	expectAWSVPCCNIPluginsCapabilities(cniClient)
 	taskEngine.(*DockerTaskEngine).appnetClient = appnetClient
 	taskEngine.(*DockerTaskEngine).taskSteadyStatePollInterval = taskSteadyStatePollInterval
 	taskEngine.(*DockerTaskEngine).serviceconnectRelay = &apitask.Task{Arn: "arn::::::/task"}
@@ -994,6 +1013,9 @@ func TestContainersWithServiceConnect(t *testing.T) {
 	sleepTask.AddTaskENI(mockENI)
 
 	dockerClient.EXPECT().ContainerEvents(gomock.Any()).Return(eventStream, nil)
+	dockerClient.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	dockerClient.EXPECT().Info(gomock.Any(), gomock.Any()).Return(types.Info{}, nil)
 	serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().AnyTimes()
 
 	sleepContainerID1 := containerID + "1"
@@ -1146,6 +1168,9 @@ func TestContainersWithServiceConnect_BridgeMode(t *testing.T) {
 	})
 
 	dockerClient.EXPECT().ContainerEvents(gomock.Any()).Return(eventStream, nil)
+	dockerClient.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	dockerClient.EXPECT().Info(gomock.Any(), gomock.Any()).Return(types.Info{}, nil)
 	serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().AnyTimes()
 
 	sleepContainerID := containerID + "1"
@@ -1474,3 +1499,46 @@ func TestCredentialSpecResourceTaskFile(t *testing.T) {
 	ret := taskEngine.(*DockerTaskEngine).createContainer(testTask, testTask.Containers[0])
 	assert.Nil(t, ret.Error)
 }
+
+func TestParseResolvConfNameservers(t *testing.T) {
+	resolvConf := []byte(`# generated by the agent
+search us-west-2.compute.internal
+nameserver 10.0.0.2
+options timeout:2 attempts:5
+nameserver   fd00:ec2::253
+nameserver
+`)
+	assert.Equal(t, []string{"10.0.0.2", "fd00:ec2::253"}, parseResolvConfNameservers(resolvConf))
+	assert.Empty(t, parseResolvConfNameservers([]byte("search example.com\n")))
+}
+
+func TestParseNofileSoftLimit(t *testing.T) {
+	limits := `Limit                     Soft Limit           Hard Limit           Units
+Max cpu time              unlimited            unlimited            seconds
+Max open files            1024                 4096                 files
+Max locked memory         65536                65536                bytes
+`
+	limit, err := parseNofileSoftLimit([]byte(limits))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1024), limit)
+
+	limit, err = parseNofileSoftLimit([]byte("Max open files            unlimited            unlimited            files\n"))
+	require.NoError(t, err)
+	assert.Zero(t, limit)
+
+	_, err = parseNofileSoftLimit([]byte("Max cpu time              unlimited            unlimited            seconds\n"))
+	assert.Error(t, err)
+}
+
+func TestCountOpenFDs(t *testing.T) {
+	fdDir := t.TempDir()
+	for _, fd := range []string{"0", "1", "2"} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(fdDir, fd), nil, 0644))
+	}
+	openFDs, err := countOpenFDs(fdDir)
+	require.NoError(t, err)
+	assert.Equal(t, 3, openFDs)
+
+	_, err = countOpenFDs(filepath.Join(fdDir, "missing"))
+	assert.Error(t, err)
+}
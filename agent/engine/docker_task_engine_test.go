@@ -22,10 +22,12 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -45,9 +47,11 @@ import (
 	mock_containermetadata "github.com/aws/amazon-ecs-agent/agent/containermetadata/mocks"
 	"github.com/aws/amazon-ecs-agent/agent/credentials"
 	mock_credentials "github.com/aws/amazon-ecs-agent/agent/credentials/mocks"
+	"github.com/aws/amazon-ecs-agent/agent/data"
 	"github.com/aws/amazon-ecs-agent/agent/dockerclient"
 	"github.com/aws/amazon-ecs-agent/agent/dockerclient/dockerapi"
 	mock_dockerapi "github.com/aws/amazon-ecs-agent/agent/dockerclient/dockerapi/mocks"
+	"github.com/aws/amazon-ecs-agent/agent/ecscni"
 	mock_ecscni "github.com/aws/amazon-ecs-agent/agent/ecscni/mocks"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
 	"github.com/aws/amazon-ecs-agent/agent/engine/execcmd"
@@ -57,6 +61,7 @@ import (
 	mock_engineserviceconnect "github.com/aws/amazon-ecs-agent/agent/engine/serviceconnect/mock"
 	"github.com/aws/amazon-ecs-agent/agent/engine/testdata"
 	"github.com/aws/amazon-ecs-agent/agent/eventstream"
+	"github.com/aws/amazon-ecs-agent/agent/metrics"
 	mock_ssm_factory "github.com/aws/amazon-ecs-agent/agent/ssm/factory/mocks"
 	mock_ssmiface "github.com/aws/amazon-ecs-agent/agent/ssm/mocks"
 	"github.com/aws/amazon-ecs-agent/agent/taskresource"
@@ -72,9 +77,14 @@ import (
 	"github.com/containernetworking/cni/pkg/types/current"
 	"github.com/docker/docker/api/types"
 	dockercontainer "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/network"
+	sdkClient "github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
 	"github.com/golang/mock/gomock"
 	"github.com/pborman/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -146,6 +156,13 @@ var (
 func init() {
 	defaultConfig = config.DefaultConfig()
 	defaultConfig.TaskCPUMemLimit.Value = config.ExplicitlyDisabled
+	defaultConfig.TaskENIEnabled.Value = config.ExplicitlyEnabled
+}
+
+// expectAWSVPCCNIPluginsCapabilities expects the CNI plugins used by awsvpc tasks to be queried for their
+// capabilities when validating the networking prerequisites of the tasks
+func expectAWSVPCCNIPluginsCapabilities(cniClient *mock_ecscni.MockCNIClient) {
+	cniClient.EXPECT().Capabilities(gomock.Any()).Return([]string{ecscni.CapabilityAWSVPCNetworkingMode}, nil).AnyTimes()
 }
 
 func getCreatedContainerName() string {
@@ -183,6 +200,8 @@ func mocks(t *testing.T, ctx context.Context, cfg *config.Config) (*gomock.Contr
 	taskEngine.(*DockerTaskEngine).ctx = ctx
 	taskEngine.(*DockerTaskEngine).stopContainerBackoffMin = time.Millisecond
 	taskEngine.(*DockerTaskEngine).stopContainerBackoffMax = time.Millisecond * 2
+	taskEngine.(*DockerTaskEngine).createContainerBackoffMin = time.Millisecond
+	taskEngine.(*DockerTaskEngine).createContainerBackoffMax = time.Millisecond * 2
 	serviceConnectManager := mock_engineserviceconnect.NewMockManager(ctrl)
 	taskEngine.(*DockerTaskEngine).serviceconnectManager = serviceConnectManager
 	return ctrl, client, mockTime, taskEngine, credentialsManager, imageManager, metadataManager, serviceConnectManager
@@ -262,6 +281,9 @@ func TestBatchContainerHappyPath(t *testing.T) {
 			containerEventsWG := sync.WaitGroup{}
 
 			client.EXPECT().ContainerEvents(gomock.Any()).Return(eventStream, nil)
+			client.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+				dockerapi.ListContainersResponse{}).AnyTimes()
+			client.EXPECT().Info(gomock.Any(), dockerclient.InfoTimeout).Return(types.Info{}, nil)
 			serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().AnyTimes()
 			containerName := make(chan string)
 			go func() {
@@ -282,6 +304,7 @@ func TestBatchContainerHappyPath(t *testing.T) {
 							execCmdMgr.EXPECT().InitializeContainer(gomock.Any(), container, gomock.Any()).Times(1)
 							// TODO: [ecs-exec] validate call control plane to report ExecCommandAgent SUCCESS/FAIL here
 							execCmdMgr.EXPECT().StartAgent(gomock.Any(), client, sleepTask, sleepTask.Containers[0], containerID)
+							execCmdMgr.EXPECT().RecordAgentStopped(sleepTask, sleepTask.Containers[0], gomock.Any()).AnyTimes()
 						}
 					})
 			}
@@ -361,6 +384,9 @@ func TestRemoveEvents(t *testing.T) {
 	// events are processed
 	containerEventsWG := sync.WaitGroup{}
 	client.EXPECT().ContainerEvents(gomock.Any()).Return(eventStream, nil)
+	client.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().Info(gomock.Any(), dockerclient.InfoTimeout).Return(types.Info{}, nil)
 	serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().AnyTimes()
 	client.EXPECT().StopContainer(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 	containerName := make(chan string)
@@ -436,6 +462,9 @@ func TestStartTimeoutThenStart(t *testing.T) {
 	testTime.EXPECT().Now().Return(time.Now()).AnyTimes()
 	testTime.EXPECT().After(gomock.Any())
 	client.EXPECT().ContainerEvents(gomock.Any()).Return(eventStream, nil)
+	client.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().Info(gomock.Any(), dockerclient.InfoTimeout).Return(types.Info{}, nil)
 	serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().AnyTimes()
 	client.EXPECT().APIVersion().Return(defaultDockerClientAPIVersion, nil)
 	for _, container := range sleepTask.Containers {
@@ -492,6 +521,9 @@ func TestSteadyStatePoll(t *testing.T) {
 	eventStream := make(chan dockerapi.DockerContainerChangeEvent)
 
 	client.EXPECT().ContainerEvents(gomock.Any()).Return(eventStream, nil)
+	client.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().Info(gomock.Any(), dockerclient.InfoTimeout).Return(types.Info{}, nil)
 	serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().AnyTimes()
 	containerName := make(chan string)
 	go func() {
@@ -571,6 +603,9 @@ func TestStopWithPendingStops(t *testing.T) {
 	eventStream := make(chan dockerapi.DockerContainerChangeEvent)
 
 	client.EXPECT().ContainerEvents(gomock.Any()).Return(eventStream, nil)
+	client.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().Info(gomock.Any(), dockerclient.InfoTimeout).Return(types.Info{}, nil)
 	serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().AnyTimes()
 	err := taskEngine.Init(ctx)
 	assert.NoError(t, err)
@@ -636,6 +671,202 @@ func TestCreateContainerSaveDockerIDAndName(t *testing.T) {
 	assert.Contains(t, containers[0].DockerName, sleepContainer.Name)
 }
 
+func TestCreateContainerRetriesOnlyTransientErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		err           error
+		expectedCalls int
+	}{
+		{
+			name:          "daemon unreachable",
+			err:           sdkClient.ErrorConnectionFailed("unix:///var/run/docker.sock"),
+			expectedCalls: createContainerMaxRetryCount,
+		},
+		{
+			name:          "daemon unavailable",
+			err:           errdefs.Unavailable(errors.New("daemon is shutting down")),
+			expectedCalls: createContainerMaxRetryCount,
+		},
+		{
+			name:          "image not found",
+			err:           errdefs.NotFound(errors.New("No such image: busybox:missing")),
+			expectedCalls: 1,
+		},
+		{
+			name:          "invalid config",
+			err:           errdefs.InvalidParameter(errors.New("invalid mount config")),
+			expectedCalls: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			ctrl, client, _, privateTaskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+			defer ctrl.Finish()
+
+			taskEngine, _ := privateTaskEngine.(*DockerTaskEngine)
+			taskEngine.SetDataClient(data.NewNoopClient())
+
+			sleepTask := testdata.LoadTask("sleep5")
+			sleepContainer, _ := sleepTask.ContainerByName("sleep5")
+
+			client.EXPECT().APIVersion().Return(defaultDockerClientAPIVersion, nil).AnyTimes()
+			client.EXPECT().CreateContainer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(dockerapi.DockerContainerMetadata{
+					Error: dockerapi.CannotCreateContainerError{FromError: tc.err},
+				}).Times(tc.expectedCalls)
+			metadata := taskEngine.createContainer(sleepTask, sleepContainer)
+			require.Error(t, metadata.Error)
+			assert.Equal(t, "CannotCreateContainerError", metadata.Error.ErrorName())
+			assert.Contains(t, metadata.Error.Error(), tc.err.Error(), "the reason docker gave should be surfaced")
+		})
+	}
+}
+
+func TestCreateContainerStopsRetryingWhenEngineIsStopped(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, client, _, privateTaskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+
+	taskEngine, _ := privateTaskEngine.(*DockerTaskEngine)
+	taskEngine.SetDataClient(data.NewNoopClient())
+	taskEngine.createContainerBackoffMin = time.Hour
+	taskEngine.createContainerBackoffMax = time.Hour
+
+	sleepTask := testdata.LoadTask("sleep5")
+	sleepContainer, _ := sleepTask.ContainerByName("sleep5")
+
+	client.EXPECT().APIVersion().Return(defaultDockerClientAPIVersion, nil).AnyTimes()
+	// The engine is stopped while waiting to retry, so the container is not created again
+	client.EXPECT().CreateContainer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, _ *dockercontainer.Config, _ *dockercontainer.HostConfig, _ string, _ time.Duration) {
+			cancel()
+		}).
+		Return(dockerapi.DockerContainerMetadata{
+			Error: dockerapi.CannotCreateContainerError{FromError: sdkClient.ErrorConnectionFailed("unix:///var/run/docker.sock")},
+		})
+	created := make(chan dockerapi.DockerContainerMetadata, 1)
+	go func() {
+		created <- taskEngine.createContainer(sleepTask, sleepContainer)
+	}()
+	select {
+	case metadata := <-created:
+		require.Error(t, metadata.Error)
+		assert.Equal(t, "CannotCreateContainerError", metadata.Error.ErrorName())
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the container create to stop retrying")
+	}
+}
+
+func TestCreateContainerSucceedsAfterTransientError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, client, _, privateTaskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+
+	taskEngine, _ := privateTaskEngine.(*DockerTaskEngine)
+	taskEngine.SetDataClient(data.NewNoopClient())
+
+	sleepTask := testdata.LoadTask("sleep5")
+	sleepContainer, _ := sleepTask.ContainerByName("sleep5")
+
+	client.EXPECT().APIVersion().Return(defaultDockerClientAPIVersion, nil).AnyTimes()
+	gomock.InOrder(
+		client.EXPECT().CreateContainer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(dockerapi.DockerContainerMetadata{
+				Error: dockerapi.CannotCreateContainerError{FromError: sdkClient.ErrorConnectionFailed("unix:///var/run/docker.sock")},
+			}),
+		client.EXPECT().CreateContainer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(dockerapi.DockerContainerMetadata{DockerID: testDockerID}),
+	)
+	metadata := taskEngine.createContainer(sleepTask, sleepContainer)
+	require.NoError(t, metadata.Error)
+	assert.Equal(t, testDockerID, sleepContainer.GetRuntimeID())
+}
+
+func TestMergeHostEnvironmentFile(t *testing.T) {
+	envDir := t.TempDir()
+	envFile := filepath.Join(envDir, "app.env")
+	require.NoError(t, os.WriteFile(envFile, []byte("# comment\nSHARED=from-file\nFILE_ONLY=from-file\n"), 0600))
+	otherDir := t.TempDir()
+	outsideFile := filepath.Join(otherDir, "outside.env")
+	require.NoError(t, os.WriteFile(outsideFile, []byte("FILE_ONLY=outside\n"), 0600))
+
+	cfg := defaultConfig
+	cfg.HostEnvironmentFileDirs = []string{envDir}
+	engine := &DockerTaskEngine{cfg: &cfg}
+
+	t.Run("inline variables take precedence", func(t *testing.T) {
+		container := &apicontainer.Container{
+			Name:        "web",
+			Environment: map[string]string{"SHARED": "inline", "INLINE_ONLY": "inline"},
+		}
+		require.NoError(t, engine.mergeHostEnvironmentFile(container, envFile))
+		assert.Equal(t, map[string]string{
+			"SHARED":      "inline",
+			"INLINE_ONLY": "inline",
+			"FILE_ONLY":   "from-file",
+		}, container.Environment)
+	})
+	t.Run("missing file", func(t *testing.T) {
+		container := &apicontainer.Container{Name: "web"}
+		err := engine.mergeHostEnvironmentFile(container, filepath.Join(envDir, "missing.env"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unable to read host environment file")
+		assert.Empty(t, container.Environment)
+	})
+	t.Run("file outside of the allowed directories", func(t *testing.T) {
+		container := &apicontainer.Container{Name: "web"}
+		err := engine.mergeHostEnvironmentFile(container, outsideFile)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "ECS_HOST_ENVIRONMENT_FILE_DIRS")
+		assert.Error(t, engine.mergeHostEnvironmentFile(container, filepath.Join(envDir, "..", filepath.Base(otherDir), "outside.env")))
+		assert.Empty(t, container.Environment)
+	})
+	t.Run("symbolic link out of the allowed directories", func(t *testing.T) {
+		link := filepath.Join(envDir, "link.env")
+		if err := os.Symlink(outsideFile, link); err != nil {
+			t.Skipf("unable to create symbolic link: %v", err)
+		}
+		container := &apicontainer.Container{Name: "web"}
+		assert.Error(t, engine.mergeHostEnvironmentFile(container, link))
+		assert.Empty(t, container.Environment)
+	})
+	t.Run("relative path", func(t *testing.T) {
+		container := &apicontainer.Container{Name: "web"}
+		assert.Error(t, engine.mergeHostEnvironmentFile(container, "app.env"))
+	})
+}
+
+func TestCreateContainerMissingHostEnvironmentFile(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	cfg := defaultConfig
+	cfg.HostEnvironmentFileDirs = []string{t.TempDir()}
+	ctrl, client, _, privateTaskEngine, _, _, _, _ := mocks(t, ctx, &cfg)
+	defer ctrl.Finish()
+
+	taskEngine, _ := privateTaskEngine.(*DockerTaskEngine)
+	taskEngine.SetDataClient(data.NewNoopClient())
+
+	sleepTask := testdata.LoadTask("sleep5")
+	sleepContainer, _ := sleepTask.ContainerByName("sleep5")
+	rawConfig, err := json.Marshal(&dockercontainer.Config{Labels: map[string]string{
+		apicontainer.HostEnvironmentFileLabel: filepath.Join(cfg.HostEnvironmentFileDirs[0], "missing.env"),
+	}})
+	require.NoError(t, err)
+	rawConfigStr := string(rawConfig)
+	sleepContainer.DockerConfig.Config = &rawConfigStr
+
+	client.EXPECT().APIVersion().Return(defaultDockerClientAPIVersion, nil).AnyTimes()
+	client.EXPECT().CreateContainer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	metadata := taskEngine.createContainer(sleepTask, sleepContainer)
+	require.Error(t, metadata.Error)
+	assert.Contains(t, metadata.Error.Error(), "missing.env")
+}
+
 func TestCreateContainerMetadata(t *testing.T) {
 	testcases := []struct {
 		name  string
@@ -714,6 +945,51 @@ func TestCreateContainerMergesLabels(t *testing.T) {
 	taskEngine.(*DockerTaskEngine).createContainer(testTask, testTask.Containers[0])
 }
 
+func TestCreateContainerEphemeralStorageSize(t *testing.T) {
+	testCases := []struct {
+		name               string
+		labels             string
+		expectedStorageOpt map[string]string
+	}{
+		{
+			name:               "ephemeral storage size set",
+			labels:             `{"Labels":{"com.amazonaws.ecs.ephemeral-storage-size":"30"}}`,
+			expectedStorageOpt: map[string]string{"size": "30G"},
+		},
+		{
+			name:   "ephemeral storage size not set",
+			labels: `{"Labels":{"key":"value"}}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			ctrl, client, _, taskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+			defer ctrl.Finish()
+
+			testTask := &apitask.Task{
+				Arn: "myTaskArn",
+				Containers: []*apicontainer.Container{
+					{
+						Name: "c1",
+						DockerConfig: apicontainer.DockerConfig{
+							Config: aws.String(tc.labels),
+						},
+					},
+				},
+			}
+			client.EXPECT().APIVersion().Return(defaultDockerClientAPIVersion, nil).AnyTimes()
+			client.EXPECT().CreateContainer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Do(
+				func(ctx context.Context, config *dockercontainer.Config, hostConfig *dockercontainer.HostConfig,
+					name string, timeout time.Duration) {
+					assert.Equal(t, tc.expectedStorageOpt, hostConfig.StorageOpt)
+				})
+			taskEngine.(*DockerTaskEngine).createContainer(testTask, testTask.Containers[0])
+		})
+	}
+}
+
 // TestCreateContainerAddV3EndpointIDToState tests that in createContainer, when the
 // container's v3 endpoint id is set, we will add mappings to engine state
 func TestCreateContainerAddV3EndpointIDToState(t *testing.T) {
@@ -769,6 +1045,9 @@ func TestTaskTransitionWhenStopContainerTimesout(t *testing.T) {
 	sleepTask := testdata.LoadTask("sleep5")
 	eventStream := make(chan dockerapi.DockerContainerChangeEvent)
 	client.EXPECT().ContainerEvents(gomock.Any()).Return(eventStream, nil)
+	client.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().Info(gomock.Any(), dockerclient.InfoTimeout).Return(types.Info{}, nil)
 	serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().AnyTimes()
 	mockTime.EXPECT().Now().Return(time.Now()).AnyTimes()
 	mockTime.EXPECT().After(gomock.Any()).AnyTimes()
@@ -837,6 +1116,9 @@ func TestTaskTransitionWhenStopContainerReturnsUnretriableError(t *testing.T) {
 	sleepTask := testdata.LoadTask("sleep5")
 	eventStream := make(chan dockerapi.DockerContainerChangeEvent)
 	client.EXPECT().ContainerEvents(gomock.Any()).Return(eventStream, nil)
+	client.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().Info(gomock.Any(), dockerclient.InfoTimeout).Return(types.Info{}, nil)
 	serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().AnyTimes()
 	mockTime.EXPECT().Now().Return(time.Now()).AnyTimes()
 	mockTime.EXPECT().After(gomock.Any()).AnyTimes()
@@ -911,6 +1193,9 @@ func TestTaskTransitionWhenStopContainerReturnsTransientErrorBeforeSucceeding(t
 	sleepTask := testdata.LoadTask("sleep5")
 	eventStream := make(chan dockerapi.DockerContainerChangeEvent)
 	client.EXPECT().ContainerEvents(gomock.Any()).Return(eventStream, nil)
+	client.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().Info(gomock.Any(), dockerclient.InfoTimeout).Return(types.Info{}, nil)
 	mockTime.EXPECT().Now().Return(time.Now()).AnyTimes()
 	mockTime.EXPECT().After(gomock.Any()).AnyTimes()
 	serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().AnyTimes()
@@ -966,6 +1251,9 @@ func TestGetTaskByArn(t *testing.T) {
 	mockTime.EXPECT().After(gomock.Any()).AnyTimes()
 	eventStream := make(chan dockerapi.DockerContainerChangeEvent)
 	client.EXPECT().ContainerEvents(gomock.Any()).Return(eventStream, nil)
+	client.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().Info(gomock.Any(), dockerclient.InfoTimeout).Return(types.Info{}, nil)
 	serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().AnyTimes()
 	imageManager.EXPECT().AddAllImageStates(gomock.Any()).AnyTimes()
 	imageManager.EXPECT().RecordContainerReference(gomock.Any()).AnyTimes()
@@ -1169,149 +1457,1504 @@ func TestStopPauseContainerCleanupCalledAwsvpc(t *testing.T) {
 	require.True(t, pauseContainer.IsContainerTornDown())
 }
 
-// TestStopPauseContainerCleanupDelayAwsvpc tests when stopping the pause container
-// its network namespace should be cleaned up first
-func TestStopPauseContainerCleanupDelayAwsvpc(t *testing.T) {
-	ctx, cancel := context.WithCancel(context.TODO())
-	defer cancel()
+// TestStopContainerWithEscalation tests that a container ignoring its stop signal is killed once its
+// stop timeout expires, and that the kill is recorded on the container and the task
+func TestStopContainerWithEscalation(t *testing.T) {
+	defer func(interval time.Duration) {
+		containerStopPollInterval = interval
+	}(containerStopPollInterval)
+	containerStopPollInterval = time.Millisecond
 
-	cfg := config.DefaultConfig()
-	cfg.TaskCPUMemLimit.Value = config.ExplicitlyDisabled
-	cfg.ENIPauseContainerCleanupDelaySeconds = expectedDelaySeconds
+	testCases := []struct {
+		name           string
+		ignoreSIGTERM  bool
+		expectedKilled bool
+	}{
+		{
+			name:           "container exits on stop signal",
+			ignoreSIGTERM:  false,
+			expectedKilled: false,
+		},
+		{
+			name:           "container ignores stop signal",
+			ignoreSIGTERM:  true,
+			expectedKilled: true,
+		},
+	}
 
-	delayedChan := make(chan time.Duration, 1)
-	ctrl, dockerClient, _, taskEngine, _, _, _, _ := mocks(t, ctx, &cfg)
-	taskEngine.(*DockerTaskEngine).handleDelay = func(d time.Duration) {
-		delayedChan <- d
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			cfg := defaultConfig
+			cfg.ContainerStopEscalation = config.BooleanDefaultFalse{Value: config.ExplicitlyEnabled}
+			ctrl, client, _, taskEngine, _, _, _, _ := mocks(t, ctx, &cfg)
+			defer ctrl.Finish()
+
+			testTask := testdata.LoadTask("sleep5")
+			container := testTask.Containers[0]
+			container.StopTimeout = 1
+			taskEngine.(*DockerTaskEngine).State().AddTask(testTask)
+			taskEngine.(*DockerTaskEngine).State().AddContainer(&apicontainer.DockerContainer{
+				DockerID:   containerID,
+				DockerName: dockerContainerName,
+				Container:  container,
+			}, testTask)
+
+			var stopped int32
+			client.EXPECT().KillContainer(gomock.Any(), containerID, "SIGTERM", dockerclient.KillContainerTimeout).Do(
+				func(ctx context.Context, id, signal string, timeout time.Duration) {
+					if !tc.ignoreSIGTERM {
+						atomic.StoreInt32(&stopped, 1)
+					}
+				}).Return(nil)
+			if tc.expectedKilled {
+				client.EXPECT().KillContainer(gomock.Any(), containerID, "SIGKILL", dockerclient.KillContainerTimeout).Do(
+					func(ctx context.Context, id, signal string, timeout time.Duration) {
+						atomic.StoreInt32(&stopped, 1)
+					}).Return(nil)
+			}
+			client.EXPECT().DescribeContainer(gomock.Any(), containerID).DoAndReturn(
+				func(ctx context.Context, id string) (apicontainerstatus.ContainerStatus, dockerapi.DockerContainerMetadata) {
+					if atomic.LoadInt32(&stopped) == 1 {
+						return apicontainerstatus.ContainerStopped, dockerapi.DockerContainerMetadata{DockerID: containerID}
+					}
+					return apicontainerstatus.ContainerRunning, dockerapi.DockerContainerMetadata{DockerID: containerID}
+				}).MinTimes(1)
+
+			md := taskEngine.(*DockerTaskEngine).stopContainer(testTask, container)
+			assert.NoError(t, md.Error)
+			assert.Equal(t, tc.expectedKilled, container.IsKilledAfterTimeout())
+			if tc.expectedKilled {
+				assert.Equal(t, "ContainerKilledAfterStopTimeout: "+container.Name, testTask.GetTerminalReason())
+			} else {
+				assert.Empty(t, testTask.GetTerminalReason())
+			}
+		})
 	}
+}
+
+// TestStopContainerWithEscalationSignalError tests that the container is stopped through docker when
+// its stop signal cannot be sent
+func TestStopContainerWithEscalationSignalError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	cfg := defaultConfig
+	cfg.ContainerStopEscalation = config.BooleanDefaultFalse{Value: config.ExplicitlyEnabled}
+	ctrl, client, _, taskEngine, _, _, _, _ := mocks(t, ctx, &cfg)
+	defer ctrl.Finish()
 
-	mockCNIClient := mock_ecscni.NewMockCNIClient(ctrl)
-	taskEngine.(*DockerTaskEngine).cniClient = mockCNIClient
 	testTask := testdata.LoadTask("sleep5")
-	pauseContainer := &apicontainer.Container{
-		Name:                "pausecontainer",
-		Type:                apicontainer.ContainerCNIPause,
-		DesiredStatusUnsafe: apicontainerstatus.ContainerStopped,
-	}
-	testTask.Containers = append(testTask.Containers, pauseContainer)
-	testTask.AddTaskENI(mockENI)
-	testTask.NetworkMode = apitask.AWSVPCNetworkMode
+	container := testTask.Containers[0]
 	taskEngine.(*DockerTaskEngine).State().AddTask(testTask)
 	taskEngine.(*DockerTaskEngine).State().AddContainer(&apicontainer.DockerContainer{
 		DockerID:   containerID,
 		DockerName: dockerContainerName,
-		Container:  pauseContainer,
+		Container:  container,
 	}, testTask)
 
 	gomock.InOrder(
-		dockerClient.EXPECT().InspectContainer(gomock.Any(), containerID, gomock.Any()).Return(&types.ContainerJSON{
-			ContainerJSONBase: &types.ContainerJSONBase{
-				ID:    containerID,
-				State: &types.ContainerState{Pid: containerPid},
-				HostConfig: &dockercontainer.HostConfig{
-					NetworkMode: containerNetworkMode,
-				},
-			},
-		}, nil),
-		mockCNIClient.EXPECT().CleanupNS(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil),
-		dockerClient.EXPECT().StopContainer(gomock.Any(),
-			containerID,
-			defaultConfig.DockerStopTimeout,
-		).Return(dockerapi.DockerContainerMetadata{}),
+		client.EXPECT().KillContainer(gomock.Any(), containerID, "SIGTERM", gomock.Any()).Return(
+			dockerapi.CannotStopContainerError{FromError: errors.New("error")}),
+		client.EXPECT().StopContainer(gomock.Any(), containerID, cfg.DockerStopTimeout).Return(
+			dockerapi.DockerContainerMetadata{}),
 	)
 
-	taskEngine.(*DockerTaskEngine).stopContainer(testTask, pauseContainer)
+	md := taskEngine.(*DockerTaskEngine).stopContainer(testTask, container)
+	assert.NoError(t, md.Error)
+	assert.False(t, container.IsKilledAfterTimeout())
+}
 
-	select {
-	case actualDelay := <-delayedChan:
-		assert.Equal(t, expectedDelay, actualDelay)
-		require.True(t, pauseContainer.IsContainerTornDown())
-	default:
-		assert.Fail(t, "engine.handleDelay wasn't called")
-	}
+// TestStopContainerWithEscalationNeverStops tests that a container which is not seen stopping after being killed
+// is stopped through docker, whose error is reported instead of the container being considered stopped
+func TestStopContainerWithEscalationNeverStops(t *testing.T) {
+	defer func(interval, killWait time.Duration) {
+		containerStopPollInterval = interval
+		stopContainerKillWait = killWait
+	}(containerStopPollInterval, stopContainerKillWait)
+	containerStopPollInterval = time.Millisecond
+	stopContainerKillWait = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	cfg := defaultConfig
+	cfg.ContainerStopEscalation = config.BooleanDefaultFalse{Value: config.ExplicitlyEnabled}
+	ctrl, client, _, taskEngine, _, _, _, _ := mocks(t, ctx, &cfg)
+	defer ctrl.Finish()
+
+	testTask := testdata.LoadTask("sleep5")
+	container := testTask.Containers[0]
+	container.StopTimeout = 1
+	taskEngine.(*DockerTaskEngine).State().AddTask(testTask)
+	taskEngine.(*DockerTaskEngine).State().AddContainer(&apicontainer.DockerContainer{
+		DockerID:   containerID,
+		DockerName: dockerContainerName,
+		Container:  container,
+	}, testTask)
+
+	stopErr := &dockerapi.DockerTimeoutError{Duration: time.Second, Transition: "stop"}
+	client.EXPECT().KillContainer(gomock.Any(), containerID, "SIGTERM", dockerclient.KillContainerTimeout).Return(nil)
+	client.EXPECT().KillContainer(gomock.Any(), containerID, "SIGKILL", dockerclient.KillContainerTimeout).Return(nil)
+	client.EXPECT().DescribeContainer(gomock.Any(), containerID).Return(
+		apicontainerstatus.ContainerRunning, dockerapi.DockerContainerMetadata{DockerID: containerID}).MinTimes(2)
+	client.EXPECT().StopContainer(gomock.Any(), containerID, time.Second).Return(
+		dockerapi.DockerContainerMetadata{Error: stopErr}).MinTimes(1)
+
+	md := taskEngine.(*DockerTaskEngine).stopContainer(testTask, container)
+	require.Error(t, md.Error)
+	assert.Equal(t, dockerapi.DockerTimeoutErrorName, md.Error.ErrorName())
+	assert.True(t, container.IsKilledAfterTimeout())
 }
 
-// TestCheckTearDownPauseContainerAwsvpc that the pause container teardown works and is idempotent
-func TestCheckTearDownPauseContainerAwsvpc(t *testing.T) {
+// TestStopContainerWithEscalationStopSignal tests that the stop signal reported by docker, e.g. set by the
+// STOPSIGNAL instruction of the image, is sent to stop the container
+func TestStopContainerWithEscalationStopSignal(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.TODO())
 	defer cancel()
-	ctrl, dockerClient, _, taskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+	cfg := defaultConfig
+	cfg.ContainerStopEscalation = config.BooleanDefaultFalse{Value: config.ExplicitlyEnabled}
+	ctrl, client, _, taskEngine, _, _, _, _ := mocks(t, ctx, &cfg)
 	defer ctrl.Finish()
 
-	mockCNIClient := mock_ecscni.NewMockCNIClient(ctrl)
-	taskEngine.(*DockerTaskEngine).cniClient = mockCNIClient
 	testTask := testdata.LoadTask("sleep5")
-	pauseContainer := &apicontainer.Container{
-		Name:                "pausecontainer",
-		Type:                apicontainer.ContainerCNIPause,
-		DesiredStatusUnsafe: apicontainerstatus.ContainerStopped,
-	}
-	testTask.Containers = append(testTask.Containers, pauseContainer)
-	testTask.AddTaskENI(mockENI)
-	testTask.NetworkMode = apitask.AWSVPCNetworkMode
-	testTask.SetAppMesh(&appmesh.AppMesh{
-		IgnoredUID:       ignoredUID,
-		ProxyIngressPort: proxyIngressPort,
-		ProxyEgressPort:  proxyEgressPort,
-		AppPorts: []string{
-			appPort,
-		},
-		EgressIgnoredIPs: []string{
-			egressIgnoredIP,
-		},
-	})
+	container := testTask.Containers[0]
+	updateContainerMetadata(&dockerapi.DockerContainerMetadata{StopSignal: "SIGQUIT"}, container, testTask)
 	taskEngine.(*DockerTaskEngine).State().AddTask(testTask)
 	taskEngine.(*DockerTaskEngine).State().AddContainer(&apicontainer.DockerContainer{
 		DockerID:   containerID,
 		DockerName: dockerContainerName,
-		Container:  pauseContainer,
+		Container:  container,
 	}, testTask)
 
 	gomock.InOrder(
-		dockerClient.EXPECT().InspectContainer(gomock.Any(), containerID, gomock.Any()).Return(&types.ContainerJSON{
-			ContainerJSONBase: &types.ContainerJSONBase{
-				ID:    containerID,
-				State: &types.ContainerState{Pid: containerPid},
-				HostConfig: &dockercontainer.HostConfig{
-					NetworkMode: containerNetworkMode,
-				},
-			},
-		}, nil).MaxTimes(1),
-		mockCNIClient.EXPECT().CleanupNS(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).MaxTimes(1),
+		client.EXPECT().KillContainer(gomock.Any(), containerID, "SIGQUIT", dockerclient.KillContainerTimeout).Return(nil),
+		client.EXPECT().DescribeContainer(gomock.Any(), containerID).Return(
+			apicontainerstatus.ContainerStopped, dockerapi.DockerContainerMetadata{DockerID: containerID}),
 	)
 
-	taskEngine.(*DockerTaskEngine).checkTearDownPauseContainer(testTask)
-	require.True(t, pauseContainer.IsContainerTornDown())
-
-	// Invoke one more time to check for idempotency (mocks configured with maxTimes = 1)
-	taskEngine.(*DockerTaskEngine).checkTearDownPauseContainer(testTask)
+	md := taskEngine.(*DockerTaskEngine).stopContainer(testTask, container)
+	assert.NoError(t, md.Error)
+	assert.False(t, container.IsKilledAfterTimeout())
 }
 
-// TestTaskWithCircularDependency tests the task with containers of which the
-// dependencies can't be resolved
-func TestTaskWithCircularDependency(t *testing.T) {
+func TestRunPeriodically(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.TODO())
 	defer cancel()
-	ctrl, client, _, taskEngine, _, _, _, serviceConnectManager := mocks(t, ctx, &defaultConfig)
-	defer ctrl.Finish()
-
-	client.EXPECT().ContainerEvents(gomock.Any())
-	serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().AnyTimes()
-
-	task := testdata.LoadTask("circular_dependency")
 
-	err := taskEngine.Init(ctx)
-	assert.NoError(t, err)
+	runPeriodically(ctx, 0, func(context.Context) {
+		t.Error("Nothing should run when the interval is not positive")
+	})
 
-	events := taskEngine.StateChangeEvents()
+	calls := make(chan struct{}, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runPeriodically(ctx, time.Millisecond, func(context.Context) {
+			select {
+			case calls <- struct{}{}:
+			default:
+			}
+		})
+	}()
+	<-calls
+	<-calls
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("runPeriodically should return once the context is cancelled")
+	}
+}
+
+func TestComputeClockDrift(t *testing.T) {
+	hostTime := time.Unix(1700000000, 0)
+	testCases := []struct {
+		name          string
+		containerTime time.Time
+		before        time.Time
+		after         time.Time
+		expectedDrift time.Duration
+	}{
+		{
+			name:          "clocks in sync",
+			containerTime: hostTime,
+			before:        hostTime.Add(100 * time.Millisecond),
+			after:         hostTime.Add(300 * time.Millisecond),
+		},
+		{
+			name:          "drift within the precision of a slow probe",
+			containerTime: hostTime.Add(time.Second),
+			before:        hostTime,
+			after:         hostTime.Add(2 * time.Second),
+		},
+		{
+			name:          "container clock ahead",
+			containerTime: hostTime.Add(10 * time.Second),
+			before:        hostTime.Add(100 * time.Millisecond),
+			after:         hostTime.Add(300 * time.Millisecond),
+			expectedDrift: 10300 * time.Millisecond,
+		},
+		{
+			name:          "container clock behind",
+			containerTime: hostTime.Add(-5 * time.Second),
+			before:        hostTime,
+			after:         hostTime.Add(200 * time.Millisecond),
+			expectedDrift: -4600 * time.Millisecond,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedDrift, computeClockDrift(tc.containerTime, tc.before, tc.after))
+		})
+	}
+}
+
+func TestRunTaskCleanupBoundsConcurrency(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	cfg := defaultConfig
+	cfg.TaskCleanupConcurrency = 2
+	ctrl, _, _, taskEngine, _, _, _, _ := mocks(t, ctx, &cfg)
+	defer ctrl.Finish()
+	dockerTaskEngine := taskEngine.(*DockerTaskEngine)
+
+	const cleanups = 5
+	var running, maxRunning int32
+	started := make(chan struct{}, cleanups)
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < cleanups; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dockerTaskEngine.runTaskCleanup(func() {
+				n := atomic.AddInt32(&running, 1)
+				for {
+					prev := atomic.LoadInt32(&maxRunning)
+					if n <= prev || atomic.CompareAndSwapInt32(&maxRunning, prev, n) {
+						break
+					}
+				}
+				started <- struct{}{}
+				<-release
+				atomic.AddInt32(&running, -1)
+			})
+		}()
+	}
+
+	// Two cleanups run while the others wait for one of them to finish
+	<-started
+	<-started
+	select {
+	case <-started:
+		t.Fatal("more task cleanups running than allowed")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	wg.Wait()
+	assert.Len(t, started, cleanups-2)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxRunning))
+}
+
+func TestStopTasksForSpotInterruption(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	cfg := defaultConfig
+	cfg.SpotInterruptionTaskStopEnabled = config.BooleanDefaultFalse{Value: config.ExplicitlyEnabled}
+	ctrl, _, _, taskEngine, _, _, _, _ := mocks(t, ctx, &cfg)
+	defer ctrl.Finish()
+	dockerTaskEngine := taskEngine.(*DockerTaskEngine)
+
+	newManagedTask := func(arn string, desiredStatus apitaskstatus.TaskStatus, labels map[string]string) *managedTask {
+		rawConfig, err := json.Marshal(&dockercontainer.Config{Labels: labels})
+		require.NoError(t, err)
+		rawConfigStr := string(rawConfig)
+		task := &apitask.Task{
+			Arn: arn,
+			Containers: []*apicontainer.Container{{
+				Name:         "web",
+				DockerConfig: apicontainer.DockerConfig{Config: &rawConfigStr},
+			}},
+		}
+		task.SetDesiredStatus(desiredStatus)
+		mtask := &managedTask{
+			Task:        task,
+			ctx:         ctx,
+			acsMessages: make(chan acsTransition, 1),
+		}
+		dockerTaskEngine.managedTasks[arn] = mtask
+		return mtask
+	}
+	running := newManagedTask("arn:aws:ecs:us-west-2:1234:task/running", apitaskstatus.TaskRunning, nil)
+	optedIn := newManagedTask("arn:aws:ecs:us-west-2:1234:task/opted-in", apitaskstatus.TaskRunning,
+		map[string]string{apitask.SpotInterruptionStopLabel: apitask.SpotInterruptionStopGraceful})
+	optedOut := newManagedTask("arn:aws:ecs:us-west-2:1234:task/opted-out", apitaskstatus.TaskRunning,
+		map[string]string{apitask.SpotInterruptionStopLabel: apitask.SpotInterruptionStopNone})
+	stopping := newManagedTask("arn:aws:ecs:us-west-2:1234:task/stopping", apitaskstatus.TaskStopped, nil)
+
+	dockerTaskEngine.StopTasksForSpotInterruption()
+
+	for _, mtask := range []*managedTask{running, optedIn} {
+		require.Len(t, mtask.acsMessages, 1, "expected task %s to be stopped", mtask.Arn)
+		assert.Equal(t, acsTransition{desiredStatus: apitaskstatus.TaskStopped}, <-mtask.acsMessages)
+		assert.Equal(t, spotInterruptionReason, mtask.GetTerminalReason())
+	}
+	for _, mtask := range []*managedTask{optedOut, stopping} {
+		assert.Empty(t, mtask.acsMessages, "expected task %s not to be stopped", mtask.Arn)
+		assert.Empty(t, mtask.GetTerminalReason())
+	}
+}
+
+func TestStopTasksForShutdown(t *testing.T) {
+	defer func(interval time.Duration) {
+		shutdownStopPollInterval = interval
+	}(shutdownStopPollInterval)
+	shutdownStopPollInterval = time.Millisecond
+
+	testCases := []struct {
+		name          string
+		enabled       bool
+		stopsInTime   bool
+		expectError   bool
+		expectStopped bool
+	}{
+		{name: "tasks stop within the timeout", enabled: true, stopsInTime: true, expectStopped: true},
+		{name: "tasks stop beyond the timeout", enabled: true, stopsInTime: false, expectError: true, expectStopped: true},
+		{name: "disabled", enabled: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			cfg := defaultConfig
+			cfg.ShutdownStopTimeout = 100 * time.Millisecond
+			if tc.enabled {
+				cfg.StopContainersOnShutdown = config.BooleanDefaultFalse{Value: config.ExplicitlyEnabled}
+			}
+			ctrl, _, _, taskEngine, _, _, _, _ := mocks(t, ctx, &cfg)
+			defer ctrl.Finish()
+			dockerTaskEngine := taskEngine.(*DockerTaskEngine)
+
+			newManagedTask := func(arn string, knownStatus apitaskstatus.TaskStatus) *managedTask {
+				task := &apitask.Task{
+					Arn:        arn,
+					Containers: []*apicontainer.Container{{Name: "web"}},
+				}
+				task.SetKnownStatus(knownStatus)
+				task.SetDesiredStatus(apitaskstatus.TaskRunning)
+				mtask := &managedTask{
+					Task:        task,
+					ctx:         ctx,
+					acsMessages: make(chan acsTransition, 1),
+				}
+				dockerTaskEngine.managedTasks[arn] = mtask
+				return mtask
+			}
+			running := newManagedTask("arn:aws:ecs:us-west-2:1234:task/running", apitaskstatus.TaskRunning)
+			stopped := newManagedTask("arn:aws:ecs:us-west-2:1234:task/stopped", apitaskstatus.TaskStopped)
+
+			// Simulate the task manager stopping the containers of the task, within the timeout or not
+			stopDelay := 10 * time.Millisecond
+			if !tc.stopsInTime {
+				stopDelay = 500 * time.Millisecond
+			}
+			transitions := make(chan acsTransition, 1)
+			go func() {
+				select {
+				case transition := <-running.acsMessages:
+					transitions <- transition
+				case <-ctx.Done():
+					return
+				}
+				time.Sleep(stopDelay)
+				running.SetKnownStatus(apitaskstatus.TaskStopped)
+			}()
+
+			start := time.Now()
+			err := dockerTaskEngine.StopTasksForShutdown()
+			if tc.expectError {
+				assert.Error(t, err)
+				assert.True(t, time.Since(start) >= cfg.ShutdownStopTimeout, "expected to wait for the timeout")
+				assert.True(t, time.Since(start) < stopDelay, "expected not to wait beyond the timeout")
+			} else {
+				assert.NoError(t, err)
+			}
+			if tc.expectStopped {
+				require.Len(t, transitions, 1, "expected task to be stopped")
+				assert.Equal(t, acsTransition{desiredStatus: apitaskstatus.TaskStopped}, <-transitions)
+				assert.Equal(t, agentShutdownReason, running.GetTerminalReason())
+			} else {
+				assert.Empty(t, transitions, "expected task not to be stopped")
+				assert.Empty(t, running.GetTerminalReason())
+			}
+			assert.Empty(t, stopped.acsMessages)
+			assert.Empty(t, stopped.GetTerminalReason())
+		})
+	}
+}
+
+const quarantinedImageDigest = "sha256:3b0a4f5e8d7c6b5a49382716a5b4c3d2e1f0a9b8c7d6e5f4a3b2c1d0e9f8a7b6"
+
+func TestCreateContainerQuarantinedImage(t *testing.T) {
+	testCases := []struct {
+		name        string
+		imageID     string
+		imageDigest string
+	}{
+		{name: "image ID", imageID: quarantinedImageDigest},
+		{name: "image digest", imageID: "sha256:other", imageDigest: quarantinedImageDigest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			ctrl, _, _, taskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+			defer ctrl.Finish()
+			dockerTaskEngine := taskEngine.(*DockerTaskEngine)
+
+			_, err := dockerTaskEngine.QuarantineImage("busybox@" + quarantinedImageDigest)
+			require.NoError(t, err)
+			assert.Equal(t, []string{quarantinedImageDigest}, dockerTaskEngine.QuarantinedImages())
+
+			testTask := testdata.LoadTask("sleep5")
+			container := testTask.Containers[0]
+			container.ImageID = tc.imageID
+			container.SetImageDigest(tc.imageDigest)
+
+			// The container is refused before docker is called
+			metadata := dockerTaskEngine.createContainer(testTask, container)
+			require.Error(t, metadata.Error)
+			assert.Equal(t, "ImageQuarantinedError", metadata.Error.ErrorName())
+			assert.Contains(t, metadata.Error.Error(), quarantinedImageDigest)
+		})
+	}
+}
+
+func TestQuarantineImageInvalidReference(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, _, _, taskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+	dockerTaskEngine := taskEngine.(*DockerTaskEngine)
+
+	_, err := dockerTaskEngine.QuarantineImage("busybox:latest")
+	assert.Error(t, err)
+	assert.Error(t, dockerTaskEngine.UnquarantineImage("sha256:abc"))
+	assert.Empty(t, dockerTaskEngine.QuarantinedImages())
+
+	_, err = dockerTaskEngine.QuarantineImage(quarantinedImageDigest)
+	require.NoError(t, err)
+	require.NoError(t, dockerTaskEngine.UnquarantineImage(quarantinedImageDigest))
+	assert.Empty(t, dockerTaskEngine.QuarantinedImages())
+}
+
+func TestQuarantinedImagesRestoredOnInit(t *testing.T) {
+	dataClient, cleanup := newTestDataClient(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, _, _, taskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+	dockerTaskEngine := taskEngine.(*DockerTaskEngine)
+	dockerTaskEngine.SetDataClient(dataClient)
+	const liftedImageDigest = "sha256:0a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f9"
+	_, err := dockerTaskEngine.QuarantineImage(quarantinedImageDigest)
+	require.NoError(t, err)
+	_, err = dockerTaskEngine.QuarantineImage(liftedImageDigest)
+	require.NoError(t, err)
+	require.NoError(t, dockerTaskEngine.UnquarantineImage(liftedImageDigest))
+
+	// A new engine, as created when the agent restarts, picks the quarantine up from the saved data
+	ctrl, client, _, taskEngine, _, _, _, serviceConnectManager := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+	restartedTaskEngine := taskEngine.(*DockerTaskEngine)
+	restartedTaskEngine.SetDataClient(dataClient)
+	client.EXPECT().ContainerEvents(gomock.Any()).Return(make(chan dockerapi.DockerContainerChangeEvent), nil)
+	client.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().Info(gomock.Any(), dockerclient.InfoTimeout).Return(types.Info{}, nil)
+	serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().AnyTimes()
+	require.NoError(t, restartedTaskEngine.Init(ctx))
+
+	assert.Equal(t, []string{quarantinedImageDigest}, restartedTaskEngine.QuarantinedImages())
+}
+
+func TestPinImage(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, _, _, taskEngine, _, imageManager, _, _ := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+	dockerTaskEngine := taskEngine.(*DockerTaskEngine)
+
+	for _, ttl := range []time.Duration{0, -time.Minute, maxImagePinTTL + time.Second} {
+		_, err := dockerTaskEngine.PinImage("app:v1", ttl)
+		assert.Error(t, err, "pin duration %s should be rejected", ttl)
+	}
+	_, err := dockerTaskEngine.PinImage("", time.Hour)
+	assert.Error(t, err)
+
+	expiresAt := time.Now().Add(time.Hour)
+	imageManager.EXPECT().PinImage("app:v1", time.Hour).Return(expiresAt)
+	pinExpiresAt, err := dockerTaskEngine.PinImage("app:v1", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, expiresAt, pinExpiresAt)
+}
+
+func TestCheckHealth(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, client, _, taskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+	dockerTaskEngine := taskEngine.(*DockerTaskEngine)
+
+	// The daemon is not pinged while the engine does not process docker events
+	err := dockerTaskEngine.CheckHealth()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "task engine is not running")
+
+	atomic.StoreUint32(&dockerTaskEngine.handlingDockerEvents, 1)
+	client.EXPECT().SystemPing(gomock.Any(), healthPingTimeout).Return(dockerapi.PingResponse{})
+	assert.NoError(t, dockerTaskEngine.CheckHealth())
+	assert.NoError(t, dockerTaskEngine.CheckHealth(), "the cached ping should be reused")
+
+	dockerTaskEngine.lastHealthPingAt = time.Time{}
+	client.EXPECT().SystemPing(gomock.Any(), healthPingTimeout).Return(dockerapi.PingResponse{
+		Error: errors.New("connection refused"),
+	})
+	err = dockerTaskEngine.CheckHealth()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "docker daemon is unreachable")
+}
+
+func TestQuarantineImageStopsTasks(t *testing.T) {
+	testCases := []struct {
+		name      string
+		stopTasks bool
+	}{
+		{name: "stop tasks enabled", stopTasks: true},
+		{name: "stop tasks disabled", stopTasks: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			cfg := defaultConfig
+			if tc.stopTasks {
+				cfg.ImageQuarantineStopTasks = config.BooleanDefaultFalse{Value: config.ExplicitlyEnabled}
+			}
+			ctrl, _, _, taskEngine, _, _, _, _ := mocks(t, ctx, &cfg)
+			defer ctrl.Finish()
+			dockerTaskEngine := taskEngine.(*DockerTaskEngine)
+
+			newManagedTask := func(arn string, desiredStatus apitaskstatus.TaskStatus, imageID, imageDigest string) *managedTask {
+				container := &apicontainer.Container{Name: "web", ImageID: imageID}
+				container.SetImageDigest(imageDigest)
+				task := &apitask.Task{
+					Arn:        arn,
+					Containers: []*apicontainer.Container{container},
+				}
+				task.SetDesiredStatus(desiredStatus)
+				mtask := &managedTask{
+					Task:        task,
+					ctx:         ctx,
+					acsMessages: make(chan acsTransition, 1),
+				}
+				dockerTaskEngine.managedTasks[arn] = mtask
+				return mtask
+			}
+			byImageID := newManagedTask("arn:aws:ecs:us-west-2:1234:task/by-image-id", apitaskstatus.TaskRunning,
+				quarantinedImageDigest, "")
+			byDigest := newManagedTask("arn:aws:ecs:us-west-2:1234:task/by-digest", apitaskstatus.TaskRunning,
+				"sha256:other", quarantinedImageDigest)
+			otherImage := newManagedTask("arn:aws:ecs:us-west-2:1234:task/other-image", apitaskstatus.TaskRunning,
+				"sha256:other", "sha256:other-digest")
+			stopping := newManagedTask("arn:aws:ecs:us-west-2:1234:task/stopping", apitaskstatus.TaskStopped,
+				quarantinedImageDigest, "")
+
+			stoppedTasks, err := dockerTaskEngine.QuarantineImage(quarantinedImageDigest)
+			require.NoError(t, err)
+
+			if !tc.stopTasks {
+				assert.Empty(t, stoppedTasks)
+				for _, mtask := range []*managedTask{byImageID, byDigest, otherImage, stopping} {
+					assert.Empty(t, mtask.acsMessages, "expected task %s not to be stopped", mtask.Arn)
+				}
+				return
+			}
+			assert.Equal(t, []string{byDigest.Arn, byImageID.Arn}, stoppedTasks)
+			for _, mtask := range []*managedTask{byImageID, byDigest} {
+				require.Len(t, mtask.acsMessages, 1, "expected task %s to be stopped", mtask.Arn)
+				assert.Equal(t, acsTransition{desiredStatus: apitaskstatus.TaskStopped}, <-mtask.acsMessages)
+				assert.Equal(t, "ImageQuarantined: "+quarantinedImageDigest, mtask.GetTerminalReason())
+			}
+			for _, mtask := range []*managedTask{otherImage, stopping} {
+				assert.Empty(t, mtask.acsMessages, "expected task %s not to be stopped", mtask.Arn)
+				assert.Empty(t, mtask.GetTerminalReason())
+			}
+		})
+	}
+}
+
+// fakeDNSLookuper answers every resolution with the configured error
+type fakeDNSLookuper struct {
+	err   error
+	hosts []string
+}
+
+func (l *fakeDNSLookuper) LookupHost(ctx context.Context, host string) ([]string, error) {
+	l.hosts = append(l.hosts, host)
+	if _, ok := ctx.Deadline(); !ok {
+		return nil, errors.New("resolution without a deadline")
+	}
+	return nil, l.err
+}
+
+func TestMeasureDNSLatency(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	testCases := []struct {
+		name            string
+		lookupErr       error
+		expectedLatency time.Duration
+		expectedError   string
+	}{
+		{
+			name:            "resolver answered",
+			expectedLatency: 20 * time.Millisecond,
+		},
+		{
+			name:            "resolver does not know the probe host name",
+			lookupErr:       &net.DNSError{Err: "no such host", Name: dnsLatencyProbeHostname, IsNotFound: true},
+			expectedLatency: 20 * time.Millisecond,
+		},
+		{
+			name:          "resolver did not answer",
+			lookupErr:     &net.DNSError{Err: "i/o timeout", Name: dnsLatencyProbeHostname, IsTimeout: true},
+			expectedError: "lookup amazonaws.com.: i/o timeout",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockTime := mock_ttime.NewMockTime(ctrl)
+			gomock.InOrder(
+				mockTime.EXPECT().Now().Return(start),
+				mockTime.EXPECT().Now().Return(start.Add(20*time.Millisecond)),
+			)
+			lookuper := &fakeDNSLookuper{err: tc.lookupErr}
+
+			latency := measureDNSLatency(context.TODO(), lookuper, "10.0.0.2", mockTime)
+			assert.Equal(t, []string{dnsLatencyProbeHostname}, lookuper.hosts)
+			assert.Equal(t, apitask.DNSLatency{
+				Resolver:  "10.0.0.2",
+				Latency:   tc.expectedLatency,
+				Error:     tc.expectedError,
+				CheckedAt: start.Add(20 * time.Millisecond),
+			}, latency)
+		})
+	}
+}
+
+func TestCheckContainerClockDrift(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, client, mockTime, taskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+
+	testTask := testdata.LoadTask("sleep5")
+	container := testTask.Containers[0]
+	container.SetRuntimeID(containerID)
+	hostTime := time.Unix(1700000000, 0)
+
+	gomock.InOrder(
+		client.EXPECT().CreateContainerExec(gomock.Any(), containerID,
+			types.ExecConfig{Cmd: clockProbeCommand, AttachStdout: true, Tty: true}, gomock.Any()).
+			Return(&types.IDResponse{ID: "execID"}, nil),
+		mockTime.EXPECT().Now().Return(hostTime),
+		client.EXPECT().AttachContainerExec(gomock.Any(), "execID", types.ExecStartCheck{Tty: true}, gomock.Any()).
+			Return([]byte("1700000030\r\n"), nil),
+		mockTime.EXPECT().Now().Return(hostTime.Add(time.Second)),
+	)
+
+	taskEngine.(*DockerTaskEngine).checkContainerClockDrift(ctx, testTask, container)
+	drift, checkedAt, ok := container.GetClockDrift()
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, drift)
+	assert.Equal(t, hostTime.Add(time.Second), checkedAt)
+}
+
+func TestCheckContainerClockDriftInvalidOutput(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, client, mockTime, taskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+
+	testTask := testdata.LoadTask("sleep5")
+	container := testTask.Containers[0]
+	container.SetRuntimeID(containerID)
+
+	client.EXPECT().CreateContainerExec(gomock.Any(), containerID, gomock.Any(), gomock.Any()).
+		Return(&types.IDResponse{ID: "execID"}, nil)
+	client.EXPECT().AttachContainerExec(gomock.Any(), "execID", gomock.Any(), gomock.Any()).
+		Return([]byte("date: not found"), nil)
+	mockTime.EXPECT().Now().Return(time.Now()).Times(2)
+
+	taskEngine.(*DockerTaskEngine).checkContainerClockDrift(ctx, testTask, container)
+	_, _, ok := container.GetClockDrift()
+	assert.False(t, ok)
+}
+
+// TestStopPauseContainerCleanupDelayAwsvpc tests when stopping the pause container
+// its network namespace should be cleaned up first
+func TestStopPauseContainerCleanupDelayAwsvpc(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	cfg := config.DefaultConfig()
+	cfg.TaskCPUMemLimit.Value = config.ExplicitlyDisabled
+	cfg.ENIPauseContainerCleanupDelaySeconds = expectedDelaySeconds
+
+	delayedChan := make(chan time.Duration, 1)
+	ctrl, dockerClient, _, taskEngine, _, _, _, _ := mocks(t, ctx, &cfg)
+	taskEngine.(*DockerTaskEngine).handleDelay = func(d time.Duration) {
+		delayedChan <- d
+	}
+
+	mockCNIClient := mock_ecscni.NewMockCNIClient(ctrl)
+	taskEngine.(*DockerTaskEngine).cniClient = mockCNIClient
+	testTask := testdata.LoadTask("sleep5")
+	pauseContainer := &apicontainer.Container{
+		Name:                "pausecontainer",
+		Type:                apicontainer.ContainerCNIPause,
+		DesiredStatusUnsafe: apicontainerstatus.ContainerStopped,
+	}
+	testTask.Containers = append(testTask.Containers, pauseContainer)
+	testTask.AddTaskENI(mockENI)
+	testTask.NetworkMode = apitask.AWSVPCNetworkMode
+	taskEngine.(*DockerTaskEngine).State().AddTask(testTask)
+	taskEngine.(*DockerTaskEngine).State().AddContainer(&apicontainer.DockerContainer{
+		DockerID:   containerID,
+		DockerName: dockerContainerName,
+		Container:  pauseContainer,
+	}, testTask)
+
+	gomock.InOrder(
+		dockerClient.EXPECT().InspectContainer(gomock.Any(), containerID, gomock.Any()).Return(&types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{
+				ID:    containerID,
+				State: &types.ContainerState{Pid: containerPid},
+				HostConfig: &dockercontainer.HostConfig{
+					NetworkMode: containerNetworkMode,
+				},
+			},
+		}, nil),
+		mockCNIClient.EXPECT().CleanupNS(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil),
+		dockerClient.EXPECT().StopContainer(gomock.Any(),
+			containerID,
+			defaultConfig.DockerStopTimeout,
+		).Return(dockerapi.DockerContainerMetadata{}),
+	)
+
+	taskEngine.(*DockerTaskEngine).stopContainer(testTask, pauseContainer)
+
+	select {
+	case actualDelay := <-delayedChan:
+		assert.Equal(t, expectedDelay, actualDelay)
+		require.True(t, pauseContainer.IsContainerTornDown())
+	default:
+		assert.Fail(t, "engine.handleDelay wasn't called")
+	}
+}
+
+// TestCheckTearDownPauseContainerAwsvpc that the pause container teardown works and is idempotent
+func TestCheckTearDownPauseContainerAwsvpc(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, dockerClient, _, taskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+
+	mockCNIClient := mock_ecscni.NewMockCNIClient(ctrl)
+	taskEngine.(*DockerTaskEngine).cniClient = mockCNIClient
+	testTask := testdata.LoadTask("sleep5")
+	pauseContainer := &apicontainer.Container{
+		Name:                "pausecontainer",
+		Type:                apicontainer.ContainerCNIPause,
+		DesiredStatusUnsafe: apicontainerstatus.ContainerStopped,
+	}
+	testTask.Containers = append(testTask.Containers, pauseContainer)
+	testTask.AddTaskENI(mockENI)
+	testTask.NetworkMode = apitask.AWSVPCNetworkMode
+	testTask.SetAppMesh(&appmesh.AppMesh{
+		IgnoredUID:       ignoredUID,
+		ProxyIngressPort: proxyIngressPort,
+		ProxyEgressPort:  proxyEgressPort,
+		AppPorts: []string{
+			appPort,
+		},
+		EgressIgnoredIPs: []string{
+			egressIgnoredIP,
+		},
+	})
+	taskEngine.(*DockerTaskEngine).State().AddTask(testTask)
+	taskEngine.(*DockerTaskEngine).State().AddContainer(&apicontainer.DockerContainer{
+		DockerID:   containerID,
+		DockerName: dockerContainerName,
+		Container:  pauseContainer,
+	}, testTask)
+
+	gomock.InOrder(
+		dockerClient.EXPECT().InspectContainer(gomock.Any(), containerID, gomock.Any()).Return(&types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{
+				ID:    containerID,
+				State: &types.ContainerState{Pid: containerPid},
+				HostConfig: &dockercontainer.HostConfig{
+					NetworkMode: containerNetworkMode,
+				},
+			},
+		}, nil).MaxTimes(1),
+		mockCNIClient.EXPECT().CleanupNS(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).MaxTimes(1),
+	)
+
+	taskEngine.(*DockerTaskEngine).checkTearDownPauseContainer(testTask)
+	require.True(t, pauseContainer.IsContainerTornDown())
+
+	// Invoke one more time to check for idempotency (mocks configured with maxTimes = 1)
+	taskEngine.(*DockerTaskEngine).checkTearDownPauseContainer(testTask)
+}
+
+// TestTaskWithCircularDependency tests the task with containers of which the
+// dependencies can't be resolved
+func TestTaskWithCircularDependency(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, client, _, taskEngine, _, _, _, serviceConnectManager := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+
+	client.EXPECT().ContainerEvents(gomock.Any())
+	client.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().Info(gomock.Any(), dockerclient.InfoTimeout).Return(types.Info{}, nil)
+	serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().AnyTimes()
+
+	task := testdata.LoadTask("circular_dependency")
+
+	err := taskEngine.Init(ctx)
+	assert.NoError(t, err)
+
+	events := taskEngine.StateChangeEvents()
+	go taskEngine.AddTask(task)
+	event := <-events
+	assert.Equal(t, event.(api.TaskStateChange).Status, apitaskstatus.TaskStopped, "Expected task to move to stopped directly")
+	_, ok := taskEngine.(*DockerTaskEngine).state.TaskByArn(task.Arn)
+	assert.True(t, ok, "Task state should be added to the agent state")
+
+	_, ok = taskEngine.(*DockerTaskEngine).managedTasks[task.Arn]
+	assert.False(t, ok, "Task should not be added to task manager for processing")
+}
+
+// TestTaskWithThreeContainerDependencyCycle tests that a task whose containers depend on each other
+// in a cycle is rejected with the containers of the cycle before any container is created
+func TestTaskWithThreeContainerDependencyCycle(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, client, _, taskEngine, _, _, _, serviceConnectManager := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+
+	// No other docker calls are expected as no container of the task may be pulled or created
+	client.EXPECT().ContainerEvents(gomock.Any())
+	client.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().Info(gomock.Any(), dockerclient.InfoTimeout).Return(types.Info{}, nil)
+	serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().AnyTimes()
+
+	task := testdata.LoadTask("sleep5")
+	task.Containers = []*apicontainer.Container{
+		{
+			Name:            "a",
+			Image:           "busybox",
+			DependsOnUnsafe: []apicontainer.DependsOn{{ContainerName: "b", Condition: "START"}},
+		},
+		{
+			Name:            "b",
+			Image:           "busybox",
+			DependsOnUnsafe: []apicontainer.DependsOn{{ContainerName: "c", Condition: "START"}},
+		},
+		{
+			Name:            "c",
+			Image:           "busybox",
+			DependsOnUnsafe: []apicontainer.DependsOn{{ContainerName: "a", Condition: "START"}},
+		},
+	}
+
+	err := taskEngine.Init(ctx)
+	assert.NoError(t, err)
+
+	events := taskEngine.StateChangeEvents()
+	go taskEngine.AddTask(task)
+	event := <-events
+	taskChange := event.(api.TaskStateChange)
+	assert.Equal(t, apitaskstatus.TaskStopped, taskChange.Status, "Expected task to move to stopped directly")
+	assert.Equal(t, TaskDependencyError{taskArn: task.Arn, cycle: []string{"a", "b", "c"}}.Error(), taskChange.Reason)
+	assert.Contains(t, taskChange.Reason, "a -> b -> c")
+	for _, container := range task.Containers {
+		assert.Equal(t, apicontainerstatus.ContainerStatusNone, container.GetKnownStatus(),
+			"Container %s should not be created", container.Name)
+	}
+	_, ok := taskEngine.(*DockerTaskEngine).managedTasks[task.Arn]
+	assert.False(t, ok, "Task should not be added to task manager for processing")
+}
+
+// TestAddTaskWhileDrained tests that new tasks are refused while the task engine
+// is drained and accepted again once it is undrained
+func TestAddTaskWhileDrained(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, client, _, taskEngine, _, _, _, serviceConnectManager := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+
+	client.EXPECT().ContainerEvents(gomock.Any())
+	client.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().Info(gomock.Any(), dockerclient.InfoTimeout).Return(types.Info{}, nil)
+	serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().AnyTimes()
+
+	err := taskEngine.Init(ctx)
+	assert.NoError(t, err)
+	dockerTaskEngine := taskEngine.(*DockerTaskEngine)
+	events := taskEngine.StateChangeEvents()
+
+	dockerTaskEngine.SetDrained(true)
+	assert.True(t, dockerTaskEngine.IsDrained())
+	task := testdata.LoadTask("circular_dependency")
+	go taskEngine.AddTask(task)
+	event := <-events
+	assert.Equal(t, apitaskstatus.TaskStopped, event.(api.TaskStateChange).Status, "Expected task to be refused")
+	assert.Equal(t, TaskEngineDrainedError{task.Arn}.Error(), event.(api.TaskStateChange).Reason)
+	_, ok := dockerTaskEngine.state.TaskByArn(task.Arn)
+	assert.False(t, ok, "Refused task should not be added to the agent state")
+
+	// The circular dependency task never starts, but it is accepted into the state once undrained
+	dockerTaskEngine.SetDrained(false)
+	assert.False(t, dockerTaskEngine.IsDrained())
+	task = testdata.LoadTask("circular_dependency")
+	go taskEngine.AddTask(task)
+	event = <-events
+	assert.Equal(t, apitaskstatus.TaskStopped, event.(api.TaskStateChange).Status)
+	assert.NotEqual(t, TaskEngineDrainedError{task.Arn}.Error(), event.(api.TaskStateChange).Reason)
+	_, ok = dockerTaskEngine.state.TaskByArn(task.Arn)
+	assert.True(t, ok, "Task should be added to the agent state once undrained")
+}
+
+func TestValidateTaskLogDrivers(t *testing.T) {
+	newTask := func(logDrivers ...string) *apitask.Task {
+		task := &apitask.Task{Arn: "arn:aws:ecs:us-west-2:1234:task/log-drivers"}
+		for i, logDriver := range logDrivers {
+			hostConfig := fmt.Sprintf(`{"LogConfig":{"Type":"%s"}}`, logDriver)
+			task.Containers = append(task.Containers, &apicontainer.Container{
+				Name:         fmt.Sprintf("container%d", i),
+				DockerConfig: apicontainer.DockerConfig{HostConfig: &hostConfig},
+			})
+		}
+		return task
+	}
+	testCases := []struct {
+		name          string
+		task          *apitask.Task
+		info          types.Info
+		infoErr       error
+		refreshes     int
+		refreshedInfo types.Info
+		expectedError error
+	}{
+		{
+			name: "supported log drivers",
+			task: newTask("awslogs", "awsfirelens", "none"),
+			info: types.Info{Plugins: types.PluginsInfo{Log: []string{"awslogs", "fluentd", "json-file"}}},
+		},
+		{
+			name:          "log driver installed after the engine was initialized",
+			task:          newTask("awslogs", "splunk"),
+			info:          types.Info{Plugins: types.PluginsInfo{Log: []string{"awslogs", "json-file"}}},
+			refreshes:     1,
+			refreshedInfo: types.Info{Plugins: types.PluginsInfo{Log: []string{"awslogs", "json-file", "splunk"}}},
+		},
+		{
+			name:          "unsupported log driver",
+			task:          newTask("awslogs", "splunk"),
+			info:          types.Info{Plugins: types.PluginsInfo{Log: []string{"awslogs", "json-file"}}},
+			refreshes:     2,
+			refreshedInfo: types.Info{Plugins: types.PluginsInfo{Log: []string{"awslogs", "json-file"}}},
+			expectedError: UnsupportedLogDriverError{
+				taskArn:   "arn:aws:ecs:us-west-2:1234:task/log-drivers",
+				container: "container1",
+				logDriver: "splunk",
+			},
+		},
+		{
+			name:    "log drivers not queried",
+			task:    newTask("splunk"),
+			infoErr: errors.New("error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			ctrl, client, _, taskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+			defer ctrl.Finish()
+			dockerTaskEngine := taskEngine.(*DockerTaskEngine)
+
+			// The supported log drivers are queried when the engine is initialized, and are not queried
+			// again for the tasks unless a log driver is to be rejected
+			client.EXPECT().Info(gomock.Any(), dockerclient.InfoTimeout).Return(tc.info, tc.infoErr)
+			if tc.refreshes > 0 {
+				client.EXPECT().Info(gomock.Any(), dockerclient.InfoTimeout).Return(tc.refreshedInfo, nil).Times(tc.refreshes)
+			}
+			dockerTaskEngine.refreshDockerInfo()
+			for i := 0; i < 2; i++ {
+				assert.Equal(t, tc.expectedError, dockerTaskEngine.validateTaskLogDrivers(tc.task))
+			}
+		})
+	}
+}
+
+func TestValidateTaskEphemeralStorage(t *testing.T) {
+	overlay2OnXFS := types.Info{
+		Driver:       "overlay2",
+		DriverStatus: [][2]string{{"Backing Filesystem", "xfs"}, {"Supports d_type", "true"}},
+	}
+	testCases := []struct {
+		name          string
+		labels        map[string]string
+		info          *types.Info
+		infoErr       error
+		expectedError error
+	}{
+		{
+			name:   "ephemeral storage size not set",
+			labels: map[string]string{"key": "value"},
+		},
+		{
+			name:   "supported storage driver",
+			labels: map[string]string{apitask.EphemeralStorageSizeLabel: "30"},
+			info:   &overlay2OnXFS,
+		},
+		{
+			name:   "overlay2 not backed by xfs",
+			labels: map[string]string{apitask.EphemeralStorageSizeLabel: "30"},
+			info: &types.Info{
+				Driver:       "overlay2",
+				DriverStatus: [][2]string{{"Backing Filesystem", "extfs"}},
+			},
+			expectedError: UnsupportedEphemeralStorageError{taskArn: "myTaskArn", storageDriver: "overlay2"},
+		},
+		{
+			name:          "unsupported storage driver",
+			labels:        map[string]string{apitask.EphemeralStorageSizeLabel: "30"},
+			info:          &types.Info{Driver: "vfs"},
+			expectedError: UnsupportedEphemeralStorageError{taskArn: "myTaskArn", storageDriver: "vfs"},
+		},
+		{
+			name:    "storage driver not queried",
+			labels:  map[string]string{apitask.EphemeralStorageSizeLabel: "30"},
+			infoErr: errors.New("error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			ctrl, client, _, taskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+			defer ctrl.Finish()
+
+			rawConfig, err := json.Marshal(&dockercontainer.Config{Labels: tc.labels})
+			require.NoError(t, err)
+			task := &apitask.Task{
+				Arn: "myTaskArn",
+				Containers: []*apicontainer.Container{{
+					Name:         "c1",
+					DockerConfig: apicontainer.DockerConfig{Config: aws.String(string(rawConfig))},
+				}},
+			}
+			if tc.info != nil {
+				client.EXPECT().Info(gomock.Any(), dockerclient.InfoTimeout).Return(*tc.info, nil)
+			} else {
+				client.EXPECT().Info(gomock.Any(), dockerclient.InfoTimeout).Return(types.Info{}, tc.infoErr)
+			}
+			taskEngine.(*DockerTaskEngine).refreshDockerInfo()
+			assert.Equal(t, tc.expectedError, taskEngine.(*DockerTaskEngine).validateTaskEphemeralStorage(task))
+		})
+	}
+}
+
+// TestAddTaskUnsupportedLogDriver tests that a task using a log driver which is not
+// supported by the docker daemon is stopped before it is started
+func TestAddTaskUnsupportedLogDriver(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, client, _, taskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+	dockerTaskEngine := taskEngine.(*DockerTaskEngine)
+	events := taskEngine.StateChangeEvents()
+
+	// The supported log drivers are queried when the engine is initialized, and again before rejecting
+	// the log driver
+	client.EXPECT().Info(gomock.Any(), dockerclient.InfoTimeout).Return(
+		types.Info{Plugins: types.PluginsInfo{Log: []string{"awslogs", "json-file"}}}, nil).Times(2)
+	dockerTaskEngine.refreshDockerInfo()
+	task := testdata.LoadTask("sleep5")
+	hostConfig := `{"LogConfig":{"Type":"splunk"}}`
+	task.Containers[0].DockerConfig.HostConfig = &hostConfig
+	go taskEngine.AddTask(task)
+	event := <-events
+	assert.Equal(t, apitaskstatus.TaskStopped, event.(api.TaskStateChange).Status, "Expected task to be stopped")
+	assert.Contains(t, event.(api.TaskStateChange).Reason, "splunk")
+	_, ok := dockerTaskEngine.state.TaskByArn(task.Arn)
+	assert.False(t, ok, "Task with an unsupported log driver should not be added to the agent state")
+}
+
+func TestValidateTaskNetworkingPrerequisites(t *testing.T) {
+	awsvpcCapabilities := []string{ecscni.CapabilityAWSVPCNetworkingMode}
+	testCases := []struct {
+		name              string
+		networkMode       string
+		eni               *apieni.ENI
+		taskENIDisabled   bool
+		trunkingDisabled  bool
+		capabilities      []string
+		capabilitiesErr   error
+		expectedErrReason string
+	}{
+		{
+			name:        "bridge mode task",
+			networkMode: apitask.BridgeNetworkMode,
+		},
+		{
+			name:         "prerequisites satisfied",
+			networkMode:  apitask.AWSVPCNetworkMode,
+			eni:          &apieni.ENI{ID: "eni-1"},
+			capabilities: awsvpcCapabilities,
+		},
+		{
+			name:              "task networking not enabled",
+			networkMode:       apitask.AWSVPCNetworkMode,
+			eni:               &apieni.ENI{ID: "eni-1"},
+			taskENIDisabled:   true,
+			expectedErrReason: "task networking is not enabled",
+		},
+		{
+			name:              "no eni attached",
+			networkMode:       apitask.AWSVPCNetworkMode,
+			expectedErrReason: "no elastic network interface is attached",
+		},
+		{
+			name:        "trunking not enabled",
+			networkMode: apitask.AWSVPCNetworkMode,
+			eni: &apieni.ENI{
+				ID:                           "eni-1",
+				InterfaceAssociationProtocol: apieni.VLANInterfaceAssociationProtocol,
+			},
+			trunkingDisabled:  true,
+			expectedErrReason: "ENI trunking is not enabled",
+		},
+		{
+			name:              "plugin without awsvpc capability",
+			networkMode:       apitask.AWSVPCNetworkMode,
+			eni:               &apieni.ENI{ID: "eni-1"},
+			capabilities:      []string{},
+			expectedErrReason: "does not support the capability " + ecscni.CapabilityAWSVPCNetworkingMode,
+		},
+		{
+			name:              "plugin not queried",
+			networkMode:       apitask.AWSVPCNetworkMode,
+			eni:               &apieni.ENI{ID: "eni-1"},
+			capabilitiesErr:   errors.New("plugin not found"),
+			expectedErrReason: "plugin not found",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			cfg := defaultConfig
+			if tc.taskENIDisabled {
+				cfg.TaskENIEnabled = config.BooleanDefaultFalse{Value: config.ExplicitlyDisabled}
+			}
+			if tc.trunkingDisabled {
+				cfg.ENITrunkingEnabled = config.BooleanDefaultTrue{Value: config.ExplicitlyDisabled}
+			}
+			ctrl, _, _, taskEngine, _, _, _, _ := mocks(t, ctx, &cfg)
+			defer ctrl.Finish()
+			cniClient := mock_ecscni.NewMockCNIClient(ctrl)
+			taskEngine.(*DockerTaskEngine).cniClient = cniClient
+
+			task := &apitask.Task{Arn: "myTaskArn", NetworkMode: tc.networkMode}
+			if tc.eni != nil {
+				task.AddTaskENI(tc.eni)
+			}
+			if tc.capabilities != nil || tc.capabilitiesErr != nil {
+				queries := 1
+				if tc.expectedErrReason == "" {
+					// The plugins are queried once, and not again when validating the next tasks
+					queries = len(awsvpcCNIPlugins(tc.eni))
+				}
+				cniClient.EXPECT().Capabilities(gomock.Any()).Return(tc.capabilities, tc.capabilitiesErr).Times(queries)
+			}
+
+			err := taskEngine.(*DockerTaskEngine).validateTaskNetworkingPrerequisites(task)
+			if tc.expectedErrReason == "" {
+				assert.NoError(t, err)
+				assert.NoError(t, taskEngine.(*DockerTaskEngine).validateTaskNetworkingPrerequisites(task))
+				return
+			}
+			require.Error(t, err)
+			assert.IsType(t, TaskNetworkingPrerequisiteError{}, err)
+			assert.Contains(t, err.Error(), tc.expectedErrReason)
+		})
+	}
+}
+
+// TestAddTaskNetworkingPrerequisitesNotSatisfied tests that an awsvpc task is stopped before it is
+// started when the networking prerequisites are not satisfied
+func TestAddTaskNetworkingPrerequisitesNotSatisfied(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, _, _, taskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+	dockerTaskEngine := taskEngine.(*DockerTaskEngine)
+	events := taskEngine.StateChangeEvents()
+
+	task := testdata.LoadTask("sleep5")
+	task.Containers[0].TransitionDependenciesMap = make(map[apicontainerstatus.ContainerStatus]apicontainer.TransitionDependencySet)
+	task.NetworkMode = apitask.AWSVPCNetworkMode
 	go taskEngine.AddTask(task)
 	event := <-events
-	assert.Equal(t, event.(api.TaskStateChange).Status, apitaskstatus.TaskStopped, "Expected task to move to stopped directly")
-	_, ok := taskEngine.(*DockerTaskEngine).state.TaskByArn(task.Arn)
-	assert.True(t, ok, "Task state should be added to the agent state")
+	assert.Equal(t, apitaskstatus.TaskStopped, event.(api.TaskStateChange).Status, "Expected task to be stopped")
+	assert.Contains(t, event.(api.TaskStateChange).Reason, "no elastic network interface is attached to the task")
+	_, ok := dockerTaskEngine.state.TaskByArn(task.Arn)
+	assert.False(t, ok, "Task with unsatisfied networking prerequisites should not be added to the agent state")
+}
 
-	_, ok = taskEngine.(*DockerTaskEngine).managedTasks[task.Arn]
-	assert.False(t, ok, "Task should not be added to task manager for processing")
+func TestStopContainerPreRemoveCommand(t *testing.T) {
+	defer func(interval time.Duration) {
+		containerExecPollInterval = interval
+	}(containerExecPollInterval)
+	containerExecPollInterval = time.Millisecond
+
+	testCases := []struct {
+		name            string
+		labels          map[string]string
+		knownStatus     apicontainerstatus.ContainerStatus
+		setExpectations func(client *mock_dockerapi.MockDockerClient) *gomock.Call
+	}{
+		{
+			name: "container already exited",
+			labels: map[string]string{
+				apicontainer.PreRemoveCommandLabel: `["sync"]`,
+			},
+			knownStatus: apicontainerstatus.ContainerStopped,
+		},
+		{
+			name: "pre-remove command completes",
+			labels: map[string]string{
+				apicontainer.PreRemoveCommandLabel: `["/bin/sh","-c","sync"]`,
+			},
+			setExpectations: func(client *mock_dockerapi.MockDockerClient) *gomock.Call {
+				last := client.EXPECT().InspectContainerExec(gomock.Any(), "execID", dockerclient.ContainerExecInspectTimeout).
+					Return(&types.ContainerExecInspect{Running: false, ExitCode: 0}, nil)
+				gomock.InOrder(
+					client.EXPECT().CreateContainerExec(gomock.Any(), "dockerID",
+						types.ExecConfig{Cmd: []string{"/bin/sh", "-c", "sync"}}, dockerclient.ContainerExecCreateTimeout).
+						Return(&types.IDResponse{ID: "execID"}, nil),
+					client.EXPECT().StartContainerExec(gomock.Any(), "execID", types.ExecStartCheck{Detach: true},
+						dockerclient.ContainerExecStartTimeout).Return(nil),
+					client.EXPECT().InspectContainerExec(gomock.Any(), "execID", dockerclient.ContainerExecInspectTimeout).
+						Return(&types.ContainerExecInspect{Running: true}, nil),
+					last,
+				)
+				return last
+			},
+		},
+		{
+			name: "pre-remove command cannot be created",
+			labels: map[string]string{
+				apicontainer.PreRemoveCommandLabel: `["sync"]`,
+			},
+			setExpectations: func(client *mock_dockerapi.MockDockerClient) *gomock.Call {
+				return client.EXPECT().CreateContainerExec(gomock.Any(), "dockerID", gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("container is not running"))
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			ctrl, client, _, privateTaskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+			defer ctrl.Finish()
+			taskEngine := privateTaskEngine.(*DockerTaskEngine)
+
+			knownStatus := tc.knownStatus
+			if knownStatus == apicontainerstatus.ContainerStatusNone {
+				knownStatus = apicontainerstatus.ContainerRunning
+			}
+			task, container := preRemoveCommandTestTask(t, tc.labels, knownStatus)
+
+			// The pre-remove command runs before the container is stopped, and the container is stopped
+			// regardless of its outcome
+			stop := client.EXPECT().StopContainer(gomock.Any(), "dockerID", gomock.Any()).
+				Return(dockerapi.DockerContainerMetadata{})
+			if tc.setExpectations != nil {
+				stop.After(tc.setExpectations(client))
+			}
+			assert.NoError(t, taskEngine.stopContainer(task, container).Error)
+		})
+	}
+}
+
+// TestStopContainerWithoutPreRemoveCommand tests that no command is exec'd in a running container which does
+// not set the pre-remove command label before it is stopped
+func TestStopContainerWithoutPreRemoveCommand(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, client, _, privateTaskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+	taskEngine := privateTaskEngine.(*DockerTaskEngine)
+
+	// The pre-remove timeout is ignored without a command
+	task, container := preRemoveCommandTestTask(t, map[string]string{
+		apicontainer.PreRemoveTimeoutLabel: "1m",
+	}, apicontainerstatus.ContainerRunning)
+
+	// The mock fails the test on any exec call
+	client.EXPECT().StopContainer(gomock.Any(), "dockerID", gomock.Any()).Return(dockerapi.DockerContainerMetadata{})
+	assert.NoError(t, taskEngine.stopContainer(task, container).Error)
+}
+
+// TestStopContainerPreRemoveCommandTimeout tests that a pre-remove command which does not complete within
+// its timeout does not hold up the stop of the container
+func TestStopContainerPreRemoveCommandTimeout(t *testing.T) {
+	defer func(interval time.Duration) {
+		containerExecPollInterval = interval
+	}(containerExecPollInterval)
+	containerExecPollInterval = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, client, _, privateTaskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+	taskEngine := privateTaskEngine.(*DockerTaskEngine)
+
+	const timeout = 50 * time.Millisecond
+	task, container := preRemoveCommandTestTask(t, map[string]string{
+		apicontainer.PreRemoveCommandLabel: `["sleep","3600"]`,
+		apicontainer.PreRemoveTimeoutLabel: timeout.String(),
+	}, apicontainerstatus.ContainerRunning)
+
+	client.EXPECT().CreateContainerExec(gomock.Any(), "dockerID", gomock.Any(), gomock.Any()).
+		Return(&types.IDResponse{ID: "execID"}, nil)
+	client.EXPECT().StartContainerExec(gomock.Any(), "execID", gomock.Any(), gomock.Any()).Return(nil)
+	inspect := client.EXPECT().InspectContainerExec(gomock.Any(), "execID", gomock.Any()).
+		Return(&types.ContainerExecInspect{Running: true}, nil).MinTimes(1)
+	client.EXPECT().StopContainer(gomock.Any(), "dockerID", gomock.Any()).
+		Return(dockerapi.DockerContainerMetadata{}).After(inspect)
+
+	start := time.Now()
+	assert.NoError(t, taskEngine.stopContainer(task, container).Error)
+	elapsed := time.Since(start)
+	assert.True(t, elapsed >= timeout, "the pre-remove command should be waited for until its timeout")
+	assert.True(t, elapsed < 10*time.Second, "the stop should not wait for the pre-remove command past its timeout")
+}
+
+// preRemoveCommandTestTask returns a task with a single container with the given docker labels and known status
+func preRemoveCommandTestTask(t *testing.T, labels map[string]string,
+	knownStatus apicontainerstatus.ContainerStatus) (*apitask.Task, *apicontainer.Container) {
+	rawConfig, err := json.Marshal(&dockercontainer.Config{Labels: labels})
+	require.NoError(t, err)
+	container := &apicontainer.Container{
+		Name: "c1",
+		DockerConfig: apicontainer.DockerConfig{
+			Config: aws.String(string(rawConfig)),
+		},
+		KnownStatusUnsafe: knownStatus,
+	}
+	container.SetRuntimeID("dockerID")
+	task := &apitask.Task{
+		Arn:        "arn:aws:ecs:us-west-2:1234567890:task/test-cluster/1dc8bcb2-5d0a-4d27-9b3c-0dfcc4f1e3ce",
+		Containers: []*apicontainer.Container{container},
+	}
+	return task, container
+}
+
+func TestStartContainerRunsHealthCheckFirstProbe(t *testing.T) {
+	defer func(interval time.Duration) {
+		containerExecPollInterval = interval
+	}(containerExecPollInterval)
+	containerExecPollInterval = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, client, _, privateTaskEngine, _, _, _, _ := mocks(t, ctx, &config.Config{})
+	defer ctrl.Finish()
+	taskEngine := privateTaskEngine.(*DockerTaskEngine)
+
+	firstProbeTimeout := 2 * time.Minute
+	rawConfig, err := json.Marshal(&dockercontainer.Config{
+		Labels: map[string]string{
+			apicontainer.HealthCheckFirstProbeTimeoutLabel: firstProbeTimeout.String(),
+		},
+		Healthcheck: &dockercontainer.HealthConfig{
+			Test:    []string{"CMD-SHELL", "curl -f http://localhost/"},
+			Timeout: 5 * time.Second,
+		},
+	})
+	require.NoError(t, err)
+	container := &apicontainer.Container{
+		Name:            "c1",
+		HealthCheckType: apicontainer.DockerHealthCheckType,
+		DockerConfig: apicontainer.DockerConfig{
+			Config: aws.String(string(rawConfig)),
+		},
+	}
+	container.SetRuntimeID("dockerID")
+	task := &apitask.Task{
+		Arn:        "arn:aws:ecs:us-west-2:1234567890:task/test-cluster/1dc8bcb2-5d0a-4d27-9b3c-0dfcc4f1e3ce",
+		Containers: []*apicontainer.Container{container},
+	}
+
+	probeDone := make(chan struct{})
+	client.EXPECT().StartContainer(gomock.Any(), "dockerID", gomock.Any()).Return(
+		dockerapi.DockerContainerMetadata{DockerID: "dockerID"})
+	client.EXPECT().CreateContainerExec(gomock.Any(), "dockerID",
+		types.ExecConfig{Cmd: append(append([]string{}, healthCheckShell...), "curl -f http://localhost/")},
+		dockerclient.ContainerExecCreateTimeout).Do(
+		func(ctx context.Context, id string, config types.ExecConfig, timeout time.Duration) {
+			// The first probe is bound by the extended timeout rather than the probe timeout
+			deadline, ok := ctx.Deadline()
+			require.True(t, ok)
+			assert.True(t, time.Until(deadline) > 5*time.Second, "First probe should not use the probe timeout")
+			assert.True(t, time.Until(deadline) <= firstProbeTimeout)
+		}).Return(&types.IDResponse{ID: "execID"}, nil)
+	client.EXPECT().StartContainerExec(gomock.Any(), "execID", gomock.Any(), gomock.Any()).Return(nil)
+	client.EXPECT().InspectContainerExec(gomock.Any(), "execID", gomock.Any()).Do(
+		func(ctx context.Context, id string, timeout time.Duration) {
+			// Docker reporting the container unhealthy while the first probe runs is deferred
+			container.SetHealthStatus(apicontainer.HealthStatus{Status: apicontainerstatus.ContainerUnhealthy})
+		}).Return(&types.ContainerExecInspect{Running: true}, nil)
+	client.EXPECT().InspectContainerExec(gomock.Any(), "execID", gomock.Any()).Do(
+		func(ctx context.Context, id string, timeout time.Duration) {
+			close(probeDone)
+		}).Return(&types.ContainerExecInspect{Running: false, ExitCode: 0}, nil)
+
+	ret := taskEngine.startContainer(task, container)
+	assert.NoError(t, ret.Error)
+	<-probeDone
+	for container.IsHealthCheckFirstProbePending() {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, apicontainerstatus.ContainerHealthy, container.GetHealthStatus().Status)
 }
 
 // TestCreateContainerOnAgentRestart tests when agent restarts it should use the
@@ -1340,6 +2983,120 @@ func TestCreateContainerOnAgentRestart(t *testing.T) {
 	}
 }
 
+func TestPullContainerConcurrencyLimits(t *testing.T) {
+	const (
+		numTasks           = 4
+		imagesPerTask      = 3
+		maxPulls           = 3
+		maxPullsPerTask    = 2
+		pullDuration       = 20 * time.Millisecond
+		imageNameSeparator = "/image-"
+	)
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	cfg := &config.Config{
+		MaxConcurrentImagePulls:        maxPulls,
+		MaxConcurrentImagePullsPerTask: maxPullsPerTask,
+	}
+	ctrl, client, _, privateTaskEngine, _, imageManager, _, _ := mocks(t, ctx, cfg)
+	defer ctrl.Finish()
+	taskEngine, _ := privateTaskEngine.(*DockerTaskEngine)
+	taskEngine._time = nil
+
+	var tasks []*apitask.Task
+	for i := 0; i < numTasks; i++ {
+		task := &apitask.Task{Arn: fmt.Sprintf("task-%d", i)}
+		for j := 0; j < imagesPerTask; j++ {
+			task.Containers = append(task.Containers, &apicontainer.Container{
+				Name:  fmt.Sprintf("container-%d", j),
+				Type:  apicontainer.ContainerNormal,
+				Image: fmt.Sprintf("%s%s%d", task.Arn, imageNameSeparator, j),
+			})
+		}
+		taskEngine.tasksLock.Lock()
+		taskEngine.newManagedTask(task)
+		taskEngine.tasksLock.Unlock()
+		tasks = append(tasks, task)
+	}
+
+	var (
+		lock              sync.Mutex
+		running           int
+		maxRunning        int
+		runningPerTask    = make(map[string]int)
+		maxRunningPerTask = make(map[string]int)
+	)
+	client.EXPECT().PullImage(gomock.Any(), gomock.Any(), nil, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, image string, auth *apicontainer.RegistryAuthenticationData,
+			timeout time.Duration) dockerapi.DockerContainerMetadata {
+			taskArn := image[:strings.Index(image, imageNameSeparator)]
+			lock.Lock()
+			running++
+			runningPerTask[taskArn]++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			if runningPerTask[taskArn] > maxRunningPerTask[taskArn] {
+				maxRunningPerTask[taskArn] = runningPerTask[taskArn]
+			}
+			lock.Unlock()
+
+			time.Sleep(pullDuration)
+
+			lock.Lock()
+			running--
+			runningPerTask[taskArn]--
+			lock.Unlock()
+			return dockerapi.DockerContainerMetadata{}
+		}).Times(numTasks * imagesPerTask)
+	imageManager.EXPECT().RecordContainerReference(gomock.Any()).Return(nil).Times(numTasks * imagesPerTask)
+	imageManager.EXPECT().GetImageStateFromImageName(gomock.Any()).Return(
+		&image.ImageState{Image: &image.Image{ImageID: "id"}}, true).Times(numTasks * imagesPerTask)
+
+	// All the images of all the tasks are pulled at once
+	var wg sync.WaitGroup
+	for _, task := range tasks {
+		for _, container := range task.Containers {
+			wg.Add(1)
+			go func(task *apitask.Task, container *apicontainer.Container) {
+				defer wg.Done()
+				metadata := taskEngine.pullContainer(task, container)
+				assert.NoError(t, metadata.Error)
+			}(task, container)
+		}
+	}
+	wg.Wait()
+
+	assert.Equal(t, maxPulls, maxRunning, "pulls across tasks should be bounded by the global limit")
+	for _, task := range tasks {
+		assert.True(t, maxRunningPerTask[task.Arn] <= maxPullsPerTask,
+			"pulls of task %s should be bounded by the per task limit, got %d", task.Arn, maxRunningPerTask[task.Arn])
+	}
+}
+
+func TestPullContainerTaskStoppedWhileWaitingForSlot(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, _, _, privateTaskEngine, _, _, _, _ := mocks(t, ctx, &config.Config{MaxConcurrentImagePullsPerTask: 1})
+	defer ctrl.Finish()
+	taskEngine, _ := privateTaskEngine.(*DockerTaskEngine)
+	taskEngine._time = nil
+
+	container := &apicontainer.Container{Name: "c1", Type: apicontainer.ContainerNormal, Image: "image"}
+	task := &apitask.Task{Arn: "task", Containers: []*apicontainer.Container{container}}
+	taskEngine.tasksLock.Lock()
+	mtask := taskEngine.newManagedTask(task)
+	taskEngine.tasksLock.Unlock()
+
+	// The only slot of the task is taken, and the task is done before it is released
+	mtask.imagePullSlots <- struct{}{}
+	mtask.cancel()
+
+	metadata := taskEngine.pullContainer(task, container)
+	require.Error(t, metadata.Error)
+	assert.Equal(t, "CannotPullContainerError", metadata.Error.ErrorName())
+}
+
 func TestPullCNIImage(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.TODO())
 	defer cancel()
@@ -1381,6 +3138,11 @@ func TestPullNormalImage(t *testing.T) {
 	imageManager.EXPECT().GetImageStateFromImageName(imageName).Return(imageState, true)
 	metadata := taskEngine.pullContainer(task, container)
 	assert.Equal(t, dockerapi.DockerContainerMetadata{}, metadata, "expected empty metadata")
+	assert.False(t, container.IsImagePullCached())
+	assert.False(t, container.ImagePullStartedAtUnsafe.IsZero())
+	assert.False(t, container.ImagePullStoppedAtUnsafe.IsZero())
+	_, ok := container.GetImagePullDuration()
+	assert.True(t, ok)
 }
 
 func TestPullImageWithImagePullOnceBehavior(t *testing.T) {
@@ -1429,6 +3191,57 @@ func TestPullImageWithImagePullOnceBehavior(t *testing.T) {
 	}
 }
 
+func TestPullImageForcePullUpdatesImageState(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, client, _, privateTaskEngine, _, _, _, _ := mocks(t, ctx, &config.Config{ImagePullBehavior: config.ImagePullOnceBehavior})
+	defer ctrl.Finish()
+	taskEngine, _ := privateTaskEngine.(*DockerTaskEngine)
+	taskEngine._time = nil
+	imageManager := &dockerImageManager{client: client, state: taskEngine.state}
+	imageManager.SetDataClient(data.NewNoopClient())
+	taskEngine.imageManager = imageManager
+
+	// the image was pulled before, so it would be used from the cache without the force pull label
+	imageName := "myimage:latest"
+	oldImageState := &image.ImageState{
+		Image:         &image.Image{ImageID: "sha256:old", Names: []string{imageName}},
+		PullSucceeded: true,
+	}
+	imageManager.AddAllImageStates([]*image.ImageState{oldImageState})
+
+	rawConfig, err := json.Marshal(&dockercontainer.Config{
+		Labels: map[string]string{apicontainer.ForcePullLabel: "true"},
+	})
+	require.NoError(t, err)
+	container := &apicontainer.Container{
+		Name:  "c1",
+		Type:  apicontainer.ContainerNormal,
+		Image: imageName,
+		DockerConfig: apicontainer.DockerConfig{
+			Config: aws.String(string(rawConfig)),
+		},
+	}
+	task := &apitask.Task{
+		Arn:        "arn:aws:ecs:us-west-2:1234567890:task/test-cluster/abc",
+		Containers: []*apicontainer.Container{container},
+	}
+
+	// the registry returns a new image for the same tag
+	client.EXPECT().PullImage(gomock.Any(), imageName, nil, gomock.Any())
+	client.EXPECT().InspectImage(imageName).Return(&types.ImageInspect{ID: "sha256:new"}, nil)
+	metadata := taskEngine.pullContainer(task, container)
+	require.NoError(t, metadata.Error)
+
+	assert.Equal(t, "sha256:new", container.ImageID)
+	imageState, ok := imageManager.GetImageStateFromImageName(imageName)
+	require.True(t, ok)
+	assert.Equal(t, "sha256:new", imageState.Image.ImageID)
+	assert.True(t, imageState.GetPullSucceeded())
+	assert.Len(t, imageState.Containers, 1)
+	assert.Empty(t, oldImageState.Image.Names, "the tag should no longer refer to the old image")
+}
+
 func TestPullImageWithImagePullPreferCachedBehaviorWithCachedImage(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.TODO())
 	defer cancel()
@@ -1447,17 +3260,136 @@ func TestPullImageWithImagePullPreferCachedBehaviorWithCachedImage(t *testing.T)
 	imageState := &image.ImageState{
 		Image: &image.Image{ImageID: "id"},
 	}
-	client.EXPECT().InspectImage(imageName).Return(nil, nil)
-	imageManager.EXPECT().RecordContainerReference(container)
-	imageManager.EXPECT().GetImageStateFromImageName(imageName).Return(imageState, true)
-	metadata := taskEngine.pullContainer(task, container)
-	assert.Equal(t, dockerapi.DockerContainerMetadata{}, metadata, "expected empty metadata")
+	client.EXPECT().InspectImage(imageName).Return(nil, nil)
+	imageManager.EXPECT().RecordContainerReference(container)
+	imageManager.EXPECT().GetImageStateFromImageName(imageName).Return(imageState, true)
+	metadata := taskEngine.pullContainer(task, container)
+	assert.Equal(t, dockerapi.DockerContainerMetadata{}, metadata, "expected empty metadata")
+	assert.True(t, container.IsImagePullCached())
+	pullDuration, ok := container.GetImagePullDuration()
+	assert.True(t, ok)
+	assert.Zero(t, pullDuration)
+}
+
+func TestPullImageWithImagePullPreferCachedBehaviorWithoutCachedImage(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, client, _, privateTaskEngine, _, imageManager, _, _ := mocks(t, ctx, &config.Config{ImagePullBehavior: config.ImagePullPreferCachedBehavior})
+	defer ctrl.Finish()
+	taskEngine, _ := privateTaskEngine.(*DockerTaskEngine)
+	taskEngine._time = nil
+	imageName := "image"
+	container := &apicontainer.Container{
+		Type:  apicontainer.ContainerNormal,
+		Image: imageName,
+	}
+	task := &apitask.Task{
+		Containers: []*apicontainer.Container{container},
+	}
+	imageState := &image.ImageState{
+		Image: &image.Image{ImageID: "id"},
+	}
+	client.EXPECT().InspectImage(imageName).Return(nil, errors.New("error"))
+	client.EXPECT().PullImage(gomock.Any(), imageName, nil, gomock.Any())
+	imageManager.EXPECT().RecordContainerReference(container)
+	imageManager.EXPECT().GetImageStateFromImageName(imageName).Return(imageState, true)
+	metadata := taskEngine.pullContainer(task, container)
+	assert.Equal(t, dockerapi.DockerContainerMetadata{}, metadata, "expected empty metadata")
+}
+
+func TestPullContainerRecordsImagePullMetrics(t *testing.T) {
+	const (
+		imageName    = "123456789012.dkr.ecr.us-west-2.amazonaws.com/app:latest"
+		registryHost = "123456789012.dkr.ecr.us-west-2.amazonaws.com"
+	)
+	testCases := []struct {
+		name           string
+		inspectErr     error
+		expectedResult string
+		otherResult    string
+	}{
+		{
+			name:           "cached image",
+			expectedResult: metrics.ImagePullCacheHit,
+			otherResult:    metrics.ImagePullDownload,
+		},
+		{
+			name:           "image pulled",
+			inspectErr:     errors.New("No such image"),
+			expectedResult: metrics.ImagePullDownload,
+			otherResult:    metrics.ImagePullCacheHit,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			registry := prometheus.NewRegistry()
+			metrics.MustInit(&config.Config{PrometheusMetricsEnabled: true}, registry)
+			defer func() {
+				metrics.MetricsEngineGlobal = &metrics.MetricsEngine{}
+			}()
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			ctrl, client, _, privateTaskEngine, _, imageManager, _, _ := mocks(t, ctx, &config.Config{ImagePullBehavior: config.ImagePullPreferCachedBehavior})
+			defer ctrl.Finish()
+			taskEngine, _ := privateTaskEngine.(*DockerTaskEngine)
+			taskEngine._time = nil
+			container := &apicontainer.Container{
+				Type:  apicontainer.ContainerNormal,
+				Image: imageName,
+			}
+			task := &apitask.Task{
+				Containers: []*apicontainer.Container{container},
+			}
+			imageState := &image.ImageState{
+				Image: &image.Image{ImageID: "id"},
+			}
+			client.EXPECT().InspectImage(imageName).Return(nil, tc.inspectErr)
+			if tc.inspectErr != nil {
+				client.EXPECT().PullImage(gomock.Any(), imageName, nil, gomock.Any())
+			}
+			imageManager.EXPECT().RecordContainerReference(container)
+			imageManager.EXPECT().GetImageStateFromImageName(imageName).Return(imageState, true)
+			metadata := taskEngine.pullContainer(task, container)
+			require.NoError(t, metadata.Error)
+
+			count, ok := imagePullMetric(t, registry, "AgentMetrics_TaskEngine_image_pull_count", tc.expectedResult, registryHost)
+			require.True(t, ok, "the image pull should be counted")
+			assert.Equal(t, 1.0, count.GetCounter().GetValue())
+			duration, ok := imagePullMetric(t, registry, "AgentMetrics_TaskEngine_image_pull_duration_seconds", tc.expectedResult, registryHost)
+			require.True(t, ok, "the image pull latency should be recorded")
+			assert.Equal(t, uint64(1), duration.GetHistogram().GetSampleCount())
+			_, ok = imagePullMetric(t, registry, "AgentMetrics_TaskEngine_image_pull_count", tc.otherResult, registryHost)
+			assert.False(t, ok)
+		})
+	}
+}
+
+// imagePullMetric returns the metric of the family with the given result and registry labels, if any
+func imagePullMetric(t *testing.T, registry *prometheus.Registry, name, result, registryHost string) (*dto.Metric, bool) {
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["Result"] == result && labels["Registry"] == registryHost {
+				return metric, true
+			}
+		}
+	}
+	return nil, false
 }
 
-func TestPullImageWithImagePullPreferCachedBehaviorWithoutCachedImage(t *testing.T) {
+func TestUpdateContainerReference(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.TODO())
 	defer cancel()
-	ctrl, client, _, privateTaskEngine, _, imageManager, _, _ := mocks(t, ctx, &config.Config{ImagePullBehavior: config.ImagePullPreferCachedBehavior})
+	ctrl, _, _, privateTaskEngine, _, imageManager, _, _ := mocks(t, ctx, &config.Config{})
 	defer ctrl.Finish()
 	taskEngine, _ := privateTaskEngine.(*DockerTaskEngine)
 	taskEngine._time = nil
@@ -1472,37 +3404,55 @@ func TestPullImageWithImagePullPreferCachedBehaviorWithoutCachedImage(t *testing
 	imageState := &image.ImageState{
 		Image: &image.Image{ImageID: "id"},
 	}
-	client.EXPECT().InspectImage(imageName).Return(nil, errors.New("error"))
-	client.EXPECT().PullImage(gomock.Any(), imageName, nil, gomock.Any())
+
 	imageManager.EXPECT().RecordContainerReference(container)
 	imageManager.EXPECT().GetImageStateFromImageName(imageName).Return(imageState, true)
-	metadata := taskEngine.pullContainer(task, container)
-	assert.Equal(t, dockerapi.DockerContainerMetadata{}, metadata, "expected empty metadata")
+	taskEngine.updateContainerReference(true, container, task)
+	assert.True(t, imageState.PullSucceeded, "PullSucceeded set to false")
 }
 
-func TestUpdateContainerReference(t *testing.T) {
+func TestUpdateContainerReferenceImageAccountingOptOut(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.TODO())
 	defer cancel()
-	ctrl, _, _, privateTaskEngine, _, imageManager, _, _ := mocks(t, ctx, &config.Config{})
+	ctrl, client, _, privateTaskEngine, _, _, _, _ := mocks(t, ctx, &config.Config{})
 	defer ctrl.Finish()
 	taskEngine, _ := privateTaskEngine.(*DockerTaskEngine)
-	taskEngine._time = nil
-	imageName := "image"
+	imageManager := NewImageManager(&config.Config{}, client, taskEngine.state)
+	imageManager.SetDataClient(data.NewNoopClient())
+	taskEngine.imageManager = imageManager
+
+	lastUsedAt := time.Now().AddDate(0, -1, 0)
+	imageState := &image.ImageState{
+		Image:      &image.Image{ImageID: "sha256:service", Names: []string{"service"}},
+		PulledAt:   lastUsedAt,
+		LastUsedAt: lastUsedAt,
+	}
+	imageManager.AddAllImageStates([]*image.ImageState{imageState})
+
 	container := &apicontainer.Container{
-		Type:  apicontainer.ContainerNormal,
-		Image: imageName,
+		Name:    "batch",
+		Image:   "service",
+		ImageID: "sha256:service",
+		DockerConfig: apicontainer.DockerConfig{
+			Config: aws.String(`{"Labels":{"` + apitask.ImageAccountingOptOutLabel + `":"true"}}`),
+		},
 	}
 	task := &apitask.Task{
+		Arn:        "arn:aws:ecs:us-west-2:1234567890:task/batch",
 		Containers: []*apicontainer.Container{container},
 	}
-	imageState := &image.ImageState{
-		Image: &image.Image{ImageID: "id"},
-	}
 
-	imageManager.EXPECT().RecordContainerReference(container)
-	imageManager.EXPECT().GetImageStateFromImageName(imageName).Return(imageState, true)
-	taskEngine.updateContainerReference(true, container, task.Arn)
-	assert.True(t, imageState.PullSucceeded, "PullSucceeded set to false")
+	taskEngine.updateContainerReference(false, container, task)
+	assert.True(t, imageState.HasNoAssociatedContainers(), "an opted out task should not reference the image")
+	assert.NoError(t, taskEngine.removeContainerReference(task, container))
+	assert.Equal(t, lastUsedAt, imageState.LastUsedAt, "an opted out task should not refresh the image")
+
+	// The same container of a task that did not opt out is accounted
+	task.Containers[0].DockerConfig.Config = nil
+	taskEngine.updateContainerReference(false, container, task)
+	assert.False(t, imageState.HasNoAssociatedContainers(), "the container should reference the image")
+	assert.NoError(t, taskEngine.removeContainerReference(task, container))
+	assert.True(t, imageState.LastUsedAt.After(lastUsedAt), "the image should have been refreshed")
 }
 
 // TestPullAndUpdateContainerReference checks whether a container is added to task engine state when
@@ -1556,7 +3506,7 @@ func TestPullAndUpdateContainerReference(t *testing.T) {
 			ImageInspect:         nil,
 			InspectImage:         true,
 			NumOfPulledContainer: 1,
-			PullImageErr:         dockerapi.CannotPullContainerError{fmt.Errorf("error")},
+			PullImageErr:         dockerapi.CannotPullContainerError{FromError: fmt.Errorf("error")},
 		},
 		{
 			Name:                 "DependentContainersPullUpfrontEnabledAndImagePullOnceBehavior",
@@ -1566,7 +3516,7 @@ func TestPullAndUpdateContainerReference(t *testing.T) {
 			ImageInspect:         nil,
 			InspectImage:         true,
 			NumOfPulledContainer: 1,
-			PullImageErr:         dockerapi.CannotPullContainerError{fmt.Errorf("error")},
+			PullImageErr:         dockerapi.CannotPullContainerError{FromError: fmt.Errorf("error")},
 		},
 		{
 			Name:                 "DependentContainersPullUpfrontEnabledAndImagePullPreferCachedBehavior",
@@ -1576,7 +3526,7 @@ func TestPullAndUpdateContainerReference(t *testing.T) {
 			ImageInspect:         nil,
 			InspectImage:         true,
 			NumOfPulledContainer: 1,
-			PullImageErr:         dockerapi.CannotPullContainerError{fmt.Errorf("error")},
+			PullImageErr:         dockerapi.CannotPullContainerError{FromError: fmt.Errorf("error")},
 		},
 		{
 			Name:                 "DependentContainersPullUpfrontEnabledAndImagePullAlwaysBehavior",
@@ -1586,7 +3536,7 @@ func TestPullAndUpdateContainerReference(t *testing.T) {
 			ImageInspect:         nil,
 			InspectImage:         false,
 			NumOfPulledContainer: 0,
-			PullImageErr:         dockerapi.CannotPullContainerError{fmt.Errorf("error")},
+			PullImageErr:         dockerapi.CannotPullContainerError{FromError: fmt.Errorf("error")},
 		},
 	}
 
@@ -1634,6 +3584,181 @@ func TestPullAndUpdateContainerReference(t *testing.T) {
 	}
 }
 
+func TestPullAndUpdateContainerReferenceUseCachedImageOnPullFailure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	cfg := &config.Config{
+		ImagePullBehavior:           config.ImagePullAlwaysBehavior,
+		UseCachedImageOnPullFailure: config.BooleanDefaultFalse{Value: config.ExplicitlyEnabled},
+	}
+	ctrl, client, _, privateTaskEngine, _, imageManager, _, _ := mocks(t, ctx, cfg)
+	defer ctrl.Finish()
+	taskEngine, _ := privateTaskEngine.(*DockerTaskEngine)
+	imageName := "image"
+	container := &apicontainer.Container{
+		Type:      apicontainer.ContainerNormal,
+		Image:     imageName,
+		Essential: true,
+	}
+	task := &apitask.Task{
+		Arn:        "taskArn",
+		Containers: []*apicontainer.Container{container},
+	}
+
+	pullErr := dockerapi.CannotPullContainerError{FromError: errors.New("registry unavailable")}
+	client.EXPECT().PullImage(gomock.Any(), imageName, nil, gomock.Any()).
+		Return(dockerapi.DockerContainerMetadata{Error: pullErr})
+	client.EXPECT().InspectImage(imageName).Return(&types.ImageInspect{}, nil)
+	imageManager.EXPECT().RecordContainerReference(container)
+	imageManager.EXPECT().GetImageStateFromImageName(imageName).Return(&image.ImageState{
+		Image: &image.Image{ImageID: "id"},
+	}, true)
+
+	metadata := taskEngine.pullAndUpdateContainerReference(task, container)
+	assert.NoError(t, metadata.Error, "the container should proceed with the cached image")
+	pulledContainersMap, _ := taskEngine.State().PulledContainerMapByArn(task.Arn)
+	assert.Len(t, pulledContainersMap, 1)
+	require.NotNil(t, container.ApplyingError, "the pull error should be reported on the container")
+	assert.Equal(t, "ImagePullFallbackToCacheError", container.ApplyingError.ErrorName())
+	assert.Contains(t, container.ApplyingError.Error(), "registry unavailable")
+	assert.NotEqual(t, apitaskstatus.TaskStopped, task.GetDesiredStatus())
+}
+
+func TestPullAndUpdateContainerReferenceCachedImageOnPullFailureDisabled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	cfg := &config.Config{ImagePullBehavior: config.ImagePullAlwaysBehavior}
+	ctrl, client, _, privateTaskEngine, _, imageManager, _, _ := mocks(t, ctx, cfg)
+	defer ctrl.Finish()
+	taskEngine, _ := privateTaskEngine.(*DockerTaskEngine)
+	imageName := "image"
+	container := &apicontainer.Container{
+		Type:      apicontainer.ContainerNormal,
+		Image:     imageName,
+		Essential: true,
+	}
+	task := &apitask.Task{
+		Arn:        "taskArn",
+		Containers: []*apicontainer.Container{container},
+	}
+
+	// the cached image is not looked up
+	pullErr := dockerapi.CannotPullContainerError{FromError: errors.New("registry unavailable")}
+	client.EXPECT().PullImage(gomock.Any(), imageName, nil, gomock.Any()).
+		Return(dockerapi.DockerContainerMetadata{Error: pullErr})
+	imageManager.EXPECT().RecordContainerReference(container)
+	imageManager.EXPECT().GetImageStateFromImageName(imageName).Return(nil, false)
+
+	metadata := taskEngine.pullAndUpdateContainerReference(task, container)
+	require.Error(t, metadata.Error)
+	assert.Nil(t, container.ApplyingError)
+
+	// the pull error fails the task
+	mtask := &managedTask{Task: task, cfg: cfg}
+	proceed := mtask.handleEventError(dockerContainerChange{
+		container: container,
+		event: dockerapi.DockerContainerChangeEvent{
+			Status:                  apicontainerstatus.ContainerPulled,
+			DockerContainerMetadata: metadata,
+		},
+	}, apicontainerstatus.ContainerStatusNone)
+	assert.False(t, proceed)
+	assert.Equal(t, apitaskstatus.TaskStopped, task.GetDesiredStatus())
+}
+
+func TestImagePullTimeoutOverrides(t *testing.T) {
+	testCases := []struct {
+		name            string
+		image           string
+		expectedTimeout time.Duration
+	}{
+		{name: "matching prefix override", image: "public.ecr.aws/ml/model:latest", expectedTimeout: 4 * time.Hour},
+		{name: "longest matching prefix override", image: "public.ecr.aws/ml/small/model", expectedTimeout: 10 * time.Minute},
+		{name: "no matching prefix falls back to the pull timeout", image: "busybox:latest", expectedTimeout: time.Hour},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			cfg := &config.Config{
+				ImagePullBehavior: config.ImagePullDefaultBehavior,
+				ImagePullTimeout:  time.Hour,
+				ImagePullTimeoutOverrides: map[string]time.Duration{
+					"public.ecr.aws/ml/":       4 * time.Hour,
+					"public.ecr.aws/ml/small/": 10 * time.Minute,
+				},
+			}
+			ctrl, client, _, privateTaskEngine, _, imageManager, _, _ := mocks(t, ctx, cfg)
+			defer ctrl.Finish()
+
+			taskEngine, _ := privateTaskEngine.(*DockerTaskEngine)
+			container := &apicontainer.Container{
+				Type:      apicontainer.ContainerNormal,
+				Image:     tc.image,
+				Essential: true,
+			}
+			task := &apitask.Task{
+				Arn:        "taskArn",
+				Containers: []*apicontainer.Container{container},
+			}
+
+			client.EXPECT().PullImage(gomock.Any(), tc.image, nil, tc.expectedTimeout).
+				Return(dockerapi.DockerContainerMetadata{})
+			imageManager.EXPECT().RecordContainerReference(container)
+			imageManager.EXPECT().GetImageStateFromImageName(tc.image).Return(nil, false)
+			metadata := taskEngine.pullAndUpdateContainerReference(task, container)
+			assert.NoError(t, metadata.Error)
+		})
+	}
+}
+
+func TestImagePullRateLimitSpacesPullsFromHost(t *testing.T) {
+	const interval = 50 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	cfg := &config.Config{
+		ImagePullBehavior: config.ImagePullDefaultBehavior,
+		ImagePullTimeout:  time.Hour,
+		ImagePullRateLimitOverrides: map[string]config.ImagePullRateLimit{
+			"registry.example.com": {Rate: float64(time.Second / interval), Burst: 1},
+		},
+	}
+	ctrl, client, _, privateTaskEngine, _, imageManager, _, _ := mocks(t, ctx, cfg)
+	defer ctrl.Finish()
+	taskEngine, _ := privateTaskEngine.(*DockerTaskEngine)
+
+	const numPulls = 5
+	image := "registry.example.com/app:latest"
+	var pullTimes []time.Time
+	client.EXPECT().PullImage(gomock.Any(), image, nil, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, _ *apicontainer.RegistryAuthenticationData, _ time.Duration) dockerapi.DockerContainerMetadata {
+			pullTimes = append(pullTimes, time.Now())
+			return dockerapi.DockerContainerMetadata{}
+		}).Times(numPulls)
+	imageManager.EXPECT().RecordContainerReference(gomock.Any()).Times(numPulls)
+	imageManager.EXPECT().GetImageStateFromImageName(image).Return(nil, false).Times(numPulls)
+
+	for i := 0; i < numPulls; i++ {
+		container := &apicontainer.Container{
+			Type:      apicontainer.ContainerNormal,
+			Image:     image,
+			Essential: true,
+		}
+		task := &apitask.Task{
+			Arn:        fmt.Sprintf("taskArn%d", i),
+			Containers: []*apicontainer.Container{container},
+		}
+		metadata := taskEngine.pullAndUpdateContainerReference(task, container)
+		require.NoError(t, metadata.Error)
+	}
+
+	require.Len(t, pullTimes, numPulls)
+	for i := 1; i < numPulls; i++ {
+		assert.True(t, pullTimes[i].Sub(pullTimes[i-1]) >= interval-interval/5,
+			"pull %d started %v after the previous one, expected at least %v", i, pullTimes[i].Sub(pullTimes[i-1]), interval)
+	}
+}
+
 // TestMetadataFileUpdatedAgentRestart checks whether metadataManager.Update(...) is
 // invoked in the path DockerTaskEngine.Init() -> .synchronizeState() -> .updateMetadataFile(...)
 // for the following case:
@@ -1667,6 +3792,9 @@ func TestMetadataFileUpdatedAgentRestart(t *testing.T) {
 	state.AddContainer(dockerContainer, task)
 	eventStream := make(chan dockerapi.DockerContainerChangeEvent)
 	client.EXPECT().ContainerEvents(gomock.Any()).Return(eventStream, nil)
+	client.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().Info(gomock.Any(), dockerclient.InfoTimeout).Return(types.Info{}, nil)
 	_, watcherCancel := context.WithTimeout(context.Background(), time.Second)
 	serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().Do(func() {
 		watcherCancel()
@@ -1853,6 +3981,9 @@ func TestNewTaskTransitionOnRestart(t *testing.T) {
 	mockTime.EXPECT().After(gomock.Any()).AnyTimes()
 	client.EXPECT().Version(gomock.Any(), gomock.Any()).MaxTimes(1)
 	client.EXPECT().ContainerEvents(gomock.Any()).MaxTimes(1)
+	client.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().Info(gomock.Any(), dockerclient.InfoTimeout).Return(types.Info{}, nil).MaxTimes(1)
 	serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().AnyTimes()
 
 	err := taskEngine.Init(ctx)
@@ -1896,6 +4027,9 @@ func TestTaskWaitForHostResourceOnRestart(t *testing.T) {
 
 	client.EXPECT().Version(gomock.Any(), gomock.Any()).MaxTimes(1)
 	client.EXPECT().ContainerEvents(gomock.Any()).MaxTimes(1)
+	client.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().Info(gomock.Any(), dockerclient.InfoTimeout).Return(types.Info{}, nil).MaxTimes(1)
 	serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().AnyTimes()
 
 	err := privateTaskEngine.Init(ctx)
@@ -2018,7 +4152,7 @@ func TestPullStoppedAtWasSetCorrectlyWhenPullFail(t *testing.T) {
 		client.EXPECT().PullImage(gomock.Any(), container.Image, nil, gomock.Any()).Return(dockerapi.DockerContainerMetadata{}),
 		client.EXPECT().PullImage(gomock.Any(), container.Image, nil, gomock.Any()).Return(dockerapi.DockerContainerMetadata{}),
 		client.EXPECT().PullImage(gomock.Any(), container.Image, nil, gomock.Any()).Return(
-			dockerapi.DockerContainerMetadata{Error: dockerapi.CannotPullContainerError{fmt.Errorf("error")}}),
+			dockerapi.DockerContainerMetadata{Error: dockerapi.CannotPullContainerError{FromError: fmt.Errorf("error")}}),
 	)
 	imageManager.EXPECT().RecordContainerReference(gomock.Any()).Times(3)
 	imageManager.EXPECT().GetImageStateFromImageName(gomock.Any()).Return(nil, false).Times(3)
@@ -2179,6 +4313,75 @@ func TestHandleDockerHealthEvent(t *testing.T) {
 	assert.Equal(t, testContainer.Health.Status, apicontainerstatus.ContainerHealthy)
 }
 
+// TestStartupCPUBoost tests that the CPU shares of a container are boosted when it starts and
+// reverted once it becomes healthy
+func TestStartupCPUBoost(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, client, _, privateTaskEngine, _, _, _, _ := mocks(t, ctx, &config.Config{})
+	defer ctrl.Finish()
+	taskEngine := privateTaskEngine.(*DockerTaskEngine)
+
+	rawConfig, err := json.Marshal(&dockercontainer.Config{
+		Labels: map[string]string{apitask.StartupCPUBoostLabel: "3"},
+	})
+	require.NoError(t, err)
+	container := &apicontainer.Container{
+		Name:            "c1",
+		CPU:             512,
+		HealthCheckType: apicontainer.DockerHealthCheckType,
+		DockerConfig: apicontainer.DockerConfig{
+			Config: aws.String(string(rawConfig)),
+		},
+	}
+	container.SetRuntimeID("dockerID")
+	task := &apitask.Task{
+		Arn:        "arn:aws:ecs:us-west-2:1234567890:task/test-cluster/1dc8bcb2-5d0a-4d27-9b3c-0dfcc4f1e3ce",
+		Containers: []*apicontainer.Container{container},
+	}
+	taskEngine.state.AddTask(task)
+	taskEngine.state.AddContainer(&apicontainer.DockerContainer{
+		DockerID:   "dockerID",
+		DockerName: "c1",
+		Container:  container,
+	}, task)
+
+	client.EXPECT().StartContainer(gomock.Any(), "dockerID", gomock.Any()).Return(
+		dockerapi.DockerContainerMetadata{DockerID: "dockerID"})
+	client.EXPECT().UpdateContainer(gomock.Any(), "dockerID", dockercontainer.Resources{CPUShares: 1536},
+		dockerclient.UpdateContainerTimeout).Return(nil)
+	ret := taskEngine.startContainer(task, container)
+	assert.NoError(t, ret.Error)
+	assert.True(t, container.IsStartupCPUBoosted(), "Expected the startup CPU boost to be applied")
+
+	// The container still starting up keeps its boost
+	healthEvent := func(status apicontainerstatus.ContainerHealthStatus) dockerapi.DockerContainerChangeEvent {
+		return dockerapi.DockerContainerChangeEvent{
+			Status: apicontainerstatus.ContainerRunning,
+			Type:   apicontainer.ContainerHealthEvent,
+			DockerContainerMetadata: dockerapi.DockerContainerMetadata{
+				DockerID: "dockerID",
+				Health:   apicontainer.HealthStatus{Status: status},
+			},
+		}
+	}
+	taskEngine.handleDockerEvent(healthEvent(apicontainerstatus.ContainerUnhealthy))
+	assert.True(t, container.IsStartupCPUBoosted())
+
+	reverted := make(chan struct{})
+	client.EXPECT().UpdateContainer(gomock.Any(), "dockerID", dockercontainer.Resources{CPUShares: 512},
+		dockerclient.UpdateContainerTimeout).Do(
+		func(ctx context.Context, id string, resources dockercontainer.Resources, timeout time.Duration) {
+			close(reverted)
+		}).Return(nil)
+	taskEngine.handleDockerEvent(healthEvent(apicontainerstatus.ContainerHealthy))
+	<-reverted
+	assert.False(t, container.IsStartupCPUBoosted(), "Expected the startup CPU boost to be reverted")
+
+	// Later health events do not revert the boost again
+	taskEngine.handleDockerEvent(healthEvent(apicontainerstatus.ContainerHealthy))
+}
+
 func TestContainerMetadataUpdatedOnRestart(t *testing.T) {
 	dockerID := "dockerID_created"
 	labels := map[string]string{
@@ -2337,6 +4540,9 @@ func TestContainerProgressParallize(t *testing.T) {
 	imageManager.EXPECT().RecordContainerReference(gomock.Any()).Return(nil).AnyTimes()
 	imageManager.EXPECT().GetImageStateFromImageName(gomock.Any()).Return(nil, false).AnyTimes()
 	client.EXPECT().ContainerEvents(gomock.Any()).Return(eventStream, nil)
+	client.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().Info(gomock.Any(), dockerclient.InfoTimeout).Return(types.Info{}, nil)
 	client.EXPECT().PullImage(gomock.Any(), fastPullImage, gomock.Any(), gomock.Any())
 	client.EXPECT().PullImage(gomock.Any(), slowPullImage, gomock.Any(), gomock.Any()).Do(
 		func(ctx interface{}, image interface{}, auth interface{}, timeout interface{}) {
@@ -2441,6 +4647,48 @@ func TestContainerProgressParallize(t *testing.T) {
 	}
 }
 
+func TestRemoveOrphanedCreatedContainers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, client, _, privateTaskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+	taskEngine := privateTaskEngine.(*DockerTaskEngine)
+
+	// The task is being launched, its created container is not known yet
+	launchingTask := testdata.LoadTask("sleep5")
+	taskEngine.State().AddTask(launchingTask)
+	// The created container of the running task is known
+	runningTask := testdata.LoadTask("sleep5")
+	runningTask.Arn = "arn:aws:ecs:us-west-2:1234567890:task/running"
+	taskEngine.State().AddTask(runningTask)
+	taskEngine.State().AddContainer(&apicontainer.DockerContainer{
+		DockerID:  "known",
+		Container: runningTask.Containers[0],
+	}, runningTask)
+
+	orphanTaskLabels := map[string]string{
+		labelTaskARN:       "arn:aws:ecs:us-west-2:1234567890:task/orphan",
+		labelContainerName: "sleep5",
+	}
+
+	// Only the created containers of tasks are listed, and none of them is inspected
+	client.EXPECT().ListContainersWithFilters(gomock.Any(), filters.NewArgs(
+		filters.Arg("status", "created"),
+		filters.Arg("label", labelTaskARN),
+	), dockerclient.ListContainersTimeout).Return(
+		dockerapi.ListContainersResponse{
+			DockerIDs: []string{"known", "orphan", "launching"},
+			Labels: map[string]map[string]string{
+				"known":     {labelTaskARN: runningTask.Arn},
+				"orphan":    orphanTaskLabels,
+				"launching": {labelTaskARN: launchingTask.Arn},
+			},
+		})
+	client.EXPECT().RemoveContainer(gomock.Any(), "orphan", dockerclient.RemoveContainerTimeout).Return(nil)
+
+	taskEngine.removeOrphanedCreatedContainers()
+}
+
 func TestSynchronizeResource(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.TODO())
 	defer cancel()
@@ -2450,6 +4698,9 @@ func TestSynchronizeResource(t *testing.T) {
 	mockTime.EXPECT().Now().AnyTimes()
 	client.EXPECT().Version(gomock.Any(), gomock.Any()).MaxTimes(1)
 	client.EXPECT().ContainerEvents(gomock.Any()).MaxTimes(1)
+	client.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().Info(gomock.Any(), dockerclient.InfoTimeout).Return(types.Info{}, nil).MaxTimes(1)
 	serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().AnyTimes()
 
 	err := taskEngine.Init(ctx)
@@ -2490,6 +4741,9 @@ func TestSynchronizeENIAttachment(t *testing.T) {
 	mockTime.EXPECT().After(gomock.Any()).AnyTimes()
 	client.EXPECT().Version(gomock.Any(), gomock.Any()).MaxTimes(1)
 	client.EXPECT().ContainerEvents(gomock.Any()).MaxTimes(1)
+	client.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().Info(gomock.Any(), dockerclient.InfoTimeout).Return(types.Info{}, nil).MaxTimes(1)
 	serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().AnyTimes()
 
 	err := taskEngine.Init(ctx)
@@ -2526,6 +4780,9 @@ func TestSynchronizeENIAttachmentRemoveData(t *testing.T) {
 	defer cleanup()
 
 	client.EXPECT().ContainerEvents(gomock.Any()).MaxTimes(1)
+	client.EXPECT().ListContainersWithFilters(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+		dockerapi.ListContainersResponse{}).AnyTimes()
+	client.EXPECT().Info(gomock.Any(), dockerclient.InfoTimeout).Return(types.Info{}, nil).MaxTimes(1)
 	serviceConnectManager.EXPECT().GetAppnetContainerTarballDir().AnyTimes()
 
 	err := taskEngine.Init(ctx)
@@ -3637,6 +5894,106 @@ func TestPeriodicExecAgentsMonitoring(t *testing.T) {
 	assert.Equal(t, execAgentPID, execMD.PID)
 }
 
+func TestCheckPauseContainers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, client, _, taskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+	dockerTaskEngine := taskEngine.(*DockerTaskEngine)
+
+	pauseContainer := &apicontainer.Container{
+		Name:              apitask.NetworkPauseContainerName,
+		Type:              apicontainer.ContainerCNIPause,
+		RuntimeID:         "pause-id",
+		KnownStatusUnsafe: apicontainerstatus.ContainerResourcesProvisioned,
+	}
+	testTask := &apitask.Task{
+		Arn:                 "arn:aws:ecs:region:account-id:task/test-task-arn",
+		NetworkMode:         apitask.AWSVPCNetworkMode,
+		Containers:          []*apicontainer.Container{pauseContainer},
+		KnownStatusUnsafe:   apitaskstatus.TaskRunning,
+		DesiredStatusUnsafe: apitaskstatus.TaskRunning,
+	}
+	dockerMessages := make(chan dockerContainerChange, 1)
+	dockerTaskEngine.managedTasks[testTask.Arn] = &managedTask{
+		Task:           testTask,
+		ctx:            ctx,
+		dockerMessages: dockerMessages,
+	}
+
+	exitCode := 137
+	client.EXPECT().DescribeContainer(gomock.Any(), "pause-id").Return(apicontainerstatus.ContainerStopped,
+		dockerapi.DockerContainerMetadata{DockerID: "pause-id", ExitCode: &exitCode})
+	dockerTaskEngine.checkPauseContainers(ctx)
+
+	select {
+	case change := <-dockerMessages:
+		assert.Equal(t, pauseContainer, change.container)
+		assert.Equal(t, apicontainerstatus.ContainerStopped, change.event.Status)
+		assert.Equal(t, &exitCode, change.event.ExitCode)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the pause container stopped event")
+	}
+}
+
+func TestCheckExecAgentsHealth(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, _, _, taskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+	dockerTaskEngine := taskEngine.(*DockerTaskEngine)
+	execCmdMgr := mock_execcmdagent.NewMockManager(ctrl)
+	dockerTaskEngine.execCmdMgr = execCmdMgr
+	stateChangeEvents := taskEngine.StateChangeEvents()
+
+	testTask := &apitask.Task{
+		Arn: "arn:aws:ecs:region:account-id:task/test-task-arn",
+		Containers: []*apicontainer.Container{
+			{
+				Name:              "test-container",
+				RuntimeID:         "runtime-ID",
+				KnownStatusUnsafe: apicontainerstatus.ContainerRunning,
+			},
+		},
+		KnownStatusUnsafe: apitaskstatus.TaskRunning,
+	}
+	testContainer := testTask.Containers[0]
+	enableExecCommandAgentForContainer(testContainer, apicontainer.ManagedAgentState{
+		Status: apicontainerstatus.ManagedAgentRunning,
+	})
+	dockerTaskEngine.state.AddTask(testTask)
+	dockerTaskEngine.managedTasks[testTask.Arn] = &managedTask{
+		Task:              testTask,
+		engine:            dockerTaskEngine,
+		ctx:               ctx,
+		stateChangeEvents: stateChangeEvents,
+	}
+
+	reason := "ExecuteCommandAgent process exited with exit code: 137"
+	waitDone := make(chan struct{})
+	go checkManagedAgentEvents(t, true, stateChangeEvents, apicontainer.ManagedAgent{
+		ManagedAgentState: apicontainer.ManagedAgentState{
+			Status: apicontainerstatus.ManagedAgentUnhealthy,
+			Reason: reason,
+		},
+	}, waitDone)
+	execCmdMgr.EXPECT().CheckAgentHealth(gomock.Any(), dockerTaskEngine.client, testTask, testContainer).DoAndReturn(
+		func(ctx context.Context, client dockerapi.DockerClient, task *apitask.Task, container *apicontainer.Container) (bool, error) {
+			container.UpdateManagedAgentByName(execcmd.ExecuteCommandAgentName, apicontainer.ManagedAgentState{
+				Status: apicontainerstatus.ManagedAgentUnhealthy,
+				Reason: reason,
+			})
+			return true, nil
+		})
+	dockerTaskEngine.checkExecAgentsHealth(ctx)
+
+	select {
+	case <-waitDone:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the managed agent event")
+	}
+}
+
 func TestCreateContainerWithExecAgent(t *testing.T) {
 	testcases := []struct {
 		name                 string
@@ -3716,3 +6073,93 @@ func TestCreateContainerWithExecAgent(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateContainerWithExecAgentInitFailureWarning(t *testing.T) {
+	initErr := errors.New("mount error")
+	testcases := []struct {
+		name                    string
+		warningEnabled          bool
+		expectedContainerReason string
+	}{
+		{
+			name:                    "warning enabled",
+			warningEnabled:          true,
+			expectedContainerReason: "ExecCommandAgentInitFailedError: ExecuteCommandAgent initialization failed: mount error",
+		},
+		{
+			name:                    "warning disabled",
+			warningEnabled:          false,
+			expectedContainerReason: "",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			cfg := &config.Config{
+				ExecInitFailureWarning: config.BooleanDefaultFalse{Value: config.ExplicitlyDisabled},
+			}
+			if tc.warningEnabled {
+				cfg.ExecInitFailureWarning = config.BooleanDefaultFalse{Value: config.ExplicitlyEnabled}
+			}
+			ctrl, client, _, engine, _, _, _, _ := mocks(t, ctx, cfg)
+			defer ctrl.Finish()
+			taskEngine, _ := engine.(*DockerTaskEngine)
+			stateChangeEvents := engine.StateChangeEvents()
+			execCmdMgr := mock_execcmdagent.NewMockManager(ctrl)
+			taskEngine.execCmdMgr = execCmdMgr
+			sleepTask := testdata.LoadTask("sleep5")
+			sleepContainer, _ := sleepTask.ContainerByName("sleep5")
+			enableExecCommandAgentForContainer(sleepContainer, apicontainer.ManagedAgentState{})
+
+			taskEngine.state.AddTask(sleepTask)
+			taskEngine.managedTasks[sleepTask.Arn] = &managedTask{
+				Task:              sleepTask,
+				engine:            taskEngine,
+				ctx:               ctx,
+				stateChangeEvents: stateChangeEvents,
+			}
+
+			reason := fmt.Sprintf("ExecuteCommandAgent Initialization failed - %v", initErr)
+			waitDone := make(chan struct{})
+			go checkManagedAgentEvents(t, true, stateChangeEvents, apicontainer.ManagedAgent{
+				ManagedAgentState: apicontainer.ManagedAgentState{
+					Status: apicontainerstatus.ManagedAgentStopped,
+					Reason: reason,
+				},
+			}, waitDone)
+			// Mimic the exec command manager, which records the failure on the managed agent state.
+			execCmdMgr.EXPECT().InitializeContainer(gomock.Any(), sleepContainer, gomock.Any()).DoAndReturn(
+				func(taskID string, container *apicontainer.Container, hostConfig *dockercontainer.HostConfig) error {
+					container.UpdateManagedAgentByName(execcmd.ExecuteCommandAgentName, apicontainer.ManagedAgentState{
+						InitFailed: true,
+						Status:     apicontainerstatus.ManagedAgentStopped,
+						Reason:     initErr.Error(),
+					})
+					return initErr
+				})
+			client.EXPECT().APIVersion().Return(defaultDockerClientAPIVersion, nil)
+			client.EXPECT().CreateContainer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			metadata := taskEngine.createContainer(sleepTask, sleepContainer)
+			assert.NoError(t, metadata.Error)
+
+			select {
+			case <-waitDone:
+			case <-time.After(time.Second):
+				t.Fatal("timed out waiting for the managed agent event")
+			}
+
+			execAgent, ok := sleepContainer.GetManagedAgentByName(execcmd.ExecuteCommandAgentName)
+			require.True(t, ok)
+			assert.True(t, execAgent.InitFailed)
+			assert.Equal(t, initErr.Error(), execAgent.Reason)
+
+			// The container is still started, with the failure reported on its state change if enabled.
+			sleepContainer.SetKnownStatus(apicontainerstatus.ContainerRunning)
+			event, err := api.NewContainerStateChangeEvent(sleepTask, sleepContainer, "")
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedContainerReason, event.Reason)
+		})
+	}
+}
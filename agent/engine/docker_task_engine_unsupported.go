@@ -30,6 +30,12 @@ const (
 	cniCleanupTimeout = time.Duration(0)
 )
 
+// healthCheckShell is the shell used to run CMD-SHELL health check probes
+var healthCheckShell = []string{"/bin/sh", "-c"}
+
+// clockProbeCommand prints the current time of the container in seconds since the epoch
+var clockProbeCommand = []string{"date", "-u", "+%s"}
+
 // updateTaskENIDependencies updates the task's dependencies for awsvpc networking mode.
 // This method is used only on Windows platform.
 func (engine *DockerTaskEngine) updateTaskENIDependencies(task *apitask.Task) {
@@ -55,3 +61,13 @@ func (engine *DockerTaskEngine) reloadAppNetImage() error {
 // with updated AppNet image
 func (engine *DockerTaskEngine) restartInstanceTask() {
 }
+
+// checkTaskDNSLatency is not supported on this platform, as the agent cannot enter the network namespace of
+// the task
+func (engine *DockerTaskEngine) checkTaskDNSLatency(ctx context.Context, task *apitask.Task) {
+}
+
+// checkContainerFDUsage is not supported on this platform
+func (engine *DockerTaskEngine) checkContainerFDUsage(ctx context.Context, task *apitask.Task,
+	container *apicontainer.Container) {
+}
@@ -22,7 +22,9 @@ import (
 	"time"
 
 	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
+	apieni "github.com/aws/amazon-ecs-agent/agent/api/eni"
 	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
+	"github.com/aws/amazon-ecs-agent/agent/ecscni"
 	"github.com/aws/amazon-ecs-agent/agent/logger"
 	"github.com/aws/amazon-ecs-agent/agent/logger/field"
 	dockercontainer "github.com/docker/docker/api/types/container"
@@ -36,6 +38,17 @@ const (
 	cniCleanupTimeout = 2 * time.Minute
 )
 
+// healthCheckShell is the shell used to run CMD-SHELL health check probes
+var healthCheckShell = []string{"cmd", "/S", "/C"}
+
+// clockProbeCommand prints the current time of the container in seconds since the epoch
+var clockProbeCommand = []string{"powershell", "-NoProfile", "-Command", "[DateTimeOffset]::UtcNow.ToUnixTimeSeconds()"}
+
+// awsvpcCNIPlugins returns the CNI plugins used to set up the network namespace of an awsvpc task with the ENI
+func awsvpcCNIPlugins(eni *apieni.ENI) []string {
+	return []string{ecscni.ECSVPCENIPluginName}
+}
+
 func (engine *DockerTaskEngine) updateTaskENIDependencies(task *apitask.Task) {
 	if !task.IsNetworkModeAWSVPC() {
 		return
@@ -98,3 +111,13 @@ func (engine *DockerTaskEngine) updateCredentialSpecMapping(taskID string, conta
 		}
 	}
 }
+
+// checkTaskDNSLatency is not supported on this platform, as the agent cannot enter the network namespace of
+// the task
+func (engine *DockerTaskEngine) checkTaskDNSLatency(ctx context.Context, task *apitask.Task) {
+}
+
+// checkContainerFDUsage is not supported on this platform
+func (engine *DockerTaskEngine) checkContainerFDUsage(ctx context.Context, task *apitask.Task,
+	container *apicontainer.Container) {
+}
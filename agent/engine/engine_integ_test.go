@@ -654,3 +654,31 @@ func TestTaskCleanup(t *testing.T) {
 	_, err = client.ContainerInspect(ctx, cid)
 	assert.Error(t, err, "Inspect should not work")
 }
+
+func TestTaskCleanupWaitDurationOverride(t *testing.T) {
+	cfg := defaultTestConfigIntegTest()
+	cfg.TaskCleanupWaitDuration = 1 * time.Second
+	cfg.TaskCleanupWaitDurationMaxOverride = 10 * time.Second
+	taskEngine, done, _ := setup(cfg, nil, t)
+	defer done()
+	stateChangeEvents := taskEngine.StateChangeEvents()
+
+	testTask := createTestTask("testTaskCleanupWaitDurationOverride")
+	testTask.Containers[0].DockerConfig.Config = aws.String(
+		fmt.Sprintf(`{"Labels":{"%s":"5s"}}`, apitask.TaskCleanupWaitDurationLabel))
+
+	go taskEngine.AddTask(testTask)
+
+	verifyTaskIsRunning(stateChangeEvents, testTask)
+	verifyTaskIsStopped(stateChangeEvents, testTask)
+	testTask.SetSentStatus(apitaskstatus.TaskStopped)
+
+	// The task outlives the global task cleanup wait duration...
+	time.Sleep(3 * time.Second)
+	_, ok := taskEngine.(*DockerTaskEngine).State().TaskByArn(testTask.Arn)
+	assert.True(t, ok, "Expected task with a longer cleanup wait duration not to be cleaned up yet")
+
+	// ...but is eventually cleaned up
+	err := verifyTaskIsCleanedUp(testTask.Arn, taskEngine)
+	assert.NoError(t, err)
+}
@@ -444,7 +444,7 @@ func TestExecCommandAgent(t *testing.T) {
 	cid := containerMap[testTask.Containers[0].Name].DockerID
 
 	// session limit is 2
-	testConfigFileName, _ := execcmd.GetExecAgentConfigFileName(2)
+	testConfigFileName, _ := execcmd.GetExecAgentConfigFileName(2, "")
 	testLogConfigFileName, _ := execcmd.GetExecAgentLogConfigFile()
 	verifyExecCmdAgentExpectedMounts(t, ctx, client, testTaskId, cid, testContainerName, testExecCmdHostBinDir+"/1.0.0.0", testConfigFileName, testLogConfigFileName)
 	pidA := verifyMockExecCommandAgentIsRunning(t, client, cid)
@@ -572,7 +572,7 @@ func TestExecCommandAgent(t *testing.T) {
 	cid := containerMap[testTask.Containers[0].Name].DockerID
 
 	// session limit is 2
-	testconfigDirName, _ := execcmd.GetExecAgentConfigDir(2)
+	testconfigDirName, _ := execcmd.GetExecAgentConfigDir(2, "", config.DefaultExecAgentFolderPerm)
 
 	// todo: change to file contents passed in
 	verifyExecCmdAgentExpectedMounts(t, ctx, client, testTaskId, cid, testContainerName, testExecCmdHostBinDir+"\\1.0.0.0", testconfigDirName)
@@ -14,6 +14,9 @@
 package engine
 
 import (
+	"fmt"
+	"strings"
+
 	apicontainerstatus "github.com/aws/amazon-ecs-agent/agent/api/container/status"
 	apierrors "github.com/aws/amazon-ecs-agent/agent/api/errors"
 )
@@ -42,13 +45,49 @@ func (err ContainerVanishedError) Error() string { return "No container matching
 // ErrorName returns the name of the error
 func (err ContainerVanishedError) ErrorName() string { return "ContainerVanishedError" }
 
+// ExecCommandAgentInitFailedError is a warning recorded on a container whose ExecCommandAgent
+// could not be initialized. The container is still started, without the exec feature.
+type ExecCommandAgentInitFailedError struct {
+	reason string
+}
+
+func (err ExecCommandAgentInitFailedError) Error() string {
+	return "ExecuteCommandAgent initialization failed: " + err.reason
+}
+
+// ErrorName returns the name of the error
+func (err ExecCommandAgentInitFailedError) ErrorName() string {
+	return "ExecCommandAgentInitFailedError"
+}
+
+// ImagePullFallbackToCacheError is a warning recorded on a container whose image couldn't be pulled, and
+// which uses the cached image of the same reference instead
+type ImagePullFallbackToCacheError struct {
+	pullErr error
+}
+
+func (err ImagePullFallbackToCacheError) Error() string {
+	return "image pull failed, using cached image: " + err.pullErr.Error()
+}
+
+// ErrorName returns the name of the error
+func (err ImagePullFallbackToCacheError) ErrorName() string {
+	return "ImagePullFallbackToCacheError"
+}
+
 // TaskDependencyError is the error for task that dependencies can't
 // be resolved
 type TaskDependencyError struct {
 	taskArn string
+	// cycle is the names of the containers that depend on each other in a cycle, if any
+	cycle []string
 }
 
 func (err TaskDependencyError) Error() string {
+	if len(err.cycle) > 0 {
+		return fmt.Sprintf("Task dependencies cannot be resolved as containers [%s] depend on each other in a cycle, taskArn: %s",
+			strings.Join(err.cycle, " -> "), err.taskArn)
+	}
 	return "Task dependencies cannot be resolved, taskArn: " + err.taskArn
 }
 
@@ -57,6 +96,90 @@ func (err TaskDependencyError) ErrorName() string {
 	return "TaskDependencyError"
 }
 
+// UnsupportedLogDriverError is the error for a task with a container
+// requesting a log driver that is not supported by the docker daemon
+type UnsupportedLogDriverError struct {
+	taskArn   string
+	container string
+	logDriver string
+}
+
+func (err UnsupportedLogDriverError) Error() string {
+	return fmt.Sprintf("Log driver %s of container %s is not supported by the docker daemon, taskArn: %s",
+		err.logDriver, err.container, err.taskArn)
+}
+
+// ErrorName is the name of the error
+func (err UnsupportedLogDriverError) ErrorName() string {
+	return "UnsupportedLogDriverError"
+}
+
+// UnsupportedEphemeralStorageError is the error for a task requesting an
+// ephemeral storage size that the storage driver of docker cannot enforce
+type UnsupportedEphemeralStorageError struct {
+	taskArn       string
+	storageDriver string
+}
+
+func (err UnsupportedEphemeralStorageError) Error() string {
+	return fmt.Sprintf("Ephemeral storage size is not supported by the docker storage driver %s, "+
+		"it requires overlay2 backed by xfs mounted with pquota, taskArn: %s", err.storageDriver, err.taskArn)
+}
+
+// ErrorName is the name of the error
+func (err UnsupportedEphemeralStorageError) ErrorName() string {
+	return "UnsupportedEphemeralStorageError"
+}
+
+// TaskNetworkingPrerequisiteError is the error for a task whose network
+// mode requires prerequisites that the container instance does not satisfy
+type TaskNetworkingPrerequisiteError struct {
+	taskArn     string
+	networkMode string
+	reason      string
+}
+
+func (err TaskNetworkingPrerequisiteError) Error() string {
+	return fmt.Sprintf("Prerequisites of the %s network mode are not satisfied: %s, taskArn: %s",
+		err.networkMode, err.reason, err.taskArn)
+}
+
+// ErrorName is the name of the error
+func (err TaskNetworkingPrerequisiteError) ErrorName() string {
+	return "TaskNetworkingPrerequisiteError"
+}
+
+// ImageQuarantinedError is the error for a container that is not created
+// because its image has been quarantined
+type ImageQuarantinedError struct {
+	container string
+	imageRef  string
+}
+
+func (err ImageQuarantinedError) Error() string {
+	return fmt.Sprintf("Container %s refused as its image %s is quarantined", err.container, err.imageRef)
+}
+
+// ErrorName is the name of the error
+func (err ImageQuarantinedError) ErrorName() string {
+	return "ImageQuarantinedError"
+}
+
+// TaskEngineDrainedError is the error for a new task that is refused
+// because the task engine has been drained
+type TaskEngineDrainedError struct {
+	taskArn string
+}
+
+func (err TaskEngineDrainedError) Error() string {
+	return "Task refused as the task engine is drained, taskArn: " + err.taskArn
+}
+
+// ErrorName is the name of the error
+func (err TaskEngineDrainedError) ErrorName() string {
+	return "TaskEngineDrainedError"
+}
+
 // TaskStoppedBeforePullBeginError is a type for task errors involving pull
 type TaskStoppedBeforePullBeginError struct {
 	taskArn string
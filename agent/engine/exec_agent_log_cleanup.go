@@ -0,0 +1,96 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/aws/amazon-ecs-agent/agent/engine/execcmd"
+	"github.com/aws/amazon-ecs-agent/agent/logger"
+	"github.com/aws/amazon-ecs-agent/agent/logger/field"
+)
+
+// execAgentLogDir returns the directory, as seen by the agent, under which the ExecCommandAgent logs of each task
+// are written to a directory named after the task ID
+func (engine *DockerTaskEngine) execAgentLogDir() string {
+	if engine.cfg.ExecAgentHostLogDir != "" {
+		return engine.cfg.ExecAgentHostLogDir
+	}
+	return execcmd.ECSAgentExecLogDir
+}
+
+// removeExecAgentLogDirs removes the ExecCommandAgent log directories of the containers of a task
+func (engine *DockerTaskEngine) removeExecAgentLogDirs(taskID string) {
+	logDir := engine.execAgentLogDir()
+	// nothing is cleaned up on platforms that don't support ECS Exec
+	if logDir == "" || taskID == "" {
+		return
+	}
+	if err := removeAll(filepath.Join(logDir, taskID)); err != nil {
+		logger.Warn("Unable to remove ExecAgent host logs for task", logger.Fields{
+			field.TaskID: taskID,
+			field.Error:  err,
+		})
+	}
+}
+
+// removeLeakedExecAgentLogDirs removes the ExecCommandAgent log directories of tasks that are unknown to the agent.
+// These are left behind when a task is removed without going through the task cleanup, e.g. when the agent is
+// stopped in the middle of it. A task is known if it is either in the engine state or in the data client, and
+// nothing is removed if the tasks of the data client can't be read.
+func (engine *DockerTaskEngine) removeLeakedExecAgentLogDirs() {
+	logDir := engine.execAgentLogDir()
+	if logDir == "" {
+		return
+	}
+	entries, err := ioutil.ReadDir(logDir)
+	if err != nil {
+		// the directory doesn't exist until ECS Exec is used for the first time
+		logger.Debug("Unable to read ExecAgent host log directory; leaked logs will not be removed", logger.Fields{
+			"logDir":    logDir,
+			field.Error: err,
+		})
+		return
+	}
+	if len(entries) == 0 {
+		return
+	}
+
+	savedTasks, err := engine.dataClient.GetTasks()
+	if err != nil {
+		logger.Warn("Unable to get saved tasks; leaked ExecAgent host logs will not be removed", logger.Fields{
+			field.Error: err,
+		})
+		return
+	}
+	knownTaskIDs := make(map[string]struct{})
+	for _, task := range append(engine.state.AllTasks(), savedTasks...) {
+		knownTaskIDs[task.GetID()] = struct{}{}
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		taskID := entry.Name()
+		if _, ok := knownTaskIDs[taskID]; ok {
+			continue
+		}
+		logger.Info("Removing leaked ExecAgent host logs of unknown task", logger.Fields{
+			field.TaskID: taskID,
+		})
+		engine.removeExecAgentLogDirs(taskID)
+	}
+}
@@ -0,0 +1,117 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
+	"github.com/aws/amazon-ecs-agent/agent/config"
+	"github.com/aws/amazon-ecs-agent/agent/data"
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type getTasksErrorDataClient struct {
+	data.Client
+}
+
+func (c *getTasksErrorDataClient) GetTasks() ([]*apitask.Task, error) {
+	return nil, errors.New("error")
+}
+
+// newExecAgentLogDirs creates the ExecCommandAgent log directories of a container of each of the given tasks
+func newExecAgentLogDirs(t *testing.T, logDir string, taskIDs ...string) {
+	for _, taskID := range taskIDs {
+		require.NoError(t, os.MkdirAll(filepath.Join(logDir, taskID, "container-name"), 0755))
+	}
+}
+
+func TestRemoveLeakedExecAgentLogDirs(t *testing.T) {
+	dataClient, cleanup := newTestDataClient(t)
+	defer cleanup()
+	logDir, err := ioutil.TempDir("", "exec-logs")
+	require.NoError(t, err)
+	defer os.RemoveAll(logDir)
+
+	state := dockerstate.NewTaskEngineState()
+	state.AddTask(&apitask.Task{Arn: "arn:aws:ecs:region:account-id:task/active-task-id"})
+	require.NoError(t, dataClient.SaveTask(&apitask.Task{Arn: "arn:aws:ecs:region:account-id:task/saved-task-id"}))
+	newExecAgentLogDirs(t, logDir, "active-task-id", "saved-task-id", "leaked-task-id")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(logDir, "not-a-task-dir"), nil, 0644))
+
+	engine := &DockerTaskEngine{
+		cfg:        &config.Config{ExecAgentHostLogDir: logDir},
+		state:      state,
+		dataClient: dataClient,
+	}
+	engine.removeLeakedExecAgentLogDirs()
+
+	assert.DirExists(t, filepath.Join(logDir, "active-task-id", "container-name"))
+	assert.DirExists(t, filepath.Join(logDir, "saved-task-id", "container-name"))
+	assert.FileExists(t, filepath.Join(logDir, "not-a-task-dir"))
+	_, err = os.Stat(filepath.Join(logDir, "leaked-task-id"))
+	assert.True(t, os.IsNotExist(err), "expected the log dirs of the leaked task to be removed")
+}
+
+func TestRemoveLeakedExecAgentLogDirsGetTasksError(t *testing.T) {
+	logDir, err := ioutil.TempDir("", "exec-logs")
+	require.NoError(t, err)
+	defer os.RemoveAll(logDir)
+	newExecAgentLogDirs(t, logDir, "leaked-task-id")
+
+	engine := &DockerTaskEngine{
+		cfg:        &config.Config{ExecAgentHostLogDir: logDir},
+		state:      dockerstate.NewTaskEngineState(),
+		dataClient: &getTasksErrorDataClient{Client: data.NewNoopClient()},
+	}
+	engine.removeLeakedExecAgentLogDirs()
+
+	assert.DirExists(t, filepath.Join(logDir, "leaked-task-id", "container-name"))
+}
+
+func TestRemoveLeakedExecAgentLogDirsNoLogDir(t *testing.T) {
+	engine := &DockerTaskEngine{
+		cfg:        &config.Config{ExecAgentHostLogDir: filepath.Join(os.TempDir(), "exec-logs-does-not-exist")},
+		state:      dockerstate.NewTaskEngineState(),
+		dataClient: data.NewNoopClient(),
+	}
+	// the log dir doesn't exist until ECS Exec is used for the first time
+	engine.removeLeakedExecAgentLogDirs()
+}
+
+func TestRemoveExecAgentLogDirs(t *testing.T) {
+	logDir, err := ioutil.TempDir("", "exec-logs")
+	require.NoError(t, err)
+	defer os.RemoveAll(logDir)
+	newExecAgentLogDirs(t, logDir, "stopped-task-id", "active-task-id")
+
+	engine := &DockerTaskEngine{
+		cfg: &config.Config{ExecAgentHostLogDir: logDir},
+	}
+	engine.removeExecAgentLogDirs("stopped-task-id")
+
+	_, err = os.Stat(filepath.Join(logDir, "stopped-task-id"))
+	assert.True(t, os.IsNotExist(err), "expected the log dirs of the stopped task to be removed")
+	assert.DirExists(t, filepath.Join(logDir, "active-task-id", "container-name"))
+}
@@ -0,0 +1,154 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package execcmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
+	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
+	"github.com/aws/amazon-ecs-agent/agent/logger"
+	"github.com/aws/amazon-ecs-agent/agent/logger/field"
+)
+
+const (
+	// AuditEventStart is the event of the audit record written when the ExecCommandAgent of a container starts
+	AuditEventStart = "START"
+	// AuditEventEnd is the event of the audit record written when the ExecCommandAgent of a container stops
+	AuditEventEnd = "END"
+
+	// rotatedAuditLogSuffix is appended to the path of the audit log it is rotated to
+	rotatedAuditLogSuffix = ".1"
+	auditLogDirPerm       = 0755
+	auditLogFilePerm      = 0600
+)
+
+// AuditRecord is the record appended to the audit log, as a line of JSON, when the ExecCommandAgent of a
+// container starts serving sessions and when it stops. The sessions themselves are brokered by the
+// ExecCommandAgent, so the agent does not know when each of them starts nor who opened it.
+type AuditRecord struct {
+	Event          string     `json:"event"`
+	Time           time.Time  `json:"time"`
+	TaskARN        string     `json:"taskArn"`
+	Container      string     `json:"container"`
+	ManagedAgentID string     `json:"managedAgentId"`
+	StartedAt      *time.Time `json:"startedAt,omitempty"`
+	EndedAt        *time.Time `json:"endedAt,omitempty"`
+	Reason         string     `json:"reason,omitempty"`
+}
+
+// auditLog appends audit records to a file on the host, rotating it once it grows above maxSize bytes
+type auditLog struct {
+	path    string
+	maxSize int64
+	lock    sync.Mutex
+}
+
+func newAuditLog(path string, maxSize int64) *auditLog {
+	return &auditLog{
+		path:    path,
+		maxSize: maxSize,
+	}
+}
+
+// write appends the record to the audit log. The audit log is first rotated if the record would grow it above
+// its maximum size, replacing the previously rotated file.
+func (l *auditLog) write(record AuditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if err := os.MkdirAll(filepath.Dir(l.path), auditLogDirPerm); err != nil {
+		return err
+	}
+	if info, err := os.Stat(l.path); err == nil && info.Size() > 0 && info.Size()+int64(len(line)) > l.maxSize {
+		if err := os.Rename(l.path, l.path+rotatedAuditLogSuffix); err != nil {
+			return err
+		}
+	}
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, auditLogFilePerm)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// RecordAgentStopped appends an end record to the audit log, if any, for the ExecCommandAgent of a container
+// which stopped along with the container
+func (m *manager) RecordAgentStopped(task *apitask.Task, container *apicontainer.Container, reason string) {
+	ma, ok := container.GetManagedAgentByName(ExecuteCommandAgentName)
+	if !ok {
+		return
+	}
+	m.auditAgentEnded(task, container, ma, reason)
+}
+
+// auditAgentStarted appends a start record to the audit log, if any, for the ExecCommandAgent of the container
+func (m *manager) auditAgentStarted(task *apitask.Task, container *apicontainer.Container, managedAgentID string, startedAt time.Time) {
+	if m.auditLog == nil {
+		return
+	}
+	m.writeAuditRecord(AuditRecord{
+		Event:          AuditEventStart,
+		Time:           startedAt,
+		TaskARN:        task.Arn,
+		Container:      container.Name,
+		ManagedAgentID: managedAgentID,
+		StartedAt:      &startedAt,
+	})
+}
+
+// auditAgentEnded appends an end record to the audit log, if any, for the ExecCommandAgent of the container. It
+// is only written while the managed agent is reported as running, so that the same stop is recorded once.
+func (m *manager) auditAgentEnded(task *apitask.Task, container *apicontainer.Container, ma apicontainer.ManagedAgent, reason string) {
+	if m.auditLog == nil || !m.isAgentStarted(ma) || !ma.Status.IsRunning() {
+		return
+	}
+	startedAt := ma.LastStartedAt
+	endedAt := time.Now()
+	m.writeAuditRecord(AuditRecord{
+		Event:          AuditEventEnd,
+		Time:           endedAt,
+		TaskARN:        task.Arn,
+		Container:      container.Name,
+		ManagedAgentID: ma.ID,
+		StartedAt:      &startedAt,
+		EndedAt:        &endedAt,
+		Reason:         reason,
+	})
+}
+
+func (m *manager) writeAuditRecord(record AuditRecord) {
+	if err := m.auditLog.write(record); err != nil {
+		logger.Error("Failed to write ExecCommandAgent audit record", logger.Fields{
+			field.TaskARN:      record.TaskARN,
+			field.Container:    record.Container,
+			field.ManagedAgent: ExecuteCommandAgentName,
+			"event":            record.Event,
+			field.Error:        err,
+		})
+	}
+}
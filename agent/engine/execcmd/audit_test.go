@@ -0,0 +1,124 @@
+//go:build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package execcmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readAuditRecords(t *testing.T, path string) []map[string]interface{} {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	var records []map[string]interface{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	require.NoError(t, scanner.Err())
+	return records
+}
+
+func TestAuditLogRecordFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "exec-audit.log")
+	auditLog := newAuditLog(path, 1024*1024)
+	startedAt := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+	endedAt := startedAt.Add(time.Hour)
+
+	require.NoError(t, auditLog.write(AuditRecord{
+		Event:          AuditEventStart,
+		Time:           startedAt,
+		TaskARN:        "task-arn",
+		Container:      "container-name",
+		ManagedAgentID: "managed-agent-id",
+		StartedAt:      &startedAt,
+	}))
+	require.NoError(t, auditLog.write(AuditRecord{
+		Event:          AuditEventEnd,
+		Time:           endedAt,
+		TaskARN:        "task-arn",
+		Container:      "container-name",
+		ManagedAgentID: "managed-agent-id",
+		StartedAt:      &startedAt,
+		EndedAt:        &endedAt,
+		Reason:         "Received Container Stopped event",
+	}))
+
+	records := readAuditRecords(t, path)
+	require.Len(t, records, 2)
+	assert.Equal(t, map[string]interface{}{
+		"event":          "START",
+		"time":           "2022-03-01T10:00:00Z",
+		"taskArn":        "task-arn",
+		"container":      "container-name",
+		"managedAgentId": "managed-agent-id",
+		"startedAt":      "2022-03-01T10:00:00Z",
+	}, records[0])
+	assert.Equal(t, map[string]interface{}{
+		"event":          "END",
+		"time":           "2022-03-01T11:00:00Z",
+		"taskArn":        "task-arn",
+		"container":      "container-name",
+		"managedAgentId": "managed-agent-id",
+		"startedAt":      "2022-03-01T10:00:00Z",
+		"endedAt":        "2022-03-01T11:00:00Z",
+		"reason":         "Received Container Stopped event",
+	}, records[1])
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(auditLogFilePerm), info.Mode().Perm())
+}
+
+func TestAuditLogRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exec-audit.log")
+	record := func(container string) AuditRecord {
+		return AuditRecord{
+			Event:          AuditEventStart,
+			Time:           time.Now(),
+			TaskARN:        "task-arn",
+			Container:      container,
+			ManagedAgentID: "managed-agent-id",
+		}
+	}
+	line, err := json.Marshal(record("c1"))
+	require.NoError(t, err)
+	// Room for two records only
+	auditLog := newAuditLog(path, int64(2*(len(line)+1)))
+
+	for _, container := range []string{"c1", "c2", "c3", "c4", "c5"} {
+		require.NoError(t, auditLog.write(record(container)))
+	}
+
+	// The oldest records are dropped along with the file rotated first
+	records := readAuditRecords(t, path)
+	require.Len(t, records, 1)
+	assert.Equal(t, "c5", records[0]["container"])
+	rotated := readAuditRecords(t, path+rotatedAuditLogSuffix)
+	require.Len(t, rotated, 2)
+	assert.Equal(t, "c3", rotated[0]["container"])
+	assert.Equal(t, "c4", rotated[1]["container"])
+}
@@ -15,11 +15,13 @@ package execcmd
 
 import (
 	"context"
+	"os"
 	"strconv"
 	"time"
 
 	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
 	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
+	"github.com/aws/amazon-ecs-agent/agent/config"
 	"github.com/aws/amazon-ecs-agent/agent/dockerclient/dockerapi"
 	"github.com/aws/amazon-ecs-agent/agent/ecs_client/model/ecs"
 
@@ -32,6 +34,7 @@ const (
 	defaultRetryMinDelay       = time.Second * 1
 	defaultRetryMaxDelay       = time.Second * 30
 	defaultInspectRetryTimeout = time.Minute * 2
+	defaultFolderPerm          = config.DefaultExecAgentFolderPerm
 	maxRetries                 = 5
 	retryDelayMultiplier       = 1.5
 	retryJitterMultiplier      = 0.2
@@ -69,19 +72,40 @@ type Manager interface {
 	InitializeContainer(taskId string, container *apicontainer.Container, hostConfig *dockercontainer.HostConfig) error
 	StartAgent(ctx context.Context, client dockerapi.DockerClient, task *apitask.Task, container *apicontainer.Container, containerId string) error
 	RestartAgentIfStopped(ctx context.Context, client dockerapi.DockerClient, task *apitask.Task, container *apicontainer.Container, containerId string) (RestartStatus, error)
+	CheckAgentHealth(ctx context.Context, client dockerapi.DockerClient, task *apitask.Task, container *apicontainer.Container) (bool, error)
+	RecordAgentStopped(task *apitask.Task, container *apicontainer.Container, reason string)
+}
+
+// TaskTagsClient retrieves the tags of a task from ECS
+type TaskTagsClient interface {
+	GetResourceTags(resourceArn string) ([]*ecs.Tag, error)
 }
 
 type manager struct {
-	hostBinDir          string
-	retryMaxDelay       time.Duration
-	retryMinDelay       time.Duration
-	startRetryTimeout   time.Duration
-	inspectRetryTimeout time.Duration
+	hostBinDir              string
+	execAgentCmdUser        string
+	folderPerm              os.FileMode
+	hostLogDir              string
+	retryMaxDelay           time.Duration
+	retryMinDelay           time.Duration
+	startRetryTimeout       time.Duration
+	inspectRetryTimeout     time.Duration
+	removeSupersededConfigs bool
+	// taskTags retrieves the tags of the tasks to read their session limit from, they are not read when it is nil
+	taskTags TaskTagsClient
+	// maxSessionLimit caps the session limit of the ExecCommandAgent, which is not capped when it is zero
+	maxSessionLimit int
+	// auditLog is where the starts and stops of the ExecCommandAgent are recorded, they are not recorded when it
+	// is nil
+	auditLog *auditLog
 }
 
 func NewManager() *manager {
 	return &manager{
 		hostBinDir:          HostBinDir,
+		execAgentCmdUser:    defaultExecAgentCmdUser,
+		folderPerm:          defaultFolderPerm,
+		hostLogDir:          HostLogDir,
 		retryMaxDelay:       defaultRetryMaxDelay,
 		retryMinDelay:       defaultRetryMinDelay,
 		startRetryTimeout:   defaultStartRetryTimeout,
@@ -95,6 +119,41 @@ func NewManagerWithBinDir(hostBinDir string) *manager {
 	return m
 }
 
+// NewManagerWithCmdUser returns a manager that runs the ExecCommandAgent as the given user. The default user
+// is used if it is empty.
+func NewManagerWithCmdUser(execAgentCmdUser string) *manager {
+	m := NewManager()
+	if execAgentCmdUser != "" {
+		m.execAgentCmdUser = execAgentCmdUser
+	}
+	return m
+}
+
+// NewManagerWithConfig returns a manager that runs the ExecCommandAgent as the configured user, creates the
+// ExecCommandAgent directories with the configured permissions, writes its logs under the configured host
+// directory and records its starts and stops in the configured audit log
+func NewManagerWithConfig(cfg *config.Config) *manager {
+	m := NewManagerWithCmdUser(cfg.ExecAgentCmdUser)
+	m.removeSupersededConfigs = cfg.ExecAgentRemoveSupersededConfigs.Enabled()
+	m.maxSessionLimit = cfg.ExecMaxSessionLimit
+	if cfg.ExecAgentFolderPerm != 0 {
+		m.folderPerm = cfg.ExecAgentFolderPerm
+	}
+	if cfg.ExecAgentHostLogDir != "" {
+		m.hostLogDir = cfg.ExecAgentHostLogDir
+	}
+	if cfg.ExecAuditLogFile != "" {
+		m.auditLog = newAuditLog(cfg.ExecAuditLogFile, int64(cfg.ExecAuditLogMaxSizeMB)*1024*1024)
+	}
+	return m
+}
+
+// SetTaskTagsClient sets the client retrieving the tags of the tasks, to read the session limit of the
+// ExecCommandAgent from their ecs:exec-session-limit tag
+func (m *manager) SetTaskTagsClient(client TaskTagsClient) {
+	m.taskTags = client
+}
+
 func (m *manager) isAgentStarted(ma apicontainer.ManagedAgent) bool {
 	return !ma.LastStartedAt.IsZero()
 }
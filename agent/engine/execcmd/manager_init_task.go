@@ -16,20 +16,30 @@
 package execcmd
 
 import (
+	"bufio"
+	"bytes"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	apicontainerstatus "github.com/aws/amazon-ecs-agent/agent/api/container/status"
+	"github.com/aws/amazon-ecs-agent/agent/logger"
+	"github.com/aws/amazon-ecs-agent/agent/logger/field"
+	"github.com/aws/amazon-ecs-agent/agent/utils/retry"
 	dockercontainer "github.com/docker/docker/api/types/container"
 
 	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/pborman/uuid"
 )
 
@@ -40,10 +50,30 @@ const (
 	filePerm            = 0644
 	defaultSessionLimit = 2
 
+	// The exec agent config files are written a few times with a small backoff, as the write can transiently
+	// fail on a busy disk or while a file is locked by an antivirus scanner on Windows
+	configFileWriteMaxAttempts = 4
+	configFileWriteMinDelay    = 100 * time.Millisecond
+	configFileWriteMaxDelay    = 1 * time.Second
+
+	// sessionLimitProperty is the property of the managed agent, sent by ECS, limiting the number of sessions
+	sessionLimitProperty = "sessionLimit"
+	// sessionLimitTaskTag is the task tag limiting the number of sessions, which takes precedence over
+	// sessionLimitProperty
+	sessionLimitTaskTag = "ecs:exec-session-limit"
+
+	// sessionShellProperty is the property of the managed agent overriding the shell launched by the
+	// session worker, for containers without the default shell
+	sessionShellProperty = "sessionShell"
+
 	containerConfigFileName = "amazon-ssm-agent.json"
 	ContainerConfigDirName  = "config"
 
 	ExecAgentLogConfigFileName = "seelog.xml"
+
+	// ExecAgentChecksumManifestName is the optional manifest shipped alongside the ExecCommandAgent binaries of
+	// a version. It lists the SHA256 checksum of each binary in the format of the output of sha256sum.
+	ExecAgentChecksumManifestName = "sha256sums"
 )
 
 var (
@@ -61,6 +91,23 @@ var (
 	}
 }`
 
+	// execAgentConfigWithShellTemplate is the ExecCommandAgent config of a container overriding the shell
+	// launched by the session worker
+	execAgentConfigWithShellTemplate = `{
+	"Mgs": {
+		"Region": "",
+		"Endpoint": "",
+		"StopTimeoutMillis": 20000,
+		"SessionWorkersLimit": %d,
+		"SessionShell": %s
+	},
+	"Agent": {
+		"Region": "",
+		"OrchestrationRootDir": "",
+		"ContainerMode": true
+	}
+}`
+
 	errExecCommandManagedAgentNotFound = fmt.Errorf("managed agent not found (%s)", ExecuteCommandAgentName)
 )
 
@@ -80,7 +127,14 @@ func (m *manager) InitializeContainer(taskId string, container *apicontainer.Con
 	if !ok {
 		return errExecCommandManagedAgentNotFound
 	}
-	sessionWorkersLimit := getSessionWorkersLimit(ma)
+	if rErr = validateExecAgentCmdUser(m.execAgentCmdUser); rErr != nil {
+		return rErr
+	}
+	sessionWorkersLimit := m.getSessionWorkersLimit(container, ma)
+	sessionShell, rErr := getSessionShell(ma)
+	if rErr != nil {
+		return rErr
+	}
 	cn := fileSystemSafeContainerName(container)
 	uuid := newUUID()
 
@@ -89,7 +143,8 @@ func (m *manager) InitializeContainer(taskId string, container *apicontainer.Con
 		return rErr
 	}
 
-	rErr = addRequiredBindMounts(taskId, cn, latestBinVersionDir, uuid, sessionWorkersLimit, hostConfig)
+	rErr = addRequiredBindMounts(taskId, cn, latestBinVersionDir, uuid, sessionWorkersLimit, sessionShell, m.folderPerm, m.hostLogDir,
+		m.removeSupersededConfigs, hostConfig)
 	if rErr != nil {
 		return rErr
 	}
@@ -122,6 +177,9 @@ func (m *manager) getLatestVersionedHostBinDir() (string, error) {
 		if !fileExists(filepath.Join(ecsAgentDepsVersionedBinDir, SessionWorkerBinName)) {
 			continue // try falling back to the previous version
 		}
+		if err := verifyExecAgentBinaries(ecsAgentDepsVersionedBinDir); err != nil {
+			return "", err
+		}
 		latest = filepath.Join(m.hostBinDir, vStr)
 		break
 	}
@@ -131,6 +189,79 @@ func (m *manager) getLatestVersionedHostBinDir() (string, error) {
 	return latest, nil
 }
 
+// verifyExecAgentBinaries verifies the ExecCommandAgent binaries in binDir against the checksum manifest
+// shipped alongside them. Verification is skipped if there is no manifest.
+func verifyExecAgentBinaries(binDir string) error {
+	manifestPath := filepath.Join(binDir, ExecAgentChecksumManifestName)
+	manifest, err := getFileContent(manifestPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			logger.Debug("No checksum manifest found for ExecCommandAgent binaries, skipping verification", logger.Fields{
+				"binDir": binDir,
+			})
+			return nil
+		}
+		return fmt.Errorf("could not read ExecCommandAgent checksum manifest %s: %v", manifestPath, err)
+	}
+	checksums, err := parseChecksumManifest(manifest)
+	if err != nil {
+		return fmt.Errorf("invalid ExecCommandAgent checksum manifest %s: %v", manifestPath, err)
+	}
+	for _, binName := range []string{SSMAgentBinName, SSMAgentWorkerBinName, SessionWorkerBinName} {
+		expected, ok := checksums[binName]
+		if !ok {
+			return fmt.Errorf("ExecCommandAgent checksum manifest %s has no checksum for %s", manifestPath, binName)
+		}
+		actual, err := getFileSHA256(filepath.Join(binDir, binName))
+		if err != nil {
+			return fmt.Errorf("could not compute checksum of ExecCommandAgent binary %s: %v", binName, err)
+		}
+		if actual != expected {
+			return fmt.Errorf("checksum mismatch for ExecCommandAgent binary %s in %s: expected %s, got %s",
+				binName, binDir, expected, actual)
+		}
+	}
+	return nil
+}
+
+// parseChecksumManifest parses lines of the form "<sha256> <file name>", where the file name may be prefixed
+// with '*' to denote binary mode, into a map of file name to checksum.
+func parseChecksumManifest(manifest []byte) (map[string]string, error) {
+	checksums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("malformed line %q", line)
+		}
+		checksum := strings.ToLower(fields[0])
+		if decoded, err := hex.DecodeString(checksum); err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("malformed checksum %q", fields[0])
+		}
+		checksums[strings.TrimPrefix(fields[1], "*")] = checksum
+	}
+	return checksums, scanner.Err()
+}
+
+var getFileSHA256 = fileSHA256
+
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
 func getReadOnlyBindMountMapping(hostDir, containerDir string) string {
 	return getBindMountMapping(hostDir, containerDir) + ":ro"
 }
@@ -151,24 +282,110 @@ func fileSystemSafeContainerName(c *apicontainer.Container) string {
 	return cn
 }
 
-func getSessionWorkersLimit(ma apicontainer.ManagedAgent) int {
-	// TODO [ecs-exec] : verify that returning the default session limit (2) is ok in case of any errors, misconfiguration
-	limit := defaultSessionLimit
-	if ma.Properties == nil { // This means ACS didn't send the limit
-		return limit
+// getSessionWorkersLimit returns the session limit of the ExecCommandAgent of the container, from the task tag,
+// the managed agent property or the default limit in this order of precedence, capped at maxSessionLimit
+func (m *manager) getSessionWorkersLimit(container *apicontainer.Container, ma apicontainer.ManagedAgent) int {
+	limit, ok := m.getTaskTagSessionLimit(container)
+	if !ok {
+		limit, ok = getManagedAgentSessionLimit(ma)
 	}
-	limitStr, ok := ma.Properties["sessionLimit"]
-	if !ok { // This also means ACS didn't send the limit
-		return limit
+	if !ok {
+		limit = defaultSessionLimit
+	}
+	if m.maxSessionLimit > 0 && limit > m.maxSessionLimit {
+		logger.Info("Capping the session limit of the ExecCommandAgent", logger.Fields{
+			field.Container:   container.Name,
+			"sessionLimit":    limit,
+			"maxSessionLimit": m.maxSessionLimit,
+		})
+		limit = m.maxSessionLimit
 	}
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil { // This means ACS send a limit that can't be converted to an int
-		return limit
+	return limit
+}
+
+// getTaskTagSessionLimit returns the session limit set by the tag of the task of the container, if the tags of
+// the tasks are read and the tag is valid
+func (m *manager) getTaskTagSessionLimit(container *apicontainer.Container) (int, bool) {
+	if m.taskTags == nil {
+		return 0, false
+	}
+	taskARN := container.GetTaskARN()
+	tags, err := m.taskTags.GetResourceTags(taskARN)
+	if err != nil {
+		logger.Warn("Unable to retrieve the tags of the task to read the session limit of the ExecCommandAgent", logger.Fields{
+			field.TaskARN: taskARN,
+			field.Error:   err,
+		})
+		return 0, false
+	}
+	for _, tag := range tags {
+		if aws.StringValue(tag.Key) != sessionLimitTaskTag {
+			continue
+		}
+		limit, err := parseSessionLimit(aws.StringValue(tag.Value))
+		if err != nil {
+			logger.Warn("Ignoring invalid session limit task tag", logger.Fields{
+				field.TaskARN: taskARN,
+				"tag":         sessionLimitTaskTag,
+				field.Error:   err,
+			})
+			return 0, false
+		}
+		return limit, true
+	}
+	return 0, false
+}
+
+// getManagedAgentSessionLimit returns the session limit sent by ECS in the properties of the managed agent, if it
+// is valid
+func getManagedAgentSessionLimit(ma apicontainer.ManagedAgent) (int, bool) {
+	limitStr, ok := ma.Properties[sessionLimitProperty]
+	if !ok { // This means ACS didn't send the limit
+		return 0, false
+	}
+	limit, err := parseSessionLimit(limitStr)
+	if err != nil {
+		return 0, false
+	}
+	return limit, true
+}
+
+// parseSessionLimit parses a session limit, which must be a positive integer
+func parseSessionLimit(limitStr string) (int, error) {
+	limit, err := strconv.Atoi(strings.TrimSpace(limitStr))
+	if err != nil {
+		return 0, err
 	}
 	if limit <= 0 {
-		limit = defaultSessionLimit
+		return 0, fmt.Errorf("session limit %d is not positive", limit)
 	}
-	return limit
+	return limit, nil
+}
+
+// getSessionShell returns the shell launched by the session worker if the container overrides it. An empty
+// string means the session worker launches its default shell.
+func getSessionShell(ma apicontainer.ManagedAgent) (string, error) {
+	shell, ok := ma.Properties[sessionShellProperty]
+	if !ok {
+		return "", nil
+	}
+	if shell == "" {
+		return "", fmt.Errorf("invalid %s: the path of the shell is empty", sessionShellProperty)
+	}
+	if !filepath.IsAbs(shell) {
+		return "", fmt.Errorf("invalid %s %q: the path of the shell must be absolute", sessionShellProperty, shell)
+	}
+	return shell, nil
+}
+
+// renderExecAgentConfig renders the ExecCommandAgent config. The session shell is only written to the config
+// when it is overridden, so that the config of the containers using the default shell is unchanged.
+func renderExecAgentConfig(sessionLimit int, sessionShell string) string {
+	if sessionShell == "" {
+		return fmt.Sprintf(execAgentConfigTemplate, sessionLimit)
+	}
+	shell, _ := json.Marshal(sessionShell)
+	return fmt.Sprintf(execAgentConfigWithShellTemplate, sessionLimit, shell)
 }
 
 var removeAll = os.RemoveAll
@@ -201,7 +418,33 @@ func isDir(path string) bool {
 	return false
 }
 
-var createNewExecAgentConfigFile = createNewConfigFile
+var createNewExecAgentConfigFile = createNewConfigFileWithRetry
+
+var writeExecAgentConfigFile = createNewConfigFile
+
+// createNewConfigFileWithRetry writes the exec agent config file, retrying transient failures with a small backoff.
+// The error of the last attempt is returned once the attempts are exhausted.
+func createNewConfigFileWithRetry(config, configFilePath string) error {
+	attempts := 0
+	backoff := retry.NewExponentialBackoff(configFileWriteMinDelay, configFileWriteMaxDelay, retryJitterMultiplier,
+		retryDelayMultiplier)
+	err := retry.RetryNWithBackoff(backoff, configFileWriteMaxAttempts, func() error {
+		attempts++
+		err := writeExecAgentConfigFile(config, configFilePath)
+		if err != nil {
+			logger.Warn("Unable to write the ExecCommandAgent config file", logger.Fields{
+				"path":      configFilePath,
+				"attempt":   attempts,
+				field.Error: err,
+			})
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("unable to write config file %s after %d attempts: %v", configFilePath, attempts, err)
+	}
+	return nil
+}
 
 func createNewConfigFile(config, configFilePath string) error {
 	return ioutil.WriteFile(configFilePath, []byte(config), filePerm)
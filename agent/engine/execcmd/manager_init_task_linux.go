@@ -17,9 +17,13 @@ package execcmd
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/aws/amazon-ecs-agent/agent/logger"
+	"github.com/aws/amazon-ecs-agent/agent/logger/field"
+
 	dockercontainer "github.com/docker/docker/api/types/container"
 )
 
@@ -114,8 +118,8 @@ func validConfigExists(configFilePath, expectedHash string) bool {
 
 var GetExecAgentConfigFileName = getAgentConfigFileName
 
-func getAgentConfigFileName(sessionLimit int) (string, error) {
-	config := fmt.Sprintf(execAgentConfigTemplate, sessionLimit)
+func getAgentConfigFileName(sessionLimit int, sessionShell string) (string, error) {
+	config := renderExecAgentConfig(sessionLimit, sessionShell)
 	hash := getExecAgentConfigHash(config)
 	configFileName := fmt.Sprintf(execAgentConfigFileNameTemplate, hash)
 	// check if config file exists already
@@ -136,18 +140,56 @@ func getAgentConfigFileName(sessionLimit int) (string, error) {
 	return configFileName, nil
 }
 
+// removeSupersededConfigFiles removes the ExecAgent config files written for session settings other than the ones
+// of the given config file. Failures are logged, as the current config file is usable regardless.
+func removeSupersededConfigFiles(configFileName string) {
+	files, err := ioUtilReadDir(ECSAgentExecConfigDir)
+	if err != nil {
+		logger.Warn("Unable to list the ExecAgent config files", logger.Fields{
+			"path":      ECSAgentExecConfigDir,
+			field.Error: err,
+		})
+		return
+	}
+	for _, file := range files {
+		name := file.Name()
+		if name == configFileName || file.IsDir() {
+			continue
+		}
+		if ok, _ := filepath.Match(fmt.Sprintf(execAgentConfigFileNameTemplate, "*"), name); !ok {
+			continue
+		}
+		path := filepath.Join(ECSAgentExecConfigDir, name)
+		if err := removeAll(path); err != nil {
+			logger.Warn("Unable to remove superseded ExecAgent config file", logger.Fields{
+				"path":      path,
+				field.Error: err,
+			})
+			continue
+		}
+		logger.Info("Removed superseded ExecAgent config file", logger.Fields{
+			"path": path,
+		})
+	}
+}
+
 func certsExist() bool {
 	return fileExists(filepath.Join(ecsAgentDepsCertsDir, "tls-ca-bundle.pem"))
 }
 
 // This function creates any necessary config directories/files and ensures that
-// the ssm-agent binaries, configs, logs, and plugin is bind mounted
-func addRequiredBindMounts(taskId, cn, latestBinVersionDir, uuid string, sessionWorkersLimit int, hostConfig *dockercontainer.HostConfig) error {
-	configFile, rErr := GetExecAgentConfigFileName(sessionWorkersLimit)
+// the ssm-agent binaries, configs, logs, and plugin is bind mounted. On linux, the config files are
+// written to existing directories and docker creates the log directory, so folderPerm is not used.
+func addRequiredBindMounts(taskId, cn, latestBinVersionDir, uuid string, sessionWorkersLimit int, sessionShell string,
+	folderPerm os.FileMode, hostLogDir string, removeSupersededConfigs bool, hostConfig *dockercontainer.HostConfig) error {
+	configFile, rErr := GetExecAgentConfigFileName(sessionWorkersLimit, sessionShell)
 	if rErr != nil {
 		rErr = fmt.Errorf("could not generate ExecAgent Config File: %v", rErr)
 		return rErr
 	}
+	if removeSupersededConfigs {
+		removeSupersededConfigFiles(configFile)
+	}
 	logConfigFile, rErr := GetExecAgentLogConfigFile()
 	if rErr != nil {
 		rErr = fmt.Errorf("could not generate ExecAgent LogConfig file: %v", rErr)
@@ -191,7 +233,7 @@ func addRequiredBindMounts(taskId, cn, latestBinVersionDir, uuid string, session
 
 	// Add ssm log bind mount
 	hostConfig.Binds = append(hostConfig.Binds, getBindMountMapping(
-		filepath.Join(HostLogDir, taskId, cn),
+		filepath.Join(hostLogDir, taskId, cn),
 		ContainerLogDir))
 	return nil
 }
@@ -20,11 +20,14 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	dockercontainer "github.com/docker/docker/api/types/container"
 	"github.com/pborman/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
 	apicontainerstatus "github.com/aws/amazon-ecs-agent/agent/api/container/status"
@@ -37,6 +40,8 @@ func TestInitializeContainer(t *testing.T) {
 		ioUtilReadDir = ioutil.ReadDir
 		osStat = os.Stat
 		GetExecAgentLogConfigFile = getAgentLogConfigFile
+		getFileContent = readFileContent
+		getFileSHA256 = fileSHA256
 	}()
 
 	newUUID = func() string {
@@ -44,6 +49,8 @@ func TestInitializeContainer(t *testing.T) {
 	}
 
 	const (
+		testChecksum             = "4f2a5bbd1f4b5c4a9a0d2e9c2b8b8f3c8e1e0a6d5b4c3a2f1e0d9c8b7a6f5e4d"
+		testCorruptedChecksum    = "0000000000000000000000000000000000000000000000000000000000000000"
 		containerNameOnlyHyphens = "--"
 		latestVersion            = "3.0.236.0"
 		previousVersion          = "2.0.0.0"
@@ -57,6 +64,8 @@ func TestInitializeContainer(t *testing.T) {
 		simulateNoValidVersion            bool
 		simulateEmptyVersionDir           bool
 		simulateFallbackToPreviousVersion bool
+		execAgentCmdUser                  string
+		simulateChecksumMismatch          bool
 		expectedVersionUsed               string
 		expectedError                     error
 	}{
@@ -100,6 +109,25 @@ func TestInitializeContainer(t *testing.T) {
 			managedAgentName:    ExecuteCommandAgentName,
 			expectedVersionUsed: latestVersion,
 		},
+		{
+			name:                     "simulate checksum mismatch of the latest version",
+			managedAgentName:         ExecuteCommandAgentName,
+			simulateChecksumMismatch: true,
+			expectedError: errors.New("checksum mismatch for ExecCommandAgent binary amazon-ssm-agent in " +
+				"/managed-agents/execute-command/bin/" + latestVersion + ": expected " + testChecksum + ", got " + testCorruptedChecksum),
+		},
+		{
+			name:             "invalid exec agent user",
+			managedAgentName: ExecuteCommandAgentName,
+			execAgentCmdUser: "root user",
+			expectedError:    errors.New(`invalid ExecCommandAgent user "root user": expected user[:group] with a name or numeric id`),
+		},
+		{
+			name:                "overridden exec agent user",
+			managedAgentName:    ExecuteCommandAgentName,
+			execAgentCmdUser:    "ssm-user:1000",
+			expectedVersionUsed: latestVersion,
+		},
 		{
 			name:                       "simulate log config file generation error",
 			managedAgentName:           ExecuteCommandAgentName,
@@ -125,8 +153,11 @@ func TestInitializeContainer(t *testing.T) {
 			}
 
 			execCmdMgr := newTestManager()
+			if test.execAgentCmdUser != "" {
+				execCmdMgr.execAgentCmdUser = test.execAgentCmdUser
+			}
 
-			GetExecAgentConfigFileName = func(s int) (string, error) {
+			GetExecAgentConfigFileName = func(s int, shell string) (string, error) {
 				return "amazon-ssm-agent.json", test.getExecAgentConfigFileNameError
 			}
 
@@ -164,6 +195,19 @@ func TestInitializeContainer(t *testing.T) {
 				return &mockFileInfo{name: "", isDir: false}, err
 			}
 
+			getFileContent = func(filePath string) ([]byte, error) {
+				if !test.simulateChecksumMismatch {
+					return nil, os.ErrNotExist
+				}
+				return []byte(testChecksum + "  " + SSMAgentBinName + "\n" +
+					testChecksum + "  " + SSMAgentWorkerBinName + "\n" +
+					testChecksum + "  " + SessionWorkerBinName + "\n"), nil
+			}
+
+			getFileSHA256 = func(path string) (string, error) {
+				return testCorruptedChecksum, nil
+			}
+
 			for _, container := range containers {
 				hc := &dockercontainer.HostConfig{}
 				err := execCmdMgr.InitializeContainer("task-id", container, hc)
@@ -176,6 +220,7 @@ func TestInitializeContainer(t *testing.T) {
 						assert.Equal(t, "", ma.ID)
 						assert.True(t, ma.InitFailed)
 						assert.Equal(t, apicontainerstatus.ManagedAgentStopped, ma.Status)
+						assert.Equal(t, test.expectedError.Error(), ma.Reason)
 					}
 					continue
 				}
@@ -285,7 +330,7 @@ func TestGetExecAgentConfigFileName(t *testing.T) {
 	}
 	defer func() {
 		osStat = os.Stat
-		createNewExecAgentConfigFile = createNewConfigFile
+		createNewExecAgentConfigFile = createNewConfigFileWithRetry
 		removeAll = os.RemoveAll
 	}()
 	for _, tc := range tests {
@@ -303,12 +348,135 @@ func TestGetExecAgentConfigFileName(t *testing.T) {
 		createNewExecAgentConfigFile = func(c, f string) error {
 			return tc.createConfigFileErr
 		}
-		fileName, err := GetExecAgentConfigFileName(2)
+		fileName, err := GetExecAgentConfigFileName(2, "")
 		assert.Equal(t, tc.expectedConfigFileName, fileName, "incorrect config file name")
 		assert.Equal(t, tc.expectedError, err)
 	}
 }
 
+func TestAddRequiredBindMountsSharesConfigFilesAcrossTasks(t *testing.T) {
+	defer func() {
+		osStat = os.Stat
+		getFileContent = readFileContent
+		createNewExecAgentConfigFile = createNewConfigFileWithRetry
+	}()
+	// files written by the agent, keyed by path
+	files := map[string]string{
+		filepath.Join(ecsAgentDepsCertsDir, "tls-ca-bundle.pem"): "certs",
+	}
+	osStat = func(name string) (os.FileInfo, error) {
+		if _, ok := files[name]; ok {
+			return &mockFileInfo{name: filepath.Base(name)}, nil
+		}
+		return nil, os.ErrNotExist
+	}
+	getFileContent = func(path string) ([]byte, error) {
+		return []byte(files[path]), nil
+	}
+	writes := 0
+	createNewExecAgentConfigFile = func(config, configFilePath string) error {
+		writes++
+		files[configFilePath] = config
+		return nil
+	}
+
+	configFileMount := func(hostConfig *dockercontainer.HostConfig) string {
+		for _, bind := range hostConfig.Binds {
+			if strings.Contains(bind, ContainerConfigFileSuffix) {
+				return bind
+			}
+		}
+		return ""
+	}
+
+	hostConfig1 := &dockercontainer.HostConfig{}
+	err := addRequiredBindMounts("task-1", "container-name", "bin-dir", "uuid-1", 2, "", 0755, HostLogDir, false, hostConfig1)
+	assert.NoError(t, err)
+	hostConfig2 := &dockercontainer.HostConfig{}
+	err = addRequiredBindMounts("task-2", "container-name", "bin-dir", "uuid-2", 2, "", 0755, HostLogDir, false, hostConfig2)
+	assert.NoError(t, err)
+
+	// the config and log config files are written once and both tasks mount the same config file
+	assert.Equal(t, 2, writes)
+	hostConfigFile := strings.SplitN(configFileMount(hostConfig1), ":", 2)[0]
+	assert.NotEmpty(t, hostConfigFile)
+	assert.Equal(t, hostConfigFile, strings.SplitN(configFileMount(hostConfig2), ":", 2)[0])
+	assert.NotEqual(t, hostConfig1.Binds, hostConfig2.Binds)
+
+	// different session settings get a config file of their own
+	hostConfig3 := &dockercontainer.HostConfig{}
+	err = addRequiredBindMounts("task-3", "container-name", "bin-dir", "uuid-3", 3, "", 0755, HostLogDir, false, hostConfig3)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, writes)
+	assert.NotEqual(t, hostConfigFile, strings.SplitN(configFileMount(hostConfig3), ":", 2)[0])
+}
+
+func TestAddRequiredBindMountsRemovesSupersededConfigFiles(t *testing.T) {
+	defer func() {
+		osStat = os.Stat
+		getFileContent = readFileContent
+		createNewExecAgentConfigFile = createNewConfigFileWithRetry
+		ioUtilReadDir = ioutil.ReadDir
+		removeAll = os.RemoveAll
+	}()
+	// files written by the agent, keyed by path
+	files := map[string]string{
+		filepath.Join(ecsAgentDepsCertsDir, "tls-ca-bundle.pem"): "certs",
+	}
+	osStat = func(name string) (os.FileInfo, error) {
+		if _, ok := files[name]; ok {
+			return &mockFileInfo{name: filepath.Base(name)}, nil
+		}
+		return nil, os.ErrNotExist
+	}
+	getFileContent = func(path string) ([]byte, error) {
+		return []byte(files[path]), nil
+	}
+	createNewExecAgentConfigFile = func(config, configFilePath string) error {
+		files[configFilePath] = config
+		return nil
+	}
+	ioUtilReadDir = func(dirname string) ([]os.FileInfo, error) {
+		var fileInfos []os.FileInfo
+		for path := range files {
+			if filepath.Dir(path) == dirname {
+				fileInfos = append(fileInfos, &mockFileInfo{name: filepath.Base(path)})
+			}
+		}
+		return fileInfos, nil
+	}
+	removeAll = func(path string) error {
+		delete(files, path)
+		return nil
+	}
+
+	execAgentConfigFiles := func() []string {
+		var names []string
+		for path := range files {
+			if ok, _ := filepath.Match(filepath.Join(ECSAgentExecConfigDir, "amazon-ssm-agent-*.json"), path); ok {
+				names = append(names, filepath.Base(path))
+			}
+		}
+		return names
+	}
+
+	err := addRequiredBindMounts("task-id", "container-name", "bin-dir", "uuid-1", 2, "", 0755, HostLogDir, true,
+		&dockercontainer.HostConfig{})
+	require.NoError(t, err)
+	require.Len(t, execAgentConfigFiles(), 1)
+
+	err = addRequiredBindMounts("task-id", "container-name", "bin-dir", "uuid-2", 3, "", 0755, HostLogDir, true,
+		&dockercontainer.HostConfig{})
+	require.NoError(t, err)
+	latestConfigFile, err := getAgentConfigFileName(3, "")
+	require.NoError(t, err)
+	// only the config file of the latest session limit remains, next to the log config file
+	assert.Equal(t, []string{latestConfigFile}, execAgentConfigFiles())
+	logConfigFile, err := getAgentLogConfigFile()
+	require.NoError(t, err)
+	assert.Contains(t, files, filepath.Join(ECSAgentExecConfigDir, logConfigFile))
+}
+
 func TestGetExecAgentLogConfigFile(t *testing.T) {
 	hash := getExecAgentConfigHash(execAgentLogConfigTemplate)
 	var tests = []struct {
@@ -395,7 +563,7 @@ func TestGetExecAgentLogConfigFile(t *testing.T) {
 	defer func() {
 		osStat = os.Stat
 		getFileContent = readFileContent
-		createNewExecAgentConfigFile = createNewConfigFile
+		createNewExecAgentConfigFile = createNewConfigFileWithRetry
 		removeAll = os.RemoveAll
 	}()
 	for _, tc := range tests {
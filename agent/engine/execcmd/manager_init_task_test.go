@@ -16,11 +16,22 @@
 package execcmd
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
 
 	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
+	"github.com/aws/amazon-ecs-agent/agent/ecs_client/model/ecs"
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetSessionWorkersLimit(t *testing.T) {
@@ -51,7 +62,273 @@ func TestGetSessionWorkersLimit(t *testing.T) {
 				"sessionLimit": strconv.Itoa(tc.sessionLimit),
 			},
 		}
-		limit := getSessionWorkersLimit(ma)
+		limit := NewManager().getSessionWorkersLimit(&apicontainer.Container{}, ma)
 		assert.Equal(t, tc.expectedLimit, limit)
 	}
 }
+
+// fakeTaskTagsClient returns the tags of a task, or an error
+type fakeTaskTagsClient struct {
+	tags map[string]string
+	err  error
+}
+
+func (c *fakeTaskTagsClient) GetResourceTags(resourceArn string) ([]*ecs.Tag, error) {
+	var tags []*ecs.Tag
+	for key, value := range c.tags {
+		tags = append(tags, &ecs.Tag{Key: aws.String(key), Value: aws.String(value)})
+	}
+	return tags, c.err
+}
+
+func TestGetSessionWorkersLimitPrecedence(t *testing.T) {
+	var tests = []struct {
+		name            string
+		taskTags        TaskTagsClient
+		properties      map[string]string
+		maxSessionLimit int
+		expectedLimit   int
+	}{
+		{
+			name:          "default",
+			expectedLimit: defaultSessionLimit,
+		},
+		{
+			name:          "managed agent property",
+			properties:    map[string]string{sessionLimitProperty: "3"},
+			expectedLimit: 3,
+		},
+		{
+			name:          "task tag takes precedence over managed agent property",
+			taskTags:      &fakeTaskTagsClient{tags: map[string]string{sessionLimitTaskTag: "5"}},
+			properties:    map[string]string{sessionLimitProperty: "3"},
+			expectedLimit: 5,
+		},
+		{
+			name:          "task tag without managed agent property",
+			taskTags:      &fakeTaskTagsClient{tags: map[string]string{sessionLimitTaskTag: "4"}},
+			expectedLimit: 4,
+		},
+		{
+			name:          "invalid task tag falls back to managed agent property",
+			taskTags:      &fakeTaskTagsClient{tags: map[string]string{sessionLimitTaskTag: "-1"}},
+			properties:    map[string]string{sessionLimitProperty: "3"},
+			expectedLimit: 3,
+		},
+		{
+			name:          "task without tag falls back to managed agent property",
+			taskTags:      &fakeTaskTagsClient{tags: map[string]string{"team": "web"}},
+			properties:    map[string]string{sessionLimitProperty: "3"},
+			expectedLimit: 3,
+		},
+		{
+			name:          "task tags unavailable falls back to default",
+			taskTags:      &fakeTaskTagsClient{err: errors.New("access denied")},
+			expectedLimit: defaultSessionLimit,
+		},
+		{
+			name:            "task tag capped",
+			taskTags:        &fakeTaskTagsClient{tags: map[string]string{sessionLimitTaskTag: "50"}},
+			maxSessionLimit: 10,
+			expectedLimit:   10,
+		},
+		{
+			name:            "managed agent property capped",
+			properties:      map[string]string{sessionLimitProperty: "20"},
+			maxSessionLimit: 10,
+			expectedLimit:   10,
+		},
+		{
+			name:            "default capped",
+			maxSessionLimit: 1,
+			expectedLimit:   1,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m := NewManager()
+			if tc.taskTags != nil {
+				m.SetTaskTagsClient(tc.taskTags)
+			}
+			m.maxSessionLimit = tc.maxSessionLimit
+			container := &apicontainer.Container{Name: "web", TaskARNUnsafe: "arn:aws:ecs:us-west-2:123456789012:task/cluster/id"}
+			limit := m.getSessionWorkersLimit(container, apicontainer.ManagedAgent{Properties: tc.properties})
+			assert.Equal(t, tc.expectedLimit, limit)
+		})
+	}
+}
+
+func TestGetSessionShell(t *testing.T) {
+	absoluteShell, err := filepath.Abs("ash")
+	require.NoError(t, err)
+	var tests = []struct {
+		name          string
+		properties    map[string]string
+		expectedShell string
+		expectError   bool
+	}{
+		{
+			name:          "no properties",
+			expectedShell: "",
+		},
+		{
+			name:          "shell not overridden",
+			properties:    map[string]string{"sessionLimit": "2"},
+			expectedShell: "",
+		},
+		{
+			name:          "absolute shell",
+			properties:    map[string]string{"sessionShell": absoluteShell},
+			expectedShell: absoluteShell,
+		},
+		{
+			name:        "empty shell",
+			properties:  map[string]string{"sessionShell": ""},
+			expectError: true,
+		},
+		{
+			name:        "relative shell",
+			properties:  map[string]string{"sessionShell": "bin/ash"},
+			expectError: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			shell, err := getSessionShell(apicontainer.ManagedAgent{Properties: tc.properties})
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedShell, shell)
+		})
+	}
+}
+
+func TestRenderExecAgentConfig(t *testing.T) {
+	// Without override, the config is unchanged so that existing config files are reused
+	defaultConfig := renderExecAgentConfig(2, "")
+	assert.Equal(t, fmt.Sprintf(execAgentConfigTemplate, 2), defaultConfig)
+	assert.NotContains(t, defaultConfig, "SessionShell")
+
+	var config struct {
+		Mgs struct {
+			SessionWorkersLimit int
+			SessionShell        string
+		}
+	}
+	require.NoError(t, json.Unmarshal([]byte(renderExecAgentConfig(3, `/opt/busybox/ash`)), &config))
+	assert.Equal(t, 3, config.Mgs.SessionWorkersLimit)
+	assert.Equal(t, "/opt/busybox/ash", config.Mgs.SessionShell)
+	assert.NotEqual(t, getExecAgentConfigHash(defaultConfig), getExecAgentConfigHash(renderExecAgentConfig(2, "/opt/busybox/ash")))
+}
+
+func TestVerifyExecAgentBinaries(t *testing.T) {
+	binaries := map[string]string{
+		SSMAgentBinName:       "ssm agent",
+		SSMAgentWorkerBinName: "ssm agent worker",
+		SessionWorkerBinName:  "session worker",
+	}
+	checksum := func(content string) string {
+		sum := sha256.Sum256([]byte(content))
+		return hex.EncodeToString(sum[:])
+	}
+
+	testCases := []struct {
+		name          string
+		manifest      *string
+		expectedError string
+	}{
+		{
+			name: "missing manifest",
+		},
+		{
+			name: "matching manifest",
+			manifest: strptr(fmt.Sprintf("%s  %s\n%s *%s\n%s  %s\n",
+				checksum("ssm agent"), SSMAgentBinName,
+				checksum("ssm agent worker"), SSMAgentWorkerBinName,
+				checksum("session worker"), SessionWorkerBinName)),
+		},
+		{
+			name: "mismatching manifest",
+			manifest: strptr(fmt.Sprintf("%s  %s\n%s  %s\n%s  %s\n",
+				checksum("ssm agent"), SSMAgentBinName,
+				checksum("corrupted"), SSMAgentWorkerBinName,
+				checksum("session worker"), SessionWorkerBinName)),
+			expectedError: "checksum mismatch for ExecCommandAgent binary " + SSMAgentWorkerBinName,
+		},
+		{
+			name:          "binary missing from manifest",
+			manifest:      strptr(fmt.Sprintf("%s  %s\n", checksum("ssm agent"), SSMAgentBinName)),
+			expectedError: "has no checksum for " + SSMAgentWorkerBinName,
+		},
+		{
+			name:          "malformed manifest",
+			manifest:      strptr("not-a-checksum " + SSMAgentBinName + "\n"),
+			expectedError: "malformed checksum",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			binDir := t.TempDir()
+			for name, content := range binaries {
+				require.NoError(t, ioutil.WriteFile(filepath.Join(binDir, name), []byte(content), 0755))
+			}
+			if tc.manifest != nil {
+				require.NoError(t, ioutil.WriteFile(filepath.Join(binDir, ExecAgentChecksumManifestName), []byte(*tc.manifest), 0644))
+			}
+
+			err := verifyExecAgentBinaries(binDir)
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestCreateNewConfigFileWithRetry(t *testing.T) {
+	defer func() {
+		writeExecAgentConfigFile = createNewConfigFile
+	}()
+
+	t.Run("succeeds after transient failures", func(t *testing.T) {
+		configFilePath := filepath.Join(t.TempDir(), containerConfigFileName)
+		attempts := 0
+		writeExecAgentConfigFile = func(config, path string) error {
+			attempts++
+			if attempts <= 2 {
+				return errors.New("file locked")
+			}
+			return createNewConfigFile(config, path)
+		}
+
+		require.NoError(t, createNewExecAgentConfigFile("config", configFilePath))
+		assert.Equal(t, 3, attempts)
+		content, err := ioutil.ReadFile(configFilePath)
+		require.NoError(t, err)
+		assert.Equal(t, "config", string(content))
+	})
+
+	t.Run("fails once attempts are exhausted", func(t *testing.T) {
+		attempts := 0
+		writeExecAgentConfigFile = func(config, path string) error {
+			attempts++
+			return errors.New("file locked")
+		}
+
+		configFilePath := filepath.Join(t.TempDir(), containerConfigFileName)
+		err := createNewExecAgentConfigFile("config", configFilePath)
+		require.Error(t, err)
+		assert.Equal(t, configFileWriteMaxAttempts, attempts)
+		assert.Contains(t, err.Error(), "file locked")
+		_, err = os.Stat(configFilePath)
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func strptr(s string) *string {
+	return &s
+}
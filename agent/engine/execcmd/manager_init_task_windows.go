@@ -25,10 +25,6 @@ import (
 	dockercontainer "github.com/docker/docker/api/types/container"
 )
 
-const (
-	folderPerm = 0755
-)
-
 var (
 	ecsAgentExecDepsDir = config.AmazonECSProgramFiles + "\\managed-agents\\execute-command"
 
@@ -83,8 +79,8 @@ var (
 var GetExecAgentConfigDir = getAgentConfigDir
 
 // Retrieves cached config dir, creates new one if needed
-func getAgentConfigDir(sessionLimit int) (string, error) {
-	agentConfig := fmt.Sprintf(execAgentConfigTemplate, sessionLimit)
+func getAgentConfigDir(sessionLimit int, sessionShell string, folderPerm os.FileMode) (string, error) {
+	agentConfig := renderExecAgentConfig(sessionLimit, sessionShell)
 	hash := getExecAgentConfigHash(agentConfig + execAgentLogConfigTemplate)
 	// check if cached config dir exists already
 	configDirPath := filepath.Join(ECSAgentExecConfigDir, hash)
@@ -98,7 +94,7 @@ func getAgentConfigDir(sessionLimit int) (string, error) {
 		}
 	}
 	// create new config dir
-	if err := createNewExecAgentConfigDir(agentConfig, configDirPath); err != nil {
+	if err := createNewExecAgentConfigDir(agentConfig, configDirPath, folderPerm); err != nil {
 		return "", err
 	}
 	return hash, nil
@@ -108,7 +104,7 @@ var createNewExecAgentConfigDir = createNewConfigDir
 
 var mkdirAll = os.MkdirAll
 
-func createNewConfigDir(agentConfig, configDirPath string) error {
+func createNewConfigDir(agentConfig, configDirPath string, folderPerm os.FileMode) error {
 	// make top level config directory
 	err := mkdirAll(configDirPath, folderPerm)
 	if err != nil {
@@ -151,14 +147,15 @@ func validConfigDirExists(configDirPath, expectedHash string) bool {
 
 // This function creates any necessary config directories/files and ensures that
 // the ssm-agent binaries, configs, logs, and plugin is bind mounted
-func addRequiredBindMounts(taskId, cn, latestBinVersionDir, uuid string, sessionWorkersLimit int, hostConfig *dockercontainer.HostConfig) error {
+func addRequiredBindMounts(taskId, cn, latestBinVersionDir, uuid string, sessionWorkersLimit int, sessionShell string,
+	folderPerm os.FileMode, hostLogDir string, removeSupersededConfigs bool, hostConfig *dockercontainer.HostConfig) error {
 	// In windows host mounts are not created automatically, so need to create
-	rErr := os.MkdirAll(filepath.Join(HostLogDir, taskId, cn), folderPerm)
+	rErr := mkdirAll(filepath.Join(hostLogDir, taskId, cn), folderPerm)
 	if rErr != nil {
 		return rErr
 	}
 
-	configDirHash, rErr := GetExecAgentConfigDir(sessionWorkersLimit)
+	configDirHash, rErr := GetExecAgentConfigDir(sessionWorkersLimit, sessionShell, folderPerm)
 	if rErr != nil {
 		rErr = fmt.Errorf("could not generate ExecAgent Config dir: %v", rErr)
 		return rErr
@@ -176,7 +173,7 @@ func addRequiredBindMounts(taskId, cn, latestBinVersionDir, uuid string, session
 
 	// Add ssm log bind mount
 	hostConfig.Binds = append(hostConfig.Binds, getBindMountMapping(
-		filepath.Join(HostLogDir, taskId, cn),
+		filepath.Join(hostLogDir, taskId, cn),
 		ContainerLogDir))
 
 	// add ssm plugin bind mount (needed for execcmd windows)
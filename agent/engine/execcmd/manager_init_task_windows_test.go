@@ -22,6 +22,9 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/aws/amazon-ecs-agent/agent/config"
+
+	dockercontainer "github.com/docker/docker/api/types/container"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -116,7 +119,7 @@ func TestGetExecAgentConfigDir(t *testing.T) {
 	defer func() {
 		osStat = os.Stat
 		getFileContent = readFileContent
-		createNewExecAgentConfigFile = createNewConfigFile
+		createNewExecAgentConfigFile = createNewConfigFileWithRetry
 		removeAll = os.RemoveAll
 		mkdirAll = os.MkdirAll
 	}()
@@ -155,13 +158,73 @@ func TestGetExecAgentConfigDir(t *testing.T) {
 		mkdirAll = func(path string, perm os.FileMode) error {
 			return tc.createNewConfigDirError
 		}
-		configDir, err := GetExecAgentConfigDir(2)
+		configDir, err := GetExecAgentConfigDir(2, "", defaultFolderPerm)
 		assert.Equal(t, tc.expectedDir, configDir)
 		assert.Equal(t, tc.expectedError, err)
 	}
 
 }
 
+func TestAddRequiredBindMountsFolderPerm(t *testing.T) {
+	defer func() {
+		osStat = os.Stat
+		createNewExecAgentConfigFile = createNewConfigFileWithRetry
+		mkdirAll = os.MkdirAll
+	}()
+	osStat = func(name string) (os.FileInfo, error) {
+		return &mockFileInfo{}, errors.New("no such file")
+	}
+	createNewExecAgentConfigFile = func(c, f string) error {
+		return nil
+	}
+	createdDirs := make(map[string]os.FileMode)
+	mkdirAll = func(path string, perm os.FileMode) error {
+		createdDirs[path] = perm
+		return nil
+	}
+
+	hostConfig := &dockercontainer.HostConfig{}
+	err := addRequiredBindMounts("task-id", "container-name", "bin-dir", "uuid", 2, "", 0700, HostLogDir, false, hostConfig)
+	assert.NoError(t, err)
+
+	hash := getExecAgentConfigHash(fmt.Sprintf(execAgentConfigTemplate, 2) + execAgentLogConfigTemplate)
+	assert.Equal(t, map[string]os.FileMode{
+		filepath.Join(HostLogDir, "task-id", "container-name"): 0700,
+		filepath.Join(ECSAgentExecConfigDir, hash):             0700,
+	}, createdDirs)
+}
+
+func TestAddRequiredBindMountsHostLogDirOverride(t *testing.T) {
+	defer func() {
+		osStat = os.Stat
+		createNewExecAgentConfigFile = createNewConfigFileWithRetry
+		mkdirAll = os.MkdirAll
+	}()
+	osStat = func(name string) (os.FileInfo, error) {
+		return &mockFileInfo{}, errors.New("no such file")
+	}
+	createNewExecAgentConfigFile = func(c, f string) error {
+		return nil
+	}
+	var createdDirs []string
+	mkdirAll = func(path string, perm os.FileMode) error {
+		createdDirs = append(createdDirs, path)
+		return nil
+	}
+
+	m := NewManagerWithConfig(&config.Config{ExecAgentHostLogDir: `D:\ecs\exec-logs`})
+	hostConfig := &dockercontainer.HostConfig{}
+	err := addRequiredBindMounts("task-id", "container-name", "bin-dir", "uuid", 2, "", 0700, m.hostLogDir, false, hostConfig)
+	assert.NoError(t, err)
+
+	expectedHostLogDir := filepath.Join(`D:\ecs\exec-logs`, "task-id", "container-name")
+	assert.Contains(t, createdDirs, expectedHostLogDir)
+	assert.Contains(t, hostConfig.Binds, getBindMountMapping(expectedHostLogDir, ContainerLogDir))
+	for _, bind := range hostConfig.Binds {
+		assert.NotContains(t, bind, HostLogDir)
+	}
+}
+
 func TestGetValidConfigDirExists(t *testing.T) {
 	var tests = []struct {
 		isValid                    bool
@@ -71,6 +71,7 @@ func (m *manager) RestartAgentIfStopped(ctx context.Context, client dockerapi.Do
 		field.RuntimeID: containerId,
 		"exitCode":      res.ExitCode,
 	})
+	m.auditAgentEnded(task, container, ma, fmt.Sprintf("ExecuteCommandAgent process exited with exit code: %d", res.ExitCode))
 	container.UpdateManagedAgentByName(ExecuteCommandAgentName, apicontainer.ManagedAgentState{
 		ID: ma.ID,
 	})
@@ -81,6 +82,40 @@ func (m *manager) RestartAgentIfStopped(ctx context.Context, client dockerapi.Do
 	return Restarted, nil
 }
 
+// CheckAgentHealth checks that the ExecCommandAgent process is still alive in the container passed as parameter, and
+// marks the managed agent as unhealthy if it is not. It returns true if the status of the managed agent was changed.
+//
+// Only a running ExecCommandAgent is checked, with a single docker exec inspect call, so that the probe stays cheap.
+// Restarting a dead ExecCommandAgent is left to RestartAgentIfStopped.
+func (m *manager) CheckAgentHealth(ctx context.Context, client dockerapi.DockerClient, task *apitask.Task, container *apicontainer.Container) (bool, error) {
+	if !IsExecEnabledContainer(container) {
+		return false, nil
+	}
+	ma, _ := container.GetManagedAgentByName(ExecuteCommandAgentName)
+	if !m.isAgentStarted(ma) || !ma.Status.IsRunning() {
+		return false, nil
+	}
+	metadata := MapToAgentMetadata(ma.Metadata)
+	res, err := client.InspectContainerExec(ctx, metadata.DockerExecID, dockerclient.ContainerExecInspectTimeout)
+	if err != nil {
+		return false, err
+	}
+	if res.Running {
+		return false, nil
+	}
+	logger.Warn("ExecCommandAgent Process is no longer running in container", logger.Fields{
+		field.TaskID:    task.GetID(),
+		field.Container: container.Name,
+		"exitCode":      res.ExitCode,
+	})
+	state := ma.ManagedAgentState
+	state.Status = status.ManagedAgentUnhealthy
+	state.Reason = fmt.Sprintf("ExecuteCommandAgent process exited with exit code: %d", res.ExitCode)
+	m.auditAgentEnded(task, container, ma, state.Reason)
+	container.UpdateManagedAgentByName(ExecuteCommandAgentName, state)
+	return true, nil
+}
+
 func (m *manager) inspectExecAgentProcess(ctx context.Context, client dockerapi.DockerClient, metadata AgentMetadata) (*types.ContainerExecInspect, error) {
 	backoff := retry.NewExponentialBackoff(m.retryMinDelay, m.retryMaxDelay, retryJitterMultiplier, retryDelayMultiplier)
 	ctx, cancel := context.WithTimeout(ctx, m.inspectRetryTimeout)
@@ -168,12 +203,14 @@ func (m *manager) StartAgent(ctx context.Context, client dockerapi.DockerClient,
 		})
 		return startErr
 	}
+	startedAt := time.Now()
 	container.UpdateManagedAgentByName(ExecuteCommandAgentName, apicontainer.ManagedAgentState{
 		ID:            ma.ID,
 		Status:        status.ManagedAgentRunning,
-		LastStartedAt: time.Now(),
+		LastStartedAt: startedAt,
 		Metadata:      execMD.ToMap(),
 	})
+	m.auditAgentStarted(task, container, ma.ID, startedAt)
 	return nil
 }
 
@@ -181,7 +218,7 @@ func (m *manager) doStartAgent(ctx context.Context, client dockerapi.DockerClien
 	execAgentCmdBinDir := getExecAgentCmdBinDir(&ma)
 	execAgentCmd := filepath.Join(execAgentCmdBinDir, SSMAgentBinName)
 	execCfg := types.ExecConfig{
-		User:   execAgentCmdUser,
+		User:   m.execAgentCmdUser,
 		Detach: true,
 		Cmd:    []string{execAgentCmd},
 	}
@@ -15,12 +15,28 @@
 // permissions and limitations under the License.
 package execcmd
 
-import apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
+import (
+	"fmt"
+	"regexp"
+
+	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
+)
 
 const (
-	execAgentCmdUser = "0"
+	defaultExecAgentCmdUser = "0"
 )
 
+// execAgentCmdUserRegex matches the user[:group] forms accepted by docker exec, where both the user and the
+// group are either a numeric id or a portable user name
+var execAgentCmdUserRegex = regexp.MustCompile(`^([0-9]+|[a-zA-Z_][a-zA-Z0-9_.-]*\$?)(:([0-9]+|[a-zA-Z_][a-zA-Z0-9_.-]*))?$`)
+
+func validateExecAgentCmdUser(user string) error {
+	if !execAgentCmdUserRegex.MatchString(user) {
+		return fmt.Errorf("invalid ExecCommandAgent user %q: expected user[:group] with a name or numeric id", user)
+	}
+	return nil
+}
+
 func getExecAgentCmdBinDir(ma *apicontainer.ManagedAgent) string {
 	return ContainerDepsDirPrefix + ma.ID
 }
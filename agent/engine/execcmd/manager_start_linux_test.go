@@ -15,7 +15,41 @@
 // permissions and limitations under the License.
 package execcmd
 
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
 const (
 	specTestCmd = "/ecs-execute-command-test-uid/amazon-ssm-agent"
 	specUser    = "0"
 )
+
+func TestValidateExecAgentCmdUser(t *testing.T) {
+	testCases := []struct {
+		user  string
+		valid bool
+	}{
+		{user: "0", valid: true},
+		{user: "1000:1000", valid: true},
+		{user: "ssm-user", valid: true},
+		{user: "ssm-user:ssm-group", valid: true},
+		{user: "ssm_user$", valid: true},
+		{user: "", valid: false},
+		{user: "root user", valid: false},
+		{user: "1000:", valid: false},
+		{user: ":1000", valid: false},
+		{user: "-user", valid: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.user, func(t *testing.T) {
+			err := validateExecAgentCmdUser(tc.user)
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
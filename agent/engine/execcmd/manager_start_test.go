@@ -18,6 +18,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strconv"
 	"testing"
 	"time"
@@ -36,6 +37,7 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func getAgentMetadata(container *container.Container) AgentMetadata {
@@ -310,6 +312,50 @@ func TestIdempotentStartAgent(t *testing.T) {
 	assert.Equal(t, apicontainerstatus.ManagedAgentRunning, ma.Status)
 }
 
+func TestStartAgentWithCmdUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+	const (
+		testDockerExecId = "abc"
+		testUser         = "ssm-user"
+	)
+	testTask := &apitask.Task{
+		Arn: "taskArn:aws:ecs:region:account-id:task/test-task-taskArn",
+		Containers: []*apicontainer.Container{{
+			RuntimeID: "123",
+			ManagedAgentsUnsafe: []apicontainer.ManagedAgent{
+				{
+					Name: ExecuteCommandAgentName,
+					ManagedAgentState: apicontainer.ManagedAgentState{
+						ID: "test-uid",
+					},
+				},
+			},
+		}},
+	}
+
+	execCfg := types.ExecConfig{
+		User:   testUser,
+		Detach: true,
+		Cmd:    []string{specTestCmd},
+	}
+	client.EXPECT().CreateContainerExec(gomock.Any(), testTask.Containers[0].RuntimeID, execCfg, dockerclient.ContainerExecCreateTimeout).
+		Return(&types.IDResponse{ID: testDockerExecId}, nil)
+	client.EXPECT().StartContainerExec(gomock.Any(), testDockerExecId, gomock.Any(), dockerclient.ContainerExecStartTimeout).
+		Return(nil)
+	client.EXPECT().InspectContainerExec(gomock.Any(), testDockerExecId, dockerclient.ContainerExecInspectTimeout).
+		Return(&types.ContainerExecInspect{ExecID: testDockerExecId, Pid: 111, Running: true}, nil)
+
+	mgr := newTestManager()
+	mgr.execAgentCmdUser = testUser
+	err := mgr.StartAgent(context.TODO(), client, testTask, testTask.Containers[0], testTask.Containers[0].RuntimeID)
+	assert.NoError(t, err)
+
+	ma, _ := testTask.Containers[0].GetManagedAgentByName(ExecuteCommandAgentName)
+	assert.Equal(t, apicontainerstatus.ManagedAgentRunning, ma.Status)
+}
+
 func TestRestartAgentIfStopped(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -466,6 +512,150 @@ func TestRestartAgentIfStopped(t *testing.T) {
 	}
 }
 
+func TestCheckAgentHealth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+
+	testTask := &apitask.Task{
+		Arn: "taskArn:aws:ecs:region:account-id:task/test-task-taskArn",
+		Containers: []*apicontainer.Container{{
+			Name: "container-name",
+			ManagedAgentsUnsafe: []apicontainer.ManagedAgent{{
+				Name: ExecuteCommandAgentName,
+				ManagedAgentState: apicontainer.ManagedAgentState{
+					ID:            "test-uid",
+					Status:        apicontainerstatus.ManagedAgentRunning,
+					LastStartedAt: time.Now(),
+					Metadata: map[string]interface{}{
+						"PID":          "456",
+						"DockerExecID": "789",
+						"CMD":          "amazon-ssm-agent",
+					},
+				},
+			}},
+		}},
+	}
+	testContainer := testTask.Containers[0]
+	mgr := newTestManager()
+
+	// The process is alive at first, then dies
+	gomock.InOrder(
+		client.EXPECT().InspectContainerExec(gomock.Any(), "789", dockerclient.ContainerExecInspectTimeout).
+			Return(&types.ContainerExecInspect{Running: true}, nil),
+		client.EXPECT().InspectContainerExec(gomock.Any(), "789", dockerclient.ContainerExecInspectTimeout).
+			Return(&types.ContainerExecInspect{Running: false, ExitCode: 137}, nil),
+	)
+
+	changed, err := mgr.CheckAgentHealth(context.TODO(), client, testTask, testContainer)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	ma, _ := testContainer.GetManagedAgentByName(ExecuteCommandAgentName)
+	assert.Equal(t, apicontainerstatus.ManagedAgentRunning, ma.Status)
+
+	changed, err = mgr.CheckAgentHealth(context.TODO(), client, testTask, testContainer)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	ma, _ = testContainer.GetManagedAgentByName(ExecuteCommandAgentName)
+	assert.Equal(t, apicontainerstatus.ManagedAgentUnhealthy, ma.Status)
+	assert.Equal(t, "ExecuteCommandAgent process exited with exit code: 137", ma.Reason)
+	assert.Equal(t, "789", getAgentMetadata(testContainer).DockerExecID, "metadata is needed to restart the agent")
+	assert.False(t, ma.LastStartedAt.IsZero(), "LastStartedAt is needed to restart the agent")
+
+	// An unhealthy agent is not probed again until it is restarted
+	changed, err = mgr.CheckAgentHealth(context.TODO(), client, testTask, testContainer)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestCheckAgentHealthInspectError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+
+	testContainer := &apicontainer.Container{
+		ManagedAgentsUnsafe: []apicontainer.ManagedAgent{{
+			Name: ExecuteCommandAgentName,
+			ManagedAgentState: apicontainer.ManagedAgentState{
+				Status:        apicontainerstatus.ManagedAgentRunning,
+				LastStartedAt: time.Now(),
+				Metadata:      map[string]interface{}{"DockerExecID": "789"},
+			},
+		}},
+	}
+	testTask := &apitask.Task{Containers: []*apicontainer.Container{testContainer}}
+	client.EXPECT().InspectContainerExec(gomock.Any(), "789", dockerclient.ContainerExecInspectTimeout).
+		Return(nil, errors.New("mock error"))
+
+	changed, err := newTestManager().CheckAgentHealth(context.TODO(), client, testTask, testContainer)
+	assert.Error(t, err)
+	assert.False(t, changed)
+	ma, _ := testContainer.GetManagedAgentByName(ExecuteCommandAgentName)
+	assert.Equal(t, apicontainerstatus.ManagedAgentRunning, ma.Status)
+}
+
+func TestAuditAgentLifecycle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_dockerapi.NewMockDockerClient(ctrl)
+
+	testContainer := &apicontainer.Container{
+		Name:      "container-name",
+		RuntimeID: "123abc",
+		ManagedAgentsUnsafe: []apicontainer.ManagedAgent{{
+			Name:              ExecuteCommandAgentName,
+			ManagedAgentState: apicontainer.ManagedAgentState{ID: "test-uid"},
+		}},
+	}
+	testTask := &apitask.Task{
+		Arn:        "taskArn:aws:ecs:region:account-id:task/test-task-taskArn",
+		Containers: []*apicontainer.Container{testContainer},
+	}
+	auditLogPath := filepath.Join(t.TempDir(), "exec-audit.log")
+	mgr := newTestManager()
+	mgr.auditLog = newAuditLog(auditLogPath, 1024*1024)
+
+	client.EXPECT().CreateContainerExec(gomock.Any(), "123abc", gomock.Any(), dockerclient.ContainerExecCreateTimeout).
+		Return(&types.IDResponse{ID: "789"}, nil)
+	client.EXPECT().StartContainerExec(gomock.Any(), "789", gomock.Any(), dockerclient.ContainerExecStartTimeout).
+		Return(nil)
+	gomock.InOrder(
+		client.EXPECT().InspectContainerExec(gomock.Any(), "789", dockerclient.ContainerExecInspectTimeout).
+			Return(&types.ContainerExecInspect{ExecID: "789", Pid: 456, Running: true}, nil),
+		client.EXPECT().InspectContainerExec(gomock.Any(), "789", dockerclient.ContainerExecInspectTimeout).
+			Return(&types.ContainerExecInspect{Running: false, ExitCode: 137}, nil),
+	)
+
+	require.NoError(t, mgr.StartAgent(context.TODO(), client, testTask, testContainer, testContainer.RuntimeID))
+	changed, err := mgr.CheckAgentHealth(context.TODO(), client, testTask, testContainer)
+	require.NoError(t, err)
+	require.True(t, changed)
+	// The agent already stopped before the container did, its stop is recorded once
+	mgr.RecordAgentStopped(testTask, testContainer, "Received Container Stopped event")
+
+	records := readAuditRecords(t, auditLogPath)
+	require.Len(t, records, 2)
+	assert.Equal(t, AuditEventStart, records[0]["event"])
+	assert.Equal(t, testTask.Arn, records[0]["taskArn"])
+	assert.Equal(t, "container-name", records[0]["container"])
+	assert.Equal(t, "test-uid", records[0]["managedAgentId"])
+	assert.Equal(t, AuditEventEnd, records[1]["event"])
+	assert.Equal(t, records[0]["startedAt"], records[1]["startedAt"])
+	assert.NotEmpty(t, records[1]["endedAt"])
+	assert.Equal(t, "ExecuteCommandAgent process exited with exit code: 137", records[1]["reason"])
+
+	// An agent stopping along with its container is recorded when the container stops
+	ma, _ := testContainer.GetManagedAgentByName(ExecuteCommandAgentName)
+	ma.Status = apicontainerstatus.ManagedAgentRunning
+	testContainer.UpdateManagedAgentByName(ExecuteCommandAgentName, ma.ManagedAgentState)
+	mgr.RecordAgentStopped(testTask, testContainer, "Received Container Stopped event")
+
+	records = readAuditRecords(t, auditLogPath)
+	require.Len(t, records, 3)
+	assert.Equal(t, AuditEventEnd, records[2]["event"])
+	assert.Equal(t, "Received Container Stopped event", records[2]["reason"])
+}
+
 func newTestManager() *manager {
 	m := NewManager()
 	m.retryMaxDelay = time.Millisecond * 30
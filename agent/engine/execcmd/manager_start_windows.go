@@ -15,13 +15,24 @@
 
 package execcmd
 
-import apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
+import (
+	"errors"
+
+	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
+)
 
 const (
-	execAgentCmdUser   = "NT AUTHORITY\\SYSTEM"
-	execAgentCmdBinDir = "C:\\Program Files\\Amazon\\SSM"
+	defaultExecAgentCmdUser = "NT AUTHORITY\\SYSTEM"
+	execAgentCmdBinDir      = "C:\\Program Files\\Amazon\\SSM"
 )
 
+func validateExecAgentCmdUser(user string) error {
+	if user == "" {
+		return errors.New("invalid ExecCommandAgent user: user must not be empty")
+	}
+	return nil
+}
+
 func getExecAgentCmdBinDir(ma *apicontainer.ManagedAgent) string {
 	return execAgentCmdBinDir
 }
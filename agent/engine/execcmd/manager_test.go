@@ -14,17 +14,22 @@
 package execcmd
 
 import (
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
 	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
+	"github.com/aws/amazon-ecs-agent/agent/config"
 )
 
 func TestNewManager(t *testing.T) {
 	m := NewManager()
 	assert.Equal(t, HostBinDir, m.hostBinDir)
+	assert.Equal(t, defaultExecAgentCmdUser, m.execAgentCmdUser)
+	assert.Equal(t, defaultFolderPerm, m.folderPerm)
 	assert.Equal(t, defaultInspectRetryTimeout, m.inspectRetryTimeout)
 	assert.Equal(t, defaultRetryMinDelay, m.retryMinDelay)
 	assert.Equal(t, defaultRetryMaxDelay, m.retryMaxDelay)
@@ -41,6 +46,39 @@ func TestNewManagerWithBinDir(t *testing.T) {
 	assert.Equal(t, defaultStartRetryTimeout, m.startRetryTimeout)
 }
 
+func TestNewManagerWithCmdUser(t *testing.T) {
+	m := NewManagerWithCmdUser("")
+	assert.Equal(t, defaultExecAgentCmdUser, m.execAgentCmdUser)
+
+	m = NewManagerWithCmdUser("1000:1000")
+	assert.Equal(t, "1000:1000", m.execAgentCmdUser)
+	assert.Equal(t, HostBinDir, m.hostBinDir)
+}
+
+func TestNewManagerWithConfig(t *testing.T) {
+	m := NewManagerWithConfig(&config.Config{})
+	assert.Equal(t, defaultExecAgentCmdUser, m.execAgentCmdUser)
+	assert.Equal(t, defaultFolderPerm, m.folderPerm)
+	assert.Equal(t, HostLogDir, m.hostLogDir)
+	assert.Nil(t, m.auditLog)
+
+	m = NewManagerWithConfig(&config.Config{ExecAgentCmdUser: "1000:1000", ExecAgentFolderPerm: 0700,
+		ExecAgentHostLogDir: "/custom/exec/logs"})
+	assert.Equal(t, "1000:1000", m.execAgentCmdUser)
+	assert.Equal(t, os.FileMode(0700), m.folderPerm)
+	assert.Equal(t, "/custom/exec/logs", m.hostLogDir)
+	assert.False(t, m.removeSupersededConfigs)
+
+	m = NewManagerWithConfig(&config.Config{
+		ExecAgentRemoveSupersededConfigs: config.BooleanDefaultFalse{Value: config.ExplicitlyEnabled}})
+	assert.True(t, m.removeSupersededConfigs)
+
+	m = NewManagerWithConfig(&config.Config{ExecAuditLogFile: "/var/log/ecs/exec-audit.log", ExecAuditLogMaxSizeMB: 2})
+	require.NotNil(t, m.auditLog)
+	assert.Equal(t, "/var/log/ecs/exec-audit.log", m.auditLog.path)
+	assert.Equal(t, int64(2*1024*1024), m.auditLog.maxSize)
+}
+
 func TestIsExecEnabledTask(t *testing.T) {
 	var tests = []struct {
 		name                string
@@ -29,6 +29,9 @@ const (
 	// When this path is empty, nothing is cleaned up for unsupported platforms.
 	ECSAgentExecLogDir = ""
 	HostBinDir         = ""
+	HostLogDir         = ""
+
+	defaultExecAgentCmdUser = ""
 )
 
 // Note: exec cmd agent is a linux/windows feature, thus implemented here as a no-op.
@@ -36,6 +39,11 @@ func (m *manager) RestartAgentIfStopped(ctx context.Context, client dockerapi.Do
 	return NotRestarted, nil
 }
 
+// Note: exec cmd agent is a linux/windows feature, thus implemented here as a no-op.
+func (m *manager) CheckAgentHealth(ctx context.Context, client dockerapi.DockerClient, task *apitask.Task, container *apicontainer.Container) (bool, error) {
+	return false, nil
+}
+
 // Note: exec cmd agent is a linux/windows feature, thus implemented here as a no-op.
 func (m *manager) StartAgent(ctx context.Context, client dockerapi.DockerClient, task *apitask.Task, container *apicontainer.Container, containerId string) error {
 	return nil
@@ -53,6 +53,21 @@ func (m *MockManager) EXPECT() *MockManagerMockRecorder {
 	return m.recorder
 }
 
+// CheckAgentHealth mocks base method
+func (m *MockManager) CheckAgentHealth(arg0 context.Context, arg1 dockerapi.DockerClient, arg2 *task.Task, arg3 *container.Container) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckAgentHealth", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckAgentHealth indicates an expected call of CheckAgentHealth
+func (mr *MockManagerMockRecorder) CheckAgentHealth(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckAgentHealth", reflect.TypeOf((*MockManager)(nil).CheckAgentHealth), arg0, arg1, arg2, arg3)
+}
+
 // InitializeContainer mocks base method
 func (m *MockManager) InitializeContainer(arg0 string, arg1 *container.Container, arg2 *container0.HostConfig) error {
 	m.ctrl.T.Helper()
@@ -67,6 +82,18 @@ func (mr *MockManagerMockRecorder) InitializeContainer(arg0, arg1, arg2 interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitializeContainer", reflect.TypeOf((*MockManager)(nil).InitializeContainer), arg0, arg1, arg2)
 }
 
+// RecordAgentStopped mocks base method
+func (m *MockManager) RecordAgentStopped(arg0 *task.Task, arg1 *container.Container, arg2 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RecordAgentStopped", arg0, arg1, arg2)
+}
+
+// RecordAgentStopped indicates an expected call of RecordAgentStopped
+func (mr *MockManagerMockRecorder) RecordAgentStopped(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordAgentStopped", reflect.TypeOf((*MockManager)(nil).RecordAgentStopped), arg0, arg1, arg2)
+}
+
 // RestartAgentIfStopped mocks base method
 func (m *MockManager) RestartAgentIfStopped(arg0 context.Context, arg1 dockerapi.DockerClient, arg2 *task.Task, arg3 *container.Container, arg4 string) (execcmd.RestartStatus, error) {
 	m.ctrl.T.Helper()
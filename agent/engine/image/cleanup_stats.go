@@ -0,0 +1,140 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package image
+
+import (
+	"fmt"
+	"time"
+)
+
+// Reasons for which an image is skipped during an image cleanup cycle
+const (
+	// CleanupSkipReasonExcluded is used for images in the cleanup exclusion list
+	CleanupSkipReasonExcluded = "Excluded"
+	// CleanupSkipReasonInUse is used for images still referenced by containers or by tasks not yet stopped
+	CleanupSkipReasonInUse = "InUse"
+	// CleanupSkipReasonTooRecent is used for images younger than the minimum deletion age
+	CleanupSkipReasonTooRecent = "TooRecent"
+	// CleanupSkipReasonRemoveFailed is used for images that docker failed to remove
+	CleanupSkipReasonRemoveFailed = "RemoveFailed"
+	// CleanupSkipReasonFamilyProtected is used for images used by a task family within the family protection
+	// window
+	CleanupSkipReasonFamilyProtected = "FamilyProtected"
+	// CleanupSkipReasonOutsideWindow is used for images that would have been removed if the cleanup cycle was
+	// within the cleanup window
+	CleanupSkipReasonOutsideWindow = "OutsideWindow"
+	// CleanupSkipReasonPinned is used for images pinned on demand until their pin expires
+	CleanupSkipReasonPinned = "Pinned"
+	// CleanupSkipReasonStartupSettling is used for images not seen in use since the agent started, within the
+	// startup settle period
+	CleanupSkipReasonStartupSettling = "StartupSettling"
+)
+
+// CleanupCycleStats holds the statistics of a single image cleanup cycle
+type CleanupCycleStats struct {
+	// ID identifies the cleanup cycle
+	ID string
+	// StartedAt is the time when the cleanup cycle started
+	StartedAt time.Time
+	// Duration is the time taken by the cleanup cycle
+	Duration time.Duration
+	// CandidatesEvaluated is the number of images evaluated for deletion
+	CandidatesEvaluated int
+	// ImagesRemoved is the number of images removed from the instance
+	ImagesRemoved int
+	// BytesReclaimed is the sum of the sizes of the removed images
+	BytesReclaimed int64
+	// RemovedImageIDs are the IDs of the removed images
+	RemovedImageIDs []string
+	// SkipReasons counts the images that were not removed per reason
+	SkipReasons map[string]int
+	// Error is the last error encountered during the cleanup cycle, empty if there was none
+	Error string
+}
+
+// NewCleanupCycleStats returns the statistics of the cleanup cycle of the given ID starting now
+func NewCleanupCycleStats(id string) *CleanupCycleStats {
+	return &CleanupCycleStats{
+		ID:          id,
+		StartedAt:   time.Now(),
+		SkipReasons: make(map[string]int),
+	}
+}
+
+// RecordSkipped records an image skipped for the given reason. It is a no-op on nil stats.
+func (stats *CleanupCycleStats) RecordSkipped(reason string) {
+	if stats == nil {
+		return
+	}
+	stats.SkipReasons[reason]++
+}
+
+// RecordEvaluated records images evaluated for deletion. It is a no-op on nil stats.
+func (stats *CleanupCycleStats) RecordEvaluated(count int) {
+	if stats == nil {
+		return
+	}
+	stats.CandidatesEvaluated += count
+}
+
+// RecordRemoved records an image of the given ID and size removed from the instance. It is a no-op
+// on nil stats.
+func (stats *CleanupCycleStats) RecordRemoved(imageID string, size int64) {
+	if stats == nil {
+		return
+	}
+	stats.ImagesRemoved++
+	stats.BytesReclaimed += size
+	stats.RemovedImageIDs = append(stats.RemovedImageIDs, imageID)
+}
+
+// RecordError records an error encountered during the cleanup cycle, replacing the previous one. It is a
+// no-op on nil stats.
+func (stats *CleanupCycleStats) RecordError(format string, args ...interface{}) {
+	if stats == nil {
+		return
+	}
+	stats.Error = fmt.Sprintf(format, args...)
+}
+
+// CleanupEligibility describes how far an image is from being removed by image cleanup
+type CleanupEligibility struct {
+	// ImageID is the ID of the image
+	ImageID string
+	// Names are the names the image is tracked under
+	Names []string
+	// LastUsedAt is when the image was last used
+	LastUsedAt time.Time
+	// TimeUntilOldEnough is the time left until the image is older than the minimum deletion age, or zero
+	// if it already is
+	TimeUntilOldEnough time.Duration
+	// HasAssociatedContainers is true if containers still reference the image
+	HasAssociatedContainers bool
+	// PinnedByTask is true if a task that is not stopped uses the image
+	PinnedByTask bool
+	// Excluded is true if the image is in the cleanup exclusion list
+	Excluded bool
+	// ProtectedByFamily is true if a task family used the image within the family protection window
+	ProtectedByFamily bool
+	// Pinned is true if the image was pinned on demand and its pin has not expired
+	Pinned bool
+	// StartupSettling is true if the image has not been seen in use since the agent started, within the startup
+	// settle period
+	StartupSettling bool
+	// Eligible is true if a cleanup cycle starting now would consider the image for removal
+	Eligible bool
+	// LRUPosition is the position of the image, starting at 1, among the tracked images ordered from the
+	// least recently used one
+	LRUPosition int
+}
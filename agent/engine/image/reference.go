@@ -0,0 +1,34 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package image
+
+import (
+	"github.com/docker/distribution/reference"
+)
+
+// NormalizeImageReference returns the fully qualified form of an image reference, so that equivalent
+// references such as "nginx", "nginx:latest", "library/nginx" and "docker.io/library/nginx" compare
+// equal. References that cannot be parsed are returned unchanged.
+func NormalizeImageReference(imageName string) string {
+	named, err := reference.ParseNormalizedNamed(imageName)
+	if err != nil {
+		return imageName
+	}
+	return reference.TagNameOnly(named).String()
+}
+
+// IsSameImageReference returns true if both image references refer to the same image
+func IsSameImageReference(imageName, otherImageName string) bool {
+	return imageName == otherImageName || NormalizeImageReference(imageName) == NormalizeImageReference(otherImageName)
+}
@@ -30,6 +30,9 @@ type Image struct {
 	ImageID string
 	Names   []string
 	Size    int64
+	// Platform is the platform of the image in the os/arch format of docker, which is the variant that was
+	// pulled for multi-platform images
+	Platform string `json:",omitempty"`
 }
 
 func (image *Image) String() string {
@@ -50,7 +53,11 @@ type ImageState struct {
 	// PullSucceeded defines whether this image has been pulled successfully before,
 	// this should be set to true when one of the pull image call succeeds.
 	PullSucceeded bool
-	lock          sync.RWMutex
+	// FamilyLastUsedAt is the time when the image was last used by a task of each task family.
+	FamilyLastUsedAt map[string]time.Time
+	// removeFailures is the number of consecutive failed attempts to remove this image
+	removeFailures int
+	lock           sync.RWMutex
 }
 
 // UpdateContainerReference updates container reference in image state
@@ -85,6 +92,20 @@ func (imageState *ImageState) GetImageID() string {
 	return imageState.Image.ImageID
 }
 
+// GetPlatform returns the platform of the image
+func (imageState *ImageState) GetPlatform() string {
+	imageState.lock.RLock()
+	defer imageState.lock.RUnlock()
+	return imageState.Image.Platform
+}
+
+// SetPlatform sets the platform of the image
+func (imageState *ImageState) SetPlatform(platform string) {
+	imageState.lock.Lock()
+	defer imageState.lock.Unlock()
+	imageState.Image.Platform = platform
+}
+
 // GetImageNamesCount returns number of image names
 func (imageState *ImageState) GetImageNamesCount() int {
 	imageState.lock.RLock()
@@ -94,9 +115,20 @@ func (imageState *ImageState) GetImageNamesCount() int {
 
 // HasNoAssociatedContainers returns true if image has no associated containers, false otherwise
 func (imageState *ImageState) HasNoAssociatedContainers() bool {
+	imageState.lock.RLock()
+	defer imageState.lock.RUnlock()
 	return len(imageState.Containers) == 0
 }
 
+// GetContainers returns the containers referencing the image
+func (imageState *ImageState) GetContainers() []*apicontainer.Container {
+	imageState.lock.RLock()
+	defer imageState.lock.RUnlock()
+	containers := make([]*apicontainer.Container, len(imageState.Containers))
+	copy(containers, imageState.Containers)
+	return containers
+}
+
 // UpdateImageState updates image name and container reference in image state
 func (imageState *ImageState) UpdateImageState(container *apicontainer.Container) {
 	imageState.AddImageName(container.Image)
@@ -108,7 +140,7 @@ func (imageState *ImageState) RemoveImageName(containerImageName string) bool {
 	imageState.lock.Lock()
 	defer imageState.lock.Unlock()
 	for i, imageName := range imageState.Image.Names {
-		if imageName == containerImageName {
+		if IsSameImageReference(imageName, containerImageName) {
 			imageState.Image.Names = append(imageState.Image.Names[:i], imageState.Image.Names[i+1:]...)
 			return true
 		}
@@ -116,10 +148,10 @@ func (imageState *ImageState) RemoveImageName(containerImageName string) bool {
 	return false
 }
 
-// HasImageName returns true if image state contains the containerImageName
+// HasImageName returns true if image state contains the containerImageName, or an equivalent reference
 func (imageState *ImageState) HasImageName(containerImageName string) bool {
 	for _, imageName := range imageState.Image.Names {
-		if imageName == containerImageName {
+		if IsSameImageReference(imageName, containerImageName) {
 			return true
 		}
 	}
@@ -148,6 +180,32 @@ func (imageState *ImageState) RemoveContainerReference(container *apicontainer.C
 	return fmt.Errorf("Container reference is not found in the image state container: %s", container.String())
 }
 
+// RecordFamilyUse records that a task of the given family used the image at the given time
+func (imageState *ImageState) RecordFamilyUse(family string, usedAt time.Time) {
+	imageState.lock.Lock()
+	defer imageState.lock.Unlock()
+
+	if imageState.FamilyLastUsedAt == nil {
+		imageState.FamilyLastUsedAt = make(map[string]time.Time)
+	}
+	if usedAt.After(imageState.FamilyLastUsedAt[family]) {
+		imageState.FamilyLastUsedAt[family] = usedAt
+	}
+}
+
+// UsedByFamilySince returns true if a task of any family used the image after the given time
+func (imageState *ImageState) UsedByFamilySince(since time.Time) bool {
+	imageState.lock.RLock()
+	defer imageState.lock.RUnlock()
+
+	for _, lastUsedAt := range imageState.FamilyLastUsedAt {
+		if lastUsedAt.After(since) {
+			return true
+		}
+	}
+	return false
+}
+
 // SetPullSucceeded sets the PullSucceeded of the imageState
 func (imageState *ImageState) SetPullSucceeded(pullSucceeded bool) {
 	imageState.lock.Lock()
@@ -164,21 +222,41 @@ func (imageState *ImageState) GetPullSucceeded() bool {
 	return imageState.PullSucceeded
 }
 
+// RecordRemoveFailure records a failed attempt to remove the image and returns the number of
+// consecutive failed attempts
+func (imageState *ImageState) RecordRemoveFailure() int {
+	imageState.lock.Lock()
+	defer imageState.lock.Unlock()
+
+	imageState.removeFailures++
+	return imageState.removeFailures
+}
+
+// ResetRemoveFailures resets the number of consecutive failed attempts to remove the image
+func (imageState *ImageState) ResetRemoveFailures() {
+	imageState.lock.Lock()
+	defer imageState.lock.Unlock()
+
+	imageState.removeFailures = 0
+}
+
 // MarshalJSON marshals image state
 func (imageState *ImageState) MarshalJSON() ([]byte, error) {
 	imageState.lock.Lock()
 	defer imageState.lock.Unlock()
 
 	return json.Marshal(&struct {
-		Image         *Image
-		PulledAt      time.Time
-		LastUsedAt    time.Time
-		PullSucceeded bool
+		Image            *Image
+		PulledAt         time.Time
+		LastUsedAt       time.Time
+		PullSucceeded    bool
+		FamilyLastUsedAt map[string]time.Time `json:",omitempty"`
 	}{
-		Image:         imageState.Image,
-		PulledAt:      imageState.PulledAt,
-		LastUsedAt:    imageState.LastUsedAt,
-		PullSucceeded: imageState.PullSucceeded,
+		Image:            imageState.Image,
+		PulledAt:         imageState.PulledAt,
+		LastUsedAt:       imageState.LastUsedAt,
+		PullSucceeded:    imageState.PullSucceeded,
+		FamilyLastUsedAt: imageState.FamilyLastUsedAt,
 	})
 }
 
@@ -0,0 +1,80 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/amazon-ecs-agent/agent/config"
+	"github.com/aws/amazon-ecs-agent/agent/dockerclient/dockerauth"
+
+	"golang.org/x/time/rate"
+)
+
+// imagePullRateLimiter limits the rate of the image pulls from each registry host with a token bucket per host.
+// A nil imagePullRateLimiter doesn't limit pulls.
+type imagePullRateLimiter struct {
+	defaultLimit config.ImagePullRateLimit
+	hostLimits   map[string]config.ImagePullRateLimit
+
+	lock     sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// newImagePullRateLimiter returns a limiter that limits the pulls from each registry host to the limit of the host
+// if any, or to the default limit otherwise. It returns nil if no limit is set.
+func newImagePullRateLimiter(defaultLimit config.ImagePullRateLimit,
+	hostLimits map[string]config.ImagePullRateLimit) *imagePullRateLimiter {
+	if defaultLimit.Rate <= 0 && len(hostLimits) == 0 {
+		return nil
+	}
+	return &imagePullRateLimiter{
+		defaultLimit: defaultLimit,
+		hostLimits:   hostLimits,
+		limiters:     make(map[string]*rate.Limiter),
+	}
+}
+
+// limiter returns the token bucket of the registry host, or nil if pulls from the host are not limited
+func (l *imagePullRateLimiter) limiter(host string) *rate.Limiter {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if limiter, ok := l.limiters[host]; ok {
+		return limiter
+	}
+	limit, ok := l.hostLimits[host]
+	if !ok {
+		limit = l.defaultLimit
+	}
+	var limiter *rate.Limiter
+	if limit.Rate > 0 {
+		limiter = rate.NewLimiter(rate.Limit(limit.Rate), limit.Burst)
+	}
+	l.limiters[host] = limiter
+	return limiter
+}
+
+// wait blocks until the image can be pulled from its registry host without exceeding the rate limit of the host,
+// or until the context is done
+func (l *imagePullRateLimiter) wait(ctx context.Context, image string) error {
+	if l == nil {
+		return nil
+	}
+	limiter := l.limiter(dockerauth.RegistryHost(image))
+	if limiter == nil {
+		return nil
+	}
+	return limiter.Wait(ctx)
+}
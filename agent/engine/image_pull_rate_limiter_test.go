@@ -0,0 +1,102 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitConcurrently waits for the limiter once per image, concurrently, and returns the sorted times the waits
+// returned at
+func waitConcurrently(t *testing.T, limiter *imagePullRateLimiter, images ...string) []time.Time {
+	var (
+		wg    sync.WaitGroup
+		lock  sync.Mutex
+		times []time.Time
+	)
+	for _, image := range images {
+		wg.Add(1)
+		go func(image string) {
+			defer wg.Done()
+			require.NoError(t, limiter.wait(context.TODO(), image))
+			lock.Lock()
+			defer lock.Unlock()
+			times = append(times, time.Now())
+		}(image)
+	}
+	wg.Wait()
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	return times
+}
+
+func TestImagePullRateLimiterNotConfigured(t *testing.T) {
+	limiter := newImagePullRateLimiter(config.ImagePullRateLimit{}, nil)
+	assert.Nil(t, limiter)
+	assert.NoError(t, limiter.wait(context.TODO(), "busybox"))
+}
+
+func TestImagePullRateLimiterSpacesPullsFromHost(t *testing.T) {
+	const interval = 50 * time.Millisecond
+	limiter := newImagePullRateLimiter(config.ImagePullRateLimit{}, map[string]config.ImagePullRateLimit{
+		"registry.example.com": {Rate: float64(time.Second / interval), Burst: 2},
+	})
+
+	images := make([]string, 6)
+	for i := range images {
+		images[i] = "registry.example.com/app:latest"
+	}
+	times := waitConcurrently(t, limiter, images...)
+
+	// the burst is pulled right away, and the remaining pulls are spaced by the interval of the rate
+	start := times[0]
+	for i, pullTime := range times[2:] {
+		minimumDelay := time.Duration(i+1)*interval - interval/5
+		assert.True(t, pullTime.Sub(start) >= minimumDelay, "pull %d started after %v, expected at least %v",
+			i+2, pullTime.Sub(start), minimumDelay)
+	}
+}
+
+func TestImagePullRateLimiterPerHost(t *testing.T) {
+	limiter := newImagePullRateLimiter(config.ImagePullRateLimit{Rate: 1, Burst: 1},
+		map[string]config.ImagePullRateLimit{
+			"unlimited.example.com": {Rate: 1000, Burst: 10},
+		})
+
+	// each host has its own bucket, so the first pull from each host is not delayed by the other ones
+	start := time.Now()
+	times := waitConcurrently(t, limiter, "busybox", "registry.example.com/app", "unlimited.example.com/a",
+		"unlimited.example.com/b", "unlimited.example.com/c")
+	assert.True(t, times[len(times)-1].Sub(start) < 500*time.Millisecond, "pulls from different hosts should not wait")
+}
+
+func TestImagePullRateLimiterContextCanceled(t *testing.T) {
+	limiter := newImagePullRateLimiter(config.ImagePullRateLimit{Rate: 0.001, Burst: 1}, nil)
+	require.NoError(t, limiter.wait(context.TODO(), "busybox"))
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	assert.Error(t, limiter.wait(ctx, "busybox"))
+}
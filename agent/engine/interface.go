@@ -49,6 +49,14 @@ type TaskEngine interface {
 	// GetTaskByArn gets a managed task, given a task arn.
 	GetTaskByArn(string) (*apitask.Task, bool)
 
+	// StopTasksForSpotInterruption gracefully stops the tasks which are to be stopped when the instance
+	// receives a spot interruption notice.
+	StopTasksForSpotInterruption()
+
+	// StopTasksForShutdown gracefully stops the tasks and waits for them to stop before the agent exits, when
+	// stopping containers on shutdown is enabled.
+	StopTasksForShutdown() error
+
 	Version() (string, error)
 
 	// LoadState loads the task engine state with data in db.
@@ -0,0 +1,77 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
+	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
+	"github.com/aws/amazon-ecs-agent/agent/logger"
+	"github.com/aws/amazon-ecs-agent/agent/logger/field"
+
+	dockercontainer "github.com/docker/docker/api/types/container"
+	"github.com/pkg/errors"
+)
+
+// managedScratchDirPerm lets any user of the containers write to the scratch directory of their task, while only
+// the owner of a file can remove it, as for /tmp
+const managedScratchDirPerm = os.ModeSticky | 0777
+
+// managedScratchDir returns the scratch directory of the task on the host, or an empty string if the agent does
+// not manage scratch directories
+func (engine *DockerTaskEngine) managedScratchDir(taskID string) string {
+	if engine.cfg.ManagedScratchDir == "" || taskID == "" {
+		return ""
+	}
+	return filepath.Join(engine.cfg.ManagedScratchDir, taskID)
+}
+
+// mountManagedScratchDir creates the scratch directory of the task, if it does not exist yet, and bind mounts it
+// in the container at the given path
+func (engine *DockerTaskEngine) mountManagedScratchDir(task *apitask.Task, container *apicontainer.Container,
+	hostConfig *dockercontainer.HostConfig, containerPath string) error {
+	hostDir := engine.managedScratchDir(task.GetID())
+	if hostDir == "" {
+		return errors.Errorf("container %s requests a scratch directory, but ECS_MANAGED_SCRATCH_DIR is not set", container.Name)
+	}
+	if !filepath.IsAbs(containerPath) {
+		return errors.Errorf("scratch directory mount path %s of container %s is not an absolute path", containerPath, container.Name)
+	}
+	if err := os.MkdirAll(hostDir, managedScratchDirPerm); err != nil {
+		return errors.Wrapf(err, "unable to create scratch directory %s", hostDir)
+	}
+	// the permissions given to MkdirAll are masked by the umask of the agent
+	if err := os.Chmod(hostDir, managedScratchDirPerm); err != nil {
+		return errors.Wrapf(err, "unable to set the permissions of scratch directory %s", hostDir)
+	}
+	hostConfig.Binds = append(hostConfig.Binds, fmt.Sprintf("%s:%s", hostDir, containerPath))
+	return nil
+}
+
+// removeManagedScratchDir removes the scratch directory of a task, along with its content
+func (engine *DockerTaskEngine) removeManagedScratchDir(taskID string) {
+	hostDir := engine.managedScratchDir(taskID)
+	if hostDir == "" {
+		return
+	}
+	if err := removeAll(hostDir); err != nil {
+		logger.Warn("Unable to remove scratch directory for task", logger.Fields{
+			field.TaskID: taskID,
+			field.Error:  err,
+		})
+	}
+}
@@ -0,0 +1,154 @@
+//go:build linux && unit
+// +build linux,unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
+	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
+	"github.com/aws/amazon-ecs-agent/agent/config"
+
+	"github.com/aws/aws-sdk-go/aws"
+	dockercontainer "github.com/docker/docker/api/types/container"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const managedScratchTaskARN = "arn:aws:ecs:region:account-id:task/scratch-task-id"
+
+func TestMountManagedScratchDir(t *testing.T) {
+	scratchDir := t.TempDir()
+	engine := &DockerTaskEngine{
+		cfg: &config.Config{ManagedScratchDir: scratchDir},
+	}
+	task := &apitask.Task{Arn: managedScratchTaskARN}
+	expectedHostDir := filepath.Join(scratchDir, "scratch-task-id")
+
+	// The containers of the task share the same scratch directory, wherever they mount it
+	hostConfig := &dockercontainer.HostConfig{Binds: []string{"/data:/data"}}
+	require.NoError(t, engine.mountManagedScratchDir(task, &apicontainer.Container{Name: "c1"}, hostConfig, "/scratch"))
+	assert.Equal(t, []string{"/data:/data", expectedHostDir + ":/scratch"}, hostConfig.Binds)
+	info, err := os.Stat(expectedHostDir)
+	require.NoError(t, err)
+	assert.True(t, info.IsDir())
+	assert.Equal(t, managedScratchDirPerm, info.Mode()&(os.ModePerm|os.ModeSticky))
+
+	require.NoError(t, os.WriteFile(filepath.Join(expectedHostDir, "file"), []byte("content"), 0644))
+	hostConfig = &dockercontainer.HostConfig{}
+	require.NoError(t, engine.mountManagedScratchDir(task, &apicontainer.Container{Name: "c2"}, hostConfig, "/tmp/work"))
+	assert.Equal(t, []string{expectedHostDir + ":/tmp/work"}, hostConfig.Binds)
+	assert.FileExists(t, filepath.Join(expectedHostDir, "file"), "the scratch directory should not be recreated")
+}
+
+func TestMountManagedScratchDirErrors(t *testing.T) {
+	task := &apitask.Task{Arn: managedScratchTaskARN}
+	container := &apicontainer.Container{Name: "c1"}
+
+	engine := &DockerTaskEngine{cfg: &config.Config{}}
+	hostConfig := &dockercontainer.HostConfig{}
+	assert.Error(t, engine.mountManagedScratchDir(task, container, hostConfig, "/scratch"),
+		"scratch directories are not managed when ECS_MANAGED_SCRATCH_DIR is unset")
+	assert.Empty(t, hostConfig.Binds)
+
+	scratchDir := t.TempDir()
+	engine = &DockerTaskEngine{cfg: &config.Config{ManagedScratchDir: scratchDir}}
+	assert.Error(t, engine.mountManagedScratchDir(task, container, hostConfig, "scratch"))
+	assert.Empty(t, hostConfig.Binds)
+	_, err := os.Stat(filepath.Join(scratchDir, "scratch-task-id"))
+	assert.True(t, os.IsNotExist(err), "no scratch directory should be created for an invalid mount path")
+}
+
+func TestCreateContainerMountsManagedScratchDir(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	cfg := defaultConfig
+	cfg.ManagedScratchDir = t.TempDir()
+	ctrl, client, _, taskEngine, _, _, _, _ := mocks(t, ctx, &cfg)
+	defer ctrl.Finish()
+
+	testTask := &apitask.Task{
+		Arn: managedScratchTaskARN,
+		Containers: []*apicontainer.Container{
+			{
+				Name: "c1",
+				DockerConfig: apicontainer.DockerConfig{
+					Config: aws.String(`{"Labels":{"com.amazonaws.ecs.managed-scratch":"/scratch"}}`),
+				},
+			},
+		},
+	}
+	expectedBind := filepath.Join(cfg.ManagedScratchDir, "scratch-task-id") + ":/scratch"
+	client.EXPECT().APIVersion().Return(defaultDockerClientAPIVersion, nil).AnyTimes()
+	client.EXPECT().CreateContainer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Do(
+		func(ctx context.Context, config *dockercontainer.Config, hostConfig *dockercontainer.HostConfig,
+			name string, timeout time.Duration) {
+			assert.Contains(t, hostConfig.Binds, expectedBind)
+		})
+	taskEngine.(*DockerTaskEngine).createContainer(testTask, testTask.Containers[0])
+	assert.DirExists(t, filepath.Join(cfg.ManagedScratchDir, "scratch-task-id"))
+}
+
+func TestCreateContainerManagedScratchDirNotConfigured(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, client, _, taskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+
+	testTask := &apitask.Task{
+		Arn: managedScratchTaskARN,
+		Containers: []*apicontainer.Container{
+			{
+				Name: "c1",
+				DockerConfig: apicontainer.DockerConfig{
+					Config: aws.String(`{"Labels":{"com.amazonaws.ecs.managed-scratch":"/scratch"}}`),
+				},
+			},
+		},
+	}
+	client.EXPECT().APIVersion().Return(defaultDockerClientAPIVersion, nil).AnyTimes()
+	client.EXPECT().CreateContainer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	metadata := taskEngine.(*DockerTaskEngine).createContainer(testTask, testTask.Containers[0])
+	require.Error(t, metadata.Error)
+	assert.Contains(t, metadata.Error.Error(), "ECS_MANAGED_SCRATCH_DIR")
+}
+
+func TestRemoveManagedScratchDir(t *testing.T) {
+	scratchDir := t.TempDir()
+	for _, taskID := range []string{"stopped-task-id", "active-task-id"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(scratchDir, taskID, "nested"), 0755))
+	}
+
+	engine := &DockerTaskEngine{
+		cfg: &config.Config{ManagedScratchDir: scratchDir},
+	}
+	engine.removeManagedScratchDir("stopped-task-id")
+
+	_, err := os.Stat(filepath.Join(scratchDir, "stopped-task-id"))
+	assert.True(t, os.IsNotExist(err), "expected the scratch directory of the stopped task to be removed")
+	assert.DirExists(t, filepath.Join(scratchDir, "active-task-id", "nested"))
+
+	// Nothing is removed when scratch directories are not managed
+	engine = &DockerTaskEngine{cfg: &config.Config{}}
+	engine.removeManagedScratchDir("active-task-id")
+	assert.DirExists(t, filepath.Join(scratchDir, "active-task-id"))
+}
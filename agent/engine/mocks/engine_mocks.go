@@ -21,6 +21,7 @@ package mock_engine
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	container "github.com/aws/amazon-ecs-agent/agent/api/container"
 	task "github.com/aws/amazon-ecs-agent/agent/api/task"
@@ -202,6 +203,32 @@ func (mr *MockTaskEngineMockRecorder) StateChangeEvents() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateChangeEvents", reflect.TypeOf((*MockTaskEngine)(nil).StateChangeEvents))
 }
 
+// StopTasksForShutdown mocks base method
+func (m *MockTaskEngine) StopTasksForShutdown() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StopTasksForShutdown")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StopTasksForShutdown indicates an expected call of StopTasksForShutdown
+func (mr *MockTaskEngineMockRecorder) StopTasksForShutdown() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopTasksForShutdown", reflect.TypeOf((*MockTaskEngine)(nil).StopTasksForShutdown))
+}
+
+// StopTasksForSpotInterruption mocks base method
+func (m *MockTaskEngine) StopTasksForSpotInterruption() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "StopTasksForSpotInterruption")
+}
+
+// StopTasksForSpotInterruption indicates an expected call of StopTasksForSpotInterruption
+func (mr *MockTaskEngineMockRecorder) StopTasksForSpotInterruption() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopTasksForSpotInterruption", reflect.TypeOf((*MockTaskEngine)(nil).StopTasksForSpotInterruption))
+}
+
 // UnmarshalJSON mocks base method
 func (m *MockTaskEngine) UnmarshalJSON(arg0 []byte) error {
 	m.ctrl.T.Helper()
@@ -266,6 +293,49 @@ func (mr *MockImageManagerMockRecorder) AddAllImageStates(arg0 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAllImageStates", reflect.TypeOf((*MockImageManager)(nil).AddAllImageStates), arg0)
 }
 
+// GetImageCleanupEligibility mocks base method
+func (m *MockImageManager) GetImageCleanupEligibility(arg0 string) (image.CleanupEligibility, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImageCleanupEligibility", arg0)
+	ret0, _ := ret[0].(image.CleanupEligibility)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// GetImageCleanupEligibility indicates an expected call of GetImageCleanupEligibility
+func (mr *MockImageManagerMockRecorder) GetImageCleanupEligibility(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImageCleanupEligibility", reflect.TypeOf((*MockImageManager)(nil).GetImageCleanupEligibility), arg0)
+}
+
+// GetImageCleanupOrder mocks base method
+func (m *MockImageManager) GetImageCleanupOrder() []image.CleanupEligibility {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImageCleanupOrder")
+	ret0, _ := ret[0].([]image.CleanupEligibility)
+	return ret0
+}
+
+// GetImageCleanupOrder indicates an expected call of GetImageCleanupOrder
+func (mr *MockImageManagerMockRecorder) GetImageCleanupOrder() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImageCleanupOrder", reflect.TypeOf((*MockImageManager)(nil).GetImageCleanupOrder))
+}
+
+// GetImageCleanupHistory mocks base method
+func (m *MockImageManager) GetImageCleanupHistory() []image.CleanupCycleStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImageCleanupHistory")
+	ret0, _ := ret[0].([]image.CleanupCycleStats)
+	return ret0
+}
+
+// GetImageCleanupHistory indicates an expected call of GetImageCleanupHistory
+func (mr *MockImageManagerMockRecorder) GetImageCleanupHistory() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImageCleanupHistory", reflect.TypeOf((*MockImageManager)(nil).GetImageCleanupHistory))
+}
+
 // GetImageStateFromImageName mocks base method
 func (m *MockImageManager) GetImageStateFromImageName(arg0 string) (*image.ImageState, bool) {
 	m.ctrl.T.Helper()
@@ -281,6 +351,46 @@ func (mr *MockImageManagerMockRecorder) GetImageStateFromImageName(arg0 interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImageStateFromImageName", reflect.TypeOf((*MockImageManager)(nil).GetImageStateFromImageName), arg0)
 }
 
+// GetPinnedImages mocks base method
+func (m *MockImageManager) GetPinnedImages() map[string]time.Time {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPinnedImages")
+	ret0, _ := ret[0].(map[string]time.Time)
+	return ret0
+}
+
+// GetPinnedImages indicates an expected call of GetPinnedImages
+func (mr *MockImageManagerMockRecorder) GetPinnedImages() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPinnedImages", reflect.TypeOf((*MockImageManager)(nil).GetPinnedImages))
+}
+
+// LoadPrewarmImages mocks base method
+func (m *MockImageManager) LoadPrewarmImages(arg0 context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "LoadPrewarmImages", arg0)
+}
+
+// LoadPrewarmImages indicates an expected call of LoadPrewarmImages
+func (mr *MockImageManagerMockRecorder) LoadPrewarmImages(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadPrewarmImages", reflect.TypeOf((*MockImageManager)(nil).LoadPrewarmImages), arg0)
+}
+
+// PinImage mocks base method
+func (m *MockImageManager) PinImage(arg0 string, arg1 time.Duration) time.Time {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PinImage", arg0, arg1)
+	ret0, _ := ret[0].(time.Time)
+	return ret0
+}
+
+// PinImage indicates an expected call of PinImage
+func (mr *MockImageManagerMockRecorder) PinImage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PinImage", reflect.TypeOf((*MockImageManager)(nil).PinImage), arg0, arg1)
+}
+
 // RecordContainerReference mocks base method
 func (m *MockImageManager) RecordContainerReference(arg0 *container.Container) error {
 	m.ctrl.T.Helper()
@@ -309,6 +419,21 @@ func (mr *MockImageManagerMockRecorder) RemoveContainerReferenceFromImageState(a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveContainerReferenceFromImageState", reflect.TypeOf((*MockImageManager)(nil).RemoveContainerReferenceFromImageState), arg0)
 }
 
+// RunImageCleanup mocks base method
+func (m *MockImageManager) RunImageCleanup(arg0 context.Context) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunImageCleanup", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunImageCleanup indicates an expected call of RunImageCleanup
+func (mr *MockImageManagerMockRecorder) RunImageCleanup(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunImageCleanup", reflect.TypeOf((*MockImageManager)(nil).RunImageCleanup), arg0)
+}
+
 // SetDataClient mocks base method
 func (m *MockImageManager) SetDataClient(arg0 data.Client) {
 	m.ctrl.T.Helper()
@@ -332,3 +457,17 @@ func (mr *MockImageManagerMockRecorder) StartImageCleanupProcess(arg0 interface{
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartImageCleanupProcess", reflect.TypeOf((*MockImageManager)(nil).StartImageCleanupProcess), arg0)
 }
+
+// UnpinImage mocks base method
+func (m *MockImageManager) UnpinImage(arg0 string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnpinImage", arg0)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// UnpinImage indicates an expected call of UnpinImage
+func (mr *MockImageManagerMockRecorder) UnpinImage(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnpinImage", reflect.TypeOf((*MockImageManager)(nil).UnpinImage), arg0)
+}
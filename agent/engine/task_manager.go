@@ -163,6 +163,14 @@ type managedTask struct {
 	// verification logic gets executed to set it to a low interval
 	steadyStatePollInterval       time.Duration
 	steadyStatePollIntervalJitter time.Duration
+
+	// containerStartSlots bounds the number of containers of the task that are pulled, created and started
+	// at the same time, their transitions are not bounded when it is nil
+	containerStartSlots chan struct{}
+
+	// imagePullSlots bounds the number of images of the task pulled at the same time, pulls are not bounded
+	// per task when it is nil
+	imagePullSlots chan struct{}
 }
 
 // newManagedTask is a method on DockerTaskEngine to create a new managedTask.
@@ -188,6 +196,12 @@ func (engine *DockerTaskEngine) newManagedTask(task *apitask.Task) *managedTask
 		steadyStatePollInterval:       engine.taskSteadyStatePollInterval,
 		steadyStatePollIntervalJitter: engine.taskSteadyStatePollIntervalJitter,
 	}
+	if engine.cfg.TaskContainerStartConcurrency > 0 {
+		t.containerStartSlots = make(chan struct{}, engine.cfg.TaskContainerStartConcurrency)
+	}
+	if engine.cfg.MaxConcurrentImagePullsPerTask > 0 {
+		t.imagePullSlots = make(chan struct{}, engine.cfg.MaxConcurrentImagePullsPerTask)
+	}
 	engine.managedTasks[task.Arn] = t
 	return t
 }
@@ -252,7 +266,29 @@ func (mtask *managedTask) overseeTask() {
 	}
 	// TODO: make this idempotent on agent restart
 	go mtask.releaseIPInIPAM()
-	mtask.cleanupTask(retry.AddJitter(mtask.cfg.TaskCleanupWaitDuration, mtask.cfg.TaskCleanupWaitDurationJitter))
+	mtask.cleanupTask(mtask.taskCleanupWaitDuration())
+}
+
+// taskCleanupWaitDuration returns the time to wait before cleaning up the stopped task. A task can
+// override the configured duration, up to the configured maximum override.
+func (mtask *managedTask) taskCleanupWaitDuration() time.Duration {
+	override, ok := mtask.GetTaskCleanupWaitDurationOverride()
+	if !ok {
+		return retry.AddJitter(mtask.cfg.TaskCleanupWaitDuration, mtask.cfg.TaskCleanupWaitDurationJitter)
+	}
+	if override > mtask.cfg.TaskCleanupWaitDurationMaxOverride {
+		logger.Warn("Task cleanup wait duration override exceeds the maximum; using the maximum", logger.Fields{
+			field.TaskID: mtask.GetID(),
+			"override":   override.String(),
+			"maximum":    mtask.cfg.TaskCleanupWaitDurationMaxOverride.String(),
+		})
+		override = mtask.cfg.TaskCleanupWaitDurationMaxOverride
+	}
+	logger.Info("Using task cleanup wait duration override", logger.Fields{
+		field.TaskID: mtask.GetID(),
+		"duration":   override.String(),
+	})
+	return override
 }
 
 // shouldExit checks if the task manager should exit, as the agent is exiting.
@@ -460,6 +496,15 @@ func (mtask *managedTask) handleContainerChange(containerChange dockerContainerC
 		return
 	}
 
+	if event.Status == apicontainerstatus.ContainerStopped && isStoppedEventFromRestart(container, event) {
+		logger.Info("Ignoring container stopped event generated while restarting the container", eventLogFields)
+		return
+	}
+
+	if event.Status == apicontainerstatus.ContainerStopped && mtask.restartOnExitCode(container, event) {
+		return
+	}
+
 	// If this is a backwards transition stopped->running, the first time set it
 	// to be known running so it will be stopped. Subsequently ignore these backward transitions
 	mtask.handleStoppedToRunningContainerTransition(event.Status, container)
@@ -490,6 +535,11 @@ func (mtask *managedTask) handleContainerChange(containerChange dockerContainerC
 		}
 	}
 
+	if event.Status == apicontainerstatus.ContainerStopped {
+		mtask.handleContainerOOM(container, event)
+		mtask.handleNetworkNamespaceLost(container)
+	}
+
 	if execcmd.IsExecEnabledContainer(container) && container.GetKnownStatus() == apicontainerstatus.ContainerStopped {
 		// if this is an execute-command-enabled container STOPPED event, we should emit a corresponding managedAgent event
 		mtask.handleManagedAgentStoppedTransition(container, execcmd.ExecuteCommandAgentName)
@@ -519,6 +569,61 @@ func (mtask *managedTask) handleContainerChange(containerChange dockerContainerC
 	}
 }
 
+// handleContainerOOM classifies the stop of a container killed due to memory usage. The out of memory error
+// becomes the reason of the container even if an earlier error was applied to it. The task is given an out of
+// memory terminal reason when the container is essential, and is also stopped when the container is not
+// essential but the OOM policy of the task is to kill the whole task.
+func (mtask *managedTask) handleContainerOOM(container *apicontainer.Container, event dockerapi.DockerContainerChangeEvent) {
+	oomErr, ok := event.Error.(dockerapi.OutOfMemoryError)
+	if !ok {
+		return
+	}
+	container.ApplyingError = apierrors.NewNamedError(oomErr)
+	terminalReason := fmt.Sprintf("%s: %s", oomErr.ErrorName(), container.Name)
+	if container.IsEssential() {
+		// A task which is already stopping keeps the reason it was stopped for
+		if mtask.GetDesiredStatus().Terminal() {
+			return
+		}
+		logger.Warn("Essential container killed due to memory usage; the task will be stopped", logger.Fields{
+			field.TaskID:    mtask.GetID(),
+			field.Container: container.Name,
+			field.RuntimeID: container.GetRuntimeID(),
+		})
+		mtask.SetTerminalReason(terminalReason)
+		return
+	}
+	if mtask.GetOOMPolicy() != apitask.OOMPolicyKillTask {
+		return
+	}
+	logger.Warn("Non-essential container killed due to memory usage; stopping the task as per its OOM policy", logger.Fields{
+		field.TaskID:    mtask.GetID(),
+		field.Container: container.Name,
+		field.RuntimeID: container.GetRuntimeID(),
+	})
+	mtask.SetTerminalReason(terminalReason)
+	mtask.SetDesiredStatus(apitaskstatus.TaskStopped)
+}
+
+// handleNetworkNamespaceLost stops an awsvpc task whose pause container stopped while the task was meant
+// to be running. The pause container holds the network namespace of the task, so the other containers of
+// the task are left without a network.
+func (mtask *managedTask) handleNetworkNamespaceLost(container *apicontainer.Container) {
+	if container.Type != apicontainer.ContainerCNIPause || !mtask.IsNetworkModeAWSVPC() {
+		return
+	}
+	if container.GetDesiredStatus().Terminal() || mtask.GetDesiredStatus().Terminal() {
+		return
+	}
+	logger.Error("Pause container stopped unexpectedly; the task lost its network namespace and will be stopped", logger.Fields{
+		field.TaskID:    mtask.GetID(),
+		field.Container: container.Name,
+		field.RuntimeID: container.GetRuntimeID(),
+	})
+	mtask.SetTerminalReason(fmt.Sprintf("%s: the pause container of the task stopped unexpectedly", networkNamespaceLostReason))
+	mtask.SetDesiredStatus(apitaskstatus.TaskStopped)
+}
+
 // handleResourceStateChange attempts to update resource's known status depending on
 // the current status and errors during transition
 func (mtask *managedTask) handleResourceStateChange(resChange resourceStateChange) {
@@ -775,6 +880,18 @@ func (mtask *managedTask) releaseIPInIPAM() {
 	}
 }
 
+// isStoppedEventFromRestart returns true if the stopped event was generated by the agent restarting
+// the container, either while the restart is in progress or when the event describes a run of the
+// container that finished before the last restart.
+func isStoppedEventFromRestart(container *apicontainer.Container, event dockerapi.DockerContainerChangeEvent) bool {
+	if container.IsRestarting() {
+		return true
+	}
+	restartedAt := container.GetLastRestartedAt()
+	finishedAt := event.DockerContainerMetadata.FinishedAt
+	return !restartedAt.IsZero() && !finishedAt.IsZero() && !finishedAt.After(restartedAt)
+}
+
 // handleStoppedToRunningContainerTransition detects a "backwards" container
 // transition where a known-stopped container is found to be running again and
 // handles it.
@@ -813,6 +930,7 @@ func (mtask *managedTask) handleManagedAgentStoppedTransition(container *apicont
 	//for now we only have the ExecuteCommandAgent
 	switch managedAgentName {
 	case execcmd.ExecuteCommandAgentName:
+		mtask.engine.execCmdMgr.RecordAgentStopped(mtask.Task, container, "Received Container Stopped event")
 		if !container.UpdateManagedAgentStatus(managedAgentName, apicontainerstatus.ManagedAgentStopped) {
 			logger.Warn("Cannot find ManagedAgent for container", logger.Fields{
 				field.TaskID:       mtask.GetID(),
@@ -855,6 +973,9 @@ func (mtask *managedTask) handleEventError(containerChange dockerContainerChange
 				field.Container: container.Name,
 				field.Error:     event.Error,
 			})
+			if category, ok := dockerapi.PullErrorCategoryOf(event.Error); ok {
+				mtask.SetTerminalReason(fmt.Sprintf("%s: %s: %s", imagePullFailedReason, category, container.Name))
+			}
 			// The task should be stopped regardless of whether this container is
 			// essential or non-essential.
 			mtask.SetDesiredStatus(apitaskstatus.TaskStopped)
@@ -975,6 +1096,9 @@ func (mtask *managedTask) progressTask() {
 	logger.Debug("Progressing containers and resources in task", logger.Fields{
 		field.TaskID: mtask.GetID(),
 	})
+	if mtask.waitForEssentialContainerStopGrace() {
+		return
+	}
 	// max number of transitions length to ensure writes will never block on
 	// these and if we exit early transitions can exit the goroutine and it'll
 	// get GC'd eventually
@@ -1056,6 +1180,42 @@ func (mtask *managedTask) progressTask() {
 	}
 }
 
+// waitForEssentialContainerStopGrace leaves the remaining containers of the task running for the configured grace
+// period after an essential container exited, while still handling the events of the task. It returns true if the
+// task was not progressed because the grace period had not elapsed.
+func (mtask *managedTask) waitForEssentialContainerStopGrace() bool {
+	remaining := mtask.essentialContainerStopGraceRemaining()
+	if remaining <= 0 {
+		return false
+	}
+	logger.Info("Essential container exited; waiting before stopping the remaining containers of the task", logger.Fields{
+		field.TaskID:         mtask.GetID(),
+		"essentialContainer": mtask.GetEssentialContainerStopped(),
+		"remaining":          remaining.String(),
+	})
+	ctx, cancel := context.WithTimeout(mtask.ctx, remaining)
+	defer cancel()
+	if timedOut := mtask.waitEvent(ctx.Done()); timedOut && ctx.Err() == context.DeadlineExceeded {
+		logger.Info("Essential container stop grace period elapsed; stopping the remaining containers of the task", logger.Fields{
+			field.TaskID: mtask.GetID(),
+		})
+	}
+	return true
+}
+
+// essentialContainerStopGraceRemaining returns how much longer the remaining containers of the task are left
+// running, when the task is stopping because an essential container exited
+func (mtask *managedTask) essentialContainerStopGraceRemaining() time.Duration {
+	if mtask.cfg.EssentialContainerStopGrace <= 0 || mtask.GetEssentialContainerStopped() == "" {
+		return 0
+	}
+	stoppedAt := mtask.GetExecutionStoppedAt()
+	if stoppedAt.IsZero() {
+		return 0
+	}
+	return mtask.cfg.EssentialContainerStopGrace - time.Since(stoppedAt)
+}
+
 // isWaitingForACSExecutionCredentials checks if the container that can't be transitioned
 // was caused by waiting for credentials and start waiting
 func (mtask *managedTask) isWaitingForACSExecutionCredentials(reasons []error) bool {
@@ -1091,6 +1251,12 @@ func (mtask *managedTask) startContainerTransitions(transitionFunc containerTran
 		transition := mtask.containerNextState(cont)
 		if transition.reason != nil {
 			if transition.reason.IsTerminal() {
+				if blockedOn, escalated := mtask.escalateDependencyTimeout(cont, transition.reason); escalated {
+					// The timed out dependency is being restarted; keep waiting for it
+					reasons = append(reasons, transition.reason)
+					blocked[cont.Name] = *blockedOn
+					continue
+				}
 				mtask.handleTerminalDependencyError(cont, transition.reason)
 			}
 			// container can't be transitioned
@@ -1127,12 +1293,145 @@ func (mtask *managedTask) startContainerTransitions(transitionFunc containerTran
 			continue
 		}
 		transitions[cont.Name] = transition.nextState
-		go transitionFunc(cont, transition.nextState)
+		// Containers whose dependencies are satisfied are transitioned concurrently, up to the start
+		// concurrency of the task
+		go func(cont *apicontainer.Container, status apicontainerstatus.ContainerStatus) {
+			release, ok := mtask.acquireContainerStartSlot(status)
+			if !ok {
+				return
+			}
+			defer release()
+			transitionFunc(cont, status)
+		}(cont, transition.nextState)
 	}
 
 	return anyCanTransition, blocked, transitions, reasons
 }
 
+// acquireContainerStartSlot waits for a container start slot of the task to be free before a container is
+// pulled, created or started. Transitions to later states are not bounded. It returns the function releasing
+// the slot, and false if the task is done while waiting.
+func (mtask *managedTask) acquireContainerStartSlot(nextStatus apicontainerstatus.ContainerStatus) (func(), bool) {
+	if mtask.containerStartSlots == nil || nextStatus > apicontainerstatus.ContainerRunning {
+		return func() {}, true
+	}
+	select {
+	case mtask.containerStartSlots <- struct{}{}:
+		return func() { <-mtask.containerStartSlots }, true
+	case <-mtask.ctx.Done():
+		return nil, false
+	}
+}
+
+// escalateDependencyTimeout restarts a container ordering dependency that has timed out if the dependency
+// allows it and has restart attempts left. It returns the dependency the container is
+// blocked on and true if the container should keep waiting instead of failing.
+func (mtask *managedTask) escalateDependencyTimeout(container *apicontainer.Container,
+	reason dependencygraph.DependencyError) (*apicontainer.DependsOn, bool) {
+	timedOutErr, ok := reason.(*dependencygraph.DependencyTimedOutError)
+	if !ok {
+		return nil, false
+	}
+	var blockedOn *apicontainer.DependsOn
+	for _, dependsOn := range container.GetDependsOn() {
+		if dependsOn.ContainerName == timedOutErr.DependencyName {
+			blockedOn = &dependsOn
+			break
+		}
+	}
+	dependency, ok := mtask.ContainerByName(timedOutErr.DependencyName)
+	if !ok || blockedOn == nil {
+		return nil, false
+	}
+	if dependency.IsRestarting() {
+		return blockedOn, true
+	}
+	maxAttempts := dependency.GetDependencyTimeoutRestartAttempts()
+	if dependency.GetRestartCountByReason(apicontainer.RestartReasonDependencyTimeout) >= maxAttempts {
+		return nil, false
+	}
+
+	attempt := dependency.IncrementRestartCount(apicontainer.RestartReasonDependencyTimeout)
+	// Mark the dependency as restarting right away so that it is not escalated again while the
+	// restart is in progress
+	dependency.SetRestarting(true)
+	logger.Warn("Container ordering dependency timed out; restarting the dependency", logger.Fields{
+		field.TaskID:    mtask.GetID(),
+		field.Container: container.Name,
+		"dependency":    dependency.Name,
+		"attempt":       attempt,
+		"maxAttempts":   maxAttempts,
+	})
+	go func() {
+		metadata := mtask.engine.restartContainer(mtask.Task, dependency)
+		if metadata.Error != nil {
+			logger.Error("Failed to restart container ordering dependency; stopping it", logger.Fields{
+				field.TaskID:    mtask.GetID(),
+				field.Container: dependency.Name,
+				field.Error:     metadata.Error,
+			})
+			mtask.engine.transitionContainer(mtask.Task, dependency, apicontainerstatus.ContainerStopped)
+		}
+	}()
+	return blockedOn, true
+}
+
+// restartOnExitCode restarts a container that exited on its own with one of its restart exit codes, or with
+// a failure its restart policy restarts it on, instead of letting it stop, as long as it has restart attempts
+// left. The restart waits for the restart backoff of the container. It returns true if the container is being
+// restarted.
+func (mtask *managedTask) restartOnExitCode(container *apicontainer.Container, event dockerapi.DockerContainerChangeEvent) bool {
+	exitCode := event.DockerContainerMetadata.ExitCode
+	if exitCode == nil || container.GetKnownStatus() != apicontainerstatus.ContainerRunning ||
+		container.GetDesiredStatus().Terminal() || mtask.GetDesiredStatus().Terminal() {
+		return false
+	}
+	reason, ok := container.ShouldRestartOnExitCode(*exitCode)
+	if !ok {
+		return false
+	}
+
+	attempt := container.IncrementRestartCount(reason)
+	backoff := container.GetRestartBackoff(attempt)
+	// Mark the container as restarting right away so that the stopped events docker generates for it
+	// in the meantime are ignored
+	container.SetRestarting(true)
+	logger.Warn("Container exited; restarting it", logger.Fields{
+		field.TaskID:    mtask.GetID(),
+		field.Container: container.Name,
+		"exitCode":      *exitCode,
+		"reason":        reason,
+		"attempt":       attempt,
+		"backoff":       backoff.String(),
+	})
+	go func() {
+		if backoff > 0 {
+			select {
+			case <-mtask.time().After(backoff):
+			case <-mtask.ctx.Done():
+				return
+			}
+			if container.GetDesiredStatus().Terminal() || mtask.GetDesiredStatus().Terminal() {
+				// The task started stopping during the backoff, let the container be stopped instead
+				container.SetRestarting(false)
+				mtask.engine.transitionContainer(mtask.Task, container, apicontainerstatus.ContainerStopped)
+				return
+			}
+		}
+		metadata := mtask.engine.restartContainer(mtask.Task, container)
+		if metadata.Error != nil {
+			logger.Error("Failed to restart container after it exited; stopping it", logger.Fields{
+				field.TaskID:    mtask.GetID(),
+				field.Container: container.Name,
+				field.Error:     metadata.Error,
+			})
+			container.SetDesiredStatus(apicontainerstatus.ContainerStopped)
+			mtask.engine.transitionContainer(mtask.Task, container, apicontainerstatus.ContainerStopped)
+		}
+	}()
+	return true
+}
+
 func (mtask *managedTask) handleTerminalDependencyError(container *apicontainer.Container, error dependencygraph.DependencyError) {
 	logger.Error("Terminal error detected during transition; marking container as stopped", logger.Fields{
 		field.Container: container.Name,
@@ -1304,6 +1603,23 @@ func (mtask *managedTask) containerNextState(container *apicontainer.Container)
 			blockedOn:      blocked,
 		}
 	}
+	if essentialContainer := mtask.GetEssentialContainerStopped(); essentialContainer != "" &&
+		mtask.GetEssentialStopTeardown() == apitask.EssentialStopTeardownOrdered {
+		if err := dependencygraph.TeardownOrderIsResolved(container, mtask.Containers); err != nil {
+			logger.Debug("Can't stop container yet due to ordered teardown of the task", logger.Fields{
+				field.TaskID:         mtask.GetID(),
+				field.Container:      container.Name,
+				field.RuntimeID:      container.GetRuntimeID(),
+				"essentialContainer": essentialContainer,
+				field.Error:          err,
+			})
+			return &containerTransition{
+				nextState:      apicontainerstatus.ContainerStatusNone,
+				actionRequired: false,
+				reason:         err,
+			}
+		}
+	}
 
 	var nextState apicontainerstatus.ContainerStatus
 	if container.DesiredTerminal() {
@@ -1502,8 +1818,10 @@ func (mtask *managedTask) cleanupTask(taskStoppedDuration time.Duration) {
 	// speedy processing of other events for other tasks
 	// discard events while the task is being removed from engine state
 	go mtask.discardEvents()
-	mtask.engine.sweepTask(mtask.Task)
-	mtask.engine.deleteTask(mtask.Task)
+	mtask.engine.runTaskCleanup(func() {
+		mtask.engine.sweepTask(mtask.Task)
+		mtask.engine.deleteTask(mtask.Task)
+	})
 
 	// Remove TaskExecutionCredentials from credentialsManager
 	if taskExecutionCredentialsID != "" {
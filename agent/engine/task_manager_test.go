@@ -18,6 +18,7 @@ package engine
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"sync"
@@ -53,7 +54,9 @@ import (
 	"github.com/aws/amazon-ecs-agent/agent/statechange"
 	"github.com/aws/amazon-ecs-agent/agent/taskresource/volume"
 	mock_ttime "github.com/aws/amazon-ecs-agent/agent/utils/ttime/mocks"
+	dockercontainer "github.com/docker/docker/api/types/container"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/golang/mock/gomock"
 )
@@ -69,6 +72,7 @@ func TestHandleEventError(t *testing.T) {
 		ExpectedContainerKnownStatus          apicontainerstatus.ContainerStatus
 		ExpectedContainerDesiredStatusStopped bool
 		ExpectedTaskDesiredStatusStopped      bool
+		ExpectedTaskTerminalReason            string
 		ExpectedOK                            bool
 	}{
 		{
@@ -187,6 +191,19 @@ func TestHandleEventError(t *testing.T) {
 			ExpectedTaskDesiredStatusStopped: true,
 			ExpectedOK:                       false,
 		},
+		{
+			Name:        "Pull image fails with a classified error and task fails",
+			EventStatus: apicontainerstatus.ContainerPulled,
+			Error: &dockerapi.CannotPullContainerError{
+				FromError: errors.New("manifest for busybox:missing not found: manifest unknown"),
+				Category:  dockerapi.PullErrorCategoryManifestNotFound,
+			},
+			ImagePullBehavior:                config.ImagePullAlwaysBehavior,
+			ExpectedContainerKnownStatusSet:  false,
+			ExpectedTaskDesiredStatusStopped: true,
+			ExpectedTaskTerminalReason:       "CannotPullContainerError: ManifestNotFound: web",
+			ExpectedOK:                       false,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -201,6 +218,7 @@ func TestHandleEventError(t *testing.T) {
 			}
 
 			container := &apicontainer.Container{
+				Name:              "web",
 				KnownStatusUnsafe: tc.CurrentContainerKnownStatus,
 			}
 			containerChange := dockerContainerChange{
@@ -233,6 +251,7 @@ func TestHandleEventError(t *testing.T) {
 					"desired status %s != %s", apicontainerstatus.ContainerStopped.String(), containerDesiredStatus.String())
 			}
 			assert.Equal(t, tc.Error.ErrorName(), containerChange.container.ApplyingError.ErrorName())
+			assert.Equal(t, tc.ExpectedTaskTerminalReason, mtask.GetTerminalReason())
 		})
 	}
 }
@@ -693,6 +712,97 @@ func TestStartContainerTransitionsWhenForwardTransitionPossible(t *testing.T) {
 	}
 }
 
+// newStartableContainer returns a created container which is due to be started
+func newStartableContainer(name string) *apicontainer.Container {
+	container := apicontainer.NewContainerWithSteadyState(apicontainerstatus.ContainerRunning)
+	container.Name = name
+	container.KnownStatusUnsafe = apicontainerstatus.ContainerCreated
+	container.DesiredStatusUnsafe = apicontainerstatus.ContainerRunning
+	return container
+}
+
+func TestStartContainerTransitionsStartsIndependentContainersConcurrently(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	dependent := newStartableContainer("dependent")
+	dependent.SetDependsOn([]apicontainer.DependsOn{{ContainerName: "app", Condition: "START"}})
+	mtask := &managedTask{
+		ctx: ctx,
+		Task: &apitask.Task{
+			Containers: []*apicontainer.Container{
+				newStartableContainer("app"),
+				newStartableContainer("sidecar"),
+				dependent,
+			},
+			DesiredStatusUnsafe: apitaskstatus.TaskRunning,
+		},
+		engine:              &DockerTaskEngine{},
+		cfg:                 &config.Config{},
+		containerStartSlots: make(chan struct{}, 2),
+	}
+
+	started := make(chan string, 3)
+	release := make(chan struct{})
+	defer close(release)
+	_, blocked, transitions, _ := mtask.startContainerTransitions(
+		func(cont *apicontainer.Container, nextStatus apicontainerstatus.ContainerStatus) {
+			started <- cont.Name
+			// Hold the transition until the test is done, so that a container started after the other
+			// one finished would never be started
+			<-release
+		})
+
+	startedContainers := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		select {
+		case name := <-started:
+			startedContainers[name] = true
+		case <-time.After(time.Second):
+			t.Fatal("independent containers were not started concurrently")
+		}
+	}
+	assert.Equal(t, map[string]bool{"app": true, "sidecar": true}, startedContainers)
+	assert.Len(t, transitions, 2)
+	assert.Contains(t, blocked, "dependent", "dependent container should wait for the container it depends on")
+}
+
+func TestStartContainerTransitionsBoundsStartConcurrency(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	mtask := &managedTask{
+		ctx: ctx,
+		Task: &apitask.Task{
+			Containers: []*apicontainer.Container{
+				newStartableContainer("app"),
+				newStartableContainer("sidecar"),
+			},
+			DesiredStatusUnsafe: apitaskstatus.TaskRunning,
+		},
+		engine:              &DockerTaskEngine{},
+		containerStartSlots: make(chan struct{}, 1),
+	}
+
+	started := make(chan string, 2)
+	release := make(chan struct{})
+	mtask.startContainerTransitions(
+		func(cont *apicontainer.Container, nextStatus apicontainerstatus.ContainerStatus) {
+			started <- cont.Name
+			<-release
+		})
+
+	first := <-started
+	select {
+	case <-started:
+		t.Fatal("more containers started than allowed")
+	case <-time.After(100 * time.Millisecond):
+	}
+	// The other container is started once the first one is done
+	release <- struct{}{}
+	second := <-started
+	release <- struct{}{}
+	assert.ElementsMatch(t, []string{"app", "sidecar"}, []string{first, second})
+}
+
 func TestStartContainerTransitionsWhenForwardTransitionIsNotPossible(t *testing.T) {
 	firstContainerName := "container1"
 	firstContainer := &apicontainer.Container{
@@ -2201,3 +2311,813 @@ func TestContainerNextStateDependsStoppedContainer(t *testing.T) {
 		})
 	}
 }
+
+func TestContainerNextStateEssentialStopTeardown(t *testing.T) {
+	testCases := []struct {
+		name          string
+		labels        map[string]string
+		expectedOrder [][]string
+	}{
+		{
+			name:          "parallel teardown by default",
+			expectedOrder: [][]string{{"db", "logger", "sidecar"}},
+		},
+		{
+			name:          "parallel teardown",
+			labels:        map[string]string{apitask.EssentialStopTeardownLabel: apitask.EssentialStopTeardownParallel},
+			expectedOrder: [][]string{{"db", "logger", "sidecar"}},
+		},
+		{
+			name:          "ordered teardown",
+			labels:        map[string]string{apitask.EssentialStopTeardownLabel: apitask.EssentialStopTeardownOrdered},
+			expectedOrder: [][]string{{"sidecar"}, {"logger"}, {"db"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rawConfig, err := json.Marshal(&dockercontainer.Config{Labels: tc.labels})
+			require.NoError(t, err)
+			newContainer := func(name string, dependencies ...string) *apicontainer.Container {
+				cont := &apicontainer.Container{
+					Name:                name,
+					KnownStatusUnsafe:   apicontainerstatus.ContainerRunning,
+					DesiredStatusUnsafe: apicontainerstatus.ContainerRunning,
+					DockerConfig:        apicontainer.DockerConfig{Config: aws.String(string(rawConfig))},
+				}
+				for _, dependency := range dependencies {
+					cont.DependsOnUnsafe = append(cont.DependsOnUnsafe,
+						apicontainer.DependsOn{ContainerName: dependency, Condition: "START"})
+				}
+				return cont
+			}
+			app := newContainer("app", "db")
+			app.Essential = true
+			containers := []*apicontainer.Container{
+				newContainer("db"),
+				app,
+				newContainer("logger"),
+				newContainer("sidecar", "app"),
+			}
+			mtask := managedTask{
+				Task: &apitask.Task{
+					Arn:                 "task1",
+					Containers:          containers,
+					DesiredStatusUnsafe: apitaskstatus.TaskRunning,
+				},
+				engine: &DockerTaskEngine{},
+			}
+
+			app.SetKnownStatus(apicontainerstatus.ContainerStopped)
+			mtask.UpdateDesiredStatus()
+			assert.Equal(t, apitaskstatus.TaskStopped, mtask.GetDesiredStatus())
+			assert.Equal(t, "app", mtask.GetEssentialContainerStopped())
+
+			for _, expected := range tc.expectedOrder {
+				var stopping []*apicontainer.Container
+				for _, cont := range containers {
+					if transition := mtask.containerNextState(cont); transition.actionRequired {
+						assert.Equal(t, apicontainerstatus.ContainerStopped, transition.nextState)
+						stopping = append(stopping, cont)
+					}
+				}
+				names := make([]string, len(stopping))
+				for i, cont := range stopping {
+					names[i] = cont.Name
+					cont.SetKnownStatus(apicontainerstatus.ContainerStopped)
+				}
+				assert.Equal(t, expected, names)
+			}
+		})
+	}
+}
+
+func TestProgressTaskHonorsEssentialContainerStopGrace(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	grace := 200 * time.Millisecond
+	app := &apicontainer.Container{
+		Name:                "app",
+		Essential:           true,
+		KnownStatusUnsafe:   apicontainerstatus.ContainerRunning,
+		DesiredStatusUnsafe: apicontainerstatus.ContainerRunning,
+	}
+	logRouter := &apicontainer.Container{
+		Name:                "log_router",
+		KnownStatusUnsafe:   apicontainerstatus.ContainerRunning,
+		DesiredStatusUnsafe: apicontainerstatus.ContainerRunning,
+	}
+	mtask := &managedTask{
+		ctx: ctx,
+		Task: &apitask.Task{
+			Arn:                 "task1",
+			Containers:          []*apicontainer.Container{app, logRouter},
+			KnownStatusUnsafe:   apitaskstatus.TaskRunning,
+			DesiredStatusUnsafe: apitaskstatus.TaskRunning,
+		},
+		cfg:                      &config.Config{EssentialContainerStopGrace: grace},
+		engine:                   &DockerTaskEngine{},
+		acsMessages:              make(chan acsTransition),
+		dockerMessages:           make(chan dockerContainerChange),
+		resourceStateChangeEvent: make(chan resourceStateChange),
+	}
+
+	app.SetKnownStatus(apicontainerstatus.ContainerStopped)
+	mtask.RecordExecutionStoppedAt(app)
+	mtask.UpdateDesiredStatus()
+	require.Equal(t, apitaskstatus.TaskStopped, mtask.GetDesiredStatus())
+
+	// The remaining containers are not transitioned, which would require a docker client, during the grace period
+	mtask.progressTask()
+	assert.True(t, time.Since(mtask.GetExecutionStoppedAt()) >= grace, "the task should have waited for the grace period")
+	assert.Equal(t, apicontainerstatus.ContainerRunning, logRouter.GetKnownStatus())
+	assert.Equal(t, apicontainerstatus.ContainerStatusNone, logRouter.GetAppliedStatus())
+
+	assert.False(t, mtask.waitForEssentialContainerStopGrace(), "the grace period should have elapsed")
+	transition := mtask.containerNextState(logRouter)
+	assert.True(t, transition.actionRequired)
+	assert.Equal(t, apicontainerstatus.ContainerStopped, transition.nextState)
+
+	mtask.cfg = &config.Config{}
+	mtask.SetExecutionStoppedAt(time.Now())
+	assert.False(t, mtask.waitForEssentialContainerStopGrace(), "there should be no grace period when it isn't configured")
+}
+
+// timedOutDependencyTestTask returns a managed task whose target container waits for its dependency to be
+// healthy, where the dependency has been running past its start timeout and allows restartAttempts restarts
+func timedOutDependencyTestTask(t *testing.T, engine *DockerTaskEngine, dockerMessages chan dockerContainerChange,
+	restartAttempts string) (*managedTask, *apicontainer.Container, *apicontainer.Container) {
+	rawConfig, err := json.Marshal(&dockercontainer.Config{Labels: map[string]string{
+		apicontainer.DependencyTimeoutRestartAttemptsLabel: restartAttempts,
+	}})
+	require.NoError(t, err)
+	dependency := &apicontainer.Container{
+		Name:                "dependency",
+		KnownStatusUnsafe:   apicontainerstatus.ContainerRunning,
+		DesiredStatusUnsafe: apicontainerstatus.ContainerRunning,
+		RuntimeID:           "dependency-id",
+		StartTimeout:        10,
+		HealthCheckType:     apicontainer.DockerHealthCheckType,
+		DockerConfig: apicontainer.DockerConfig{
+			Config: aws.String(string(rawConfig)),
+		},
+	}
+	dependency.SetStartedAt(time.Now().Add(-time.Minute))
+	target := &apicontainer.Container{
+		Name:                "target",
+		KnownStatusUnsafe:   apicontainerstatus.ContainerPulled,
+		DesiredStatusUnsafe: apicontainerstatus.ContainerRunning,
+		DependsOnUnsafe: []apicontainer.DependsOn{
+			{
+				ContainerName: dependency.Name,
+				Condition:     "HEALTHY",
+			},
+		},
+	}
+	mtask := &managedTask{
+		Task: &apitask.Task{
+			Arn:                 "arn",
+			Containers:          []*apicontainer.Container{dependency, target},
+			DesiredStatusUnsafe: apitaskstatus.TaskRunning,
+		},
+		engine:         engine,
+		cfg:            &config.Config{},
+		dockerMessages: dockerMessages,
+	}
+	return mtask, dependency, target
+}
+
+func TestStartContainerTransitionsRestartsTimedOutDependency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockClient := mock_dockerapi.NewMockDockerClient(ctrl)
+	mockTime := mock_ttime.NewMockTime(ctrl)
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	dockerMessagesChan := make(chan dockerContainerChange, 2)
+	task, dependency, target := timedOutDependencyTestTask(t, &DockerTaskEngine{
+		ctx:    ctx,
+		client: mockClient,
+		cfg:    &config.Config{DockerStopTimeout: time.Second, ContainerStartTimeout: time.Second},
+		_time:  mockTime,
+	}, dockerMessagesChan, "1")
+	// Restarts of the dependency upon its exit codes do not use up its attempts for dependency timeouts
+	dependency.IncrementRestartCount(apicontainer.RestartReasonExitCode)
+
+	restartedAt := time.Now()
+	restarted := make(chan struct{})
+	gomock.InOrder(
+		mockClient.EXPECT().StopContainer(gomock.Any(), "dependency-id", time.Second).
+			Return(dockerapi.DockerContainerMetadata{}),
+		mockClient.EXPECT().StartContainer(gomock.Any(), "dependency-id", time.Second).
+			Return(dockerapi.DockerContainerMetadata{}),
+		mockTime.EXPECT().Now().Do(func() { close(restarted) }).Return(restartedAt),
+	)
+
+	transitions := make(chan apicontainerstatus.ContainerStatus, 1)
+	transitionFunc := func(cont *apicontainer.Container, nextStatus apicontainerstatus.ContainerStatus) {
+		if cont.Name != target.Name {
+			t.Errorf("Unexpected transition of container %s", cont.Name)
+		}
+		transitions <- nextStatus
+	}
+	canTransition, blocked, _, reasons := task.startContainerTransitions(transitionFunc)
+	assert.False(t, canTransition)
+	require.Len(t, reasons, 2)
+	assert.IsType(t, &dependencygraph.DependencyTimedOutError{}, reasons[1])
+	assert.Equal(t, dependency.Name, blocked[target.Name].ContainerName)
+	assert.Equal(t, 1, dependency.GetRestartCountByReason(apicontainer.RestartReasonDependencyTimeout))
+	assert.Equal(t, 2, dependency.GetRestartCount())
+
+	select {
+	case <-restarted:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the dependency to be restarted")
+	}
+	for i := 0; dependency.IsRestarting() && i < 100; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.False(t, dependency.IsRestarting())
+	assert.Equal(t, restartedAt, dependency.GetLastRestartedAt())
+	assert.Equal(t, restartedAt, dependency.GetStartedAt(), "the start timeout should apply to the new run")
+
+	// Once the dependency has been restarted its start timeout applies again, so the target keeps
+	// waiting without being failed
+	canTransition, _, _, reasons = task.startContainerTransitions(transitionFunc)
+	assert.False(t, canTransition)
+	require.Len(t, reasons, 2)
+	assert.False(t, reasons[1].(dependencygraph.DependencyError).IsTerminal())
+
+	// The dependency turns healthy after the restart, and the target is started
+	dependency.SetHealthStatus(apicontainer.HealthStatus{Status: apicontainerstatus.ContainerHealthy})
+	canTransition, _, _, _ = task.startContainerTransitions(transitionFunc)
+	assert.True(t, canTransition)
+	select {
+	case nextStatus := <-transitions:
+		assert.Equal(t, apicontainerstatus.ContainerCreated, nextStatus)
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the target to be transitioned")
+	}
+	assert.Equal(t, 1, dependency.GetRestartCountByReason(apicontainer.RestartReasonDependencyTimeout))
+	assert.Empty(t, dockerMessagesChan)
+}
+
+func TestStartContainerTransitionsFailsAfterDependencyRestartAttempts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The mock docker client fails the test if the dependency is restarted again
+	dockerMessagesChan := make(chan dockerContainerChange, 1)
+	task, dependency, target := timedOutDependencyTestTask(t, &DockerTaskEngine{
+		client: mock_dockerapi.NewMockDockerClient(ctrl),
+	}, dockerMessagesChan, "10")
+	for i := 0; i < apicontainer.MaxDependencyTimeoutRestartAttempts; i++ {
+		dependency.IncrementRestartCount(apicontainer.RestartReasonDependencyTimeout)
+	}
+
+	transitionFunc := func(cont *apicontainer.Container, nextStatus apicontainerstatus.ContainerStatus) {
+		t.Error("Transition function should not be called once the dependency is out of restart attempts")
+	}
+	canTransition, _, _, reasons := task.startContainerTransitions(transitionFunc)
+	assert.False(t, canTransition)
+	require.Len(t, reasons, 2)
+	timedOutErr, ok := reasons[1].(*dependencygraph.DependencyTimedOutError)
+	require.True(t, ok, "the target should fail with a dependency timed out error")
+	assert.True(t, timedOutErr.IsTerminal())
+	assert.Equal(t, dependency.Name, timedOutErr.DependencyName)
+	assert.Equal(t, apicontainer.MaxDependencyTimeoutRestartAttempts,
+		dependency.GetRestartCountByReason(apicontainer.RestartReasonDependencyTimeout))
+
+	select {
+	case change := <-dockerMessagesChan:
+		assert.Equal(t, target, change.container)
+		assert.Equal(t, apicontainerstatus.ContainerStopped, change.event.Status)
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the target to be stopped")
+	}
+	assert.Equal(t, apicontainerstatus.ContainerStopped, target.GetDesiredStatus())
+}
+
+func TestIsStoppedEventFromRestart(t *testing.T) {
+	restartedAt := time.Now()
+	container := &apicontainer.Container{}
+	stoppedEvent := func(finishedAt time.Time) dockerapi.DockerContainerChangeEvent {
+		return dockerapi.DockerContainerChangeEvent{
+			Status: apicontainerstatus.ContainerStopped,
+			DockerContainerMetadata: dockerapi.DockerContainerMetadata{
+				FinishedAt: finishedAt,
+			},
+		}
+	}
+
+	assert.False(t, isStoppedEventFromRestart(container, stoppedEvent(restartedAt)))
+
+	container.SetRestarting(true)
+	assert.True(t, isStoppedEventFromRestart(container, stoppedEvent(restartedAt)))
+
+	container.SetRestarting(false)
+	container.SetLastRestartedAt(restartedAt)
+	assert.True(t, isStoppedEventFromRestart(container, stoppedEvent(restartedAt.Add(-time.Second))))
+	assert.False(t, isStoppedEventFromRestart(container, stoppedEvent(restartedAt.Add(time.Second))))
+	assert.False(t, isStoppedEventFromRestart(container, stoppedEvent(time.Time{})))
+}
+
+func TestHandleContainerChangeRestartExitCodes(t *testing.T) {
+	testCases := []struct {
+		name              string
+		exitCode          int
+		expectedRestarted bool
+	}{
+		{
+			name:              "restart exit code",
+			exitCode:          75,
+			expectedRestarted: true,
+		},
+		{
+			name:              "fatal exit code",
+			exitCode:          1,
+			expectedRestarted: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := mock_dockerapi.NewMockDockerClient(ctrl)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			containerChangeEventStream := eventstream.NewEventStream("TestHandleContainerChangeRestartExitCodes", ctx)
+			containerChangeEventStream.StartListening()
+
+			rawConfig, err := json.Marshal(&dockercontainer.Config{Labels: map[string]string{
+				apicontainer.RestartExitCodesLabel: "75,76",
+			}})
+			require.NoError(t, err)
+			container := &apicontainer.Container{
+				Name:                "app",
+				RuntimeID:           "app-id",
+				KnownStatusUnsafe:   apicontainerstatus.ContainerRunning,
+				DesiredStatusUnsafe: apicontainerstatus.ContainerRunning,
+				DockerConfig: apicontainer.DockerConfig{
+					Config: aws.String(string(rawConfig)),
+				},
+			}
+			mTask := &managedTask{
+				Task: &apitask.Task{
+					Arn:                 "arn:aws:ecs:us-west-2:1234567890:task/test-cluster/task-id",
+					Containers:          []*apicontainer.Container{container},
+					KnownStatusUnsafe:   apitaskstatus.TaskRunning,
+					DesiredStatusUnsafe: apitaskstatus.TaskRunning,
+				},
+				containerChangeEventStream: containerChangeEventStream,
+				stateChangeEvents:          make(chan statechange.Event),
+				ctx:                        ctx,
+				dockerClient:               client,
+				engine: &DockerTaskEngine{
+					ctx:        ctx,
+					client:     client,
+					cfg:        &config.Config{DockerStopTimeout: time.Second, ContainerStartTimeout: time.Second},
+					dataClient: data.NewNoopClient(),
+				},
+			}
+			defer discardEvents(mTask.stateChangeEvents)()
+
+			restarted := make(chan struct{})
+			if tc.expectedRestarted {
+				gomock.InOrder(
+					client.EXPECT().StopContainer(gomock.Any(), "app-id", time.Second).
+						Return(dockerapi.DockerContainerMetadata{}),
+					client.EXPECT().StartContainer(gomock.Any(), "app-id", time.Second).
+						Do(func(interface{}, interface{}, interface{}) { close(restarted) }).
+						Return(dockerapi.DockerContainerMetadata{}),
+				)
+			} else {
+				client.EXPECT().SystemPing(gomock.Any(), gomock.Any()).Return(dockerapi.PingResponse{}).AnyTimes()
+			}
+
+			mTask.handleContainerChange(dockerContainerChange{
+				container: container,
+				event: dockerapi.DockerContainerChangeEvent{
+					Status: apicontainerstatus.ContainerStopped,
+					DockerContainerMetadata: dockerapi.DockerContainerMetadata{
+						DockerID: "app-id",
+						ExitCode: aws.Int(tc.exitCode),
+					},
+				},
+			})
+
+			if !tc.expectedRestarted {
+				assert.Equal(t, apicontainerstatus.ContainerStopped, container.GetKnownStatus())
+				assert.Equal(t, 0, container.GetRestartCount())
+				return
+			}
+			assert.Equal(t, apicontainerstatus.ContainerRunning, container.GetKnownStatus())
+			assert.Equal(t, 1, container.GetRestartCountByReason(apicontainer.RestartReasonExitCode))
+			select {
+			case <-restarted:
+			case <-time.After(time.Second):
+				t.Fatal("Timed out waiting for the container to be restarted")
+			}
+			for i := 0; container.IsRestarting() && i < 100; i++ {
+				time.Sleep(10 * time.Millisecond)
+			}
+			require.False(t, container.IsRestarting())
+			assert.False(t, container.GetLastRestartedAt().IsZero())
+		})
+	}
+}
+
+func TestHandleContainerChangeRestartPolicy(t *testing.T) {
+	const backoff = 50 * time.Millisecond
+	testCases := []struct {
+		name                 string
+		essential            bool
+		restartCount         int
+		expectedRestarted    bool
+		expectedRestartCount int
+	}{
+		{
+			name:                 "restarted after backoff",
+			expectedRestarted:    true,
+			expectedRestartCount: 1,
+		},
+		{
+			name:                 "retries exhausted",
+			restartCount:         2,
+			expectedRestartCount: 2,
+		},
+		{
+			name:      "essential container",
+			essential: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := mock_dockerapi.NewMockDockerClient(ctrl)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			containerChangeEventStream := eventstream.NewEventStream("TestHandleContainerChangeRestartPolicy", ctx)
+			containerChangeEventStream.StartListening()
+
+			rawConfig, err := json.Marshal(&dockercontainer.Config{Labels: map[string]string{
+				apicontainer.RestartPolicyLabel:      apicontainer.RestartPolicyOnFailure,
+				apicontainer.RestartMaxAttemptsLabel: "2",
+				apicontainer.RestartBackoffLabel:     backoff.String(),
+			}})
+			require.NoError(t, err)
+			container := &apicontainer.Container{
+				Name:                "sidecar",
+				RuntimeID:           "sidecar-id",
+				Essential:           tc.essential,
+				KnownStatusUnsafe:   apicontainerstatus.ContainerRunning,
+				DesiredStatusUnsafe: apicontainerstatus.ContainerRunning,
+				RestartCountsByReasonUnsafe: map[apicontainer.RestartReason]int{
+					apicontainer.RestartReasonRestartPolicy: tc.restartCount,
+				},
+				DockerConfig: apicontainer.DockerConfig{
+					Config: aws.String(string(rawConfig)),
+				},
+			}
+			mTask := &managedTask{
+				Task: &apitask.Task{
+					Arn:                 "arn:aws:ecs:us-west-2:1234567890:task/test-cluster/task-id",
+					Containers:          []*apicontainer.Container{container},
+					KnownStatusUnsafe:   apitaskstatus.TaskRunning,
+					DesiredStatusUnsafe: apitaskstatus.TaskRunning,
+				},
+				containerChangeEventStream: containerChangeEventStream,
+				stateChangeEvents:          make(chan statechange.Event),
+				ctx:                        ctx,
+				dockerClient:               client,
+				engine: &DockerTaskEngine{
+					ctx:        ctx,
+					client:     client,
+					cfg:        &config.Config{DockerStopTimeout: time.Second, ContainerStartTimeout: time.Second},
+					dataClient: data.NewNoopClient(),
+				},
+			}
+			defer discardEvents(mTask.stateChangeEvents)()
+
+			restarted := make(chan time.Time)
+			if tc.expectedRestarted {
+				gomock.InOrder(
+					client.EXPECT().StopContainer(gomock.Any(), "sidecar-id", time.Second).
+						Return(dockerapi.DockerContainerMetadata{}),
+					client.EXPECT().StartContainer(gomock.Any(), "sidecar-id", time.Second).
+						Do(func(interface{}, interface{}, interface{}) { restarted <- time.Now() }).
+						Return(dockerapi.DockerContainerMetadata{}),
+				)
+			} else {
+				client.EXPECT().SystemPing(gomock.Any(), gomock.Any()).Return(dockerapi.PingResponse{}).AnyTimes()
+			}
+
+			exitedAt := time.Now()
+			mTask.handleContainerChange(dockerContainerChange{
+				container: container,
+				event: dockerapi.DockerContainerChangeEvent{
+					Status: apicontainerstatus.ContainerStopped,
+					DockerContainerMetadata: dockerapi.DockerContainerMetadata{
+						DockerID: "sidecar-id",
+						ExitCode: aws.Int(1),
+					},
+				},
+			})
+
+			assert.Equal(t, tc.expectedRestartCount,
+				container.GetRestartCountByReason(apicontainer.RestartReasonRestartPolicy))
+			if !tc.expectedRestarted {
+				assert.Equal(t, apicontainerstatus.ContainerStopped, container.GetKnownStatus())
+				return
+			}
+			assert.Equal(t, apicontainerstatus.ContainerRunning, container.GetKnownStatus())
+			select {
+			case restartedAt := <-restarted:
+				assert.True(t, restartedAt.Sub(exitedAt) >= backoff, "container restarted before the backoff")
+			case <-time.After(time.Second):
+				t.Fatal("Timed out waiting for the container to be restarted")
+			}
+			for i := 0; container.IsRestarting() && i < 100; i++ {
+				time.Sleep(10 * time.Millisecond)
+			}
+			require.False(t, container.IsRestarting())
+		})
+	}
+}
+
+func TestHandleContainerChangeNetworkNamespaceLost(t *testing.T) {
+	testCases := []struct {
+		name                string
+		taskDesiredStatus   apitaskstatus.TaskStatus
+		expectedReasonFound bool
+	}{
+		{
+			name:                "pause container dies while the task is running",
+			taskDesiredStatus:   apitaskstatus.TaskRunning,
+			expectedReasonFound: true,
+		},
+		{
+			name:                "pause container stops while the task is stopping",
+			taskDesiredStatus:   apitaskstatus.TaskStopped,
+			expectedReasonFound: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := mock_dockerapi.NewMockDockerClient(ctrl)
+			client.EXPECT().SystemPing(gomock.Any(), gomock.Any()).Return(dockerapi.PingResponse{}).AnyTimes()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			containerChangeEventStream := eventstream.NewEventStream("TestHandleContainerChangeNetworkNamespaceLost", ctx)
+			containerChangeEventStream.StartListening()
+
+			pauseContainer := &apicontainer.Container{
+				Name:                apitask.NetworkPauseContainerName,
+				Type:                apicontainer.ContainerCNIPause,
+				Essential:           true,
+				KnownStatusUnsafe:   apicontainerstatus.ContainerResourcesProvisioned,
+				DesiredStatusUnsafe: apicontainerstatus.ContainerResourcesProvisioned,
+			}
+			pauseContainer.SetSteadyStateStatusUnsafe(apicontainerstatus.ContainerResourcesProvisioned)
+			appContainer := &apicontainer.Container{
+				Name:                "app",
+				Essential:           true,
+				KnownStatusUnsafe:   apicontainerstatus.ContainerRunning,
+				DesiredStatusUnsafe: apicontainerstatus.ContainerRunning,
+			}
+			mTask := &managedTask{
+				Task: &apitask.Task{
+					Arn:                 "arn:aws:ecs:us-west-2:1234567890:task/test-cluster/task-id",
+					NetworkMode:         apitask.AWSVPCNetworkMode,
+					Containers:          []*apicontainer.Container{pauseContainer, appContainer},
+					KnownStatusUnsafe:   apitaskstatus.TaskRunning,
+					DesiredStatusUnsafe: tc.taskDesiredStatus,
+				},
+				containerChangeEventStream: containerChangeEventStream,
+				stateChangeEvents:          make(chan statechange.Event),
+				ctx:                        ctx,
+				dockerClient:               client,
+				engine: &DockerTaskEngine{
+					dataClient: data.NewNoopClient(),
+				},
+			}
+			defer discardEvents(mTask.stateChangeEvents)()
+
+			mTask.handleContainerChange(dockerContainerChange{
+				container: pauseContainer,
+				event: dockerapi.DockerContainerChangeEvent{
+					Status: apicontainerstatus.ContainerStopped,
+					DockerContainerMetadata: dockerapi.DockerContainerMetadata{
+						DockerID: "pause-id",
+						ExitCode: aws.Int(137),
+					},
+				},
+			})
+			mTask.UpdateDesiredStatus()
+
+			assert.Equal(t, apitaskstatus.TaskStopped, mTask.GetDesiredStatus())
+			assert.Equal(t, apicontainerstatus.ContainerStopped, appContainer.GetDesiredStatus())
+			if tc.expectedReasonFound {
+				assert.Equal(t, "NetworkNamespaceLost: the pause container of the task stopped unexpectedly",
+					mTask.GetTerminalReason())
+			} else {
+				assert.Empty(t, mTask.GetTerminalReason())
+			}
+		})
+	}
+}
+
+func TestHandleContainerChangeOOMPolicy(t *testing.T) {
+	testCases := []struct {
+		name                   string
+		oomPolicy              string
+		essential              bool
+		earlierError           bool
+		taskStopping           bool
+		expectedTaskStopped    bool
+		expectedTerminalReason string
+	}{
+		{
+			name:                   "non essential container with kill-task policy",
+			oomPolicy:              apitask.OOMPolicyKillTask,
+			expectedTaskStopped:    true,
+			expectedTerminalReason: "OutOfMemoryError: oom",
+		},
+		{
+			name:                "non essential container with kill-container policy",
+			oomPolicy:           apitask.OOMPolicyKillContainer,
+			expectedTaskStopped: false,
+		},
+		{
+			name:                "non essential container without policy",
+			expectedTaskStopped: false,
+		},
+		{
+			name:                   "essential container with kill-container policy",
+			oomPolicy:              apitask.OOMPolicyKillContainer,
+			essential:              true,
+			expectedTaskStopped:    true,
+			expectedTerminalReason: "OutOfMemoryError: oom",
+		},
+		{
+			name:                   "essential container with an earlier error",
+			essential:              true,
+			earlierError:           true,
+			expectedTaskStopped:    true,
+			expectedTerminalReason: "OutOfMemoryError: oom",
+		},
+		{
+			name:                "essential container of a stopping task",
+			essential:           true,
+			taskStopping:        true,
+			expectedTaskStopped: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			client := mock_dockerapi.NewMockDockerClient(ctrl)
+			client.EXPECT().SystemPing(gomock.Any(), gomock.Any()).Return(dockerapi.PingResponse{})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			containerChangeEventStream := eventstream.NewEventStream("TestHandleContainerChangeOOMPolicy", ctx)
+			containerChangeEventStream.StartListening()
+
+			labels := map[string]string{}
+			if tc.oomPolicy != "" {
+				labels[apitask.OOMPolicyLabel] = tc.oomPolicy
+			}
+			rawConfig, err := json.Marshal(&dockercontainer.Config{Labels: labels})
+			require.NoError(t, err)
+			essentialContainer := &apicontainer.Container{
+				Name:                "essential",
+				Essential:           true,
+				KnownStatusUnsafe:   apicontainerstatus.ContainerRunning,
+				DesiredStatusUnsafe: apicontainerstatus.ContainerRunning,
+				DockerConfig: apicontainer.DockerConfig{
+					Config: aws.String(string(rawConfig)),
+				},
+			}
+			oomContainer := &apicontainer.Container{
+				Name:                "oom",
+				Essential:           tc.essential,
+				KnownStatusUnsafe:   apicontainerstatus.ContainerRunning,
+				DesiredStatusUnsafe: apicontainerstatus.ContainerRunning,
+			}
+			if tc.earlierError {
+				oomContainer.ApplyingError = apierrors.NewNamedError(errors.New("earlier error"))
+			}
+			taskDesiredStatus := apitaskstatus.TaskRunning
+			if tc.taskStopping {
+				taskDesiredStatus = apitaskstatus.TaskStopped
+			}
+			mTask := &managedTask{
+				Task: &apitask.Task{
+					Arn:                 "arn:aws:ecs:us-west-2:1234567890:task/test-cluster/task-id",
+					Containers:          []*apicontainer.Container{essentialContainer, oomContainer},
+					KnownStatusUnsafe:   apitaskstatus.TaskRunning,
+					DesiredStatusUnsafe: taskDesiredStatus,
+				},
+				containerChangeEventStream: containerChangeEventStream,
+				stateChangeEvents:          make(chan statechange.Event),
+				ctx:                        ctx,
+				dockerClient:               client,
+				engine: &DockerTaskEngine{
+					dataClient: data.NewNoopClient(),
+				},
+			}
+			// Discard all the statechange events
+			defer discardEvents(mTask.stateChangeEvents)()
+
+			mTask.handleContainerChange(dockerContainerChange{
+				container: oomContainer,
+				event: dockerapi.DockerContainerChangeEvent{
+					Status: apicontainerstatus.ContainerStopped,
+					DockerContainerMetadata: dockerapi.DockerContainerMetadata{
+						DockerID: "dockerID",
+						ExitCode: aws.Int(137),
+						Error:    dockerapi.OutOfMemoryError{},
+					},
+				},
+			})
+			mTask.UpdateDesiredStatus()
+
+			assert.Equal(t, apicontainerstatus.ContainerStopped, oomContainer.GetKnownStatus())
+			require.NotNil(t, oomContainer.ApplyingError)
+			assert.Equal(t, dockerapi.OutOfMemoryError{}.ErrorName(), oomContainer.ApplyingError.ErrorName())
+			assert.Equal(t, tc.expectedTerminalReason, mTask.GetTerminalReason())
+			if tc.expectedTaskStopped {
+				assert.Equal(t, apitaskstatus.TaskStopped, mTask.GetDesiredStatus())
+				for _, container := range mTask.Containers {
+					assert.Equal(t, apicontainerstatus.ContainerStopped, container.GetDesiredStatus(),
+						"container %s should be stopped", container.Name)
+				}
+			} else {
+				assert.Equal(t, apitaskstatus.TaskRunning, mTask.GetDesiredStatus())
+				assert.Equal(t, apicontainerstatus.ContainerRunning, essentialContainer.GetDesiredStatus())
+			}
+		})
+	}
+}
+
+func TestTaskCleanupWaitDuration(t *testing.T) {
+	testCases := []struct {
+		name     string
+		labels   map[string]string
+		expected time.Duration
+	}{
+		{
+			name:     "no override",
+			labels:   map[string]string{},
+			expected: time.Minute,
+		},
+		{
+			name:     "override below the maximum",
+			labels:   map[string]string{apitask.TaskCleanupWaitDurationLabel: "2h"},
+			expected: 2 * time.Hour,
+		},
+		{
+			name:     "override above the maximum",
+			labels:   map[string]string{apitask.TaskCleanupWaitDurationLabel: "48h"},
+			expected: 4 * time.Hour,
+		},
+		{
+			name:     "invalid override",
+			labels:   map[string]string{apitask.TaskCleanupWaitDurationLabel: "forever"},
+			expected: time.Minute,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rawConfig, err := json.Marshal(&dockercontainer.Config{Labels: tc.labels})
+			require.NoError(t, err)
+			mtask := &managedTask{
+				Task: &apitask.Task{
+					Arn: "arn:aws:ecs:us-west-2:1234567890:task/test-cluster/task-id",
+					Containers: []*apicontainer.Container{
+						{
+							Name: "container",
+							DockerConfig: apicontainer.DockerConfig{
+								Config: aws.String(string(rawConfig)),
+							},
+						},
+					},
+				},
+				cfg: &config.Config{
+					TaskCleanupWaitDuration:            time.Minute,
+					TaskCleanupWaitDurationMaxOverride: 4 * time.Hour,
+				},
+			}
+			assert.Equal(t, tc.expected, mtask.taskCleanupWaitDuration())
+		})
+	}
+}
@@ -24,9 +24,11 @@ import (
 )
 
 // HandleEngineEvents handles state change events from the state change event channel by sending it to
-// responsible event handler
+// responsible event handler. The task and container state changes are also published to the lifecycle event
+// subscribers, if any.
 func HandleEngineEvents(ctx context.Context, taskEngine engine.TaskEngine, client api.ECSClient,
-	taskHandler *TaskHandler, attachmentEventHandler *AttachmentEventHandler) {
+	taskHandler *TaskHandler, attachmentEventHandler *AttachmentEventHandler,
+	lifecycleEvents *LifecycleEventBroadcaster) {
 
 	for {
 		stateChangeEvents := taskEngine.StateChangeEvents()
@@ -46,6 +48,9 @@ func HandleEngineEvents(ctx context.Context, taskEngine engine.TaskEngine, clien
 				if err != nil {
 					seelog.Errorf("Handler unable to add state change event %v: %v", event, err)
 				}
+				if lifecycleEvents != nil {
+					lifecycleEvents.Publish(event)
+				}
 			}
 		}
 	}
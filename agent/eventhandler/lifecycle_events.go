@@ -0,0 +1,130 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package eventhandler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	"github.com/aws/amazon-ecs-agent/agent/statechange"
+	"github.com/cihub/seelog"
+)
+
+const (
+	// LifecycleEventTypeTask is the type of the lifecycle events of tasks
+	LifecycleEventTypeTask = "task"
+	// LifecycleEventTypeContainer is the type of the lifecycle events of containers
+	LifecycleEventTypeContainer = "container"
+
+	// lifecycleEventSubscriberBufferSize is the number of lifecycle events buffered for each subscriber. Events
+	// are dropped for a subscriber that does not keep up, so that subscribers never hold up the submission of
+	// the state changes to ECS.
+	lifecycleEventSubscriberBufferSize = 100
+)
+
+// LifecycleEvent is a state transition of a task or a container, as streamed to local consumers
+type LifecycleEvent struct {
+	Type          string    `json:"type"`
+	TaskARN       string    `json:"taskArn"`
+	ContainerName string    `json:"containerName,omitempty"`
+	RuntimeID     string    `json:"runtimeId,omitempty"`
+	Status        string    `json:"status"`
+	Reason        string    `json:"reason,omitempty"`
+	ExitCode      *int      `json:"exitCode,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// newLifecycleEvent returns the lifecycle event of a task or container state change. It returns false for the
+// other state changes.
+func newLifecycleEvent(change statechange.Event, timestamp time.Time) (LifecycleEvent, bool) {
+	switch event := change.(type) {
+	case api.TaskStateChange:
+		return LifecycleEvent{
+			Type:      LifecycleEventTypeTask,
+			TaskARN:   event.TaskARN,
+			Status:    event.Status.String(),
+			Reason:    event.Reason,
+			Timestamp: timestamp,
+		}, true
+	case api.ContainerStateChange:
+		return LifecycleEvent{
+			Type:          LifecycleEventTypeContainer,
+			TaskARN:       event.TaskArn,
+			ContainerName: event.ContainerName,
+			RuntimeID:     event.RuntimeID,
+			Status:        event.Status.String(),
+			Reason:        event.Reason,
+			ExitCode:      event.ExitCode,
+			Timestamp:     timestamp,
+		}, true
+	default:
+		return LifecycleEvent{}, false
+	}
+}
+
+// LifecycleEventBroadcaster fans the task and container state changes emitted by the engine out to local
+// subscribers
+type LifecycleEventBroadcaster struct {
+	subscribers      map[int]chan LifecycleEvent
+	nextSubscriberID int
+	lock             sync.RWMutex
+}
+
+// NewLifecycleEventBroadcaster returns a broadcaster without subscribers
+func NewLifecycleEventBroadcaster() *LifecycleEventBroadcaster {
+	return &LifecycleEventBroadcaster{
+		subscribers: make(map[int]chan LifecycleEvent),
+	}
+}
+
+// Subscribe returns the channel on which the lifecycle events are sent to a new subscriber, along with the
+// function unsubscribing it, which closes the channel
+func (broadcaster *LifecycleEventBroadcaster) Subscribe() (<-chan LifecycleEvent, func()) {
+	broadcaster.lock.Lock()
+	defer broadcaster.lock.Unlock()
+	id := broadcaster.nextSubscriberID
+	broadcaster.nextSubscriberID++
+	events := make(chan LifecycleEvent, lifecycleEventSubscriberBufferSize)
+	broadcaster.subscribers[id] = events
+
+	var once sync.Once
+	return events, func() {
+		once.Do(func() {
+			broadcaster.lock.Lock()
+			defer broadcaster.lock.Unlock()
+			delete(broadcaster.subscribers, id)
+			close(events)
+		})
+	}
+}
+
+// Publish sends the lifecycle event of the state change to all subscribers. It never blocks: the event is
+// dropped for the subscribers whose buffer is full.
+func (broadcaster *LifecycleEventBroadcaster) Publish(change statechange.Event) {
+	event, ok := newLifecycleEvent(change, time.Now())
+	if !ok {
+		return
+	}
+	broadcaster.lock.RLock()
+	defer broadcaster.lock.RUnlock()
+	for id, events := range broadcaster.subscribers {
+		select {
+		case events <- event:
+		default:
+			seelog.Warnf("Lifecycle event subscriber %d is not keeping up, dropping %s event of task %s",
+				id, event.Type, event.TaskARN)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package eventhandler
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	apicontainerstatus "github.com/aws/amazon-ecs-agent/agent/api/container/status"
+	apitaskstatus "github.com/aws/amazon-ecs-agent/agent/api/task/status"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLifecycleEventBroadcasterSubscribersReceiveSameEvents(t *testing.T) {
+	broadcaster := NewLifecycleEventBroadcaster()
+	first, unsubscribeFirst := broadcaster.Subscribe()
+	defer unsubscribeFirst()
+	second, unsubscribeSecond := broadcaster.Subscribe()
+	defer unsubscribeSecond()
+
+	exitCode := 1
+	broadcaster.Publish(api.ContainerStateChange{
+		TaskArn:       taskARN,
+		ContainerName: "container",
+		RuntimeID:     "runtime-id",
+		Status:        apicontainerstatus.ContainerStopped,
+		Reason:        "exited",
+		ExitCode:      &exitCode,
+	})
+	broadcaster.Publish(api.TaskStateChange{
+		TaskARN: taskARN,
+		Status:  apitaskstatus.TaskStopped,
+	})
+	// Attachment state changes are not lifecycle events
+	broadcaster.Publish(api.AttachmentStateChange{})
+
+	for _, events := range []<-chan LifecycleEvent{first, second} {
+		require.Len(t, events, 2)
+		containerEvent := <-events
+		assert.Equal(t, LifecycleEventTypeContainer, containerEvent.Type)
+		assert.Equal(t, taskARN, containerEvent.TaskARN)
+		assert.Equal(t, "container", containerEvent.ContainerName)
+		assert.Equal(t, "runtime-id", containerEvent.RuntimeID)
+		assert.Equal(t, apicontainerstatus.ContainerStopped.String(), containerEvent.Status)
+		assert.Equal(t, "exited", containerEvent.Reason)
+		require.NotNil(t, containerEvent.ExitCode)
+		assert.Equal(t, 1, *containerEvent.ExitCode)
+
+		taskEvent := <-events
+		assert.Equal(t, LifecycleEventTypeTask, taskEvent.Type)
+		assert.Equal(t, taskARN, taskEvent.TaskARN)
+		assert.Equal(t, apitaskstatus.TaskStopped.String(), taskEvent.Status)
+	}
+}
+
+func TestLifecycleEventBroadcasterDropsEventsForSlowSubscribers(t *testing.T) {
+	broadcaster := NewLifecycleEventBroadcaster()
+	events, unsubscribe := broadcaster.Subscribe()
+
+	for i := 0; i < lifecycleEventSubscriberBufferSize+1; i++ {
+		broadcaster.Publish(api.TaskStateChange{TaskARN: taskARN, Status: apitaskstatus.TaskRunning})
+	}
+	assert.Len(t, events, lifecycleEventSubscriberBufferSize)
+
+	unsubscribe()
+	// Unsubscribing twice is safe, and events are no longer sent after unsubscribing
+	unsubscribe()
+	broadcaster.Publish(api.TaskStateChange{TaskARN: taskARN, Status: apitaskstatus.TaskStopped})
+	assert.Len(t, events, lifecycleEventSubscriberBufferSize)
+}
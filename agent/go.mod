@@ -14,7 +14,7 @@ require (
 	github.com/containernetworking/plugins v0.8.6
 	github.com/deniswernert/udev v0.0.0-20140626150257-82fe5be8ca5f
 	github.com/didip/tollbooth v3.0.2+incompatible
-	github.com/docker/distribution v0.0.0-20181002220433-1cb4180b1a5b // indirect
+	github.com/docker/distribution v0.0.0-20181002220433-1cb4180b1a5b
 	github.com/docker/docker v0.0.0-20200531234253-77e06fda0c94
 	github.com/docker/go-connections v0.4.0
 	github.com/docker/go-units v0.4.0
@@ -41,6 +41,7 @@ require (
 	go.etcd.io/bbolt v1.3.6
 	golang.org/x/net v0.0.0-20220624214902-1bab6f366d9e
 	golang.org/x/sys v0.0.0-20220624220833-87e55d714810
+	golang.org/x/time v0.0.0-20191024005414-555d28b269f0
 	golang.org/x/tools v0.1.5
 	google.golang.org/genproto v0.0.0-20220913154956-18f8339a66a5 // indirect
 	google.golang.org/grpc v1.48.0
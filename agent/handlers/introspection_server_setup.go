@@ -17,6 +17,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"net"
 	"net/http"
 	"net/http/pprof"
 	"strconv"
@@ -26,6 +27,7 @@ import (
 	"github.com/aws/amazon-ecs-agent/agent/engine"
 	handlersutils "github.com/aws/amazon-ecs-agent/agent/handlers/utils"
 	v1 "github.com/aws/amazon-ecs-agent/agent/handlers/v1"
+	"github.com/aws/amazon-ecs-agent/agent/stats"
 	"github.com/aws/amazon-ecs-agent/agent/utils/retry"
 	"github.com/cihub/seelog"
 )
@@ -55,9 +57,18 @@ var (
 	pprofTraceHandler   = pprof.Trace
 )
 
-func introspectionServerSetup(containerInstanceArn *string, taskEngine handlersutils.DockerStateResolver, cfg *config.Config) *http.Server {
-	paths := []string{v1.AgentMetadataPath, v1.TaskContainerMetadataPath, v1.LicensePath}
+func introspectionServerSetup(containerInstanceArn *string, taskEngine handlersutils.IntrospectionTaskEngine,
+	statsEngine stats.Engine, cfg *config.Config) *http.Server {
+	paths := []string{v1.AgentMetadataPath, v1.TaskContainerMetadataPath, v1.LicensePath, v1.DrainPath,
+		v1.ImageCleanupHistoryPath, v1.ImageCleanupPath, v1.ImageCleanupEligibilityPath, v1.TaskStatsPath,
+		v1.HealthPath, v1.ImagePinsPath, v1.ImageCleanupOrderPath}
 
+	if cfg.IntrospectionContainerLogsEnabled.Enabled() {
+		paths = append(paths, v1.ContainerLogsPath)
+	}
+	if cfg.IntrospectionImageQuarantineEnabled.Enabled() {
+		paths = append(paths, v1.ImageQuarantinePath)
+	}
 	if cfg.EnableRuntimeStats.Enabled() {
 		paths = append(paths, pprofBasePath, pprofCMDLinePath, pprofProfilePath, pprofSymbolPath, pprofTracePath)
 	}
@@ -76,7 +87,7 @@ func introspectionServerSetup(containerInstanceArn *string, taskEngine handlersu
 	serverMux := http.NewServeMux()
 	serverMux.HandleFunc("/", defaultHandler)
 
-	v1HandlersSetup(serverMux, containerInstanceArn, taskEngine, cfg)
+	v1HandlersSetup(serverMux, containerInstanceArn, taskEngine, statsEngine, cfg)
 	pprofHandlerSetup(serverMux, cfg)
 
 	// Log all requests and then pass through to serverMux
@@ -100,11 +111,43 @@ func introspectionServerSetup(containerInstanceArn *string, taskEngine handlersu
 // v1HandlersSetup adds all handlers except CredentialsHandler in v1 package to the server mux.
 func v1HandlersSetup(serverMux *http.ServeMux,
 	containerInstanceArn *string,
-	taskEngine handlersutils.DockerStateResolver,
+	taskEngine handlersutils.IntrospectionTaskEngine,
+	statsEngine stats.Engine,
 	cfg *config.Config) {
-	serverMux.HandleFunc(v1.AgentMetadataPath, v1.AgentMetadataHandler(containerInstanceArn, cfg))
+	serverMux.HandleFunc(v1.AgentMetadataPath, v1.AgentMetadataHandler(containerInstanceArn, taskEngine, taskEngine, cfg))
 	serverMux.HandleFunc(v1.TaskContainerMetadataPath, v1.TaskContainerMetadataHandler(taskEngine))
 	serverMux.HandleFunc(v1.LicensePath, v1.LicenseHandler)
+	// Draining stops the agent from accepting tasks, only let callers on the instance itself do it
+	serverMux.HandleFunc(v1.DrainPath, loopbackOnly(v1.DrainHandler(taskEngine)))
+	serverMux.HandleFunc(v1.ImageCleanupHistoryPath, v1.ImageCleanupHistoryHandler(taskEngine))
+	serverMux.HandleFunc(v1.ImageCleanupPath, loopbackOnly(v1.ImageCleanupHandler(taskEngine)))
+	serverMux.HandleFunc(v1.ImageCleanupEligibilityPath, v1.ImageCleanupEligibilityHandler(taskEngine))
+	serverMux.HandleFunc(v1.TaskStatsPath, v1.TaskStatsHandler(taskEngine, statsEngine))
+	if cfg.IntrospectionContainerLogsEnabled.Enabled() {
+		// Container logs may hold application secrets, only hand them out to callers on the instance itself
+		serverMux.HandleFunc(v1.ContainerLogsPathPrefix, loopbackOnly(v1.ContainerLogsHandler(taskEngine, taskEngine)))
+	}
+	if cfg.IntrospectionImageQuarantineEnabled.Enabled() {
+		// Quarantining an image stops the agent from creating containers from it
+		serverMux.HandleFunc(v1.ImageQuarantinePath, loopbackOnly(v1.ImageQuarantineHandler(taskEngine)))
+	}
+	serverMux.HandleFunc(v1.HealthPath, v1.HealthHandler(taskEngine))
+	// Pinning an image keeps it from being cleaned up, only let callers on the instance itself do it
+	serverMux.HandleFunc(v1.ImagePinsPath, loopbackOnly(v1.ImagePinsHandler(taskEngine)))
+	serverMux.HandleFunc(v1.ImageCleanupOrderPath, v1.ImageCleanupOrderHandler(taskEngine))
+}
+
+// loopbackOnly wraps a handler so that it rejects requests which don't come from the loopback interface.
+func loopbackOnly(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if ip := net.ParseIP(host); err != nil || ip == nil || !ip.IsLoopback() {
+			seelog.Warnf("Rejecting introspection request for %s from non-loopback address %s", r.URL.Path, r.RemoteAddr)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		handler(w, r)
+	}
 }
 
 func pprofHandlerSetup(serverMux *http.ServeMux, cfg *config.Config) {
@@ -121,12 +164,13 @@ func pprofHandlerSetup(serverMux *http.ServeMux, cfg *config.Config) {
 // ServeIntrospectionHTTPEndpoint serves information about this agent/containerInstance and tasks
 // running on it. "V1" here indicates the hostname version of this server instead
 // of the handler versions, i.e. "V1" server can include "V1" and "V2" handlers.
-func ServeIntrospectionHTTPEndpoint(ctx context.Context, containerInstanceArn *string, taskEngine engine.TaskEngine, cfg *config.Config) {
+func ServeIntrospectionHTTPEndpoint(ctx context.Context, containerInstanceArn *string, taskEngine engine.TaskEngine,
+	statsEngine stats.Engine, cfg *config.Config) {
 	// Is this the right level to type assert, assuming we'd abstract multiple taskengines here?
 	// Revisit if we ever add another type..
 	dockerTaskEngine := taskEngine.(*engine.DockerTaskEngine)
 
-	server := introspectionServerSetup(containerInstanceArn, dockerTaskEngine, cfg)
+	server := introspectionServerSetup(containerInstanceArn, dockerTaskEngine, statsEngine, cfg)
 
 	go func() {
 		<-ctx.Done()
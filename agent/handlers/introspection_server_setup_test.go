@@ -18,11 +18,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
 	apieni "github.com/aws/amazon-ecs-agent/agent/api/eni"
@@ -30,9 +32,14 @@ import (
 	apitaskstatus "github.com/aws/amazon-ecs-agent/agent/api/task/status"
 	"github.com/aws/amazon-ecs-agent/agent/config"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+	"github.com/aws/amazon-ecs-agent/agent/engine/image"
 	mock_utils "github.com/aws/amazon-ecs-agent/agent/handlers/mocks"
 	v1 "github.com/aws/amazon-ecs-agent/agent/handlers/v1"
+	"github.com/aws/amazon-ecs-agent/agent/stats"
+	mock_stats "github.com/aws/amazon-ecs-agent/agent/stats/mock"
 	"github.com/aws/amazon-ecs-agent/agent/utils"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/docker/docker/api/types"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -46,8 +53,46 @@ const (
 
 var runtimeStatsConfigForTest = config.BooleanDefaultFalse{}
 
+// introspectionTaskEngineMock composes the mocks of the sub-interfaces of the task engine used by the
+// introspection server, so that tests only set expectations on the ones they exercise
+type introspectionTaskEngineMock struct {
+	*mock_utils.MockContainerLogsProvider
+	*mock_utils.MockDockerStateResolver
+	*mock_utils.MockHealthChecker
+	*mock_utils.MockImageCleanupEligibilityProvider
+	*mock_utils.MockImageCleanupHistoryProvider
+	*mock_utils.MockImageCleanupOrderProvider
+	*mock_utils.MockImageCleanupRunner
+	*mock_utils.MockImagePinner
+	*mock_utils.MockImageQuarantiner
+	*mock_utils.MockTaskEngineDrainer
+}
+
+func newIntrospectionTaskEngineMock(ctrl *gomock.Controller) *introspectionTaskEngineMock {
+	return &introspectionTaskEngineMock{
+		MockContainerLogsProvider:           mock_utils.NewMockContainerLogsProvider(ctrl),
+		MockDockerStateResolver:             mock_utils.NewMockDockerStateResolver(ctrl),
+		MockHealthChecker:                   mock_utils.NewMockHealthChecker(ctrl),
+		MockImageCleanupEligibilityProvider: mock_utils.NewMockImageCleanupEligibilityProvider(ctrl),
+		MockImageCleanupHistoryProvider:     mock_utils.NewMockImageCleanupHistoryProvider(ctrl),
+		MockImageCleanupOrderProvider:       mock_utils.NewMockImageCleanupOrderProvider(ctrl),
+		MockImageCleanupRunner:              mock_utils.NewMockImageCleanupRunner(ctrl),
+		MockImagePinner:                     mock_utils.NewMockImagePinner(ctrl),
+		MockImageQuarantiner:                mock_utils.NewMockImageQuarantiner(ctrl),
+		MockTaskEngineDrainer:               mock_utils.NewMockTaskEngineDrainer(ctrl),
+	}
+}
+
 func TestMetadataHandler(t *testing.T) {
-	metadataHandler := v1.AgentMetadataHandler(utils.Strptr(testContainerInstanceArn), &config.Config{Cluster: testClusterArn})
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDrainer := mock_utils.NewMockTaskEngineDrainer(ctrl)
+	mockDrainer.EXPECT().IsDrained().Return(false)
+	mockImageCleanupHistory := mock_utils.NewMockImageCleanupHistoryProvider(ctrl)
+	mockImageCleanupHistory.EXPECT().ImageCleanupHistory().Return(nil)
+	metadataHandler := v1.AgentMetadataHandler(utils.Strptr(testContainerInstanceArn), mockDrainer,
+		mockImageCleanupHistory, &config.Config{Cluster: testClusterArn})
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "http://localhost:"+strconv.Itoa(config.AgentIntrospectionPort), nil)
@@ -62,6 +107,851 @@ func TestMetadataHandler(t *testing.T) {
 	if *resp.ContainerInstanceArn != testContainerInstanceArn {
 		t.Error("Metadata returned the wrong cluster arn")
 	}
+	if resp.Drained {
+		t.Error("Metadata returned the wrong drain state")
+	}
+	assert.Nil(t, resp.LastImageCleanupTime, "No image cleanup has run yet")
+	assert.Nil(t, resp.LastImageCleanupRemovedCount, "No image cleanup has run yet")
+}
+
+func TestMetadataHandlerLastImageCleanup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lastCleanupAt := time.Date(2020, 1, 1, 1, 0, 0, 0, time.UTC)
+	mockDrainer := mock_utils.NewMockTaskEngineDrainer(ctrl)
+	mockDrainer.EXPECT().IsDrained().Return(false)
+	mockImageCleanupHistory := mock_utils.NewMockImageCleanupHistoryProvider(ctrl)
+	mockImageCleanupHistory.EXPECT().ImageCleanupHistory().Return([]image.CleanupCycleStats{
+		{
+			StartedAt:     lastCleanupAt.Add(-time.Hour),
+			ImagesRemoved: 3,
+		},
+		{
+			StartedAt:     lastCleanupAt,
+			ImagesRemoved: 1,
+			Error:         "unable to remove image sha256:abc: conflict",
+		},
+	})
+	metadataHandler := v1.AgentMetadataHandler(utils.Strptr(testContainerInstanceArn), mockDrainer,
+		mockImageCleanupHistory, &config.Config{Cluster: testClusterArn})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", v1.AgentMetadataPath, nil)
+	metadataHandler(w, req)
+
+	var resp v1.MetadataResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.NotNil(t, resp.LastImageCleanupTime)
+	assert.True(t, lastCleanupAt.Equal(*resp.LastImageCleanupTime))
+	require.NotNil(t, resp.LastImageCleanupRemovedCount)
+	assert.Equal(t, 1, *resp.LastImageCleanupRemovedCount)
+	assert.Equal(t, "unable to remove image sha256:abc: conflict", resp.LastImageCleanupError)
+}
+
+func TestMetadataHandlerDrained(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDrainer := mock_utils.NewMockTaskEngineDrainer(ctrl)
+	mockDrainer.EXPECT().IsDrained().Return(true)
+	mockImageCleanupHistory := mock_utils.NewMockImageCleanupHistoryProvider(ctrl)
+	mockImageCleanupHistory.EXPECT().ImageCleanupHistory().Return(nil)
+	metadataHandler := v1.AgentMetadataHandler(utils.Strptr(testContainerInstanceArn), mockDrainer,
+		mockImageCleanupHistory, &config.Config{Cluster: testClusterArn})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "http://localhost:"+strconv.Itoa(config.AgentIntrospectionPort), nil)
+	metadataHandler(w, req)
+
+	var resp v1.MetadataResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, resp.Drained)
+}
+
+func TestDrainHandler(t *testing.T) {
+	testCases := []struct {
+		method          string
+		expectedDrained *bool
+		expectedCode    int
+	}{
+		{method: http.MethodPost, expectedDrained: aws.Bool(true), expectedCode: http.StatusOK},
+		{method: http.MethodDelete, expectedDrained: aws.Bool(false), expectedCode: http.StatusOK},
+		{method: http.MethodGet, expectedCode: http.StatusOK},
+		{method: http.MethodPut, expectedCode: http.StatusMethodNotAllowed},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.method, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockDrainer := mock_utils.NewMockTaskEngineDrainer(ctrl)
+			drained := false
+			if tc.expectedDrained != nil {
+				mockDrainer.EXPECT().SetDrained(*tc.expectedDrained).Do(func(value bool) { drained = value })
+			}
+			if tc.expectedCode == http.StatusOK {
+				mockDrainer.EXPECT().IsDrained().DoAndReturn(func() bool { return drained })
+			}
+
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, v1.DrainPath, nil)
+			v1.DrainHandler(mockDrainer)(recorder, req)
+
+			assert.Equal(t, tc.expectedCode, recorder.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			var resp v1.DrainResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+			assert.Equal(t, drained, resp.Drained)
+		})
+	}
+}
+
+func TestDrainHandlerAccess(t *testing.T) {
+	testCases := []struct {
+		name           string
+		remoteAddr     string
+		expectedStatus int
+	}{
+		{
+			name:           "off-host caller",
+			remoteAddr:     "10.0.0.12:12345",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "loopback caller",
+			remoteAddr:     "127.0.0.1:12345",
+			expectedStatus: http.StatusOK,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			taskEngine := newIntrospectionTaskEngineMock(ctrl)
+			if tc.expectedStatus == http.StatusOK {
+				taskEngine.MockTaskEngineDrainer.EXPECT().SetDrained(true)
+				taskEngine.MockTaskEngineDrainer.EXPECT().IsDrained().Return(true)
+			}
+			requestHandler := introspectionServerSetup(utils.Strptr(testContainerInstanceArn), taskEngine,
+				mock_stats.NewMockEngine(ctrl), &config.Config{})
+
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, v1.DrainPath, nil)
+			req.RemoteAddr = tc.remoteAddr
+			requestHandler.Handler.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.expectedStatus, recorder.Code)
+		})
+	}
+}
+
+func TestImageCleanupHistoryHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	startedAt := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockImageCleanupHistory := mock_utils.NewMockImageCleanupHistoryProvider(ctrl)
+	mockImageCleanupHistory.EXPECT().ImageCleanupHistory().Return([]image.CleanupCycleStats{
+		{
+			ID:                  "cycle-1",
+			StartedAt:           startedAt,
+			Duration:            1500 * time.Millisecond,
+			CandidatesEvaluated: 3,
+			ImagesRemoved:       1,
+			BytesReclaimed:      1024,
+			SkipReasons:         map[string]int{image.CleanupSkipReasonInUse: 2},
+		},
+	})
+
+	recorder := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", v1.ImageCleanupHistoryPath, nil)
+	v1.ImageCleanupHistoryHandler(mockImageCleanupHistory)(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var resp v1.ImageCleanupHistoryResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	require.Len(t, resp.Cycles, 1)
+	assert.Equal(t, "cycle-1", resp.Cycles[0].CycleID)
+	assert.True(t, startedAt.Equal(resp.Cycles[0].StartedAt))
+	assert.Equal(t, int64(1500), resp.Cycles[0].DurationMillis)
+	assert.Equal(t, 3, resp.Cycles[0].CandidatesEvaluated)
+	assert.Equal(t, 1, resp.Cycles[0].ImagesRemoved)
+	assert.Equal(t, int64(1024), resp.Cycles[0].BytesReclaimed)
+	assert.Equal(t, map[string]int{image.CleanupSkipReasonInUse: 2}, resp.Cycles[0].SkipReasons)
+}
+
+func TestImageCleanupHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockImageCleanupRunner := mock_utils.NewMockImageCleanupRunner(ctrl)
+	mockImageCleanupRunner.EXPECT().RunImageCleanup(gomock.Any()).Return("cycle-1", nil)
+	taskEngine := newIntrospectionTaskEngineMock(ctrl)
+	taskEngine.MockImageCleanupRunner = mockImageCleanupRunner
+	requestHandler := introspectionServerSetup(utils.Strptr(testContainerInstanceArn), taskEngine,
+		mock_stats.NewMockEngine(ctrl), &config.Config{})
+
+	// Only callers on the instance itself can run a cleanup cycle
+	recorder := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, v1.ImageCleanupPath, nil)
+	req.RemoteAddr = "10.0.0.12:12345"
+	requestHandler.Handler.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, v1.ImageCleanupPath, nil)
+	req.RemoteAddr = "127.0.0.1:12345"
+	requestHandler.Handler.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+	var resp v1.ImageCleanupStartedResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	assert.Equal(t, "cycle-1", resp.CycleID)
+}
+
+func TestImageCleanupHandlerErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockImageCleanupRunner := mock_utils.NewMockImageCleanupRunner(ctrl)
+	mockImageCleanupRunner.EXPECT().RunImageCleanup(gomock.Any()).Return("", errors.New("image cleanup is disabled"))
+
+	recorder := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, v1.ImageCleanupPath, nil)
+	v1.ImageCleanupHandler(mockImageCleanupRunner)(recorder, req)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	// Only POST runs a cleanup cycle
+	recorder = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, v1.ImageCleanupPath, nil)
+	v1.ImageCleanupHandler(mockImageCleanupRunner)(recorder, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+	assert.Equal(t, "POST", recorder.Header().Get("Allow"))
+}
+
+func TestImageCleanupEligibilityHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockImageCleanupEligibility := mock_utils.NewMockImageCleanupEligibilityProvider(ctrl)
+	mockImageCleanupEligibility.EXPECT().ImageCleanupEligibility("busybox:latest").Return(image.CleanupEligibility{
+		ImageID:            "sha256:busybox",
+		Names:              []string{"busybox:latest"},
+		TimeUntilOldEnough: 90 * time.Second,
+		LRUPosition:        2,
+	}, true)
+	taskEngine := newIntrospectionTaskEngineMock(ctrl)
+	taskEngine.MockImageCleanupEligibilityProvider = mockImageCleanupEligibility
+	requestHandler := introspectionServerSetup(utils.Strptr(testContainerInstanceArn), taskEngine,
+		mock_stats.NewMockEngine(ctrl), &config.Config{})
+
+	recorder := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, v1.ImageCleanupEligibilityPath+"?image=busybox:latest", nil)
+	requestHandler.Handler.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var resp v1.ImageCleanupEligibilityResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	assert.Equal(t, "sha256:busybox", resp.ImageID)
+	assert.Equal(t, []string{"busybox:latest"}, resp.Names)
+	assert.False(t, resp.Eligible)
+	assert.Equal(t, int64(90000), resp.MillisUntilOldEnough)
+	assert.Equal(t, 2, resp.LRUPosition)
+}
+
+func TestImageCleanupOrderHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lastUsedAt := time.Date(2021, time.March, 1, 10, 0, 0, 0, time.UTC)
+	order := []image.CleanupEligibility{
+		{
+			ImageID:     "sha256:busybox",
+			Names:       []string{"busybox:latest"},
+			LastUsedAt:  lastUsedAt,
+			Eligible:    true,
+			LRUPosition: 1,
+		},
+		{
+			ImageID:            "sha256:nginx",
+			Names:              []string{"nginx:latest"},
+			LastUsedAt:         lastUsedAt.Add(time.Hour),
+			TimeUntilOldEnough: 90 * time.Second,
+			Pinned:             true,
+			LRUPosition:        2,
+		},
+	}
+	mockImageCleanupOrder := mock_utils.NewMockImageCleanupOrderProvider(ctrl)
+	mockImageCleanupOrder.EXPECT().ImageCleanupOrder().Return(order)
+	taskEngine := newIntrospectionTaskEngineMock(ctrl)
+	taskEngine.MockImageCleanupOrderProvider = mockImageCleanupOrder
+	requestHandler := introspectionServerSetup(utils.Strptr(testContainerInstanceArn), taskEngine,
+		mock_stats.NewMockEngine(ctrl), &config.Config{})
+
+	recorder := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, v1.ImageCleanupOrderPath, nil)
+	requestHandler.Handler.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	expectedJSON, err := json.Marshal(v1.NewImageCleanupOrderResponse(order))
+	require.NoError(t, err)
+	assert.JSONEq(t, string(expectedJSON), recorder.Body.String())
+
+	var resp v1.ImageCleanupOrderResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	require.Len(t, resp.Images, 2)
+	assert.Equal(t, "sha256:busybox", resp.Images[0].ImageID)
+	require.NotNil(t, resp.Images[0].LastUsedAt)
+	assert.True(t, lastUsedAt.Equal(*resp.Images[0].LastUsedAt))
+	assert.True(t, resp.Images[0].Eligible)
+	assert.Equal(t, "sha256:nginx", resp.Images[1].ImageID)
+	assert.True(t, resp.Images[1].Pinned)
+	assert.Equal(t, 2, resp.Images[1].LRUPosition)
+}
+
+func TestImageCleanupEligibilityHandlerErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockImageCleanupEligibility := mock_utils.NewMockImageCleanupEligibilityProvider(ctrl)
+	mockImageCleanupEligibility.EXPECT().ImageCleanupEligibility("unknown").Return(image.CleanupEligibility{}, false)
+
+	recorder := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, v1.ImageCleanupEligibilityPath+"?image=unknown", nil)
+	v1.ImageCleanupEligibilityHandler(mockImageCleanupEligibility)(recorder, req)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "not tracked")
+
+	recorder = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, v1.ImageCleanupEligibilityPath, nil)
+	v1.ImageCleanupEligibilityHandler(mockImageCleanupEligibility)(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestTaskStatsHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	state := dockerstate.NewTaskEngineState()
+	stateSetupHelper(state, []*apitask.Task{
+		{
+			Arn: "task1",
+			Containers: []*apicontainer.Container{
+				{Name: "web"},
+				{Name: "sidecar"},
+			},
+		},
+	})
+	mockStateResolver := mock_utils.NewMockDockerStateResolver(ctrl)
+	mockStateResolver.EXPECT().State().Return(state)
+
+	webStats := &types.StatsJSON{}
+	webStats.Read = time.Unix(1600000000, 0).UTC()
+	webStats.MemoryStats.Usage = 64 * 1024 * 1024
+	webStats.MemoryStats.Limit = 512 * 1024 * 1024
+	mockStatsEngine := mock_stats.NewMockEngine(ctrl)
+	mockStatsEngine.EXPECT().ContainerDockerStats("task1", "dockerid-task1-web").Return(webStats, nil, nil)
+	// The sidecar has no stats collected yet and is left out of the response.
+	mockStatsEngine.EXPECT().ContainerDockerStats("task1", "dockerid-task1-sidecar").
+		Return(nil, nil, errors.New("no stats yet"))
+
+	taskEngine := newIntrospectionTaskEngineMock(ctrl)
+	taskEngine.MockDockerStateResolver = mockStateResolver
+	requestHandler := introspectionServerSetup(utils.Strptr(testContainerInstanceArn), taskEngine,
+		mockStatsEngine, &config.Config{})
+
+	recorder := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, v1.TaskStatsPath, nil)
+	requestHandler.Handler.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var resp v1.TasksStatsResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	require.Len(t, resp.Tasks, 1)
+	assert.Equal(t, "task1", resp.Tasks[0].Arn)
+	require.Len(t, resp.Tasks[0].Containers, 1)
+	containerStats := resp.Tasks[0].Containers[0]
+	assert.Equal(t, "dockerid-task1-web", containerStats.DockerID)
+	assert.Equal(t, "web", containerStats.Name)
+	assert.Equal(t, stats.CPUUsagePercent(webStats), containerStats.CPUPercent)
+	assert.Equal(t, stats.MemoryUsageBytes(webStats), containerStats.MemoryUsageBytes)
+	assert.Equal(t, uint64(512*1024*1024), containerStats.MemoryLimitBytes)
+	assert.Equal(t, webStats.Read, containerStats.ReadAt)
+}
+
+func TestContainerLogsHandler(t *testing.T) {
+	const taskArn = "arn:aws:ecs:us-west-2:123456789012:task/cluster/abc"
+	jsonFileHostConfig := `{"LogConfig":{"Type":"json-file"}}`
+	awslogsHostConfig := `{"LogConfig":{"Type":"awslogs"}}`
+	testTask := &apitask.Task{
+		Arn: taskArn,
+		Containers: []*apicontainer.Container{
+			{Name: "web", DockerConfig: apicontainer.DockerConfig{HostConfig: &jsonFileHostConfig}},
+			{Name: "sidecar", DockerConfig: apicontainer.DockerConfig{HostConfig: &awslogsHostConfig}},
+		},
+	}
+	webDockerID := "dockerid-" + taskArn + "-web"
+
+	testCases := []struct {
+		name           string
+		path           string
+		expectedTail   int
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "default tail",
+			path:           "/v1/tasks/" + taskArn + "/containers/web/logs",
+			expectedTail:   100,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "tail capped",
+			path:           "/v1/tasks/" + taskArn + "/containers/web/logs?tail=5000",
+			expectedTail:   1000,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid tail",
+			path:           "/v1/tasks/" + taskArn + "/containers/web/logs?tail=-1",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Invalid tail query field",
+		},
+		{
+			name:           "unsupported log driver",
+			path:           "/v1/tasks/" + taskArn + "/containers/sidecar/logs",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "log driver awslogs is not json-file or local",
+		},
+		{
+			name:           "unknown container",
+			path:           "/v1/tasks/" + taskArn + "/containers/unknown/logs",
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "Container unknown",
+		},
+		{
+			name:           "unknown task",
+			path:           "/v1/tasks/unknown/containers/web/logs",
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "Task unknown is not managed by the agent",
+		},
+		{
+			name:           "unknown path",
+			path:           "/v1/tasks/" + taskArn,
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "Unknown path",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			state := dockerstate.NewTaskEngineState()
+			stateSetupHelper(state, []*apitask.Task{testTask})
+			mockStateResolver := mock_utils.NewMockDockerStateResolver(ctrl)
+			mockStateResolver.EXPECT().State().Return(state).AnyTimes()
+			mockContainerLogs := mock_utils.NewMockContainerLogsProvider(ctrl)
+			if tc.expectedStatus == http.StatusOK {
+				mockContainerLogs.EXPECT().ContainerLogs(webDockerID, tc.expectedTail).Return([]byte("line 1\nline 2\n"), nil)
+			}
+			taskEngine := newIntrospectionTaskEngineMock(ctrl)
+			taskEngine.MockDockerStateResolver = mockStateResolver
+			taskEngine.MockContainerLogsProvider = mockContainerLogs
+			requestHandler := introspectionServerSetup(utils.Strptr(testContainerInstanceArn), taskEngine,
+				mock_stats.NewMockEngine(ctrl), &config.Config{
+					IntrospectionContainerLogsEnabled: config.BooleanDefaultFalse{Value: config.ExplicitlyEnabled},
+				})
+
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, tc.path, nil)
+			req.RemoteAddr = "127.0.0.1:12345"
+			requestHandler.Handler.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.expectedStatus, recorder.Code)
+			if tc.expectedStatus != http.StatusOK {
+				assert.Contains(t, recorder.Body.String(), tc.expectedError)
+				return
+			}
+			var resp v1.ContainerLogsResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+			assert.Equal(t, v1.ContainerLogsResponse{
+				DockerID: webDockerID,
+				Name:     "web",
+				Tail:     tc.expectedTail,
+				Logs:     "line 1\nline 2\n",
+			}, resp)
+		})
+	}
+}
+
+func TestContainerLogsHandlerAccess(t *testing.T) {
+	const taskArn = "arn:aws:ecs:us-west-2:123456789012:task/cluster/abc"
+	jsonFileHostConfig := `{"LogConfig":{"Type":"json-file"}}`
+	testTask := &apitask.Task{
+		Arn: taskArn,
+		Containers: []*apicontainer.Container{
+			{Name: "web", DockerConfig: apicontainer.DockerConfig{HostConfig: &jsonFileHostConfig}},
+		},
+	}
+	path := "/v1/tasks/" + taskArn + "/containers/web/logs"
+
+	testCases := []struct {
+		name           string
+		enabled        config.Conditional
+		remoteAddr     string
+		expectedStatus int
+	}{
+		{
+			name:           "disabled",
+			enabled:        config.NotSet,
+			remoteAddr:     "127.0.0.1:12345",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "enabled, off-host caller",
+			enabled:        config.ExplicitlyEnabled,
+			remoteAddr:     "10.0.0.12:12345",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "enabled, IPv6 loopback caller",
+			enabled:        config.ExplicitlyEnabled,
+			remoteAddr:     "[::1]:12345",
+			expectedStatus: http.StatusOK,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			state := dockerstate.NewTaskEngineState()
+			stateSetupHelper(state, []*apitask.Task{testTask})
+			mockStateResolver := mock_utils.NewMockDockerStateResolver(ctrl)
+			mockStateResolver.EXPECT().State().Return(state).AnyTimes()
+			mockContainerLogs := mock_utils.NewMockContainerLogsProvider(ctrl)
+			enabled := tc.enabled == config.ExplicitlyEnabled
+			if enabled && tc.expectedStatus == http.StatusOK {
+				mockContainerLogs.EXPECT().ContainerLogs(gomock.Any(), gomock.Any()).Return([]byte("line 1\n"), nil)
+			}
+			taskEngine := newIntrospectionTaskEngineMock(ctrl)
+			taskEngine.MockDockerStateResolver = mockStateResolver
+			taskEngine.MockContainerLogsProvider = mockContainerLogs
+			requestHandler := introspectionServerSetup(utils.Strptr(testContainerInstanceArn), taskEngine,
+				mock_stats.NewMockEngine(ctrl), &config.Config{
+					IntrospectionContainerLogsEnabled: config.BooleanDefaultFalse{Value: tc.enabled},
+				})
+
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, path, nil)
+			req.RemoteAddr = tc.remoteAddr
+			requestHandler.Handler.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.expectedStatus, recorder.Code)
+			if !enabled {
+				// The request falls through to the list of available commands
+				assert.NotContains(t, recorder.Body.String(), "/logs")
+				assert.NotContains(t, recorder.Body.String(), "line 1")
+			}
+		})
+	}
+}
+
+func TestImageQuarantineHandler(t *testing.T) {
+	const imageDigest = "sha256:3b0a4f5e8d7c6b5a49382716a5b4c3d2e1f0a9b8c7d6e5f4a3b2c1d0e9f8a7b6"
+	testCases := []struct {
+		name                 string
+		method               string
+		path                 string
+		disabled             bool
+		remoteAddr           string
+		setExpectations      func(quarantiner *mock_utils.MockImageQuarantiner)
+		expectedCode         int
+		expectedStoppedTasks []string
+	}{
+		{
+			name:   "quarantine",
+			method: http.MethodPost,
+			path:   v1.ImageQuarantinePath + "?image=" + imageDigest,
+			setExpectations: func(quarantiner *mock_utils.MockImageQuarantiner) {
+				quarantiner.EXPECT().QuarantineImage(imageDigest).Return([]string{taskARN}, nil)
+				quarantiner.EXPECT().QuarantinedImages().Return([]string{imageDigest})
+			},
+			expectedCode:         http.StatusOK,
+			expectedStoppedTasks: []string{taskARN},
+		},
+		{
+			name:   "unquarantine",
+			method: http.MethodDelete,
+			path:   v1.ImageQuarantinePath + "?image=" + imageDigest,
+			setExpectations: func(quarantiner *mock_utils.MockImageQuarantiner) {
+				quarantiner.EXPECT().UnquarantineImage(imageDigest).Return(nil)
+				quarantiner.EXPECT().QuarantinedImages().Return([]string{})
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:   "list",
+			method: http.MethodGet,
+			path:   v1.ImageQuarantinePath,
+			setExpectations: func(quarantiner *mock_utils.MockImageQuarantiner) {
+				quarantiner.EXPECT().QuarantinedImages().Return([]string{imageDigest})
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "missing image",
+			method:       http.MethodPost,
+			path:         v1.ImageQuarantinePath,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid image",
+			method: http.MethodPost,
+			path:   v1.ImageQuarantinePath + "?image=busybox",
+			setExpectations: func(quarantiner *mock_utils.MockImageQuarantiner) {
+				quarantiner.EXPECT().QuarantineImage("busybox").Return(nil, errors.New("invalid image reference"))
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "method not allowed",
+			method:       http.MethodPut,
+			path:         v1.ImageQuarantinePath,
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:         "off-host caller",
+			method:       http.MethodPost,
+			path:         v1.ImageQuarantinePath + "?image=" + imageDigest,
+			remoteAddr:   "10.0.0.12:12345",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:     "disabled",
+			method:   http.MethodPost,
+			path:     v1.ImageQuarantinePath + "?image=" + imageDigest,
+			disabled: true,
+			// The request falls through to the list of available commands
+			expectedCode: http.StatusOK,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockQuarantiner := mock_utils.NewMockImageQuarantiner(ctrl)
+			if tc.setExpectations != nil {
+				tc.setExpectations(mockQuarantiner)
+			}
+			taskEngine := newIntrospectionTaskEngineMock(ctrl)
+			taskEngine.MockImageQuarantiner = mockQuarantiner
+			enabled := config.ExplicitlyEnabled
+			if tc.disabled {
+				enabled = config.NotSet
+			}
+			requestHandler := introspectionServerSetup(utils.Strptr(testContainerInstanceArn), taskEngine,
+				mock_stats.NewMockEngine(ctrl), &config.Config{
+					IntrospectionImageQuarantineEnabled: config.BooleanDefaultFalse{Value: enabled},
+				})
+
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.path, nil)
+			req.RemoteAddr = "127.0.0.1:12345"
+			if tc.remoteAddr != "" {
+				req.RemoteAddr = tc.remoteAddr
+			}
+			requestHandler.Handler.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.expectedCode, recorder.Code)
+			if tc.disabled {
+				assert.NotContains(t, recorder.Body.String(), v1.ImageQuarantinePath)
+				return
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			var resp v1.ImageQuarantineResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+			assert.Equal(t, tc.expectedStoppedTasks, resp.StoppedTasks)
+		})
+	}
+}
+
+func TestHealthHandler(t *testing.T) {
+	testCases := []struct {
+		name             string
+		healthErr        error
+		expectedCode     int
+		expectedResponse v1.HealthResponse
+	}{
+		{
+			name:             "docker reachable",
+			expectedCode:     http.StatusOK,
+			expectedResponse: v1.HealthResponse{Healthy: true},
+		},
+		{
+			name:             "docker unreachable",
+			healthErr:        errors.New("docker daemon is unreachable: connection refused"),
+			expectedCode:     http.StatusServiceUnavailable,
+			expectedResponse: v1.HealthResponse{Reason: "docker daemon is unreachable: connection refused"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockHealthChecker := mock_utils.NewMockHealthChecker(ctrl)
+			mockHealthChecker.EXPECT().CheckHealth().Return(tc.healthErr)
+			taskEngine := newIntrospectionTaskEngineMock(ctrl)
+			taskEngine.MockHealthChecker = mockHealthChecker
+			requestHandler := introspectionServerSetup(utils.Strptr(testContainerInstanceArn), taskEngine,
+				mock_stats.NewMockEngine(ctrl), &config.Config{})
+
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, v1.HealthPath, nil)
+			requestHandler.Handler.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.expectedCode, recorder.Code)
+			var resp v1.HealthResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+			assert.Equal(t, tc.expectedResponse, resp)
+		})
+	}
+}
+
+func TestImagePinsHandler(t *testing.T) {
+	const imageName = "app:v1"
+	expiresAt := time.Date(2021, time.June, 1, 12, 30, 0, 0, time.UTC)
+	testCases := []struct {
+		name                 string
+		method               string
+		path                 string
+		remoteAddr           string
+		setExpectations      func(pinner *mock_utils.MockImagePinner)
+		expectedCode         int
+		expectedPinnedImages map[string]time.Time
+	}{
+		{
+			name:   "pin",
+			method: http.MethodPost,
+			path:   v1.ImagePinsPath + "?image=" + imageName + "&ttl=30m",
+			setExpectations: func(pinner *mock_utils.MockImagePinner) {
+				pinner.EXPECT().PinImage(imageName, 30*time.Minute).Return(expiresAt, nil)
+				pinner.EXPECT().PinnedImages().Return(map[string]time.Time{imageName: expiresAt})
+			},
+			expectedCode:         http.StatusOK,
+			expectedPinnedImages: map[string]time.Time{imageName: expiresAt},
+		},
+		{
+			name:   "unpin",
+			method: http.MethodDelete,
+			path:   v1.ImagePinsPath + "?image=" + imageName,
+			setExpectations: func(pinner *mock_utils.MockImagePinner) {
+				pinner.EXPECT().UnpinImage(imageName).Return(true)
+				pinner.EXPECT().PinnedImages().Return(nil)
+			},
+			expectedCode:         http.StatusOK,
+			expectedPinnedImages: map[string]time.Time{},
+		},
+		{
+			name:   "unpin image not pinned",
+			method: http.MethodDelete,
+			path:   v1.ImagePinsPath + "?image=" + imageName,
+			setExpectations: func(pinner *mock_utils.MockImagePinner) {
+				pinner.EXPECT().UnpinImage(imageName).Return(false)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:   "list",
+			method: http.MethodGet,
+			path:   v1.ImagePinsPath,
+			setExpectations: func(pinner *mock_utils.MockImagePinner) {
+				pinner.EXPECT().PinnedImages().Return(map[string]time.Time{imageName: expiresAt})
+			},
+			expectedCode:         http.StatusOK,
+			expectedPinnedImages: map[string]time.Time{imageName: expiresAt},
+		},
+		{
+			name:         "missing image",
+			method:       http.MethodPost,
+			path:         v1.ImagePinsPath + "?ttl=30m",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "missing ttl",
+			method:       http.MethodPost,
+			path:         v1.ImagePinsPath + "?image=" + imageName,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid ttl",
+			method:       http.MethodPost,
+			path:         v1.ImagePinsPath + "?image=" + imageName + "&ttl=soon",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:   "ttl out of bounds",
+			method: http.MethodPost,
+			path:   v1.ImagePinsPath + "?image=" + imageName + "&ttl=48h",
+			setExpectations: func(pinner *mock_utils.MockImagePinner) {
+				pinner.EXPECT().PinImage(imageName, 48*time.Hour).Return(time.Time{}, errors.New("invalid pin duration"))
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "method not allowed",
+			method:       http.MethodPut,
+			path:         v1.ImagePinsPath,
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:         "off-host caller",
+			method:       http.MethodPost,
+			path:         v1.ImagePinsPath + "?image=" + imageName + "&ttl=30m",
+			remoteAddr:   "10.0.0.12:12345",
+			expectedCode: http.StatusForbidden,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockPinner := mock_utils.NewMockImagePinner(ctrl)
+			if tc.setExpectations != nil {
+				tc.setExpectations(mockPinner)
+			}
+			taskEngine := newIntrospectionTaskEngineMock(ctrl)
+			taskEngine.MockImagePinner = mockPinner
+			requestHandler := introspectionServerSetup(utils.Strptr(testContainerInstanceArn), taskEngine,
+				mock_stats.NewMockEngine(ctrl), &config.Config{})
+
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.path, nil)
+			req.RemoteAddr = "127.0.0.1:12345"
+			if tc.remoteAddr != "" {
+				req.RemoteAddr = tc.remoteAddr
+			}
+			requestHandler.Handler.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tc.expectedCode, recorder.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			var resp v1.ImagePinsResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+			assert.Equal(t, tc.expectedPinnedImages, resp.PinnedImages)
+		})
+	}
 }
 
 func TestListMultipleTasks(t *testing.T) {
@@ -299,7 +1189,7 @@ func TestPProfHandlerSetup(t *testing.T) {
 					assert.Equal(t, p, recorder.Body.String())
 				} else {
 					assert.Equal(t, http.StatusOK, recorder.Code)
-					assert.Equal(t, `{"AvailableCommands":["/v1/metadata","/v1/tasks","/license"]}`, recorder.Body.String())
+					assert.Equal(t, `{"AvailableCommands":["/v1/metadata","/v1/tasks","/license","/v1/drain","/v1/imagecleanup","/v1/images/cleanup","/v1/imagecleanup/eligibility","/v1/tasks/stats","/v1/health","/v1/images/pins","/v1/imagecleanup/order"]}`, recorder.Body.String())
 
 				}
 			})
@@ -481,10 +1371,13 @@ func performMockRequest(t *testing.T, path string) *httptest.ResponseRecorder {
 		mockStateResolver.EXPECT().State().Return(state)
 	}
 
-	requestHandler := introspectionServerSetup(utils.Strptr(testContainerInstanceArn), mockStateResolver, &config.Config{
-		Cluster:            testClusterArn,
-		EnableRuntimeStats: runtimeStatsConfigForTest,
-	})
+	taskEngine := newIntrospectionTaskEngineMock(ctrl)
+	taskEngine.MockDockerStateResolver = mockStateResolver
+	requestHandler := introspectionServerSetup(utils.Strptr(testContainerInstanceArn), taskEngine,
+		mock_stats.NewMockEngine(ctrl), &config.Config{
+			Cluster:            testClusterArn,
+			EnableRuntimeStats: runtimeStatsConfigForTest,
+		})
 
 	recorder := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", path, nil)
@@ -0,0 +1,113 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/eventhandler"
+	"github.com/aws/amazon-ecs-agent/agent/utils/retry"
+	"github.com/cihub/seelog"
+)
+
+// LifecycleEventsPath is the path on which the task and container lifecycle events are streamed
+const LifecycleEventsPath = "/v1/lifecycle-events"
+
+func lifecycleEventsServerSetup(ctx context.Context, broadcaster *eventhandler.LifecycleEventBroadcaster) *http.Server {
+	serverMux := http.NewServeMux()
+	serverMux.HandleFunc(LifecycleEventsPath, lifecycleEventsHandler(broadcaster))
+
+	return &http.Server{
+		Handler:     serverMux,
+		ReadTimeout: readTimeout,
+		// Events are streamed until the client disconnects, so there is no write timeout
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+}
+
+// lifecycleEventsHandler streams the lifecycle events published after the request is received, as
+// newline-delimited JSON, until the client disconnects or the agent stops
+func lifecycleEventsHandler(broadcaster *eventhandler.LifecycleEventBroadcaster) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+			return
+		}
+
+		events, unsubscribe := broadcaster.Subscribe()
+		defer unsubscribe()
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		encoder := json.NewEncoder(w)
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case event := <-events:
+				if err := encoder.Encode(event); err != nil {
+					seelog.Debugf("Unable to write lifecycle event to subscriber: %v", err)
+					return
+				}
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// ServeLifecycleEventsEndpoint streams the task and container lifecycle events published to the broadcaster
+// over the unix socket at socketPath
+func ServeLifecycleEventsEndpoint(ctx context.Context, socketPath string,
+	broadcaster *eventhandler.LifecycleEventBroadcaster) {
+	server := lifecycleEventsServerSetup(ctx, broadcaster)
+
+	go func() {
+		<-ctx.Done()
+		if err := server.Shutdown(context.Background()); err != nil {
+			seelog.Infof("Lifecycle events server Shutdown: %v", err)
+		}
+	}()
+
+	retry.RetryWithBackoffCtx(ctx, retry.NewExponentialBackoff(time.Second, time.Minute, 0.2, 2), func() error {
+		// Remove the socket left behind by a previous run of the agent
+		if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+			seelog.Errorf("Unable to remove existing lifecycle events socket %s: %v", socketPath, err)
+			return err
+		}
+		listener, err := net.Listen("unix", socketPath)
+		if err != nil {
+			seelog.Errorf("Unable to listen on lifecycle events socket %s: %v", socketPath, err)
+			return err
+		}
+		if err := server.Serve(listener); err != http.ErrServerClosed {
+			seelog.Errorf("Error running lifecycle events endpoint: %v", err)
+			return err
+		}
+		// server was cleanly closed via context
+		return nil
+	})
+}
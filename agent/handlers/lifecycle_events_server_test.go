@@ -0,0 +1,76 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	apitaskstatus "github.com/aws/amazon-ecs-agent/agent/api/task/status"
+	"github.com/aws/amazon-ecs-agent/agent/eventhandler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLifecycleEventsStreamedToAllClients(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	broadcaster := eventhandler.NewLifecycleEventBroadcaster()
+	server := httptest.NewUnstartedServer(lifecycleEventsServerSetup(ctx, broadcaster).Handler)
+	server.Start()
+	defer server.Close()
+
+	var streams []*bufio.Scanner
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+LifecycleEventsPath, nil)
+		require.NoError(t, err)
+		// The response headers are only sent once the client is subscribed
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+		streams = append(streams, bufio.NewScanner(resp.Body))
+	}
+
+	broadcaster.Publish(api.TaskStateChange{TaskARN: "taskarn", Status: apitaskstatus.TaskRunning})
+	broadcaster.Publish(api.TaskStateChange{TaskARN: "taskarn", Status: apitaskstatus.TaskStopped})
+
+	for _, stream := range streams {
+		for _, status := range []apitaskstatus.TaskStatus{apitaskstatus.TaskRunning, apitaskstatus.TaskStopped} {
+			require.True(t, stream.Scan())
+			var event eventhandler.LifecycleEvent
+			require.NoError(t, json.Unmarshal(stream.Bytes(), &event))
+			assert.Equal(t, eventhandler.LifecycleEventTypeTask, event.Type)
+			assert.Equal(t, "taskarn", event.TaskARN)
+			assert.Equal(t, status.String(), event.Status)
+		}
+	}
+}
+
+func TestLifecycleEventsRejectsNonGetRequests(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, LifecycleEventsPath, nil)
+	require.NoError(t, err)
+	lifecycleEventsHandler(eventhandler.NewLifecycleEventBroadcaster())(recorder, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
@@ -13,18 +13,59 @@
 //
 
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/aws/amazon-ecs-agent/agent/handlers/utils (interfaces: DockerStateResolver)
+// Source: github.com/aws/amazon-ecs-agent/agent/handlers/utils (interfaces: ContainerLogsProvider,DockerStateResolver,HealthChecker,ImageCleanupEligibilityProvider,ImageCleanupHistoryProvider,ImageCleanupOrderProvider,ImageCleanupRunner,ImagePinner,ImageQuarantiner,TaskEngineDrainer)
 
 // Package mock_utils is a generated GoMock package.
 package mock_utils
 
 import (
+	context "context"
 	reflect "reflect"
+	time "time"
 
 	dockerstate "github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+	image "github.com/aws/amazon-ecs-agent/agent/engine/image"
 	gomock "github.com/golang/mock/gomock"
 )
 
+// MockContainerLogsProvider is a mock of ContainerLogsProvider interface
+type MockContainerLogsProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockContainerLogsProviderMockRecorder
+}
+
+// MockContainerLogsProviderMockRecorder is the mock recorder for MockContainerLogsProvider
+type MockContainerLogsProviderMockRecorder struct {
+	mock *MockContainerLogsProvider
+}
+
+// NewMockContainerLogsProvider creates a new mock instance
+func NewMockContainerLogsProvider(ctrl *gomock.Controller) *MockContainerLogsProvider {
+	mock := &MockContainerLogsProvider{ctrl: ctrl}
+	mock.recorder = &MockContainerLogsProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockContainerLogsProvider) EXPECT() *MockContainerLogsProviderMockRecorder {
+	return m.recorder
+}
+
+// ContainerLogs mocks base method
+func (m *MockContainerLogsProvider) ContainerLogs(arg0 string, arg1 int) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ContainerLogs", arg0, arg1)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ContainerLogs indicates an expected call of ContainerLogs
+func (mr *MockContainerLogsProviderMockRecorder) ContainerLogs(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ContainerLogs", reflect.TypeOf((*MockContainerLogsProvider)(nil).ContainerLogs), arg0, arg1)
+}
+
 // MockDockerStateResolver is a mock of DockerStateResolver interface
 type MockDockerStateResolver struct {
 	ctrl     *gomock.Controller
@@ -61,3 +102,371 @@ func (mr *MockDockerStateResolverMockRecorder) State() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "State", reflect.TypeOf((*MockDockerStateResolver)(nil).State))
 }
+
+// MockHealthChecker is a mock of HealthChecker interface
+type MockHealthChecker struct {
+	ctrl     *gomock.Controller
+	recorder *MockHealthCheckerMockRecorder
+}
+
+// MockHealthCheckerMockRecorder is the mock recorder for MockHealthChecker
+type MockHealthCheckerMockRecorder struct {
+	mock *MockHealthChecker
+}
+
+// NewMockHealthChecker creates a new mock instance
+func NewMockHealthChecker(ctrl *gomock.Controller) *MockHealthChecker {
+	mock := &MockHealthChecker{ctrl: ctrl}
+	mock.recorder = &MockHealthCheckerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockHealthChecker) EXPECT() *MockHealthCheckerMockRecorder {
+	return m.recorder
+}
+
+// CheckHealth mocks base method
+func (m *MockHealthChecker) CheckHealth() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckHealth")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckHealth indicates an expected call of CheckHealth
+func (mr *MockHealthCheckerMockRecorder) CheckHealth() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckHealth", reflect.TypeOf((*MockHealthChecker)(nil).CheckHealth))
+}
+
+// MockImageCleanupEligibilityProvider is a mock of ImageCleanupEligibilityProvider interface
+type MockImageCleanupEligibilityProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockImageCleanupEligibilityProviderMockRecorder
+}
+
+// MockImageCleanupEligibilityProviderMockRecorder is the mock recorder for MockImageCleanupEligibilityProvider
+type MockImageCleanupEligibilityProviderMockRecorder struct {
+	mock *MockImageCleanupEligibilityProvider
+}
+
+// NewMockImageCleanupEligibilityProvider creates a new mock instance
+func NewMockImageCleanupEligibilityProvider(ctrl *gomock.Controller) *MockImageCleanupEligibilityProvider {
+	mock := &MockImageCleanupEligibilityProvider{ctrl: ctrl}
+	mock.recorder = &MockImageCleanupEligibilityProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockImageCleanupEligibilityProvider) EXPECT() *MockImageCleanupEligibilityProviderMockRecorder {
+	return m.recorder
+}
+
+// ImageCleanupEligibility mocks base method
+func (m *MockImageCleanupEligibilityProvider) ImageCleanupEligibility(arg0 string) (image.CleanupEligibility, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImageCleanupEligibility", arg0)
+	ret0, _ := ret[0].(image.CleanupEligibility)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// ImageCleanupEligibility indicates an expected call of ImageCleanupEligibility
+func (mr *MockImageCleanupEligibilityProviderMockRecorder) ImageCleanupEligibility(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImageCleanupEligibility", reflect.TypeOf((*MockImageCleanupEligibilityProvider)(nil).ImageCleanupEligibility), arg0)
+}
+
+// MockImageCleanupHistoryProvider is a mock of ImageCleanupHistoryProvider interface
+type MockImageCleanupHistoryProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockImageCleanupHistoryProviderMockRecorder
+}
+
+// MockImageCleanupHistoryProviderMockRecorder is the mock recorder for MockImageCleanupHistoryProvider
+type MockImageCleanupHistoryProviderMockRecorder struct {
+	mock *MockImageCleanupHistoryProvider
+}
+
+// NewMockImageCleanupHistoryProvider creates a new mock instance
+func NewMockImageCleanupHistoryProvider(ctrl *gomock.Controller) *MockImageCleanupHistoryProvider {
+	mock := &MockImageCleanupHistoryProvider{ctrl: ctrl}
+	mock.recorder = &MockImageCleanupHistoryProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockImageCleanupHistoryProvider) EXPECT() *MockImageCleanupHistoryProviderMockRecorder {
+	return m.recorder
+}
+
+// ImageCleanupHistory mocks base method
+func (m *MockImageCleanupHistoryProvider) ImageCleanupHistory() []image.CleanupCycleStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImageCleanupHistory")
+	ret0, _ := ret[0].([]image.CleanupCycleStats)
+	return ret0
+}
+
+// ImageCleanupHistory indicates an expected call of ImageCleanupHistory
+func (mr *MockImageCleanupHistoryProviderMockRecorder) ImageCleanupHistory() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImageCleanupHistory", reflect.TypeOf((*MockImageCleanupHistoryProvider)(nil).ImageCleanupHistory))
+}
+
+// MockImageCleanupOrderProvider is a mock of ImageCleanupOrderProvider interface
+type MockImageCleanupOrderProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockImageCleanupOrderProviderMockRecorder
+}
+
+// MockImageCleanupOrderProviderMockRecorder is the mock recorder for MockImageCleanupOrderProvider
+type MockImageCleanupOrderProviderMockRecorder struct {
+	mock *MockImageCleanupOrderProvider
+}
+
+// NewMockImageCleanupOrderProvider creates a new mock instance
+func NewMockImageCleanupOrderProvider(ctrl *gomock.Controller) *MockImageCleanupOrderProvider {
+	mock := &MockImageCleanupOrderProvider{ctrl: ctrl}
+	mock.recorder = &MockImageCleanupOrderProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockImageCleanupOrderProvider) EXPECT() *MockImageCleanupOrderProviderMockRecorder {
+	return m.recorder
+}
+
+// ImageCleanupOrder mocks base method
+func (m *MockImageCleanupOrderProvider) ImageCleanupOrder() []image.CleanupEligibility {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImageCleanupOrder")
+	ret0, _ := ret[0].([]image.CleanupEligibility)
+	return ret0
+}
+
+// ImageCleanupOrder indicates an expected call of ImageCleanupOrder
+func (mr *MockImageCleanupOrderProviderMockRecorder) ImageCleanupOrder() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImageCleanupOrder", reflect.TypeOf((*MockImageCleanupOrderProvider)(nil).ImageCleanupOrder))
+}
+
+// MockImageCleanupRunner is a mock of ImageCleanupRunner interface
+type MockImageCleanupRunner struct {
+	ctrl     *gomock.Controller
+	recorder *MockImageCleanupRunnerMockRecorder
+}
+
+// MockImageCleanupRunnerMockRecorder is the mock recorder for MockImageCleanupRunner
+type MockImageCleanupRunnerMockRecorder struct {
+	mock *MockImageCleanupRunner
+}
+
+// NewMockImageCleanupRunner creates a new mock instance
+func NewMockImageCleanupRunner(ctrl *gomock.Controller) *MockImageCleanupRunner {
+	mock := &MockImageCleanupRunner{ctrl: ctrl}
+	mock.recorder = &MockImageCleanupRunnerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockImageCleanupRunner) EXPECT() *MockImageCleanupRunnerMockRecorder {
+	return m.recorder
+}
+
+// RunImageCleanup mocks base method
+func (m *MockImageCleanupRunner) RunImageCleanup(arg0 context.Context) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunImageCleanup", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunImageCleanup indicates an expected call of RunImageCleanup
+func (mr *MockImageCleanupRunnerMockRecorder) RunImageCleanup(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunImageCleanup", reflect.TypeOf((*MockImageCleanupRunner)(nil).RunImageCleanup), arg0)
+}
+
+// MockImagePinner is a mock of ImagePinner interface
+type MockImagePinner struct {
+	ctrl     *gomock.Controller
+	recorder *MockImagePinnerMockRecorder
+}
+
+// MockImagePinnerMockRecorder is the mock recorder for MockImagePinner
+type MockImagePinnerMockRecorder struct {
+	mock *MockImagePinner
+}
+
+// NewMockImagePinner creates a new mock instance
+func NewMockImagePinner(ctrl *gomock.Controller) *MockImagePinner {
+	mock := &MockImagePinner{ctrl: ctrl}
+	mock.recorder = &MockImagePinnerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockImagePinner) EXPECT() *MockImagePinnerMockRecorder {
+	return m.recorder
+}
+
+// PinImage mocks base method
+func (m *MockImagePinner) PinImage(arg0 string, arg1 time.Duration) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PinImage", arg0, arg1)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PinImage indicates an expected call of PinImage
+func (mr *MockImagePinnerMockRecorder) PinImage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PinImage", reflect.TypeOf((*MockImagePinner)(nil).PinImage), arg0, arg1)
+}
+
+// PinnedImages mocks base method
+func (m *MockImagePinner) PinnedImages() map[string]time.Time {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PinnedImages")
+	ret0, _ := ret[0].(map[string]time.Time)
+	return ret0
+}
+
+// PinnedImages indicates an expected call of PinnedImages
+func (mr *MockImagePinnerMockRecorder) PinnedImages() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PinnedImages", reflect.TypeOf((*MockImagePinner)(nil).PinnedImages))
+}
+
+// UnpinImage mocks base method
+func (m *MockImagePinner) UnpinImage(arg0 string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnpinImage", arg0)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// UnpinImage indicates an expected call of UnpinImage
+func (mr *MockImagePinnerMockRecorder) UnpinImage(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnpinImage", reflect.TypeOf((*MockImagePinner)(nil).UnpinImage), arg0)
+}
+
+// MockImageQuarantiner is a mock of ImageQuarantiner interface
+type MockImageQuarantiner struct {
+	ctrl     *gomock.Controller
+	recorder *MockImageQuarantinerMockRecorder
+}
+
+// MockImageQuarantinerMockRecorder is the mock recorder for MockImageQuarantiner
+type MockImageQuarantinerMockRecorder struct {
+	mock *MockImageQuarantiner
+}
+
+// NewMockImageQuarantiner creates a new mock instance
+func NewMockImageQuarantiner(ctrl *gomock.Controller) *MockImageQuarantiner {
+	mock := &MockImageQuarantiner{ctrl: ctrl}
+	mock.recorder = &MockImageQuarantinerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockImageQuarantiner) EXPECT() *MockImageQuarantinerMockRecorder {
+	return m.recorder
+}
+
+// QuarantineImage mocks base method
+func (m *MockImageQuarantiner) QuarantineImage(arg0 string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QuarantineImage", arg0)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QuarantineImage indicates an expected call of QuarantineImage
+func (mr *MockImageQuarantinerMockRecorder) QuarantineImage(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QuarantineImage", reflect.TypeOf((*MockImageQuarantiner)(nil).QuarantineImage), arg0)
+}
+
+// QuarantinedImages mocks base method
+func (m *MockImageQuarantiner) QuarantinedImages() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QuarantinedImages")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// QuarantinedImages indicates an expected call of QuarantinedImages
+func (mr *MockImageQuarantinerMockRecorder) QuarantinedImages() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QuarantinedImages", reflect.TypeOf((*MockImageQuarantiner)(nil).QuarantinedImages))
+}
+
+// UnquarantineImage mocks base method
+func (m *MockImageQuarantiner) UnquarantineImage(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnquarantineImage", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnquarantineImage indicates an expected call of UnquarantineImage
+func (mr *MockImageQuarantinerMockRecorder) UnquarantineImage(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnquarantineImage", reflect.TypeOf((*MockImageQuarantiner)(nil).UnquarantineImage), arg0)
+}
+
+// MockTaskEngineDrainer is a mock of TaskEngineDrainer interface
+type MockTaskEngineDrainer struct {
+	ctrl     *gomock.Controller
+	recorder *MockTaskEngineDrainerMockRecorder
+}
+
+// MockTaskEngineDrainerMockRecorder is the mock recorder for MockTaskEngineDrainer
+type MockTaskEngineDrainerMockRecorder struct {
+	mock *MockTaskEngineDrainer
+}
+
+// NewMockTaskEngineDrainer creates a new mock instance
+func NewMockTaskEngineDrainer(ctrl *gomock.Controller) *MockTaskEngineDrainer {
+	mock := &MockTaskEngineDrainer{ctrl: ctrl}
+	mock.recorder = &MockTaskEngineDrainerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockTaskEngineDrainer) EXPECT() *MockTaskEngineDrainerMockRecorder {
+	return m.recorder
+}
+
+// IsDrained mocks base method
+func (m *MockTaskEngineDrainer) IsDrained() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsDrained")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsDrained indicates an expected call of IsDrained
+func (mr *MockTaskEngineDrainerMockRecorder) IsDrained() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsDrained", reflect.TypeOf((*MockTaskEngineDrainer)(nil).IsDrained))
+}
+
+// SetDrained mocks base method
+func (m *MockTaskEngineDrainer) SetDrained(arg0 bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetDrained", arg0)
+}
+
+// SetDrained indicates an expected call of SetDrained
+func (mr *MockTaskEngineDrainerMockRecorder) SetDrained(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDrained", reflect.TypeOf((*MockTaskEngineDrainer)(nil).SetDrained), arg0)
+}
@@ -457,6 +457,8 @@ var (
 		},
 		Containers: []v4.ContainerResponse{expectedV4ContainerResponse},
 		VPCID:      vpcID,
+		// the containers of the test tasks are only known to the state
+		ResourceReservation: &v4.ResourceReservationResponse{},
 	}
 	expectedV4PulledTaskResponse = v4.TaskResponse{
 		TaskResponse: &v2.TaskResponse{
@@ -479,6 +481,8 @@ var (
 		},
 		Containers: []v4.ContainerResponse{expectedV4ContainerResponse, expectedV4PulledContainerResponse},
 		VPCID:      vpcID,
+		// the containers of the test tasks are only known to the state
+		ResourceReservation: &v4.ResourceReservationResponse{},
 	}
 	expectedV4BridgeContainerResponse = v4.ContainerResponse{
 		ContainerResponse: &expectedBridgeContainerResponse,
@@ -517,6 +521,8 @@ var (
 		},
 		Containers: []v4.ContainerResponse{expectedV4BridgeContainerResponse},
 		VPCID:      vpcID,
+		// the containers of the test tasks are only known to the state
+		ResourceReservation: &v4.ResourceReservationResponse{},
 	}
 )
 
@@ -1610,6 +1616,7 @@ func TestV4TaskStats(t *testing.T) {
 		state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return(taskARN, true),
 		state.EXPECT().ContainerMapByArn(taskARN).Return(containerMap, true),
 		statsEngine.EXPECT().ContainerDockerStats(taskARN, containerID).Return(dockerStats, &stats.NetworkStatsPerSec{}, nil),
+		statsEngine.EXPECT().GetCPUStealStats(dockerStats).Return(nil),
 	)
 	server := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient, clusterName, region, statsEngine,
 		config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, "", vpcID,
@@ -1639,11 +1646,13 @@ func TestV4ContainerStats(t *testing.T) {
 
 	dockerStats := &types.StatsJSON{}
 	dockerStats.NumProcs = 2
+	dockerStats.PidsStats = types.PidsStats{Current: 30, Limit: 120}
 
 	gomock.InOrder(
 		state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return(taskARN, true),
 		state.EXPECT().DockerIDByV3EndpointID(v3EndpointID).Return(containerID, true),
 		statsEngine.EXPECT().ContainerDockerStats(taskARN, containerID).Return(dockerStats, &stats.NetworkStatsPerSec{}, nil),
+		statsEngine.EXPECT().GetCPUStealStats(dockerStats).Return(&stats.CPUStealStats{HostStealPerc: 12.5, EstimatedStealNs: 1000}),
 	)
 	server := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient, clusterName, region, statsEngine,
 		config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, "", vpcID,
@@ -1654,10 +1663,12 @@ func TestV4ContainerStats(t *testing.T) {
 	res, err := ioutil.ReadAll(recorder.Body)
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusOK, recorder.Code)
-	var statsFromResult *types.StatsJSON
+	var statsFromResult *v4.StatsResponse
 	err = json.Unmarshal(res, &statsFromResult)
 	assert.NoError(t, err)
 	assert.Equal(t, dockerStats.NumProcs, statsFromResult.NumProcs)
+	assert.Equal(t, &stats.ProcessStats{Current: 30, Limit: 120, UtilizationPerc: 25}, statsFromResult.Process_stats)
+	assert.Equal(t, &stats.CPUStealStats{HostStealPerc: 12.5, EstimatedStealNs: 1000}, statsFromResult.Cpu_steal_stats)
 }
 
 func TestV4ContainerAssociations(t *testing.T) {
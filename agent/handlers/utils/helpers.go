@@ -45,6 +45,36 @@ const (
 	// RequestTypeAgentMetadata specifies the Agent metadata request type of AgentMetadataHandler.
 	RequestTypeAgentMetadata = "agent metadata"
 
+	// RequestTypeDrain specifies the drain request type of DrainHandler.
+	RequestTypeDrain = "drain"
+
+	// RequestTypeImageCleanupHistory specifies the image cleanup history request type of ImageCleanupHistoryHandler.
+	RequestTypeImageCleanupHistory = "image cleanup history"
+
+	// RequestTypeImageCleanupEligibility specifies the image cleanup eligibility request type of ImageCleanupEligibilityHandler.
+	RequestTypeImageCleanupEligibility = "image cleanup eligibility"
+
+	// RequestTypeImageCleanupOrder specifies the image cleanup order request type of ImageCleanupOrderHandler.
+	RequestTypeImageCleanupOrder = "image cleanup order"
+
+	// RequestTypeTasksStats specifies the tasks stats request type of TaskStatsHandler.
+	RequestTypeTasksStats = "tasks stats"
+
+	// RequestTypeContainerLogs specifies the container logs request type of ContainerLogsHandler.
+	RequestTypeContainerLogs = "container logs"
+
+	// RequestTypeImageCleanup specifies the image cleanup request type of ImageCleanupHandler.
+	RequestTypeImageCleanup = "image cleanup"
+
+	// RequestTypeImageQuarantine specifies the image quarantine request type of ImageQuarantineHandler.
+	RequestTypeImageQuarantine = "image quarantine"
+
+	// RequestTypeImagePins specifies the image pins request type of ImagePinsHandler.
+	RequestTypeImagePins = "image pins"
+
+	// RequestTypeHealth specifies the health request type of HealthHandler.
+	RequestTypeHealth = "health"
+
 	// RequestTypeContainerAssociations specifies the container associations request type of ContainerAssociationsHandler.
 	RequestTypeContainerAssociations = "container associations"
 
@@ -13,10 +13,89 @@
 
 package utils
 
-import "github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+import (
+	"context"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+	"github.com/aws/amazon-ecs-agent/agent/engine/image"
+)
+
+// ContainerLogsProvider is a sub-interface of the docker task engine to retrieve the last lines of the logs
+// of a container, to make it easy to test code in this package
+type ContainerLogsProvider interface {
+	ContainerLogs(dockerID string, tail int) ([]byte, error)
+}
 
 // DockerStateResolver is a sub-interface for the engine.TaskEngine interface
 // to make it easy to test code in this package
 type DockerStateResolver interface {
 	State() dockerstate.TaskEngineState
 }
+
+// HealthChecker is a sub-interface of the docker task engine to check whether the agent is healthy, to make
+// it easy to test code in this package
+type HealthChecker interface {
+	CheckHealth() error
+}
+
+// ImageCleanupEligibilityProvider is a sub-interface of the docker task engine to retrieve how far
+// an image is from being removed by image cleanup, to make it easy to test code in this package
+type ImageCleanupEligibilityProvider interface {
+	ImageCleanupEligibility(imageRef string) (image.CleanupEligibility, bool)
+}
+
+// ImageCleanupHistoryProvider is a sub-interface of the docker task engine to retrieve the
+// statistics of the recent image cleanup cycles, to make it easy to test code in this package
+type ImageCleanupHistoryProvider interface {
+	ImageCleanupHistory() []image.CleanupCycleStats
+}
+
+// ImageCleanupOrderProvider is a sub-interface of the docker task engine to retrieve the tracked images in
+// the order image cleanup considers them, to make it easy to test code in this package
+type ImageCleanupOrderProvider interface {
+	ImageCleanupOrder() []image.CleanupEligibility
+}
+
+// ImageCleanupRunner is a sub-interface of the docker task engine to start an image cleanup
+// cycle on demand, to make it easy to test code in this package
+type ImageCleanupRunner interface {
+	RunImageCleanup(ctx context.Context) (string, error)
+}
+
+// ImagePinner is a sub-interface of the docker task engine to pin images so that image cleanup skips them, to
+// make it easy to test code in this package
+type ImagePinner interface {
+	PinImage(imageRef string, ttl time.Duration) (time.Time, error)
+	UnpinImage(imageRef string) bool
+	PinnedImages() map[string]time.Time
+}
+
+// ImageQuarantiner is a sub-interface of the docker task engine to quarantine images, to make it easy
+// to test code in this package
+type ImageQuarantiner interface {
+	QuarantineImage(imageRef string) ([]string, error)
+	UnquarantineImage(imageRef string) error
+	QuarantinedImages() []string
+}
+
+// TaskEngineDrainer is a sub-interface of the docker task engine to drain and undrain
+// it, to make it easy to test code in this package
+type TaskEngineDrainer interface {
+	SetDrained(drained bool)
+	IsDrained() bool
+}
+
+// IntrospectionTaskEngine is the part of the docker task engine the handlers of the introspection server use
+type IntrospectionTaskEngine interface {
+	ContainerLogsProvider
+	DockerStateResolver
+	HealthChecker
+	ImageCleanupEligibilityProvider
+	ImageCleanupHistoryProvider
+	ImageCleanupOrderProvider
+	ImageCleanupRunner
+	ImagePinner
+	ImageQuarantiner
+	TaskEngineDrainer
+}
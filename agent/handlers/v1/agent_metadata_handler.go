@@ -26,12 +26,20 @@ import (
 const AgentMetadataPath = "/v1/metadata"
 
 // AgentMetadataHandler creates response for 'v1/metadata' API.
-func AgentMetadataHandler(containerInstanceArn *string, cfg *config.Config) func(http.ResponseWriter, *http.Request) {
+func AgentMetadataHandler(containerInstanceArn *string, drainer utils.TaskEngineDrainer,
+	imageCleanupHistory utils.ImageCleanupHistoryProvider, cfg *config.Config) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		resp := &MetadataResponse{
 			Cluster:              cfg.Cluster,
 			ContainerInstanceArn: containerInstanceArn,
 			Version:              agentversion.String(),
+			Drained:              drainer.IsDrained(),
+		}
+		if history := imageCleanupHistory.ImageCleanupHistory(); len(history) > 0 {
+			lastCleanup := history[len(history)-1]
+			resp.LastImageCleanupTime = &lastCleanup.StartedAt
+			resp.LastImageCleanupRemovedCount = &lastCleanup.ImagesRemoved
+			resp.LastImageCleanupError = lastCleanup.Error
 		}
 		responseJSON, err := json.Marshal(resp)
 		if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
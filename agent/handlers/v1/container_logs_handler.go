@@ -0,0 +1,141 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aws/amazon-ecs-agent/agent/handlers/utils"
+)
+
+const (
+	// ContainerLogsPath is the container logs path for v1 handler, where {arn} is the ARN of the task and {name}
+	// the name of the container.
+	ContainerLogsPath = "/v1/tasks/{arn}/containers/{name}/logs"
+	// ContainerLogsPathPrefix is the prefix of the container logs paths, under which the container logs handler
+	// is registered.
+	ContainerLogsPathPrefix = "/v1/tasks/"
+
+	containerLogsPathSeparator = "/containers/"
+	containerLogsPathSuffix    = "/logs"
+	tailQueryField             = "tail"
+	// defaultContainerLogsTail is the number of lines returned when the tail query field is not set
+	defaultContainerLogsTail = 100
+	// maxContainerLogsTail is the maximum number of lines returned, larger tail query fields are capped to it
+	maxContainerLogsTail = 1000
+)
+
+// containerLogsSupportedLogDrivers are the log drivers whose logs can be read back through docker. An empty log
+// driver is the default log driver of the docker daemon, which is json-file unless configured otherwise.
+var containerLogsSupportedLogDrivers = map[string]struct{}{
+	"":          {},
+	"json-file": {},
+	"local":     {},
+}
+
+// ContainerLogsHandler creates response for the 'v1/tasks/{arn}/containers/{name}/logs' API. It returns the
+// last lines of the stdout and stderr of the container, up to the number of lines in the 'tail' query field.
+// Only the logs of containers using the json-file or local log drivers can be read.
+func ContainerLogsHandler(taskEngine utils.DockerStateResolver,
+	provider utils.ContainerLogsProvider) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		taskArn, containerName, ok := parseContainerLogsPath(r.URL.Path)
+		if !ok {
+			writeContainerLogsError(w, http.StatusNotFound, fmt.Sprintf("Unknown path %s, expected %s",
+				r.URL.Path, ContainerLogsPath))
+			return
+		}
+		tail := defaultContainerLogsTail
+		if tailValue, ok := utils.ValueFromRequest(r, tailQueryField); ok {
+			var err error
+			tail, err = strconv.Atoi(tailValue)
+			if err != nil || tail < 1 {
+				writeContainerLogsError(w, http.StatusBadRequest, fmt.Sprintf(
+					"Invalid %s query field %s, expected a positive number of lines", tailQueryField, tailValue))
+				return
+			}
+			if tail > maxContainerLogsTail {
+				tail = maxContainerLogsTail
+			}
+		}
+
+		state := taskEngine.State()
+		containerMap, ok := state.ContainerMapByArn(taskArn)
+		if !ok {
+			writeContainerLogsError(w, http.StatusNotFound, fmt.Sprintf("Task %s is not managed by the agent", taskArn))
+			return
+		}
+		dockerContainer, ok := containerMap[containerName]
+		if !ok || dockerContainer.DockerID == "" {
+			writeContainerLogsError(w, http.StatusNotFound, fmt.Sprintf(
+				"Container %s of task %s has not been created", containerName, taskArn))
+			return
+		}
+		logDriver := dockerContainer.Container.GetLogDriver()
+		if _, ok := containerLogsSupportedLogDrivers[logDriver]; !ok {
+			writeContainerLogsError(w, http.StatusBadRequest, fmt.Sprintf(
+				"Logs of container %s cannot be read as its log driver %s is not json-file or local",
+				containerName, logDriver))
+			return
+		}
+
+		logs, err := provider.ContainerLogs(dockerContainer.DockerID, tail)
+		if err != nil {
+			writeContainerLogsError(w, http.StatusInternalServerError, fmt.Sprintf(
+				"Unable to get the logs of container %s: %v", containerName, err))
+			return
+		}
+		responseJSON, err := json.Marshal(ContainerLogsResponse{
+			DockerID: dockerContainer.DockerID,
+			Name:     containerName,
+			Tail:     tail,
+			Logs:     string(logs),
+		})
+		if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
+			return
+		}
+		utils.WriteJSONToResponse(w, http.StatusOK, responseJSON, utils.RequestTypeContainerLogs)
+	}
+}
+
+// parseContainerLogsPath returns the task ARN and the container name of a container logs path. The task ARN
+// may contain slashes.
+func parseContainerLogsPath(path string) (string, string, bool) {
+	if !strings.HasPrefix(path, ContainerLogsPathPrefix) || !strings.HasSuffix(path, containerLogsPathSuffix) {
+		return "", "", false
+	}
+	path = strings.TrimSuffix(strings.TrimPrefix(path, ContainerLogsPathPrefix), containerLogsPathSuffix)
+	separatorIndex := strings.LastIndex(path, containerLogsPathSeparator)
+	if separatorIndex < 1 {
+		return "", "", false
+	}
+	taskArn := path[:separatorIndex]
+	containerName := path[separatorIndex+len(containerLogsPathSeparator):]
+	if containerName == "" || strings.Contains(containerName, "/") {
+		return "", "", false
+	}
+	return taskArn, containerName, true
+}
+
+func writeContainerLogsError(w http.ResponseWriter, status int, message string) {
+	errResponseJSON, err := json.Marshal(message)
+	if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
+		return
+	}
+	utils.WriteJSONToResponse(w, status, errResponseJSON, utils.RequestTypeContainerLogs)
+}
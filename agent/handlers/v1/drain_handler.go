@@ -0,0 +1,48 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aws/amazon-ecs-agent/agent/handlers/utils"
+)
+
+// DrainPath is the drain path for v1 handler.
+const DrainPath = "/v1/drain"
+
+// DrainHandler creates response for 'v1/drain' API. A POST request drains the task engine so that
+// it stops accepting new tasks, a DELETE request undrains it and a GET request returns the current
+// drain state.
+func DrainHandler(drainer utils.TaskEngineDrainer) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			drainer.SetDrained(true)
+		case http.MethodDelete:
+			drainer.SetDrained(false)
+		case http.MethodGet:
+		default:
+			w.Header().Set("Allow", "GET, POST, DELETE")
+			utils.WriteJSONToResponse(w, http.StatusMethodNotAllowed, []byte(`{}`), utils.RequestTypeDrain)
+			return
+		}
+		responseJSON, err := json.Marshal(&DrainResponse{Drained: drainer.IsDrained()})
+		if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
+			return
+		}
+		utils.WriteJSONToResponse(w, http.StatusOK, responseJSON, utils.RequestTypeDrain)
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aws/amazon-ecs-agent/agent/handlers/utils"
+)
+
+// HealthPath is the health path for v1 handler.
+const HealthPath = "/v1/health"
+
+// HealthHandler creates response for 'v1/health' API. It returns 200 when the task engine is running and the
+// docker daemon can be reached, and 503 with the reason otherwise.
+func HealthHandler(healthChecker utils.HealthChecker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := http.StatusOK
+		resp := &HealthResponse{Healthy: true}
+		if err := healthChecker.CheckHealth(); err != nil {
+			status = http.StatusServiceUnavailable
+			resp = &HealthResponse{Reason: err.Error()}
+		}
+		responseJSON, err := json.Marshal(resp)
+		if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
+			return
+		}
+		utils.WriteJSONToResponse(w, status, responseJSON, utils.RequestTypeHealth)
+	}
+}
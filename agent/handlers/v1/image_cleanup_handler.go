@@ -0,0 +1,121 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/amazon-ecs-agent/agent/handlers/utils"
+)
+
+// ImageCleanupHistoryPath is the image cleanup history path for v1 handler.
+const ImageCleanupHistoryPath = "/v1/imagecleanup"
+
+// ImageCleanupPath is the image cleanup path for v1 handler.
+const ImageCleanupPath = "/v1/images/cleanup"
+
+// ImageCleanupEligibilityPath is the image cleanup eligibility path for v1 handler.
+const ImageCleanupEligibilityPath = "/v1/imagecleanup/eligibility"
+
+// ImageCleanupOrderPath is the image cleanup order path for v1 handler.
+const ImageCleanupOrderPath = "/v1/imagecleanup/order"
+
+// imageQueryField is the query field holding the ID or the name of an image
+const imageQueryField = "image"
+
+// ImageCleanupHistoryHandler creates response for 'v1/imagecleanup' API. It returns the statistics
+// of the most recent image cleanup cycles, oldest first.
+func ImageCleanupHistoryHandler(provider utils.ImageCleanupHistoryProvider) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		responseJSON, err := json.Marshal(NewImageCleanupHistoryResponse(provider.ImageCleanupHistory()))
+		if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
+			return
+		}
+		utils.WriteJSONToResponse(w, http.StatusOK, responseJSON, utils.RequestTypeImageCleanupHistory)
+	}
+}
+
+// ImageCleanupHandler creates response for 'v1/images/cleanup' API. A POST request starts an image
+// cleanup cycle right away and returns its ID without waiting for it, as the cycle may take minutes. The
+// statistics of the cycle are found under its ID in the response of the 'v1/imagecleanup' API once it
+// completes.
+func ImageCleanupHandler(runner utils.ImageCleanupRunner) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			utils.WriteJSONToResponse(w, http.StatusMethodNotAllowed, []byte(`{}`), utils.RequestTypeImageCleanup)
+			return
+		}
+		cycleID, err := runner.RunImageCleanup(r.Context())
+		if err != nil {
+			errResponseJSON, err := json.Marshal(fmt.Sprintf("Unable to run image cleanup: %v", err))
+			if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
+				return
+			}
+			utils.WriteJSONToResponse(w, http.StatusConflict, errResponseJSON, utils.RequestTypeImageCleanup)
+			return
+		}
+		responseJSON, err := json.Marshal(&ImageCleanupStartedResponse{CycleID: cycleID})
+		if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
+			return
+		}
+		utils.WriteJSONToResponse(w, http.StatusAccepted, responseJSON, utils.RequestTypeImageCleanup)
+	}
+}
+
+// ImageCleanupEligibilityHandler creates response for 'v1/imagecleanup/eligibility' API. Given the ID or the
+// name of an image in the 'image' query field, it returns how far the image is from being removed by image
+// cleanup.
+func ImageCleanupEligibilityHandler(provider utils.ImageCleanupEligibilityProvider) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		imageRef, ok := utils.ValueFromRequest(r, imageQueryField)
+		if !ok {
+			errResponseJSON, err := json.Marshal(fmt.Sprintf("Missing %s query field", imageQueryField))
+			if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
+				return
+			}
+			utils.WriteJSONToResponse(w, http.StatusBadRequest, errResponseJSON, utils.RequestTypeImageCleanupEligibility)
+			return
+		}
+		eligibility, found := provider.ImageCleanupEligibility(imageRef)
+		if !found {
+			errResponseJSON, err := json.Marshal(fmt.Sprintf("Image %s is not tracked by the agent", imageRef))
+			if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
+				return
+			}
+			utils.WriteJSONToResponse(w, http.StatusNotFound, errResponseJSON, utils.RequestTypeImageCleanupEligibility)
+			return
+		}
+		responseJSON, err := json.Marshal(NewImageCleanupEligibilityResponse(eligibility))
+		if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
+			return
+		}
+		utils.WriteJSONToResponse(w, http.StatusOK, responseJSON, utils.RequestTypeImageCleanupEligibility)
+	}
+}
+
+// ImageCleanupOrderHandler creates response for 'v1/imagecleanup/order' API. It returns the tracked images
+// from the least recently used one, which is the order image cleanup considers them in, along with how far
+// each of them is from being removed.
+func ImageCleanupOrderHandler(provider utils.ImageCleanupOrderProvider) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		responseJSON, err := json.Marshal(NewImageCleanupOrderResponse(provider.ImageCleanupOrder()))
+		if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
+			return
+		}
+		utils.WriteJSONToResponse(w, http.StatusOK, responseJSON, utils.RequestTypeImageCleanupOrder)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/handlers/utils"
+)
+
+const (
+	// ImagePinsPath is the image pins path for v1 handler.
+	ImagePinsPath = "/v1/images/pins"
+	// ttlQueryField is the query field holding how long an image is pinned for, e.g. 30m
+	ttlQueryField = "ttl"
+)
+
+// ImagePinsHandler creates response for 'v1/images/pins' API. Given the ID or the name of an image in the 'image'
+// query field, a POST request pins the image for the duration in the 'ttl' query field so that image cleanup
+// skips it until the pin expires, and a DELETE request lifts its pin. A GET request returns the pinned images
+// and when their pin expires.
+func ImagePinsHandler(pinner utils.ImagePinner) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodDelete:
+			imageRef, ok := utils.ValueFromRequest(r, imageQueryField)
+			if !ok {
+				writeImagePinsError(w, http.StatusBadRequest, fmt.Sprintf("Missing %s query field", imageQueryField))
+				return
+			}
+			if r.Method == http.MethodDelete {
+				if !pinner.UnpinImage(imageRef) {
+					writeImagePinsError(w, http.StatusNotFound, fmt.Sprintf("Image %s is not pinned", imageRef))
+					return
+				}
+				break
+			}
+			ttlValue, ok := utils.ValueFromRequest(r, ttlQueryField)
+			if !ok {
+				writeImagePinsError(w, http.StatusBadRequest, fmt.Sprintf("Missing %s query field", ttlQueryField))
+				return
+			}
+			ttl, err := time.ParseDuration(ttlValue)
+			if err != nil {
+				writeImagePinsError(w, http.StatusBadRequest, fmt.Sprintf("Invalid %s query field: %v", ttlQueryField, err))
+				return
+			}
+			if _, err := pinner.PinImage(imageRef, ttl); err != nil {
+				writeImagePinsError(w, http.StatusBadRequest, fmt.Sprintf("Unable to pin image: %v", err))
+				return
+			}
+		case http.MethodGet:
+		default:
+			w.Header().Set("Allow", "GET, POST, DELETE")
+			utils.WriteJSONToResponse(w, http.StatusMethodNotAllowed, []byte(`{}`), utils.RequestTypeImagePins)
+			return
+		}
+		pinnedImages := pinner.PinnedImages()
+		if pinnedImages == nil {
+			pinnedImages = make(map[string]time.Time)
+		}
+		responseJSON, err := json.Marshal(&ImagePinsResponse{PinnedImages: pinnedImages})
+		if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
+			return
+		}
+		utils.WriteJSONToResponse(w, http.StatusOK, responseJSON, utils.RequestTypeImagePins)
+	}
+}
+
+func writeImagePinsError(w http.ResponseWriter, status int, message string) {
+	errResponseJSON, err := json.Marshal(message)
+	if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
+		return
+	}
+	utils.WriteJSONToResponse(w, status, errResponseJSON, utils.RequestTypeImagePins)
+}
@@ -0,0 +1,73 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/amazon-ecs-agent/agent/handlers/utils"
+)
+
+// ImageQuarantinePath is the image quarantine path for v1 handler.
+const ImageQuarantinePath = "/v1/images/quarantine"
+
+// ImageQuarantineHandler creates response for 'v1/images/quarantine' API. Given the ID or the digest of an
+// image in the 'image' query field, a POST request quarantines the image so that containers are no longer
+// created from it, and a DELETE request lifts its quarantine. A GET request returns the quarantined images.
+func ImageQuarantineHandler(quarantiner utils.ImageQuarantiner) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var stoppedTasks []string
+		switch r.Method {
+		case http.MethodPost, http.MethodDelete:
+			imageRef, ok := utils.ValueFromRequest(r, imageQueryField)
+			if !ok {
+				writeImageQuarantineError(w, http.StatusBadRequest, fmt.Sprintf("Missing %s query field", imageQueryField))
+				return
+			}
+			var err error
+			if r.Method == http.MethodPost {
+				stoppedTasks, err = quarantiner.QuarantineImage(imageRef)
+			} else {
+				err = quarantiner.UnquarantineImage(imageRef)
+			}
+			if err != nil {
+				writeImageQuarantineError(w, http.StatusBadRequest, fmt.Sprintf("Unable to update image quarantine: %v", err))
+				return
+			}
+		case http.MethodGet:
+		default:
+			w.Header().Set("Allow", "GET, POST, DELETE")
+			utils.WriteJSONToResponse(w, http.StatusMethodNotAllowed, []byte(`{}`), utils.RequestTypeImageQuarantine)
+			return
+		}
+		responseJSON, err := json.Marshal(&ImageQuarantineResponse{
+			QuarantinedImages: quarantiner.QuarantinedImages(),
+			StoppedTasks:      stoppedTasks,
+		})
+		if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
+			return
+		}
+		utils.WriteJSONToResponse(w, http.StatusOK, responseJSON, utils.RequestTypeImageQuarantine)
+	}
+}
+
+func writeImageQuarantineError(w http.ResponseWriter, status int, message string) {
+	errResponseJSON, err := json.Marshal(message)
+	if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
+		return
+	}
+	utils.WriteJSONToResponse(w, status, errResponseJSON, utils.RequestTypeImageQuarantine)
+}
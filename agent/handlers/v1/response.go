@@ -14,12 +14,17 @@
 package v1
 
 import (
+	"sort"
+	"time"
+
 	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
 	apieni "github.com/aws/amazon-ecs-agent/agent/api/eni"
 	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
 	"github.com/aws/amazon-ecs-agent/agent/containermetadata"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+	"github.com/aws/amazon-ecs-agent/agent/engine/image"
 	"github.com/aws/amazon-ecs-agent/agent/handlers/utils"
+	"github.com/aws/amazon-ecs-agent/agent/stats"
 )
 
 // MetadataResponse is the schema for the metadata response JSON object
@@ -27,6 +32,204 @@ type MetadataResponse struct {
 	Cluster              string  `json:"Cluster"`
 	ContainerInstanceArn *string `json:"ContainerInstanceArn"`
 	Version              string  `json:"Version"`
+	Drained              bool    `json:"Drained,omitempty"`
+	// The outcome of the most recent image cleanup cycle, omitted until a cycle has run
+	LastImageCleanupTime         *time.Time `json:"LastImageCleanupTime,omitempty"`
+	LastImageCleanupRemovedCount *int       `json:"LastImageCleanupRemovedCount,omitempty"`
+	LastImageCleanupError        string     `json:"LastImageCleanupError,omitempty"`
+}
+
+// ImageCleanupHistoryResponse is the schema for the image cleanup history response JSON object
+type ImageCleanupHistoryResponse struct {
+	Cycles []ImageCleanupCycleResponse `json:"Cycles"`
+}
+
+// ImageCleanupCycleResponse is the schema for the statistics of an image cleanup cycle
+type ImageCleanupCycleResponse struct {
+	CycleID             string         `json:"CycleID"`
+	StartedAt           time.Time      `json:"StartedAt"`
+	DurationMillis      int64          `json:"DurationMillis"`
+	CandidatesEvaluated int            `json:"CandidatesEvaluated"`
+	ImagesRemoved       int            `json:"ImagesRemoved"`
+	BytesReclaimed      int64          `json:"BytesReclaimed"`
+	SkipReasons         map[string]int `json:"SkipReasons,omitempty"`
+	RemovedImageIDs     []string       `json:"RemovedImageIDs,omitempty"`
+	Error               string         `json:"Error,omitempty"`
+}
+
+// ImageCleanupStartedResponse is the schema for the response JSON object of an image cleanup cycle started
+// on demand
+type ImageCleanupStartedResponse struct {
+	CycleID string `json:"CycleID"`
+}
+
+// NewImageCleanupHistoryResponse creates an ImageCleanupHistoryResponse from the statistics of
+// the recent image cleanup cycles.
+func NewImageCleanupHistoryResponse(history []image.CleanupCycleStats) *ImageCleanupHistoryResponse {
+	resp := &ImageCleanupHistoryResponse{
+		Cycles: make([]ImageCleanupCycleResponse, 0, len(history)),
+	}
+	for _, stats := range history {
+		resp.Cycles = append(resp.Cycles, *NewImageCleanupCycleResponse(stats))
+	}
+	return resp
+}
+
+// NewImageCleanupCycleResponse creates an ImageCleanupCycleResponse from the statistics of an
+// image cleanup cycle.
+func NewImageCleanupCycleResponse(stats image.CleanupCycleStats) *ImageCleanupCycleResponse {
+	return &ImageCleanupCycleResponse{
+		CycleID:             stats.ID,
+		StartedAt:           stats.StartedAt,
+		DurationMillis:      stats.Duration.Milliseconds(),
+		CandidatesEvaluated: stats.CandidatesEvaluated,
+		ImagesRemoved:       stats.ImagesRemoved,
+		BytesReclaimed:      stats.BytesReclaimed,
+		SkipReasons:         stats.SkipReasons,
+		RemovedImageIDs:     stats.RemovedImageIDs,
+		Error:               stats.Error,
+	}
+}
+
+// ImageCleanupEligibilityResponse is the schema for the image cleanup eligibility response JSON object
+type ImageCleanupEligibilityResponse struct {
+	ImageID                 string     `json:"ImageID"`
+	Names                   []string   `json:"Names,omitempty"`
+	LastUsedAt              *time.Time `json:"LastUsedAt,omitempty"`
+	Eligible                bool       `json:"Eligible"`
+	MillisUntilOldEnough    int64      `json:"MillisUntilOldEnough"`
+	HasAssociatedContainers bool       `json:"HasAssociatedContainers"`
+	PinnedByTask            bool       `json:"PinnedByTask"`
+	Excluded                bool       `json:"Excluded"`
+	ProtectedByFamily       bool       `json:"ProtectedByFamily"`
+	Pinned                  bool       `json:"Pinned"`
+	StartupSettling         bool       `json:"StartupSettling"`
+	LRUPosition             int        `json:"LRUPosition"`
+}
+
+// NewImageCleanupEligibilityResponse creates an ImageCleanupEligibilityResponse from the cleanup
+// eligibility of an image.
+func NewImageCleanupEligibilityResponse(eligibility image.CleanupEligibility) *ImageCleanupEligibilityResponse {
+	resp := &ImageCleanupEligibilityResponse{
+		ImageID:                 eligibility.ImageID,
+		Names:                   eligibility.Names,
+		Eligible:                eligibility.Eligible,
+		MillisUntilOldEnough:    eligibility.TimeUntilOldEnough.Milliseconds(),
+		HasAssociatedContainers: eligibility.HasAssociatedContainers,
+		PinnedByTask:            eligibility.PinnedByTask,
+		Excluded:                eligibility.Excluded,
+		ProtectedByFamily:       eligibility.ProtectedByFamily,
+		Pinned:                  eligibility.Pinned,
+		StartupSettling:         eligibility.StartupSettling,
+		LRUPosition:             eligibility.LRUPosition,
+	}
+	if !eligibility.LastUsedAt.IsZero() {
+		lastUsedAt := eligibility.LastUsedAt
+		resp.LastUsedAt = &lastUsedAt
+	}
+	return resp
+}
+
+// ImageCleanupOrderResponse is the schema for the image cleanup order response JSON object
+type ImageCleanupOrderResponse struct {
+	Images []*ImageCleanupEligibilityResponse `json:"Images"`
+}
+
+// NewImageCleanupOrderResponse creates an ImageCleanupOrderResponse from the cleanup eligibility of the
+// tracked images, keeping their order.
+func NewImageCleanupOrderResponse(order []image.CleanupEligibility) *ImageCleanupOrderResponse {
+	resp := &ImageCleanupOrderResponse{Images: make([]*ImageCleanupEligibilityResponse, 0, len(order))}
+	for _, eligibility := range order {
+		resp.Images = append(resp.Images, NewImageCleanupEligibilityResponse(eligibility))
+	}
+	return resp
+}
+
+// TasksStatsResponse is the schema for the tasks stats response JSON object
+type TasksStatsResponse struct {
+	Tasks []*TaskStatsResponse `json:"Tasks"`
+}
+
+// TaskStatsResponse is the schema for the resource usage of the containers of a task
+type TaskStatsResponse struct {
+	Arn        string                   `json:"Arn"`
+	Containers []ContainerStatsResponse `json:"Containers"`
+}
+
+// ContainerStatsResponse is the schema for the resource usage of a container, as of the latest docker stats
+// collected for it
+type ContainerStatsResponse struct {
+	DockerID         string    `json:"DockerId"`
+	Name             string    `json:"Name"`
+	CPUPercent       float64   `json:"CPUPercent"`
+	MemoryUsageBytes uint64    `json:"MemoryUsageBytes"`
+	MemoryLimitBytes uint64    `json:"MemoryLimitBytes"`
+	ReadAt           time.Time `json:"ReadAt"`
+}
+
+// NewTasksStatsResponse creates a TasksStatsResponse from the stats cached by the stats engine for the
+// containers of all the tasks. Containers without stats yet are left out.
+func NewTasksStatsResponse(state dockerstate.TaskEngineState, statsEngine stats.Engine) *TasksStatsResponse {
+	resp := &TasksStatsResponse{Tasks: []*TaskStatsResponse{}}
+	for _, task := range state.AllTasks() {
+		taskResp := &TaskStatsResponse{Arn: task.Arn, Containers: []ContainerStatsResponse{}}
+		containerMap, _ := state.ContainerMapByArn(task.Arn)
+		for _, dockerContainer := range containerMap {
+			dockerStats, _, err := statsEngine.ContainerDockerStats(task.Arn, dockerContainer.DockerID)
+			if err != nil || dockerStats == nil {
+				continue
+			}
+			taskResp.Containers = append(taskResp.Containers, ContainerStatsResponse{
+				DockerID:         dockerContainer.DockerID,
+				Name:             dockerContainer.Container.Name,
+				CPUPercent:       stats.CPUUsagePercent(dockerStats),
+				MemoryUsageBytes: stats.MemoryUsageBytes(dockerStats),
+				MemoryLimitBytes: dockerStats.MemoryStats.Limit,
+				ReadAt:           dockerStats.Read,
+			})
+		}
+		sort.Slice(taskResp.Containers, func(i, j int) bool {
+			return taskResp.Containers[i].Name < taskResp.Containers[j].Name
+		})
+		resp.Tasks = append(resp.Tasks, taskResp)
+	}
+	sort.Slice(resp.Tasks, func(i, j int) bool {
+		return resp.Tasks[i].Arn < resp.Tasks[j].Arn
+	})
+	return resp
+}
+
+// ContainerLogsResponse is the schema for the container logs response JSON object
+type ContainerLogsResponse struct {
+	DockerID string `json:"DockerId"`
+	Name     string `json:"Name"`
+	Tail     int    `json:"Tail"`
+	Logs     string `json:"Logs"`
+}
+
+// DrainResponse is the schema for the drain response JSON object
+type DrainResponse struct {
+	Drained bool `json:"Drained"`
+}
+
+// ImageQuarantineResponse is the schema for the image quarantine response JSON object
+type ImageQuarantineResponse struct {
+	QuarantinedImages []string `json:"QuarantinedImages"`
+	// StoppedTasks are the ARNs of the tasks stopped because the image was quarantined
+	StoppedTasks []string `json:"StoppedTasks,omitempty"`
+}
+
+// ImagePinsResponse is the schema for the image pins response JSON object
+type ImagePinsResponse struct {
+	// PinnedImages maps the IDs and names of the pinned images to when their pin expires
+	PinnedImages map[string]time.Time `json:"PinnedImages"`
+}
+
+// HealthResponse is the schema for the health response JSON object
+type HealthResponse struct {
+	Healthy bool `json:"Healthy"`
+	// Reason is why the agent is unhealthy
+	Reason string `json:"Reason,omitempty"`
 }
 
 // TaskResponse is the schema for the task response JSON object
@@ -0,0 +1,38 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aws/amazon-ecs-agent/agent/handlers/utils"
+	"github.com/aws/amazon-ecs-agent/agent/stats"
+)
+
+// TaskStatsPath is the task stats path for v1 handler.
+const TaskStatsPath = "/v1/tasks/stats"
+
+// TaskStatsHandler creates response for 'v1/tasks/stats' API. It returns the CPU and memory usage of the
+// containers of each task from the latest docker stats collected by the stats engine, without requesting new
+// stats from docker.
+func TaskStatsHandler(taskEngine utils.DockerStateResolver, statsEngine stats.Engine) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		responseJSON, err := json.Marshal(NewTasksStatsResponse(taskEngine.State(), statsEngine))
+		if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
+			return
+		}
+		utils.WriteJSONToResponse(w, http.StatusOK, responseJSON, utils.RequestTypeTasksStats)
+	}
+}
@@ -74,6 +74,20 @@ type ContainerResponse struct {
 	LogDriver     string                      `json:"LogDriver,omitempty"`
 	LogOptions    map[string]string           `json:"LogOptions,omitempty"`
 	ContainerARN  string                      `json:"ContainerARN,omitempty"`
+
+	ImagePullDurationMillis *int64 `json:"ImagePullDurationMillis,omitempty"`
+	ImagePullCached         *bool  `json:"ImagePullCached,omitempty"`
+
+	ClockDriftMillis    *int64     `json:"ClockDriftMillis,omitempty"`
+	ClockDriftCheckedAt *time.Time `json:"ClockDriftCheckedAt,omitempty"`
+
+	OpenFileDescriptors         *int       `json:"OpenFileDescriptors,omitempty"`
+	FileDescriptorLimit         *uint64    `json:"FileDescriptorLimit,omitempty"`
+	FileDescriptorLeakSuspected bool       `json:"FileDescriptorLeakSuspected,omitempty"`
+	FileDescriptorsCheckedAt    *time.Time `json:"FileDescriptorsCheckedAt,omitempty"`
+
+	RestartCount    *int       `json:"RestartCount,omitempty"`
+	LastRestartedAt *time.Time `json:"LastRestartedAt,omitempty"`
 }
 
 // LimitsResponse defines the schema for task/cpu limits response
@@ -250,6 +264,31 @@ func NewContainerResponse(
 		resp.LogDriver = container.GetLogDriver()
 		resp.LogOptions = container.GetLogOptions()
 		resp.ContainerARN = container.ContainerArn
+		if pullDuration, ok := container.GetImagePullDuration(); ok {
+			resp.ImagePullDurationMillis = aws.Int64(pullDuration.Milliseconds())
+			resp.ImagePullCached = aws.Bool(container.IsImagePullCached())
+		}
+		if drift, checkedAt, ok := container.GetClockDrift(); ok {
+			checkedAt = checkedAt.UTC()
+			resp.ClockDriftMillis = aws.Int64(drift.Milliseconds())
+			resp.ClockDriftCheckedAt = &checkedAt
+		}
+		if fdUsage, ok := container.GetFDUsage(); ok {
+			checkedAt := fdUsage.CheckedAt.UTC()
+			resp.OpenFileDescriptors = aws.Int(fdUsage.OpenFDs)
+			if fdUsage.Limit > 0 {
+				resp.FileDescriptorLimit = aws.Uint64(fdUsage.Limit)
+			}
+			resp.FileDescriptorLeakSuspected = fdUsage.LeakSuspected
+			resp.FileDescriptorsCheckedAt = &checkedAt
+		}
+		if restartCount := container.GetRestartCount(); restartCount > 0 {
+			resp.RestartCount = aws.Int(restartCount)
+			if restartedAt := container.GetLastRestartedAt(); !restartedAt.IsZero() {
+				restartedAt = restartedAt.UTC()
+				resp.LastRestartedAt = &restartedAt
+			}
+		}
 	}
 
 	// Write the container health status inside the container
@@ -36,6 +36,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -324,6 +325,151 @@ func TestContainerResponse(t *testing.T) {
 	}
 }
 
+func TestContainerResponseImagePullDuration(t *testing.T) {
+	pullStartedAt := time.Now()
+	testCases := []struct {
+		name             string
+		cached           bool
+		pullStoppedAt    time.Time
+		expectedDuration *int64
+		expectedCached   *bool
+	}{
+		{
+			name:             "cache miss",
+			pullStoppedAt:    pullStartedAt.Add(1500 * time.Millisecond),
+			expectedDuration: aws.Int64(1500),
+			expectedCached:   aws.Bool(false),
+		},
+		{
+			name:             "cache hit",
+			cached:           true,
+			expectedDuration: aws.Int64(0),
+			expectedCached:   aws.Bool(true),
+		},
+		{
+			name: "pull in progress",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			container := &apicontainer.Container{
+				Name:  containerName,
+				Image: imageName,
+				Type:  apicontainer.ContainerNormal,
+			}
+			if tc.cached {
+				container.SetImagePullCached(true)
+			} else {
+				container.SetImagePullStartedAt(pullStartedAt)
+				container.SetImagePullStoppedAt(tc.pullStoppedAt)
+			}
+			dockerContainer := &apicontainer.DockerContainer{
+				DockerID:   containerID,
+				DockerName: containerName,
+				Container:  container,
+			}
+
+			containerResponse := NewContainerResponse(dockerContainer, nil, true)
+			assert.Equal(t, tc.expectedDuration, containerResponse.ImagePullDurationMillis)
+			assert.Equal(t, tc.expectedCached, containerResponse.ImagePullCached)
+
+			// The pull duration is only reported by the v4 endpoint
+			containerResponse = NewContainerResponse(dockerContainer, nil, false)
+			assert.Nil(t, containerResponse.ImagePullDurationMillis)
+			assert.Nil(t, containerResponse.ImagePullCached)
+		})
+	}
+}
+
+func TestContainerResponseFDUsage(t *testing.T) {
+	container := &apicontainer.Container{
+		Name:  containerName,
+		Image: imageName,
+		Type:  apicontainer.ContainerNormal,
+	}
+	dockerContainer := &apicontainer.DockerContainer{
+		DockerID:   containerID,
+		DockerName: containerName,
+		Container:  container,
+	}
+
+	containerResponse := NewContainerResponse(dockerContainer, nil, true)
+	assert.Nil(t, containerResponse.OpenFileDescriptors)
+	assert.Nil(t, containerResponse.FileDescriptorsCheckedAt)
+
+	checkedAt := time.Now()
+	container.RecordFDUsage(120, 1024, checkedAt)
+	containerResponse = NewContainerResponse(dockerContainer, nil, true)
+	assert.Equal(t, aws.Int(120), containerResponse.OpenFileDescriptors)
+	assert.Equal(t, aws.Uint64(1024), containerResponse.FileDescriptorLimit)
+	assert.False(t, containerResponse.FileDescriptorLeakSuspected)
+	require.NotNil(t, containerResponse.FileDescriptorsCheckedAt)
+	assert.True(t, checkedAt.Equal(*containerResponse.FileDescriptorsCheckedAt))
+
+	// The file descriptor usage is only reported by the v4 endpoint
+	containerResponse = NewContainerResponse(dockerContainer, nil, false)
+	assert.Nil(t, containerResponse.OpenFileDescriptors)
+}
+
+func TestContainerResponseRestarts(t *testing.T) {
+	container := &apicontainer.Container{
+		Name:  containerName,
+		Image: imageName,
+		Type:  apicontainer.ContainerNormal,
+	}
+	dockerContainer := &apicontainer.DockerContainer{
+		DockerID:   containerID,
+		DockerName: containerName,
+		Container:  container,
+	}
+
+	containerResponse := NewContainerResponse(dockerContainer, nil, true)
+	assert.Nil(t, containerResponse.RestartCount)
+	assert.Nil(t, containerResponse.LastRestartedAt)
+
+	restartedAt := time.Now()
+	container.IncrementRestartCount(apicontainer.RestartReasonExitCode)
+	container.IncrementRestartCount(apicontainer.RestartReasonRestartPolicy)
+	container.SetLastRestartedAt(restartedAt)
+	containerResponse = NewContainerResponse(dockerContainer, nil, true)
+	assert.Equal(t, aws.Int(2), containerResponse.RestartCount)
+	require.NotNil(t, containerResponse.LastRestartedAt)
+	assert.True(t, restartedAt.Equal(*containerResponse.LastRestartedAt))
+
+	// The restarts are only reported by the v4 endpoint
+	containerResponse = NewContainerResponse(dockerContainer, nil, false)
+	assert.Nil(t, containerResponse.RestartCount)
+}
+
+func TestContainerResponseClockDrift(t *testing.T) {
+	container := &apicontainer.Container{
+		Name:  containerName,
+		Image: imageName,
+		Type:  apicontainer.ContainerNormal,
+	}
+	dockerContainer := &apicontainer.DockerContainer{
+		DockerID:   containerID,
+		DockerName: containerName,
+		Container:  container,
+	}
+
+	containerResponse := NewContainerResponse(dockerContainer, nil, true)
+	assert.Nil(t, containerResponse.ClockDriftMillis)
+	assert.Nil(t, containerResponse.ClockDriftCheckedAt)
+
+	checkedAt := time.Now()
+	container.SetClockDrift(-2500*time.Millisecond, checkedAt)
+	containerResponse = NewContainerResponse(dockerContainer, nil, true)
+	assert.Equal(t, aws.Int64(-2500), containerResponse.ClockDriftMillis)
+	require.NotNil(t, containerResponse.ClockDriftCheckedAt)
+	assert.True(t, checkedAt.Equal(*containerResponse.ClockDriftCheckedAt))
+
+	// The clock drift is only reported by the v4 endpoint
+	containerResponse = NewContainerResponse(dockerContainer, nil, false)
+	assert.Nil(t, containerResponse.ClockDriftMillis)
+}
+
 func TestTaskResponseMarshal(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
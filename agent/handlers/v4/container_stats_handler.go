@@ -75,6 +75,8 @@ func WriteV4ContainerStatsResponse(w http.ResponseWriter,
 	containerStatsResponse := StatsResponse{
 		StatsJSON:          dockerStats,
 		Network_rate_stats: network_rate_stats,
+		Process_stats:      stats.GetProcessStats(dockerStats),
+		Cpu_steal_stats:    statsEngine.GetCPUStealStats(dockerStats),
 	}
 
 	responseJSON, err := json.Marshal(containerStatsResponse)
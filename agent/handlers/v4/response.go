@@ -14,6 +14,8 @@
 package v4
 
 import (
+	"time"
+
 	"github.com/aws/amazon-ecs-agent/agent/api"
 	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
 	apieni "github.com/aws/amazon-ecs-agent/agent/api/eni"
@@ -22,9 +24,12 @@ import (
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
 	"github.com/aws/amazon-ecs-agent/agent/handlers/utils"
 	v2 "github.com/aws/amazon-ecs-agent/agent/handlers/v2"
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/pkg/errors"
 )
 
+const bytesPerMiB = 1024 * 1024
+
 // TaskResponse is the v4 Task response. It augments the v4 Container response
 // with the v2 task response object.
 type TaskResponse struct {
@@ -32,6 +37,17 @@ type TaskResponse struct {
 	Containers  []ContainerResponse `json:"Containers,omitempty"`
 	VPCID       string              `json:"VPCID,omitempty"`
 	ServiceName string              `json:"ServiceName,omitempty"`
+	// ResourceReservation is the aggregate of the resources the containers of the task request from docker
+	ResourceReservation *ResourceReservationResponse `json:"ResourceReservation,omitempty"`
+}
+
+// ResourceReservationResponse is the aggregate of the CPU shares and memory the containers of a task are
+// created with. Memory values are in MiB, and containers without a memory reservation or limit do not
+// contribute to the respective sum.
+type ResourceReservationResponse struct {
+	CPUShares         int64 `json:"CPUShares"`
+	MemoryReservation int64 `json:"MemoryReservation"`
+	MemoryLimit       int64 `json:"MemoryLimit"`
 }
 
 // ContainerResponse is the v4 Container response. It augments the v4 Network response
@@ -74,6 +90,19 @@ type NetworkInterfaceProperties struct {
 	PrivateDNSName string `json:"PrivateDNSName,omitempty"`
 	// SubnetGatewayIPV4Address is the IPv4 gateway address for the network interface.
 	SubnetGatewayIPV4Address string `json:"SubnetGatewayIpv4Address,omitempty"`
+	// DNSLatencies are the latencies of the DNS resolvers of the task measured from within its network
+	// namespace. They are only measured if enabled in the agent config.
+	DNSLatencies []DNSLatency `json:"DNSLatencies,omitempty"`
+}
+
+// DNSLatency is the latency of a DNS resolver of the task
+type DNSLatency struct {
+	Resolver string `json:"Resolver"`
+	// LatencyMillis is only set if the resolver answered
+	LatencyMillis *int64 `json:"LatencyMillis,omitempty"`
+	// Error is the reason the resolver did not answer
+	Error     string    `json:"Error,omitempty"`
+	CheckedAt time.Time `json:"CheckedAt"`
 }
 
 // NewTaskResponse creates a new v4 response object for the task. It augments v2 task response
@@ -118,6 +147,18 @@ func NewTaskResponse(
 	}, nil
 }
 
+// NewResourceReservationResponse sums the CPU shares, memory reservations and memory limits requested from docker
+// by the containers of the task, as computed from their container definitions.
+func NewResourceReservationResponse(task *apitask.Task) *ResourceReservationResponse {
+	resp := &ResourceReservationResponse{}
+	for _, container := range task.Containers {
+		resp.CPUShares += task.GetContainerCPUShares(container)
+		resp.MemoryReservation += container.GetMemoryReservationFromHostConfig() / bytesPerMiB
+		resp.MemoryLimit += int64(container.Memory)
+	}
+	return resp
+}
+
 // NewContainerResponse creates a new v4 container response based on container id.  It augments
 // v4 container response with additional network interface fields.
 func NewContainerResponse(
@@ -193,9 +234,26 @@ func newNetworkInterfaceProperties(task *apitask.Task) (NetworkInterfaceProperti
 		DomainNameSearchList:     eni.DomainNameSearchList,
 		PrivateDNSName:           eni.PrivateDNSName,
 		SubnetGatewayIPV4Address: eni.SubnetGatewayIPV4Address,
+		DNSLatencies:             newDNSLatencies(task.GetDNSLatencies()),
 	}, nil
 }
 
+func newDNSLatencies(latencies []apitask.DNSLatency) []DNSLatency {
+	var resp []DNSLatency
+	for _, latency := range latencies {
+		respLatency := DNSLatency{
+			Resolver:  latency.Resolver,
+			Error:     latency.Error,
+			CheckedAt: latency.CheckedAt.UTC(),
+		}
+		if latency.Error == "" {
+			respLatency.LatencyMillis = aws.Int64(latency.Latency.Milliseconds())
+		}
+		resp = append(resp, respLatency)
+	}
+	return resp
+}
+
 // NewPulledContainerResponse creates a new v4 container response for a pulled container.
 // It augments v4 container response with an additional empty network interface field.
 func NewPulledContainerResponse(
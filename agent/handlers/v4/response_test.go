@@ -28,6 +28,7 @@ import (
 	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
 	apitaskstatus "github.com/aws/amazon-ecs-agent/agent/api/task/status"
 	mock_dockerstate "github.com/aws/amazon-ecs-agent/agent/engine/dockerstate/mocks"
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/docker/docker/api/types"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -161,3 +162,52 @@ func TestNewTaskContainerResponses(t *testing.T) {
 	assert.Equal(t, "192.168.0.0/24", containerResponse.Networks[0].IPV4SubnetCIDRBlock)
 	assert.Equal(t, subnetGatewayIPV4Address, containerResponse.Networks[0].SubnetGatewayIPV4Address)
 }
+
+func TestNewResourceReservationResponse(t *testing.T) {
+	task := &apitask.Task{
+		Arn: taskARN,
+		Containers: []*apicontainer.Container{
+			{
+				Name:   "web",
+				CPU:    512,
+				Memory: 256,
+				DockerConfig: apicontainer.DockerConfig{
+					HostConfig: aws.String(`{"MemoryReservation":134217728}`),
+				},
+			},
+			{
+				Name:   "sidecar",
+				CPU:    256,
+				Memory: 128,
+				DockerConfig: apicontainer.DockerConfig{
+					HostConfig: aws.String(`{"MemoryReservation":67108864}`),
+				},
+			},
+			{
+				// no CPU or memory settings; the container gets the minimum CPU shares
+				Name: "logger",
+			},
+		},
+	}
+
+	resp := NewResourceReservationResponse(task)
+	assert.Equal(t, int64(512+256+2), resp.CPUShares)
+	assert.Equal(t, int64(128+64), resp.MemoryReservation)
+	assert.Equal(t, int64(256+128), resp.MemoryLimit)
+}
+
+func TestNewDNSLatencies(t *testing.T) {
+	checkedAt := time.Now()
+	latencies := newDNSLatencies([]apitask.DNSLatency{
+		{Resolver: "10.0.0.2", Latency: 3 * time.Millisecond, CheckedAt: checkedAt},
+		{Resolver: "10.0.0.3", Error: "i/o timeout", CheckedAt: checkedAt},
+	})
+	require.Len(t, latencies, 2)
+	assert.Equal(t, "10.0.0.2", latencies[0].Resolver)
+	require.NotNil(t, latencies[0].LatencyMillis)
+	assert.Equal(t, int64(3), *latencies[0].LatencyMillis)
+	assert.Equal(t, checkedAt.UTC(), latencies[0].CheckedAt)
+	assert.Equal(t, "10.0.0.3", latencies[1].Resolver)
+	assert.Nil(t, latencies[1].LatencyMillis)
+	assert.Equal(t, "i/o timeout", latencies[1].Error)
+}
@@ -26,6 +26,8 @@ import (
 type StatsResponse struct {
 	*types.StatsJSON
 	Network_rate_stats *stats.NetworkStatsPerSec `json:"network_rate_stats,omitempty"`
+	Process_stats      *stats.ProcessStats       `json:"process_stats,omitempty"`
+	Cpu_steal_stats    *stats.CPUStealStats      `json:"cpu_steal_stats,omitempty"`
 }
 
 // NewV4TaskStatsResponse returns a new v4 task stats response object
@@ -54,6 +56,8 @@ func NewV4TaskStatsResponse(taskARN string,
 		statsResponse := StatsResponse{
 			StatsJSON:          dockerStats,
 			Network_rate_stats: network_rate_stats,
+			Process_stats:      stats.GetProcessStats(dockerStats),
+			Cpu_steal_stats:    statsEngine.GetCPUStealStats(dockerStats),
 		}
 
 		resp[containerID] = statsResponse
@@ -17,6 +17,7 @@ package httpclient
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net"
 	"net/http"
@@ -49,14 +50,49 @@ func (client *ecsRoundTripper) RoundTrip(req *http.Request) (*http.Response, err
 }
 
 func (client *ecsRoundTripper) CancelRequest(req *http.Request) {
-	if def, ok := client.transport.(*http.Transport); ok {
+	switch def := client.transport.(type) {
+	case *http.Transport:
+		def.CancelRequest(req)
+	case *hostRoundTripper:
 		def.CancelRequest(req)
 	}
 }
 
 // New returns an ECS httpClient with a roundtrip timeout of the given duration
 func New(timeout time.Duration, insecureSkipVerify bool) *http.Client {
-	// Transport is the transport requests will be made over
+	client := &http.Client{
+		Transport: &ecsRoundTripper{insecureSkipVerify, newTransport(insecureSkipVerify, nil)},
+		Timeout:   timeout,
+	}
+
+	return client
+}
+
+// NewWithRootCAs returns an ECS httpClient with a roundtrip timeout of the given duration, which verifies the
+// certificates of the given hosts against the given CAs rather than the ones trusted by the host
+func NewWithRootCAs(timeout time.Duration, insecureSkipVerify bool, rootCAs map[string]*x509.CertPool) *http.Client {
+	if len(rootCAs) == 0 {
+		return New(timeout, insecureSkipVerify)
+	}
+	transport := &hostRoundTripper{
+		defaultTransport: newTransport(insecureSkipVerify, nil),
+		hostTransports:   make(map[string]http.RoundTripper, len(rootCAs)),
+	}
+	for host, pool := range rootCAs {
+		transport.hostTransports[host] = newTransport(insecureSkipVerify, pool)
+	}
+
+	client := &http.Client{
+		Transport: &ecsRoundTripper{insecureSkipVerify, transport},
+		Timeout:   timeout,
+	}
+
+	return client
+}
+
+// newTransport returns the transport requests will be made over. The certificates of the servers are verified
+// against the given CAs, or the ones trusted by the host if there are none.
+func newTransport(insecureSkipVerify bool, rootCAs *x509.CertPool) *http.Transport {
 	// Note, these defaults are taken from the golang http library. We do not
 	// explicitly do not use theirs to avoid changing their behavior.
 	transport := &http.Transport{
@@ -71,13 +107,35 @@ func New(timeout time.Duration, insecureSkipVerify bool) *http.Client {
 	transport.TLSClientConfig = &tls.Config{}
 	cipher.WithSupportedCipherSuites(transport.TLSClientConfig)
 	transport.TLSClientConfig.InsecureSkipVerify = insecureSkipVerify
+	transport.TLSClientConfig.RootCAs = rootCAs
+	return transport
+}
 
-	client := &http.Client{
-		Transport: &ecsRoundTripper{insecureSkipVerify, transport},
-		Timeout:   timeout,
+// hostRoundTripper makes the requests to a host over the transport of that host if it has one, and over the
+// default transport otherwise. Hosts are matched with their port first, and then without it.
+type hostRoundTripper struct {
+	defaultTransport http.RoundTripper
+	hostTransports   map[string]http.RoundTripper
+}
+
+func (rt *hostRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return rt.transportFor(req).RoundTrip(req)
+}
+
+func (rt *hostRoundTripper) CancelRequest(req *http.Request) {
+	if def, ok := rt.transportFor(req).(*http.Transport); ok {
+		def.CancelRequest(req)
 	}
+}
 
-	return client
+func (rt *hostRoundTripper) transportFor(req *http.Request) http.RoundTripper {
+	if transport, ok := rt.hostTransports[req.URL.Host]; ok {
+		return transport
+	}
+	if transport, ok := rt.hostTransports[req.URL.Hostname()]; ok {
+		return transport
+	}
+	return rt.defaultTransport
 }
 
 // OverridableTransport is a transport that provides an override for testing purposes.
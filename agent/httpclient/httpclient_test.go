@@ -17,14 +17,19 @@
 package httpclient
 
 import (
+	"crypto/x509"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
 	"time"
 
+	mock_http "github.com/aws/amazon-ecs-agent/agent/httpclient/mock"
 	"github.com/aws/amazon-ecs-agent/agent/utils/cipher"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewHttpClient(t *testing.T) {
@@ -47,3 +52,49 @@ func TestNewHttpClientProxy(t *testing.T) {
 	// Error message should contain the proxy url which shows that client tried to use the proxy url to connect
 	assert.True(t, strings.Contains(err.Error(), proxy_url), "proxy url not found in: %s", err.Error())
 }
+
+func TestNewHttpClientWithRootCAs(t *testing.T) {
+	pool := x509.NewCertPool()
+	client := NewWithRootCAs(time.Duration(10), false, map[string]*x509.CertPool{"registry.example.com": pool})
+	transport := client.Transport.(*ecsRoundTripper).transport.(*hostRoundTripper)
+	registryTransport := transport.hostTransports["registry.example.com"].(*http.Transport)
+	assert.Equal(t, pool, registryTransport.TLSClientConfig.RootCAs)
+	assert.Equal(t, cipher.SupportedCipherSuites, registryTransport.TLSClientConfig.CipherSuites)
+	assert.Nil(t, transport.defaultTransport.(*http.Transport).TLSClientConfig.RootCAs)
+}
+
+func TestHostRoundTripper(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	defaultTransport := mock_http.NewMockRoundTripper(ctrl)
+	registryTransport := mock_http.NewMockRoundTripper(ctrl)
+	transport := &hostRoundTripper{
+		defaultTransport: defaultTransport,
+		hostTransports:   map[string]http.RoundTripper{"registry.example.com": registryTransport},
+	}
+	client := &http.Client{Transport: &ecsRoundTripper{false, transport}}
+
+	registryTransport.EXPECT().RoundTrip(gomock.Any()).Return(&http.Response{StatusCode: http.StatusOK}, nil).Times(2)
+	defaultTransport.EXPECT().RoundTrip(gomock.Any()).Return(&http.Response{StatusCode: http.StatusOK}, nil)
+	for _, url := range []string{"https://registry.example.com/v2/", "https://registry.example.com:5000/v2/",
+		"https://other.example.com/v2/"} {
+		resp, err := client.Get(url)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestNewHttpClientWithRootCAsVerifiesHost(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+
+	// The certificate of the server is only trusted for the host it was configured for
+	_, err := NewWithRootCAs(10*time.Second, false, map[string]*x509.CertPool{"registry.example.com": pool}).Get(server.URL)
+	assert.Error(t, err)
+	resp, err := NewWithRootCAs(10*time.Second, false, map[string]*x509.CertPool{"127.0.0.1": pool}).Get(server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
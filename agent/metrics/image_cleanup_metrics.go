@@ -0,0 +1,97 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// imageCleanupMetrics records what each image cleanup cycle did: the images it evaluated, removed and skipped,
+// by reason, the bytes it reclaimed and how long it took. The metrics without labels are vectors as well, so that
+// they are only exposed once a cycle has completed.
+type imageCleanupMetrics struct {
+	durationVec  *prometheus.HistogramVec
+	evaluatedVec *prometheus.CounterVec
+	removedVec   *prometheus.CounterVec
+	reclaimedVec *prometheus.CounterVec
+	skippedVec   *prometheus.CounterVec
+}
+
+func newImageCleanupMetrics(registry *prometheus.Registry) *imageCleanupMetrics {
+	aDurationVec := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: AgentNamespace,
+		Subsystem: TaskEngineSubsystem,
+		Name:      "image_cleanup_duration_seconds",
+		Help:      "Image cleanup cycle duration in seconds",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 12),
+	}, nil)
+	registry.MustRegister(aDurationVec)
+
+	aEvaluatedVec := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: AgentNamespace,
+		Subsystem: TaskEngineSubsystem,
+		Name:      "image_cleanup_images_evaluated_count",
+		Help:      "Number of images evaluated by image cleanup cycles",
+	}, nil)
+	registry.MustRegister(aEvaluatedVec)
+
+	aRemovedVec := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: AgentNamespace,
+		Subsystem: TaskEngineSubsystem,
+		Name:      "image_cleanup_images_removed_count",
+		Help:      "Number of images removed by image cleanup cycles",
+	}, nil)
+	registry.MustRegister(aRemovedVec)
+
+	aReclaimedVec := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: AgentNamespace,
+		Subsystem: TaskEngineSubsystem,
+		Name:      "image_cleanup_reclaimed_bytes",
+		Help:      "Disk space in bytes reclaimed by image cleanup cycles",
+	}, nil)
+	registry.MustRegister(aReclaimedVec)
+
+	aSkippedVec := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: AgentNamespace,
+		Subsystem: TaskEngineSubsystem,
+		Name:      "image_cleanup_images_skipped_count",
+		Help:      "Number of images image cleanup cycles did not remove, by reason",
+	}, []string{"Reason"})
+	registry.MustRegister(aSkippedVec)
+
+	return &imageCleanupMetrics{
+		durationVec:  aDurationVec,
+		evaluatedVec: aEvaluatedVec,
+		removedVec:   aRemovedVec,
+		reclaimedVec: aReclaimedVec,
+		skippedVec:   aSkippedVec,
+	}
+}
+
+// RecordImageCleanupCycle records the statistics of a completed image cleanup cycle
+func (engine *MetricsEngine) RecordImageCleanupCycle(duration time.Duration, evaluated, removed int,
+	bytesReclaimed int64, skipReasons map[string]int) {
+	if engine == nil || !engine.collection || engine.imageCleanups == nil {
+		return
+	}
+	engine.imageCleanups.durationVec.WithLabelValues().Observe(duration.Seconds())
+	engine.imageCleanups.evaluatedVec.WithLabelValues().Add(float64(evaluated))
+	engine.imageCleanups.removedVec.WithLabelValues().Add(float64(removed))
+	engine.imageCleanups.reclaimedVec.WithLabelValues().Add(float64(bytesReclaimed))
+	for reason, count := range skipReasons {
+		engine.imageCleanups.skippedVec.WithLabelValues(reason).Add(float64(count))
+	}
+}
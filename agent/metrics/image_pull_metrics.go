@@ -0,0 +1,68 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// ImagePullCacheHit is the result of an image pull for a container served by the image already on the instance
+	ImagePullCacheHit = "CacheHit"
+	// ImagePullDownload is the result of an image pull for a container downloaded from the registry of the image
+	ImagePullDownload = "Download"
+)
+
+// imagePullMetrics records the count and the latency of the image pulls for containers, by result and registry
+// host of the image, which tells how often containers start from a cached image
+type imagePullMetrics struct {
+	durationVec *prometheus.HistogramVec
+	counterVec  *prometheus.CounterVec
+}
+
+func newImagePullMetrics(registry *prometheus.Registry) *imagePullMetrics {
+	aDurationVec := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: AgentNamespace,
+		Subsystem: TaskEngineSubsystem,
+		Name:      "image_pull_duration_seconds",
+		Help:      "Image pull duration in seconds, by result and registry host",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 14),
+	}, []string{"Result", "Registry"})
+	registry.MustRegister(aDurationVec)
+
+	aCounterVec := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: AgentNamespace,
+		Subsystem: TaskEngineSubsystem,
+		Name:      "image_pull_count",
+		Help:      "Image pull count, by result and registry host",
+	}, []string{"Result", "Registry"})
+	registry.MustRegister(aCounterVec)
+
+	return &imagePullMetrics{
+		durationVec: aDurationVec,
+		counterVec:  aCounterVec,
+	}
+}
+
+// RecordImagePull records an image pull for a container, whose result is either ImagePullCacheHit or
+// ImagePullDownload, from the registry host of the image, along with the time it took
+func (engine *MetricsEngine) RecordImagePull(result, registry string, duration time.Duration) {
+	if engine == nil || !engine.collection || engine.imagePulls == nil {
+		return
+	}
+	engine.imagePulls.counterVec.WithLabelValues(result, registry).Inc()
+	engine.imagePulls.durationVec.WithLabelValues(result, registry).Observe(duration.Seconds())
+}
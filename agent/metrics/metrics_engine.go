@@ -30,6 +30,8 @@ type MetricsEngine struct {
 	cfg            *config.Config
 	Registry       *prometheus.Registry
 	managedMetrics map[APIType]MetricsClient
+	imagePulls     *imagePullMetrics
+	imageCleanups  *imageCleanupMetrics
 }
 
 const (
@@ -86,6 +88,8 @@ func NewMetricsEngine(cfg *config.Config, registry *prometheus.Registry) *Metric
 		cfg:            cfg,
 		Registry:       registry,
 		managedMetrics: make(map[APIType]MetricsClient),
+		imagePulls:     newImagePullMetrics(registry),
+		imageCleanups:  newImageCleanupMetrics(registry),
 	}
 	for managedAPI := range managedAPIs {
 		aClient := NewMetricsClient(managedAPI, metricsEngine.Registry)
@@ -14,7 +14,7 @@
 // Package sighandlers handle signals and behave appropriately.
 // SIGTERM:
 //
-//	Flush state to disk and exit
+//	Stop the tasks if configured to, flush state to disk and exit
 //
 // SIGUSR1:
 //
@@ -49,14 +49,22 @@ type TerminationHandler func(state dockerstate.TaskEngineState, dataClient data.
 
 // StartDefaultTerminationHandler defines a default termination handler suitable for running in a process
 func StartDefaultTerminationHandler(state dockerstate.TaskEngineState, dataClient data.Client, taskEngine engine.TaskEngine, cancel context.CancelFunc) {
-	// when we receive a termination signal, first save the state, then
-	// cancel the agent's context so other goroutines can exit cleanly.
+	// when we receive a termination signal, first stop the tasks if configured to and save the state,
+	// then cancel the agent's context so other goroutines can exit cleanly.
 	signalC := make(chan os.Signal, 2)
 	signal.Notify(signalC, os.Interrupt, syscall.SIGTERM)
 
 	sig := <-signalC
 	seelog.Infof("Agent received termination signal: %s", sig.String())
 
+	// Save the state before waiting for the tasks to stop, in case the agent is killed before the wait is over
+	if err := saveState(state, dataClient); err != nil {
+		seelog.Warnf("Unable to save state before stopping tasks for shutdown: %v", err)
+	}
+	if err := taskEngine.StopTasksForShutdown(); err != nil {
+		seelog.Warnf("Unable to stop all tasks before shutting down: %v", err)
+	}
+
 	err := FinalSave(state, dataClient, taskEngine)
 	if err != nil {
 		seelog.Criticalf("Error saving state before final shutdown: %v", err)
@@ -86,23 +94,28 @@ func FinalSave(state dockerstate.TaskEngineState, dataClient data.Client, taskEn
 
 	disableErr := <-engineDisabled
 
-	stateSaved := make(chan error)
+	seelog.Debug("Saving state before shutting down")
+	saveErr := saveState(state, dataClient)
+
+	if disableErr != nil || saveErr != nil {
+		return apierrors.NewMultiError(disableErr, saveErr)
+	}
+	return nil
+}
+
+// saveState flushes the state to disk, returning an error if it can't be saved within a short timeout
+func saveState(state dockerstate.TaskEngineState, dataClient data.Client) error {
+	stateSaved := make(chan error, 1)
 	saveTimer := time.AfterFunc(finalSaveTimeout, func() {
 		stateSaved <- errors.New("final save: timed out trying to save to disk")
 	})
 	go func() {
-		seelog.Debug("Saving state before shutting down")
 		saveStateAll(state, dataClient)
-		saveTimer.Stop()
-		stateSaved <- nil
+		if saveTimer.Stop() {
+			stateSaved <- nil
+		}
 	}()
-
-	saveErr := <-stateSaved
-
-	if disableErr != nil || saveErr != nil {
-		return apierrors.NewMultiError(disableErr, saveErr)
-	}
-	return nil
+	return <-stateSaved
 }
 
 func saveStateAll(state dockerstate.TaskEngineState, dataClient data.Client) {
@@ -100,6 +100,29 @@ func TestFinalSave(t *testing.T) {
 	assert.Len(t, imageStates, 1)
 }
 
+func TestSaveStateBeforeStoppingTasks(t *testing.T) {
+	dataClient, cleanup := newTestDataClient(t)
+	defer cleanup()
+
+	state := dockerstate.NewTaskEngineState()
+	taskEngine := engine.NewTaskEngine(&config.Config{}, nil, nil,
+		nil, nil, state, nil, nil, nil, nil)
+	state.AddTask(&apitask.Task{
+		Arn:     taskARN,
+		Family:  "test",
+		Version: "1",
+	})
+
+	require.NoError(t, saveState(state, dataClient))
+	tasks, err := dataClient.GetTasks()
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 1)
+
+	// Unlike the final save, saving the state leaves the engine free to stop its tasks
+	_, err = taskEngine.ListTasks()
+	assert.NoError(t, err)
+}
+
 func newTestDataClient(t *testing.T) (data.Client, func()) {
 	testDir, err := ioutil.TempDir("", "termination_handler_unit_test")
 	require.NoError(t, err)
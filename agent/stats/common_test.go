@@ -359,6 +359,13 @@ func (engine *MockTaskEngine) Version() (string, error) {
 	return "", nil
 }
 
+func (engine *MockTaskEngine) StopTasksForSpotInterruption() {
+}
+
+func (engine *MockTaskEngine) StopTasksForShutdown() error {
+	return nil
+}
+
 func (engine *MockTaskEngine) LoadState() error {
 	return nil
 }
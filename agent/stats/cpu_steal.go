@@ -0,0 +1,198 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stats
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/logger"
+	"github.com/aws/amazon-ecs-agent/agent/logger/field"
+
+	"github.com/docker/docker/api/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	// cpuStealSampleInterval is the interval at which the CPU times of the host are sampled
+	cpuStealSampleInterval = 10 * time.Second
+	// Indices of the CPU times in the cpu line of /proc/stat, which reports the user, nice, system,
+	// idle, iowait, irq, softirq and steal times in that order. Older kernels do not report steal.
+	procStatIdleField   = 3
+	procStatIOWaitField = 4
+	procStatStealField  = 7
+)
+
+// hostProcStatPath is the path of the kernel statistics of the host. The cpu line of /proc/stat
+// is not namespaced, so the agent container reads the CPU times of the host.
+var hostProcStatPath = "/proc/stat"
+
+// hostCPUTimes are the cumulative CPU times of the host, in clock ticks
+type hostCPUTimes struct {
+	busy  uint64
+	steal uint64
+	total uint64
+}
+
+// parseHostCPUTimes parses the aggregated cpu line of /proc/stat. It returns false if the kernel
+// does not report the steal time.
+func parseHostCPUTimes(reader io.Reader) (hostCPUTimes, bool, error) {
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] != "cpu" {
+			continue
+		}
+		values := fields[1:]
+		if len(values) <= procStatStealField {
+			return hostCPUTimes{}, false, nil
+		}
+		var times hostCPUTimes
+		// Guest times are already accounted in the user and nice times
+		for i := 0; i <= procStatStealField; i++ {
+			value, err := strconv.ParseUint(values[i], 10, 64)
+			if err != nil {
+				return hostCPUTimes{}, false, errors.Wrapf(err, "invalid cpu time %q", values[i])
+			}
+			times.total += value
+			switch i {
+			case procStatIdleField, procStatIOWaitField:
+			case procStatStealField:
+				times.steal = value
+			default:
+				times.busy += value
+			}
+		}
+		return times, true, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return hostCPUTimes{}, false, err
+	}
+	return hostCPUTimes{}, false, errors.New("cpu line not found")
+}
+
+// hostCPUSteal is the CPU steal of the host over the last sampling interval
+type hostCPUSteal struct {
+	// stealPerc is the percentage of the CPU time of the host stolen by the hypervisor
+	stealPerc float32
+	// stealPerBusy is the CPU time stolen per unit of CPU time spent running tasks
+	stealPerBusy float64
+}
+
+// cpuStealSampler keeps track of the CPU steal of the host from consecutive samples of its CPU times
+type cpuStealSampler struct {
+	lock     sync.RWMutex
+	previous *hostCPUTimes
+	steal    *hostCPUSteal
+}
+
+// record updates the CPU steal of the host with a new sample of its CPU times
+func (sampler *cpuStealSampler) record(times hostCPUTimes) {
+	sampler.lock.Lock()
+	defer sampler.lock.Unlock()
+	previous := sampler.previous
+	sampler.previous = &times
+	// Counters going backwards, e.g. after a hibernation, are not meaningful
+	if previous == nil || times.total <= previous.total || times.steal < previous.steal || times.busy < previous.busy {
+		sampler.steal = nil
+		return
+	}
+	totalDelta := times.total - previous.total
+	stealDelta := times.steal - previous.steal
+	busyDelta := times.busy - previous.busy
+	steal := &hostCPUSteal{
+		stealPerc: 100 * float32(stealDelta) / float32(totalDelta),
+	}
+	if busyDelta > 0 {
+		steal.stealPerBusy = float64(stealDelta) / float64(busyDelta)
+	}
+	sampler.steal = steal
+}
+
+// get returns the CPU steal of the host over the last sampling interval, if known
+func (sampler *cpuStealSampler) get() (hostCPUSteal, bool) {
+	sampler.lock.RLock()
+	defer sampler.lock.RUnlock()
+	if sampler.steal == nil {
+		return hostCPUSteal{}, false
+	}
+	return *sampler.steal, true
+}
+
+// sample reads the CPU times of the host and records them. It returns false if the host does not
+// report the CPU steal, in which case sampling is pointless.
+func (sampler *cpuStealSampler) sample() bool {
+	file, err := os.Open(hostProcStatPath)
+	if err != nil {
+		logger.Warn("Unable to read the CPU times of the host; CPU steal will not be reported", logger.Fields{
+			field.Error: err,
+		})
+		return false
+	}
+	defer file.Close()
+	times, ok, err := parseHostCPUTimes(file)
+	if err != nil {
+		logger.Warn("Unable to parse the CPU times of the host; CPU steal will not be reported", logger.Fields{
+			field.Error: err,
+		})
+		return false
+	}
+	if !ok {
+		logger.Info("The host does not report CPU steal time; CPU steal will not be reported")
+		return false
+	}
+	sampler.record(times)
+	return true
+}
+
+// run samples the CPU times of the host periodically until the context is cancelled
+func (sampler *cpuStealSampler) run(ctx context.Context) {
+	if !sampler.sample() {
+		return
+	}
+	ticker := time.NewTicker(cpuStealSampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !sampler.sample() {
+				return
+			}
+		}
+	}
+}
+
+// getCPUStealStats returns the CPU steal experienced by the container, estimated from the CPU steal of
+// the host and the CPU usage of the container between its last two docker stats
+func getCPUStealStats(dockerStats *types.StatsJSON, steal hostCPUSteal) *CPUStealStats {
+	stealStats := &CPUStealStats{
+		HostStealPerc: steal.stealPerc,
+	}
+	if dockerStats != nil {
+		usage := dockerStats.CPUStats.CPUUsage.TotalUsage
+		preUsage := dockerStats.PreCPUStats.CPUUsage.TotalUsage
+		if preUsage > 0 && usage > preUsage {
+			stealStats.EstimatedStealNs = uint64(float64(usage-preUsage) * steal.stealPerBusy)
+		}
+	}
+	return stealStats
+}
@@ -0,0 +1,122 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stats
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ecs-agent/agent/config"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHostCPUTimes(t *testing.T) {
+	testCases := []struct {
+		name     string
+		procStat string
+		expected hostCPUTimes
+		ok       bool
+		err      bool
+	}{
+		{
+			name:     "steal reported",
+			procStat: "cpu  100 10 50 800 20 5 5 10 0 0\ncpu0 50 5 25 400 10 2 3 5 0 0\n",
+			expected: hostCPUTimes{busy: 170, steal: 10, total: 1000},
+			ok:       true,
+		},
+		{
+			name:     "steal not reported",
+			procStat: "cpu  100 10 50 800 20 5 5\n",
+		},
+		{
+			name:     "invalid cpu time",
+			procStat: "cpu  100 10 50 800 20 5 5 x\n",
+			err:      true,
+		},
+		{
+			name:     "cpu line missing",
+			procStat: "intr 1234\n",
+			err:      true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			times, ok, err := parseHostCPUTimes(strings.NewReader(tc.procStat))
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.expected, times)
+		})
+	}
+}
+
+func TestCPUStealSamplerRecord(t *testing.T) {
+	sampler := &cpuStealSampler{}
+	sampler.record(hostCPUTimes{busy: 100, steal: 10, total: 1000})
+	_, ok := sampler.get()
+	assert.False(t, ok, "a single sample does not give the steal over an interval")
+
+	sampler.record(hostCPUTimes{busy: 300, steal: 60, total: 1500})
+	steal, ok := sampler.get()
+	require.True(t, ok)
+	assert.Equal(t, float32(10), steal.stealPerc)
+	assert.Equal(t, 0.25, steal.stealPerBusy)
+
+	// Counters going backwards invalidate the steal until the next interval
+	sampler.record(hostCPUTimes{busy: 10, steal: 1, total: 100})
+	_, ok = sampler.get()
+	assert.False(t, ok)
+}
+
+func TestGetCPUStealStats(t *testing.T) {
+	hostProcStat := filepath.Join(t.TempDir(), "stat")
+	defer func(path string) { hostProcStatPath = path }(hostProcStatPath)
+	hostProcStatPath = hostProcStat
+
+	engine := NewDockerStatsEngine(&config.Config{
+		CPUStealReportingEnabled: config.BooleanDefaultFalse{Value: config.ExplicitlyEnabled},
+	}, nil, nil)
+	dockerStats := &types.StatsJSON{}
+	dockerStats.PreCPUStats.CPUUsage.TotalUsage = 1000000
+	dockerStats.CPUStats.CPUUsage.TotalUsage = 5000000
+	assert.Nil(t, engine.GetCPUStealStats(dockerStats), "no steal is known before two samples")
+
+	require.NoError(t, os.WriteFile(hostProcStat, []byte("cpu  100 0 0 890 0 0 0 10 0 0\n"), 0644))
+	require.True(t, engine.cpuStealSampler.sample())
+	require.NoError(t, os.WriteFile(hostProcStat, []byte("cpu  500 0 0 1390 0 0 0 110 0 0\n"), 0644))
+	require.True(t, engine.cpuStealSampler.sample())
+
+	// 100 of the 1000 ticks were stolen, 1 tick per 4 ticks running tasks
+	assert.Equal(t, &CPUStealStats{HostStealPerc: 10, EstimatedStealNs: 1000000}, engine.GetCPUStealStats(dockerStats))
+
+	// Hosts which do not report steal are not sampled
+	require.NoError(t, os.WriteFile(hostProcStat, []byte("cpu  500 0 0 1390 0 0 0\n"), 0644))
+	assert.False(t, engine.cpuStealSampler.sample())
+}
+
+func TestGetCPUStealStatsDisabled(t *testing.T) {
+	engine := NewDockerStatsEngine(&config.Config{}, nil, nil)
+	assert.Nil(t, engine.GetCPUStealStats(&types.StatsJSON{}))
+}
@@ -74,6 +74,7 @@ type Engine interface {
 	GetPublishServiceConnectTickerInterval() int32
 	SetPublishServiceConnectTickerInterval(int32)
 	GetPublishMetricsTicker() *time.Ticker
+	GetCPUStealStats(dockerStats *types.StatsJSON) *CPUStealStats
 }
 
 // DockerStatsEngine is used to monitor docker container events and to report
@@ -99,6 +100,8 @@ type DockerStatsEngine struct {
 	taskToServiceConnectStats           map[string]*ServiceConnectStats
 	publishServiceConnectTickerInterval int32
 	publishMetricsTicker                *time.Ticker
+	// cpuStealSampler keeps track of the CPU steal of the host when CPU steal reporting is enabled
+	cpuStealSampler *cpuStealSampler
 }
 
 // ResolveTask resolves the api task object, given container id.
@@ -142,6 +145,10 @@ func (resolver *DockerContainerMetadataResolver) ResolveContainer(dockerID strin
 // NewDockerStatsEngine creates a new instance of the DockerStatsEngine object.
 // MustInit() must be called to initialize the fields of the new event listener.
 func NewDockerStatsEngine(cfg *config.Config, client dockerapi.DockerClient, containerChangeEventStream *eventstream.EventStream) *DockerStatsEngine {
+	var stealSampler *cpuStealSampler
+	if cfg.CPUStealReportingEnabled.Enabled() {
+		stealSampler = &cpuStealSampler{}
+	}
 	return &DockerStatsEngine{
 		client:                              client,
 		resolver:                            nil,
@@ -153,6 +160,7 @@ func NewDockerStatsEngine(cfg *config.Config, client dockerapi.DockerClient, con
 		taskToServiceConnectStats:           make(map[string]*ServiceConnectStats),
 		containerChangeEventStream:          containerChangeEventStream,
 		publishServiceConnectTickerInterval: 0,
+		cpuStealSampler:                     stealSampler,
 	}
 }
 
@@ -246,6 +254,9 @@ func (engine *DockerStatsEngine) MustInit(ctx context.Context, taskEngine ecseng
 		})
 	}
 
+	if engine.cpuStealSampler != nil {
+		go engine.cpuStealSampler.run(derivedCtx)
+	}
 	go engine.waitToStop()
 	return nil
 }
@@ -899,6 +910,19 @@ func (engine *DockerStatsEngine) ContainerDockerStats(taskARN string, containerI
 	return containerStats, containerNetworkRateStats, nil
 }
 
+// GetCPUStealStats returns the CPU steal experienced by the container with the given docker stats. Nil is
+// returned if CPU steal reporting is disabled or the host does not report CPU steal.
+func (engine *DockerStatsEngine) GetCPUStealStats(dockerStats *types.StatsJSON) *CPUStealStats {
+	if engine.cpuStealSampler == nil {
+		return nil
+	}
+	steal, ok := engine.cpuStealSampler.get()
+	if !ok {
+		return nil
+	}
+	return getCPUStealStats(dockerStats, steal)
+}
+
 // getTaskStatsToCollect returns a map of taskArns for which task metrics needs to collected
 func (engine *DockerStatsEngine) getTaskStatsToCollect() map[string]bool {
 	taskStatsToCollect := make(map[string]bool)
@@ -67,6 +67,20 @@ func (mr *MockEngineMockRecorder) ContainerDockerStats(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ContainerDockerStats", reflect.TypeOf((*MockEngine)(nil).ContainerDockerStats), arg0, arg1)
 }
 
+// GetCPUStealStats mocks base method
+func (m *MockEngine) GetCPUStealStats(arg0 *types.StatsJSON) *stats.CPUStealStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCPUStealStats", arg0)
+	ret0, _ := ret[0].(*stats.CPUStealStats)
+	return ret0
+}
+
+// GetCPUStealStats indicates an expected call of GetCPUStealStats
+func (mr *MockEngineMockRecorder) GetCPUStealStats(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCPUStealStats", reflect.TypeOf((*MockEngine)(nil).GetCPUStealStats), arg0)
+}
+
 // GetInstanceMetrics mocks base method
 func (m *MockEngine) GetInstanceMetrics(arg0 bool) (*ecstcs.MetricsMetadata, []*ecstcs.TaskMetric, error) {
 	m.ctrl.T.Helper()
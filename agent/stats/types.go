@@ -98,3 +98,20 @@ type NetworkStatsPerSec struct {
 	RxBytesPerSecond float32 `json:"rx_bytes_per_sec"`
 	TxBytesPerSecond float32 `json:"tx_bytes_per_sec"`
 }
+
+// ProcessStats contains the process count of a container as read from the pids cgroup controller
+type ProcessStats struct {
+	Current uint64 `json:"current"`
+	// Limit and UtilizationPerc are only set when the container has a pids limit
+	Limit           uint64  `json:"limit,omitempty"`
+	UtilizationPerc float32 `json:"utilization_perc,omitempty"`
+}
+
+// CPUStealStats contains the CPU time stolen by the hypervisor on hosts which report it
+type CPUStealStats struct {
+	// HostStealPerc is the percentage of the CPU time of the host stolen over the last sampling interval
+	HostStealPerc float32 `json:"host_steal_perc"`
+	// EstimatedStealNs is the CPU time stolen from the container between its last two stats, estimated
+	// in proportion to its CPU usage
+	EstimatedStealNs uint64 `json:"estimated_steal_ns"`
+}
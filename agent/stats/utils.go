@@ -27,6 +27,24 @@ func nan32() float32 {
 	return (float32)(math.NaN())
 }
 
+// GetProcessStats returns the process count of the container from its docker stats, along with the
+// utilization of its pids limit if one is set. Nil is returned when the pids cgroup controller is not
+// available on the host, in which case docker does not report any process.
+func GetProcessStats(dockerStats *types.StatsJSON) *ProcessStats {
+	if dockerStats == nil || dockerStats.PidsStats.Current == 0 {
+		return nil
+	}
+	processStats := &ProcessStats{
+		Current: dockerStats.PidsStats.Current,
+	}
+	// An unlimited pids controller is reported either without a limit or with the maximum value
+	if limit := dockerStats.PidsStats.Limit; limit != 0 && limit != math.MaxUint64 {
+		processStats.Limit = limit
+		processStats.UtilizationPerc = 100 * float32(processStats.Current) / float32(limit)
+	}
+	return processStats
+}
+
 func getNetworkStats(dockerStats *types.StatsJSON) *NetworkStats {
 	if dockerStats.Networks == nil {
 		return nil
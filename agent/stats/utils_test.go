@@ -83,3 +83,40 @@ func validateNetworkMetrics(t *testing.T, netStats *NetworkStats) {
 	assert.True(t, math.IsNaN(float64(netStats.RxBytesPerSecond)))
 	assert.True(t, math.IsNaN(float64(netStats.TxBytesPerSecond)))
 }
+
+func TestGetProcessStats(t *testing.T) {
+	testCases := []struct {
+		name      string
+		pidsStats types.PidsStats
+		expected  *ProcessStats
+	}{
+		{
+			name:      "pids limit set",
+			pidsStats: types.PidsStats{Current: 25, Limit: 100},
+			expected:  &ProcessStats{Current: 25, Limit: 100, UtilizationPerc: 25},
+		},
+		{
+			name:      "no pids limit",
+			pidsStats: types.PidsStats{Current: 25},
+			expected:  &ProcessStats{Current: 25},
+		},
+		{
+			name:      "unlimited pids limit",
+			pidsStats: types.PidsStats{Current: 25, Limit: math.MaxUint64},
+			expected:  &ProcessStats{Current: 25},
+		},
+		{
+			name:      "pids controller not available",
+			pidsStats: types.PidsStats{},
+			expected:  nil,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dockerStats := &types.StatsJSON{}
+			dockerStats.PidsStats = tc.pidsStats
+			assert.Equal(t, tc.expected, GetProcessStats(dockerStats))
+		})
+	}
+	assert.Nil(t, GetProcessStats(nil))
+}
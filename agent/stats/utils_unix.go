@@ -40,6 +40,26 @@ func dockerStatsToContainerStats(dockerStats *types.StatsJSON) (*ContainerStats,
 	}, nil
 }
 
+// CPUUsagePercent returns the CPU usage of the container between the two reads of the docker stats, as a
+// percentage of a single core, computed the way the docker cli does
+func CPUUsagePercent(dockerStats *types.StatsJSON) float64 {
+	cpuDelta := float64(dockerStats.CPUStats.CPUUsage.TotalUsage) - float64(dockerStats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(dockerStats.CPUStats.SystemUsage) - float64(dockerStats.PreCPUStats.SystemUsage)
+	onlineCPUs := float64(dockerStats.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(dockerStats.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta <= 0 || systemDelta <= 0 {
+		return 0
+	}
+	return cpuDelta / systemDelta * onlineCPUs * 100
+}
+
+// MemoryUsageBytes returns the memory usage of the container, excluding its page cache
+func MemoryUsageBytes(dockerStats *types.StatsJSON) uint64 {
+	return getMemUsage(dockerStats.MemoryStats)
+}
+
 func getMemUsage(mem types.MemoryStats) uint64 {
 	if config.CgroupV2 {
 		// for cgroupv2 systems, mem usage calculation uses the same method that the docker cli uses
@@ -59,3 +59,25 @@ func TestDockerStatsToContainerStatsEmptyCpuUsageGeneratesError(t *testing.T) {
 	assert.Error(t, err, "expected error converting container stats with numCores=0")
 	numCores = prevNumCores
 }
+
+func TestCPUUsagePercent(t *testing.T) {
+	dockerStat := &types.StatsJSON{}
+	dockerStat.PreCPUStats.CPUUsage.TotalUsage = 1000
+	dockerStat.PreCPUStats.SystemUsage = 10000
+	dockerStat.CPUStats.CPUUsage.TotalUsage = 1500
+	dockerStat.CPUStats.SystemUsage = 12000
+	dockerStat.CPUStats.OnlineCPUs = 2
+	assert.InDelta(t, 50.0, CPUUsagePercent(dockerStat), 0.0001)
+
+	// Older docker versions don't report the online cpus; the per cpu usage is used instead.
+	dockerStat.CPUStats.OnlineCPUs = 0
+	dockerStat.CPUStats.CPUUsage.PercpuUsage = []uint64{700, 800, 0, 0}
+	assert.InDelta(t, 100.0, CPUUsagePercent(dockerStat), 0.0001)
+}
+
+func TestCPUUsagePercentWithoutPreviousRead(t *testing.T) {
+	dockerStat := &types.StatsJSON{}
+	dockerStat.CPUStats.CPUUsage.TotalUsage = 1500
+	dockerStat.CPUStats.OnlineCPUs = 2
+	assert.Equal(t, 0.0, CPUUsagePercent(dockerStat))
+}
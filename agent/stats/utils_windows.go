@@ -39,6 +39,23 @@ func dockerStatsToContainerStats(dockerStats *types.StatsJSON) (*ContainerStats,
 	}, nil
 }
 
+// CPUUsagePercent returns the CPU usage of the container between the two reads of the docker stats, as a
+// percentage of a single core like on linux. Unlike the docker cli, it is not divided by the number of processors.
+func CPUUsagePercent(dockerStats *types.StatsJSON) float64 {
+	// CPU usage is reported in 100ns intervals
+	possibleIntervals := float64(dockerStats.Read.Sub(dockerStats.PreRead).Nanoseconds()) / 100
+	usedIntervals := float64(dockerStats.CPUStats.CPUUsage.TotalUsage) - float64(dockerStats.PreCPUStats.CPUUsage.TotalUsage)
+	if possibleIntervals <= 0 || usedIntervals <= 0 {
+		return 0
+	}
+	return usedIntervals / possibleIntervals * 100
+}
+
+// MemoryUsageBytes returns the private working set of the container
+func MemoryUsageBytes(dockerStats *types.StatsJSON) uint64 {
+	return dockerStats.MemoryStats.PrivateWorkingSet
+}
+
 func validateDockerStats(dockerStats *types.StatsJSON) error {
 	if numCores == uint64(0) {
 		return fmt.Errorf("invalid container statistics reported, no cpu core usage reported")
@@ -550,6 +550,16 @@ var open = func(name string) (oswrapper.File, error) {
 }
 
 func (envfile *EnvironmentFileResource) readEnvVarsFromFile(envfilePath string) (map[string]string, error) {
+	return readEnvVarsFromFile(envfile.bufio, envfilePath)
+}
+
+// ReadEnvVarsFromFile reads the environment variables of the environment file at the given path. Lines are
+// formatted as VARIABLE=VALUE, and lines starting with # are ignored.
+func ReadEnvVarsFromFile(envfilePath string) (map[string]string, error) {
+	return readEnvVarsFromFile(bufiowrapper.NewBufio(), envfilePath)
+}
+
+func readEnvVarsFromFile(bufio bufiowrapper.Bufio, envfilePath string) (map[string]string, error) {
 	file, err := open(envfilePath)
 	if err != nil {
 		seelog.Errorf("Unable to open environment file at %s to read the variables", envfilePath)
@@ -557,7 +567,7 @@ func (envfile *EnvironmentFileResource) readEnvVarsFromFile(envfilePath string)
 	}
 	defer file.Close()
 
-	scanner := envfile.bufio.NewScanner(file)
+	scanner := bufio.NewScanner(file)
 	envVars := make(map[string]string)
 	lineNum := 0
 	for scanner.Scan() {
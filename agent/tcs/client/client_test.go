@@ -115,6 +115,10 @@ func (*mockStatsEngine) ContainerDockerStats(taskARN string, id string) (*types.
 	return nil, nil, fmt.Errorf("not implemented")
 }
 
+func (*mockStatsEngine) GetCPUStealStats(dockerStats *types.StatsJSON) *stats.CPUStealStats {
+	return nil
+}
+
 func (*mockStatsEngine) GetTaskHealthMetrics() (*ecstcs.HealthMetadata, []*ecstcs.TaskHealth, error) {
 	return nil, nil, nil
 }
@@ -141,6 +145,10 @@ func (*emptyStatsEngine) ContainerDockerStats(taskARN string, id string) (*types
 	return nil, nil, fmt.Errorf("not implemented")
 }
 
+func (*emptyStatsEngine) GetCPUStealStats(dockerStats *types.StatsJSON) *stats.CPUStealStats {
+	return nil
+}
+
 func (*emptyStatsEngine) GetTaskHealthMetrics() (*ecstcs.HealthMetadata, []*ecstcs.TaskHealth, error) {
 	return nil, nil, nil
 }
@@ -173,6 +181,10 @@ func (*idleStatsEngine) ContainerDockerStats(taskARN string, id string) (*types.
 	return nil, nil, fmt.Errorf("not implemented")
 }
 
+func (*idleStatsEngine) GetCPUStealStats(dockerStats *types.StatsJSON) *stats.CPUStealStats {
+	return nil
+}
+
 func (*idleStatsEngine) GetTaskHealthMetrics() (*ecstcs.HealthMetadata, []*ecstcs.TaskHealth, error) {
 	return nil, nil, nil
 }
@@ -213,6 +225,10 @@ func (*nonIdleStatsEngine) ContainerDockerStats(taskARN string, id string) (*typ
 	return nil, nil, fmt.Errorf("not implemented")
 }
 
+func (*nonIdleStatsEngine) GetCPUStealStats(dockerStats *types.StatsJSON) *stats.CPUStealStats {
+	return nil
+}
+
 func (*nonIdleStatsEngine) GetTaskHealthMetrics() (*ecstcs.HealthMetadata, []*ecstcs.TaskHealth, error) {
 	return nil, nil, nil
 }
@@ -314,6 +330,10 @@ func (*serviceConnectStatsEngine) ContainerDockerStats(taskARN string, id string
 	return nil, nil, fmt.Errorf("not implemented")
 }
 
+func (*serviceConnectStatsEngine) GetCPUStealStats(dockerStats *types.StatsJSON) *stats.CPUStealStats {
+	return nil
+}
+
 func (*serviceConnectStatsEngine) GetTaskHealthMetrics() (*ecstcs.HealthMetadata, []*ecstcs.TaskHealth, error) {
 	return nil, nil, nil
 }
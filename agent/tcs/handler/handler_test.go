@@ -76,6 +76,10 @@ func (*mockStatsEngine) ContainerDockerStats(taskARN string, id string) (*types.
 	return nil, nil, fmt.Errorf("not implemented")
 }
 
+func (*mockStatsEngine) GetCPUStealStats(dockerStats *types.StatsJSON) *stats.CPUStealStats {
+	return nil
+}
+
 func (*mockStatsEngine) GetTaskHealthMetrics() (*ecstcs.HealthMetadata, []*ecstcs.TaskHealth, error) {
 	return nil, nil, nil
 }
@@ -69,6 +69,11 @@ const (
 	// maxRetries specifies the maximum number of retries for ping to return
 	// a successful response from the docker socket
 	maxRetries = 5
+	// stopAgentTimeoutSeconds specifies how long the Agent is given to exit
+	// before it is killed. It covers the Agent waiting up to a minute for its
+	// tasks to stop on shutdown and saving its state afterwards, and stays
+	// below the 90 second default stop timeout of the systemd unit
+	stopAgentTimeoutSeconds = 75
 	// CapNetAdmin to start agent with NET_ADMIN capability
 	// For more information on capabilities, please read this manpage:
 	// http://man7.org/linux/man-pages/man7/capabilities.7.html
@@ -571,8 +576,7 @@ func (c *client) StopAgent() error {
 		log.Info("No running Agent to stop")
 		return nil
 	}
-	stopContainerTimeoutSeconds := uint(10)
-	err = c.docker.StopContainer(id, stopContainerTimeoutSeconds)
+	err = c.docker.StopContainer(id, stopAgentTimeoutSeconds)
 	if _, ok := err.(*godocker.ContainerNotRunning); ok {
 		log.Info("Agent is already stopped")
 		return nil
@@ -677,7 +677,7 @@ func TestStopAgent(t *testing.T) {
 			}
 
 			if !tc.listEmpty && !tc.listFailed {
-				mockDocker.EXPECT().StopContainer("id", uint(10)).Return(stopErr)
+				mockDocker.EXPECT().StopContainer("id", uint(stopAgentTimeoutSeconds)).Return(stopErr)
 			}
 
 			if tc.listFailed || tc.stopFailedOther {